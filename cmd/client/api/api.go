@@ -29,10 +29,12 @@ const (
 	API_DATA_REPOSITORY_LIST   = "/api/datarepository"
 	API_DATA_REPOSITORY_DELETE = "/api/datarepository"
 
-	API_VIRTUAL_DEVICE_LIST    = "/api/virtualdevice"
-	API_VIRTUAL_DEVICE_ENABLE  = "/api/virtualdevice/enable"
-	API_VIRTUAL_DEVICE_DISABLE = "/api/virtualdevice/disable"
-	API_VIRTUAL_DEVICE_DELETE  = "/api/virtualdevice"
+	API_VIRTUAL_DEVICE_LIST     = "/api/virtualdevice"
+	API_VIRTUAL_DEVICE_ENABLE   = "/api/virtualdevice/enable"
+	API_VIRTUAL_DEVICE_DISABLE  = "/api/virtualdevice/disable"
+	API_VIRTUAL_DEVICE_DELETE   = "/api/virtualdevice"
+	API_VIRTUAL_DEVICE_GENERATE = "/api/virtualdevice/generate"
+	API_VIRTUAL_DEVICE_ACCEPT   = "/api/virtualdevice/generate/accept"
 
 	API_VIRTUAL_ASSISTANT_LIST    = "/api/virtualassistant"
 	API_VIRTUAL_ASSISTANT_ENABLE  = "/api/virtualassistant/enable"
@@ -55,9 +57,17 @@ const (
 	API_HANDLER_DISABLE = "/api/handler/disable"
 	API_HANDLER_DELETE  = "/api/handler"
 
+	API_DASHBOARD_LIST = "/api/dashboard"
+
 	API_FORWARD_PAYLOAD_LIST   = "/api/forwardpayload"
 	API_FORWARD_PAYLOAD_DELETE = "/api/forwardpayload"
 
 	API_BACKUP_LIST   = "/api/backup"
 	API_BACKUP_DELETE = "/api/backup"
+
+	API_APPLY = "/api/apply"
+
+	API_WEBSOCKET = "/api/ws"
+
+	API_METRIC = "/api/metric"
 )
@@ -0,0 +1,22 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/mycontroller-org/server/v2/pkg/json"
+	manifestTY "github.com/mycontroller-org/server/v2/pkg/types/manifest"
+)
+
+func (c *Client) Apply(request *manifestTY.ApplyRequest) (*manifestTY.Plan, error) {
+	res, err := c.executeJson(API_APPLY, http.MethodPost, nil, nil, request, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &manifestTY.Plan{}
+	err = json.Unmarshal(res.Body, plan)
+	if err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
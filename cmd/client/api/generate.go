@@ -0,0 +1,27 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/mycontroller-org/server/v2/pkg/json"
+	vdTY "github.com/mycontroller-org/server/v2/pkg/types/virtual_device"
+)
+
+func (c *Client) GenerateVirtualDevices(request *vdTY.GeneratorRequest) ([]vdTY.Proposal, error) {
+	res, err := c.executeJson(API_VIRTUAL_DEVICE_GENERATE, http.MethodPost, nil, nil, request, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+
+	proposals := make([]vdTY.Proposal, 0)
+	err = json.Unmarshal(res.Body, &proposals)
+	if err != nil {
+		return nil, err
+	}
+	return proposals, nil
+}
+
+func (c *Client) AcceptVirtualDevices(devices []vdTY.VirtualDevice) error {
+	_, err := c.executeJson(API_VIRTUAL_DEVICE_ACCEPT, http.MethodPost, nil, nil, devices, http.StatusOK)
+	return err
+}
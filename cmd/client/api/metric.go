@@ -0,0 +1,23 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/mycontroller-org/server/v2/pkg/json"
+	mtsTY "github.com/mycontroller-org/server/v2/plugin/database/metric/types"
+)
+
+// QueryMetric returns the metric data, mapped with the query name
+func (c *Client) QueryMetric(queryConfig *mtsTY.QueryConfig) (map[string][]mtsTY.ResponseData, error) {
+	res, err := c.executeJson(API_METRIC, http.MethodPost, nil, nil, queryConfig, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string][]mtsTY.ResponseData{}
+	err = json.Unmarshal(res.Body, &result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,136 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/mycontroller-org/server/v2/pkg/json"
+	types "github.com/mycontroller-org/server/v2/pkg/types"
+	manifestTY "github.com/mycontroller-org/server/v2/pkg/types/manifest"
+	storageTY "github.com/mycontroller-org/server/v2/plugin/database/storage/types"
+)
+
+// resource api of the kinds, supports get by id and save
+var resourceAPIs = map[string]string{
+	types.EntityGateway:          API_GATEWAY_LIST,
+	types.EntityNode:             API_NODE_LIST,
+	types.EntitySource:           API_SOURCE_LIST,
+	types.EntityField:            API_FIELD_LIST,
+	types.EntityFirmware:         API_FIRMWARE_LIST,
+	types.EntityDataRepository:   API_DATA_REPOSITORY_LIST,
+	types.EntityVirtualDevice:    API_VIRTUAL_DEVICE_LIST,
+	types.EntityVirtualAssistant: API_VIRTUAL_ASSISTANT_LIST,
+	types.EntityTask:             API_TASK_LIST,
+	types.EntitySchedule:         API_SCHEDULE_LIST,
+	types.EntityHandler:          API_HANDLER_LIST,
+	types.EntityForwardPayload:   API_FORWARD_PAYLOAD_LIST,
+	types.EntityDashboard:        API_DASHBOARD_LIST,
+}
+
+// short names of the kinds, same as the aliases used on the get command
+var resourceAliases = map[string]string{
+	"gw": types.EntityGateway,
+	"va": types.EntityVirtualAssistant,
+	"vd": types.EntityVirtualDevice,
+	"fp": types.EntityForwardPayload,
+	"dr": types.EntityDataRepository,
+}
+
+// ResourceKinds returns the supported kinds
+func ResourceKinds() []string {
+	kinds := make([]string, 0, len(resourceAPIs))
+	for kind := range resourceAPIs {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// NormalizeResourceKind returns the entity name of the kind
+// accepts alias, plural and the manifest kind formats
+func NormalizeResourceKind(kind string) (string, error) {
+	normalized := strings.ToLower(strings.TrimSpace(kind))
+	if actualKind, found := resourceAliases[normalized]; found {
+		return actualKind, nil
+	}
+	for _, candidate := range []string{normalized, strings.TrimSuffix(normalized, "s")} {
+		candidate = manifestTY.NormalizeKind(candidate)
+		if _, found := resourceAPIs[candidate]; found {
+			return candidate, nil
+		}
+		// kinds without separator. ex: "virtualdevice"
+		for actualKind := range resourceAPIs {
+			if candidate == strings.ReplaceAll(actualKind, "_", "") {
+				return actualKind, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("unsupported kind '%s'. supported kinds: %s", kind, strings.Join(ResourceKinds(), ", "))
+}
+
+func getResourceAPI(kind string) (string, error) {
+	actualKind, err := NormalizeResourceKind(kind)
+	if err != nil {
+		return "", err
+	}
+	return resourceAPIs[actualKind], nil
+}
+
+// GetResource returns the resource as a map
+func (c *Client) GetResource(kind, id string) (map[string]interface{}, error) {
+	api, err := getResourceAPI(kind)
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.executeJson(fmt.Sprintf("%s/%s", api, id), http.MethodGet, nil, nil, nil, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+
+	resource := map[string]interface{}{}
+	err = json.Unmarshal(res.Body, &resource)
+	if err != nil {
+		return nil, err
+	}
+	return resource, nil
+}
+
+// ListResource returns the resources of the kind
+func (c *Client) ListResource(kind string, filters []storageTY.Filter, limit int64) (*storageTY.Result, error) {
+	api, err := getResourceAPI(kind)
+	if err != nil {
+		return nil, err
+	}
+	filtersBytes, err := json.Marshal(filters)
+	if err != nil {
+		return nil, err
+	}
+	queryParams := map[string]interface{}{
+		"limit":  limit,
+		"offset": 0,
+		"filter": string(filtersBytes),
+	}
+	return c.listResource(api, queryParams)
+}
+
+// IsResourceExists returns true, if the resource is available on the server
+func (c *Client) IsResourceExists(kind, id string) (bool, error) {
+	filters := []storageTY.Filter{{Key: types.KeyID, Operator: storageTY.OperatorEqual, Value: id}}
+	result, err := c.ListResource(kind, filters, 1)
+	if err != nil {
+		return false, err
+	}
+	return result.Count > 0, nil
+}
+
+// SaveResource creates or updates the resource
+func (c *Client) SaveResource(kind string, resource interface{}) error {
+	api, err := getResourceAPI(kind)
+	if err != nil {
+		return err
+	}
+	_, err = c.executeJson(api, http.MethodPost, nil, nil, resource, http.StatusOK)
+	return err
+}
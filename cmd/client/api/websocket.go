@@ -0,0 +1,95 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	ws "github.com/gorilla/websocket"
+	"github.com/mycontroller-org/server/v2/pkg/json"
+	eventTY "github.com/mycontroller-org/server/v2/pkg/types/event"
+	handlerTY "github.com/mycontroller-org/server/v2/pkg/types/web_handler"
+	wsTY "github.com/mycontroller-org/server/v2/pkg/types/websocket"
+)
+
+const websocketHandshakeTimeout = 10 * time.Second
+
+// EventFunc called on each event received from the server
+type EventFunc func(event *eventTY.Event)
+
+// StreamEvents connects to the websocket and calls the eventFunc on each event
+// blocks till the context cancelled or the connection closed
+func (c *Client) StreamEvents(ctx context.Context, eventFunc EventFunc) error {
+	wsURL, err := c.getWebsocketURL()
+	if err != nil {
+		return err
+	}
+
+	dialer := &ws.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: websocketHandshakeTimeout,
+	}
+	if c.Insecure {
+		dialer.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+
+	headers := http.Header{}
+	headers.Set(handlerTY.HeaderAuthorization, c.Token)
+	conn, response, err := dialer.DialContext(ctx, wsURL, headers)
+	if err != nil {
+		if response != nil {
+			return fmt.Errorf("error on websocket connection. status:%s, error:%w", response.Status, err)
+		}
+		return err
+	}
+	defer func() { _ = conn.Close() }()
+
+	// closes the connection on context cancel, unblocks the read
+	go func() {
+		<-ctx.Done()
+		_ = conn.Close()
+	}()
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		response := &wsTY.Response{}
+		err = json.Unmarshal(data, response)
+		if err != nil || response.Type != wsTY.ResponseTypeEvent {
+			continue
+		}
+
+		event := &eventTY.Event{}
+		eventBytes, err := json.Marshal(response.Data)
+		if err != nil {
+			continue
+		}
+		err = json.Unmarshal(eventBytes, event)
+		if err != nil {
+			continue
+		}
+		eventFunc(event)
+	}
+}
+
+func (c *Client) getWebsocketURL() (string, error) {
+	address := strings.TrimSuffix(c.ServerAddress, "/")
+	switch {
+	case strings.HasPrefix(address, "https://"):
+		address = "wss://" + strings.TrimPrefix(address, "https://")
+	case strings.HasPrefix(address, "http://"):
+		address = "ws://" + strings.TrimPrefix(address, "http://")
+	default:
+		return "", fmt.Errorf("invalid server address '%s'", c.ServerAddress)
+	}
+	return address + API_WEBSOCKET, nil
+}
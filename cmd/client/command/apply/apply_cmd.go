@@ -0,0 +1,49 @@
+package apply
+
+import (
+	"fmt"
+	"strings"
+
+	rootCmd "github.com/mycontroller-org/server/v2/cmd/client/command/root"
+	manifestTY "github.com/mycontroller-org/server/v2/pkg/types/manifest"
+	"github.com/mycontroller-org/server/v2/pkg/utils/printer"
+)
+
+func printPlan(plan *manifestTY.Plan) {
+	headers := []printer.Header{
+		{Title: "kind"},
+		{Title: "id"},
+		{Title: "action"},
+		{Title: "changes", ValueFunc: func(item interface{}) string {
+			if planItem, ok := item.(*manifestTY.PlanItem); ok {
+				return strings.Join(planItem.Changes, ",")
+			}
+			return ""
+		}},
+		{Title: "error"},
+	}
+
+	rows := make([]interface{}, 0)
+	for index := range plan.Items {
+		rows = append(rows, &plan.Items[index])
+	}
+	printer.Print(rootCmd.IOStreams.Out, headers, rows, rootCmd.HideHeader, rootCmd.OutputFormat, rootCmd.Pretty)
+
+	if !plan.HasChanges() {
+		_, _ = fmt.Fprintln(rootCmd.IOStreams.Out, "No changes")
+		return
+	}
+	if plan.Applied {
+		failed := 0
+		for _, item := range plan.Items {
+			if item.Error != "" {
+				failed++
+			}
+		}
+		if failed > 0 {
+			_, _ = fmt.Fprintf(rootCmd.IOStreams.ErrOut, "error: failed to apply %d item(s)\n", failed)
+			return
+		}
+		_, _ = fmt.Fprintln(rootCmd.IOStreams.Out, "Applied successfully")
+	}
+}
@@ -0,0 +1,107 @@
+package apply
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	rootCmd "github.com/mycontroller-org/server/v2/cmd/client/command/root"
+	manifestTY "github.com/mycontroller-org/server/v2/pkg/types/manifest"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	filename    string
+	secretsFile string
+	prune       bool
+	dryRun      bool
+	autoConfirm bool
+)
+
+func init() {
+	rootCmd.Cmd.AddCommand(applyCmd)
+	applyCmd.Flags().StringVarP(&filename, "filename", "f", "", "manifest file or directory. directories are loaded recursively")
+	applyCmd.Flags().StringVar(&secretsFile, "secrets-file", "", "yaml file with secrets, used to resolve the \"${secret:name}\" references")
+	applyCmd.Flags().BoolVar(&prune, "prune", false, "deletes the entities created by the earlier apply and not available on the manifests")
+	applyCmd.Flags().BoolVar(&dryRun, "dry-run", false, "prints the plan, does not apply the changes")
+	applyCmd.Flags().BoolVarP(&autoConfirm, "yes", "y", false, "applies the changes without confirmation")
+	_ = applyCmd.MarkFlagRequired("filename")
+}
+
+var applyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Applies the manifests from a file or from a directory",
+	Long: `Applies the manifests from a file or from a directory
+
+Compares the manifests with the entities on the server, prints the plan and applies the changes.
+Secrets can be referred as "${secret:name}", resolved from the secrets file or
+from the environment variable "MYC_SECRET_<NAME>".
+`,
+	Example: `  # prints the plan
+  myc apply -f ./manifests --dry-run
+
+  # applies and deletes the entities removed from the manifests
+  myc apply -f ./manifests --prune --secrets-file ./secrets.yaml`,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		rootCmd.UpdateStreams(cmd)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		err := executeApply()
+		if err != nil {
+			_, _ = fmt.Fprintf(rootCmd.IOStreams.ErrOut, "error:%s\n", err)
+		}
+	},
+}
+
+func executeApply() error {
+	manifests, err := manifestTY.LoadPath(filename)
+	if err != nil {
+		return err
+	}
+	if len(manifests) == 0 {
+		return fmt.Errorf("no manifest found in '%s'", filename)
+	}
+
+	err = rootCmd.ResolveSecrets(manifests, secretsFile)
+	if err != nil {
+		return err
+	}
+
+	client := rootCmd.GetClient()
+	request := &manifestTY.ApplyRequest{Manifests: manifests, Prune: prune, DryRun: true}
+
+	plan, err := client.Apply(request)
+	if err != nil {
+		return err
+	}
+	printPlan(plan)
+
+	if dryRun || !plan.HasChanges() {
+		return nil
+	}
+
+	if !autoConfirm && !confirm() {
+		_, _ = fmt.Fprintln(rootCmd.IOStreams.Out, "Apply cancelled")
+		return nil
+	}
+
+	request.DryRun = false
+	plan, err = client.Apply(request)
+	if err != nil {
+		return err
+	}
+	printPlan(plan)
+	return nil
+}
+
+func confirm() bool {
+	_, _ = fmt.Fprint(rootCmd.IOStreams.Out, "Do you want to apply the changes? [y/N]: ")
+	reader := bufio.NewReader(rootCmd.IOStreams.In)
+	answer, err := reader.ReadString('\n')
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -0,0 +1,36 @@
+package create
+
+import (
+	rootCmd "github.com/mycontroller-org/server/v2/cmd/client/command/root"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	filename    string
+	secretsFile string
+)
+
+func init() {
+	rootCmd.Cmd.AddCommand(createCmd)
+	createCmd.Flags().StringVarP(&filename, "filename", "f", "", "manifest file or directory. directories are loaded recursively")
+	createCmd.Flags().StringVar(&secretsFile, "secrets-file", "", "yaml file with secrets, used to resolve the \"${secret:name}\" references")
+	_ = createCmd.MarkFlagRequired("filename")
+}
+
+var createCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Creates the resources from a file or from a directory",
+	Long: `Creates the resources from a file or from a directory
+
+Fails on a resource, if it is already available on the server. Use "apply" to update the existing resources.
+`,
+	Example: `  # creates a gateway
+  myc create -f gateway.yaml`,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		rootCmd.UpdateStreams(cmd)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		executeCreate()
+	},
+}
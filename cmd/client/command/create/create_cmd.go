@@ -0,0 +1,53 @@
+package create
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mycontroller-org/server/v2/cmd/client/api"
+	rootCmd "github.com/mycontroller-org/server/v2/cmd/client/command/root"
+	manifestTY "github.com/mycontroller-org/server/v2/pkg/types/manifest"
+)
+
+func executeCreate() {
+	manifests, err := manifestTY.LoadPath(filename)
+	if err != nil {
+		_, _ = fmt.Fprintf(rootCmd.IOStreams.ErrOut, "error:%s\n", err)
+		return
+	}
+	if len(manifests) == 0 {
+		_, _ = fmt.Fprintf(rootCmd.IOStreams.ErrOut, "error:no manifest found in '%s'\n", filename)
+		return
+	}
+
+	err = rootCmd.ResolveSecrets(manifests, secretsFile)
+	if err != nil {
+		_, _ = fmt.Fprintf(rootCmd.IOStreams.ErrOut, "error:%s\n", err)
+		return
+	}
+
+	client := rootCmd.GetClient()
+	for index := range manifests {
+		manifest := manifests[index]
+		err = createResource(client, &manifest)
+		if err != nil {
+			_, _ = fmt.Fprintf(rootCmd.IOStreams.ErrOut, "error:%s, %s\n", manifest.Key(), err)
+			continue
+		}
+		_, _ = fmt.Fprintf(rootCmd.IOStreams.Out, "%s created\n", manifest.Key())
+	}
+}
+
+func createResource(client *api.Client, manifest *manifestTY.Manifest) error {
+	if manifest.GetID() == "" {
+		return errors.New("'id' can not be empty")
+	}
+	exists, err := client.IsResourceExists(manifest.Kind, manifest.GetID())
+	if err != nil {
+		return err
+	}
+	if exists {
+		return errors.New("already exists")
+	}
+	return client.SaveResource(manifest.Kind, manifest.Spec)
+}
@@ -0,0 +1,42 @@
+package describe
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mycontroller-org/server/v2/cmd/client/api"
+	rootCmd "github.com/mycontroller-org/server/v2/cmd/client/command/root"
+
+	"github.com/spf13/cobra"
+)
+
+var relatedLimit int64
+
+func init() {
+	rootCmd.Cmd.AddCommand(describeCmd)
+	describeCmd.Flags().Int64Var(&relatedLimit, "related-limit", 50, "limits number of related resources")
+}
+
+var describeCmd = &cobra.Command{
+	Use:   "describe <kind> <id>",
+	Short: "Prints the detailed description of a resource",
+	Long: fmt.Sprintf(`Prints the detailed description of a resource, includes state, labels and the related resources
+
+Supported kinds: %s
+`, strings.Join(api.ResourceKinds(), ", ")),
+	Example: `  # describe a gateway, includes the nodes of the gateway
+  myc describe gateway mysensors
+
+  # describe a node in yaml format
+  myc describe node mysensors_1 --output yaml`,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		rootCmd.UpdateStreams(cmd)
+	},
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		err := executeDescribe(args[0], args[1])
+		if err != nil {
+			_, _ = fmt.Fprintf(rootCmd.IOStreams.ErrOut, "error:%s\n", err)
+		}
+	},
+}
@@ -0,0 +1,207 @@
+package describe
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mycontroller-org/server/v2/cmd/client/api"
+	rootCmd "github.com/mycontroller-org/server/v2/cmd/client/command/root"
+	types "github.com/mycontroller-org/server/v2/pkg/types"
+	"github.com/mycontroller-org/server/v2/pkg/utils/convertor"
+	"github.com/mycontroller-org/server/v2/pkg/utils/printer"
+	storageTY "github.com/mycontroller-org/server/v2/plugin/database/storage/types"
+	"github.com/nleeper/goment"
+)
+
+// Description of a resource
+type Description struct {
+	Kind     string                 `json:"kind" yaml:"kind"`
+	Resource map[string]interface{} `json:"resource" yaml:"resource"`
+	Related  []RelatedResources     `json:"related,omitempty" yaml:"related,omitempty"`
+}
+
+// RelatedResources of a kind
+type RelatedResources struct {
+	Kind  string        `json:"kind" yaml:"kind"`
+	Count int64         `json:"count" yaml:"count"`
+	Items []interface{} `json:"items" yaml:"items"`
+}
+
+// related kind and the keys used to filter the related resources
+type relation struct {
+	kind       string
+	filterKeys map[string]string // filter key => value path on the resource
+}
+
+var relations = map[string][]relation{
+	types.EntityGateway: {
+		{kind: types.EntityNode, filterKeys: map[string]string{types.KeyGatewayID: "id"}},
+	},
+	types.EntityNode: {
+		{kind: types.EntitySource, filterKeys: map[string]string{types.KeyGatewayID: "gatewayId", types.KeyNodeID: "nodeId"}},
+		{kind: types.EntityField, filterKeys: map[string]string{types.KeyGatewayID: "gatewayId", types.KeyNodeID: "nodeId"}},
+	},
+	types.EntitySource: {
+		{kind: types.EntityField, filterKeys: map[string]string{types.KeyGatewayID: "gatewayId", types.KeyNodeID: "nodeId", types.KeySourceID: "sourceId"}},
+	},
+}
+
+// printed on the summary, when available
+var summaryKeys = []struct {
+	title     string
+	valuePath string
+}{
+	{"Gateway ID", "gatewayId"},
+	{"Node ID", "nodeId"},
+	{"Source ID", "sourceId"},
+	{"Field ID", "fieldId"},
+	{"Name", "name"},
+	{"Description", "description"},
+	{"Type", "type"},
+	{"Enabled", "enabled"},
+	{"Provider", "provider.type"},
+	{"Protocol", "provider.protocol.type"},
+	{"Last Seen", "lastSeen"},
+	{"Modified On", "modifiedOn"},
+}
+
+func executeDescribe(kind, id string) error {
+	actualKind, err := api.NormalizeResourceKind(kind)
+	if err != nil {
+		return err
+	}
+
+	client := rootCmd.GetClient()
+	resource, err := client.GetResource(actualKind, id)
+	if err != nil {
+		return err
+	}
+
+	description := &Description{Kind: actualKind, Resource: resource, Related: []RelatedResources{}}
+	for _, _relation := range relations[actualKind] {
+		filters := []storageTY.Filter{}
+		for key, valuePath := range _relation.filterKeys {
+			value := getValue(resource, valuePath)
+			filters = append(filters, storageTY.Filter{Key: key, Operator: storageTY.OperatorEqual, Value: value})
+		}
+		result, err := client.ListResource(_relation.kind, filters, relatedLimit)
+		if err != nil {
+			return err
+		}
+		items, _ := result.Data.([]interface{})
+		description.Related = append(description.Related, RelatedResources{Kind: _relation.kind, Count: result.Count, Items: items})
+	}
+
+	switch rootCmd.OutputFormat {
+	case printer.OutputJSON, printer.OutputYAML:
+		printer.Print(rootCmd.IOStreams.Out, nil, description, rootCmd.HideHeader, rootCmd.OutputFormat, rootCmd.Pretty)
+	default:
+		printDescription(description)
+	}
+	return nil
+}
+
+func printDescription(description *Description) {
+	writer := tabwriter.NewWriter(rootCmd.IOStreams.Out, 0, 0, 2, ' ', 0)
+	writeLine := func(title string, value interface{}) {
+		_, _ = fmt.Fprintf(writer, "%s:\t%s\n", title, convertor.ToString(value))
+	}
+
+	writeLine("Kind", description.Kind)
+	writeLine("ID", description.Resource["id"])
+	for _, summary := range summaryKeys {
+		value := getValue(description.Resource, summary.valuePath)
+		if value == nil {
+			continue
+		}
+		writeLine(summary.title, formatValue(value))
+	}
+
+	// labels
+	labels, _ := description.Resource["labels"].(map[string]interface{})
+	writeLine("Labels", "")
+	for _, key := range sortedKeys(labels) {
+		_, _ = fmt.Fprintf(writer, "  %s:\t%s\n", key, convertor.ToString(labels[key]))
+	}
+
+	// state
+	if state, ok := description.Resource["state"].(map[string]interface{}); ok {
+		writeLine("State", "")
+		_, _ = fmt.Fprintf(writer, "  Status:\t%s\n", convertor.ToString(state["status"]))
+		_, _ = fmt.Fprintf(writer, "  Message:\t%s\n", convertor.ToString(state["message"]))
+		_, _ = fmt.Fprintf(writer, "  Since:\t%s\n", formatValue(state["since"]))
+	}
+	_ = writer.Flush()
+
+	// related resources
+	for _, related := range description.Related {
+		_, _ = fmt.Fprintf(rootCmd.IOStreams.Out, "\nRelated %s(s): %d\n", strings.ReplaceAll(related.Kind, "_", " "), related.Count)
+		if len(related.Items) == 0 {
+			continue
+		}
+		headers := []printer.Header{
+			{Title: "id", ValueFunc: valueFunc("id")},
+			{Title: "name", ValueFunc: valueFunc("name")},
+			{Title: "status", ValueFunc: valueFunc("state.status")},
+			{Title: "last seen", ValueFunc: valueFunc("lastSeen")},
+		}
+		printer.Print(rootCmd.IOStreams.Out, headers, related.Items, rootCmd.HideHeader, printer.OutputConsole, false)
+	}
+}
+
+// formatValue returns relative time for the timestamps
+func formatValue(value interface{}) string {
+	strValue := convertor.ToString(value)
+	timestamp, err := time.Parse(time.RFC3339Nano, strValue)
+	if err != nil {
+		return strValue
+	}
+	if timestamp.IsZero() {
+		return ""
+	}
+	g, err := goment.New(timestamp.UnixNano())
+	if err != nil {
+		return strValue
+	}
+	return fmt.Sprintf("%s (%s)", g.FromNow(), strValue)
+}
+
+func sortedKeys(data map[string]interface{}) []string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// getValue returns the value of the key path from a map, nil if not available
+func getValue(data map[string]interface{}, keyPath string) interface{} {
+	keys := strings.Split(keyPath, ".")
+	var value interface{} = data
+	for _, key := range keys {
+		valueMap, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = valueMap[key]
+	}
+	return value
+}
+
+func valueFunc(keyPath string) printer.ValueFunc {
+	return func(item interface{}) string {
+		data, ok := item.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		value := getValue(data, keyPath)
+		if value == nil {
+			return ""
+		}
+		return formatValue(value)
+	}
+}
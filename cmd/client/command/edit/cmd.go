@@ -0,0 +1,41 @@
+package edit
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mycontroller-org/server/v2/cmd/client/api"
+	rootCmd "github.com/mycontroller-org/server/v2/cmd/client/command/root"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.Cmd.AddCommand(editCmd)
+}
+
+var editCmd = &cobra.Command{
+	Use:   "edit <kind> <id>",
+	Short: "Edits a resource on the editor",
+	Long: fmt.Sprintf(`Edits a resource on the editor
+
+Opens the resource in the editor defined on the "EDITOR" environment variable, defaults to "vi".
+The resource is updated on the server, when the changes are saved and the editor is closed.
+Supported kinds: %s
+`, strings.Join(api.ResourceKinds(), ", ")),
+	Example: `  # edit a gateway
+  myc edit gateway mysensors
+
+  # edit a task in JSON format
+  EDITOR=nano myc edit task my_task --output json`,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		rootCmd.UpdateStreams(cmd)
+	},
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		err := executeEdit(args[0], args[1])
+		if err != nil {
+			_, _ = fmt.Fprintf(rootCmd.IOStreams.ErrOut, "error:%s\n", err)
+		}
+	},
+}
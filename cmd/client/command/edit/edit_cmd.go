@@ -0,0 +1,112 @@
+package edit
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	rootCmd "github.com/mycontroller-org/server/v2/cmd/client/command/root"
+	"github.com/mycontroller-org/server/v2/pkg/json"
+	"github.com/mycontroller-org/server/v2/pkg/utils/printer"
+	"gopkg.in/yaml.v3"
+)
+
+const defaultEditor = "vi"
+
+func executeEdit(kind, id string) error {
+	client := rootCmd.GetClient()
+	resource, err := client.GetResource(kind, id)
+	if err != nil {
+		return err
+	}
+
+	isJSON := rootCmd.OutputFormat == printer.OutputJSON
+	var original []byte
+	if isJSON {
+		original, err = json.MarshalIndent(resource, "", "  ")
+	} else {
+		original, err = yaml.Marshal(resource)
+	}
+	if err != nil {
+		return err
+	}
+
+	extension := "yaml"
+	if isJSON {
+		extension = "json"
+	}
+	tmpFile, err := os.CreateTemp("", fmt.Sprintf("myc-edit-*.%s", extension))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmpFile.Name()) }()
+
+	_, err = tmpFile.Write(original)
+	if err != nil {
+		_ = tmpFile.Close()
+		return err
+	}
+	err = tmpFile.Close()
+	if err != nil {
+		return err
+	}
+
+	err = openEditor(tmpFile.Name())
+	if err != nil {
+		return err
+	}
+
+	edited, err := os.ReadFile(tmpFile.Name())
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(bytes.TrimSpace(original), bytes.TrimSpace(edited)) {
+		_, _ = fmt.Fprintln(rootCmd.IOStreams.Out, "Edit cancelled, no changes made")
+		return nil
+	}
+
+	updated := map[string]interface{}{}
+	if isJSON {
+		err = json.Unmarshal(edited, &updated)
+	} else {
+		err = yaml.Unmarshal(edited, &updated)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid content. error:%w", err)
+	}
+	if len(updated) == 0 {
+		return errors.New("empty content, edit cancelled")
+	}
+	if updatedID, _ := updated["id"].(string); updatedID != id {
+		return fmt.Errorf("'id' can not be changed, expected:%s, received:%s", id, updatedID)
+	}
+
+	err = client.SaveResource(kind, updated)
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(rootCmd.IOStreams.Out, "%s/%s edited\n", kind, id)
+	return nil
+}
+
+// openEditor opens the file on the editor and waits till the editor closed
+func openEditor(filename string) error {
+	editor := strings.TrimSpace(os.Getenv("EDITOR"))
+	if editor == "" {
+		editor = defaultEditor
+	}
+	// editor can have arguments. ex: "code --wait"
+	editorArgs := strings.Fields(editor)
+	cmd := exec.Command(editorArgs[0], append(editorArgs[1:], filename)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	err := cmd.Run()
+	if err != nil {
+		return fmt.Errorf("error on editor '%s'. error:%w", editor, err)
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package generate
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	rootCmd "github.com/mycontroller-org/server/v2/cmd/client/command/root"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.Cmd.AddCommand(generateCmd)
+}
+
+var generateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Generates resources from the existing resources",
+	PreRun: func(cmd *cobra.Command, args []string) {
+		rootCmd.UpdateStreams(cmd)
+	},
+}
+
+func confirm(message string) bool {
+	_, _ = fmt.Fprintf(rootCmd.IOStreams.Out, "%s [y/N]: ", message)
+	reader := bufio.NewReader(rootCmd.IOStreams.In)
+	answer, err := reader.ReadString('\n')
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -0,0 +1,136 @@
+package generate
+
+import (
+	"fmt"
+	"strings"
+
+	rootCmd "github.com/mycontroller-org/server/v2/cmd/client/command/root"
+	vdTY "github.com/mycontroller-org/server/v2/pkg/types/virtual_device"
+	"github.com/mycontroller-org/server/v2/pkg/utils"
+	"github.com/mycontroller-org/server/v2/pkg/utils/printer"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	gatewayID        string
+	nodeIDs          []string
+	keys             []string
+	includeUnchanged bool
+	accept           bool
+	autoConfirm      bool
+)
+
+func init() {
+	generateCmd.AddCommand(virtualDeviceGenerateCmd)
+	virtualDeviceGenerateCmd.Flags().StringVar(&gatewayID, "gateway", "", "generates only for the nodes of this gateway")
+	virtualDeviceGenerateCmd.Flags().StringSliceVar(&nodeIDs, "node", []string{}, "generates only for these nodes, comma separated or repeated. requires gateway")
+	virtualDeviceGenerateCmd.Flags().StringSliceVar(&keys, "key", []string{}, "accepts only these proposals, comma separated or repeated")
+	virtualDeviceGenerateCmd.Flags().BoolVar(&includeUnchanged, "include-unchanged", false, "prints the generated devices those are not changed")
+	virtualDeviceGenerateCmd.Flags().BoolVar(&accept, "accept", false, "saves the proposed devices")
+	virtualDeviceGenerateCmd.Flags().BoolVarP(&autoConfirm, "yes", "y", false, "saves the proposed devices without confirmation")
+}
+
+var virtualDeviceGenerateCmd = &cobra.Command{
+	Use:     "virtual-device",
+	Aliases: []string{"virtual-devices", "vd"},
+	Short:   "Proposes virtual devices from the nodes and fields",
+	Long: `Proposes virtual devices from the nodes and fields
+
+Fields are mapped to the traits based on the labels, provider hints, metric types and units.
+Prints the proposals, saved only with "--accept" flag.
+The generated devices are updated automatically, when new fields are added on the node.
+`,
+	Example: `  # prints the proposals of a gateway
+  myc generate virtual-device --gateway tasmota
+
+  # saves the selected proposals
+  myc generate virtual-device --gateway tasmota --key tasmota.kitchen.Control --accept`,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		rootCmd.UpdateStreams(cmd)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		err := executeGenerate()
+		if err != nil {
+			_, _ = fmt.Fprintf(rootCmd.IOStreams.ErrOut, "error:%s\n", err)
+		}
+	},
+}
+
+func executeGenerate() error {
+	if len(nodeIDs) > 0 && gatewayID == "" {
+		return fmt.Errorf("gateway is required to filter by node")
+	}
+
+	client := rootCmd.GetClient()
+	request := &vdTY.GeneratorRequest{GatewayID: gatewayID, NodeIDs: nodeIDs, IncludeUnchanged: includeUnchanged}
+	proposals, err := client.GenerateVirtualDevices(request)
+	if err != nil {
+		return err
+	}
+
+	selected := make([]vdTY.Proposal, 0)
+	for _, proposal := range proposals {
+		if _, found := utils.FindItem(keys, proposal.Key); len(keys) > 0 && !found {
+			continue
+		}
+		selected = append(selected, proposal)
+	}
+	if len(selected) == 0 {
+		_, _ = fmt.Fprintln(rootCmd.IOStreams.Out, "No proposal found")
+		return nil
+	}
+	printProposals(selected)
+
+	if !accept {
+		return nil
+	}
+
+	devices := make([]vdTY.VirtualDevice, 0)
+	for _, proposal := range selected {
+		if proposal.Status != vdTY.ProposalStatusUnchanged {
+			devices = append(devices, proposal.Device)
+		}
+	}
+	if len(devices) == 0 {
+		return nil
+	}
+
+	if !autoConfirm && !confirm("Do you want to save the proposed devices?") {
+		_, _ = fmt.Fprintln(rootCmd.IOStreams.Out, "Generate cancelled")
+		return nil
+	}
+
+	err = client.AcceptVirtualDevices(devices)
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(rootCmd.IOStreams.Out, "Saved %d device(s)\n", len(devices))
+	return nil
+}
+
+func printProposals(proposals []vdTY.Proposal) {
+	headers := []printer.Header{
+		{Title: "key"},
+		{Title: "status"},
+		{Title: "id", ValuePath: "device.id", IsWide: true},
+		{Title: "name", ValuePath: "device.name"},
+		{Title: "device type", ValuePath: "device.deviceType"},
+		{Title: "traits", ValueFunc: func(item interface{}) string {
+			traits := make([]string, 0)
+			for _, resource := range item.(*vdTY.Proposal).Device.Traits {
+				traits = append(traits, fmt.Sprintf("%s:%s", resource.TraitType, resource.Name))
+			}
+			return strings.Join(traits, ", ")
+		}},
+		{Title: "new traits", ValueFunc: func(item interface{}) string {
+			return strings.Join(item.(*vdTY.Proposal).NewTraits, ", ")
+		}},
+	}
+
+	rows := make([]interface{}, 0)
+	for index := range proposals {
+		rows = append(rows, &proposals[index])
+	}
+	printer.Print(rootCmd.IOStreams.Out, headers, rows, rootCmd.HideHeader, rootCmd.OutputFormat, rootCmd.Pretty)
+}
@@ -0,0 +1,51 @@
+package logs
+
+import (
+	"fmt"
+
+	rootCmd "github.com/mycontroller-org/server/v2/cmd/client/command/root"
+	clientTY "github.com/mycontroller-org/server/v2/pkg/types/client"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	eventTypes  []string
+	entityTypes []string
+	quickIDs    []string
+	labelSlice  []string
+)
+
+func init() {
+	rootCmd.Cmd.AddCommand(logsCmd)
+	logsCmd.Flags().StringSliceVarP(&labelSlice, "label", "l", []string{}, "filter the events by label. comma separated or repeated label=value")
+	logsCmd.Flags().StringSliceVar(&eventTypes, "event-type", []string{}, "filter the events by event type. options: created, updated, deleted")
+	logsCmd.Flags().StringSliceVar(&entityTypes, "entity-type", []string{}, "filter the events by entity type. ex: gateway, node, field")
+	logsCmd.Flags().StringSliceVar(&quickIDs, "quick-id", []string{}, "filter the events by quick id, supports wildcard. ex: node:mysensors.*")
+}
+
+var logsCmd = &cobra.Command{
+	Use:   "logs",
+	Short: "Follows the events from the server as log lines",
+	Long: `Follows the events from the server as log lines
+
+Each event is printed in a single line with the changed state, field value and the labels.
+Use "watch" to get the events in table format.
+`,
+	Example: `  # follow the events of a gateway and its nodes
+  myc logs --quick-id "gateway:mysensors,node:mysensors.*"
+
+  # follow the events of the entities labelled with location=kitchen
+  myc logs --label location=kitchen`,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		rootCmd.UpdateStreams(cmd)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		filter, err := clientTY.NewEventFilter(eventTypes, entityTypes, quickIDs, labelSlice)
+		if err != nil {
+			_, _ = fmt.Fprintf(rootCmd.IOStreams.ErrOut, "error:%s\n", err)
+			return
+		}
+		rootCmd.StreamEvents(filter, printEvent)
+	},
+}
@@ -0,0 +1,88 @@
+package logs
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	rootCmd "github.com/mycontroller-org/server/v2/cmd/client/command/root"
+	eventTY "github.com/mycontroller-org/server/v2/pkg/types/event"
+	"github.com/mycontroller-org/server/v2/pkg/utils/printer"
+)
+
+// LogEntry of an event
+type LogEntry struct {
+	Timestamp  time.Time         `json:"timestamp" yaml:"timestamp"`
+	Event      string            `json:"event" yaml:"event"`
+	EntityType string            `json:"entityType" yaml:"entityType"`
+	QuickID    string            `json:"quickId" yaml:"quickId"`
+	Details    map[string]string `json:"details,omitempty" yaml:"details,omitempty"`
+}
+
+// entity keys included on the log line, when available
+var detailKeys = [][]string{
+	{"state", "status"},
+	{"state", "message"},
+	{"current", "value"},
+	{"unit"},
+}
+
+func printEvent(event *eventTY.Event) {
+	entry := &LogEntry{
+		Timestamp:  time.Now(),
+		Event:      event.Type,
+		EntityType: event.EntityType,
+		QuickID:    event.EntityQuickID,
+		Details:    map[string]string{},
+	}
+	if entry.QuickID == "" {
+		entry.QuickID = event.EntityID
+	}
+
+	entity, _ := event.Entity.(map[string]interface{})
+	for _, keys := range detailKeys {
+		if value := getValue(entity, keys...); value != "" {
+			entry.Details[strings.Join(keys, ".")] = value
+		}
+	}
+	if labels, ok := entity["labels"].(map[string]interface{}); ok {
+		for key, value := range labels {
+			entry.Details["labels."+key] = fmt.Sprintf("%v", value)
+		}
+	}
+
+	switch rootCmd.OutputFormat {
+	case printer.OutputJSON, printer.OutputYAML:
+		printer.NewStreamPrinter(rootCmd.IOStreams.Out, nil, true, rootCmd.OutputFormat, rootCmd.Pretty).Print(entry)
+
+	default:
+		keys := make([]string, 0, len(entry.Details))
+		for key := range entry.Details {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		details := make([]string, 0, len(keys))
+		for _, key := range keys {
+			details = append(details, fmt.Sprintf("%s=%q", key, entry.Details[key]))
+		}
+		_, _ = fmt.Fprintf(rootCmd.IOStreams.Out, "%s %-8s %-16s %s %s\n",
+			entry.Timestamp.Format(time.RFC3339), entry.Event, entry.EntityType, entry.QuickID, strings.Join(details, " "))
+	}
+}
+
+// getValue returns the string value of the keys from the entity map
+func getValue(entity map[string]interface{}, keys ...string) string {
+	var value interface{} = entity
+	for _, key := range keys {
+		valueMap, ok := value.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		value = valueMap[key]
+	}
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprintf("%v", value)
+}
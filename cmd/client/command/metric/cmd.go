@@ -0,0 +1,126 @@
+package metric
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/mycontroller-org/server/v2/cmd/client/api"
+	rootCmd "github.com/mycontroller-org/server/v2/cmd/client/command/root"
+	"github.com/mycontroller-org/server/v2/pkg/types"
+	fieldTY "github.com/mycontroller-org/server/v2/pkg/types/field"
+	"github.com/mycontroller-org/server/v2/pkg/utils"
+	quickIdUtils "github.com/mycontroller-org/server/v2/pkg/utils/quick_id"
+	mtsTY "github.com/mycontroller-org/server/v2/plugin/database/metric/types"
+	storageTY "github.com/mycontroller-org/server/v2/plugin/database/storage/types"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	start     string
+	stop      string
+	window    string
+	functions []string
+)
+
+func init() {
+	rootCmd.Cmd.AddCommand(metricCmd)
+	metricCmd.PersistentFlags().StringVar(&start, "start", "-1h", "start time, relative or RFC3339 timestamp. ex: -24h, 2022-01-01T00:00:00Z")
+	metricCmd.PersistentFlags().StringVar(&stop, "stop", "", "stop time, relative or RFC3339 timestamp. defaults to now")
+	metricCmd.PersistentFlags().StringVar(&window, "window", "5m", "aggregation window. ex: 1m, 5m, 1h")
+	metricCmd.PersistentFlags().StringSliceVar(&functions, "fn", []string{}, "aggregation functions. comma separated or repeated. ex: mean,min,max,percentile_99")
+}
+
+var metricCmd = &cobra.Command{
+	Use:     "metric",
+	Aliases: []string{"metrics"},
+	Short:   "Queries and exports the metric data of the fields",
+	PreRun: func(cmd *cobra.Command, args []string) {
+		rootCmd.UpdateStreams(cmd)
+	},
+}
+
+// getFields returns the fields of the quick ids, quick id can be a wildcard pattern
+func getFields(client *api.Client, quickIDs []string) ([]fieldTY.Field, error) {
+	fields := make([]fieldTY.Field, 0)
+	added := map[string]bool{}
+	for _, quickID := range quickIDs {
+		pattern := strings.TrimPrefix(quickID, quickIdUtils.QuickIdField+":")
+		ids := strings.Split(pattern, ".")
+		if len(ids) != 4 {
+			return nil, fmt.Errorf("invalid field quick id '%s', expected format: gatewayId.nodeId.sourceId.fieldId", quickID)
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid quick id pattern '%s'. error:%w", quickID, err)
+		}
+
+		// filter with the ids, which are not a pattern
+		filters := []storageTY.Filter{}
+		for index, key := range []string{types.KeyGatewayID, types.KeyNodeID, types.KeySourceID, types.KeyFieldID} {
+			if !strings.ContainsAny(ids[index], "*?[\\") {
+				filters = append(filters, storageTY.Filter{Key: key, Operator: storageTY.OperatorEqual, Value: ids[index]})
+			}
+		}
+
+		result, err := client.ListResource(types.EntityField, filters, -1)
+		if err != nil {
+			return nil, err
+		}
+		items, _ := result.Data.([]interface{})
+		matched := 0
+		for _, item := range items {
+			data, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			field := fieldTY.Field{}
+			err = utils.MapToStruct(utils.TagNameJSON, data, &field)
+			if err != nil {
+				return nil, err
+			}
+			fieldQuickID := getQuickID(&field)
+			if ok, _ := path.Match(pattern, fieldQuickID); !ok {
+				continue
+			}
+			matched++
+			if !added[field.ID] {
+				added[field.ID] = true
+				fields = append(fields, field)
+			}
+		}
+		if matched == 0 {
+			return nil, fmt.Errorf("no field found for the quick id '%s'", quickID)
+		}
+	}
+	return fields, nil
+}
+
+// queryFields returns the metric data of the fields, mapped with the field id
+func queryFields(client *api.Client, fields []fieldTY.Field) (map[string][]mtsTY.ResponseData, error) {
+	queryConfig := &mtsTY.QueryConfig{
+		Global:     mtsTY.Query{Start: start, Stop: stop, Window: window, Functions: functions},
+		Individual: []mtsTY.Query{},
+	}
+	for _, field := range fields {
+		switch field.MetricType {
+		case mtsTY.MetricTypeNone, mtsTY.MetricTypeString, mtsTY.MetricTypeGEO, "":
+			_, _ = fmt.Fprintf(rootCmd.IOStreams.ErrOut, "skipping the field '%s', metric type '%s' not supported\n", getQuickID(&field), field.MetricType)
+			continue
+		}
+		queryConfig.Individual = append(queryConfig.Individual, mtsTY.Query{
+			Name:       field.ID,
+			MetricType: field.MetricType,
+			Tags:       map[string]string{types.KeyID: field.ID},
+		})
+	}
+	if len(queryConfig.Individual) == 0 {
+		return nil, errors.New("there is no field with supported metric type")
+	}
+	return client.QueryMetric(queryConfig)
+}
+
+func getQuickID(field *fieldTY.Field) string {
+	return fmt.Sprintf("%s.%s.%s.%s", field.GatewayID, field.NodeID, field.SourceID, field.FieldID)
+}
@@ -0,0 +1,138 @@
+package metric
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// escapes the measurement name on line protocol
+	measurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `)
+	// escapes the tag key, tag value and field key on line protocol
+	keyEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+	// escapes the string field value on line protocol
+	stringValueEscaper = strings.NewReplacer(`"`, `\"`, `\`, `\\`)
+)
+
+func writeCSV(out io.Writer, rows []*MetricRow) error {
+	writer := csv.NewWriter(out)
+	valueKeys := getValueKeys(rows)
+
+	header := append([]string{"timestamp", "quick_id", "name", "metric_type", "unit"}, valueKeys...)
+	err := writer.Write(header)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		record := []string{row.Timestamp.Format(time.RFC3339Nano), row.QuickID, row.Name, row.MetricType, row.Unit}
+		for _, key := range valueKeys {
+			record = append(record, formatValue(row.Values[key]))
+		}
+		err = writer.Write(record)
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// writeLineProtocol writes the rows in InfluxDB line protocol format
+// rows without any value are skipped
+func writeLineProtocol(out io.Writer, measurement string, rows []*MetricRow) error {
+	for _, row := range rows {
+		tags := map[string]string{
+			"quick_id":    row.QuickID,
+			"metric_type": row.MetricType,
+		}
+		if row.Field != nil {
+			tags["gateway_id"] = row.Field.GatewayID
+			tags["node_id"] = row.Field.NodeID
+			tags["source_id"] = row.Field.SourceID
+			tags["field_id"] = row.Field.FieldID
+		}
+		if row.Name != "" {
+			tags["name"] = row.Name
+		}
+		if row.Unit != "" {
+			tags["unit"] = row.Unit
+		}
+
+		fields := make([]string, 0, len(row.Values))
+		for _, key := range sortedKeys(row.Values) {
+			value, ok := toFieldValue(row.Values[key])
+			if !ok {
+				continue
+			}
+			fields = append(fields, fmt.Sprintf("%s=%s", keyEscaper.Replace(key), value))
+		}
+		if len(fields) == 0 {
+			continue
+		}
+
+		tagsSlice := make([]string, 0, len(tags))
+		for _, key := range sortedStringKeys(tags) {
+			tagsSlice = append(tagsSlice, fmt.Sprintf("%s=%s", keyEscaper.Replace(key), keyEscaper.Replace(tags[key])))
+		}
+
+		_, err := fmt.Fprintf(out, "%s,%s %s %d\n", measurementEscaper.Replace(measurement), strings.Join(tagsSlice, ","), strings.Join(fields, ","), row.Timestamp.UnixNano())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeJSON(out io.Writer, rows []*MetricRow) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", " ")
+	return encoder.Encode(rows)
+}
+
+// toFieldValue returns the line protocol field value
+func toFieldValue(value interface{}) (string, bool) {
+	switch data := value.(type) {
+	case nil:
+		return "", false
+	case float64:
+		return strconv.FormatFloat(data, 'f', -1, 64), true
+	case float32:
+		return strconv.FormatFloat(float64(data), 'f', -1, 32), true
+	case int, int32, int64:
+		return fmt.Sprintf("%di", data), true
+	case bool:
+		return strconv.FormatBool(data), true
+	case string:
+		if data == "" {
+			return "", false
+		}
+		return fmt.Sprintf(`"%s"`, stringValueEscaper.Replace(data)), true
+	default:
+		return fmt.Sprintf(`"%s"`, stringValueEscaper.Replace(fmt.Sprintf("%v", data))), true
+	}
+}
+
+func sortedKeys(data map[string]interface{}) []string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedStringKeys(data map[string]string) []string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,221 @@
+package metric
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	rootCmd "github.com/mycontroller-org/server/v2/cmd/client/command/root"
+	fieldTY "github.com/mycontroller-org/server/v2/pkg/types/field"
+	"github.com/mycontroller-org/server/v2/pkg/utils/convertor"
+	"github.com/mycontroller-org/server/v2/pkg/utils/printer"
+
+	"github.com/spf13/cobra"
+)
+
+// export formats
+const (
+	ExportFormatCSV          = "csv"
+	ExportFormatLineProtocol = "line-protocol"
+	ExportFormatJSON         = "json"
+)
+
+var (
+	exportFormat      string
+	exportFile        string
+	exportMeasurement string
+)
+
+func init() {
+	metricCmd.AddCommand(queryCmd)
+	metricCmd.AddCommand(exportCmd)
+	exportCmd.Flags().StringVar(&exportFormat, "format", ExportFormatCSV, "export format. options: csv, line-protocol, json")
+	exportCmd.Flags().StringVarP(&exportFile, "file", "f", "", "writes the export into the file, defaults to the standard output")
+	exportCmd.Flags().StringVar(&exportMeasurement, "measurement", "mycontroller", "measurement name used on line-protocol format")
+}
+
+// MetricRow is a metric data of a field
+type MetricRow struct {
+	Timestamp  time.Time              `json:"timestamp" yaml:"timestamp"`
+	QuickID    string                 `json:"quickId" yaml:"quickId"`
+	Name       string                 `json:"name" yaml:"name"`
+	MetricType string                 `json:"metricType" yaml:"metricType"`
+	Unit       string                 `json:"unit" yaml:"unit"`
+	Field      *fieldTY.Field         `json:"-" yaml:"-"`
+	Values     map[string]interface{} `json:"values" yaml:"values"`
+}
+
+var queryCmd = &cobra.Command{
+	Use:   "query <quickId>",
+	Short: "Prints the metric data of a field",
+	Example: `  # mean value of a field in the last 24 hours, aggregated by 5 minutes
+  myc metric query mysensors.1.1.V_TEMP --start -24h --window 5m --fn mean
+
+  # min, max values of a field in csv format
+  myc metric query field:mysensors.1.1.V_TEMP --fn min,max --output csv`,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		rootCmd.UpdateStreams(cmd)
+	},
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		rows, err := getRows(args)
+		if err != nil {
+			_, _ = fmt.Fprintf(rootCmd.IOStreams.ErrOut, "error:%s\n", err)
+			return
+		}
+		if len(rows) == 0 {
+			_, _ = fmt.Fprintln(rootCmd.IOStreams.Out, "No data found")
+			return
+		}
+
+		switch rootCmd.OutputFormat {
+		case printer.OutputJSON, printer.OutputYAML:
+			printer.Print(rootCmd.IOStreams.Out, nil, rows, rootCmd.HideHeader, rootCmd.OutputFormat, rootCmd.Pretty)
+
+		default:
+			headers := []printer.Header{
+				{Title: "timestamp", ValueFunc: func(item interface{}) string {
+					return item.(*MetricRow).Timestamp.Format(time.RFC3339)
+				}},
+				{Title: "quick id", IsWide: true, ValueFunc: func(item interface{}) string { return item.(*MetricRow).QuickID }},
+			}
+			for _, key := range getValueKeys(rows) {
+				valueKey := key
+				headers = append(headers, printer.Header{Title: valueKey, ValueFunc: func(item interface{}) string {
+					return formatValue(item.(*MetricRow).Values[valueKey])
+				}})
+			}
+			data := make([]interface{}, 0, len(rows))
+			for _, row := range rows {
+				data = append(data, row)
+			}
+			printer.Print(rootCmd.IOStreams.Out, headers, data, rootCmd.HideHeader, rootCmd.OutputFormat, rootCmd.Pretty)
+		}
+	},
+}
+
+var exportCmd = &cobra.Command{
+	Use:   "export <quickId>...",
+	Short: "Exports the metric data of the fields",
+	Long: `Exports the metric data of the fields in a time range
+
+Quick id can be a wildcard pattern to export many fields. ex: "mysensors.1.*.*"
+Supported formats: csv, line-protocol (InfluxDB) and json
+`,
+	Example: `  # export all the fields of a node in the last 7 days into a csv file
+  myc metric export "mysensors.1.*.*" --start -7d --window 1h --fn mean,min,max --file node_1.csv
+
+  # export into line protocol format
+  myc metric export mysensors.1.1.V_TEMP mysensors.1.2.V_HUM --format line-protocol --file data.lp`,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		rootCmd.UpdateStreams(cmd)
+	},
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		err := executeExport(args)
+		if err != nil {
+			_, _ = fmt.Fprintf(rootCmd.IOStreams.ErrOut, "error:%s\n", err)
+		}
+	},
+}
+
+func executeExport(quickIDs []string) error {
+	switch exportFormat {
+	case ExportFormatCSV, ExportFormatLineProtocol, ExportFormatJSON:
+	default:
+		return fmt.Errorf("unsupported export format '%s'", exportFormat)
+	}
+
+	rows, err := getRows(quickIDs)
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer = rootCmd.IOStreams.Out
+	if exportFile != "" {
+		file, err := os.Create(exportFile)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = file.Close() }()
+		out = file
+	}
+
+	switch exportFormat {
+	case ExportFormatCSV:
+		err = writeCSV(out, rows)
+	case ExportFormatLineProtocol:
+		err = writeLineProtocol(out, exportMeasurement, rows)
+	case ExportFormatJSON:
+		err = writeJSON(out, rows)
+	}
+	if err != nil {
+		return err
+	}
+
+	if exportFile != "" {
+		_, _ = fmt.Fprintf(rootCmd.IOStreams.Out, "exported %d rows into '%s'\n", len(rows), exportFile)
+	}
+	return nil
+}
+
+// getRows returns the metric data of the quick ids, sorted by quick id and timestamp
+func getRows(quickIDs []string) ([]*MetricRow, error) {
+	client := rootCmd.GetClient()
+	fields, err := getFields(client, quickIDs)
+	if err != nil {
+		return nil, err
+	}
+	result, err := queryFields(client, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]*MetricRow, 0)
+	for index := range fields {
+		field := &fields[index]
+		for _, data := range result[field.ID] {
+			rows = append(rows, &MetricRow{
+				Timestamp:  data.Time,
+				QuickID:    getQuickID(field),
+				Name:       field.Name,
+				MetricType: field.MetricType,
+				Unit:       field.Unit,
+				Field:      field,
+				Values:     data.Metric,
+			})
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].QuickID != rows[j].QuickID {
+			return rows[i].QuickID < rows[j].QuickID
+		}
+		return rows[i].Timestamp.Before(rows[j].Timestamp)
+	})
+	return rows, nil
+}
+
+// getValueKeys returns all the value keys of the rows
+func getValueKeys(rows []*MetricRow) []string {
+	keysMap := map[string]bool{}
+	for _, row := range rows {
+		for key := range row.Values {
+			keysMap[key] = true
+		}
+	}
+	keys := make([]string, 0, len(keysMap))
+	for key := range keysMap {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatValue(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	return convertor.ToString(value)
+}
@@ -0,0 +1,43 @@
+package root
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/mycontroller-org/server/v2/cmd/client/api"
+	clientTY "github.com/mycontroller-org/server/v2/pkg/types/client"
+	eventTY "github.com/mycontroller-org/server/v2/pkg/types/event"
+)
+
+const eventsReconnectDelay = 5 * time.Second
+
+// StreamEvents streams the matching events from the server till interrupted
+// reconnects on connection failures
+func StreamEvents(filter *clientTY.EventFilter, eventFunc api.EventFunc) {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	client := GetClient()
+	for {
+		err := client.StreamEvents(ctx, func(event *eventTY.Event) {
+			if filter.IsMatching(event) {
+				eventFunc(event)
+			}
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			_, _ = fmt.Fprintf(IOStreams.ErrOut, "error:%s, reconnecting in %s\n", err, eventsReconnectDelay)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(eventsReconnectDelay):
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package root
+
+import (
+	manifestTY "github.com/mycontroller-org/server/v2/pkg/types/manifest"
+)
+
+// ResolveSecrets replaces the secret references on the manifests
+// secrets file gets the priority over the environment variables
+func ResolveSecrets(manifests []manifestTY.Manifest, secretsFile string) error {
+	lookupFuncs := []manifestTY.SecretLookupFunc{}
+	if secretsFile != "" {
+		fileLookup, err := manifestTY.FileSecretLookup(secretsFile)
+		if err != nil {
+			return err
+		}
+		lookupFuncs = append(lookupFuncs, fileLookup)
+	}
+	lookupFuncs = append(lookupFuncs, manifestTY.EnvSecretLookup)
+
+	for index := range manifests {
+		err := manifests[index].ResolveSecrets(lookupFuncs...)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
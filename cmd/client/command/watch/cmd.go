@@ -0,0 +1,91 @@
+package watch
+
+import (
+	"fmt"
+	"time"
+
+	rootCmd "github.com/mycontroller-org/server/v2/cmd/client/command/root"
+	clientTY "github.com/mycontroller-org/server/v2/pkg/types/client"
+	eventTY "github.com/mycontroller-org/server/v2/pkg/types/event"
+	"github.com/mycontroller-org/server/v2/pkg/utils/printer"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	eventTypes  []string
+	entityTypes []string
+	quickIDs    []string
+	labelSlice  []string
+)
+
+func init() {
+	rootCmd.Cmd.AddCommand(watchCmd)
+	watchCmd.PersistentFlags().StringSliceVarP(&labelSlice, "label", "l", []string{}, "filter the events by label. comma separated or repeated label=value")
+	watchCmd.Flags().StringSliceVar(&eventTypes, "event-type", []string{}, "filter the events by event type. options: created, updated, deleted")
+	watchCmd.Flags().StringSliceVar(&entityTypes, "entity-type", []string{}, "filter the events by entity type. ex: gateway, node, field")
+	watchCmd.Flags().StringSliceVar(&quickIDs, "quick-id", []string{}, "filter the events by quick id, supports wildcard. ex: node:mysensors.*")
+}
+
+// WatchEvent is an event with received timestamp
+type WatchEvent struct {
+	Timestamp     time.Time `json:"timestamp" yaml:"timestamp"`
+	eventTY.Event `yaml:",inline"`
+}
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Watches the events from the server",
+	Example: `  # watch all the events
+  myc watch
+
+  # watch the gateway and node events
+  myc watch --entity-type gateway,node
+
+  # watch the events of a node in json format
+  myc watch --quick-id "node:mysensors.1" --output json`,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		rootCmd.UpdateStreams(cmd)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		filter, err := clientTY.NewEventFilter(eventTypes, entityTypes, quickIDs, labelSlice)
+		if err != nil {
+			_, _ = fmt.Fprintf(rootCmd.IOStreams.ErrOut, "error:%s\n", err)
+			return
+		}
+
+		headers := []printer.Header{
+			{Title: "timestamp", Width: 24, ValueFunc: func(item interface{}) string {
+				return item.(*WatchEvent).Timestamp.Format(time.RFC3339)
+			}},
+			{Title: "event", ValueFunc: func(item interface{}) string { return item.(*WatchEvent).Type }},
+			{Title: "entity type", Width: 16, ValueFunc: func(item interface{}) string { return item.(*WatchEvent).EntityType }},
+			{Title: "entity id", Width: 30, IsWide: true, ValueFunc: func(item interface{}) string { return item.(*WatchEvent).EntityID }},
+			{Title: "quick id", Width: 40, ValueFunc: func(item interface{}) string { return item.(*WatchEvent).EntityQuickID }},
+			{Title: "status", ValueFunc: func(item interface{}) string {
+				return getString(item.(*WatchEvent).Entity, "state", "status")
+			}},
+		}
+		streamPrinter := printer.NewStreamPrinter(rootCmd.IOStreams.Out, headers, rootCmd.HideHeader, rootCmd.OutputFormat, rootCmd.Pretty)
+
+		rootCmd.StreamEvents(filter, func(event *eventTY.Event) {
+			streamPrinter.Print(&WatchEvent{Timestamp: time.Now(), Event: *event})
+		})
+	},
+}
+
+// getString returns the string value of the keys from the entity map
+func getString(entity interface{}, keys ...string) string {
+	value := entity
+	for _, key := range keys {
+		valueMap, ok := value.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		value = valueMap[key]
+	}
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprintf("%v", value)
+}
@@ -0,0 +1,102 @@
+package watch
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	rootCmd "github.com/mycontroller-org/server/v2/cmd/client/command/root"
+	"github.com/mycontroller-org/server/v2/pkg/types"
+	clientTY "github.com/mycontroller-org/server/v2/pkg/types/client"
+	eventTY "github.com/mycontroller-org/server/v2/pkg/types/event"
+	fieldTY "github.com/mycontroller-org/server/v2/pkg/types/field"
+	"github.com/mycontroller-org/server/v2/pkg/utils/printer"
+	quickIdUtils "github.com/mycontroller-org/server/v2/pkg/utils/quick_id"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	watchCmd.AddCommand(fieldWatchCmd)
+}
+
+// FieldChange is a value change of a field
+type FieldChange struct {
+	Timestamp     time.Time   `json:"timestamp" yaml:"timestamp"`
+	QuickID       string      `json:"quickId" yaml:"quickId"`
+	Name          string      `json:"name" yaml:"name"`
+	Value         interface{} `json:"value" yaml:"value"`
+	PreviousValue interface{} `json:"previousValue" yaml:"previousValue"`
+	Unit          string      `json:"unit" yaml:"unit"`
+}
+
+var fieldWatchCmd = &cobra.Command{
+	Use:     "field <quickId>...",
+	Aliases: []string{"fields"},
+	Short:   "Watches the value changes of the fields",
+	Example: `  # watch a field
+  myc watch field mysensors.1.1.V_TEMP
+
+  # watch all the fields of a node
+  myc watch field "mysensors.1.*"`,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		rootCmd.UpdateStreams(cmd)
+	},
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		fieldQuickIDs := make([]string, 0, len(args))
+		for _, quickID := range args {
+			if !strings.HasPrefix(quickID, quickIdUtils.QuickIdField+":") {
+				quickID = fmt.Sprintf("%s:%s", quickIdUtils.QuickIdField, quickID)
+			}
+			fieldQuickIDs = append(fieldQuickIDs, quickID)
+		}
+		filter, err := clientTY.NewEventFilter([]string{eventTY.TypeCreated, eventTY.TypeUpdated}, []string{types.EntityField}, fieldQuickIDs, labelSlice)
+		if err != nil {
+			_, _ = fmt.Fprintf(rootCmd.IOStreams.ErrOut, "error:%s\n", err)
+			return
+		}
+
+		headers := []printer.Header{
+			{Title: "timestamp", Width: 24, ValueFunc: func(item interface{}) string {
+				return item.(*FieldChange).Timestamp.Format(time.RFC3339)
+			}},
+			{Title: "quick id", Width: 40, ValueFunc: func(item interface{}) string { return item.(*FieldChange).QuickID }},
+			{Title: "name", Width: 20, IsWide: true, ValueFunc: func(item interface{}) string { return item.(*FieldChange).Name }},
+			{Title: "value", Width: 16, ValueFunc: func(item interface{}) string { return toString(item.(*FieldChange).Value) }},
+			{Title: "previous value", Width: 16, IsWide: true, ValueFunc: func(item interface{}) string {
+				return toString(item.(*FieldChange).PreviousValue)
+			}},
+			{Title: "unit", ValueFunc: func(item interface{}) string { return item.(*FieldChange).Unit }},
+		}
+		streamPrinter := printer.NewStreamPrinter(rootCmd.IOStreams.Out, headers, rootCmd.HideHeader, rootCmd.OutputFormat, rootCmd.Pretty)
+
+		rootCmd.StreamEvents(filter, func(event *eventTY.Event) {
+			field := &fieldTY.Field{}
+			err := event.LoadEntity(field)
+			if err != nil {
+				_, _ = fmt.Fprintf(rootCmd.IOStreams.ErrOut, "error on loading field:%s\n", err)
+				return
+			}
+			timestamp := field.Current.Timestamp
+			if timestamp.IsZero() {
+				timestamp = time.Now()
+			}
+			streamPrinter.Print(&FieldChange{
+				Timestamp:     timestamp,
+				QuickID:       event.EntityQuickID,
+				Name:          field.Name,
+				Value:         field.Current.Value,
+				PreviousValue: field.Previous.Value,
+				Unit:          field.Unit,
+			})
+		})
+	},
+}
+
+func toString(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprintf("%v", value)
+}
@@ -80,7 +80,7 @@ func getDefaultServerConfig() *config.Config {
 		},
 
 		Bus: cmap.CustomMap{
-			"type":               "embedded", // other option: natsio
+			"type":               "embedded", // other options: natsio, mqtt
 			"topic_prefix":       "mc_bus",
 			"server_url":         "nats://192.168.1.21:4222",
 			"insecure":           false,
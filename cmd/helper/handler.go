@@ -74,7 +74,8 @@ func (h Handler) Start(ctx context.Context, configFilePath string) error {
 	//-------------------------------------------
 
 	// handler service
-	handler, err := handlerSVC.New(ctx, &cfg.Gateway)
+	// sunrise api not available, storage is not accessible on the standalone handler
+	handler, err := handlerSVC.New(ctx, &cfg.Gateway, nil)
 	if err != nil {
 		logger.Error("error on getting gateway service", zap.Error(err))
 		return err
@@ -195,7 +195,7 @@ func (s *Server) Start(ctx context.Context, configFilePath string) error {
 	}
 
 	// handler service
-	handler, err := handlerSVC.New(ctx, &cfg.Handler)
+	handler, err := handlerSVC.New(ctx, &cfg.Handler, api.Sunrise())
 	if err != nil {
 		logger.Error("error on getting handler service", zap.Error(err))
 		return err
@@ -113,11 +113,22 @@ func (s *Server) triggerSystemRestore(cfg *config.StartupRestore) {
 		return
 	}
 
+	// apply incremental backups
+	for _, extractedDir := range cfg.IncrementalDirectories {
+		err = restoreEngine.ExecuteIncrementalRestore(s.storage, storageApiMap, extractedDir, s.updateRestoreApiMap)
+		if err != nil {
+			s.logger.Fatal("error on incremental restore", zap.String("extractedDirectory", extractedDir), zap.Error(err))
+			return
+		}
+	}
+
 	// clean extracted files
-	err = utils.RemoveDir(cfg.ExtractedDirectory)
-	if err != nil {
-		s.logger.Fatal("error on deleting extracted backup files", zap.Any("restoreConfig", cfg), zap.Error(err))
-		return
+	for _, extractedDir := range append([]string{cfg.ExtractedDirectory}, cfg.IncrementalDirectories...) {
+		err = utils.RemoveDir(extractedDir)
+		if err != nil {
+			s.logger.Fatal("error on deleting extracted backup files", zap.Any("restoreConfig", cfg), zap.Error(err))
+			return
+		}
 	}
 }
 
@@ -0,0 +1,46 @@
+package action
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mycontroller-org/server/v2/pkg/types"
+	webhookTY "github.com/mycontroller-org/server/v2/pkg/types/inbound_webhook"
+	rsTY "github.com/mycontroller-org/server/v2/pkg/types/resource_service"
+	"github.com/mycontroller-org/server/v2/pkg/types/topic"
+	busUtils "github.com/mycontroller-org/server/v2/pkg/utils/bus_utils"
+)
+
+// toTaskAction triggers the task now or executes enable, disable and reload actions
+func (a *ActionAPI) toTaskAction(id, action string) error {
+	if types.GetAction(action) != types.ActionTrigger {
+		return a.toEnableDisableReloadAction(a.api.Task(), id, action)
+	}
+	task, err := a.api.Task().GetByID(id)
+	if err != nil {
+		return err
+	}
+	if !task.Enabled {
+		return fmt.Errorf("task disabled. [id: %s]", id)
+	}
+	trigger := webhookTY.TaskTrigger{Task: *task, Request: webhookTY.Request{Timestamp: time.Now()}}
+	busUtils.PostToService(a.logger, a.bus, topic.TopicServiceTask, task.ID, trigger, rsTY.TypeTask, rsTY.CommandTrigger, "")
+	return nil
+}
+
+// toScheduleAction runs the schedule now or executes enable, disable and reload actions
+func (a *ActionAPI) toScheduleAction(id, action string) error {
+	if types.GetAction(action) != types.ActionTrigger {
+		return a.toEnableDisableReloadAction(a.api.Schedule(), id, action)
+	}
+	schedule, err := a.api.Schedule().GetByID(id)
+	if err != nil {
+		return err
+	}
+	if !schedule.Enabled {
+		return fmt.Errorf("schedule disabled. [id: %s]", id)
+	}
+	trigger := webhookTY.ScheduleTrigger{Schedule: *schedule, Request: webhookTY.Request{Timestamp: time.Now()}}
+	busUtils.PostToService(a.logger, a.bus, topic.TopicServiceScheduler, schedule.ID, trigger, rsTY.TypeScheduler, rsTY.CommandTrigger, "")
+	return nil
+}
@@ -66,10 +66,10 @@ func (a *ActionAPI) ExecuteActionOnResourceByQuickID(data *handlerTY.ResourceDat
 		return a.toField(kvMap[types.KeyGatewayID], kvMap[types.KeyNodeID], kvMap[types.KeySourceID], kvMap[types.KeyFieldID], data.Payload)
 
 	case quickIdUtils.QuickIdTask:
-		return a.toEnableDisableReloadAction(a.api.Task(), kvMap[types.KeyID], data.Payload)
+		return a.toTaskAction(kvMap[types.KeyID], data.Payload)
 
 	case quickIdUtils.QuickIdSchedule:
-		return a.toEnableDisableReloadAction(a.api.Schedule(), kvMap[types.KeyID], data.Payload)
+		return a.toScheduleAction(kvMap[types.KeyID], data.Payload)
 
 	case quickIdUtils.QuickIdHandler:
 		return a.toEnableDisableReloadAction(a.api.Handler(), kvMap[types.KeyID], data.Payload)
@@ -157,7 +157,7 @@ func (a *ActionAPI) ExecuteActionOnResourceByLabels(data *handlerTY.ResourceData
 		items := result.Data.(*[]taskTY.Config)
 		for index := 0; index < len(*items); index++ {
 			item := (*items)[index]
-			err = a.toEnableDisableReloadAction(a.api.Task(), item.ID, data.Payload)
+			err = a.toTaskAction(item.ID, data.Payload)
 			if err != nil {
 				a.logger.Error("error on sending data", zap.Error(err), zap.String("taskID", item.ID), zap.String("payload", data.Payload))
 			}
@@ -174,7 +174,7 @@ func (a *ActionAPI) ExecuteActionOnResourceByLabels(data *handlerTY.ResourceData
 		items := result.Data.(*[]schedulerTY.Config)
 		for index := 0; index < len(*items); index++ {
 			item := (*items)[index]
-			err = a.toEnableDisableReloadAction(a.api.Schedule(), item.ID, data.Payload)
+			err = a.toScheduleAction(item.ID, data.Payload)
 			if err != nil {
 				a.logger.Error("error on sending data", zap.Error(err), zap.String("scheduleID", item.ID), zap.String("payload", data.Payload))
 			}
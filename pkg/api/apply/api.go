@@ -0,0 +1,382 @@
+package apply
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+
+	entitiesAPI "github.com/mycontroller-org/server/v2/pkg/api/entities"
+	bkpMap "github.com/mycontroller-org/server/v2/pkg/backup"
+	encryptionAPI "github.com/mycontroller-org/server/v2/pkg/encryption"
+	json "github.com/mycontroller-org/server/v2/pkg/json"
+	types "github.com/mycontroller-org/server/v2/pkg/types"
+	dashboardTY "github.com/mycontroller-org/server/v2/pkg/types/dashboard"
+	repositoryTY "github.com/mycontroller-org/server/v2/pkg/types/data_repository"
+	fwdPayloadTY "github.com/mycontroller-org/server/v2/pkg/types/forward_payload"
+	webhookTY "github.com/mycontroller-org/server/v2/pkg/types/inbound_webhook"
+	manifestTY "github.com/mycontroller-org/server/v2/pkg/types/manifest"
+	schedulerTY "github.com/mycontroller-org/server/v2/pkg/types/scheduler"
+	taskTY "github.com/mycontroller-org/server/v2/pkg/types/task"
+	vdTY "github.com/mycontroller-org/server/v2/pkg/types/virtual_device"
+	backupTY "github.com/mycontroller-org/server/v2/plugin/database/storage/backup"
+	storageTY "github.com/mycontroller-org/server/v2/plugin/database/storage/types"
+	gwTY "github.com/mycontroller-org/server/v2/plugin/gateway/types"
+	handlerTY "github.com/mycontroller-org/server/v2/plugin/handler/types"
+	vaTY "github.com/mycontroller-org/server/v2/plugin/virtual_assistant/types"
+	"go.uber.org/zap"
+)
+
+const listLimit = int64(50)
+
+// keys maintained by the server, retained from the existing entity and ignored on the diff
+var ignoredKeys = map[string]bool{
+	"state":      true,
+	"modifiedOn": true,
+	"resources":  true, // virtual device, derived from the traits
+}
+
+// deleter implemented by the entity apis
+type deleter interface {
+	Delete(ids []string) (int64, error)
+}
+
+// reloader implemented by the entity apis, which has a running service
+type reloader interface {
+	Reload(ids []string) error
+}
+
+type ApplyAPI struct {
+	ctx      context.Context
+	logger   *zap.Logger
+	enc      *encryptionAPI.Encryption
+	entities *entitiesAPI.API
+	apiMap   map[string]backupTY.Backup
+}
+
+// entry holds the planned change of a manifest
+type entry struct {
+	kind   string
+	id     string
+	entity interface{} // pointer to the actual entity type
+}
+
+func New(ctx context.Context, logger *zap.Logger, enc *encryptionAPI.Encryption) (*ApplyAPI, error) {
+	entities, err := entitiesAPI.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	apiMap, err := bkpMap.GetStorageApiMap(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &ApplyAPI{
+		ctx:      ctx,
+		logger:   logger.Named("apply_api"),
+		enc:      enc,
+		entities: entities,
+		apiMap:   apiMap,
+	}, nil
+}
+
+// Apply compares the manifests with the entities on the server and returns the plan
+// changes are applied, if it is not a dry run
+func (a *ApplyAPI) Apply(request *manifestTY.ApplyRequest) (*manifestTY.Plan, error) {
+	if request == nil || len(request.Manifests) == 0 {
+		return nil, errors.New("there is no manifest supplied")
+	}
+
+	plan := &manifestTY.Plan{DryRun: request.DryRun, Items: make([]manifestTY.PlanItem, 0)}
+	entries := make([]entry, 0)
+	managed := map[string]map[string]bool{} // kind => ids
+
+	for index := range request.Manifests {
+		manifest := request.Manifests[index]
+		kind := manifestTY.NormalizeKind(manifest.Kind)
+		if !manifestTY.IsSupportedKind(kind) {
+			return nil, fmt.Errorf("unsupported kind '%s'. supported kinds: %v", manifest.Kind, manifestTY.SupportedKinds)
+		}
+		manifest.Kind = kind
+		id := manifest.GetID()
+		if id == "" {
+			return nil, fmt.Errorf("'id' can not be empty. manifest index:%d, kind:%s", index, kind)
+		}
+		if _, found := managed[kind]; !found {
+			managed[kind] = map[string]bool{}
+		}
+		if managed[kind][id] {
+			return nil, fmt.Errorf("duplicate manifest found: %s", manifest.Key())
+		}
+		managed[kind][id] = true
+
+		item, applyEntry, err := a.planManifest(&manifest)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", manifest.Key(), err)
+		}
+		plan.Items = append(plan.Items, *item)
+		if item.Action != manifestTY.ActionUnchanged {
+			entries = append(entries, *applyEntry)
+		}
+	}
+
+	// entities created by the earlier apply and not available on the manifests
+	// all the supported kinds are verified, a kind can be removed entirely from the manifests
+	if request.Prune {
+		kinds := append([]string{}, manifestTY.SupportedKinds...)
+		sort.Strings(kinds)
+		for _, kind := range kinds {
+			ids, err := a.getManagedIDs(kind)
+			if err != nil {
+				return nil, err
+			}
+			for _, id := range ids {
+				if !managed[kind][id] {
+					plan.Items = append(plan.Items, manifestTY.PlanItem{Kind: kind, ID: id, Action: manifestTY.ActionDelete, Changes: []string{}})
+				}
+			}
+		}
+	}
+
+	if request.DryRun || !plan.HasChanges() {
+		return plan, nil
+	}
+
+	a.execute(plan, entries)
+	plan.Applied = true
+	return plan, nil
+}
+
+// planManifest returns the plan item and the entity to be imported
+func (a *ApplyAPI) planManifest(manifest *manifestTY.Manifest) (*manifestTY.PlanItem, *entry, error) {
+	api := a.apiMap[manifest.Kind]
+	id := manifest.GetID()
+
+	existing, err := a.getExisting(api, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// the manifest replaces the existing entity, keys removed from the manifest are removed from the entity.
+	// keys maintained by the server are retained
+	merged := cloneMap(manifest.Spec)
+	for key := range ignoredKeys {
+		if value, found := existing[key]; found {
+			merged[key] = value
+		}
+	}
+
+	// mark the entity as managed by apply, used on prune
+	labels, ok := merged["labels"].(map[string]interface{})
+	if !ok || labels == nil {
+		labels = map[string]interface{}{}
+	}
+	labels[manifestTY.LabelManagedBy] = manifestTY.ManagedByApply
+	merged["labels"] = labels
+
+	// normalize via the actual entity type
+	entity, err := toEntity(api, merged)
+	if err != nil {
+		return nil, nil, err
+	}
+	normalized, err := toMap(entity)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	item := &manifestTY.PlanItem{Kind: manifest.Kind, ID: id}
+	if existing == nil {
+		item.Action = manifestTY.ActionCreate
+		item.Changes = sortedKeys(manifest.Spec)
+	} else {
+		item.Changes = diffKeys("", existing, normalized)
+		if len(item.Changes) == 0 {
+			item.Action = manifestTY.ActionUnchanged
+		} else {
+			item.Action = manifestTY.ActionUpdate
+		}
+	}
+	return item, &entry{kind: manifest.Kind, id: id, entity: entity}, nil
+}
+
+// execute the plan, errors are reported on the plan items
+func (a *ApplyAPI) execute(plan *manifestTY.Plan, entries []entry) {
+	entryMap := map[string]entry{}
+	for _, _entry := range entries {
+		entryMap[fmt.Sprintf("%s/%s", _entry.kind, _entry.id)] = _entry
+	}
+
+	for index := range plan.Items {
+		item := &plan.Items[index]
+		api := a.apiMap[item.Kind]
+
+		switch item.Action {
+		case manifestTY.ActionCreate, manifestTY.ActionUpdate:
+			_entry := entryMap[fmt.Sprintf("%s/%s", item.Kind, item.ID)]
+			// encrypt passwords, tokens
+			err := a.enc.EncryptSecrets(_entry.entity)
+			if err != nil {
+				item.Error = err.Error()
+				continue
+			}
+			err = a.save(_entry.entity)
+			if err != nil {
+				item.Error = err.Error()
+				continue
+			}
+			if _reloader, ok := api.(reloader); ok {
+				err = _reloader.Reload([]string{item.ID})
+				if err != nil {
+					item.Error = err.Error()
+				}
+			}
+
+		case manifestTY.ActionDelete:
+			_deleter, ok := api.(deleter)
+			if !ok {
+				item.Error = fmt.Sprintf("delete not supported on kind '%s'", item.Kind)
+				continue
+			}
+			_, err := _deleter.Delete([]string{item.ID})
+			if err != nil {
+				item.Error = err.Error()
+			}
+		}
+
+		if item.Error != "" {
+			a.logger.Error("error on applying a manifest", zap.String("kind", item.Kind), zap.String("id", item.ID), zap.String("action", item.Action), zap.String("error", item.Error))
+		} else {
+			a.logger.Info("manifest applied", zap.String("kind", item.Kind), zap.String("id", item.ID), zap.String("action", item.Action))
+		}
+	}
+}
+
+// save the entity via the entity api, posts the events and updates the modified timestamp
+func (a *ApplyAPI) save(entity interface{}) error {
+	switch data := entity.(type) {
+	case *gwTY.Config:
+		return a.entities.Gateway().Save(data)
+	case *handlerTY.Config:
+		return a.entities.Handler().Save(data)
+	case *taskTY.Config:
+		return a.entities.Task().Save(data)
+	case *schedulerTY.Config:
+		return a.entities.Schedule().Save(data)
+	case *fwdPayloadTY.Config:
+		return a.entities.ForwardPayload().Save(data)
+	case *vdTY.VirtualDevice:
+		return a.entities.VirtualDevice().Save(data)
+	case *vaTY.Config:
+		return a.entities.VirtualAssistant().Save(data)
+	case *dashboardTY.Config:
+		return a.entities.Dashboard().Save(data)
+	case *repositoryTY.Config:
+		return a.entities.DataRepository().Save(data)
+	case *webhookTY.Config:
+		return a.entities.InboundWebhook().Save(data)
+	default:
+		return fmt.Errorf("save not supported, type:%T", entity)
+	}
+}
+
+// getExisting returns the existing entity as a map with decrypted secrets, nil if not available
+func (a *ApplyAPI) getExisting(api backupTY.Backup, id string) (map[string]interface{}, error) {
+	filters := []storageTY.Filter{{Key: types.KeyID, Operator: storageTY.OperatorEqual, Value: id}}
+	result, err := api.List(filters, &storageTY.Pagination{Limit: 1})
+	if err != nil {
+		return nil, err
+	}
+	entities, err := toEntities(api, result.Data)
+	if err != nil {
+		return nil, err
+	}
+	if len(entities) == 0 {
+		return nil, nil
+	}
+	entity := entities[0]
+	err = a.enc.DecryptSecrets(entity)
+	if err != nil {
+		return nil, err
+	}
+	return toMap(entity)
+}
+
+// getManagedIDs returns ids of the entities managed by apply
+func (a *ApplyAPI) getManagedIDs(kind string) ([]string, error) {
+	api := a.apiMap[kind]
+	ids := make([]string, 0)
+	pagination := &storageTY.Pagination{
+		Limit: listLimit, SortBy: []storageTY.Sort{{Field: types.KeyID, OrderBy: storageTY.SortByASC}},
+	}
+	for offset := int64(0); ; offset += listLimit {
+		pagination.Offset = offset
+		result, err := api.List(nil, pagination)
+		if err != nil {
+			return nil, err
+		}
+		entities, err := toEntities(api, result.Data)
+		if err != nil {
+			return nil, err
+		}
+		for _, entity := range entities {
+			entityMap, err := toMap(entity)
+			if err != nil {
+				return nil, err
+			}
+			labels, _ := entityMap["labels"].(map[string]interface{})
+			if labels[manifestTY.LabelManagedBy] == manifestTY.ManagedByApply {
+				if id, ok := entityMap["id"].(string); ok {
+					ids = append(ids, id)
+				}
+			}
+		}
+		if result.Count <= offset+listLimit || len(entities) == 0 {
+			break
+		}
+	}
+	return ids, nil
+}
+
+// toEntity converts the map to the actual entity type, returns pointer
+func toEntity(api backupTY.Backup, data map[string]interface{}) (interface{}, error) {
+	entity := reflect.New(reflect.TypeOf(api.GetEntityInterface())).Interface()
+	dataBytes, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(dataBytes, entity)
+	if err != nil {
+		return nil, err
+	}
+	return entity, nil
+}
+
+// toEntities converts the storage result data to slice of entity pointers
+func toEntities(api backupTY.Backup, data interface{}) ([]interface{}, error) {
+	entityType := reflect.TypeOf(api.GetEntityInterface())
+	entities := reflect.New(reflect.SliceOf(entityType))
+	dataBytes, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(dataBytes, entities.Interface())
+	if err != nil {
+		return nil, err
+	}
+	items := make([]interface{}, 0)
+	for index := 0; index < entities.Elem().Len(); index++ {
+		items = append(items, entities.Elem().Index(index).Addr().Interface())
+	}
+	return items, nil
+}
+
+// toMap converts the entity into a generic map
+func toMap(entity interface{}) (map[string]interface{}, error) {
+	dataBytes, err := json.Marshal(entity)
+	if err != nil {
+		return nil, err
+	}
+	out := map[string]interface{}{}
+	err = json.Unmarshal(dataBytes, &out)
+	return out, err
+}
@@ -0,0 +1,221 @@
+package apply
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	entityAPI "github.com/mycontroller-org/server/v2/pkg/api/entities"
+	encryptionAPI "github.com/mycontroller-org/server/v2/pkg/encryption"
+	coreScheduler "github.com/mycontroller-org/server/v2/pkg/service/core_scheduler"
+	types "github.com/mycontroller-org/server/v2/pkg/types"
+	"github.com/mycontroller-org/server/v2/pkg/types/cmap"
+	dashboardTY "github.com/mycontroller-org/server/v2/pkg/types/dashboard"
+	manifestTY "github.com/mycontroller-org/server/v2/pkg/types/manifest"
+	schedulerTY "github.com/mycontroller-org/server/v2/pkg/types/scheduler"
+	loggerUtils "github.com/mycontroller-org/server/v2/pkg/utils/logger"
+	"github.com/mycontroller-org/server/v2/plugin/bus/embedded"
+	busTY "github.com/mycontroller-org/server/v2/plugin/bus/types"
+	"github.com/mycontroller-org/server/v2/plugin/database/storage/memory"
+	storageTY "github.com/mycontroller-org/server/v2/plugin/database/storage/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newTestApplyAPI(t *testing.T) *ApplyAPI {
+	ctx := loggerUtils.WithContext(context.Background(), zap.NewNop())
+	ctx = schedulerTY.WithContext(ctx, coreScheduler.New())
+
+	storage, err := memory.New(ctx, cmap.CustomMap{})
+	require.NoError(t, err)
+	ctx = storageTY.WithContext(ctx, storage)
+
+	bus, err := embedded.NewClient(ctx, cmap.CustomMap{})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = bus.Close() })
+	ctx = busTY.WithContext(ctx, bus)
+	enc := encryptionAPI.New(zap.NewNop(), "0123456789abcdef0123456789abcdef", nil, "")
+	ctx = encryptionAPI.WithContext(ctx, enc)
+
+	entities, err := entityAPI.New(ctx)
+	require.NoError(t, err)
+	ctx = entityAPI.WithContext(ctx, entities)
+
+	api, err := New(ctx, zap.NewNop(), enc)
+	require.NoError(t, err)
+	return api
+}
+
+// returns the stored ids of the kind
+func getIDs(t *testing.T, a *ApplyAPI, kind string) []string {
+	result, err := a.apiMap[kind].List(nil, nil)
+	require.NoError(t, err)
+	entities, err := toEntities(a.apiMap[kind], result.Data)
+	require.NoError(t, err)
+	ids := make([]string, 0)
+	for _, entity := range entities {
+		entityMap, err := toMap(entity)
+		require.NoError(t, err)
+		ids = append(ids, entityMap["id"].(string))
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func dashboard(id, description string, labels map[string]interface{}) manifestTY.Manifest {
+	spec := cmap.CustomMap{"id": id, "description": description}
+	if labels != nil {
+		spec["labels"] = labels
+	}
+	return manifestTY.Manifest{Kind: "Dashboard", Spec: spec}
+}
+
+func handler(id, password string) manifestTY.Manifest {
+	return manifestTY.Manifest{Kind: "handler", Spec: cmap.CustomMap{
+		"id": id, "type": "noop", "spec": map[string]interface{}{"password": password},
+	}}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name      string
+		applied   []manifestTY.Manifest // applied earlier
+		unmanaged []string              // dashboards created outside of apply
+		request   manifestTY.ApplyRequest
+		items     []manifestTY.PlanItem
+		isApplied bool
+		stored    map[string][]string // kind => ids, after the apply
+	}{
+		{
+			name:      "create",
+			request:   manifestTY.ApplyRequest{Manifests: []manifestTY.Manifest{dashboard("d1", "a", nil)}},
+			items:     []manifestTY.PlanItem{{Kind: types.EntityDashboard, ID: "d1", Action: manifestTY.ActionCreate, Changes: []string{"description", "id"}}},
+			isApplied: true,
+			stored:    map[string][]string{types.EntityDashboard: {"d1"}},
+		},
+		{
+			name:    "unchanged",
+			applied: []manifestTY.Manifest{dashboard("d1", "a", nil)},
+			request: manifestTY.ApplyRequest{Manifests: []manifestTY.Manifest{dashboard("d1", "a", nil)}},
+			items:   []manifestTY.PlanItem{{Kind: types.EntityDashboard, ID: "d1", Action: manifestTY.ActionUnchanged, Changes: []string{}}},
+			stored:  map[string][]string{types.EntityDashboard: {"d1"}},
+		},
+		{
+			name:      "update",
+			applied:   []manifestTY.Manifest{dashboard("d1", "a", nil)},
+			request:   manifestTY.ApplyRequest{Manifests: []manifestTY.Manifest{dashboard("d1", "b", nil)}},
+			items:     []manifestTY.PlanItem{{Kind: types.EntityDashboard, ID: "d1", Action: manifestTY.ActionUpdate, Changes: []string{"description"}}},
+			isApplied: true,
+		},
+		{
+			name:      "key removed from the manifest",
+			applied:   []manifestTY.Manifest{dashboard("d1", "a", map[string]interface{}{"room": "kitchen"})},
+			request:   manifestTY.ApplyRequest{Manifests: []manifestTY.Manifest{dashboard("d1", "a", nil)}},
+			items:     []manifestTY.PlanItem{{Kind: types.EntityDashboard, ID: "d1", Action: manifestTY.ActionUpdate, Changes: []string{"labels.room"}}},
+			isApplied: true,
+		},
+		{
+			name:    "secrets compared with the decrypted value",
+			applied: []manifestTY.Manifest{handler("h1", "secret")},
+			request: manifestTY.ApplyRequest{Manifests: []manifestTY.Manifest{handler("h1", "secret")}},
+			items:   []manifestTY.PlanItem{{Kind: types.EntityHandler, ID: "h1", Action: manifestTY.ActionUnchanged, Changes: []string{}}},
+		},
+		{
+			name:    "dry run",
+			request: manifestTY.ApplyRequest{DryRun: true, Manifests: []manifestTY.Manifest{dashboard("d1", "a", nil)}},
+			items:   []manifestTY.PlanItem{{Kind: types.EntityDashboard, ID: "d1", Action: manifestTY.ActionCreate, Changes: []string{"description", "id"}}},
+			stored:  map[string][]string{types.EntityDashboard: {}},
+		},
+		{
+			name:      "prune",
+			applied:   []manifestTY.Manifest{dashboard("d1", "a", nil), dashboard("d2", "a", nil)},
+			unmanaged: []string{"d3"},
+			request:   manifestTY.ApplyRequest{Prune: true, Manifests: []manifestTY.Manifest{dashboard("d1", "a", nil)}},
+			items: []manifestTY.PlanItem{
+				{Kind: types.EntityDashboard, ID: "d1", Action: manifestTY.ActionUnchanged, Changes: []string{}},
+				{Kind: types.EntityDashboard, ID: "d2", Action: manifestTY.ActionDelete, Changes: []string{}},
+			},
+			isApplied: true,
+			stored:    map[string][]string{types.EntityDashboard: {"d1", "d3"}},
+		},
+		{
+			name:    "prune a kind not available on the manifests",
+			applied: []manifestTY.Manifest{dashboard("d1", "a", nil), handler("h1", "secret")},
+			request: manifestTY.ApplyRequest{Prune: true, Manifests: []manifestTY.Manifest{dashboard("d1", "a", nil)}},
+			items: []manifestTY.PlanItem{
+				{Kind: types.EntityDashboard, ID: "d1", Action: manifestTY.ActionUnchanged, Changes: []string{}},
+				{Kind: types.EntityHandler, ID: "h1", Action: manifestTY.ActionDelete, Changes: []string{}},
+			},
+			isApplied: true,
+			stored:    map[string][]string{types.EntityDashboard: {"d1"}, types.EntityHandler: {}},
+		},
+		{
+			name:    "prune dry run",
+			applied: []manifestTY.Manifest{dashboard("d1", "a", nil), handler("h1", "secret")},
+			request: manifestTY.ApplyRequest{DryRun: true, Prune: true, Manifests: []manifestTY.Manifest{dashboard("d1", "a", nil)}},
+			items: []manifestTY.PlanItem{
+				{Kind: types.EntityDashboard, ID: "d1", Action: manifestTY.ActionUnchanged, Changes: []string{}},
+				{Kind: types.EntityHandler, ID: "h1", Action: manifestTY.ActionDelete, Changes: []string{}},
+			},
+			stored: map[string][]string{types.EntityDashboard: {"d1"}, types.EntityHandler: {"h1"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a := newTestApplyAPI(t)
+			if len(test.applied) > 0 {
+				plan, err := a.Apply(&manifestTY.ApplyRequest{Manifests: test.applied})
+				require.NoError(t, err)
+				require.True(t, plan.Applied)
+			}
+			for _, id := range test.unmanaged {
+				require.NoError(t, a.entities.Dashboard().Save(&dashboardTY.Config{ID: id}))
+			}
+
+			plan, err := a.Apply(&test.request)
+			require.NoError(t, err)
+			assert.Equal(t, test.items, plan.Items)
+			assert.Equal(t, test.request.DryRun, plan.DryRun)
+			assert.Equal(t, test.isApplied, plan.Applied)
+			for kind, ids := range test.stored {
+				assert.Equal(t, ids, getIDs(t, a, kind), kind)
+			}
+		})
+	}
+}
+
+func TestApplyUpdatesEntity(t *testing.T) {
+	a := newTestApplyAPI(t)
+	_, err := a.Apply(&manifestTY.ApplyRequest{Manifests: []manifestTY.Manifest{dashboard("d1", "a", map[string]interface{}{"room": "kitchen"})}})
+	require.NoError(t, err)
+	_, err = a.Apply(&manifestTY.ApplyRequest{Manifests: []manifestTY.Manifest{dashboard("d1", "b", nil)}})
+	require.NoError(t, err)
+
+	existing, err := a.getExisting(a.apiMap[types.EntityDashboard], "d1")
+	require.NoError(t, err)
+	assert.Equal(t, "b", existing["description"])
+	// only the managed by label, the removed label is not retained
+	assert.Equal(t, map[string]interface{}{manifestTY.LabelManagedBy: manifestTY.ManagedByApply}, existing["labels"])
+}
+
+func TestApplyValidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		manifests []manifestTY.Manifest
+		err       string
+	}{
+		{name: "no manifest", err: "there is no manifest supplied"},
+		{name: "unsupported kind", manifests: []manifestTY.Manifest{{Kind: "user", Spec: cmap.CustomMap{"id": "u1"}}}, err: "unsupported kind 'user'"},
+		{name: "empty id", manifests: []manifestTY.Manifest{{Kind: "dashboard", Spec: cmap.CustomMap{}}}, err: "'id' can not be empty"},
+		{name: "duplicate", manifests: []manifestTY.Manifest{dashboard("d1", "a", nil), dashboard("d1", "b", nil)}, err: "duplicate manifest found: dashboard/d1"},
+	}
+	a := newTestApplyAPI(t)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := a.Apply(&manifestTY.ApplyRequest{Manifests: test.manifests})
+			assert.ErrorContains(t, err, test.err)
+		})
+	}
+}
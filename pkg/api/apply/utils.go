@@ -0,0 +1,106 @@
+package apply
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// cloneMap returns a deep copy of the map
+func cloneMap(src map[string]interface{}) map[string]interface{} {
+	dst := make(map[string]interface{}, len(src))
+	for key, value := range src {
+		if valueMap, ok := toStringMap(value); ok {
+			dst[key] = cloneMap(valueMap)
+		} else {
+			dst[key] = value
+		}
+	}
+	return dst
+}
+
+// diffKeys returns the key paths that differ between the maps
+func diffKeys(prefix string, old, new map[string]interface{}) []string {
+	changes := make([]string, 0)
+	keys := map[string]bool{}
+	for key := range old {
+		keys[key] = true
+	}
+	for key := range new {
+		keys[key] = true
+	}
+
+	for key := range keys {
+		if prefix == "" && ignoredKeys[key] {
+			continue
+		}
+		keyPath := key
+		if prefix != "" {
+			keyPath = fmt.Sprintf("%s.%s", prefix, key)
+		}
+		oldMap, oldIsMap := toStringMap(old[key])
+		newMap, newIsMap := toStringMap(new[key])
+		if oldIsMap && newIsMap {
+			changes = append(changes, diffKeys(keyPath, oldMap, newMap)...)
+			continue
+		}
+		if !isEqual(old[key], new[key]) {
+			changes = append(changes, keyPath)
+		}
+	}
+	sort.Strings(changes)
+	return changes
+}
+
+// isEqual treats nil and empty values as equal
+func isEqual(a, b interface{}) bool {
+	if isEmpty(a) && isEmpty(b) {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func isEmpty(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Map, reflect.Slice, reflect.String:
+		return rv.Len() == 0
+	}
+	return false
+}
+
+func toStringMap(value interface{}) (map[string]interface{}, bool) {
+	switch data := value.(type) {
+	case map[string]interface{}:
+		return data, true
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(data))
+		for key, item := range data {
+			out[fmt.Sprintf("%v", key)] = item
+		}
+		return out, true
+	}
+	// named map types, ex: cmap.CustomMap
+	rv := reflect.ValueOf(value)
+	if rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String {
+		out := make(map[string]interface{}, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			out[iter.Key().String()] = iter.Value().Interface()
+		}
+		return out, true
+	}
+	return nil, false
+}
+
+func sortedKeys(data map[string]interface{}) []string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
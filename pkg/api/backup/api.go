@@ -11,6 +11,7 @@ import (
 	busTY "github.com/mycontroller-org/server/v2/plugin/bus/types"
 	backupTY "github.com/mycontroller-org/server/v2/plugin/database/storage/backup"
 	storageTY "github.com/mycontroller-org/server/v2/plugin/database/storage/types"
+	"github.com/mycontroller-org/server/v2/plugin/handler/backup/remote"
 	"go.uber.org/zap"
 )
 
@@ -88,7 +89,11 @@ func (bk *BackupAPI) Delete(IDs []string) (int64, error) {
 			continue
 		}
 
-		err = utils.RemoveFileOrEmptyDir(exportedFile.FullPath)
+		if remote.IsRemoteProvider(exportedFile.ProviderType) {
+			err = bk.deleteRemoteFile(exportedFile)
+		} else {
+			err = utils.RemoveFileOrEmptyDir(exportedFile.FullPath)
+		}
 		if err != nil {
 			return deletedCount, err
 		}
@@ -97,3 +102,13 @@ func (bk *BackupAPI) Delete(IDs []string) (int64, error) {
 
 	return deletedCount, nil
 }
+
+// deletes a backup file from the remote location
+func (bk *BackupAPI) deleteRemoteFile(file backupTY.BackupFile) error {
+	remoteStorage, err := bk.getRemoteStorage(file.LocationName)
+	if err != nil {
+		return err
+	}
+	defer remoteStorage.Close()
+	return remoteStorage.Delete(file.FileName)
+}
@@ -2,30 +2,88 @@ package importexport
 
 import (
 	"fmt"
+	"path/filepath"
 	"strings"
 
 	types "github.com/mycontroller-org/server/v2/pkg/types"
 	"github.com/mycontroller-org/server/v2/pkg/types/cmap"
 	"github.com/mycontroller-org/server/v2/pkg/types/config"
+	settingsTY "github.com/mycontroller-org/server/v2/pkg/types/settings"
 	"github.com/mycontroller-org/server/v2/pkg/utils"
 	busUtils "github.com/mycontroller-org/server/v2/pkg/utils/bus_utils"
 	backupTY "github.com/mycontroller-org/server/v2/plugin/database/storage/backup"
 	"github.com/mycontroller-org/server/v2/plugin/handler/backup/disk"
+	"github.com/mycontroller-org/server/v2/plugin/handler/backup/remote"
 	backupUtil "github.com/mycontroller-org/server/v2/plugin/handler/backup/util"
 	handlerTY "github.com/mycontroller-org/server/v2/plugin/handler/types"
 	"go.uber.org/zap"
 )
 
 // RunRestore func
-func (bk *BackupAPI) RunRestore(file backupTY.BackupFile) error {
+// an incremental backup restored with the latest full backup and the incremental backups in between
+func (bk *BackupAPI) RunRestore(file backupTY.BackupFile, restoreCfg *backupTY.RestoreConfig) error {
 	bk.logger.Info("restore data request received", zap.Any("backupFile", file))
 
 	dirDataInternal := types.GetEnvString(types.ENV_DIR_DATA_INTERNAL)
 	if dirDataInternal == "" {
 		return fmt.Errorf("environment '%s' not set", types.ENV_DIR_DATA_INTERNAL)
 	}
+	if restoreCfg == nil {
+		restoreCfg = &backupTY.RestoreConfig{}
+	}
+
+	files, err := bk.getRestoreFiles(file)
+	if err != nil {
+		return err
+	}
+
+	var remoteStorage remote.Storage
+	if remote.IsRemoteProvider(file.ProviderType) {
+		remoteStorage, err = bk.getRemoteStorage(file.LocationName)
+		if err != nil {
+			return err
+		}
+		defer remoteStorage.Close()
+	}
+
+	// downloaded and decrypted files removed once extracted
+	tmpFiles := make([]string, 0)
+	defer func() {
+		for _, tmpFile := range tmpFiles {
+			if err := utils.RemoveFileOrEmptyDir(tmpFile); err != nil {
+				bk.logger.Error("error on removing temporary backup file", zap.Error(err), zap.String("filename", tmpFile))
+			}
+		}
+	}()
+
+	zipFiles := make([]string, 0, len(files))
+	for _, restoreFile := range files {
+		localFile := restoreFile.FullPath
+		if remoteStorage != nil {
+			// download the file from the remote location
+			localFile = filepath.Join(dirDataInternal, restoreFile.FileName)
+			tmpFiles = append(tmpFiles, localFile)
+			err = remoteStorage.Download(restoreFile.FileName, localFile)
+			if err != nil {
+				bk.logger.Error("error on downloading backup file", zap.Error(err), zap.Any("file", restoreFile))
+				return err
+			}
+		}
+
+		if restoreFile.Encrypted {
+			decryptedFile := filepath.Join(dirDataInternal, strings.TrimSuffix(restoreFile.FileName, backupUtil.EncryptedFileExtension))
+			tmpFiles = append(tmpFiles, decryptedFile)
+			err = backupUtil.DecryptFile(localFile, decryptedFile, restoreCfg.Passphrase, restoreCfg.Identity)
+			if err != nil {
+				bk.logger.Error("error on decrypting backup file", zap.Error(err), zap.Any("file", restoreFile))
+				return err
+			}
+			localFile = decryptedFile
+		}
+		zipFiles = append(zipFiles, localFile)
+	}
 
-	err := bk.backupRestore.ExtractExportedZipFile(file.FullPath, dirDataInternal, dirDataInternal, config.SystemStartJobsFilename)
+	err = bk.backupRestore.ExtractExportedZipFiles(zipFiles, dirDataInternal, dirDataInternal, config.SystemStartJobsFilename)
 	if err != nil {
 		bk.logger.Error("error on extract", zap.Error(err), zap.Any("file", file))
 		return err
@@ -37,6 +95,38 @@ func (bk *BackupAPI) RunRestore(file backupTY.BackupFile) error {
 	return nil
 }
 
+// returns the files required to restore the backup file, in the order of restore
+func (bk *BackupAPI) getRestoreFiles(file backupTY.BackupFile) ([]backupTY.BackupFile, error) {
+	if file.Mode != backupUtil.ModeIncremental {
+		return []backupTY.BackupFile{file}, nil
+	}
+
+	rawFiles, err := bk.GetBackupFilesList()
+	if err != nil {
+		return nil, err
+	}
+	locationFiles := map[string]backupTY.BackupFile{}
+	names := make([]types.File, 0)
+	for _, rawFile := range rawFiles {
+		backupFile, ok := rawFile.(backupTY.BackupFile)
+		if !ok || backupFile.LocationName != file.LocationName || backupFile.Directory != file.Directory {
+			continue
+		}
+		locationFiles[backupFile.FileName] = backupFile
+		names = append(names, types.File{Name: backupFile.FileName})
+	}
+
+	chain, err := backupUtil.GetRestoreChain(names, file.FileName)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]backupTY.BackupFile, 0, len(chain))
+	for _, name := range chain {
+		files = append(files, locationFiles[name])
+	}
+	return files, nil
+}
+
 // triggers on demand export
 func (bk *BackupAPI) RunOnDemandBackup(input *backupTY.OnDemandBackupConfig) error {
 	bk.logger.Debug("on-demand backup request received", zap.Any("config", input))
@@ -97,10 +187,80 @@ func (bk *BackupAPI) GetBackupFilesList() ([]interface{}, error) {
 					FullPath:     rawFile.FullPath,
 					ModifiedOn:   rawFile.ModifiedTime,
 				}
+				updateFileDetails(&exportedFile)
 				exportedFiles = append(exportedFiles, exportedFile)
 			}
+		} else if remote.IsRemoteProvider(location.Type) {
+			// an unreachable remote location should not hide the files from other locations
+			files, err := bk.getRemoteFiles(location)
+			if err != nil {
+				bk.logger.Error("error on listing remote backup files", zap.String("location", location.Name), zap.String("providerType", location.Type), zap.Error(err))
+				continue
+			}
+			exportedFiles = append(exportedFiles, files...)
 		}
 	}
 
 	return exportedFiles, nil
 }
+
+// returns backup files from a remote location
+func (bk *BackupAPI) getRemoteFiles(location settingsTY.BackupLocation) ([]interface{}, error) {
+	remoteStorage, err := remote.NewStorage(location.Type, location.Config)
+	if err != nil {
+		return nil, err
+	}
+	defer remoteStorage.Close()
+
+	rawFiles, err := remoteStorage.List()
+	if err != nil {
+		return nil, err
+	}
+
+	exportedFiles := make([]interface{}, 0)
+	for _, rawFile := range rawFiles {
+		if !strings.Contains(rawFile.Name, backupUtil.BackupIdentifier) {
+			continue
+		}
+		exportedFile := backupTY.BackupFile{
+			ID:           fmt.Sprintf("%s:%s", location.Name, rawFile.FullPath),
+			LocationName: location.Name,
+			ProviderType: location.Type,
+			Directory:    location.Config.GetString("directory"),
+			FileName:     rawFile.Name,
+			FileSize:     rawFile.Size,
+			FullPath:     rawFile.FullPath,
+			ModifiedOn:   rawFile.ModifiedTime,
+		}
+		updateFileDetails(&exportedFile)
+		exportedFiles = append(exportedFiles, exportedFile)
+	}
+	return exportedFiles, nil
+}
+
+// returns remote storage client of the backup location
+func (bk *BackupAPI) getRemoteStorage(locationName string) (remote.Storage, error) {
+	locationsSettings, err := bk.settingsAPI.GetBackupLocations()
+	if err != nil {
+		return nil, err
+	}
+	for _, location := range locationsSettings.Locations {
+		if location.Name == locationName {
+			return remote.NewStorage(location.Type, location.Config)
+		}
+	}
+	return nil, fmt.Errorf("backup location '%s' not found", locationName)
+}
+
+// updates mode and encryption details from the filename
+func updateFileDetails(file *backupTY.BackupFile) {
+	file.Mode = backupUtil.ModeFull
+	details, ok := backupUtil.ParseFilename(file.FileName)
+	if !ok {
+		return
+	}
+	if details.Incremental {
+		file.Mode = backupUtil.ModeIncremental
+	}
+	file.Encrypted = details.Encrypted
+}
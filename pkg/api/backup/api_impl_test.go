@@ -0,0 +1,198 @@
+package importexport
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"path"
+	"sort"
+	"testing"
+	"time"
+
+	entityAPI "github.com/mycontroller-org/server/v2/pkg/api/entities"
+	encryptionAPI "github.com/mycontroller-org/server/v2/pkg/encryption"
+	coreScheduler "github.com/mycontroller-org/server/v2/pkg/service/core_scheduler"
+	"github.com/mycontroller-org/server/v2/pkg/types"
+	"github.com/mycontroller-org/server/v2/pkg/types/cmap"
+	"github.com/mycontroller-org/server/v2/pkg/types/config"
+	dashboardTY "github.com/mycontroller-org/server/v2/pkg/types/dashboard"
+	schedulerTY "github.com/mycontroller-org/server/v2/pkg/types/scheduler"
+	settingsTY "github.com/mycontroller-org/server/v2/pkg/types/settings"
+	loggerUtils "github.com/mycontroller-org/server/v2/pkg/utils/logger"
+	"github.com/mycontroller-org/server/v2/plugin/bus/embedded"
+	busTY "github.com/mycontroller-org/server/v2/plugin/bus/types"
+	backupTY "github.com/mycontroller-org/server/v2/plugin/database/storage/backup"
+	"github.com/mycontroller-org/server/v2/plugin/database/storage/memory"
+	storageTY "github.com/mycontroller-org/server/v2/plugin/database/storage/types"
+	"github.com/mycontroller-org/server/v2/plugin/handler/backup/remote"
+	backupUtil "github.com/mycontroller-org/server/v2/plugin/handler/backup/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"golang.org/x/net/webdav"
+	"gopkg.in/yaml.v3"
+)
+
+const testLocation = "remote"
+
+// returns the backup api with a webdav backup location, holds a full and an incremental backup
+func newTestBackupAPI(t *testing.T, passphrase string) *BackupAPI {
+	t.Setenv(types.ENV_DIR_DATA_FIRMWARE, t.TempDir())
+	ctx := loggerUtils.WithContext(context.Background(), zap.NewNop())
+	ctx = schedulerTY.WithContext(ctx, coreScheduler.New())
+
+	storage, err := memory.New(ctx, cmap.CustomMap{})
+	require.NoError(t, err)
+	ctx = storageTY.WithContext(ctx, storage)
+
+	bus, err := embedded.NewClient(ctx, cmap.CustomMap{})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = bus.Close() })
+	ctx = busTY.WithContext(ctx, bus)
+	enc := encryptionAPI.New(zap.NewNop(), "0123456789abcdef0123456789abcdef", nil, "")
+	ctx = encryptionAPI.WithContext(ctx, enc)
+
+	entities, err := entityAPI.New(ctx)
+	require.NoError(t, err)
+	ctx = entityAPI.WithContext(ctx, entities)
+
+	// in-process webdav server
+	server := httptest.NewServer(&webdav.Handler{FileSystem: webdav.NewMemFS(), LockSystem: webdav.NewMemLS()})
+	t.Cleanup(server.Close)
+	locationConfig := cmap.CustomMap{"url": server.URL, "directory": "backups"}
+
+	backupRestore, err := backupTY.New(ctx, map[string]string{})
+	require.NoError(t, err)
+	bk := New(ctx, zap.NewNop(), backupRestore, storage, bus, enc)
+	err = bk.settingsAPI.UpdateSettings(&settingsTY.Settings{
+		ID: settingsTY.KeySystemBackupLocations,
+		Spec: cmap.CustomMap{"locations": []settingsTY.BackupLocation{
+			{Name: testLocation, Type: backupUtil.ProviderWebDAV, Config: locationConfig},
+		}},
+	})
+	require.NoError(t, err)
+
+	remoteStorage, err := remote.NewStorage(backupUtil.ProviderWebDAV, locationConfig)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = remoteStorage.Close() })
+
+	options := backupUtil.Options{Encryption: &backupUtil.EncryptionConfig{Passphrase: passphrase}}
+	backupDir := t.TempDir()
+	for _, dashboardID := range []string{"full", "incremental"} {
+		require.NoError(t, entities.Dashboard().Save(&dashboardTY.Config{ID: dashboardID}))
+		filename, err := backupUtil.Backup(ctx, zap.NewNop(), backupDir, "test", backupTY.TypeYAML, false, false, storage, bus, options)
+		require.NoError(t, err)
+		require.NoError(t, remoteStorage.Upload(filename, path.Base(filename)))
+		since := time.Now()
+		options.Since = &since
+	}
+	return bk
+}
+
+// returns the backup file of the mode
+func getBackupFile(t *testing.T, bk *BackupAPI, mode string) backupTY.BackupFile {
+	files, err := bk.GetBackupFilesList()
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	for _, rawFile := range files {
+		file := rawFile.(backupTY.BackupFile)
+		if file.Mode == mode {
+			return file
+		}
+	}
+	require.FailNow(t, "backup file not found", mode)
+	return backupTY.BackupFile{}
+}
+
+func TestRunRestoreFromRemote(t *testing.T) {
+	tests := []struct {
+		name       string
+		passphrase string
+		mode       string
+		restoreCfg *backupTY.RestoreConfig
+		extracted  []string // extracted directories of the files, in the order of restore
+		err        string
+	}{
+		{
+			name:      "full backup",
+			mode:      backupUtil.ModeFull,
+			extracted: []string{"full"},
+		},
+		{
+			name:      "incremental backup with the full backup",
+			mode:      backupUtil.ModeIncremental,
+			extracted: []string{"full", "incremental"},
+		},
+		{
+			name:       "encrypted incremental backup",
+			passphrase: "backup_passphrase",
+			mode:       backupUtil.ModeIncremental,
+			restoreCfg: &backupTY.RestoreConfig{Passphrase: "backup_passphrase"},
+			extracted:  []string{"full", "incremental"},
+		},
+		{
+			name:       "invalid passphrase",
+			passphrase: "backup_passphrase",
+			mode:       backupUtil.ModeFull,
+			restoreCfg: &backupTY.RestoreConfig{Passphrase: "invalid"},
+			err:        "incorrect passphrase",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dirDataInternal := t.TempDir()
+			t.Setenv(types.ENV_DIR_DATA_INTERNAL, dirDataInternal)
+			bk := newTestBackupAPI(t, test.passphrase)
+			file := getBackupFile(t, bk, test.mode)
+			assert.Equal(t, test.passphrase != "", file.Encrypted)
+
+			err := bk.RunRestore(file, test.restoreCfg)
+			if test.err != "" {
+				assert.ErrorContains(t, err, test.err)
+				// downloaded files removed
+				entries, err := os.ReadDir(dirDataInternal)
+				require.NoError(t, err)
+				assert.Empty(t, entries)
+				return
+			}
+			require.NoError(t, err)
+
+			data, err := os.ReadFile(path.Join(dirDataInternal, config.SystemStartJobsFilename))
+			require.NoError(t, err)
+			startupJobs := &config.SystemStartupJobs{}
+			require.NoError(t, yaml.Unmarshal(data, startupJobs))
+			assert.True(t, startupJobs.Restore.Enabled)
+
+			extracted := append([]string{startupJobs.Restore.ExtractedDirectory}, startupJobs.Restore.IncrementalDirectories...)
+			require.Len(t, extracted, len(test.extracted))
+			for index, directory := range extracted {
+				assert.Equal(t, dirDataInternal, path.Dir(directory))
+				details, ok := backupUtil.ParseFilename(path.Base(directory) + ".zip")
+				require.True(t, ok, directory)
+				assert.Equal(t, test.extracted[index] == "incremental", details.Incremental, directory)
+				assert.DirExists(t, directory)
+			}
+
+			// downloaded and decrypted files removed
+			entries, err := os.ReadDir(dirDataInternal)
+			require.NoError(t, err)
+			names := make([]string, 0)
+			for _, entry := range entries {
+				if !entry.IsDir() {
+					names = append(names, entry.Name())
+				}
+			}
+			sort.Strings(names)
+			assert.Equal(t, []string{config.SystemStartJobsFilename}, names)
+		})
+	}
+}
+
+func TestRunRestoreUnknownLocation(t *testing.T) {
+	t.Setenv(types.ENV_DIR_DATA_INTERNAL, t.TempDir())
+	bk := newTestBackupAPI(t, "")
+	file := getBackupFile(t, bk, backupUtil.ModeFull)
+	file.LocationName = "unknown"
+	assert.EqualError(t, bk.RunRestore(file, nil), "backup location 'unknown' not found")
+}
@@ -3,6 +3,7 @@ package dashboard
 import (
 	"context"
 	"fmt"
+	"time"
 
 	types "github.com/mycontroller-org/server/v2/pkg/types"
 	dashboardTY "github.com/mycontroller-org/server/v2/pkg/types/dashboard"
@@ -43,6 +44,7 @@ func (d *DashboardAPI) Save(dashboard *dashboardTY.Config) error {
 	if dashboard.ID == "" {
 		dashboard.ID = utils.RandUUID()
 	}
+	dashboard.ModifiedOn = time.Now()
 	filters := []storageTY.Filter{
 		{Key: types.KeyID, Value: dashboard.ID},
 	}
@@ -67,6 +69,7 @@ func (d *DashboardAPI) Import(data interface{}) error {
 		{Key: types.KeyID, Value: input.ID},
 	}
 
+	input.ModifiedOn = time.Now()
 	return d.storage.Upsert(types.EntityDashboard, &input, filters)
 }
 
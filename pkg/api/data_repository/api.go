@@ -141,6 +141,7 @@ func (dr *DataRepositoryAPI) Import(data interface{}) error {
 		{Key: types.KeyID, Value: input.ID},
 	}
 
+	input.ModifiedOn = time.Now()
 	return dr.storage.Upsert(types.EntityDataRepository, &input, filters)
 }
 
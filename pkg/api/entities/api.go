@@ -11,6 +11,7 @@ import (
 	forwardPayload "github.com/mycontroller-org/server/v2/pkg/api/forward_payload"
 	gateway "github.com/mycontroller-org/server/v2/pkg/api/gateway"
 	handler "github.com/mycontroller-org/server/v2/pkg/api/handler"
+	inboundWebhook "github.com/mycontroller-org/server/v2/pkg/api/inbound_webhook"
 	node "github.com/mycontroller-org/server/v2/pkg/api/node"
 	schedule "github.com/mycontroller-org/server/v2/pkg/api/schedule"
 	serviceToken "github.com/mycontroller-org/server/v2/pkg/api/service_token"
@@ -112,6 +113,10 @@ func (a *API) Handler() *handler.HandlerAPI {
 	return handler.New(a.ctx, a.logger, a.storage, a.enc, a.bus)
 }
 
+func (a *API) InboundWebhook() *inboundWebhook.InboundWebhookAPI {
+	return inboundWebhook.New(a.ctx, a.logger, a.storage, a.enc, a.bus)
+}
+
 func (a *API) Node() *node.NodeAPI {
 	return node.New(a.ctx, a.logger, a.storage, a.bus)
 }
@@ -148,7 +153,7 @@ func (a *API) User() *user.UserAPI {
 }
 
 func (a *API) VirtualAssistant() *virtualAssistant.VirtualAssistantAPI {
-	return virtualAssistant.New(a.ctx, a.logger, a.storage, a.bus)
+	return virtualAssistant.New(a.ctx, a.logger, a.storage, a.enc, a.bus)
 }
 
 func (a *API) VirtualDevice() *virtualDevice.VirtualDeviceAPI {
@@ -3,6 +3,7 @@ package field
 import (
 	"context"
 	"fmt"
+	"time"
 
 	types "github.com/mycontroller-org/server/v2/pkg/types"
 	eventTY "github.com/mycontroller-org/server/v2/pkg/types/event"
@@ -73,6 +74,7 @@ func (f *FieldAPI) Save(field *fieldTY.Field, retainValue bool) error {
 		field.Current = fieldOrg.Current
 		field.Previous = fieldOrg.Previous
 	}
+	field.ModifiedOn = time.Now()
 	err := f.storage.Upsert(types.EntityField, field, filters)
 	if err != nil {
 		return err
@@ -133,6 +135,7 @@ func (f *FieldAPI) Import(data interface{}) error {
 		{Key: types.KeyID, Value: input.ID},
 	}
 
+	input.ModifiedOn = time.Now()
 	return f.storage.Upsert(types.EntityField, &input, filters)
 }
 
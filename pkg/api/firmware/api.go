@@ -210,6 +210,7 @@ func (fw *FirmwareAPI) Import(data interface{}) error {
 		{Key: types.KeyID, Value: input.ID},
 	}
 
+	input.ModifiedOn = time.Now()
 	return fw.storage.Upsert(types.EntityFirmware, &input, filters)
 }
 
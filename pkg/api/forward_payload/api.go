@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	types "github.com/mycontroller-org/server/v2/pkg/types"
 	eventTY "github.com/mycontroller-org/server/v2/pkg/types/event"
@@ -52,6 +53,7 @@ func (fpl *ForwardPayloadAPI) Save(fp *fwdPayloadTY.Config) error {
 		fp.ID = utils.RandUUID()
 		eventType = eventTY.TypeCreated
 	}
+	fp.ModifiedOn = time.Now()
 	filters := []storageTY.Filter{
 		{Key: types.KeyID, Value: fp.ID},
 	}
@@ -123,6 +125,7 @@ func (fpl *ForwardPayloadAPI) Import(data interface{}) error {
 		return errors.New("'id' can not be empty")
 	}
 
+	input.ModifiedOn = time.Now()
 	filters := []storageTY.Filter{
 		{Key: types.KeyID, Value: input.ID},
 	}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	encryptionAPI "github.com/mycontroller-org/server/v2/pkg/encryption"
 	types "github.com/mycontroller-org/server/v2/pkg/types"
@@ -93,6 +94,7 @@ func (gw *GatewayAPI) Save(gwCfg *gwTY.Config) error {
 		return err
 	}
 
+	gwCfg.ModifiedOn = time.Now()
 	err = gw.storage.Upsert(types.EntityGateway, gwCfg, nil)
 	if err != nil {
 		return err
@@ -147,6 +149,7 @@ func (gw *GatewayAPI) Import(data interface{}) error {
 		return errors.New("'id' can not be empty")
 	}
 
+	input.ModifiedOn = time.Now()
 	filters := []storageTY.Filter{
 		{Key: types.KeyID, Value: input.ID},
 	}
@@ -26,9 +26,8 @@ func (gw *GatewayAPI) GetGatewaySleepingQueue(gatewayID string) (map[string][]ms
 	}
 
 	messages := make(map[string][]msgTY.Message)
-	onReceive := func(item interface{}) bool { return false }
 
-	err := query.QueryService(gw.logger, gw.bus, topic.TopicServiceGateway, "", rsTY.TypeGateway, rsTY.CommandGetSleepingQueue, ids, onReceive, &messages, queryTimeout)
+	err := query.QueryService(gw.logger, gw.bus, topic.TopicServiceGateway, "", rsTY.TypeGateway, rsTY.CommandGetSleepingQueue, ids, &messages, queryTimeout)
 	if err != nil {
 		return nil, err
 	}
@@ -46,9 +45,8 @@ func (gw *GatewayAPI) GetNodeSleepingQueue(gatewayID, nodeID string) ([]msgTY.Me
 	}
 
 	messages := make([]msgTY.Message, 0)
-	onReceive := func(item interface{}) bool { return false }
 
-	err := query.QueryService(gw.logger, gw.bus, topic.TopicServiceGateway, "", rsTY.TypeGateway, rsTY.CommandGetSleepingQueue, ids, onReceive, &messages, queryTimeout)
+	err := query.QueryService(gw.logger, gw.bus, topic.TopicServiceGateway, "", rsTY.TypeGateway, rsTY.CommandGetSleepingQueue, ids, &messages, queryTimeout)
 	if err != nil {
 		return nil, err
 	}
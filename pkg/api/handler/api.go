@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	encryptionAPI "github.com/mycontroller-org/server/v2/pkg/encryption"
 	types "github.com/mycontroller-org/server/v2/pkg/types"
@@ -72,6 +73,7 @@ func (h *HandlerAPI) Save(cfg *handlerTY.Config) error {
 		return err
 	}
 
+	cfg.ModifiedOn = time.Now()
 	filters := []storageTY.Filter{
 		{Key: types.KeyID, Value: cfg.ID},
 	}
@@ -133,6 +135,7 @@ func (h *HandlerAPI) Import(data interface{}) error {
 		return errors.New("'id' can not be empty")
 	}
 
+	input.ModifiedOn = time.Now()
 	filters := []storageTY.Filter{
 		{Key: types.KeyID, Value: input.ID},
 	}
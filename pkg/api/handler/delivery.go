@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"errors"
+	"time"
+
+	rsTY "github.com/mycontroller-org/server/v2/pkg/types/resource_service"
+	"github.com/mycontroller-org/server/v2/pkg/types/topic"
+	busUtils "github.com/mycontroller-org/server/v2/pkg/utils/bus_utils"
+	"github.com/mycontroller-org/server/v2/pkg/utils/bus_utils/query"
+	handlerTY "github.com/mycontroller-org/server/v2/plugin/handler/types"
+)
+
+const (
+	queryTimeout = time.Second * 2
+)
+
+// Limitations: if multiple handler services are running,
+// for now shows only the first received data from a handler service
+
+// ListDeadLetters returns the failed deliveries, filtered by handler id if supplied
+func (h *HandlerAPI) ListDeadLetters(handlerID string) ([]handlerTY.Delivery, error) {
+	deadLetters := make([]handlerTY.Delivery, 0)
+
+	err := query.QueryService(h.logger, h.bus, topic.TopicServiceHandler, handlerID, rsTY.TypeHandlerDelivery, rsTY.CommandList, nil, &deadLetters, queryTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return deadLetters, nil
+}
+
+// GetDeliveryStats returns the delivery stats of the handlers
+func (h *HandlerAPI) GetDeliveryStats() ([]handlerTY.DeliveryStats, error) {
+	stats := make([]handlerTY.DeliveryStats, 0)
+
+	err := query.QueryService(h.logger, h.bus, topic.TopicServiceHandler, "", rsTY.TypeHandlerDelivery, rsTY.CommandStats, nil, &stats, queryTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// RetryDeadLetters sends the dead letters again with the fresh attempts
+func (h *HandlerAPI) RetryDeadLetters(ids []string) error {
+	if len(ids) == 0 {
+		return errors.New("supply dead letter id(s)")
+	}
+	busUtils.PostToService(h.logger, h.bus, topic.TopicServiceHandler, "", ids, rsTY.TypeHandlerDelivery, rsTY.CommandRetry, "")
+	return nil
+}
+
+// DiscardDeadLetters removes the dead letters
+func (h *HandlerAPI) DiscardDeadLetters(ids []string) error {
+	if len(ids) == 0 {
+		return errors.New("supply dead letter id(s)")
+	}
+	busUtils.PostToService(h.logger, h.bus, topic.TopicServiceHandler, "", ids, rsTY.TypeHandlerDelivery, rsTY.CommandRemove, "")
+	return nil
+}
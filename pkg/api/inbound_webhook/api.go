@@ -0,0 +1,172 @@
+package inboundwebhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	encryptionAPI "github.com/mycontroller-org/server/v2/pkg/encryption"
+	types "github.com/mycontroller-org/server/v2/pkg/types"
+	eventTY "github.com/mycontroller-org/server/v2/pkg/types/event"
+	webhookTY "github.com/mycontroller-org/server/v2/pkg/types/inbound_webhook"
+	"github.com/mycontroller-org/server/v2/pkg/types/topic"
+	"github.com/mycontroller-org/server/v2/pkg/utils"
+	busUtils "github.com/mycontroller-org/server/v2/pkg/utils/bus_utils"
+	quickIdUtils "github.com/mycontroller-org/server/v2/pkg/utils/quick_id"
+	busTY "github.com/mycontroller-org/server/v2/plugin/bus/types"
+	storageTY "github.com/mycontroller-org/server/v2/plugin/database/storage/types"
+	"go.uber.org/zap"
+)
+
+type InboundWebhookAPI struct {
+	ctx     context.Context
+	logger  *zap.Logger
+	storage storageTY.Plugin
+	enc     *encryptionAPI.Encryption
+	bus     busTY.Plugin
+}
+
+func New(ctx context.Context, logger *zap.Logger, storage storageTY.Plugin, enc *encryptionAPI.Encryption, bus busTY.Plugin) *InboundWebhookAPI {
+	return &InboundWebhookAPI{
+		ctx:     ctx,
+		logger:  logger.Named("inbound_webhook_api"),
+		storage: storage,
+		enc:     enc,
+		bus:     bus,
+	}
+}
+
+// List by filter and pagination
+func (iw *InboundWebhookAPI) List(filters []storageTY.Filter, pagination *storageTY.Pagination) (*storageTY.Result, error) {
+	result := make([]webhookTY.Config, 0)
+	return iw.storage.Find(types.EntityInboundWebhook, &result, filters, pagination)
+}
+
+// Get returns a item
+func (iw *InboundWebhookAPI) Get(filters []storageTY.Filter) (*webhookTY.Config, error) {
+	result := &webhookTY.Config{}
+	err := iw.storage.FindOne(types.EntityInboundWebhook, result, filters)
+	return result, err
+}
+
+// GetByID returns a item by id
+func (iw *InboundWebhookAPI) GetByID(id string) (*webhookTY.Config, error) {
+	filters := []storageTY.Filter{
+		{Key: types.KeyID, Value: id},
+	}
+	return iw.Get(filters)
+}
+
+// Save a item details
+func (iw *InboundWebhookAPI) Save(cfg *webhookTY.Config) error {
+	_, err := iw.SaveAndGetToken(cfg)
+	return err
+}
+
+// SaveAndGetToken saves the item and returns the token, if it was generated on this call.
+// the token is stored encrypted, the generated token can not be learned later
+func (iw *InboundWebhookAPI) SaveAndGetToken(cfg *webhookTY.Config) (string, error) {
+	if cfg.ID == "" {
+		return "", errors.New("'id' can not be empty")
+	}
+	switch cfg.Action {
+	case webhookTY.ActionTask, webhookTY.ActionSchedule, webhookTY.ActionField:
+	default:
+		return "", fmt.Errorf("unknown action: %s", cfg.Action)
+	}
+	if cfg.TargetID == "" {
+		return "", errors.New("'targetId' can not be empty")
+	}
+	if cfg.Action == webhookTY.ActionField {
+		if _, _, err := quickIdUtils.EntityKeyValueMap(cfg.TargetID); err != nil {
+			return "", err
+		}
+	}
+	generatedToken := ""
+	if cfg.Token == "" {
+		generatedToken = utils.RandUUID()
+		cfg.Token = generatedToken
+	}
+
+	eventType := eventTY.TypeUpdated
+	existing, err := iw.GetByID(cfg.ID)
+	if err != nil || existing.ID == "" {
+		eventType = eventTY.TypeCreated
+	}
+
+	cfg.ModifiedOn = time.Now()
+
+	// encrypt the token
+	err = iw.enc.EncryptSecrets(cfg)
+	if err != nil {
+		return "", err
+	}
+
+	filters := []storageTY.Filter{
+		{Key: types.KeyID, Value: cfg.ID},
+	}
+	err = iw.storage.Upsert(types.EntityInboundWebhook, cfg, filters)
+	if err != nil {
+		return "", err
+	}
+	busUtils.PostEvent(iw.logger, iw.bus, topic.TopicEventInboundWebhook, eventType, types.EntityInboundWebhook, cfg)
+	return generatedToken, nil
+}
+
+// Delete items
+func (iw *InboundWebhookAPI) Delete(IDs []string) (int64, error) {
+	filters := []storageTY.Filter{{Key: types.KeyID, Operator: storageTY.OperatorIn, Value: IDs}}
+	return iw.storage.Delete(types.EntityInboundWebhook, filters)
+}
+
+// Enable inbound webhooks
+func (iw *InboundWebhookAPI) Enable(ids []string) error {
+	return iw.setEnabled(ids, true)
+}
+
+// Disable inbound webhooks
+func (iw *InboundWebhookAPI) Disable(ids []string) error {
+	return iw.setEnabled(ids, false)
+}
+
+func (iw *InboundWebhookAPI) setEnabled(ids []string, enabled bool) error {
+	filters := []storageTY.Filter{{Key: types.KeyID, Operator: storageTY.OperatorIn, Value: ids}}
+	pagination := &storageTY.Pagination{Limit: 100}
+	response, err := iw.List(filters, pagination)
+	if err != nil {
+		return err
+	}
+	webhooks := *response.Data.(*[]webhookTY.Config)
+	for index := 0; index < len(webhooks); index++ {
+		webhook := webhooks[index]
+		if webhook.Enabled != enabled {
+			webhook.Enabled = enabled
+			err = iw.Save(&webhook)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (iw *InboundWebhookAPI) Import(data interface{}) error {
+	input, ok := data.(webhookTY.Config)
+	if !ok {
+		return fmt.Errorf("invalid type:%T", data)
+	}
+	if input.ID == "" {
+		return errors.New("'id' can not be empty")
+	}
+
+	input.ModifiedOn = time.Now()
+	filters := []storageTY.Filter{
+		{Key: types.KeyID, Value: input.ID},
+	}
+	return iw.storage.Upsert(types.EntityInboundWebhook, &input, filters)
+}
+
+func (iw *InboundWebhookAPI) GetEntityInterface() interface{} {
+	return webhookTY.Config{}
+}
@@ -0,0 +1,187 @@
+package inboundwebhook
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	encryptionAPI "github.com/mycontroller-org/server/v2/pkg/encryption"
+	coreScheduler "github.com/mycontroller-org/server/v2/pkg/service/core_scheduler"
+	types "github.com/mycontroller-org/server/v2/pkg/types"
+	"github.com/mycontroller-org/server/v2/pkg/types/cmap"
+	webhookTY "github.com/mycontroller-org/server/v2/pkg/types/inbound_webhook"
+	rsTY "github.com/mycontroller-org/server/v2/pkg/types/resource_service"
+	schedulerTY "github.com/mycontroller-org/server/v2/pkg/types/scheduler"
+	taskTY "github.com/mycontroller-org/server/v2/pkg/types/task"
+	"github.com/mycontroller-org/server/v2/pkg/types/topic"
+	loggerUtils "github.com/mycontroller-org/server/v2/pkg/utils/logger"
+	"github.com/mycontroller-org/server/v2/plugin/bus/embedded"
+	busTY "github.com/mycontroller-org/server/v2/plugin/bus/types"
+	"github.com/mycontroller-org/server/v2/plugin/database/storage/memory"
+	handlerTY "github.com/mycontroller-org/server/v2/plugin/handler/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newTestAPI returns the api and the service events received on the supplied topics
+func newTestAPI(t *testing.T, topics ...string) (*InboundWebhookAPI, chan *rsTY.ServiceEvent) {
+	ctx := loggerUtils.WithContext(context.Background(), zap.NewNop())
+	ctx = schedulerTY.WithContext(ctx, coreScheduler.New())
+
+	storage, err := memory.New(ctx, cmap.CustomMap{})
+	require.NoError(t, err)
+
+	bus, err := embedded.NewClient(ctx, cmap.CustomMap{})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = bus.Close() })
+
+	events := make(chan *rsTY.ServiceEvent, 10)
+	for _, serviceTopic := range topics {
+		_, err = bus.Subscribe(serviceTopic, func(data *busTY.BusData) {
+			event := &rsTY.ServiceEvent{}
+			if data.LoadData(event) == nil {
+				events <- event
+			}
+		})
+		require.NoError(t, err)
+	}
+
+	enc := encryptionAPI.New(zap.NewNop(), "0123456789abcdef0123456789abcdef", nil, "")
+	return New(ctx, zap.NewNop(), storage, enc, bus), events
+}
+
+func receiveEvent(t *testing.T, events chan *rsTY.ServiceEvent) *rsTY.ServiceEvent {
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "service event not received")
+	}
+	return nil
+}
+
+func TestSaveToken(t *testing.T) {
+	api, _ := newTestAPI(t)
+
+	// generated token returned once, stored encrypted
+	cfg := &webhookTY.Config{ID: "hook", Enabled: true, Action: webhookTY.ActionTask, TargetID: "task1"}
+	token, err := api.SaveAndGetToken(cfg)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+
+	stored, err := api.GetByID("hook")
+	require.NoError(t, err)
+	assert.NotEqual(t, token, stored.Token)
+	require.NoError(t, api.enc.DecryptSecrets(stored))
+	assert.Equal(t, token, stored.Token)
+
+	// updated with the stored token, not generated again
+	token, err = api.SaveAndGetToken(stored)
+	require.NoError(t, err)
+	assert.Empty(t, token)
+
+	// field action needs a valid quick id
+	err = api.Save(&webhookTY.Config{ID: "field", Action: webhookTY.ActionField, TargetID: "temperature"})
+	assert.Error(t, err)
+}
+
+func TestTriggerToken(t *testing.T) {
+	api, events := newTestAPI(t, topic.TopicServiceTask)
+	require.NoError(t, api.storage.Insert(types.EntityTask, &taskTY.Config{ID: "task1", Enabled: true}))
+
+	require.NoError(t, api.Save(&webhookTY.Config{ID: "hook", Enabled: true, Token: "token", Action: webhookTY.ActionTask, TargetID: "task1"}))
+	require.NoError(t, api.Save(&webhookTY.Config{ID: "disabled", Enabled: false, Token: "token", Action: webhookTY.ActionTask, TargetID: "task1"}))
+
+	tests := []struct {
+		name  string
+		id    string
+		token string
+	}{
+		{name: "empty token", id: "hook", token: ""},
+		{name: "invalid token", id: "hook", token: "invalid"},
+		{name: "unknown webhook", id: "unknown", token: "token"},
+		{name: "disabled webhook", id: "disabled", token: "token"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := api.Trigger(test.id, test.token, &webhookTY.Request{})
+			assert.ErrorIs(t, err, ErrUnauthorized)
+		})
+	}
+	assert.Empty(t, events)
+
+	require.NoError(t, api.Trigger("hook", "token", &webhookTY.Request{Body: "hello"}))
+	event := receiveEvent(t, events)
+	assert.Equal(t, rsTY.TypeTask, event.Type)
+	assert.Equal(t, rsTY.CommandTrigger, event.Command)
+}
+
+func TestTriggerTask(t *testing.T) {
+	api, events := newTestAPI(t, topic.TopicServiceTask)
+	require.NoError(t, api.storage.Insert(types.EntityTask, &taskTY.Config{ID: "task1", Enabled: true}))
+	require.NoError(t, api.storage.Insert(types.EntityTask, &taskTY.Config{ID: "task2", Enabled: false}))
+	require.NoError(t, api.Save(&webhookTY.Config{ID: "hook", Enabled: true, Token: "token", Action: webhookTY.ActionTask, TargetID: "task1"}))
+	require.NoError(t, api.Save(&webhookTY.Config{ID: "hook_disabled_task", Enabled: true, Token: "token", Action: webhookTY.ActionTask, TargetID: "task2"}))
+
+	require.NoError(t, api.Trigger("hook", "token", &webhookTY.Request{Body: `{"on":true}`, Data: map[string]interface{}{"on": true}}))
+	event := receiveEvent(t, events)
+	assert.Equal(t, "task1", event.ID)
+	trigger := &webhookTY.TaskTrigger{}
+	require.NoError(t, event.LoadData(trigger))
+	assert.Equal(t, "task1", trigger.Task.ID)
+	assert.Equal(t, "hook", trigger.Request.WebhookID)
+	assert.Equal(t, map[string]interface{}{"on": true}, trigger.Request.Data)
+
+	assert.ErrorContains(t, api.Trigger("hook_disabled_task", "token", &webhookTY.Request{}), "task disabled")
+}
+
+func TestTriggerSchedule(t *testing.T) {
+	api, events := newTestAPI(t, topic.TopicServiceScheduler)
+	require.NoError(t, api.storage.Insert(types.EntitySchedule, &schedulerTY.Config{ID: "schedule1", Enabled: true}))
+	require.NoError(t, api.Save(&webhookTY.Config{ID: "hook", Enabled: true, Token: "token", Action: webhookTY.ActionSchedule, TargetID: "schedule1"}))
+	require.NoError(t, api.Save(&webhookTY.Config{ID: "hook_unknown", Enabled: true, Token: "token", Action: webhookTY.ActionSchedule, TargetID: "unknown"}))
+
+	require.NoError(t, api.Trigger("hook", "token", &webhookTY.Request{Method: "POST"}))
+	event := receiveEvent(t, events)
+	assert.Equal(t, rsTY.TypeScheduler, event.Type)
+	assert.Equal(t, rsTY.CommandTrigger, event.Command)
+	trigger := &webhookTY.ScheduleTrigger{}
+	require.NoError(t, event.LoadData(trigger))
+	assert.Equal(t, "schedule1", trigger.Schedule.ID)
+	assert.Equal(t, "POST", trigger.Request.Method)
+
+	assert.ErrorContains(t, api.Trigger("hook_unknown", "token", &webhookTY.Request{}), "schedule not available")
+}
+
+func TestTriggerField(t *testing.T) {
+	api, events := newTestAPI(t, topic.TopicServiceResourceServer)
+	require.NoError(t, api.Save(&webhookTY.Config{ID: "body", Enabled: true, Token: "token", Action: webhookTY.ActionField, TargetID: "field:gw.node.source.field"}))
+	require.NoError(t, api.Save(&webhookTY.Config{ID: "path", Enabled: true, Token: "token", Action: webhookTY.ActionField, TargetID: "field:gw.node.source.field", PayloadPath: "sensor.temperature"}))
+
+	receiveAction := func() *handlerTY.ResourceData {
+		event := receiveEvent(t, events)
+		assert.Equal(t, rsTY.TypeResourceAction, event.Type)
+		assert.Equal(t, rsTY.CommandSet, event.Command)
+		rsData := &handlerTY.ResourceData{}
+		require.NoError(t, event.LoadData(rsData))
+		return rsData
+	}
+
+	// whole body, resource type supplied separately
+	require.NoError(t, api.Trigger("body", "token", &webhookTY.Request{Body: "21.5"}))
+	rsData := receiveAction()
+	assert.Equal(t, "field", rsData.ResourceType)
+	assert.Equal(t, "gw.node.source.field", rsData.QuickID)
+	assert.Equal(t, "21.5", rsData.Payload)
+
+	// value from the payload path
+	data := map[string]interface{}{"sensor": map[string]interface{}{"temperature": 22.5}}
+	require.NoError(t, api.Trigger("path", "token", &webhookTY.Request{Body: `{"sensor":{"temperature":22.5}}`, Data: data}))
+	rsData = receiveAction()
+	assert.Equal(t, "field", rsData.ResourceType)
+	assert.Equal(t, "22.5", rsData.Payload)
+
+	// payload path on a non json body
+	assert.ErrorContains(t, api.Trigger("path", "token", &webhookTY.Request{Body: "abc"}), "body is not a json")
+}
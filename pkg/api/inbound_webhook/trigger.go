@@ -0,0 +1,127 @@
+package inboundwebhook
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mycontroller-org/server/v2/pkg/json"
+	types "github.com/mycontroller-org/server/v2/pkg/types"
+	webhookTY "github.com/mycontroller-org/server/v2/pkg/types/inbound_webhook"
+	rsTY "github.com/mycontroller-org/server/v2/pkg/types/resource_service"
+	schedulerTY "github.com/mycontroller-org/server/v2/pkg/types/scheduler"
+	taskTY "github.com/mycontroller-org/server/v2/pkg/types/task"
+	"github.com/mycontroller-org/server/v2/pkg/types/topic"
+	busUtils "github.com/mycontroller-org/server/v2/pkg/utils/bus_utils"
+	filterUtils "github.com/mycontroller-org/server/v2/pkg/utils/filter_sort"
+	quickIdUtils "github.com/mycontroller-org/server/v2/pkg/utils/quick_id"
+	storageTY "github.com/mycontroller-org/server/v2/plugin/database/storage/types"
+	handlerTY "github.com/mycontroller-org/server/v2/plugin/handler/types"
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrUnauthorized returned on invalid token, disabled or unknown webhook,
+	// the caller should not know the difference
+	ErrUnauthorized = errors.New("unauthorized")
+)
+
+// Trigger verifies the token and performs the configured action
+func (iw *InboundWebhookAPI) Trigger(id, token string, request *webhookTY.Request) error {
+	cfg, err := iw.GetByID(id)
+	if err != nil || cfg.ID == "" {
+		return ErrUnauthorized
+	}
+
+	err = iw.enc.DecryptSecrets(cfg)
+	if err != nil {
+		return err
+	}
+	if !cfg.Enabled || token == "" || subtle.ConstantTimeCompare([]byte(cfg.Token), []byte(token)) != 1 {
+		return ErrUnauthorized
+	}
+
+	request.WebhookID = cfg.ID
+	iw.logger.Debug("inbound webhook triggered", zap.String("id", cfg.ID), zap.String("action", cfg.Action), zap.String("targetId", cfg.TargetID))
+
+	switch cfg.Action {
+	case webhookTY.ActionTask:
+		return iw.triggerTask(cfg, request)
+
+	case webhookTY.ActionSchedule:
+		return iw.triggerSchedule(cfg, request)
+
+	case webhookTY.ActionField:
+		return iw.setField(cfg, request)
+
+	default:
+		return fmt.Errorf("unknown action: %s", cfg.Action)
+	}
+}
+
+func (iw *InboundWebhookAPI) triggerTask(cfg *webhookTY.Config, request *webhookTY.Request) error {
+	task := taskTY.Config{}
+	err := iw.storage.FindOne(types.EntityTask, &task, []storageTY.Filter{{Key: types.KeyID, Value: cfg.TargetID}})
+	if err != nil {
+		return fmt.Errorf("task not available. [id: %s]", cfg.TargetID)
+	}
+	if !task.Enabled {
+		return fmt.Errorf("task disabled. [id: %s]", cfg.TargetID)
+	}
+	trigger := webhookTY.TaskTrigger{Task: task, Request: *request}
+	busUtils.PostToService(iw.logger, iw.bus, topic.TopicServiceTask, task.ID, trigger, rsTY.TypeTask, rsTY.CommandTrigger, "")
+	return nil
+}
+
+func (iw *InboundWebhookAPI) triggerSchedule(cfg *webhookTY.Config, request *webhookTY.Request) error {
+	schedule := schedulerTY.Config{}
+	err := iw.storage.FindOne(types.EntitySchedule, &schedule, []storageTY.Filter{{Key: types.KeyID, Value: cfg.TargetID}})
+	if err != nil {
+		return fmt.Errorf("schedule not available. [id: %s]", cfg.TargetID)
+	}
+	if !schedule.Enabled {
+		return fmt.Errorf("schedule disabled. [id: %s]", cfg.TargetID)
+	}
+	trigger := webhookTY.ScheduleTrigger{Schedule: schedule, Request: *request}
+	busUtils.PostToService(iw.logger, iw.bus, topic.TopicServiceScheduler, schedule.ID, trigger, rsTY.TypeScheduler, rsTY.CommandTrigger, "")
+	return nil
+}
+
+// setField sets the body or the value from the payload path via resource action
+func (iw *InboundWebhookAPI) setField(cfg *webhookTY.Config, request *webhookTY.Request) error {
+	payload := request.Body
+	if cfg.PayloadPath != "" {
+		if request.Data == nil {
+			return errors.New("payload path supplied, but the body is not a json")
+		}
+		// the root should be a struct
+		data := struct{ Body interface{} }{Body: request.Data}
+		_, value, err := filterUtils.GetValueByKeyPath(data, fmt.Sprintf("body.%s", cfg.PayloadPath))
+		if err != nil {
+			return err
+		}
+		if stringValue, ok := value.(string); ok {
+			payload = stringValue
+		} else {
+			valueBytes, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+			payload = string(valueBytes)
+		}
+	}
+
+	// resource type is supplied separately, ex: field:gw.node.source.field
+	resourceType, _, err := quickIdUtils.EntityKeyValueMap(cfg.TargetID)
+	if err != nil {
+		return err
+	}
+	rsData := handlerTY.ResourceData{
+		ResourceType: resourceType,
+		QuickID:      strings.SplitN(cfg.TargetID, ":", 2)[1],
+		Payload:      payload,
+	}
+	busUtils.PostToResourceService(iw.logger, iw.bus, cfg.ID, rsData, rsTY.TypeResourceAction, rsTY.CommandSet, "")
+	return nil
+}
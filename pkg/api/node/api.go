@@ -53,6 +53,7 @@ func (n *NodeAPI) Save(node *nodeTY.Node, publishEvent bool) error {
 		node.ID = utils.RandUUID()
 		eventType = eventTY.TypeCreated
 	}
+	node.ModifiedOn = time.Now()
 	filters := []storageTY.Filter{
 		{Key: types.KeyID, Value: node.ID},
 	}
@@ -236,6 +237,7 @@ func (n *NodeAPI) Import(data interface{}) error {
 		input.ID = utils.RandUUID()
 	}
 
+	input.ModifiedOn = time.Now()
 	filters := []storageTY.Filter{
 		{Key: types.KeyID, Value: input.ID},
 	}
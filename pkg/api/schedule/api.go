@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	types "github.com/mycontroller-org/server/v2/pkg/types"
 	eventTY "github.com/mycontroller-org/server/v2/pkg/types/event"
@@ -53,6 +54,7 @@ func (sh *ScheduleAPI) Save(schedule *schedulerTY.Config) error {
 		eventType = eventTY.TypeCreated
 	}
 
+	schedule.ModifiedOn = time.Now()
 	filters := []storageTY.Filter{
 		{Key: types.KeyID, Value: schedule.ID},
 	}
@@ -114,6 +116,7 @@ func (sh *ScheduleAPI) Import(data interface{}) error {
 		return errors.New("'id' can not be empty")
 	}
 
+	input.ModifiedOn = time.Now()
 	filters := []storageTY.Filter{
 		{Key: types.KeyID, Value: input.ID},
 	}
@@ -48,6 +48,7 @@ func (s *SettingsAPI) Save(settings *settingsTY.Settings) error {
 	if settings.ID == "" {
 		return errors.New("id should not be nil")
 	}
+	settings.ModifiedOn = time.Now()
 	filters := []storageTY.Filter{
 		{Key: types.KeyID, Value: settings.ID},
 	}
@@ -249,6 +250,12 @@ func (s *SettingsAPI) GetBackupLocations() (*settingsTY.BackupLocations, error)
 		return nil, err
 	}
 
+	// remote locations need the credentials
+	err = s.enc.DecryptSecrets(systemSettings)
+	if err != nil {
+		return nil, err
+	}
+
 	return systemSettings, nil
 }
 
@@ -366,6 +373,7 @@ func (s *SettingsAPI) Import(data interface{}) error {
 		return errors.New("'id' can not be empty")
 	}
 
+	input.ModifiedOn = time.Now()
 	filters := []storageTY.Filter{
 		{Key: types.KeyID, Value: input.ID},
 	}
@@ -3,6 +3,7 @@ package source
 import (
 	"context"
 	"fmt"
+	"time"
 
 	types "github.com/mycontroller-org/server/v2/pkg/types"
 	eventTY "github.com/mycontroller-org/server/v2/pkg/types/event"
@@ -49,6 +50,7 @@ func (s *SourceAPI) Save(source *sourceTY.Source) error {
 	if source.ID == "" {
 		source.ID = utils.RandUUID()
 	}
+	source.ModifiedOn = time.Now()
 	f := []storageTY.Filter{
 		{Key: types.KeyID, Value: source.ID},
 	}
@@ -100,6 +102,7 @@ func (s *SourceAPI) Import(data interface{}) error {
 		input.ID = utils.RandUUID()
 	}
 
+	input.ModifiedOn = time.Now()
 	filters := []storageTY.Filter{
 		{Key: types.KeyID, Value: input.ID},
 	}
@@ -57,6 +57,7 @@ type Status struct {
 	Uptime            uint64           `json:"uptime"` // in milliseconds
 	MetricsDBDisabled bool             `json:"metricsDBDisabled"`
 	Language          string           `json:"language"`
+	Bus               *busTY.Stats     `json:"bus,omitempty"` // deliveries stats, if supported by the bus plugin
 }
 
 func (s *StatusAPI) get(isDetailed bool) Status {
@@ -76,6 +77,10 @@ func (s *StatusAPI) get(isDetailed bool) Status {
 		status.ServerTime = time.Now()
 		status.StartTime = startTime
 		status.Uptime = uint64(time.Since(startTime).Milliseconds())
+
+		if statsProvider, ok := s.bus.(busTY.StatsProvider); ok {
+			status.Bus = statsProvider.Stats()
+		}
 	}
 
 	// include login message
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	types "github.com/mycontroller-org/server/v2/pkg/types"
 	eventTY "github.com/mycontroller-org/server/v2/pkg/types/event"
@@ -52,6 +53,7 @@ func (t *TaskAPI) Save(task *taskTY.Config) error {
 		task.ID = utils.RandUUID()
 		eventType = eventTY.TypeCreated
 	}
+	task.ModifiedOn = time.Now()
 	filters := []storageTY.Filter{
 		{Key: types.KeyID, Value: task.ID},
 	}
@@ -112,6 +114,7 @@ func (t *TaskAPI) Import(data interface{}) error {
 		return errors.New("'id' can not be empty")
 	}
 
+	input.ModifiedOn = time.Now()
 	filters := []storageTY.Filter{
 		{Key: types.KeyID, Value: input.ID},
 	}
@@ -143,6 +143,7 @@ func (u *UserAPI) Import(data interface{}) error {
 		input.ID = utils.RandUUID()
 	}
 
+	input.ModifiedOn = time.Now()
 	filters := []storageTY.Filter{
 		{Key: types.KeyID, Value: input.ID},
 	}
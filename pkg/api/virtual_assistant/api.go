@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"time"
 
+	encryptionAPI "github.com/mycontroller-org/server/v2/pkg/encryption"
 	types "github.com/mycontroller-org/server/v2/pkg/types"
 	eventTY "github.com/mycontroller-org/server/v2/pkg/types/event"
 	"github.com/mycontroller-org/server/v2/pkg/types/topic"
@@ -21,14 +22,16 @@ type VirtualAssistantAPI struct {
 	ctx     context.Context
 	logger  *zap.Logger
 	storage storageTY.Plugin
+	enc     *encryptionAPI.Encryption
 	bus     busTY.Plugin
 }
 
-func New(ctx context.Context, logger *zap.Logger, storage storageTY.Plugin, bus busTY.Plugin) *VirtualAssistantAPI {
+func New(ctx context.Context, logger *zap.Logger, storage storageTY.Plugin, enc *encryptionAPI.Encryption, bus busTY.Plugin) *VirtualAssistantAPI {
 	return &VirtualAssistantAPI{
 		ctx:     ctx,
 		logger:  logger.Named("virtual_assistant_api"),
 		storage: storage,
+		enc:     enc,
 		bus:     bus,
 	}
 }
@@ -60,7 +63,13 @@ func (va *VirtualAssistantAPI) Save(cfg *vaTY.Config) error {
 
 	cfg.ModifiedOn = time.Now()
 
-	err := va.storage.Upsert(types.EntityVirtualAssistant, cfg, filters)
+	// encrypt client secrets, tokens
+	err := va.enc.EncryptSecrets(cfg)
+	if err != nil {
+		return err
+	}
+
+	err = va.storage.Upsert(types.EntityVirtualAssistant, cfg, filters)
 	if err != nil {
 		return err
 	}
@@ -118,6 +127,7 @@ func (va *VirtualAssistantAPI) Import(data interface{}) error {
 		return errors.New("'id' can not be empty")
 	}
 
+	input.ModifiedOn = time.Now()
 	filters := []storageTY.Filter{
 		{Key: types.KeyID, Value: input.ID},
 	}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	types "github.com/mycontroller-org/server/v2/pkg/types"
 	eventTY "github.com/mycontroller-org/server/v2/pkg/types/event"
@@ -65,6 +66,11 @@ func (vd *VirtualDeviceAPI) Save(device *vdTY.VirtualDevice) error {
 		{Key: types.KeyID, Value: device.ID},
 	}
 
+	eventType := eventTY.TypeUpdated
+	if _, err := vd.GetByID(device.ID); err != nil {
+		eventType = eventTY.TypeCreated
+	}
+
 	// update quickId based resource details
 	resources := []string{}
 	for _, resource := range device.Traits {
@@ -75,11 +81,13 @@ func (vd *VirtualDeviceAPI) Save(device *vdTY.VirtualDevice) error {
 	}
 	device.Resources = resources
 
+	device.ModifiedOn = time.Now()
 	err := vd.storage.Upsert(types.EntityVirtualDevice, device, filters)
 	if err != nil {
 		return err
 	}
 
+	busUtils.PostEvent(vd.logger, vd.bus, topic.TopicEventVirtualDevice, eventType, types.EntityVirtualDevice, device)
 	return nil
 }
 
@@ -116,6 +124,7 @@ func (vd *VirtualDeviceAPI) Import(data interface{}) error {
 		input.ID = utils.RandUUID()
 	}
 
+	input.ModifiedOn = time.Now()
 	filters := []storageTY.Filter{
 		{Key: types.KeyID, Value: input.ID},
 	}
@@ -0,0 +1,634 @@
+package virtual_device
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	types "github.com/mycontroller-org/server/v2/pkg/types"
+	"github.com/mycontroller-org/server/v2/pkg/types/cmap"
+	fieldTY "github.com/mycontroller-org/server/v2/pkg/types/field"
+	nodeTY "github.com/mycontroller-org/server/v2/pkg/types/node"
+	sourceTY "github.com/mycontroller-org/server/v2/pkg/types/source"
+	vdTY "github.com/mycontroller-org/server/v2/pkg/types/virtual_device"
+	"github.com/mycontroller-org/server/v2/pkg/utils"
+	quickIdUtils "github.com/mycontroller-org/server/v2/pkg/utils/quick_id"
+	metricTY "github.com/mycontroller-org/server/v2/plugin/database/metric/types"
+	storageTY "github.com/mycontroller-org/server/v2/plugin/database/storage/types"
+	gwTY "github.com/mycontroller-org/server/v2/plugin/gateway/types"
+	"go.uber.org/zap"
+)
+
+// The generator proposes virtual devices from the fields of the nodes.
+// fields are grouped by source, each group becomes a device. if a group has more than one on_off field,
+// each on_off field becomes a separate device, example: tasmota "POWER1", "POWER2".
+// trait of a field is selected in the following order
+//   - "trait_type" and "param_type" labels on the field
+//   - provider hints, esphome entity type and device class, tasmota "POWER" and "Dimmer" fields
+//   - metric type, unit and name of the field
+
+const (
+	generatorPageLimit = int64(100)
+	unknownName        = "unknown"
+
+	providerTasmota = "tasmota"
+	providerESPHome = "esphome"
+
+	// esphome labels and entity types
+	esphomeLabelType        = "type"
+	esphomeLabelDeviceClass = "device_class"
+	esphomeBinarySensor     = "binary_sensor"
+	esphomeClimate          = "climate"
+	esphomeCover            = "cover"
+	esphomeFan              = "fan"
+	esphomeLight            = "light"
+	esphomeSensor           = "sensor"
+	esphomeSwitch           = "switch"
+
+	// tasmota fields
+	tasmotaSourceControl = "Control"
+	tasmotaFieldDimmer   = "Dimmer"
+)
+
+var (
+	tasmotaPowerRegex = regexp.MustCompile(`^POWER[0-9]*$`)
+
+	// sensor names supported on the assistants, matched with device class, field id and name
+	sensorNames = []string{"temperature", "humidity", "illuminance", "pressure", "motion", "occupancy", "presence", "contact", "door", "window"}
+
+	// device class aliases
+	sensorAliases = map[string]string{"opening": "contact", "lux": "illuminance"}
+
+	// cover device types, matched with device class
+	coverDeviceTypes = map[string]string{
+		"awning":      vdTY.DeviceTypeAwning,
+		"blind":       vdTY.DeviceTypeBlinds,
+		"curtain":     vdTY.DeviceTypeCurtain,
+		"door":        vdTY.DeviceTypeDoor,
+		"garage":      vdTY.DeviceTypeGarageDoor,
+		"gate":        vdTY.DeviceTypeGate,
+		"shutter":     vdTY.DeviceTypeShutter,
+		"window":      vdTY.DeviceTypeWindow,
+		"garage_door": vdTY.DeviceTypeGarageDoor,
+	}
+)
+
+// fieldTrait is the trait resource proposed for a field
+type fieldTrait struct {
+	trait      string
+	deviceType string // device type hint
+	labels     map[string]string
+}
+
+type fieldResource struct {
+	field    *fieldTY.Field
+	resource vdTY.Resource
+	hint     string
+}
+
+// fieldGroup is the fields of a source, the devices are generated from a group
+type fieldGroup struct {
+	key       string
+	provider  string
+	node      *nodeTY.Node
+	source    *sourceTY.Source
+	resources []fieldResource
+}
+
+// generatedDevice is a device proposed from a field group
+type generatedDevice struct {
+	key       string
+	name      string
+	group     *fieldGroup
+	resources []fieldResource
+}
+
+// Generate returns the virtual device proposals for the fields of the requested nodes
+func (vd *VirtualDeviceAPI) Generate(request *vdTY.GeneratorRequest) ([]vdTY.Proposal, error) {
+	if request == nil {
+		request = &vdTY.GeneratorRequest{}
+	}
+
+	groups, err := vd.getFieldGroups(request)
+	if err != nil {
+		return nil, err
+	}
+
+	// existing devices, used to update the generated devices and to skip the resources already in use
+	vDevices, err := findAll[vdTY.VirtualDevice](vd.storage, types.EntityVirtualDevice, nil)
+	if err != nil {
+		return nil, err
+	}
+	generatedDevices := make(map[string]*vdTY.VirtualDevice)
+	usedResources := make(map[string]bool)
+	for index := range vDevices {
+		vDevice := &vDevices[index]
+		if key := vDevice.Labels.Get(vdTY.LabelGeneratorKey); key != "" {
+			generatedDevices[key] = vDevice
+		}
+		for _, resource := range vDevice.Traits {
+			usedResources[getResourceKey(&resource)] = true
+		}
+	}
+
+	// devices count of a node, used on the device name
+	devicesCount := make(map[string]int)
+	devices := make([]generatedDevice, 0)
+	for _, group := range groups {
+		groupDevices := group.getDevices()
+		devicesCount[group.node.ID] += len(groupDevices)
+		devices = append(devices, groupDevices...)
+	}
+
+	proposals := make([]vdTY.Proposal, 0)
+	for _, device := range devices {
+		proposal := vd.getProposal(&device, devicesCount[device.group.node.ID] > 1, generatedDevices[device.key], usedResources)
+		if proposal == nil {
+			continue
+		}
+		if proposal.Status == vdTY.ProposalStatusUnchanged && !request.IncludeUnchanged {
+			continue
+		}
+		proposals = append(proposals, *proposal)
+	}
+	return proposals, nil
+}
+
+// Accept saves the reviewed proposals devices
+func (vd *VirtualDeviceAPI) Accept(devices []vdTY.VirtualDevice) (int64, error) {
+	saved := int64(0)
+	for index := range devices {
+		device := &devices[index]
+		if device.ID == "" {
+			device.ID = utils.RandUUID()
+		}
+		device.Labels = device.Labels.Init()
+		if !device.Labels.IsExists(vdTY.LabelGenerated) {
+			device.Labels.Set(vdTY.LabelGenerated, "true")
+		}
+		device.ModifiedOn = time.Now()
+		err := vd.Save(device)
+		if err != nil {
+			return saved, err
+		}
+		saved++
+	}
+	return saved, nil
+}
+
+// UpdateGenerated adds the new fields of the node to the generated devices.
+// new devices are not created, they should be reviewed and accepted by the user
+func (vd *VirtualDeviceAPI) UpdateGenerated(gatewayID, nodeID string) error {
+	proposals, err := vd.Generate(&vdTY.GeneratorRequest{GatewayID: gatewayID, NodeIDs: []string{nodeID}})
+	if err != nil {
+		return err
+	}
+	for index := range proposals {
+		proposal := &proposals[index]
+		if proposal.Status != vdTY.ProposalStatusUpdate || !proposal.Device.Labels.GetBool(vdTY.LabelGenerated) {
+			continue
+		}
+		proposal.Device.ModifiedOn = time.Now()
+		err = vd.Save(&proposal.Device)
+		if err != nil {
+			return err
+		}
+		vd.logger.Info("new traits added on the generated device", zap.String("id", proposal.Device.ID), zap.Strings("traits", proposal.NewTraits))
+	}
+	return nil
+}
+
+// returns proposal of the device, nil if there is nothing to propose
+func (vd *VirtualDeviceAPI) getProposal(device *generatedDevice, multipleDevices bool, existing *vdTY.VirtualDevice, usedResources map[string]bool) *vdTY.Proposal {
+	if existing != nil {
+		updated := *existing
+		updated.Labels = existing.Labels.Clone()
+		updated.Traits = append([]vdTY.Resource{}, existing.Traits...)
+		newTraits := make([]string, 0)
+		for _, fr := range device.resources {
+			if usedResources[getResourceKey(&fr.resource)] {
+				continue
+			}
+			updated.Traits = append(updated.Traits, fr.resource)
+			newTraits = append(newTraits, fr.resource.Name)
+		}
+		status := vdTY.ProposalStatusUpdate
+		if len(newTraits) == 0 {
+			status = vdTY.ProposalStatusUnchanged
+		}
+		return &vdTY.Proposal{Key: device.key, Status: status, NewTraits: newTraits, Device: updated}
+	}
+
+	traits := make([]vdTY.Resource, 0)
+	names := make([]string, 0)
+	for _, fr := range device.resources {
+		if usedResources[getResourceKey(&fr.resource)] {
+			continue
+		}
+		traits = append(traits, fr.resource)
+		names = append(names, fr.resource.Name)
+	}
+	if len(traits) == 0 {
+		return nil
+	}
+
+	node := device.group.node
+	name := getName(node.Name, node.NodeID)
+	if multipleDevices {
+		name = fmt.Sprintf("%s %s", name, device.name)
+	}
+
+	labels := cmap.CustomStringMap{}
+	labels.Set(vdTY.LabelGenerated, "true")
+	labels.Set(vdTY.LabelGeneratorKey, device.key)
+	room := node.Labels.Get(types.LabelRoom)
+	if room != "" {
+		labels.Set(types.LabelRoom, room)
+	}
+
+	vDevice := vdTY.VirtualDevice{
+		Name:        name,
+		Description: fmt.Sprintf("generated from %s", device.key),
+		Enabled:     true,
+		DeviceType:  device.getDeviceType(traits),
+		Traits:      traits,
+		Location:    room,
+		Labels:      labels,
+	}
+	return &vdTY.Proposal{Key: device.key, Status: vdTY.ProposalStatusNew, NewTraits: names, Device: vDevice}
+}
+
+// loads the fields of the requested nodes and groups them by source
+func (vd *VirtualDeviceAPI) getFieldGroups(request *vdTY.GeneratorRequest) ([]*fieldGroup, error) {
+	filters := make([]storageTY.Filter, 0)
+	if request.GatewayID != "" {
+		filters = append(filters, storageTY.Filter{Key: types.KeyGatewayID, Operator: storageTY.OperatorEqual, Value: request.GatewayID})
+		if len(request.NodeIDs) > 0 {
+			filters = append(filters, storageTY.Filter{Key: types.KeyNodeID, Operator: storageTY.OperatorIn, Value: request.NodeIDs})
+		}
+	}
+
+	gateways, err := findAll[gwTY.Config](vd.storage, types.EntityGateway, nil)
+	if err != nil {
+		return nil, err
+	}
+	providers := make(map[string]string)
+	for _, gateway := range gateways {
+		providers[gateway.ID] = gateway.Provider.GetString(types.KeyType)
+	}
+
+	nodes, err := findAll[nodeTY.Node](vd.storage, types.EntityNode, filters)
+	if err != nil {
+		return nil, err
+	}
+	nodesMap := make(map[string]*nodeTY.Node)
+	for index := range nodes {
+		node := &nodes[index]
+		nodesMap[fmt.Sprintf("%s.%s", node.GatewayID, node.NodeID)] = node
+	}
+
+	sources, err := findAll[sourceTY.Source](vd.storage, types.EntitySource, filters)
+	if err != nil {
+		return nil, err
+	}
+	sourcesMap := make(map[string]*sourceTY.Source)
+	for index := range sources {
+		source := &sources[index]
+		sourcesMap[fmt.Sprintf("%s.%s.%s", source.GatewayID, source.NodeID, source.SourceID)] = source
+	}
+
+	fields, err := findAll[fieldTY.Field](vd.storage, types.EntityField, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	groupsMap := make(map[string]*fieldGroup)
+	for index := range fields {
+		field := &fields[index]
+		node, found := nodesMap[fmt.Sprintf("%s.%s", field.GatewayID, field.NodeID)]
+		if !found {
+			continue
+		}
+		key := fmt.Sprintf("%s.%s.%s", field.GatewayID, field.NodeID, field.SourceID)
+		group, found := groupsMap[key]
+		if !found {
+			source, found := sourcesMap[key]
+			if !found {
+				source = &sourceTY.Source{GatewayID: field.GatewayID, NodeID: field.NodeID, SourceID: field.SourceID}
+			}
+			group = &fieldGroup{key: key, provider: providers[field.GatewayID], node: node, source: source}
+			groupsMap[key] = group
+		}
+
+		fTrait := group.getFieldTrait(field)
+		if fTrait == nil {
+			continue
+		}
+		group.resources = append(group.resources, fieldResource{field: field, resource: getResource(field, fTrait), hint: fTrait.deviceType})
+	}
+
+	groups := make([]*fieldGroup, 0)
+	for _, group := range groupsMap {
+		if len(group.resources) == 0 {
+			continue
+		}
+		sort.SliceStable(group.resources, func(i, j int) bool {
+			return group.resources[i].field.FieldID < group.resources[j].field.FieldID
+		})
+		groups = append(groups, group)
+	}
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i].key < groups[j].key })
+	return groups, nil
+}
+
+// returns the devices of the group, on_off fields are splitted into separate devices
+func (g *fieldGroup) getDevices() []generatedDevice {
+	sourceName := getName(g.source.Name, g.source.SourceID)
+	onOffCount := 0
+	for _, fr := range g.resources {
+		if fr.resource.TraitType == vdTY.DeviceTraitOnOff {
+			onOffCount++
+		}
+	}
+	if onOffCount <= 1 {
+		return []generatedDevice{{key: g.key, name: sourceName, group: g, resources: g.resources}}
+	}
+
+	// first on_off device keeps the other traits
+	devices := make([]generatedDevice, 0)
+	for _, fr := range g.resources {
+		if fr.resource.TraitType != vdTY.DeviceTraitOnOff {
+			continue
+		}
+		name := fmt.Sprintf("%s %s", sourceName, getName(fr.field.Name, fr.field.FieldID))
+		if len(devices) == 0 {
+			devices = append(devices, generatedDevice{key: g.key, name: name, group: g, resources: []fieldResource{fr}})
+			continue
+		}
+		key := fmt.Sprintf("%s.%s", g.key, fr.field.FieldID)
+		devices = append(devices, generatedDevice{key: key, name: name, group: g, resources: []fieldResource{fr}})
+	}
+	for _, fr := range g.resources {
+		if fr.resource.TraitType != vdTY.DeviceTraitOnOff {
+			devices[0].resources = append(devices[0].resources, fr)
+		}
+	}
+	return devices
+}
+
+// returns the trait of the field, nil if not supported
+func (g *fieldGroup) getFieldTrait(field *fieldTY.Field) *fieldTrait {
+	// defined by the user
+	if trait := field.Labels.Get(types.LabelTraitType); trait != "" {
+		fTrait := &fieldTrait{trait: trait, deviceType: field.Labels.Get(types.LabelDeviceType), labels: map[string]string{}}
+		if parameter := field.Labels.Get(types.LabelParamType); parameter != "" {
+			fTrait.labels[vdTY.LabelTraitParameter] = parameter
+		}
+		return fTrait
+	}
+
+	switch g.provider {
+	case providerESPHome:
+		return g.getESPHomeTrait(field)
+
+	case providerTasmota:
+		if g.source.SourceID == tasmotaSourceControl {
+			return getTasmotaTrait(field)
+		}
+	}
+	return getGenericTrait(field)
+}
+
+// esphome entity is a source, entity type and device class are used as hints
+func (g *fieldGroup) getESPHomeTrait(field *fieldTY.Field) *fieldTrait {
+	deviceClass := g.source.Labels.Get(esphomeLabelDeviceClass)
+	switch field.Labels.Get(esphomeLabelType) {
+	case esphomeSwitch:
+		if field.FieldID == "state" {
+			return newFieldTrait(vdTY.DeviceTraitOnOff, vdTY.DeviceTypeSwitch)
+		}
+
+	case esphomeLight:
+		switch field.FieldID {
+		case "state":
+			return newFieldTrait(vdTY.DeviceTraitOnOff, vdTY.DeviceTypeLight)
+		case "brightness":
+			return newFieldTrait(vdTY.DeviceTraitBrightness, vdTY.DeviceTypeLight)
+		case "rgb":
+			fTrait := newFieldTrait(vdTY.DeviceTraitColorSetting, vdTY.DeviceTypeLight)
+			fTrait.labels[vdTY.LabelTraitParameter] = vdTY.TraitParameterRGB
+			fTrait.labels[vdTY.LabelTraitUnit] = vdTY.TraitUnitHex
+			return fTrait
+		}
+
+	case esphomeFan:
+		if field.FieldID == "state" {
+			return newFieldTrait(vdTY.DeviceTraitOnOff, vdTY.DeviceTypeFan)
+		}
+
+	case esphomeCover:
+		if field.FieldID == "position" {
+			fTrait := newFieldTrait(vdTY.DeviceTraitOpenClose, getCoverDeviceType(deviceClass))
+			fTrait.labels[vdTY.LabelTraitMin] = "0"
+			fTrait.labels[vdTY.LabelTraitMax] = "1"
+			return fTrait
+		}
+
+	case esphomeClimate:
+		switch field.FieldID {
+		case "target_temperature":
+			fTrait := newFieldTrait(vdTY.DeviceTraitTemperatureSetting, vdTY.DeviceTypeThermostat)
+			fTrait.labels[vdTY.LabelTraitParameter] = vdTY.TraitParameterSetpoint
+			return fTrait
+		case "current_temperature":
+			fTrait := newFieldTrait(vdTY.DeviceTraitTemperatureSetting, vdTY.DeviceTypeThermostat)
+			fTrait.labels[vdTY.LabelTraitParameter] = vdTY.TraitParameterAmbient
+			return fTrait
+		}
+
+	case esphomeSensor, esphomeBinarySensor:
+		if field.FieldID == "state" {
+			name := getSensorName(deviceClass, g.source.Name, field.Unit)
+			if name == "" {
+				return nil
+			}
+			fTrait := newFieldTrait(vdTY.DeviceTraitSensorState, vdTY.DeviceTypeSensor)
+			fTrait.labels[vdTY.LabelTraitName] = name
+			return fTrait
+		}
+	}
+	return nil
+}
+
+// tasmota control source, power and dimmer fields are used
+func getTasmotaTrait(field *fieldTY.Field) *fieldTrait {
+	switch {
+	case tasmotaPowerRegex.MatchString(field.FieldID):
+		return newFieldTrait(vdTY.DeviceTraitOnOff, "")
+	case field.FieldID == tasmotaFieldDimmer:
+		return newFieldTrait(vdTY.DeviceTraitBrightness, vdTY.DeviceTypeLight)
+	}
+	return nil
+}
+
+// trait based on metric type, unit and name of the field
+func getGenericTrait(field *fieldTY.Field) *fieldTrait {
+	switch field.MetricType {
+	case metricTY.MetricTypeBinary:
+		if !field.Labels.GetBool(types.LabelReadOnly) {
+			return newFieldTrait(vdTY.DeviceTraitOnOff, "")
+		}
+		name := getSensorName(field.FieldID, field.Name, "")
+		if name == "" {
+			return nil
+		}
+		fTrait := newFieldTrait(vdTY.DeviceTraitSensorState, vdTY.DeviceTypeSensor)
+		fTrait.labels[vdTY.LabelTraitName] = name
+		return fTrait
+
+	case metricTY.MetricTypeGauge, metricTY.MetricTypeGaugeFloat:
+		name := getSensorName(field.FieldID, field.Name, field.Unit)
+		if name == "" {
+			return nil
+		}
+		fTrait := newFieldTrait(vdTY.DeviceTraitSensorState, vdTY.DeviceTypeSensor)
+		fTrait.labels[vdTY.LabelTraitName] = name
+		switch field.Unit {
+		case metricTY.UnitCelsius:
+			fTrait.labels[vdTY.LabelTraitUnit] = vdTY.TraitUnitCelsius
+		case metricTY.UnitFahrenheit:
+			fTrait.labels[vdTY.LabelTraitUnit] = vdTY.TraitUnitFahrenheit
+		}
+		return fTrait
+	}
+	return nil
+}
+
+// returns the device type, user defined labels takes the precedence
+func (d *generatedDevice) getDeviceType(traits []vdTY.Resource) string {
+	for _, fr := range d.resources {
+		if deviceType := fr.field.Labels.Get(types.LabelDeviceType); deviceType != "" {
+			return deviceType
+		}
+	}
+	if deviceType := d.group.source.Labels.Get(types.LabelDeviceType); deviceType != "" {
+		return deviceType
+	}
+	if deviceType := d.group.node.Labels.Get(types.LabelDeviceType); deviceType != "" {
+		return deviceType
+	}
+
+	hasTrait := func(trait string) bool {
+		for _, resource := range traits {
+			if resource.TraitType == trait {
+				return true
+			}
+		}
+		return false
+	}
+	switch {
+	case hasTrait(vdTY.DeviceTraitTemperatureSetting):
+		return vdTY.DeviceTypeThermostat
+	case hasTrait(vdTY.DeviceTraitOpenClose):
+		return getCoverDeviceType(d.group.source.Labels.Get(esphomeLabelDeviceClass))
+	case hasTrait(vdTY.DeviceTraitFanSpeed):
+		return vdTY.DeviceTypeFan
+	case hasTrait(vdTY.DeviceTraitBrightness), hasTrait(vdTY.DeviceTraitColorSetting):
+		return vdTY.DeviceTypeLight
+	}
+
+	// provider hints
+	for _, fr := range d.resources {
+		if fr.hint != "" {
+			return fr.hint
+		}
+	}
+	if hasTrait(vdTY.DeviceTraitOnOff) {
+		return vdTY.DeviceTypeSwitch
+	}
+	return vdTY.DeviceTypeSensor
+}
+
+func newFieldTrait(trait, deviceType string) *fieldTrait {
+	return &fieldTrait{trait: trait, deviceType: deviceType, labels: map[string]string{}}
+}
+
+func getResource(field *fieldTY.Field, fTrait *fieldTrait) vdTY.Resource {
+	labels := cmap.CustomStringMap{}
+	for key, value := range fTrait.labels {
+		labels.Set(key, value)
+	}
+	return vdTY.Resource{
+		Name:         getName(field.Name, field.FieldID),
+		TraitType:    fTrait.trait,
+		ResourceType: quickIdUtils.QuickIdField,
+		QuickID:      fmt.Sprintf("%s.%s.%s.%s", field.GatewayID, field.NodeID, field.SourceID, field.FieldID),
+		Labels:       labels,
+	}
+}
+
+// a field used on a device is not proposed again, even with a different trait
+func getResourceKey(resource *vdTY.Resource) string {
+	return fmt.Sprintf("%s:%s", resource.ResourceType, resource.QuickID)
+}
+
+// returns the sensor name, if any of the hints contains a known sensor name
+func getSensorName(hint, name, unit string) string {
+	if unit == metricTY.UnitCelsius || unit == metricTY.UnitFahrenheit {
+		return "temperature"
+	}
+	for _, value := range []string{hint, name} {
+		value = strings.ToLower(value)
+		if value == "" {
+			continue
+		}
+		if alias, found := sensorAliases[value]; found {
+			return alias
+		}
+		for _, sensorName := range sensorNames {
+			if strings.Contains(value, sensorName) {
+				return sensorName
+			}
+		}
+	}
+	return ""
+}
+
+func getCoverDeviceType(deviceClass string) string {
+	if deviceType, found := coverDeviceTypes[strings.ToLower(deviceClass)]; found {
+		return deviceType
+	}
+	return vdTY.DeviceTypeBlinds
+}
+
+func getName(name, fallback string) string {
+	if name == "" || name == unknownName {
+		return fallback
+	}
+	return name
+}
+
+// returns all the entities, loaded page by page
+func findAll[T any](storage storageTY.Plugin, entityType string, filters []storageTY.Filter) ([]T, error) {
+	items := make([]T, 0)
+	offset := int64(0)
+	for {
+		pagination := &storageTY.Pagination{
+			Offset: offset,
+			Limit:  generatorPageLimit,
+			SortBy: []storageTY.Sort{{Field: types.KeyID, OrderBy: storageTY.SortByASC}},
+		}
+		page := make([]T, 0)
+		result, err := storage.Find(entityType, &page, filters, pagination)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, page...)
+		offset += int64(len(page))
+		if len(page) == 0 || offset >= result.Count {
+			return items, nil
+		}
+	}
+}
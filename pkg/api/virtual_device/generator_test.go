@@ -0,0 +1,319 @@
+package virtual_device
+
+import (
+	"context"
+	"testing"
+
+	coreScheduler "github.com/mycontroller-org/server/v2/pkg/service/core_scheduler"
+	types "github.com/mycontroller-org/server/v2/pkg/types"
+	"github.com/mycontroller-org/server/v2/pkg/types/cmap"
+	fieldTY "github.com/mycontroller-org/server/v2/pkg/types/field"
+	nodeTY "github.com/mycontroller-org/server/v2/pkg/types/node"
+	schedulerTY "github.com/mycontroller-org/server/v2/pkg/types/scheduler"
+	sourceTY "github.com/mycontroller-org/server/v2/pkg/types/source"
+	vdTY "github.com/mycontroller-org/server/v2/pkg/types/virtual_device"
+	loggerUtils "github.com/mycontroller-org/server/v2/pkg/utils/logger"
+	"github.com/mycontroller-org/server/v2/plugin/bus/embedded"
+	metricTY "github.com/mycontroller-org/server/v2/plugin/database/metric/types"
+	"github.com/mycontroller-org/server/v2/plugin/database/storage/memory"
+	storageTY "github.com/mycontroller-org/server/v2/plugin/database/storage/types"
+	gwTY "github.com/mycontroller-org/server/v2/plugin/gateway/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// expected trait of a field, nil trait if the field is not supported
+type expectedTrait struct {
+	trait      string
+	deviceType string
+	labels     map[string]string
+}
+
+func assertFieldTrait(t *testing.T, expected *expectedTrait, actual *fieldTrait) {
+	if expected == nil {
+		assert.Nil(t, actual)
+		return
+	}
+	require.NotNil(t, actual)
+	assert.Equal(t, expected.trait, actual.trait)
+	assert.Equal(t, expected.deviceType, actual.deviceType)
+	labels := expected.labels
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	assert.Equal(t, labels, actual.labels)
+}
+
+func TestGetESPHomeTrait(t *testing.T) {
+	tests := []struct {
+		name        string
+		entityType  string
+		deviceClass string
+		sourceName  string
+		fieldID     string
+		unit        string
+		expected    *expectedTrait
+	}{
+		{name: "switch", entityType: esphomeSwitch, fieldID: "state", expected: &expectedTrait{trait: vdTY.DeviceTraitOnOff, deviceType: vdTY.DeviceTypeSwitch}},
+		{name: "switch other field", entityType: esphomeSwitch, fieldID: "restore_mode"},
+		{name: "light state", entityType: esphomeLight, fieldID: "state", expected: &expectedTrait{trait: vdTY.DeviceTraitOnOff, deviceType: vdTY.DeviceTypeLight}},
+		{name: "light brightness", entityType: esphomeLight, fieldID: "brightness", expected: &expectedTrait{trait: vdTY.DeviceTraitBrightness, deviceType: vdTY.DeviceTypeLight}},
+		{name: "light rgb", entityType: esphomeLight, fieldID: "rgb", expected: &expectedTrait{
+			trait: vdTY.DeviceTraitColorSetting, deviceType: vdTY.DeviceTypeLight,
+			labels: map[string]string{vdTY.LabelTraitParameter: vdTY.TraitParameterRGB, vdTY.LabelTraitUnit: vdTY.TraitUnitHex},
+		}},
+		{name: "light effect", entityType: esphomeLight, fieldID: "effect"},
+		{name: "fan", entityType: esphomeFan, fieldID: "state", expected: &expectedTrait{trait: vdTY.DeviceTraitOnOff, deviceType: vdTY.DeviceTypeFan}},
+		{name: "cover garage", entityType: esphomeCover, deviceClass: "garage", fieldID: "position", expected: &expectedTrait{
+			trait: vdTY.DeviceTraitOpenClose, deviceType: vdTY.DeviceTypeGarageDoor,
+			labels: map[string]string{vdTY.LabelTraitMin: "0", vdTY.LabelTraitMax: "1"},
+		}},
+		{name: "cover without device class", entityType: esphomeCover, fieldID: "position", expected: &expectedTrait{
+			trait: vdTY.DeviceTraitOpenClose, deviceType: vdTY.DeviceTypeBlinds,
+			labels: map[string]string{vdTY.LabelTraitMin: "0", vdTY.LabelTraitMax: "1"},
+		}},
+		{name: "climate setpoint", entityType: esphomeClimate, fieldID: "target_temperature", expected: &expectedTrait{
+			trait: vdTY.DeviceTraitTemperatureSetting, deviceType: vdTY.DeviceTypeThermostat,
+			labels: map[string]string{vdTY.LabelTraitParameter: vdTY.TraitParameterSetpoint},
+		}},
+		{name: "climate ambient", entityType: esphomeClimate, fieldID: "current_temperature", expected: &expectedTrait{
+			trait: vdTY.DeviceTraitTemperatureSetting, deviceType: vdTY.DeviceTypeThermostat,
+			labels: map[string]string{vdTY.LabelTraitParameter: vdTY.TraitParameterAmbient},
+		}},
+		{name: "sensor device class", entityType: esphomeSensor, deviceClass: "humidity", fieldID: "state", expected: &expectedTrait{
+			trait: vdTY.DeviceTraitSensorState, deviceType: vdTY.DeviceTypeSensor,
+			labels: map[string]string{vdTY.LabelTraitName: "humidity"},
+		}},
+		{name: "sensor unit", entityType: esphomeSensor, fieldID: "state", unit: metricTY.UnitCelsius, expected: &expectedTrait{
+			trait: vdTY.DeviceTraitSensorState, deviceType: vdTY.DeviceTypeSensor,
+			labels: map[string]string{vdTY.LabelTraitName: "temperature"},
+		}},
+		{name: "sensor name", entityType: esphomeSensor, sourceName: "Living Room Illuminance", fieldID: "state", expected: &expectedTrait{
+			trait: vdTY.DeviceTraitSensorState, deviceType: vdTY.DeviceTypeSensor,
+			labels: map[string]string{vdTY.LabelTraitName: "illuminance"},
+		}},
+		{name: "binary sensor alias", entityType: esphomeBinarySensor, deviceClass: "opening", fieldID: "state", expected: &expectedTrait{
+			trait: vdTY.DeviceTraitSensorState, deviceType: vdTY.DeviceTypeSensor,
+			labels: map[string]string{vdTY.LabelTraitName: "contact"},
+		}},
+		{name: "sensor not supported", entityType: esphomeSensor, deviceClass: "power", sourceName: "Power", fieldID: "state"},
+		{name: "entity type not supported", entityType: "text_sensor", fieldID: "state"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			group := &fieldGroup{
+				provider: providerESPHome,
+				source:   &sourceTY.Source{Name: test.sourceName, Labels: cmap.CustomStringMap{esphomeLabelDeviceClass: test.deviceClass}},
+			}
+			field := &fieldTY.Field{FieldID: test.fieldID, Unit: test.unit, Labels: cmap.CustomStringMap{esphomeLabelType: test.entityType}}
+			assertFieldTrait(t, test.expected, group.getESPHomeTrait(field))
+		})
+	}
+}
+
+func TestGetTasmotaTrait(t *testing.T) {
+	tests := []struct {
+		fieldID  string
+		expected *expectedTrait
+	}{
+		{fieldID: "POWER", expected: &expectedTrait{trait: vdTY.DeviceTraitOnOff}},
+		{fieldID: "POWER1", expected: &expectedTrait{trait: vdTY.DeviceTraitOnOff}},
+		{fieldID: "POWER12", expected: &expectedTrait{trait: vdTY.DeviceTraitOnOff}},
+		{fieldID: "Dimmer", expected: &expectedTrait{trait: vdTY.DeviceTraitBrightness, deviceType: vdTY.DeviceTypeLight}},
+		{fieldID: "POWERONSTATE"},
+		{fieldID: "power"},
+		{fieldID: "Color"},
+	}
+	for _, test := range tests {
+		t.Run(test.fieldID, func(t *testing.T) {
+			assertFieldTrait(t, test.expected, getTasmotaTrait(&fieldTY.Field{FieldID: test.fieldID}))
+		})
+	}
+
+	// only the control source uses the tasmota traits
+	group := &fieldGroup{provider: providerTasmota, source: &sourceTY.Source{SourceID: tasmotaSourceControl}}
+	assertFieldTrait(t, &expectedTrait{trait: vdTY.DeviceTraitBrightness, deviceType: vdTY.DeviceTypeLight}, group.getFieldTrait(&fieldTY.Field{FieldID: "Dimmer"}))
+	group.source.SourceID = "SENSOR"
+	assertFieldTrait(t, nil, group.getFieldTrait(&fieldTY.Field{FieldID: "Dimmer"}))
+}
+
+func TestGetGenericTrait(t *testing.T) {
+	tests := []struct {
+		name     string
+		field    fieldTY.Field
+		expected *expectedTrait
+	}{
+		{name: "writable binary", field: fieldTY.Field{FieldID: "relay", MetricType: metricTY.MetricTypeBinary}, expected: &expectedTrait{trait: vdTY.DeviceTraitOnOff}},
+		{name: "read only binary", field: fieldTY.Field{FieldID: "motion", MetricType: metricTY.MetricTypeBinary, Labels: cmap.CustomStringMap{types.LabelReadOnly: "true"}}, expected: &expectedTrait{
+			trait: vdTY.DeviceTraitSensorState, deviceType: vdTY.DeviceTypeSensor,
+			labels: map[string]string{vdTY.LabelTraitName: "motion"},
+		}},
+		{name: "read only binary name", field: fieldTY.Field{FieldID: "V_TRIPPED", Name: "Front Door", MetricType: metricTY.MetricTypeBinary, Labels: cmap.CustomStringMap{types.LabelReadOnly: "true"}}, expected: &expectedTrait{
+			trait: vdTY.DeviceTraitSensorState, deviceType: vdTY.DeviceTypeSensor,
+			labels: map[string]string{vdTY.LabelTraitName: "door"},
+		}},
+		{name: "read only binary unknown", field: fieldTY.Field{FieldID: "state", MetricType: metricTY.MetricTypeBinary, Labels: cmap.CustomStringMap{types.LabelReadOnly: "true"}}},
+		{name: "celsius", field: fieldTY.Field{FieldID: "V_TEMP", MetricType: metricTY.MetricTypeGaugeFloat, Unit: metricTY.UnitCelsius}, expected: &expectedTrait{
+			trait: vdTY.DeviceTraitSensorState, deviceType: vdTY.DeviceTypeSensor,
+			labels: map[string]string{vdTY.LabelTraitName: "temperature", vdTY.LabelTraitUnit: vdTY.TraitUnitCelsius},
+		}},
+		{name: "fahrenheit", field: fieldTY.Field{FieldID: "temp", MetricType: metricTY.MetricTypeGauge, Unit: metricTY.UnitFahrenheit}, expected: &expectedTrait{
+			trait: vdTY.DeviceTraitSensorState, deviceType: vdTY.DeviceTypeSensor,
+			labels: map[string]string{vdTY.LabelTraitName: "temperature", vdTY.LabelTraitUnit: vdTY.TraitUnitFahrenheit},
+		}},
+		{name: "humidity", field: fieldTY.Field{FieldID: "Humidity", MetricType: metricTY.MetricTypeGaugeFloat, Unit: metricTY.UnitPercent}, expected: &expectedTrait{
+			trait: vdTY.DeviceTraitSensorState, deviceType: vdTY.DeviceTypeSensor,
+			labels: map[string]string{vdTY.LabelTraitName: "humidity"},
+		}},
+		{name: "alias", field: fieldTY.Field{FieldID: "lux", MetricType: metricTY.MetricTypeGauge}, expected: &expectedTrait{
+			trait: vdTY.DeviceTraitSensorState, deviceType: vdTY.DeviceTypeSensor,
+			labels: map[string]string{vdTY.LabelTraitName: "illuminance"},
+		}},
+		{name: "gauge not supported", field: fieldTY.Field{FieldID: "voltage", MetricType: metricTY.MetricTypeGaugeFloat, Unit: metricTY.UnitVoltage}},
+		{name: "string", field: fieldTY.Field{FieldID: "temperature", MetricType: metricTY.MetricTypeString}},
+		{name: "counter", field: fieldTY.Field{FieldID: "motion", MetricType: metricTY.MetricTypeCounter}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assertFieldTrait(t, test.expected, getGenericTrait(&test.field))
+		})
+	}
+
+	// user defined labels takes the precedence
+	group := &fieldGroup{provider: providerESPHome, source: &sourceTY.Source{}}
+	field := &fieldTY.Field{FieldID: "voltage", Labels: cmap.CustomStringMap{
+		types.LabelTraitType:  vdTY.DeviceTraitTemperatureSetting,
+		types.LabelParamType:  vdTY.TraitParameterSetpoint,
+		types.LabelDeviceType: vdTY.DeviceTypeHeater,
+	}}
+	assertFieldTrait(t, &expectedTrait{
+		trait: vdTY.DeviceTraitTemperatureSetting, deviceType: vdTY.DeviceTypeHeater,
+		labels: map[string]string{vdTY.LabelTraitParameter: vdTY.TraitParameterSetpoint},
+	}, group.getFieldTrait(field))
+}
+
+func TestGetDeviceType(t *testing.T) {
+	tests := []struct {
+		name         string
+		traits       []string
+		hint         string
+		fieldLabels  cmap.CustomStringMap
+		sourceLabels cmap.CustomStringMap
+		nodeLabels   cmap.CustomStringMap
+		expected     string
+	}{
+		{name: "field label", traits: []string{vdTY.DeviceTraitOnOff}, fieldLabels: cmap.CustomStringMap{types.LabelDeviceType: vdTY.DeviceTypeOutlet}, sourceLabels: cmap.CustomStringMap{types.LabelDeviceType: vdTY.DeviceTypeFan}, expected: vdTY.DeviceTypeOutlet},
+		{name: "source label", traits: []string{vdTY.DeviceTraitOnOff}, sourceLabels: cmap.CustomStringMap{types.LabelDeviceType: vdTY.DeviceTypeFan}, nodeLabels: cmap.CustomStringMap{types.LabelDeviceType: vdTY.DeviceTypeLight}, expected: vdTY.DeviceTypeFan},
+		{name: "node label", traits: []string{vdTY.DeviceTraitOnOff}, nodeLabels: cmap.CustomStringMap{types.LabelDeviceType: vdTY.DeviceTypeLight}, expected: vdTY.DeviceTypeLight},
+		{name: "temperature setting", traits: []string{vdTY.DeviceTraitOnOff, vdTY.DeviceTraitTemperatureSetting}, expected: vdTY.DeviceTypeThermostat},
+		{name: "open close", traits: []string{vdTY.DeviceTraitOpenClose}, sourceLabels: cmap.CustomStringMap{esphomeLabelDeviceClass: "Gate"}, expected: vdTY.DeviceTypeGate},
+		{name: "open close default", traits: []string{vdTY.DeviceTraitOpenClose}, expected: vdTY.DeviceTypeBlinds},
+		{name: "fan speed", traits: []string{vdTY.DeviceTraitOnOff, vdTY.DeviceTraitFanSpeed}, expected: vdTY.DeviceTypeFan},
+		{name: "brightness", traits: []string{vdTY.DeviceTraitOnOff, vdTY.DeviceTraitBrightness}, expected: vdTY.DeviceTypeLight},
+		{name: "color", traits: []string{vdTY.DeviceTraitColorSetting}, expected: vdTY.DeviceTypeLight},
+		{name: "provider hint", traits: []string{vdTY.DeviceTraitOnOff}, hint: vdTY.DeviceTypeFan, expected: vdTY.DeviceTypeFan},
+		{name: "on off", traits: []string{vdTY.DeviceTraitOnOff}, expected: vdTY.DeviceTypeSwitch},
+		{name: "sensor", traits: []string{vdTY.DeviceTraitSensorState}, expected: vdTY.DeviceTypeSensor},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			group := &fieldGroup{
+				source: &sourceTY.Source{Labels: test.sourceLabels},
+				node:   &nodeTY.Node{Labels: test.nodeLabels},
+			}
+			device := &generatedDevice{group: group}
+			traits := make([]vdTY.Resource, 0)
+			for _, trait := range test.traits {
+				resource := vdTY.Resource{TraitType: trait}
+				traits = append(traits, resource)
+				device.resources = append(device.resources, fieldResource{
+					field:    &fieldTY.Field{Labels: test.fieldLabels},
+					resource: resource,
+					hint:     test.hint,
+				})
+			}
+			assert.Equal(t, test.expected, device.getDeviceType(traits))
+		})
+	}
+}
+
+func newTestAPI(t *testing.T) *VirtualDeviceAPI {
+	ctx := loggerUtils.WithContext(context.Background(), zap.NewNop())
+	ctx = schedulerTY.WithContext(ctx, coreScheduler.New())
+	storage, err := memory.New(ctx, cmap.CustomMap{})
+	require.NoError(t, err)
+	bus, err := embedded.NewClient(ctx, cmap.CustomMap{})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = bus.Close() })
+	return New(ctx, zap.NewNop(), storage, bus)
+}
+
+func upsert(t *testing.T, storage storageTY.Plugin, entityType, id string, data interface{}) {
+	require.NoError(t, storage.Upsert(entityType, data, []storageTY.Filter{{Key: types.KeyID, Value: id}}))
+}
+
+func TestUpdateGenerated(t *testing.T) {
+	vd := newTestAPI(t)
+	upsert(t, vd.storage, types.EntityGateway, "gw", &gwTY.Config{ID: "gw", Provider: cmap.CustomMap{types.KeyType: "mysensors_v2"}})
+	upsert(t, vd.storage, types.EntityNode, "gw.node", &nodeTY.Node{ID: "gw.node", GatewayID: "gw", NodeID: "node", Name: "Kitchen"})
+	upsert(t, vd.storage, types.EntitySource, "gw.node.relay", &sourceTY.Source{ID: "gw.node.relay", GatewayID: "gw", NodeID: "node", SourceID: "relay", Name: "Relay"})
+	addField := func(fieldID, metricType, unit string) {
+		id := "gw.node.relay." + fieldID
+		upsert(t, vd.storage, types.EntityField, id, &fieldTY.Field{ID: id, GatewayID: "gw", NodeID: "node", SourceID: "relay", FieldID: fieldID, MetricType: metricType, Unit: unit})
+	}
+	addField("power", metricTY.MetricTypeBinary, "")
+
+	proposals, err := vd.Generate(nil)
+	require.NoError(t, err)
+	require.Len(t, proposals, 1)
+	assert.Equal(t, vdTY.ProposalStatusNew, proposals[0].Status)
+	assert.Equal(t, "gw.node.relay", proposals[0].Key)
+	assert.Equal(t, "Kitchen", proposals[0].Device.Name)
+	assert.Equal(t, vdTY.DeviceTypeSwitch, proposals[0].Device.DeviceType)
+	saved, err := vd.Accept([]vdTY.VirtualDevice{proposals[0].Device})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), saved)
+
+	// accepted device is not proposed again
+	proposals, err = vd.Generate(nil)
+	require.NoError(t, err)
+	assert.Empty(t, proposals)
+
+	// new field extends the generated device
+	addField("temperature", metricTY.MetricTypeGaugeFloat, metricTY.UnitCelsius)
+	require.NoError(t, vd.UpdateGenerated("gw", "node"))
+
+	devices, err := findAll[vdTY.VirtualDevice](vd.storage, types.EntityVirtualDevice, nil)
+	require.NoError(t, err)
+	require.Len(t, devices, 1)
+	device := devices[0]
+	require.Len(t, device.Traits, 2)
+	assert.Equal(t, vdTY.DeviceTraitOnOff, device.Traits[0].TraitType)
+	assert.Equal(t, "gw.node.relay.power", device.Traits[0].QuickID)
+	assert.Equal(t, vdTY.DeviceTraitSensorState, device.Traits[1].TraitType)
+	assert.Equal(t, "gw.node.relay.temperature", device.Traits[1].QuickID)
+	assert.Equal(t, "temperature", device.Traits[1].Labels.Get(vdTY.LabelTraitName))
+	assert.Equal(t, []string{"field:gw.node.relay.power", "field:gw.node.relay.temperature"}, device.Resources)
+
+	// second on_off field is a new device, not created without review
+	addField("power2", metricTY.MetricTypeBinary, "")
+	require.NoError(t, vd.UpdateGenerated("gw", "node"))
+	devices, err = findAll[vdTY.VirtualDevice](vd.storage, types.EntityVirtualDevice, nil)
+	require.NoError(t, err)
+	require.Len(t, devices, 1)
+	proposals, err = vd.Generate(nil)
+	require.NoError(t, err)
+	require.Len(t, proposals, 1)
+	assert.Equal(t, vdTY.ProposalStatusNew, proposals[0].Status)
+	assert.Equal(t, "gw.node.relay.power2", proposals[0].Key)
+
+	// updates stopped on the device, generated label set to false
+	device.Labels.Set(vdTY.LabelGenerated, "false")
+	require.NoError(t, vd.Save(&device))
+	addField("humidity", metricTY.MetricTypeGaugeFloat, metricTY.UnitPercent)
+	require.NoError(t, vd.UpdateGenerated("gw", "node"))
+	updated, err := vd.GetByID(device.ID)
+	require.NoError(t, err)
+	assert.Len(t, updated.Traits, 2)
+}
@@ -26,6 +26,7 @@ func GetStorageApiMap(ctx context.Context) (map[string]backupTY.Backup, error) {
 		types.EntityForwardPayload:   entities.ForwardPayload(),
 		types.EntityGateway:          entities.Gateway(),
 		types.EntityHandler:          entities.Handler(),
+		types.EntityInboundWebhook:   entities.InboundWebhook(),
 		types.EntityNode:             entities.Node(),
 		types.EntitySchedule:         entities.Schedule(),
 		types.EntitySettings:         entities.Settings(),
@@ -30,12 +30,14 @@ var (
 	}
 	nonRestrictedAPIs = []string{
 		"/api/status",                            // reports mycontroller server status
+		"/api/openapi.json",                      // api specification
 		"/api/user/registration",                 // register new user. TODO: this api not used. verify and remove this
 		"/api/user/login",                        // login api
 		handlerTY.InsecureShareDirWebHandlerPath, // web file insecure share api
 		"/api/oauth/login",                       // oauth login api
 		"/api/oauth/token",                       // oauth token api
 		"/api/plugin/gateway",                    // gateway plugin api
+		"/api/inbound-webhook/",                  // inbound webhook trigger, verified with the webhook token
 	}
 )
 
@@ -0,0 +1,38 @@
+package routes
+
+import (
+	"net/http"
+
+	json "github.com/mycontroller-org/server/v2/pkg/json"
+	manifestTY "github.com/mycontroller-org/server/v2/pkg/types/manifest"
+	handlerUtils "github.com/mycontroller-org/server/v2/pkg/utils/http_handler"
+)
+
+// registerApplyRoutes registers apply api
+func (h *Routes) registerApplyRoutes() {
+	h.router.HandleFunc("/api/apply", h.applyManifests).Methods(http.MethodPost)
+}
+
+func (h *Routes) applyManifests(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	request := &manifestTY.ApplyRequest{}
+	err := handlerUtils.LoadEntity(w, r, request)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	plan, err := h.applyAPI.Apply(request)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	od, err := json.Marshal(plan)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	handlerUtils.WriteResponse(w, od)
+}
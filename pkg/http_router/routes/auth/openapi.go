@@ -0,0 +1,25 @@
+package auth
+
+import (
+	"net/http"
+
+	userTY "github.com/mycontroller-org/server/v2/pkg/types/user"
+	handlerTY "github.com/mycontroller-org/server/v2/pkg/types/web_handler"
+	openapi "github.com/mycontroller-org/server/v2/pkg/utils/openapi"
+)
+
+const contentTypeForm = "application/x-www-form-urlencoded"
+
+// OpenAPIRoutes returns api specification of the auth and oauth routes
+func OpenAPIRoutes() []openapi.Route {
+	return []openapi.Route{
+		{Method: http.MethodPost, Path: "/api/user/login", Tag: "auth", Summary: "login with username/password or service token", Public: true, Request: handlerTY.UserLogin{}, Response: handlerTY.JwtTokenResponse{}},
+		{Method: http.MethodGet, Path: "/api/user/profile", Tag: "auth", Summary: "get profile of the logged in user", Response: userTY.User{}},
+		{Method: http.MethodPost, Path: "/api/user/profile", Tag: "auth", Summary: "update profile of the logged in user", Request: userTY.UserProfileUpdate{}},
+
+		{Method: http.MethodGet, Path: "/api/oauth/login", Tag: "oauth", Summary: "oauth login page", Public: true, ContentType: openapi.ContentTypeHTML, Response: ""},
+		{Method: http.MethodPost, Path: "/api/oauth/login", Tag: "oauth", Summary: "oauth login", Public: true, RequestType: contentTypeForm, Request: map[string]string{}},
+		{Method: http.MethodPost, Path: "/api/oauth/token", Tag: "oauth", Summary: "oauth token exchange", Public: true, RequestType: contentTypeForm, Request: map[string]string{}, Response: map[string]interface{}{}},
+		{Method: http.MethodPost, Path: "/api/oauth/token-alexa", Tag: "oauth", Summary: "oauth token exchange for alexa", Public: true, RequestType: contentTypeForm, Request: map[string]string{}, Response: map[string]interface{}{}},
+	}
+}
@@ -17,6 +17,7 @@ func (h *Routes) registerBackupRestoreRoutes() {
 	h.router.HandleFunc("/api/backup", h.deleteBackupFile).Methods(http.MethodDelete)
 	h.router.HandleFunc("/api/backup/run", h.runBackup).Methods(http.MethodPost)
 	h.router.HandleFunc("/api/restore/run", h.runRestore).Methods(http.MethodGet)
+	h.router.HandleFunc("/api/restore/run", h.runRestoreWithConfig).Methods(http.MethodPost)
 }
 
 func (h *Routes) listBackupFiles(w http.ResponseWriter, r *http.Request) {
@@ -57,11 +58,24 @@ func (h *Routes) deleteBackupFile(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Routes) runRestore(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
+	h.restore(w, &backupTY.RestoreConfig{ID: r.URL.Query().Get("id")})
+}
 
-	id := r.URL.Query().Get("id")
+// passphrase and identity should not be sent as query parameters
+func (h *Routes) runRestoreWithConfig(w http.ResponseWriter, r *http.Request) {
+	restoreCfg := &backupTY.RestoreConfig{}
+	err := handlerUtils.LoadEntity(w, r, restoreCfg)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	h.restore(w, restoreCfg)
+}
+
+func (h *Routes) restore(w http.ResponseWriter, restoreCfg *backupTY.RestoreConfig) {
+	w.Header().Set("Content-Type", "application/json")
 
-	filter := storageTY.Filter{Key: "id", Operator: storageTY.OperatorEqual, Value: id}
+	filter := storageTY.Filter{Key: "id", Operator: storageTY.OperatorEqual, Value: restoreCfg.ID}
 
 	result, err := h.backupAPI.List([]storageTY.Filter{filter}, nil)
 	if err != nil {
@@ -91,8 +105,8 @@ func (h *Routes) runRestore(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.backupAPI.RunRestore(file)
-	if len(data) != 1 {
+	err = h.backupAPI.RunRestore(file, restoreCfg)
+	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -20,6 +20,10 @@ func (h *Routes) registerHandlerRoutes() {
 	h.router.HandleFunc("/api/handler/disable", h.disableHandler).Methods(http.MethodPost)
 	h.router.HandleFunc("/api/handler/reload", h.reloadHandler).Methods(http.MethodPost)
 	h.router.HandleFunc("/api/handler", h.deleteHandler).Methods(http.MethodDelete)
+	h.router.HandleFunc("/api/handler-deadletter", h.listDeadLetters).Methods(http.MethodGet)
+	h.router.HandleFunc("/api/handler-deadletter/retry", h.retryDeadLetters).Methods(http.MethodPost)
+	h.router.HandleFunc("/api/handler-deadletter", h.discardDeadLetters).Methods(http.MethodDelete)
+	h.router.HandleFunc("/api/handler-delivery-stats", h.getDeliveryStats).Methods(http.MethodGet)
 }
 
 func (h *Routes) listHandler(w http.ResponseWriter, r *http.Request) {
@@ -108,3 +112,52 @@ func (h *Routes) deleteHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	handlerUtils.UpdateData(w, r, &IDs, updateFn)
 }
+
+func (h *Routes) listDeadLetters(w http.ResponseWriter, r *http.Request) {
+	params, err := handlerUtils.ReceivedQueryMap(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	handlerID := handlerUtils.GetParameter("handlerId", params)
+	deadLetters, err := h.api.Handler().ListDeadLetters(handlerID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	handlerUtils.PostSuccessResponse(w, deadLetters)
+}
+
+func (h *Routes) retryDeadLetters(w http.ResponseWriter, r *http.Request) {
+	ids := []string{}
+	updateFn := func(f []storageTY.Filter, p *storageTY.Pagination, d []byte) (interface{}, error) {
+		err := h.api.Handler().RetryDeadLetters(ids)
+		if err != nil {
+			return nil, err
+		}
+		return "Retry requested", nil
+	}
+	handlerUtils.UpdateData(w, r, &ids, updateFn)
+}
+
+func (h *Routes) discardDeadLetters(w http.ResponseWriter, r *http.Request) {
+	ids := []string{}
+	updateFn := func(f []storageTY.Filter, p *storageTY.Pagination, d []byte) (interface{}, error) {
+		err := h.api.Handler().DiscardDeadLetters(ids)
+		if err != nil {
+			return nil, err
+		}
+		return "Discard requested", nil
+	}
+	handlerUtils.UpdateData(w, r, &ids, updateFn)
+}
+
+func (h *Routes) getDeliveryStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.api.Handler().GetDeliveryStats()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	handlerUtils.PostSuccessResponse(w, stats)
+}
@@ -0,0 +1,171 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	inboundWebhookAPI "github.com/mycontroller-org/server/v2/pkg/api/inbound_webhook"
+	"github.com/mycontroller-org/server/v2/pkg/json"
+	types "github.com/mycontroller-org/server/v2/pkg/types"
+	webhookTY "github.com/mycontroller-org/server/v2/pkg/types/inbound_webhook"
+	handlerUtils "github.com/mycontroller-org/server/v2/pkg/utils/http_handler"
+	storageTY "github.com/mycontroller-org/server/v2/plugin/database/storage/types"
+)
+
+const (
+	inboundWebhookMaxBodySize = 1024 * 1024 // 1 MB
+)
+
+// registerInboundWebhookRoutes registers inbound webhook api
+func (h *Routes) registerInboundWebhookRoutes() {
+	h.router.HandleFunc("/api/inboundwebhook", h.listInboundWebhook).Methods(http.MethodGet)
+	h.router.HandleFunc("/api/inboundwebhook/{id}", h.getInboundWebhook).Methods(http.MethodGet)
+	h.router.HandleFunc("/api/inboundwebhook", h.updateInboundWebhook).Methods(http.MethodPost)
+	h.router.HandleFunc("/api/inboundwebhook", h.deleteInboundWebhook).Methods(http.MethodDelete)
+	h.router.HandleFunc("/api/inboundwebhook/enable", h.enableInboundWebhook).Methods(http.MethodPost)
+	h.router.HandleFunc("/api/inboundwebhook/disable", h.disableInboundWebhook).Methods(http.MethodPost)
+
+	// public endpoint, verified with the webhook token
+	h.router.HandleFunc("/api/inbound-webhook/{id}", h.triggerInboundWebhook).Methods(http.MethodGet, http.MethodPost, http.MethodPut)
+}
+
+func (h *Routes) listInboundWebhook(w http.ResponseWriter, r *http.Request) {
+	handlerUtils.FindMany(h.storage, w, r, types.EntityInboundWebhook, &[]webhookTY.Config{})
+}
+
+func (h *Routes) getInboundWebhook(w http.ResponseWriter, r *http.Request) {
+	handlerUtils.FindOne(h.storage, w, r, types.EntityInboundWebhook, &webhookTY.Config{})
+}
+
+func (h *Routes) updateInboundWebhook(w http.ResponseWriter, r *http.Request) {
+	entity := &webhookTY.Config{}
+	err := handlerUtils.LoadEntity(w, r, entity)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	token, err := h.api.InboundWebhook().SaveAndGetToken(entity)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// generated token is returned only once, it is stored encrypted
+	if token != "" {
+		handlerUtils.PostSuccessResponse(w, map[string]interface{}{"token": token})
+	}
+}
+
+func (h *Routes) deleteInboundWebhook(w http.ResponseWriter, r *http.Request) {
+	ids := []string{}
+	updateFn := func(f []storageTY.Filter, p *storageTY.Pagination, d []byte) (interface{}, error) {
+		if len(ids) > 0 {
+			count, err := h.api.InboundWebhook().Delete(ids)
+			if err != nil {
+				return nil, err
+			}
+			return fmt.Sprintf("deleted: %d", count), nil
+		}
+		return nil, errors.New("supply id(s)")
+	}
+	handlerUtils.UpdateData(w, r, &ids, updateFn)
+}
+
+func (h *Routes) enableInboundWebhook(w http.ResponseWriter, r *http.Request) {
+	ids := []string{}
+	updateFn := func(f []storageTY.Filter, p *storageTY.Pagination, d []byte) (interface{}, error) {
+		if len(ids) > 0 {
+			err := h.api.InboundWebhook().Enable(ids)
+			if err != nil {
+				return nil, err
+			}
+			return "Enabled", nil
+		}
+		return nil, errors.New("supply a webhook id")
+	}
+	handlerUtils.UpdateData(w, r, &ids, updateFn)
+}
+
+func (h *Routes) disableInboundWebhook(w http.ResponseWriter, r *http.Request) {
+	ids := []string{}
+	updateFn := func(f []storageTY.Filter, p *storageTY.Pagination, d []byte) (interface{}, error) {
+		if len(ids) > 0 {
+			err := h.api.InboundWebhook().Disable(ids)
+			if err != nil {
+				return nil, err
+			}
+			return "Disabled", nil
+		}
+		return nil, errors.New("supply a webhook id")
+	}
+	handlerUtils.UpdateData(w, r, &ids, updateFn)
+}
+
+func (h *Routes) triggerInboundWebhook(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, inboundWebhookMaxBodySize))
+	if err != nil {
+		handlerUtils.PostErrorResponse(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	// token from the header, bearer authorization or from the query parameter
+	token := r.Header.Get(webhookTY.HeaderToken)
+	if token == "" {
+		if authorization := r.Header.Get("Authorization"); strings.HasPrefix(authorization, "Bearer ") {
+			token = strings.TrimPrefix(authorization, "Bearer ")
+		}
+	}
+	query := r.URL.Query()
+	if token == "" {
+		token = query.Get(webhookTY.QueryParamToken)
+	}
+
+	request := &webhookTY.Request{
+		Method:        r.Method,
+		Headers:       map[string]string{},
+		Query:         map[string]interface{}{},
+		Body:          string(body),
+		RemoteAddress: r.RemoteAddr,
+		Timestamp:     time.Now(),
+	}
+	// token should not be exposed to the tasks and schedules
+	for key := range r.Header {
+		if key != webhookTY.HeaderToken && key != "Authorization" && key != "Cookie" {
+			request.Headers[key] = r.Header.Get(key)
+		}
+	}
+	for key, values := range query {
+		if key == webhookTY.QueryParamToken {
+			continue
+		}
+		if len(values) == 1 {
+			request.Query[key] = values[0]
+		} else {
+			request.Query[key] = values
+		}
+	}
+	if len(body) > 0 {
+		var data interface{}
+		if err := json.Unmarshal(body, &data); err == nil {
+			request.Data = data
+		}
+	}
+
+	err = h.api.InboundWebhook().Trigger(id, token, request)
+	if err != nil {
+		if errors.Is(err, inboundWebhookAPI.ErrUnauthorized) {
+			handlerUtils.PostErrorResponse(w, "401 Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		handlerUtils.PostErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	handlerUtils.PostSuccessResponse(w, map[string]interface{}{"success": true})
+}
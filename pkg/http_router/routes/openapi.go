@@ -0,0 +1,222 @@
+package routes
+
+import (
+	"net/http"
+	"sync"
+
+	statusAPI "github.com/mycontroller-org/server/v2/pkg/api/status"
+	authRoutes "github.com/mycontroller-org/server/v2/pkg/http_router/routes/auth"
+	json "github.com/mycontroller-org/server/v2/pkg/json"
+	dashboardTY "github.com/mycontroller-org/server/v2/pkg/types/dashboard"
+	dataRepositoryTY "github.com/mycontroller-org/server/v2/pkg/types/data_repository"
+	fieldTY "github.com/mycontroller-org/server/v2/pkg/types/field"
+	fwTY "github.com/mycontroller-org/server/v2/pkg/types/firmware"
+	fwdPayloadTY "github.com/mycontroller-org/server/v2/pkg/types/forward_payload"
+	inboundWebhookTY "github.com/mycontroller-org/server/v2/pkg/types/inbound_webhook"
+	manifestTY "github.com/mycontroller-org/server/v2/pkg/types/manifest"
+	msgTY "github.com/mycontroller-org/server/v2/pkg/types/message"
+	nodeTY "github.com/mycontroller-org/server/v2/pkg/types/node"
+	schedulerTY "github.com/mycontroller-org/server/v2/pkg/types/scheduler"
+	svcTokenTY "github.com/mycontroller-org/server/v2/pkg/types/service_token"
+	settingsTY "github.com/mycontroller-org/server/v2/pkg/types/settings"
+	sourceTY "github.com/mycontroller-org/server/v2/pkg/types/source"
+	taskTY "github.com/mycontroller-org/server/v2/pkg/types/task"
+	vdTY "github.com/mycontroller-org/server/v2/pkg/types/virtual_device"
+	webHandlerTY "github.com/mycontroller-org/server/v2/pkg/types/web_handler"
+	handlerUtils "github.com/mycontroller-org/server/v2/pkg/utils/http_handler"
+	openapi "github.com/mycontroller-org/server/v2/pkg/utils/openapi"
+	"github.com/mycontroller-org/server/v2/pkg/version"
+	mtsTY "github.com/mycontroller-org/server/v2/plugin/database/metric/types"
+	backupTY "github.com/mycontroller-org/server/v2/plugin/database/storage/backup"
+	gwTY "github.com/mycontroller-org/server/v2/plugin/gateway/types"
+	handlerTY "github.com/mycontroller-org/server/v2/plugin/handler/types"
+	vaTY "github.com/mycontroller-org/server/v2/plugin/virtual_assistant/types"
+)
+
+// OpenAPIPath is the well known path of the api specification
+const OpenAPIPath = "/api/openapi.json"
+
+var (
+	openAPIOnce sync.Once
+	openAPIData []byte
+	openAPIErr  error
+)
+
+// registerOpenAPIRoutes registers api specification route
+func (h *Routes) registerOpenAPIRoutes() {
+	h.router.HandleFunc(OpenAPIPath, h.getOpenAPI).Methods(http.MethodGet)
+}
+
+func (h *Routes) getOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// routes and types are static, generate the document only once
+	openAPIOnce.Do(func() {
+		doc, err := OpenAPIDocument()
+		if err != nil {
+			openAPIErr = err
+			return
+		}
+		openAPIData, openAPIErr = json.Marshal(doc)
+	})
+	if openAPIErr != nil {
+		http.Error(w, openAPIErr.Error(), http.StatusInternalServerError)
+		return
+	}
+	handlerUtils.WriteResponse(w, openAPIData)
+}
+
+// OpenAPIDocument returns OpenAPI specification of the server api
+func OpenAPIDocument() (*openapi.Document, error) {
+	info := openapi.Info{
+		Title:       "MyController Server API",
+		Description: "REST api of the MyController server",
+		Version:     version.Get().Version,
+	}
+	return openapi.Build(info, OpenAPIRoutes())
+}
+
+// OpenAPIRoutes returns api specification of all the registered routes
+// should be updated on adding a new route
+func OpenAPIRoutes() []openapi.Route {
+	apiRoutes := []openapi.Route{
+		{Method: http.MethodGet, Path: OpenAPIPath, Tag: "system", Summary: "api specification", Public: true, Response: map[string]interface{}{}},
+	}
+
+	apiRoutes = append(apiRoutes, openAPIEntityRoutes("gateway", "/api/gateway", gwTY.Config{}, true, true)...)
+	apiRoutes = append(apiRoutes, openAPIEntityRoutes("node", "/api/node", nodeTY.Node{}, false, false)...)
+	apiRoutes = append(apiRoutes, openAPIEntityRoutes("source", "/api/source", sourceTY.Source{}, false, false)...)
+	apiRoutes = append(apiRoutes, openAPIEntityRoutes("field", "/api/field", fieldTY.Field{}, false, false)...)
+	apiRoutes = append(apiRoutes, openAPIEntityRoutes("firmware", "/api/firmware", fwTY.Firmware{}, false, false)...)
+	apiRoutes = append(apiRoutes, openAPIEntityRoutes("task", "/api/task", taskTY.Config{}, true, false)...)
+	apiRoutes = append(apiRoutes, openAPIEntityRoutes("schedule", "/api/schedule", schedulerTY.Config{}, true, false)...)
+	apiRoutes = append(apiRoutes, openAPIEntityRoutes("handler", "/api/handler", handlerTY.Config{}, true, true)...)
+	apiRoutes = append(apiRoutes, openAPIEntityRoutes("forward_payload", "/api/forwardpayload", fwdPayloadTY.Config{}, true, false)...)
+	apiRoutes = append(apiRoutes, openAPIEntityRoutes("dashboard", "/api/dashboard", dashboardTY.Config{}, false, false)...)
+	apiRoutes = append(apiRoutes, openAPIEntityRoutes("data_repository", "/api/datarepository", dataRepositoryTY.Config{}, false, false)...)
+	apiRoutes = append(apiRoutes, openAPIEntityRoutes("virtual_assistant", "/api/virtualassistant", vaTY.Config{}, true, false)...)
+	apiRoutes = append(apiRoutes, openAPIEntityRoutes("virtual_device", "/api/virtualdevice", vdTY.VirtualDevice{}, false, false)...)
+	apiRoutes = append(apiRoutes, openAPIEntityRoutes("inbound_webhook", "/api/inboundwebhook", inboundWebhookTY.Config{}, true, false)...)
+
+	webhookTokenQuery := []openapi.Parameter{openapi.QueryParameter(inboundWebhookTY.QueryParamToken, "webhook token, if not supplied on the header", false)}
+	for _, method := range []string{http.MethodGet, http.MethodPost, http.MethodPut} {
+		apiRoutes = append(apiRoutes, openapi.Route{Method: method, Path: "/api/inbound-webhook/{id}", Tag: "inbound_webhook", Summary: "trigger an inbound webhook", Public: true, Query: webhookTokenQuery, Request: map[string]interface{}{}, Response: map[string]interface{}{}})
+	}
+
+	gatewayQuery := []openapi.Parameter{
+		openapi.QueryParameter("gatewayId", "gateway id", true),
+		openapi.QueryParameter("nodeId", "node id", false),
+	}
+	idsQuery := []openapi.Parameter{
+		openapi.QueryParameter(keyAction, "action name", true),
+		openapi.QueryParameter(keyID, "id, can be repeated", true),
+	}
+	metricQuery := []openapi.Parameter{
+		openapi.QueryParameter(QuickID, "quick id of the resource", true),
+		openapi.QueryParameter(mtsTY.QueryKeyStart, "start time, ex: -1h", false),
+		openapi.QueryParameter(mtsTY.QueryKeyStop, "stop time", false),
+		openapi.QueryParameter(mtsTY.QueryKeyWindow, "aggregation window, ex: 5m", false),
+		openapi.QueryParameter(mtsTY.QueryKeyFunctions, "aggregation function, can be repeated", false),
+	}
+
+	apiRoutes = append(apiRoutes, []openapi.Route{
+		// gateway sleeping queue
+		{Method: http.MethodGet, Path: "/api/gateway-sleeping-queue", Tag: "gateway", Summary: "get sleeping queue messages", Query: gatewayQuery, Response: map[string][]msgTY.Message{}},
+		{Method: http.MethodGet, Path: "/api/gateway-sleeping-queue/clear", Tag: "gateway", Summary: "clear sleeping queue messages", Query: gatewayQuery},
+
+		// handler deliveries
+		{Method: http.MethodGet, Path: "/api/handler-deadletter", Tag: "handler", Summary: "list failed deliveries", Query: []openapi.Parameter{openapi.QueryParameter("handlerId", "handler id", false)}, List: true, Response: handlerTY.Delivery{}},
+		{Method: http.MethodPost, Path: "/api/handler-deadletter/retry", Tag: "handler", Summary: "retry failed deliveries", Request: []string{}, Response: ""},
+		{Method: http.MethodDelete, Path: "/api/handler-deadletter", Tag: "handler", Summary: "discard failed deliveries", Request: []string{}, Response: ""},
+		{Method: http.MethodGet, Path: "/api/handler-delivery-stats", Tag: "handler", Summary: "get delivery stats of the handlers", List: true, Response: handlerTY.DeliveryStats{}},
+
+		// firmware upload
+		{Method: http.MethodPost, Path: "/api/firmware/upload/{id}", Tag: "firmware", Summary: "upload firmware file as multipart form field 'file'", RequestType: "multipart/form-data", Request: struct {
+			File []byte `json:"file"`
+		}{}},
+
+		// service token
+		{Method: http.MethodGet, Path: "/api/servicetoken", Tag: "service_token", Summary: "list service tokens", List: true, Response: svcTokenTY.ServiceToken{}},
+		{Method: http.MethodGet, Path: "/api/servicetoken/{id}", Tag: "service_token", Summary: "get a service token", Response: svcTokenTY.ServiceToken{}},
+		{Method: http.MethodPost, Path: "/api/servicetoken/create", Tag: "service_token", Summary: "create a service token", Request: svcTokenTY.ServiceToken{}, Response: svcTokenTY.CreateTokenResponse{}},
+		{Method: http.MethodPost, Path: "/api/servicetoken/update", Tag: "service_token", Summary: "update a service token", Request: svcTokenTY.ServiceToken{}},
+		{Method: http.MethodDelete, Path: "/api/servicetoken", Tag: "service_token", Summary: "delete service tokens", Request: []string{}, Response: ""},
+
+		// action
+		{Method: http.MethodGet, Path: "/api/action", Tag: "action", Summary: "execute an action on a resource", Query: []openapi.Parameter{
+			openapi.QueryParameter(keyResource, "quick id of the resource", true),
+			openapi.QueryParameter(keyPayload, "payload", true),
+			openapi.QueryParameter(keyKeyPath, "key path", false),
+		}},
+		{Method: http.MethodPost, Path: "/api/action", Tag: "action", Summary: "execute actions on resources", Request: []webHandlerTY.ActionConfig{}},
+		{Method: http.MethodGet, Path: "/api/action/node", Tag: "action", Summary: "execute an action on nodes", Query: idsQuery},
+		{Method: http.MethodGet, Path: "/api/action/gateway", Tag: "action", Summary: "execute an action on gateways", Query: idsQuery},
+
+		// apply manifests
+		{Method: http.MethodPost, Path: "/api/apply", Tag: "apply", Summary: "apply entity manifests, returns the plan", Request: manifestTY.ApplyRequest{}, Response: manifestTY.Plan{}},
+
+		// backup and restore
+		{Method: http.MethodGet, Path: "/api/backup", Tag: "backup", Summary: "list backup files", List: true, Response: backupTY.BackupFile{}},
+		{Method: http.MethodDelete, Path: "/api/backup", Tag: "backup", Summary: "delete backup files", Request: []string{}, Response: ""},
+		{Method: http.MethodPost, Path: "/api/backup/run", Tag: "backup", Summary: "run on demand backup", Request: backupTY.OnDemandBackupConfig{}},
+		{Method: http.MethodGet, Path: "/api/restore/run", Tag: "backup", Summary: "restore from a backup file", ContentType: openapi.ContentTypeText, Response: "", Query: []openapi.Parameter{
+			openapi.QueryParameter("id", "backup file id", true),
+		}},
+		{Method: http.MethodPost, Path: "/api/restore/run", Tag: "backup", Summary: "restore from a backup file, supports encrypted backup", ContentType: openapi.ContentTypeText, Request: backupTY.RestoreConfig{}, Response: ""},
+
+		// metric
+		{Method: http.MethodGet, Path: "/api/metric", Tag: "metric", Summary: "query metric of a resource", Query: metricQuery, Response: map[string][]mtsTY.ResponseData{}},
+		{Method: http.MethodPost, Path: "/api/metric", Tag: "metric", Summary: "query metrics", Request: mtsTY.QueryConfig{}, Response: map[string][]mtsTY.ResponseData{}},
+
+		// quick id
+		{Method: http.MethodGet, Path: "/api/quickid", Tag: "quick_id", Summary: "get resources by quick id", Response: map[string]interface{}{}, Query: []openapi.Parameter{
+			openapi.QueryParameter("id", "quick id, can be repeated", true),
+		}},
+
+		// virtual device generator
+		{Method: http.MethodPost, Path: "/api/virtualdevice/generate", Tag: "virtual_device", Summary: "propose virtual devices from the nodes and fields", Request: vdTY.GeneratorRequest{}, Response: []vdTY.Proposal{}},
+		{Method: http.MethodPost, Path: "/api/virtualdevice/generate/accept", Tag: "virtual_device", Summary: "save the reviewed proposals", Request: []vdTY.VirtualDevice{}, Response: ""},
+
+		// virtual device state
+		{Method: http.MethodGet, Path: "/api/virtualdevice/state", Tag: "virtual_device", Summary: "list virtual devices with the current trait values", List: true, Response: vdTY.DeviceState{}},
+		{Method: http.MethodGet, Path: "/api/virtualdevice/state/{id}", Tag: "virtual_device", Summary: "get a virtual device with the current trait values", Response: vdTY.DeviceState{}},
+		{Method: http.MethodPost, Path: "/api/virtualdevice/command/{id}", Tag: "virtual_device", Summary: "execute a trait command on a virtual device", Request: vdTY.DeviceCommand{}},
+
+		// status
+		{Method: http.MethodGet, Path: "/api/version", Tag: "system", Summary: "server version", Response: version.Version{}},
+		{Method: http.MethodGet, Path: "/api/status", Tag: "system", Summary: "server status", Public: true, Response: statusAPI.Status{}},
+		{Method: http.MethodGet, Path: "/api/server/status", Tag: "system", Summary: "detailed server status", Response: statusAPI.Status{}},
+
+		// settings
+		{Method: http.MethodPost, Path: "/api/settings", Tag: "settings", Summary: "update settings", Request: settingsTY.Settings{}},
+		{Method: http.MethodGet, Path: "/api/settings/system", Tag: "settings", Summary: "get system settings", Response: settingsTY.Settings{}},
+		{Method: http.MethodGet, Path: "/api/settings/system/jwtsecret/reset", Tag: "settings", Summary: "reset jwt secret"},
+		{Method: http.MethodGet, Path: "/api/settings/backuplocations", Tag: "settings", Summary: "get backup locations", Response: settingsTY.Settings{}},
+	}...)
+
+	apiRoutes = append(apiRoutes, authRoutes.OpenAPIRoutes()...)
+	return apiRoutes
+}
+
+// openAPIEntityRoutes returns the common routes of an entity, list, get, save, delete.
+// includes enable, disable and reload routes, if supported
+func openAPIEntityRoutes(tag, path string, entity interface{}, enableDisable, reload bool) []openapi.Route {
+	apiRoutes := []openapi.Route{
+		{Method: http.MethodGet, Path: path, Tag: tag, Summary: "list " + tag, List: true, Response: entity},
+		{Method: http.MethodGet, Path: path + "/{id}", Tag: tag, Summary: "get a " + tag, Response: entity},
+		{Method: http.MethodPost, Path: path, Tag: tag, Summary: "save a " + tag, Request: entity},
+		{Method: http.MethodDelete, Path: path, Tag: tag, Summary: "delete " + tag + "(s)", Request: []string{}, Response: ""},
+	}
+	if enableDisable {
+		apiRoutes = append(apiRoutes,
+			openapi.Route{Method: http.MethodPost, Path: path + "/enable", Tag: tag, Summary: "enable " + tag + "(s)", Request: []string{}, Response: ""},
+			openapi.Route{Method: http.MethodPost, Path: path + "/disable", Tag: tag, Summary: "disable " + tag + "(s)", Request: []string{}, Response: ""},
+		)
+	}
+	if reload {
+		apiRoutes = append(apiRoutes,
+			openapi.Route{Method: http.MethodPost, Path: path + "/reload", Tag: tag, Summary: "reload " + tag + "(s)", Request: []string{}, Response: ""},
+		)
+	}
+	return apiRoutes
+}
@@ -0,0 +1,140 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	authRoutes "github.com/mycontroller-org/server/v2/pkg/http_router/routes/auth"
+	json "github.com/mycontroller-org/server/v2/pkg/json"
+	openapi "github.com/mycontroller-org/server/v2/pkg/utils/openapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// registeredRoutes returns "METHOD path" of all the api routes registered on the router
+func registeredRoutes(t *testing.T) map[string]bool {
+	router := mux.NewRouter()
+	h := &Routes{router: router}
+	h.registerRoutes(false)
+	authRoutes.NewAuthRoutes(nil, nil, router).RegisterRoutes()
+	authRoutes.NewOAuthRoutes(nil, nil, router).RegisterRoutes()
+
+	registered := map[string]bool{}
+	err := router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return fmt.Errorf("route without method: %s", path)
+		}
+		for _, method := range methods {
+			registered[fmt.Sprintf("%s %s", method, path)] = true
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	return registered
+}
+
+func TestOpenAPIRoutesCoverage(t *testing.T) {
+	registered := registeredRoutes(t)
+	require.NotEmpty(t, registered)
+
+	documented := map[string]bool{}
+	for _, route := range OpenAPIRoutes() {
+		assert.False(t, documented[route.Key()], "duplicate spec entry: %s", route.Key())
+		documented[route.Key()] = true
+	}
+
+	for key := range registered {
+		assert.True(t, documented[key], "route registered without an OpenAPI spec entry: %s", key)
+	}
+	for key := range documented {
+		assert.True(t, registered[key], "OpenAPI spec entry without a registered route: %s", key)
+	}
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	doc, err := OpenAPIDocument()
+	require.NoError(t, err)
+	assert.Equal(t, openapi.Version, doc.OpenAPI)
+
+	// list routes should refer the storage filter and pagination conventions
+	gatewayList := doc.Paths["/api/gateway"].Get
+	require.NotNil(t, gatewayList)
+	refs := []string{}
+	for _, param := range gatewayList.Parameters {
+		refs = append(refs, param.Ref)
+	}
+	assert.Contains(t, refs, "#/components/parameters/filter")
+	assert.Contains(t, refs, "#/components/parameters/limit")
+
+	// entity schemas should be available in the components
+	gatewaySchema, found := doc.Components.Schemas["plugin.gateway.types.Config"]
+	require.True(t, found)
+	assert.Contains(t, gatewaySchema.Properties, "id")
+	assert.Contains(t, gatewaySchema.Properties, "provider")
+
+	// all the references should be resolvable
+	data, err := json.Marshal(doc)
+	require.NoError(t, err)
+	for _, part := range strings.Split(string(data), `"$ref":"#/components/schemas/`)[1:] {
+		name := part[:strings.Index(part, `"`)]
+		assert.Contains(t, doc.Components.Schemas, name)
+	}
+}
+
+func TestOpenAPIPublicRoutes(t *testing.T) {
+	doc, err := OpenAPIDocument()
+	require.NoError(t, err)
+	data, err := json.Marshal(doc)
+	require.NoError(t, err)
+	out := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(data, &out))
+
+	getOperation := func(path, method string) map[string]interface{} {
+		paths, _ := out["paths"].(map[string]interface{})
+		pathItem, _ := paths[path].(map[string]interface{})
+		operation, _ := pathItem[method].(map[string]interface{})
+		require.NotNil(t, operation, "%s %s", method, path)
+		return operation
+	}
+
+	// empty security list overrides the global security
+	public := [][]string{
+		{"/api/user/login", "post"},
+		{"/api/status", "get"},
+		{"/api/inbound-webhook/{id}", "post"},
+		{"/api/inbound-webhook/{id}", "get"},
+		{OpenAPIPath, "get"},
+	}
+	for _, route := range public {
+		operation := getOperation(route[0], route[1])
+		require.Contains(t, operation, "security", "%s %s", route[1], route[0])
+		assert.Equal(t, []interface{}{}, operation["security"], "%s %s", route[1], route[0])
+	}
+
+	// protected routes use the global security
+	assert.NotContains(t, getOperation("/api/gateway", "get"), "security")
+	assert.Equal(t, []interface{}{map[string]interface{}{"bearerAuth": []interface{}{}}}, out["security"])
+}
+
+func TestOpenAPIHandler(t *testing.T) {
+	h := &Routes{router: mux.NewRouter()}
+	h.registerOpenAPIRoutes()
+
+	recorder := httptest.NewRecorder()
+	h.router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, OpenAPIPath, nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	doc := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &doc))
+	assert.Equal(t, openapi.Version, doc["openapi"])
+	assert.Contains(t, doc["paths"], OpenAPIPath)
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/gorilla/mux"
 	action "github.com/mycontroller-org/server/v2/pkg/api/action"
+	applyAPI "github.com/mycontroller-org/server/v2/pkg/api/apply"
 	backupRestoreAPI "github.com/mycontroller-org/server/v2/pkg/api/backup"
 	entitiesAPI "github.com/mycontroller-org/server/v2/pkg/api/entities"
 	quickIdAPI "github.com/mycontroller-org/server/v2/pkg/api/quickid"
@@ -15,6 +16,7 @@ import (
 	metricTY "github.com/mycontroller-org/server/v2/plugin/database/metric/types"
 	export "github.com/mycontroller-org/server/v2/plugin/database/storage/backup"
 	storageTY "github.com/mycontroller-org/server/v2/plugin/database/storage/types"
+	deviceAPI "github.com/mycontroller-org/server/v2/plugin/virtual_assistant/device_api"
 	"go.uber.org/zap"
 )
 
@@ -26,9 +28,11 @@ type Routes struct {
 	metric     metricTY.Plugin
 	api        *entitiesAPI.API
 	action     *action.ActionAPI
+	applyAPI   *applyAPI.ApplyAPI
 	router     *mux.Router
 	backupAPI  *backupRestoreAPI.BackupAPI
 	quickIdAPI *quickIdAPI.QuickIdAPI
+	deviceAPI  *deviceAPI.DeviceAPI
 }
 
 func New(ctx context.Context, router *mux.Router, enableProfiling bool) (*Routes, error) {
@@ -65,6 +69,16 @@ func New(ctx context.Context, router *mux.Router, enableProfiling bool) (*Routes
 		return nil, err
 	}
 
+	_deviceAPI, err := deviceAPI.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	_applyAPI, err := applyAPI.New(ctx, logger, enc)
+	if err != nil {
+		return nil, err
+	}
+
 	bkpDirs, err := bkpMap.GetDirectories(true, true)
 	if err != nil {
 		return nil, err
@@ -83,37 +97,47 @@ func New(ctx context.Context, router *mux.Router, enableProfiling bool) (*Routes
 		api:        api,
 		enc:        enc,
 		action:     _action,
+		applyAPI:   _applyAPI,
 		router:     router,
 		backupAPI:  _backupAPI,
 		quickIdAPI: _quickIdAPI,
+		deviceAPI:  _deviceAPI,
 	}
 
 	// register routes
-	routes.registerActionRoutes()
-	routes.registerBackupRestoreRoutes()
-	routes.registerDashboardRoutes()
-	routes.registerDataRepositoryRoutes()
-	routes.registerFieldRoutes()
-	routes.registerFirmwareRoutes()
-	routes.registerForwardPayloadRoutes()
-	routes.registerGatewayRoutes()
-	routes.registerHandlerRoutes()
-	routes.registerMetricRoutes()
-	routes.registerNodeRoutes()
-	routes.registerQuickIDRoutes()
-	routes.registerSchedulerRoutes()
-	routes.registerServiceTokenRoutes()
-	routes.registerSourceRoutes()
-	routes.registerStatusRoutes()
-	routes.registerSystemRoutes()
-	routes.registerTaskRoutes()
-	routes.registerVirtualAssistantRoutes()
-	routes.registerVirtualDeviceRoutes()
+	routes.registerRoutes(enableProfiling)
+
+	return routes, nil
+}
+
+// registerRoutes registers all the application api routes
+func (h *Routes) registerRoutes(enableProfiling bool) {
+	h.registerActionRoutes()
+	h.registerApplyRoutes()
+	h.registerBackupRestoreRoutes()
+	h.registerDashboardRoutes()
+	h.registerDataRepositoryRoutes()
+	h.registerFieldRoutes()
+	h.registerFirmwareRoutes()
+	h.registerForwardPayloadRoutes()
+	h.registerGatewayRoutes()
+	h.registerHandlerRoutes()
+	h.registerInboundWebhookRoutes()
+	h.registerMetricRoutes()
+	h.registerNodeRoutes()
+	h.registerOpenAPIRoutes()
+	h.registerQuickIDRoutes()
+	h.registerSchedulerRoutes()
+	h.registerServiceTokenRoutes()
+	h.registerSourceRoutes()
+	h.registerStatusRoutes()
+	h.registerSystemRoutes()
+	h.registerTaskRoutes()
+	h.registerVirtualAssistantRoutes()
+	h.registerVirtualDeviceRoutes()
 
 	// enables profiling
 	if enableProfiling {
-		routes.registerPProfRoutes()
+		h.registerPProfRoutes()
 	}
-
-	return routes, nil
 }
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/gorilla/mux"
 	types "github.com/mycontroller-org/server/v2/pkg/types"
 	vdTY "github.com/mycontroller-org/server/v2/pkg/types/virtual_device"
 	handlerUtils "github.com/mycontroller-org/server/v2/pkg/utils/http_handler"
@@ -15,9 +16,14 @@ import (
 // registers virtual device api
 func (h *Routes) registerVirtualDeviceRoutes() {
 	h.router.HandleFunc("/api/virtualdevice", h.listVirtualDevices).Methods(http.MethodGet)
+	h.router.HandleFunc("/api/virtualdevice/state", h.listVirtualDeviceStates).Methods(http.MethodGet)
+	h.router.HandleFunc("/api/virtualdevice/state/{id}", h.getVirtualDeviceState).Methods(http.MethodGet)
+	h.router.HandleFunc("/api/virtualdevice/command/{id}", h.executeVirtualDeviceCommand).Methods(http.MethodPost)
 	h.router.HandleFunc("/api/virtualdevice/{id}", h.getVirtualDevice).Methods(http.MethodGet)
 	h.router.HandleFunc("/api/virtualdevice", h.updateVirtualDevice).Methods(http.MethodPost)
 	h.router.HandleFunc("/api/virtualdevice", h.deleteVirtualDevices).Methods(http.MethodDelete)
+	h.router.HandleFunc("/api/virtualdevice/generate", h.generateVirtualDevices).Methods(http.MethodPost)
+	h.router.HandleFunc("/api/virtualdevice/generate/accept", h.acceptVirtualDevices).Methods(http.MethodPost)
 }
 
 func (h *Routes) listVirtualDevices(w http.ResponseWriter, r *http.Request) {
@@ -60,3 +66,72 @@ func (h *Routes) deleteVirtualDevices(w http.ResponseWriter, r *http.Request) {
 	}
 	handlerUtils.UpdateData(w, r, &IDs, updateFn)
 }
+
+// returns the virtual device proposals, generated from the nodes and fields
+func (h *Routes) generateVirtualDevices(w http.ResponseWriter, r *http.Request) {
+	request := &vdTY.GeneratorRequest{}
+	updateFn := func(f []storageTY.Filter, p *storageTY.Pagination, d []byte) (interface{}, error) {
+		return h.api.VirtualDevice().Generate(request)
+	}
+	handlerUtils.UpdateData(w, r, request, updateFn)
+}
+
+// saves the reviewed proposals
+func (h *Routes) acceptVirtualDevices(w http.ResponseWriter, r *http.Request) {
+	devices := []vdTY.VirtualDevice{}
+	updateFn := func(f []storageTY.Filter, p *storageTY.Pagination, d []byte) (interface{}, error) {
+		if len(devices) == 0 {
+			return nil, errors.New("supply device(s)")
+		}
+		count, err := h.api.VirtualDevice().Accept(devices)
+		if err != nil {
+			return nil, err
+		}
+		return fmt.Sprintf("accepted: %d", count), nil
+	}
+	handlerUtils.UpdateData(w, r, &devices, updateFn)
+}
+
+// returns the virtual devices with the current value of the traits and the online status
+func (h *Routes) listVirtualDeviceStates(w http.ResponseWriter, r *http.Request) {
+	entityFn := func(f []storageTY.Filter, p *storageTY.Pagination) (interface{}, error) {
+		result, err := h.api.VirtualDevice().List(f, p)
+		if err != nil {
+			return nil, err
+		}
+		devices, ok := result.Data.(*[]vdTY.VirtualDevice)
+		if !ok {
+			return nil, fmt.Errorf("invalid data type:%T", result.Data)
+		}
+		result.Data = h.deviceAPI.GetDeviceStates(*devices)
+		return result, nil
+	}
+	handlerUtils.LoadData(w, r, entityFn)
+}
+
+func (h *Routes) getVirtualDeviceState(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	entityFn := func(f []storageTY.Filter, p *storageTY.Pagination) (interface{}, error) {
+		device, err := h.api.VirtualDevice().GetByID(id)
+		if err != nil {
+			return nil, err
+		}
+		states := h.deviceAPI.GetDeviceStates([]vdTY.VirtualDevice{*device})
+		return states[0], nil
+	}
+	handlerUtils.LoadData(w, r, entityFn)
+}
+
+// executes a trait level command on a virtual device, example: {"trait":"brightness","value":40}
+func (h *Routes) executeVirtualDeviceCommand(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	command := &vdTY.DeviceCommand{}
+	updateFn := func(f []storageTY.Filter, p *storageTY.Pagination, d []byte) (interface{}, error) {
+		device, err := h.api.VirtualDevice().GetByID(id)
+		if err != nil {
+			return nil, err
+		}
+		return nil, h.deviceAPI.ExecuteCommand(device, command)
+	}
+	handlerUtils.UpdateData(w, r, command, updateFn)
+}
@@ -56,6 +56,12 @@ func (svc *GatewayService) onEvent(event *busTY.BusData) {
 		svc.logger.Warn("Failed to convert to target type", zap.Error(err))
 		return
 	}
+
+	// reply topic of a request, the response has to be posted on it
+	if event.ReplyTopic != "" {
+		reqEvent.ReplyTopic = event.ReplyTopic
+	}
+
 	if reqEvent.Type == "" {
 		svc.logger.Warn("received an empty event", zap.Any("event", event))
 		return
@@ -117,7 +117,8 @@ func (svc *MessageProcessor) onMessageReceive(busData *busTY.BusData) {
 		return
 	}
 	svc.logger.Debug("Message added into processing queue", zap.Any("message", msg))
-	status := svc.eventsQueue.Produce(msg)
+	// acknowledged to the bus, once the message processed
+	status := svc.eventsQueue.ProduceWithAck(msg, busData.TakeAck())
 	if !status {
 		svc.logger.Warn("Failed to store the message into queue", zap.Any("message", msg))
 	}
@@ -566,11 +567,15 @@ func (svc *MessageProcessor) updateFieldData(
 	}
 
 	startTime := time.Now()
+	isNewField := field.ID == ""
 	err := svc.api.Field().Save(field, false)
 	if err != nil {
 		svc.logger.Error("failed to update field in to database", zap.Error(err), zap.Any("field", field))
 	} else {
 		svc.logger.Debug("inserted in to storage db", zap.String("timeTaken", time.Since(startTime).String()))
+		if isNewField {
+			busUtils.PostEvent(svc.logger, svc.bus, topic.TopicEventField, eventTY.TypeCreated, types.EntityField, field)
+		}
 	}
 
 	// post field data to event listeners
@@ -0,0 +1,392 @@
+package handler
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mycontroller-org/server/v2/pkg/json"
+	"github.com/mycontroller-org/server/v2/pkg/types"
+	"github.com/mycontroller-org/server/v2/pkg/utils"
+	handlerTY "github.com/mycontroller-org/server/v2/plugin/handler/types"
+	"go.uber.org/zap"
+)
+
+const (
+	deliveryStoreDirectory = "handler"
+	deliveryStoreFilename  = "deliveries.json"
+	maxDeadLetters         = 1000
+	storeFileMode          = fs.FileMode(0600) // payloads may contain secrets, readable only by the owner
+)
+
+// deliveryStore keeps the pending deliveries and dead letters, persisted on the disk by the flush job.
+// delivery stats are kept only in memory
+type deliveryStore struct {
+	mutex       sync.Mutex
+	flushMutex  sync.Mutex
+	dirty       bool // changes not yet written to the disk
+	logger      *zap.Logger
+	pending     map[string]*handlerTY.Delivery
+	deadLetters map[string]*handlerTY.Delivery
+	queued      map[string]bool // deliveries available in the processing queue
+	stats       map[string]*handlerTY.DeliveryStats
+}
+
+// persisted data format
+type deliveryStoreData struct {
+	Pending     []*handlerTY.Delivery `json:"pending"`
+	DeadLetters []*handlerTY.Delivery `json:"deadLetters"`
+}
+
+func newDeliveryStore(logger *zap.Logger) *deliveryStore {
+	return &deliveryStore{
+		logger:      logger,
+		pending:     map[string]*handlerTY.Delivery{},
+		deadLetters: map[string]*handlerTY.Delivery{},
+		queued:      map[string]bool{},
+		stats:       map[string]*handlerTY.DeliveryStats{},
+	}
+}
+
+func (s *deliveryStore) getFilename() string {
+	baseDir := types.GetEnvString(types.ENV_DIR_DATA)
+	dir := filepath.Join(baseDir, deliveryStoreDirectory)
+	err := utils.CreateDir(dir)
+	if err != nil {
+		s.logger.Error("failed to create handler data persistence directory", zap.String("directory", dir), zap.Error(err))
+	}
+	return filepath.Join(dir, deliveryStoreFilename)
+}
+
+// add a new delivery, marked as queued
+func (s *deliveryStore) add(delivery *handlerTY.Delivery) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.pending[delivery.ID] = delivery
+	s.queued[delivery.ID] = true
+	s.dirty = true
+}
+
+// unqueue marks the delivery as not available in the queue, will be picked on the next retry
+func (s *deliveryStore) unqueue(id string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.queued, id)
+}
+
+// remove a delivery, will be redelivered by the bus
+func (s *deliveryStore) remove(id string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.pending, id)
+	delete(s.queued, id)
+	s.dirty = true
+}
+
+// getDue returns the pending deliveries ready for the next attempt and marks them as queued
+func (s *deliveryStore) getDue() []*handlerTY.Delivery {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	deliveries := make([]*handlerTY.Delivery, 0)
+	for id, delivery := range s.pending {
+		if s.queued[id] || delivery.NextAttempt.After(now) {
+			continue
+		}
+		s.queued[id] = true
+		deliveries = append(deliveries, delivery)
+	}
+	sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].CreatedOn.Before(deliveries[j].CreatedOn) })
+	return deliveries
+}
+
+// onSuccess removes the delivery and updates the stats
+func (s *deliveryStore) onSuccess(delivery *handlerTY.Delivery, latency time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.pending, delivery.ID)
+	delete(s.queued, delivery.ID)
+
+	stats := s.getStats(delivery.HandlerID)
+	latencyMs := float64(latency.Microseconds()) / 1000
+	stats.AverageLatencyMs = (stats.AverageLatencyMs*float64(stats.Sent) + latencyMs) / float64(stats.Sent+1)
+	stats.Sent++
+	stats.LastLatencyMs = latencyMs
+	if latencyMs > stats.MaxLatencyMs {
+		stats.MaxLatencyMs = latencyMs
+	}
+	stats.LastSuccess = time.Now()
+	s.dirty = true
+}
+
+// onDeduplicated updates the stats, when a duplicate message dropped
+func (s *deliveryStore) onDeduplicated(handlerID string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.getStats(handlerID).Deduplicated++
+}
+
+// onFailure schedules the next attempt or moves the delivery to the dead letters.
+// permanent failures are moved to the dead letters on the first attempt.
+// the remaining data, if supplied, replaces the delivery data, not to send again the parts already sent.
+// returns true, if moved to the dead letters
+func (s *deliveryStore) onFailure(delivery *handlerTY.Delivery, remainingData map[string]interface{}, err error, retryPolicy *handlerTY.RetryPolicy) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.queued, delivery.ID)
+
+	if remainingData != nil {
+		delivery.Data = remainingData
+	}
+
+	delivery.Attempts++
+	delivery.LastAttempt = time.Now()
+	delivery.LastError = err.Error()
+
+	stats := s.getStats(delivery.HandlerID)
+	stats.Failed++
+	stats.LastFailure = delivery.LastAttempt
+
+	deadLettered := false
+	if delivery.Attempts >= retryPolicy.GetMaxAttempts() || errors.Is(err, handlerTY.ErrPermanent) {
+		delete(s.pending, delivery.ID)
+		delivery.NextAttempt = time.Time{}
+		s.addDeadLetter(delivery)
+		stats.DeadLettered++
+		deadLettered = true
+	} else {
+		delivery.NextAttempt = delivery.LastAttempt.Add(retryPolicy.GetBackoff(delivery.Attempts))
+	}
+	s.dirty = true
+	return deadLettered
+}
+
+func (s *deliveryStore) addDeadLetter(delivery *handlerTY.Delivery) {
+	// remove the oldest one, when reaches the limit
+	if len(s.deadLetters) >= maxDeadLetters {
+		var oldest *handlerTY.Delivery
+		for _, deadLetter := range s.deadLetters {
+			if oldest == nil || deadLetter.LastAttempt.Before(oldest.LastAttempt) {
+				oldest = deadLetter
+			}
+		}
+		s.logger.Warn("dead letters limit reached, removing the oldest one", zap.Int("limit", maxDeadLetters), zap.Any("deadLetter", oldest))
+		delete(s.deadLetters, oldest.ID)
+	}
+	s.deadLetters[delivery.ID] = delivery
+}
+
+// listDeadLetters returns the dead letters, filtered by handler id, if supplied
+func (s *deliveryStore) listDeadLetters(handlerID string) []handlerTY.Delivery {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	deadLetters := make([]handlerTY.Delivery, 0)
+	for _, deadLetter := range s.deadLetters {
+		if handlerID == "" || deadLetter.HandlerID == handlerID {
+			deadLetters = append(deadLetters, *deadLetter)
+		}
+	}
+	sort.Slice(deadLetters, func(i, j int) bool { return deadLetters[i].CreatedOn.Before(deadLetters[j].CreatedOn) })
+	return deadLetters
+}
+
+// retryDeadLetters moves the dead letters to pending with the fresh attempts
+func (s *deliveryStore) retryDeadLetters(ids []string) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	count := 0
+	for _, id := range ids {
+		delivery, found := s.deadLetters[id]
+		if !found {
+			continue
+		}
+		delete(s.deadLetters, id)
+		delivery.Attempts = 0
+		delivery.NextAttempt = time.Time{}
+		s.pending[id] = delivery
+		count++
+	}
+	if count > 0 {
+		s.dirty = true
+	}
+	return count
+}
+
+// discardDeadLetters removes the dead letters
+func (s *deliveryStore) discardDeadLetters(ids []string) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	count := 0
+	for _, id := range ids {
+		if _, found := s.deadLetters[id]; found {
+			delete(s.deadLetters, id)
+			count++
+		}
+	}
+	if count > 0 {
+		s.dirty = true
+	}
+	return count
+}
+
+// listStats returns the delivery stats of the handlers, includes the held messages count
+func (s *deliveryStore) listStats(heldCount map[string]int) []handlerTY.DeliveryStats {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for handlerID := range heldCount {
+		s.getStats(handlerID)
+	}
+	for _, delivery := range s.pending {
+		s.getStats(delivery.HandlerID)
+	}
+	for _, delivery := range s.deadLetters {
+		s.getStats(delivery.HandlerID)
+	}
+
+	statsList := make([]handlerTY.DeliveryStats, 0, len(s.stats))
+	for handlerID, stats := range s.stats {
+		_stats := *stats
+		_stats.Held = heldCount[handlerID]
+		for _, delivery := range s.pending {
+			if delivery.HandlerID == handlerID {
+				_stats.Pending++
+			}
+		}
+		for _, delivery := range s.deadLetters {
+			if delivery.HandlerID == handlerID {
+				_stats.DeadLetters++
+			}
+		}
+		statsList = append(statsList, _stats)
+	}
+	sort.Slice(statsList, func(i, j int) bool { return statsList[i].HandlerID < statsList[j].HandlerID })
+	return statsList
+}
+
+func (s *deliveryStore) getStats(handlerID string) *handlerTY.DeliveryStats {
+	stats, found := s.stats[handlerID]
+	if !found {
+		stats = &handlerTY.DeliveryStats{HandlerID: handlerID}
+		s.stats[handlerID] = stats
+	}
+	return stats
+}
+
+// load the pending deliveries and dead letters from the disk
+func (s *deliveryStore) load() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	filename := s.getFilename()
+	if !utils.IsFileExists(filename) {
+		return nil
+	}
+	dataBytes, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	data := &deliveryStoreData{}
+	err = json.Unmarshal(dataBytes, data)
+	if err != nil {
+		return err
+	}
+	for _, delivery := range data.Pending {
+		s.pending[delivery.ID] = delivery
+	}
+	for _, delivery := range data.DeadLetters {
+		s.deadLetters[delivery.ID] = delivery
+	}
+	s.logger.Info("loaded handler deliveries", zap.Int("pending", len(s.pending)), zap.Int("deadLetters", len(s.deadLetters)))
+	return nil
+}
+
+// cloneData returns a copy of the delivery data, to be updated by the handler without the lock.
+// the parameters are copied one level down, handlers update only the parameter fields
+func cloneData(data map[string]interface{}) map[string]interface{} {
+	cloned := make(map[string]interface{}, len(data))
+	for name, parameter := range data {
+		if parameterMap, ok := parameter.(map[string]interface{}); ok {
+			clonedParameter := make(map[string]interface{}, len(parameterMap))
+			for key, value := range parameterMap {
+				clonedParameter[key] = value
+			}
+			parameter = clonedParameter
+		}
+		cloned[name] = parameter
+	}
+	return cloned
+}
+
+// flush writes the changes to the disk, called periodically and on close.
+// writes are batched, a file write per delivery slows down the delivery on bursts
+func (s *deliveryStore) flush() {
+	s.flushMutex.Lock()
+	defer s.flushMutex.Unlock()
+
+	dataBytes, err := s.marshal()
+	if err != nil {
+		s.logger.Error("error on converting handler deliveries", zap.Error(err))
+		return
+	}
+	if dataBytes == nil {
+		return
+	}
+	err = writeStoreFile(s.getFilename(), dataBytes)
+	if err != nil {
+		s.logger.Error("error on saving handler deliveries", zap.String("filename", s.getFilename()), zap.Error(err))
+		// keep as dirty to retry on the next flush
+		s.mutex.Lock()
+		s.dirty = true
+		s.mutex.Unlock()
+	}
+}
+
+// writeStoreFile writes the data readable only by the owner, the mode of an existing file is updated
+func writeStoreFile(filename string, data []byte) error {
+	err := os.WriteFile(filename, data, storeFileMode)
+	if err != nil {
+		return err
+	}
+	return os.Chmod(filename, storeFileMode)
+}
+
+// marshal returns the persisted data format, nil if there is no change
+func (s *deliveryStore) marshal() ([]byte, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.dirty {
+		return nil, nil
+	}
+	data := &deliveryStoreData{
+		Pending:     make([]*handlerTY.Delivery, 0, len(s.pending)),
+		DeadLetters: make([]*handlerTY.Delivery, 0, len(s.deadLetters)),
+	}
+	for _, delivery := range s.pending {
+		data.Pending = append(data.Pending, delivery)
+	}
+	for _, delivery := range s.deadLetters {
+		data.DeadLetters = append(data.DeadLetters, delivery)
+	}
+	dataBytes, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	s.dirty = false
+	return dataBytes, nil
+}
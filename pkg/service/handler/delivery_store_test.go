@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mycontroller-org/server/v2/pkg/types"
+	handlerTY "github.com/mycontroller-org/server/v2/plugin/handler/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestDeliveryStoreFailures(t *testing.T) {
+	t.Setenv(types.ENV_DIR_DATA, t.TempDir())
+	store := newDeliveryStore(zap.NewNop())
+	retryPolicy := &handlerTY.RetryPolicy{MaxAttempts: 3, Delay: "1s"}
+
+	// transient failure, keeps the remaining data for the next attempt
+	delivery := &handlerTY.Delivery{ID: "transient", HandlerID: "h1", Data: map[string]interface{}{"a": "1", "b": "2"}, CreatedOn: time.Now()}
+	store.add(delivery)
+	deadLettered := store.onFailure(delivery, map[string]interface{}{"b": "2"}, errors.New("timeout"), retryPolicy)
+	assert.False(t, deadLettered)
+	assert.Equal(t, map[string]interface{}{"b": "2"}, delivery.Data)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.True(t, delivery.NextAttempt.After(delivery.LastAttempt))
+
+	// dead lettered after the max attempts
+	store.onFailure(delivery, nil, errors.New("timeout"), retryPolicy)
+	assert.True(t, store.onFailure(delivery, nil, errors.New("timeout"), retryPolicy))
+	assert.Equal(t, map[string]interface{}{"b": "2"}, delivery.Data)
+
+	// permanent failure, dead lettered on the first attempt
+	permanent := &handlerTY.Delivery{ID: "permanent", HandlerID: "h1", CreatedOn: time.Now()}
+	store.add(permanent)
+	err := fmt.Errorf("%w: bad request", handlerTY.ErrPermanent)
+	assert.True(t, store.onFailure(permanent, nil, err, retryPolicy))
+	assert.Equal(t, 1, permanent.Attempts)
+
+	deadLetters := store.listDeadLetters("h1")
+	require.Len(t, deadLetters, 2)
+	assert.Empty(t, store.pending)
+
+	stats := store.listStats(nil)
+	require.Len(t, stats, 1)
+	assert.Equal(t, int64(4), stats[0].Failed)
+	assert.Equal(t, int64(2), stats[0].DeadLettered)
+}
+
+func TestDeliveryStoreFlush(t *testing.T) {
+	dataDir := t.TempDir()
+	t.Setenv(types.ENV_DIR_DATA, dataDir)
+	filename := filepath.Join(dataDir, deliveryStoreDirectory, deliveryStoreFilename)
+
+	store := newDeliveryStore(zap.NewNop())
+	for index := 0; index < 10; index++ {
+		store.add(&handlerTY.Delivery{ID: fmt.Sprintf("id_%d", index), HandlerID: "h1", CreatedOn: time.Now()})
+	}
+	// nothing written till the flush
+	assert.NoFileExists(t, filename)
+
+	store.flush()
+	require.FileExists(t, filename)
+	info, err := os.Stat(filename)
+	require.NoError(t, err)
+	assert.Equal(t, storeFileMode, info.Mode().Perm())
+
+	// no change, no write
+	require.NoError(t, os.Remove(filename))
+	store.flush()
+	assert.NoFileExists(t, filename)
+
+	store.onSuccess(store.pending["id_0"], time.Millisecond)
+	store.flush()
+	require.FileExists(t, filename)
+	assert.NotZero(t, info.Size())
+
+	loaded := newDeliveryStore(zap.NewNop())
+	require.NoError(t, loaded.load())
+	assert.Len(t, loaded.pending, 9)
+	assert.NotContains(t, loaded.pending, "id_0")
+
+	// mode of an existing file is restricted
+	require.NoError(t, os.Chmod(filename, 0644))
+	store.onSuccess(store.pending["id_1"], time.Millisecond)
+	store.flush()
+	info, err = os.Stat(filename)
+	require.NoError(t, err)
+	assert.Equal(t, storeFileMode, info.Mode().Perm())
+}
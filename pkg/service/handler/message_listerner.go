@@ -1,10 +1,12 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
 	types "github.com/mycontroller-org/server/v2/pkg/types"
+	"github.com/mycontroller-org/server/v2/pkg/utils"
 	busUtils "github.com/mycontroller-org/server/v2/pkg/utils/bus_utils"
 	busTY "github.com/mycontroller-org/server/v2/plugin/bus/types"
 	handlerTY "github.com/mycontroller-org/server/v2/plugin/handler/types"
@@ -35,48 +37,117 @@ func (svc *HandlerService) onMessageReceive(event *busTY.BusData) {
 		svc.logger.Warn("received an empty event", zap.Any("event", event))
 		return
 	}
-	svc.logger.Debug("message added into processing queue", zap.Any("message", msg))
-	status := svc.messageQueue.Produce(msg)
+
+	// handler may be served by another handler service
+	if svc.store.Get(msg.ID) == nil {
+		svc.logger.Debug("handler not available", zap.Any("handlerID", msg.ID), zap.Any("availableHandlers", svc.store.ListIDs()))
+		return
+	}
+
+	switch svc.policies.apply(msg.ID, svc.store.GetNotifyPolicy(msg.ID), msg.Data) {
+	case policyDrop:
+		svc.logger.Debug("duplicate message dropped", zap.String("handlerID", msg.ID), zap.Any("message", msg))
+		svc.deliveries.onDeduplicated(msg.ID)
+		return
+
+	case policyHold:
+		svc.logger.Debug("message held by the notify policy", zap.String("handlerID", msg.ID), zap.Any("message", msg))
+		return
+	}
+
+	// acknowledged to the bus, once the first attempt is done. failed deliveries are retried from the delivery store
+	svc.deliver(msg.ID, msg.Data, event.TakeAck())
+}
+
+// deliver keeps the message as pending delivery till it is sent and adds it into processing queue.
+// if the ack func supplied and the queue is full, the delivery is left to the bus redelivery
+func (svc *HandlerService) deliver(handlerID string, data map[string]interface{}, ack func(err error)) {
+	delivery := &handlerTY.Delivery{
+		ID:        utils.RandUUID(),
+		HandlerID: handlerID,
+		Data:      data,
+		CreatedOn: time.Now(),
+	}
+	svc.deliveries.add(delivery)
+
+	svc.logger.Debug("message added into processing queue", zap.String("handlerID", handlerID), zap.String("deliveryID", delivery.ID))
+	status := svc.messageQueue.ProduceWithAck(delivery, ack)
 	if !status {
-		svc.logger.Warn("failed to store the message into queue", zap.Any("message", msg))
+		svc.logger.Warn("failed to store the message into queue, will be retried", zap.String("handlerID", handlerID), zap.String("deliveryID", delivery.ID))
+		if ack != nil {
+			svc.deliveries.remove(delivery.ID)
+		} else {
+			svc.deliveries.unqueue(delivery.ID)
+		}
+	}
+}
+
+// releaseHeldMessages delivers the messages held by the notify policies as digest, when they are due
+func (svc *HandlerService) releaseHeldMessages() {
+	for handlerID, data := range svc.policies.release(time.Now()) {
+		svc.logger.Debug("releasing held messages", zap.String("handlerID", handlerID))
+		svc.deliver(handlerID, data, nil)
 	}
 }
 
-// close listener service
+// flushStores writes the deliveries and the notify policy data to the disk
+func (svc *HandlerService) flushStores() {
+	svc.deliveries.flush()
+	svc.policies.flush()
+}
+
 func (svc *HandlerService) closeMessageListener() {
 	svc.messageQueue.Close()
 }
 
+// retryDeliveries adds the pending deliveries into the processing queue, when the next attempt is due
+func (svc *HandlerService) retryDeliveries() {
+	for _, delivery := range svc.deliveries.getDue() {
+		svc.logger.Debug("retrying a delivery", zap.String("handlerID", delivery.HandlerID), zap.String("deliveryID", delivery.ID), zap.Int("attempts", delivery.Attempts))
+		status := svc.messageQueue.Produce(delivery)
+		if !status {
+			svc.deliveries.unqueue(delivery.ID)
+		}
+	}
+}
+
 func (svc *HandlerService) processHandlerMessage(item interface{}) error {
-	msg := item.(*handlerTY.MessageWrapper)
+	delivery := item.(*handlerTY.Delivery)
 	start := time.Now()
 
-	svc.logger.Debug("starting message processing", zap.Any("handlerID", msg.ID))
+	svc.logger.Debug("starting message processing", zap.Any("handlerID", delivery.HandlerID), zap.String("deliveryID", delivery.ID))
 
-	handler := svc.store.Get(msg.ID)
+	handler := svc.store.Get(delivery.HandlerID)
 	if handler == nil {
-		svc.logger.Info("handler not available", zap.Any("handlerID", msg.ID), zap.Any("availableHandlers", svc.store.ListIDs()))
-		return nil // Don't requeue if handler not available
+		// handler may be reloading or removed, attempts are limited by the retry policy
+		deadLettered := svc.deliveries.onFailure(delivery, nil, errors.New("handler not available"), nil)
+		svc.logger.Info("handler not available", zap.Any("handlerID", delivery.HandlerID), zap.Bool("deadLettered", deadLettered), zap.Any("availableHandlers", svc.store.ListIDs()))
+		return nil
 	}
 
 	state := handler.State()
 
-	err := handler.Post(msg.Data)
+	// handler removes the sent parts from the data, the remaining parts are retried
+	data := cloneData(delivery.Data)
+	err := handler.Post(data)
 	if err != nil {
-		if err == handlerTY.ErrReQueue {
-			// Requeue the message to try again
-			svc.logger.Info("requeuing message", zap.Any("handlerID", msg.ID))
-			return err
+		retryPolicy := svc.store.GetRetryPolicy(delivery.HandlerID)
+		deadLettered := svc.deliveries.onFailure(delivery, data, err, retryPolicy)
+		if deadLettered {
+			svc.logger.Error("delivery failed, moved to dead letters", zap.Any("handlerID", delivery.HandlerID), zap.String("deliveryID", delivery.ID), zap.Int("attempts", delivery.Attempts), zap.Error(err))
+			state.Message = fmt.Sprintf("delivery failed after %d attempt(s), moved to dead letters: %s", delivery.Attempts, err.Error())
+		} else {
+			svc.logger.Warn("error from handler, will be retried", zap.Any("handlerID", delivery.HandlerID), zap.String("deliveryID", delivery.ID), zap.Int("attempts", delivery.Attempts), zap.Time("nextAttempt", delivery.NextAttempt), zap.Error(err))
+			state.Message = fmt.Sprintf("attempt %d/%d failed, retries at %s: %s", delivery.Attempts, retryPolicy.GetMaxAttempts(), delivery.NextAttempt.Format(time.RFC3339), err.Error())
 		}
-		svc.logger.Warn("error from handler", zap.Any("handlerID", msg.ID), zap.Error(err))
 		state.Status = types.StatusError
-		state.Message = err.Error()
 	} else {
+		svc.deliveries.onSuccess(delivery, time.Since(start))
 		state.Status = types.StatusOk
 		state.Message = fmt.Sprintf("execution time: %s", time.Since(start).String())
 	}
 
 	state.Since = time.Now()
-	busUtils.SetHandlerState(svc.logger, svc.bus, msg.ID, *state)
+	busUtils.SetHandlerState(svc.logger, svc.bus, delivery.HandlerID, *state)
 	return nil
 }
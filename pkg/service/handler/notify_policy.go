@@ -0,0 +1,530 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mycontroller-org/server/v2/pkg/json"
+	"github.com/mycontroller-org/server/v2/pkg/types"
+	"github.com/mycontroller-org/server/v2/pkg/types/cmap"
+	"github.com/mycontroller-org/server/v2/pkg/utils"
+	"github.com/mycontroller-org/server/v2/plugin/handler/notification"
+	handlerTY "github.com/mycontroller-org/server/v2/plugin/handler/types"
+	"go.uber.org/zap"
+)
+
+const (
+	heldMessagesFilename = "held_messages.json"
+)
+
+// policy results
+const (
+	policyDeliver = "deliver"
+	policyHold    = "hold"
+	policyDrop    = "drop"
+)
+
+// digestFields text and title fields of the data types, merged on a digest.
+// for the other data types the latest parameter is delivered
+var digestFields = map[string]struct{ text, title string }{
+	handlerTY.DataTypeEmail:    {text: "body", title: "subject"},
+	handlerTY.DataTypeTelegram: {text: "text"},
+	handlerTY.DataTypeNtfy:     {text: "message", title: "title"},
+	handlerTY.DataTypeGotify:   {text: "message", title: "title"},
+	handlerTY.DataTypePushover: {text: "message", title: "title"},
+	handlerTY.DataTypeMatrix:   {text: "message", title: "title"},
+	handlerTY.DataTypeSlack:    {text: "message", title: "title"},
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// notifyPolicyStore applies the notify policies of the handlers.
+// held messages, rate limit and deduplicate data are persisted on the disk by the flush job
+type notifyPolicyStore struct {
+	mutex      sync.Mutex
+	flushMutex sync.Mutex
+	dirty      bool // changes not yet written to the disk
+	logger     *zap.Logger
+	sunriseAPI types.Sunrise
+	states     map[string]*notifyState
+}
+
+type notifyState struct {
+	Policy      *handlerTY.NotifyPolicy `json:"policy"` // last known policy, applied on the release even if the handler is not loaded
+	Held        []*heldMessage          `json:"held"`
+	ReleaseAt   time.Time               `json:"releaseAt"`
+	DigestUntil time.Time               `json:"digestUntil"` // messages till this time are held as digest
+	Sent        []time.Time             `json:"sent"`
+	DedupKeys   map[string]time.Time    `json:"dedupKeys"`
+}
+
+type heldMessage struct {
+	Data       map[string]interface{} `json:"data"`
+	ReceivedOn time.Time              `json:"receivedOn"`
+}
+
+func newNotifyPolicyStore(logger *zap.Logger, sunriseAPI types.Sunrise) *notifyPolicyStore {
+	return &notifyPolicyStore{
+		logger:     logger,
+		sunriseAPI: sunriseAPI,
+		states:     map[string]*notifyState{},
+	}
+}
+
+func (s *notifyPolicyStore) getState(handlerID string) *notifyState {
+	state, found := s.states[handlerID]
+	if !found {
+		state = &notifyState{DedupKeys: map[string]time.Time{}}
+		s.states[handlerID] = state
+	}
+	return state
+}
+
+// setPolicy updates the policy of a handler, called when the handler is loaded.
+// the held messages are checked against the updated policy on the next release
+func (s *notifyPolicyStore) setPolicy(handlerID string, policy *handlerTY.NotifyPolicy) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	state, found := s.states[handlerID]
+	if !found && policy == nil {
+		return
+	}
+	if !found {
+		state = s.getState(handlerID)
+	}
+	state.Policy = policy.Clone()
+	state.ReleaseAt = time.Time{}
+	s.dirty = true
+}
+
+// apply the policy on a message, returns deliver, hold or drop
+func (s *notifyPolicyStore) apply(handlerID string, policy *handlerTY.NotifyPolicy, data map[string]interface{}) string {
+	if policy == nil {
+		return policyDeliver
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	state := s.getState(handlerID)
+	state.Policy = policy.Clone()
+	s.dirty = true
+
+	if policy.Deduplicate != nil {
+		key := dedupKey(data)
+		for _key, receivedOn := range state.DedupKeys {
+			if now.Sub(receivedOn) >= policy.Deduplicate.GetWindow() {
+				delete(state.DedupKeys, _key)
+			}
+		}
+		if _, found := state.DedupKeys[key]; found {
+			return policyDrop
+		}
+		state.DedupKeys[key] = now
+	} else if len(state.DedupKeys) > 0 {
+		state.DedupKeys = map[string]time.Time{}
+	}
+
+	if isCritical(data) {
+		recordSent(state, policy, now)
+		return policyDeliver
+	}
+
+	// keeps the order, when messages are already held
+	if len(state.Held) > 0 {
+		s.hold(state, data, now, policy)
+		return policyHold
+	}
+
+	if quiet, end := s.isQuietTime(policy.QuietHours, now); quiet {
+		state.ReleaseAt = end
+		s.hold(state, data, now, policy)
+		return policyHold
+	}
+
+	// the first message is delivered and starts the digest window,
+	// the following messages within the window are held and delivered as digest at the end of the window
+	if policy.Digest != nil && now.Before(state.DigestUntil) {
+		state.ReleaseAt = state.DigestUntil
+		s.hold(state, data, now, policy)
+		return policyHold
+	}
+
+	if limited, releaseAt := isRateLimited(state, policy.RateLimit, now); limited {
+		state.ReleaseAt = releaseAt
+		s.hold(state, data, now, policy)
+		return policyHold
+	}
+
+	if policy.Digest != nil {
+		state.DigestUntil = now.Add(policy.Digest.GetWindow())
+	}
+	recordSent(state, policy, now)
+	return policyDeliver
+}
+
+// recordSent keeps the sent time, when the rate limit is configured
+func recordSent(state *notifyState, policy *handlerTY.NotifyPolicy, now time.Time) {
+	if policy == nil || policy.RateLimit == nil || policy.RateLimit.Limit <= 0 {
+		state.Sent = nil
+		return
+	}
+	state.Sent = append(state.Sent, now)
+}
+
+// hold should be called with the lock
+func (s *notifyPolicyStore) hold(state *notifyState, data map[string]interface{}, now time.Time, policy *handlerTY.NotifyPolicy) {
+	state.Held = append(state.Held, &heldMessage{Data: data, ReceivedOn: now})
+	// deliver on the next release check, if the digest reaches the max messages
+	if policy.Digest != nil && policy.Digest.MaxMessages > 0 && len(state.Held) >= policy.Digest.MaxMessages {
+		if quiet, _ := s.isQuietTime(policy.QuietHours, now); !quiet {
+			state.ReleaseAt = now
+		}
+	}
+}
+
+// release returns the held messages as digest, when they are due.
+// applies the last known policy of the handler, the handler might be reloading
+func (s *notifyPolicyStore) release(now time.Time) map[string]map[string]interface{} {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	released := map[string]map[string]interface{}{}
+	for handlerID, state := range s.states {
+		if len(state.Held) == 0 || state.ReleaseAt.After(now) {
+			continue
+		}
+
+		policy := state.Policy
+		if policy != nil {
+			if quiet, end := s.isQuietTime(policy.QuietHours, now); quiet {
+				state.ReleaseAt = end
+				continue
+			}
+			if limited, releaseAt := isRateLimited(state, policy.RateLimit, now); limited {
+				state.ReleaseAt = releaseAt
+				continue
+			}
+		}
+
+		messages := make([]map[string]interface{}, 0, len(state.Held))
+		for _, held := range state.Held {
+			messages = append(messages, held.Data)
+		}
+		released[handlerID] = mergeMessages(messages)
+		recordSent(state, policy, now)
+		state.Held = nil
+		state.ReleaseAt = time.Time{}
+		// the messages received within the next window are held as the next digest
+		if policy != nil && policy.Digest != nil {
+			state.DigestUntil = now.Add(policy.Digest.GetWindow())
+		}
+		s.dirty = true
+	}
+	return released
+}
+
+// heldCount returns the number of held messages on the handlers
+func (s *notifyPolicyStore) heldCount() map[string]int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	counts := map[string]int{}
+	for handlerID, state := range s.states {
+		if len(state.Held) > 0 {
+			counts[handlerID] = len(state.Held)
+		}
+	}
+	return counts
+}
+
+// isRateLimited returns true and the time of the next allowed message, if the limit reached.
+// removes the expired sent entries
+func isRateLimited(state *notifyState, rateLimit *handlerTY.RateLimit, now time.Time) (bool, time.Time) {
+	if rateLimit == nil || rateLimit.Limit <= 0 {
+		state.Sent = nil
+		return false, time.Time{}
+	}
+	period := rateLimit.GetPeriod()
+	sent := make([]time.Time, 0, len(state.Sent))
+	for _, sentOn := range state.Sent {
+		if now.Sub(sentOn) < period {
+			sent = append(sent, sentOn)
+		}
+	}
+	state.Sent = sent
+	if len(sent) < rateLimit.Limit {
+		return false, time.Time{}
+	}
+	return true, sent[len(sent)-rateLimit.Limit].Add(period)
+}
+
+// isQuietTime returns true and the end time, if the time is within any of the quiet hours
+func (s *notifyPolicyStore) isQuietTime(quietHours []handlerTY.QuietHours, now time.Time) (bool, time.Time) {
+	for _, qh := range quietHours {
+		from, err := s.toTimeOfDay(qh.From)
+		if err != nil {
+			s.logger.Warn("invalid quiet hours from time", zap.String("from", qh.From), zap.Error(err))
+			continue
+		}
+		to, err := s.toTimeOfDay(qh.To)
+		if err != nil {
+			s.logger.Warn("invalid quiet hours to time", zap.String("to", qh.To), zap.Error(err))
+			continue
+		}
+
+		// quiet hours might be started yesterday
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		for _, day := range []time.Time{today.AddDate(0, 0, -1), today} {
+			start := day.Add(from)
+			end := day.Add(to)
+			if to <= from {
+				end = end.AddDate(0, 0, 1)
+			}
+			if !isDayIncluded(qh.Days, start.Weekday()) {
+				continue
+			}
+			if !now.Before(start) && now.Before(end) {
+				return true, end
+			}
+		}
+	}
+	return false, time.Time{}
+}
+
+func isDayIncluded(days []string, weekday time.Weekday) bool {
+	if len(days) == 0 {
+		return true
+	}
+	for _, day := range days {
+		day = strings.ToLower(strings.TrimSpace(day))
+		if len(day) > 3 {
+			day = day[:3]
+		}
+		if _weekday, found := weekdays[day]; found && _weekday == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// toTimeOfDay converts the time to duration from the midnight
+func (s *notifyPolicyStore) toTimeOfDay(value string) (time.Duration, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	for _, sunType := range []string{handlerTY.QuietHoursSunrise, handlerTY.QuietHoursSunset} {
+		if !strings.HasPrefix(value, sunType) {
+			continue
+		}
+		if s.sunriseAPI == nil {
+			return 0, fmt.Errorf("%s not available on this service", sunType)
+		}
+		var sunTime *time.Time
+		var err error
+		if sunType == handlerTY.QuietHoursSunrise {
+			sunTime, err = s.sunriseAPI.SunriseTime()
+		} else {
+			sunTime, err = s.sunriseAPI.SunsetTime()
+		}
+		if err != nil {
+			return 0, err
+		}
+		offset := time.Duration(0)
+		if offsetString := strings.TrimPrefix(value, sunType); offsetString != "" {
+			offset, err = time.ParseDuration(offsetString)
+			if err != nil {
+				return 0, err
+			}
+		}
+		localTime := sunTime.Local()
+		timeOfDay := time.Duration(localTime.Hour())*time.Hour + time.Duration(localTime.Minute())*time.Minute + time.Duration(localTime.Second())*time.Second
+		return timeOfDay + offset, nil
+	}
+
+	for _, layout := range []string{"15:04:05", "15:04"} {
+		parsed, err := time.Parse(layout, value)
+		if err == nil {
+			return time.Duration(parsed.Hour())*time.Hour + time.Duration(parsed.Minute())*time.Minute + time.Duration(parsed.Second())*time.Second, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid time: %s", value)
+}
+
+// isCritical returns true, if any of the parameters is marked as critical or has high or urgent priority
+func isCritical(data map[string]interface{}) bool {
+	for _, rawParameter := range data {
+		parameterMap, ok := rawParameter.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		parameter := cmap.CustomMap(parameterMap)
+		if parameter.GetBool(handlerTY.KeyCritical) {
+			return true
+		}
+		if priority := parameter.GetString("priority"); priority != "" && notification.PriorityLevel(priority) >= notification.PriorityLevel(notification.PriorityHigh) {
+			return true
+		}
+	}
+	return false
+}
+
+// dedupKey returns the key from the parameters, if not available the checksum of the message
+func dedupKey(data map[string]interface{}) string {
+	names := make([]string, 0, len(data))
+	for name := range data {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if parameterMap, ok := data[name].(map[string]interface{}); ok {
+			if key := cmap.CustomMap(parameterMap).GetString(handlerTY.KeyDedupKey); key != "" {
+				return key
+			}
+		}
+	}
+	dataBytes, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Sprintf("%v", data)
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(dataBytes))
+}
+
+// mergeMessages coalesces the messages into a single message.
+// texts of the notification data types are joined, for the other data types the latest parameter is kept
+func mergeMessages(messages []map[string]interface{}) map[string]interface{} {
+	if len(messages) == 1 {
+		return messages[0]
+	}
+
+	merged := map[string]interface{}{}
+	counts := map[string]int{}
+	for _, message := range messages {
+		for name, rawParameter := range message {
+			parameterMap, ok := rawParameter.(map[string]interface{})
+			if !ok {
+				merged[name] = rawParameter
+				continue
+			}
+			fields, isTextType := digestFields[cmap.CustomMap(parameterMap).GetString(types.KeyType)]
+			existing, found := merged[name].(map[string]interface{})
+			if !found || !isTextType {
+				merged[name] = cloneParameter(parameterMap)
+				counts[name] = 1
+				continue
+			}
+			existingText := cmap.CustomMap(existing).GetString(fields.text)
+			newText := cmap.CustomMap(parameterMap).GetString(fields.text)
+			existing[fields.text] = fmt.Sprintf("%s\n%s", existingText, newText)
+			counts[name]++
+		}
+	}
+
+	// update the title with the count of messages
+	for name, count := range counts {
+		parameterMap, ok := merged[name].(map[string]interface{})
+		if !ok || count < 2 {
+			continue
+		}
+		fields := digestFields[cmap.CustomMap(parameterMap).GetString(types.KeyType)]
+		if fields.title != "" {
+			title := cmap.CustomMap(parameterMap).GetString(fields.title)
+			parameterMap[fields.title] = strings.TrimSpace(fmt.Sprintf("%s (%d messages)", title, count))
+		}
+	}
+	return merged
+}
+
+func cloneParameter(parameter map[string]interface{}) map[string]interface{} {
+	cloned := make(map[string]interface{}, len(parameter))
+	for key, value := range parameter {
+		cloned[key] = value
+	}
+	return cloned
+}
+
+func (s *notifyPolicyStore) getFilename() string {
+	baseDir := types.GetEnvString(types.ENV_DIR_DATA)
+	dir := filepath.Join(baseDir, deliveryStoreDirectory)
+	err := utils.CreateDir(dir)
+	if err != nil {
+		s.logger.Error("failed to create handler data persistence directory", zap.String("directory", dir), zap.Error(err))
+	}
+	return filepath.Join(dir, heldMessagesFilename)
+}
+
+// load the held messages, rate limit and deduplicate data from the disk
+func (s *notifyPolicyStore) load() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	filename := s.getFilename()
+	if !utils.IsFileExists(filename) {
+		return nil
+	}
+	dataBytes, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	states := map[string]*notifyState{}
+	err = json.Unmarshal(dataBytes, &states)
+	if err != nil {
+		return err
+	}
+	for handlerID, state := range states {
+		if state.DedupKeys == nil {
+			state.DedupKeys = map[string]time.Time{}
+		}
+		s.states[handlerID] = state
+	}
+	s.logger.Info("loaded held messages", zap.Int("handlers", len(states)))
+	return nil
+}
+
+// flush writes the changes to the disk, called periodically and on close
+func (s *notifyPolicyStore) flush() {
+	s.flushMutex.Lock()
+	defer s.flushMutex.Unlock()
+
+	dataBytes, err := s.marshal()
+	if err != nil {
+		s.logger.Error("error on converting held messages", zap.Error(err))
+		return
+	}
+	if dataBytes == nil {
+		return
+	}
+	err = writeStoreFile(s.getFilename(), dataBytes)
+	if err != nil {
+		s.logger.Error("error on saving held messages", zap.String("filename", s.getFilename()), zap.Error(err))
+		// keep as dirty to retry on the next flush
+		s.mutex.Lock()
+		s.dirty = true
+		s.mutex.Unlock()
+	}
+}
+
+// marshal returns the persisted data format, nil if there is no change
+func (s *notifyPolicyStore) marshal() ([]byte, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.dirty {
+		return nil, nil
+	}
+	dataBytes, err := json.Marshal(s.states)
+	if err != nil {
+		return nil, err
+	}
+	s.dirty = false
+	return dataBytes, nil
+}
@@ -0,0 +1,233 @@
+package handler
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mycontroller-org/server/v2/pkg/types"
+	handlerTY "github.com/mycontroller-org/server/v2/plugin/handler/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type fakeSunrise struct {
+	sunrise time.Time
+	sunset  time.Time
+}
+
+func (fs *fakeSunrise) SunriseTime() (*time.Time, error) { return &fs.sunrise, nil }
+func (fs *fakeSunrise) SunsetTime() (*time.Time, error)  { return &fs.sunset, nil }
+
+func newTestPolicyStore(t *testing.T) *notifyPolicyStore {
+	t.Setenv(types.ENV_DIR_DATA, t.TempDir())
+	sunrise := &fakeSunrise{
+		sunrise: time.Date(2024, 6, 1, 6, 15, 0, 0, time.Local),
+		sunset:  time.Date(2024, 6, 1, 20, 45, 0, 0, time.Local),
+	}
+	return newNotifyPolicyStore(zap.NewNop(), sunrise)
+}
+
+func ntfyMessage(title, message string) map[string]interface{} {
+	return map[string]interface{}{
+		"notify": map[string]interface{}{types.KeyType: handlerTY.DataTypeNtfy, "title": title, "message": message},
+	}
+}
+
+func TestToTimeOfDay(t *testing.T) {
+	store := newTestPolicyStore(t)
+
+	tests := []struct {
+		value    string
+		expected time.Duration
+	}{
+		{value: "22:00", expected: 22 * time.Hour},
+		{value: "07:30:15", expected: 7*time.Hour + 30*time.Minute + 15*time.Second},
+		{value: "sunrise", expected: 6*time.Hour + 15*time.Minute},
+		{value: "sunrise-1h", expected: 5*time.Hour + 15*time.Minute},
+		{value: "Sunset+30m", expected: 21*time.Hour + 15*time.Minute},
+	}
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			timeOfDay, err := store.toTimeOfDay(test.value)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, timeOfDay)
+		})
+	}
+
+	_, err := store.toTimeOfDay("sunset+abc")
+	assert.Error(t, err)
+	_, err = store.toTimeOfDay("25:00")
+	assert.Error(t, err)
+
+	noSunStore := newNotifyPolicyStore(zap.NewNop(), nil)
+	_, err = noSunStore.toTimeOfDay("sunset")
+	assert.Error(t, err)
+}
+
+func TestIsQuietTime(t *testing.T) {
+	store := newTestPolicyStore(t)
+	// 2024-06-01 is a saturday
+	at := func(day, hour, minute int) time.Time { return time.Date(2024, 6, day, hour, minute, 0, 0, time.Local) }
+
+	overnight := []handlerTY.QuietHours{{From: "22:00", To: "07:00"}}
+	sunBased := []handlerTY.QuietHours{{From: "sunset+30m", To: "sunrise-1h"}}
+	weekend := []handlerTY.QuietHours{{From: "22:00", To: "09:00", Days: []string{"Saturday", "sun"}}}
+
+	tests := []struct {
+		name       string
+		quietHours []handlerTY.QuietHours
+		now        time.Time
+		quiet      bool
+		end        time.Time
+	}{
+		{name: "before start", quietHours: overnight, now: at(1, 21, 59), quiet: false},
+		{name: "after start", quietHours: overnight, now: at(1, 23, 0), quiet: true, end: at(2, 7, 0)},
+		{name: "started yesterday", quietHours: overnight, now: at(2, 6, 59), quiet: true, end: at(2, 7, 0)},
+		{name: "on end", quietHours: overnight, now: at(2, 7, 0), quiet: false},
+		{name: "before sunset offset", quietHours: sunBased, now: at(1, 21, 0), quiet: false},
+		{name: "after sunset offset", quietHours: sunBased, now: at(1, 21, 15), quiet: true, end: at(2, 5, 15)},
+		{name: "before sunrise offset", quietHours: sunBased, now: at(2, 5, 0), quiet: true, end: at(2, 5, 15)},
+		{name: "after sunrise offset", quietHours: sunBased, now: at(2, 5, 30), quiet: false},
+		{name: "included day", quietHours: weekend, now: at(1, 23, 0), quiet: true, end: at(2, 9, 0)},
+		{name: "started on included day", quietHours: weekend, now: at(3, 8, 0), quiet: true, end: at(3, 9, 0)},
+		{name: "excluded day", quietHours: weekend, now: at(3, 23, 0), quiet: false},
+		{name: "invalid time skipped", quietHours: []handlerTY.QuietHours{{From: "abc", To: "07:00"}}, now: at(1, 6, 0), quiet: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			quiet, end := store.isQuietTime(test.quietHours, test.now)
+			assert.Equal(t, test.quiet, quiet)
+			assert.Equal(t, test.end, end)
+		})
+	}
+}
+
+func TestNotifyPolicyRateLimit(t *testing.T) {
+	store := newTestPolicyStore(t)
+	policy := &handlerTY.NotifyPolicy{RateLimit: &handlerTY.RateLimit{Limit: 2, Period: "1m"}}
+
+	assert.Equal(t, policyDeliver, store.apply("h1", policy, ntfyMessage("t", "1")))
+	assert.Equal(t, policyDeliver, store.apply("h1", policy, ntfyMessage("t", "2")))
+	assert.Equal(t, policyHold, store.apply("h1", policy, ntfyMessage("t", "3")))
+	assert.Equal(t, policyHold, store.apply("h1", policy, ntfyMessage("t", "4")))
+
+	// critical messages skip the rate limit
+	critical := ntfyMessage("t", "critical")
+	critical["notify"].(map[string]interface{})[handlerTY.KeyCritical] = true
+	assert.Equal(t, policyDeliver, store.apply("h1", policy, critical))
+	assert.Equal(t, map[string]int{"h1": 2}, store.heldCount())
+
+	state := store.states["h1"]
+	releaseAt := state.ReleaseAt
+	assert.WithinDuration(t, time.Now().Add(time.Minute), releaseAt, time.Second)
+
+	assert.Empty(t, store.release(releaseAt.Add(-time.Second)))
+
+	released := store.release(releaseAt.Add(time.Minute))
+	require.Contains(t, released, "h1")
+	notify := released["h1"]["notify"].(map[string]interface{})
+	assert.Equal(t, "3\n4", notify["message"])
+	assert.Equal(t, "t (2 messages)", notify["title"])
+	assert.Empty(t, store.heldCount())
+}
+
+func TestNotifyPolicyDeduplicate(t *testing.T) {
+	store := newTestPolicyStore(t)
+	policy := &handlerTY.NotifyPolicy{Deduplicate: &handlerTY.Deduplicate{Window: "1m"}}
+
+	assert.Equal(t, policyDeliver, store.apply("h1", policy, ntfyMessage("t", "1")))
+	assert.Equal(t, policyDrop, store.apply("h1", policy, ntfyMessage("t", "1")))
+	assert.Equal(t, policyDeliver, store.apply("h1", policy, ntfyMessage("t", "2")))
+	// other handlers have their own keys
+	assert.Equal(t, policyDeliver, store.apply("h2", policy, ntfyMessage("t", "1")))
+
+	// messages with the same dedup key are duplicates, even with a different text
+	withKey := func(message string) map[string]interface{} {
+		data := ntfyMessage("t", message)
+		data["notify"].(map[string]interface{})[handlerTY.KeyDedupKey] = "door_open"
+		return data
+	}
+	assert.Equal(t, policyDeliver, store.apply("h1", policy, withKey("a")))
+	assert.Equal(t, policyDrop, store.apply("h1", policy, withKey("b")))
+
+	// delivered again after the window
+	for key := range store.states["h1"].DedupKeys {
+		store.states["h1"].DedupKeys[key] = time.Now().Add(-time.Minute)
+	}
+	assert.Equal(t, policyDeliver, store.apply("h1", policy, ntfyMessage("t", "1")))
+	assert.Equal(t, policyDeliver, store.apply("h1", policy, withKey("c")))
+}
+
+func TestNotifyPolicyDigest(t *testing.T) {
+	store := newTestPolicyStore(t)
+	policy := &handlerTY.NotifyPolicy{Digest: &handlerTY.Digest{Window: "5m", MaxMessages: 3}}
+
+	// first message delivered at once, starts the window
+	assert.Equal(t, policyDeliver, store.apply("h1", policy, ntfyMessage("alert", "1")))
+	assert.Equal(t, policyHold, store.apply("h1", policy, ntfyMessage("alert", "2")))
+
+	other := ntfyMessage("alert", "3")
+	other["mqtt"] = map[string]interface{}{types.KeyType: handlerTY.DataTypeMqtt, "publish": "a/b", "data": "first"}
+	assert.Equal(t, policyHold, store.apply("h1", policy, other))
+
+	state := store.states["h1"]
+	assert.Equal(t, state.DigestUntil, state.ReleaseAt)
+	assert.Empty(t, store.release(time.Now()))
+
+	released := store.release(state.DigestUntil)
+	require.Contains(t, released, "h1")
+	notify := released["h1"]["notify"].(map[string]interface{})
+	assert.Equal(t, "2\n3", notify["message"])
+	assert.Equal(t, "alert (2 messages)", notify["title"])
+	assert.Equal(t, "first", released["h1"]["mqtt"].(map[string]interface{})["data"])
+
+	// released digest starts the next window, reaching max messages releases before the window ends
+	assert.True(t, state.DigestUntil.After(time.Now()))
+	for index := 0; index < 3; index++ {
+		assert.Equal(t, policyHold, store.apply("h1", policy, ntfyMessage("alert", "x")))
+	}
+	released = store.release(time.Now())
+	require.Contains(t, released, "h1")
+	assert.Equal(t, "alert (3 messages)", released["h1"]["notify"].(map[string]interface{})["title"])
+}
+
+func TestNotifyPolicyReleaseLastKnownPolicy(t *testing.T) {
+	store := newTestPolicyStore(t)
+	// quiet for the whole day
+	policy := &handlerTY.NotifyPolicy{QuietHours: []handlerTY.QuietHours{{From: "00:00", To: "00:00"}}}
+
+	assert.Equal(t, policyHold, store.apply("h1", policy, ntfyMessage("t", "1")))
+	state := store.states["h1"]
+
+	// handler not loaded, keeps holding with the last known policy
+	assert.Empty(t, store.release(state.ReleaseAt))
+	assert.Equal(t, map[string]int{"h1": 1}, store.heldCount())
+
+	// handler loaded without a policy, delivered
+	store.setPolicy("h1", nil)
+	released := store.release(time.Now())
+	assert.Equal(t, ntfyMessage("t", "1"), released["h1"])
+}
+
+func TestNotifyPolicyPersistence(t *testing.T) {
+	store := newTestPolicyStore(t)
+	policy := &handlerTY.NotifyPolicy{
+		RateLimit:   &handlerTY.RateLimit{Limit: 1, Period: "1h"},
+		Deduplicate: &handlerTY.Deduplicate{Window: "1h"},
+	}
+	assert.Equal(t, policyDeliver, store.apply("h1", policy, ntfyMessage("t", "1")))
+	assert.Equal(t, policyHold, store.apply("h1", policy, ntfyMessage("t", "2")))
+	store.flush()
+	info, err := os.Stat(store.getFilename())
+	require.NoError(t, err)
+	assert.Equal(t, storeFileMode, info.Mode().Perm())
+
+	// restarted store keeps the rate limit and dedup data
+	loaded := newNotifyPolicyStore(zap.NewNop(), nil)
+	require.NoError(t, loaded.load())
+	assert.Equal(t, map[string]int{"h1": 1}, loaded.heldCount())
+	assert.Equal(t, policyDrop, loaded.apply("h1", policy, ntfyMessage("t", "1")))
+	assert.Empty(t, loaded.release(time.Now()))
+}
@@ -35,7 +35,8 @@ func (svc *HandlerService) startHandler(cfg *handlerTY.Config) error {
 	} else {
 		state.Message = "started successfully"
 		state.Status = types.StatusUp
-		svc.store.Add(cfg.ID, handler)
+		svc.store.Add(cfg.ID, handler, cfg.RetryPolicy, cfg.NotifyPolicy)
+		svc.policies.setPolicy(cfg.ID, cfg.NotifyPolicy)
 	}
 
 	busUtils.SetHandlerState(svc.logger, svc.bus, cfg.ID, state)
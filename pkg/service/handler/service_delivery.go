@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"fmt"
+
+	rsTY "github.com/mycontroller-org/server/v2/pkg/types/resource_service"
+	"go.uber.org/zap"
+)
+
+// deliveryService serves the dead letters and delivery stats requests
+func (svc *HandlerService) deliveryService(reqEvent *rsTY.ServiceEvent) error {
+	resEvent := &rsTY.ServiceEvent{
+		Type:    reqEvent.Type,
+		Command: reqEvent.ReplyCommand,
+	}
+
+	switch reqEvent.Command {
+	case rsTY.CommandList:
+		resEvent.SetData(svc.deliveries.listDeadLetters(reqEvent.ID))
+
+	case rsTY.CommandStats:
+		resEvent.SetData(svc.deliveries.listStats(svc.policies.heldCount()))
+
+	case rsTY.CommandRetry:
+		ids := []string{}
+		err := reqEvent.LoadData(&ids)
+		if err != nil {
+			svc.logger.Error("error on data conversion", zap.Any("data", reqEvent.Data), zap.Error(err))
+			return err
+		}
+		count := svc.deliveries.retryDeadLetters(ids)
+		svc.logger.Info("dead letters moved to pending deliveries", zap.Int("count", count))
+		return nil
+
+	case rsTY.CommandRemove:
+		ids := []string{}
+		err := reqEvent.LoadData(&ids)
+		if err != nil {
+			svc.logger.Error("error on data conversion", zap.Any("data", reqEvent.Data), zap.Error(err))
+			return err
+		}
+		count := svc.deliveries.discardDeadLetters(ids)
+		svc.logger.Info("dead letters discarded", zap.Int("count", count))
+		return nil
+
+	default:
+		return fmt.Errorf("unknown command: %s", reqEvent.Command)
+	}
+
+	if reqEvent.ReplyTopic == "" {
+		return nil
+	}
+	return svc.bus.Publish(reqEvent.ReplyTopic, resEvent)
+}
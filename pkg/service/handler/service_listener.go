@@ -4,7 +4,9 @@ import (
 	"context"
 
 	encryptionAPI "github.com/mycontroller-org/server/v2/pkg/encryption"
+	"github.com/mycontroller-org/server/v2/pkg/types"
 	rsTY "github.com/mycontroller-org/server/v2/pkg/types/resource_service"
+	schedulerTY "github.com/mycontroller-org/server/v2/pkg/types/scheduler"
 	serviceTY "github.com/mycontroller-org/server/v2/pkg/types/service"
 	sfTY "github.com/mycontroller-org/server/v2/pkg/types/service_filter"
 	"github.com/mycontroller-org/server/v2/pkg/types/topic"
@@ -19,6 +21,15 @@ import (
 const (
 	defaultQueueSize = int(100)
 	defaultWorkers   = int(1)
+
+	retryJobName = "handler_service_delivery_retry"
+	retryJobSpec = "@every 1s"
+
+	releaseJobName = "handler_service_held_messages_release"
+	releaseJobSpec = "@every 1s"
+
+	flushJobName = "handler_service_stores_flush"
+	flushJobSpec = "@every 2s"
 )
 
 type HandlerService struct {
@@ -28,11 +39,15 @@ type HandlerService struct {
 	filter       *sfTY.ServiceFilter
 	bus          busTY.Plugin
 	enc          *encryptionAPI.Encryption
+	scheduler    schedulerTY.CoreScheduler
+	deliveries   *deliveryStore
+	policies     *notifyPolicyStore
 	serviceQueue *queueUtils.QueueSpec
 	messageQueue *queueUtils.QueueSpec
 }
 
-func New(ctx context.Context, filter *sfTY.ServiceFilter) (serviceTY.Service, error) {
+// New handler service, sunrise api is optional, used on the notify policy quiet hours
+func New(ctx context.Context, filter *sfTY.ServiceFilter, sunriseAPI types.Sunrise) (serviceTY.Service, error) {
 	logger, err := loggerUtils.FromContext(ctx)
 	if err != nil {
 		return nil, err
@@ -45,16 +60,28 @@ func New(ctx context.Context, filter *sfTY.ServiceFilter) (serviceTY.Service, er
 	if err != nil {
 		return nil, err
 	}
+	scheduler, err := schedulerTY.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	svc := &HandlerService{
-		ctx:    ctx,
-		logger: logger.Named("handler_service"),
-		filter: filter,
-		bus:    bus,
-		enc:    enc,
+		ctx:       ctx,
+		logger:    logger.Named("handler_service"),
+		filter:    filter,
+		bus:       bus,
+		enc:       enc,
+		scheduler: scheduler,
 	}
 
-	svc.store = &Store{handlers: make(map[string]handlerTY.Plugin), logger: svc.logger}
+	svc.store = &Store{
+		handlers:       make(map[string]handlerTY.Plugin),
+		retryPolicies:  make(map[string]*handlerTY.RetryPolicy),
+		notifyPolicies: make(map[string]*handlerTY.NotifyPolicy),
+		logger:         svc.logger,
+	}
+	svc.deliveries = newDeliveryStore(svc.logger)
+	svc.policies = newNotifyPolicyStore(svc.logger, sunriseAPI)
 
 	svc.serviceQueue = &queueUtils.QueueSpec{
 		Queue:          queueUtils.New(svc.logger, "handler_service", defaultQueueSize, svc.postProcessServiceEvent, defaultWorkers),
@@ -63,7 +90,8 @@ func New(ctx context.Context, filter *sfTY.ServiceFilter) (serviceTY.Service, er
 	}
 
 	svc.messageQueue = &queueUtils.QueueSpec{
-		Queue:          queueUtils.New(svc.logger, "handler_message", defaultQueueSize, svc.processHandlerMessage, defaultWorkers),
+		// failed deliveries are retried by the retry job, not by the queue
+		Queue:          queueUtils.NewWithRetry(svc.logger, "handler_message", defaultQueueSize, svc.processHandlerMessage, defaultWorkers, false, 0, 0),
 		Topic:          topic.TopicPostMessageNotifyHandler,
 		SubscriptionId: -1,
 	}
@@ -100,6 +128,30 @@ func (svc *HandlerService) Start() error {
 		return err
 	}
 
+	// load pending deliveries and dead letters
+	err = svc.deliveries.load()
+	if err != nil {
+		svc.logger.Error("error on loading handler deliveries", zap.Error(err))
+	}
+	err = svc.scheduler.AddFunc(retryJobName, retryJobSpec, svc.retryDeliveries)
+	if err != nil {
+		return err
+	}
+
+	// load messages held by the notify policies
+	err = svc.policies.load()
+	if err != nil {
+		svc.logger.Error("error on loading held messages", zap.Error(err))
+	}
+	err = svc.scheduler.AddFunc(releaseJobName, releaseJobSpec, svc.releaseHeldMessages)
+	if err != nil {
+		return err
+	}
+	err = svc.scheduler.AddFunc(flushJobName, flushJobSpec, svc.flushStores)
+	if err != nil {
+		return err
+	}
+
 	// load handlers
 	reqEvent := rsTY.ServiceEvent{
 		Type:    rsTY.TypeHandler,
@@ -113,9 +165,13 @@ func (svc *HandlerService) Close() error {
 	if svc.filter.Disabled {
 		return nil
 	}
+	svc.scheduler.RemoveFunc(retryJobName)
+	svc.scheduler.RemoveFunc(releaseJobName)
+	svc.scheduler.RemoveFunc(flushJobName)
 	svc.unloadAll()
 	svc.serviceQueue.Close()
 	svc.closeMessageListener()
+	svc.flushStores()
 	return nil
 }
 
@@ -126,6 +182,12 @@ func (svc *HandlerService) onServiceEvent(event *busTY.BusData) {
 		svc.logger.Warn("failed to convert to target type", zap.Error(err))
 		return
 	}
+
+	// reply topic of a request, the response has to be posted on it
+	if event.ReplyTopic != "" {
+		reqEvent.ReplyTopic = event.ReplyTopic
+	}
+
 	if reqEvent.Type == "" {
 		svc.logger.Warn("received an empty event", zap.Any("event", event))
 		return
@@ -142,6 +204,10 @@ func (svc *HandlerService) postProcessServiceEvent(event interface{}) error {
 	reqEvent := event.(*rsTY.ServiceEvent)
 	svc.logger.Debug("processing a request", zap.Any("event", reqEvent))
 
+	if reqEvent.Type == rsTY.TypeHandlerDelivery {
+		return svc.deliveryService(reqEvent)
+	}
+
 	if reqEvent.Type != rsTY.TypeHandler {
 		svc.logger.Warn("unsupported event type", zap.Any("event", reqEvent))
 		return nil
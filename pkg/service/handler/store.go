@@ -8,17 +8,21 @@ import (
 )
 
 type Store struct {
-	handlers map[string]handlerTY.Plugin
-	mutex    sync.Mutex
-	logger   *zap.Logger
+	handlers       map[string]handlerTY.Plugin
+	retryPolicies  map[string]*handlerTY.RetryPolicy
+	notifyPolicies map[string]*handlerTY.NotifyPolicy
+	mutex          sync.Mutex
+	logger         *zap.Logger
 }
 
 // Add a handler
-func (s *Store) Add(id string, handler handlerTY.Plugin) {
+func (s *Store) Add(id string, handler handlerTY.Plugin, retryPolicy *handlerTY.RetryPolicy, notifyPolicy *handlerTY.NotifyPolicy) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
 	s.handlers[id] = handler
+	s.retryPolicies[id] = retryPolicy
+	s.notifyPolicies[id] = notifyPolicy
 }
 
 // Remove a handler
@@ -27,6 +31,24 @@ func (s *Store) Remove(id string) {
 	defer s.mutex.Unlock()
 
 	delete(s.handlers, id)
+	delete(s.retryPolicies, id)
+	delete(s.notifyPolicies, id)
+}
+
+// GetRetryPolicy returns retry policy of a handler, nil returns the default policy
+func (s *Store) GetRetryPolicy(id string) *handlerTY.RetryPolicy {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.retryPolicies[id]
+}
+
+// GetNotifyPolicy returns notify policy of a handler, nil if not configured
+func (s *Store) GetNotifyPolicy(id string) *handlerTY.NotifyPolicy {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.notifyPolicies[id]
 }
 
 // GetByID returns handler by id
@@ -49,6 +71,8 @@ func (s *Store) CloseHandlers() {
 		}
 	}
 	s.handlers = make(map[string]handlerTY.Plugin)
+	s.retryPolicies = make(map[string]*handlerTY.RetryPolicy)
+	s.notifyPolicies = make(map[string]*handlerTY.NotifyPolicy)
 }
 
 func (s *Store) ListIDs() []string {
@@ -7,6 +7,7 @@ import (
 
 	actionAPI "github.com/mycontroller-org/server/v2/pkg/api/action"
 	entityAPI "github.com/mycontroller-org/server/v2/pkg/api/entities"
+	quickIdAPI "github.com/mycontroller-org/server/v2/pkg/api/quickid"
 	"github.com/mycontroller-org/server/v2/pkg/types/cmap"
 	rsTY "github.com/mycontroller-org/server/v2/pkg/types/resource_service"
 	serviceTY "github.com/mycontroller-org/server/v2/pkg/types/service"
@@ -20,17 +21,20 @@ import (
 )
 
 const (
-	defaultQueueSize = int(50)
-	defaultWorkers   = int(5)
+	defaultQueueSize          = int(50)
+	defaultWorkers            = int(5)
+	firmwareCacheInactiveTime = 10 * time.Minute // firmware file removed from the cache, if not accessed
 )
 
 type ResourceService struct {
-	ctx         context.Context
-	logger      *zap.Logger
-	api         *entityAPI.API
-	actionAPI   *actionAPI.ActionAPI
-	bus         busTY.Plugin
-	eventsQueue *queueUtils.QueueSpec
+	ctx           context.Context
+	logger        *zap.Logger
+	api           *entityAPI.API
+	actionAPI     *actionAPI.ActionAPI
+	quickIdAPI    *quickIdAPI.QuickIdAPI
+	bus           busTY.Plugin
+	eventsQueue   *queueUtils.QueueSpec
+	firmwareCache *firmwareCache
 }
 
 func New(ctx context.Context) (serviceTY.Service, error) {
@@ -51,12 +55,19 @@ func New(ctx context.Context) (serviceTY.Service, error) {
 		return nil, err
 	}
 
+	_quickIdAPI, err := quickIdAPI.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	svc := &ResourceService{
-		ctx:       ctx,
-		logger:    logger.Named("resource_service"),
-		api:       api,
-		actionAPI: _actionAPI,
-		bus:       bus,
+		ctx:           ctx,
+		logger:        logger.Named("resource_service"),
+		api:           api,
+		actionAPI:     _actionAPI,
+		quickIdAPI:    _quickIdAPI,
+		bus:           bus,
+		firmwareCache: newFirmwareCache(firmwareCacheInactiveTime),
 	}
 
 	svc.eventsQueue = &queueUtils.QueueSpec{
@@ -101,6 +112,11 @@ func (svc *ResourceService) onEvent(data *busTY.BusData) {
 		return
 	}
 
+	// reply topic of a request, the response has to be posted on it
+	if data.ReplyTopic != "" {
+		reqEvent.ReplyTopic = data.ReplyTopic
+	}
+
 	if reqEvent.Type == "" {
 		svc.logger.Warn("received an empty event", zap.Any("event", data))
 		return
@@ -117,57 +133,42 @@ func (svc *ResourceService) processEvent(item interface{}) error {
 	request := item.(*rsTY.ServiceEvent)
 	svc.logger.Debug("processing an event", zap.Any("event", request))
 	start := time.Now()
+	var err error
 	switch request.Type {
 	case rsTY.TypeGateway:
-		err := svc.gatewayService(request)
-		if err != nil {
-			svc.logger.Error("error on serving gateway request", zap.Error(err))
-		}
+		err = svc.gatewayService(request)
 
 	case rsTY.TypeNode:
-		err := svc.nodeService(request)
-		if err != nil {
-			svc.logger.Error("error on serving node request", zap.Error(err))
-		}
+		err = svc.nodeService(request)
 
 	case rsTY.TypeTask:
-		err := svc.taskService(request)
-		if err != nil {
-			svc.logger.Error("error on serving task request", zap.Error(err))
-		}
+		err = svc.taskService(request)
 
 	case rsTY.TypeHandler:
-		err := svc.handlerService(request)
-		if err != nil {
-			svc.logger.Error("error on serving handler request", zap.Error(err))
-		}
+		err = svc.handlerService(request)
 
 	case rsTY.TypeScheduler:
-		err := svc.schedulerService(request)
-		if err != nil {
-			svc.logger.Error("error on serving scheduler request", zap.Error(err))
-		}
+		err = svc.schedulerService(request)
 
 	case rsTY.TypeResourceAction:
-		err := svc.resourceActionService(request)
-		if err != nil {
-			svc.logger.Error("error on serving resource quickID request", zap.Error(err))
-		}
+		err = svc.resourceActionService(request)
 
 	case rsTY.TypeFirmware:
-		err := svc.firmwareService(request)
-		if err != nil {
-			svc.logger.Error("error on serving firmware service request", zap.Error(err))
-		}
+		err = svc.firmwareService(request)
 
 	case rsTY.TypeVirtualAssistant:
-		err := svc.virtualAssistantService(request)
-		if err != nil {
-			svc.logger.Error("error on serving virtual assistant service request", zap.Error(err))
-		}
+		err = svc.virtualAssistantService(request)
+
+	case rsTY.TypeQuickID:
+		err = svc.quickIdService(request)
 
 	default:
-		svc.logger.Warn("unknown event type", zap.Any("event", request))
+		err = fmt.Errorf("unknown event type: %s", request.Type)
+	}
+
+	if err != nil {
+		svc.logger.Error("error on serving a request", zap.String("type", request.Type), zap.String("command", request.Command), zap.Error(err))
+		svc.postError(request, err)
 	}
 	svc.logger.Debug("completed a resource service", zap.String("timeTaken", time.Since(start).String()), zap.Any("data", request))
 	return nil
@@ -180,6 +181,19 @@ func (svc *ResourceService) postResponse(topic string, response *rsTY.ServiceEve
 	return svc.bus.Publish(topic, response)
 }
 
+// postError reports the error to the requester, if a reply topic supplied
+func (svc *ResourceService) postError(request *rsTY.ServiceEvent, err error) {
+	response := &rsTY.ServiceEvent{
+		Type:    request.Type,
+		Command: request.ReplyCommand,
+		Error:   err.Error(),
+	}
+	postErr := svc.postResponse(request.ReplyTopic, response)
+	if postErr != nil {
+		svc.logger.Error("error on posting a response", zap.String("replyTopic", request.ReplyTopic), zap.Error(postErr))
+	}
+}
+
 func (svc *ResourceService) getLabelsFilter(labels cmap.CustomStringMap) []storageTY.Filter {
 	filters := make([]storageTY.Filter, 0)
 	for key, value := range labels {
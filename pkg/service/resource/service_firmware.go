@@ -2,6 +2,9 @@ package resource
 
 import (
 	"errors"
+	"fmt"
+	"sync"
+	"time"
 
 	"github.com/mycontroller-org/server/v2/pkg/types"
 	firmwareTY "github.com/mycontroller-org/server/v2/pkg/types/firmware"
@@ -25,8 +28,11 @@ func (svc *ResourceService) firmwareService(reqEvent *rsTY.ServiceEvent) error {
 		resEvent.SetData(data)
 
 	case rsTY.CommandBlocks:
-		svc.sendFirmwareBlocks(reqEvent)
-		return nil
+		data, err := svc.getFirmwareBlock(reqEvent)
+		if err != nil {
+			resEvent.Error = err.Error()
+		}
+		resEvent.SetData(data)
 
 	default:
 		return errors.New("unknown command")
@@ -52,65 +58,107 @@ func (svc *ResourceService) getFirmware(request *rsTY.ServiceEvent) (interface{}
 	return nil, errors.New("filter not supplied")
 }
 
-func (svc *ResourceService) sendFirmwareBlocks(reqEvent *rsTY.ServiceEvent) {
-	if reqEvent.ID == "" || reqEvent.ReplyTopic == "" {
-		return
+// getFirmwareBlock returns a block of the firmware file, block number supplied as data.
+// file bytes are cached, a file read per block slows down the transfer
+func (svc *ResourceService) getFirmwareBlock(request *rsTY.ServiceEvent) (*firmwareTY.FirmwareBlock, error) {
+	if request.ID == "" {
+		return nil, errors.New("firmware id not supplied")
 	}
-	fw, err := svc.api.Firmware().GetByID(reqEvent.ID)
+	blockNumber := 0
+	err := request.LoadData(&blockNumber)
 	if err != nil {
-		svc.logger.Error("error fetching firmware", zap.String("id", reqEvent.ID), zap.Error(err))
-		return
+		return nil, err
 	}
-	firmwareBaseDir := types.GetEnvString(types.ENV_DIR_DATA_FIRMWARE)
-	fwBytes, err := utils.ReadFile(firmwareBaseDir, fw.File.InternalName)
-	if err != nil {
-		svc.logger.Error("error on reading a firmware file", zap.String("directory", firmwareBaseDir), zap.String("fileName", fw.File.InternalName), zap.Error(err))
-		return
+	if blockNumber < 0 {
+		return nil, fmt.Errorf("invalid block number:%d", blockNumber)
 	}
 
-	blockNumber := 0
-	totalBytes := len(fwBytes)
-	for {
-		positionStart := blockNumber * firmwareTY.BlockSize
-		positionEnd := positionStart + firmwareTY.BlockSize
-
-		reachedEnd := false
-		var bytes []byte
-		if positionEnd < len(fwBytes) {
-			bytes = fwBytes[positionStart:positionEnd]
-		} else {
-			bytes = fwBytes[positionStart:]
-			reachedEnd = true
-		}
-
-		err := svc.postFirmwareBlock(reqEvent.ReplyTopic, fw.ID, bytes, blockNumber, totalBytes, reachedEnd)
+	fw, err := svc.api.Firmware().GetByID(request.ID)
+	if err != nil {
+		return nil, err
+	}
+	fwBytes, err := svc.firmwareCache.get(fw.ID, fw.File.Checksum, func() ([]byte, error) {
+		firmwareBaseDir := types.GetEnvString(types.ENV_DIR_DATA_FIRMWARE)
+		fwBytes, err := utils.ReadFile(firmwareBaseDir, fw.File.InternalName)
 		if err != nil {
-			svc.logger.Error("error on posting firmware blocks", zap.String("firmwareId", fw.ID), zap.Error(err))
-		}
-
-		if reachedEnd {
-			return
+			svc.logger.Error("error on reading a firmware file", zap.String("directory", firmwareBaseDir), zap.String("fileName", fw.File.InternalName), zap.Error(err))
+			return nil, err
 		}
-		blockNumber++
+		return fwBytes, nil
+	})
+	if err != nil {
+		return nil, err
 	}
-
+	return getBlock(fw.ID, blockNumber, fwBytes)
 }
 
-func (svc *ResourceService) postFirmwareBlock(replyTopic, id string, bytes []byte, blockNumber, totalBytes int, isFinal bool) error {
-	resEvent := &rsTY.ServiceEvent{
-		Type:    rsTY.TypeFirmware,
-		Command: rsTY.CommandBlocks,
-		ID:      id,
+// getBlock returns the block from the firmware file bytes
+func getBlock(id string, blockNumber int, fwBytes []byte) (*firmwareTY.FirmwareBlock, error) {
+	positionStart := blockNumber * firmwareTY.BlockSize
+	if blockNumber > 0 && positionStart >= len(fwBytes) {
+		return nil, fmt.Errorf("block number out of range. blockNumber:%d, totalBytes:%d", blockNumber, len(fwBytes))
+	}
+	positionEnd := positionStart + firmwareTY.BlockSize
+	reachedEnd := false
+	if positionEnd >= len(fwBytes) {
+		positionEnd = len(fwBytes)
+		reachedEnd = true
 	}
 
-	fwBlock := firmwareTY.FirmwareBlock{
+	fwBlock := &firmwareTY.FirmwareBlock{
 		ID:          id,
 		BlockNumber: blockNumber,
-		TotalBytes:  totalBytes,
-		Data:        bytes,
-		IsFinal:     isFinal,
+		TotalBytes:  len(fwBytes),
+		Data:        fwBytes[positionStart:positionEnd],
+		IsFinal:     reachedEnd,
 	}
+	return fwBlock, nil
+}
+
+// firmwareFile bytes of a firmware file, valid till the checksum changes
+type firmwareFile struct {
+	checksum   string
+	data       []byte
+	lastAccess time.Time
+}
+
+// firmwareCache keeps the firmware files those are being transferred, removed when inactive
+type firmwareCache struct {
+	files        map[string]*firmwareFile
+	inactiveTime time.Duration
+	mutex        sync.Mutex
+}
+
+func newFirmwareCache(inactiveTime time.Duration) *firmwareCache {
+	return &firmwareCache{files: make(map[string]*firmwareFile), inactiveTime: inactiveTime}
+}
+
+// get returns the file bytes, loads the file if not available or the checksum changed
+func (fc *firmwareCache) get(id, checksum string, loadFn func() ([]byte, error)) ([]byte, error) {
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+
+	now := time.Now()
+	fc.purge(now)
 
-	resEvent.SetData(fwBlock)
-	return svc.postResponse(replyTopic, resEvent)
+	file, found := fc.files[id]
+	if !found || file.checksum != checksum {
+		data, err := loadFn()
+		if err != nil {
+			return nil, err
+		}
+		file = &firmwareFile{checksum: checksum, data: data}
+		fc.files[id] = file
+	}
+	file.lastAccess = now
+	return file.data, nil
+}
+
+// purge removes the inactive files, caller should hold the lock
+func (fc *firmwareCache) purge(now time.Time) {
+	for id, file := range fc.files {
+		if now.Sub(file.lastAccess) >= fc.inactiveTime {
+			delete(fc.files, id)
+		}
+	}
 }
@@ -0,0 +1,88 @@
+package resource
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	firmwareTY "github.com/mycontroller-org/server/v2/pkg/types/firmware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetBlock(t *testing.T) {
+	fwBytes := bytes.Repeat([]byte{0x01}, firmwareTY.BlockSize*2+10)
+
+	tests := []struct {
+		name        string
+		blockNumber int
+		size        int
+		isFinal     bool
+		hasError    bool
+	}{
+		{name: "first block", blockNumber: 0, size: firmwareTY.BlockSize},
+		{name: "middle block", blockNumber: 1, size: firmwareTY.BlockSize},
+		{name: "final block", blockNumber: 2, size: 10, isFinal: true},
+		{name: "out of range", blockNumber: 3, hasError: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			block, err := getBlock("fw", test.blockNumber, fwBytes)
+			if test.hasError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "fw", block.ID)
+			assert.Equal(t, test.blockNumber, block.BlockNumber)
+			assert.Equal(t, len(fwBytes), block.TotalBytes)
+			assert.Len(t, block.Data, test.size)
+			assert.Equal(t, test.isFinal, block.IsFinal)
+		})
+	}
+
+	// empty file, the first block is the final block
+	block, err := getBlock("fw", 0, []byte{})
+	require.NoError(t, err)
+	assert.True(t, block.IsFinal)
+	assert.Empty(t, block.Data)
+}
+
+func TestFirmwareCache(t *testing.T) {
+	cache := newFirmwareCache(time.Minute)
+
+	loads := 0
+	loadFn := func(data string) func() ([]byte, error) {
+		return func() ([]byte, error) {
+			loads++
+			return []byte(data), nil
+		}
+	}
+
+	// loaded once for all the blocks
+	for index := 0; index < 5; index++ {
+		data, err := cache.get("fw", "sha256:1", loadFn("v1"))
+		require.NoError(t, err)
+		assert.Equal(t, "v1", string(data))
+	}
+	assert.Equal(t, 1, loads)
+
+	// file updated
+	data, err := cache.get("fw", "sha256:2", loadFn("v2"))
+	require.NoError(t, err)
+	assert.Equal(t, "v2", string(data))
+	assert.Equal(t, 2, loads)
+
+	// load errors are not cached
+	_, err = cache.get("other", "sha256:3", func() ([]byte, error) { return nil, errors.New("file not found") })
+	assert.Error(t, err)
+	assert.NotContains(t, cache.files, "other")
+
+	// inactive files removed
+	cache.files["fw"].lastAccess = time.Now().Add(-time.Hour)
+	_, err = cache.get("another", "sha256:4", loadFn("v4"))
+	require.NoError(t, err)
+	assert.NotContains(t, cache.files, "fw")
+	assert.Contains(t, cache.files, "another")
+}
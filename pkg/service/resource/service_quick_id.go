@@ -0,0 +1,44 @@
+package resource
+
+import (
+	"errors"
+	"fmt"
+
+	rsTY "github.com/mycontroller-org/server/v2/pkg/types/resource_service"
+)
+
+func (svc *ResourceService) quickIdService(reqEvent *rsTY.ServiceEvent) error {
+	resEvent := &rsTY.ServiceEvent{
+		Type:    reqEvent.Type,
+		Command: reqEvent.ReplyCommand,
+	}
+
+	switch reqEvent.Command {
+	case rsTY.CommandGet:
+		data, err := svc.getResourceByQuickID(reqEvent)
+		if err != nil {
+			resEvent.Error = err.Error()
+		}
+		resEvent.SetData(data)
+
+	default:
+		return fmt.Errorf("unknown command: %s", reqEvent.Command)
+	}
+	return svc.postResponse(reqEvent.ReplyTopic, resEvent)
+}
+
+// getResourceByQuickID returns the resource, quick id supplied as id
+func (svc *ResourceService) getResourceByQuickID(request *rsTY.ServiceEvent) (interface{}, error) {
+	if request.ID == "" {
+		return nil, errors.New("quick id not supplied")
+	}
+	resources, err := svc.quickIdAPI.GetResources([]string{request.ID})
+	if err != nil {
+		return nil, err
+	}
+	resource, found := resources[request.ID]
+	if !found {
+		return nil, fmt.Errorf("resource not found. quickId:%s", request.ID)
+	}
+	return resource, nil
+}
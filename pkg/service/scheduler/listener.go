@@ -1,6 +1,7 @@
 package scheduler
 
 import (
+	webhookTY "github.com/mycontroller-org/server/v2/pkg/types/inbound_webhook"
 	rsTY "github.com/mycontroller-org/server/v2/pkg/types/resource_service"
 	schedulerTY "github.com/mycontroller-org/server/v2/pkg/types/scheduler"
 	"github.com/mycontroller-org/server/v2/pkg/types/topic"
@@ -105,6 +106,19 @@ func (svc *SchedulerService) processServiceEvent(event interface{}) error {
 	case rsTY.CommandUnloadAll:
 		svc.unloadAll()
 
+	case rsTY.CommandTrigger:
+		trigger := &webhookTY.ScheduleTrigger{}
+		err := reqEvent.LoadData(trigger)
+		if err != nil {
+			svc.logger.Error("error on data conversion", zap.Any("data", reqEvent.Data), zap.Error(err))
+			return nil
+		}
+		cfg := &trigger.Schedule
+		if cfg.Enabled && filterUtils.IsMine(svc.filter, cfg.Type, cfg.ID, cfg.Labels) {
+			// do not block the service queue, the custom variables script may take time
+			go svc.triggerNow(cfg, &trigger.Request)
+		}
+
 	default:
 		svc.logger.Warn("unsupported command", zap.Any("event", reqEvent))
 	}
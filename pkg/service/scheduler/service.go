@@ -32,6 +32,7 @@ type SchedulerService struct {
 	filter          *sfTY.ServiceFilter
 	eventsQueue     *queueUtils.QueueSpec
 	variablesEngine types.VariablesEngine
+	store           *Store
 }
 
 func New(ctx context.Context, filter *sfTY.ServiceFilter, variablesEngine types.VariablesEngine, sunriseApi types.Sunrise) (serviceTY.Service, error) {
@@ -60,6 +61,7 @@ func New(ctx context.Context, filter *sfTY.ServiceFilter, variablesEngine types.
 		sunriseApi:      sunriseApi,
 		variablesEngine: variablesEngine,
 		filter:          filter,
+		store:           &Store{schedules: make(map[string]*schedulerTY.Config)},
 	}
 
 	svc.eventsQueue = &queueUtils.QueueSpec{
@@ -102,7 +104,9 @@ func (svc *SchedulerService) schedule(cfg *schedulerTY.Config) {
 		cfg.State.LastStatus = false
 		cfg.State.Message = fmt.Sprintf("Error on adding into scheduler: %s", err.Error())
 		busUtils.SetScheduleState(svc.logger, svc.bus, cfg.ID, *cfg.State)
+		return
 	}
+	svc.store.Add(cfg)
 	svc.logger.Debug("added a schedule", zap.String("name", name), zap.String("ID", cfg.ID), zap.Any("cronSpec", cronSpec))
 	cfg.State.Message = fmt.Sprintf("Added into scheduler. cron spec:[%s]", cronSpec)
 	busUtils.SetScheduleState(svc.logger, svc.bus, cfg.ID, *cfg.State)
@@ -111,11 +115,13 @@ func (svc *SchedulerService) schedule(cfg *schedulerTY.Config) {
 func (svc *SchedulerService) unschedule(id string) {
 	name := getScheduleID(id)
 	svc.coreScheduler.RemoveFunc(name)
+	svc.store.Remove(id)
 	svc.logger.Debug("removed a schedule", zap.String("name", name), zap.String("id", id))
 }
 
 func (svc *SchedulerService) unloadAll() {
 	svc.coreScheduler.RemoveWithPrefix(schedulePrefix)
+	svc.store.RemoveAll()
 }
 
 func getScheduleID(id string) string {
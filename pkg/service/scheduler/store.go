@@ -0,0 +1,48 @@
+package scheduler
+
+import (
+	"sync"
+
+	schedulerTY "github.com/mycontroller-org/server/v2/pkg/types/scheduler"
+)
+
+// Store keeps the loaded schedules
+type Store struct {
+	schedules map[string]*schedulerTY.Config
+	mutex     sync.Mutex
+}
+
+// Add a schedule
+func (s *Store) Add(cfg *schedulerTY.Config) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.schedules[cfg.ID] = cfg
+}
+
+// Remove a schedule
+func (s *Store) Remove(id string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.schedules, id)
+}
+
+// Get returns the loaded schedule by id
+func (s *Store) Get(id string) *schedulerTY.Config {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if cfg, found := s.schedules[id]; found {
+		return cfg
+	}
+	return nil
+}
+
+// RemoveAll schedules
+func (s *Store) RemoveAll() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.schedules = make(map[string]*schedulerTY.Config)
+}
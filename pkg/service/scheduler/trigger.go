@@ -8,6 +8,7 @@ import (
 
 	types "github.com/mycontroller-org/server/v2/pkg/types"
 	dateTimeTY "github.com/mycontroller-org/server/v2/pkg/types/cusom_datetime"
+	webhookTY "github.com/mycontroller-org/server/v2/pkg/types/inbound_webhook"
 	schedulerTY "github.com/mycontroller-org/server/v2/pkg/types/scheduler"
 	"github.com/mycontroller-org/server/v2/pkg/utils"
 	busUtils "github.com/mycontroller-org/server/v2/pkg/utils/bus_utils"
@@ -27,7 +28,23 @@ func (svc *SchedulerService) scheduleTriggerFunc(cfg *schedulerTY.Config, spec s
 		svc.logger.Debug("at this time, this is not a valid schedule", zap.String("ScheduleID", cfg.ID), zap.String("spec", spec), zap.Any("validity details", cfg.Validity))
 		return
 	}
+	svc.executeSchedule(cfg, spec, nil)
+}
 
+// triggerNow executes the schedule immediately, called via inbound webhook.
+// validity and repeat count are not verified
+func (svc *SchedulerService) triggerNow(cfg *schedulerTY.Config, request *webhookTY.Request) {
+	// update the state of the loaded schedule, the received config is a copy
+	if loadedCfg := svc.store.Get(cfg.ID); loadedCfg != nil {
+		cfg = loadedCfg
+	}
+	if cfg.State == nil {
+		cfg.State = &schedulerTY.State{}
+	}
+	svc.executeSchedule(cfg, "inbound_webhook", request)
+}
+
+func (svc *SchedulerService) executeSchedule(cfg *schedulerTY.Config, spec string, webhookRequest *webhookTY.Request) {
 	start := time.Now()
 
 	cfg.State.LastRun = time.Now()
@@ -40,7 +57,9 @@ func (svc *SchedulerService) scheduleTriggerFunc(cfg *schedulerTY.Config, spec s
 
 	// disable even there is a error on the schedule
 	// call it inside func to get updated "executionError" value
-	defer func() { svc.verifyAndDisableSchedule(cfg, time.Since(start), executionError) }()
+	if webhookRequest == nil {
+		defer func() { svc.verifyAndDisableSchedule(cfg, time.Since(start), executionError) }()
+	}
 
 	// load variables
 	variables, err := svc.variablesEngine.Load(cfg.Variables)
@@ -55,6 +74,9 @@ func (svc *SchedulerService) scheduleTriggerFunc(cfg *schedulerTY.Config, spec s
 	}
 
 	variables[types.KeySchedule] = cfg // include schedule in to the variables list
+	if webhookRequest != nil {
+		variables[types.KeyWebhook] = webhookRequest
+	}
 
 	switch cfg.CustomVariableType {
 	case schedulerTY.CustomVariableTypeNone, "":
@@ -0,0 +1,66 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+
+	coreScheduler "github.com/mycontroller-org/server/v2/pkg/service/core_scheduler"
+	types "github.com/mycontroller-org/server/v2/pkg/types"
+	"github.com/mycontroller-org/server/v2/pkg/types/cmap"
+	webhookTY "github.com/mycontroller-org/server/v2/pkg/types/inbound_webhook"
+	schedulerTY "github.com/mycontroller-org/server/v2/pkg/types/scheduler"
+	loggerUtils "github.com/mycontroller-org/server/v2/pkg/utils/logger"
+	"github.com/mycontroller-org/server/v2/plugin/bus/embedded"
+	busTY "github.com/mycontroller-org/server/v2/plugin/bus/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type testVariablesEngine struct{}
+
+func (ve *testVariablesEngine) Load(input map[string]interface{}) (map[string]interface{}, error) {
+	return map[string]interface{}{}, nil
+}
+
+func (ve *testVariablesEngine) TemplateEngine() types.TemplateEngine {
+	return nil
+}
+
+func newTestService(t *testing.T) *SchedulerService {
+	ctx := loggerUtils.WithContext(context.Background(), zap.NewNop())
+	ctx = schedulerTY.WithContext(ctx, coreScheduler.New())
+	bus, err := embedded.NewClient(ctx, cmap.CustomMap{})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = bus.Close() })
+	ctx = busTY.WithContext(ctx, bus)
+
+	svc, err := New(ctx, nil, &testVariablesEngine{}, nil)
+	require.NoError(t, err)
+	return svc.(*SchedulerService)
+}
+
+func TestTriggerNowUpdatesLoadedSchedule(t *testing.T) {
+	svc := newTestService(t)
+
+	cfg := &schedulerTY.Config{ID: "s1", Enabled: true, Type: schedulerTY.TypeRepeat, Spec: cmap.CustomMap{"interval": "1h"}}
+	svc.schedule(cfg)
+	t.Cleanup(func() { svc.unschedule(cfg.ID) })
+	require.Equal(t, cfg, svc.store.Get(cfg.ID))
+
+	// the config received via bus is a copy of the loaded schedule
+	received := &schedulerTY.Config{ID: "s1", Enabled: true, Type: schedulerTY.TypeRepeat, Spec: cmap.CustomMap{"interval": "1h"}}
+	svc.triggerNow(received, &webhookTY.Request{})
+	svc.triggerNow(received, &webhookTY.Request{})
+	assert.Equal(t, int64(2), cfg.State.ExecutedCount)
+	assert.Nil(t, received.State)
+
+	// removed from the store
+	svc.unschedule(cfg.ID)
+	assert.Nil(t, svc.store.Get(cfg.ID))
+
+	// not loaded, executed with the received config
+	svc.triggerNow(received, &webhookTY.Request{})
+	require.NotNil(t, received.State)
+	assert.Equal(t, int64(1), received.State.ExecutedCount)
+}
@@ -35,9 +35,14 @@ func (svc *TaskService) executeTask(task *taskTY.Config, evntWrapper *eventWrapp
 		return
 	}
 
-	// user can access event
+	// user can access event and the inbound webhook request
 	if evntWrapper != nil {
-		variables[types.KeyTaskEvent] = evntWrapper.Event
+		if evntWrapper.Event != nil {
+			variables[types.KeyTaskEvent] = evntWrapper.Event
+		}
+		if evntWrapper.Webhook != nil {
+			variables[types.KeyWebhook] = evntWrapper.Webhook
+		}
 	}
 	variables[types.KeyTask] = task // include task in to the variables list
 
@@ -5,6 +5,7 @@ import (
 	dataRepositoryTY "github.com/mycontroller-org/server/v2/pkg/types/data_repository"
 	eventTY "github.com/mycontroller-org/server/v2/pkg/types/event"
 	fieldTY "github.com/mycontroller-org/server/v2/pkg/types/field"
+	webhookTY "github.com/mycontroller-org/server/v2/pkg/types/inbound_webhook"
 	nodeTY "github.com/mycontroller-org/server/v2/pkg/types/node"
 	"github.com/mycontroller-org/server/v2/pkg/types/source"
 	taskTY "github.com/mycontroller-org/server/v2/pkg/types/task"
@@ -14,8 +15,9 @@ import (
 )
 
 type eventWrapper struct {
-	Event *eventTY.Event
-	Tasks []taskTY.Config
+	Event   *eventTY.Event
+	Webhook *webhookTY.Request // available, when triggered via inbound webhook
+	Tasks   []taskTY.Config
 }
 
 // initEventListener events listener
@@ -127,7 +129,9 @@ func (svc *TaskService) resourcePostProcessor(item interface{}) error {
 		return nil
 	}
 
-	svc.logger.Debug("resourceWrapper received", zap.String("entityType", evntWrapper.Event.EntityType))
+	if evntWrapper.Event != nil {
+		svc.logger.Debug("resourceWrapper received", zap.String("entityType", evntWrapper.Event.EntityType))
+	}
 
 	for index := 0; index < len(evntWrapper.Tasks); index++ {
 		task := evntWrapper.Tasks[index]
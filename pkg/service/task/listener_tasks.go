@@ -1,6 +1,7 @@
 package task
 
 import (
+	webhookTY "github.com/mycontroller-org/server/v2/pkg/types/inbound_webhook"
 	rsTY "github.com/mycontroller-org/server/v2/pkg/types/resource_service"
 	taskTY "github.com/mycontroller-org/server/v2/pkg/types/task"
 	"github.com/mycontroller-org/server/v2/pkg/types/topic"
@@ -104,6 +105,27 @@ func (svc *TaskService) processServiceEvent(event interface{}) error {
 	case rsTY.CommandUnloadAll:
 		svc.store.RemoveAll()
 
+	case rsTY.CommandTrigger:
+		trigger := &webhookTY.TaskTrigger{}
+		err := reqEvent.LoadData(trigger)
+		if err != nil {
+			svc.logger.Error("error on data conversion", zap.Any("data", reqEvent.Data), zap.Error(err))
+			return nil
+		}
+		task := trigger.Task
+		if !task.Enabled || !filterUtils.IsMine(svc.filter, task.EvaluationType, task.ID, task.Labels) {
+			return nil
+		}
+		if task.State == nil {
+			task.State = &taskTY.State{}
+		}
+		// executes on the post processor, same as the event triggered tasks
+		evntWrapper := &eventWrapper{Tasks: []taskTY.Config{task}, Webhook: &trigger.Request}
+		status := svc.postEventsQueue.Produce(evntWrapper)
+		if !status {
+			svc.logger.Error("failed to post triggered task on post processor queue", zap.String("id", task.ID))
+		}
+
 	case rsTY.CommandReload:
 		cfg := svc.getConfig(reqEvent)
 		if cfg != nil {
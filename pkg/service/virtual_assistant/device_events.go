@@ -0,0 +1,369 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	types "github.com/mycontroller-org/server/v2/pkg/types"
+	"github.com/mycontroller-org/server/v2/pkg/types/cmap"
+	eventTY "github.com/mycontroller-org/server/v2/pkg/types/event"
+	fieldTY "github.com/mycontroller-org/server/v2/pkg/types/field"
+	"github.com/mycontroller-org/server/v2/pkg/types/topic"
+	vdTY "github.com/mycontroller-org/server/v2/pkg/types/virtual_device"
+	"github.com/mycontroller-org/server/v2/pkg/utils"
+	busUtils "github.com/mycontroller-org/server/v2/pkg/utils/bus_utils"
+	convertorUtil "github.com/mycontroller-org/server/v2/pkg/utils/convertor"
+	quickIdUtils "github.com/mycontroller-org/server/v2/pkg/utils/quick_id"
+	busTY "github.com/mycontroller-org/server/v2/plugin/bus/types"
+	storageTY "github.com/mycontroller-org/server/v2/plugin/database/storage/types"
+	"go.uber.org/zap"
+)
+
+const (
+	deviceIndexPageLimit = int64(100)
+)
+
+// ResourceResolver returns the quickId of a resource, resolves the resources selected by labels
+type ResourceResolver func(vResource *vdTY.Resource) (string, error)
+
+// DeviceIndex keeps the resources to virtual devices mapping
+type DeviceIndex struct {
+	resources map[string][]string            // resource quickId => virtual device ids
+	devices   map[string][]string            // virtual device id => resource quickIds
+	selectors map[string]*vdTY.VirtualDevice // virtual device id => device, if it has resources selected by labels
+	resolver  ResourceResolver
+	mutex     sync.RWMutex
+}
+
+func NewDeviceIndex(resolver ResourceResolver) *DeviceIndex {
+	return &DeviceIndex{
+		resources: make(map[string][]string),
+		devices:   make(map[string][]string),
+		selectors: make(map[string]*vdTY.VirtualDevice),
+		resolver:  resolver,
+	}
+}
+
+// Update the resources of a device.
+// resources selected by labels are resolved, not indexed if no resource matches the labels
+func (di *DeviceIndex) Update(vDevice *vdTY.VirtualDevice) {
+	quickIDs := make([]string, 0)
+	hasSelectors := false
+	for index := range vDevice.Traits {
+		resource := &vDevice.Traits[index]
+		quickID := resource.QuickID
+		if quickID == "" {
+			if len(getSelectorLabels(resource)) == 0 || di.resolver == nil {
+				continue
+			}
+			hasSelectors = true
+			resolvedQuickID, err := di.resolver(resource)
+			if err != nil {
+				continue
+			}
+			quickID = resolvedQuickID
+		}
+		quickIDs = append(quickIDs, fmt.Sprintf("%s:%s", resource.ResourceType, quickID))
+	}
+
+	di.mutex.Lock()
+	defer di.mutex.Unlock()
+
+	di.remove(vDevice.ID)
+	for _, quickID := range quickIDs {
+		di.resources[quickID] = append(di.resources[quickID], vDevice.ID)
+	}
+	di.devices[vDevice.ID] = quickIDs
+	if hasSelectors {
+		di.selectors[vDevice.ID] = vDevice
+	}
+}
+
+// HasSelectors returns true, if any of the devices has resources selected by labels
+func (di *DeviceIndex) HasSelectors() bool {
+	di.mutex.RLock()
+	defer di.mutex.RUnlock()
+	return len(di.selectors) > 0
+}
+
+// RefreshSelectors resolves the label selectors again, on the devices those are
+// using the resource or having a selector matches the resource labels.
+// returns the refreshed device ids
+func (di *DeviceIndex) RefreshSelectors(resourceType, quickID string, labels cmap.CustomStringMap) []string {
+	di.mutex.RLock()
+	vDevices := make([]*vdTY.VirtualDevice, 0)
+	for deviceID, vDevice := range di.selectors {
+		if di.isUsing(deviceID, quickID) || matchesSelector(vDevice, resourceType, labels) {
+			vDevices = append(vDevices, vDevice)
+		}
+	}
+	di.mutex.RUnlock()
+
+	deviceIDs := make([]string, 0, len(vDevices))
+	for _, vDevice := range vDevices {
+		di.Update(vDevice)
+		deviceIDs = append(deviceIDs, vDevice.ID)
+	}
+	sort.Strings(deviceIDs)
+	return deviceIDs
+}
+
+func (di *DeviceIndex) isUsing(deviceID, quickID string) bool {
+	for _, id := range di.devices[deviceID] {
+		if id == quickID {
+			return true
+		}
+	}
+	return false
+}
+
+// returns true, if any of the label selectors of the device matches the labels
+func matchesSelector(vDevice *vdTY.VirtualDevice, resourceType string, labels cmap.CustomStringMap) bool {
+	for index := range vDevice.Traits {
+		resource := &vDevice.Traits[index]
+		if resource.QuickID != "" || resource.ResourceType != resourceType {
+			continue
+		}
+		selectorLabels := getSelectorLabels(resource)
+		if len(selectorLabels) == 0 {
+			continue
+		}
+		matches := true
+		for key, value := range selectorLabels {
+			if labels.Get(key) != value {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
+
+// returns the labels used to select the resource, trait labels are excluded
+func getSelectorLabels(resource *vdTY.Resource) map[string]string {
+	selectorLabels := make(map[string]string)
+	for key, value := range resource.Labels {
+		if !strings.HasPrefix(key, "trait_") {
+			selectorLabels[key] = value
+		}
+	}
+	return selectorLabels
+}
+
+// Remove a device
+func (di *DeviceIndex) Remove(deviceID string) {
+	di.mutex.Lock()
+	defer di.mutex.Unlock()
+
+	di.remove(deviceID)
+}
+
+func (di *DeviceIndex) remove(deviceID string) {
+	for _, quickID := range di.devices[deviceID] {
+		deviceIDs := make([]string, 0)
+		for _, id := range di.resources[quickID] {
+			if id != deviceID {
+				deviceIDs = append(deviceIDs, id)
+			}
+		}
+		if len(deviceIDs) == 0 {
+			delete(di.resources, quickID)
+		} else {
+			di.resources[quickID] = deviceIDs
+		}
+	}
+	delete(di.devices, deviceID)
+	delete(di.selectors, deviceID)
+}
+
+// GetDeviceIDs returns the virtual devices those are using the resource
+func (di *DeviceIndex) GetDeviceIDs(quickID string) []string {
+	di.mutex.RLock()
+	defer di.mutex.RUnlock()
+
+	deviceIDs := make([]string, 0)
+	for _, id := range di.resources[quickID] {
+		// a device can use the same resource on more than one trait
+		found := false
+		for _, addedID := range deviceIDs {
+			if addedID == id {
+				found = true
+				break
+			}
+		}
+		if !found {
+			deviceIDs = append(deviceIDs, id)
+		}
+	}
+	return deviceIDs
+}
+
+// loads all the virtual devices into the device index
+func (svc *VirtualAssistantService) loadDeviceIndex() error {
+	offset := int64(0)
+	for {
+		pagination := &storageTY.Pagination{
+			Offset: offset,
+			Limit:  deviceIndexPageLimit,
+			SortBy: []storageTY.Sort{{Field: types.KeyID, OrderBy: storageTY.SortByASC}},
+		}
+		result, err := svc.api.VirtualDevice().List(nil, pagination)
+		if err != nil {
+			return err
+		}
+		vDevices, ok := result.Data.(*[]vdTY.VirtualDevice)
+		if !ok {
+			return fmt.Errorf("invalid data type received: %T", result.Data)
+		}
+		for index := range *vDevices {
+			svc.deviceIndex.Update(&(*vDevices)[index])
+		}
+		offset += int64(len(*vDevices))
+		if len(*vDevices) == 0 || offset >= result.Count {
+			return nil
+		}
+	}
+}
+
+func (svc *VirtualAssistantService) onFieldEvent(busData *busTY.BusData) {
+	event := &eventTY.Event{}
+	err := busData.LoadData(event)
+	if err != nil {
+		svc.logger.Warn("failed to convert to target type", zap.Any("topic", busData.Topic), zap.Error(err))
+		return
+	}
+
+	if event.EntityType != types.EntityField || event.Entity == nil {
+		return
+	}
+
+	switch event.Type {
+	case eventTY.TypeCreated:
+		// new field, may be added on the generated devices
+
+	case eventTY.TypeUpdated:
+		// report only for the resources used on the virtual devices, labels may match a selector
+		if len(svc.deviceIndex.GetDeviceIDs(event.EntityQuickID)) == 0 && !svc.deviceIndex.HasSelectors() {
+			return
+		}
+
+	default:
+		return
+	}
+
+	status := svc.fieldEventsQueue.Produce(event)
+	if !status {
+		svc.logger.Warn("failed to store the event into queue", zap.Any("event", event))
+	}
+}
+
+func (svc *VirtualAssistantService) onVirtualDeviceEvent(busData *busTY.BusData) {
+	event := &eventTY.Event{}
+	err := busData.LoadData(event)
+	if err != nil {
+		svc.logger.Warn("failed to convert to target type", zap.Any("topic", busData.Topic), zap.Error(err))
+		return
+	}
+
+	if event.EntityType != types.EntityVirtualDevice || event.Entity == nil {
+		return
+	}
+
+	status := svc.deviceEventsQueue.Produce(event)
+	if !status {
+		svc.logger.Warn("failed to store the event into queue", zap.Any("event", event))
+	}
+}
+
+// processFieldEvent reports the state of the virtual devices to the assistants, new fields are added on the generated devices
+func (svc *VirtualAssistantService) processFieldEvent(item interface{}) error {
+	event := item.(*eventTY.Event)
+
+	field := fieldTY.Field{}
+	err := event.LoadEntity(&field)
+	if err != nil {
+		svc.logger.Warn("error on conversion", zap.Any("event", event), zap.Error(err))
+		return nil
+	}
+
+	if event.Type == eventTY.TypeCreated {
+		err = svc.api.VirtualDevice().UpdateGenerated(field.GatewayID, field.NodeID)
+		if err != nil {
+			svc.logger.Error("error on updating generated devices", zap.String("gatewayId", field.GatewayID), zap.String("nodeId", field.NodeID), zap.Error(err))
+		}
+	}
+
+	// label selectors may resolve to this field, created or labels updated
+	deviceIDs := svc.deviceIndex.RefreshSelectors(quickIdUtils.QuickIdField, event.EntityQuickID, field.Labels)
+
+	// report the value change
+	if event.Type == eventTY.TypeUpdated && convertorUtil.ToString(field.Current.Value) != convertorUtil.ToString(field.Previous.Value) {
+		for _, deviceID := range svc.deviceIndex.GetDeviceIDs(event.EntityQuickID) {
+			if !utils.ContainsString(deviceIDs, deviceID) {
+				deviceIDs = append(deviceIDs, deviceID)
+			}
+		}
+	}
+	if len(deviceIDs) == 0 {
+		return nil
+	}
+
+	for _, assistant := range svc.store.List() {
+		err = assistant.ReportState(deviceIDs)
+		if err != nil {
+			svc.logger.Error("error on reporting state", zap.String("assistantId", assistant.Config().ID), zap.Strings("deviceIds", deviceIDs), zap.Error(err))
+		}
+	}
+
+	// post the device states, websocket clients receive the changes
+	svc.postDeviceStates(deviceIDs)
+	return nil
+}
+
+// postDeviceStates posts the current state of the virtual devices as events
+func (svc *VirtualAssistantService) postDeviceStates(deviceIDs []string) {
+	vDevices := make([]vdTY.VirtualDevice, 0, len(deviceIDs))
+	for _, deviceID := range deviceIDs {
+		vDevice, err := svc.api.VirtualDevice().GetByID(deviceID)
+		if err != nil {
+			svc.logger.Error("error on getting a virtual device", zap.String("deviceId", deviceID), zap.Error(err))
+			continue
+		}
+		vDevices = append(vDevices, *vDevice)
+	}
+
+	for _, state := range svc.deviceAPI.GetDeviceStates(vDevices) {
+		busUtils.PostEvent(svc.logger, svc.bus, topic.TopicEventVirtualDeviceState, eventTY.TypeUpdated, vdTY.EntityDeviceState, state)
+	}
+}
+
+// processVirtualDeviceEvent updates the device index and notifies to the assistants
+func (svc *VirtualAssistantService) processVirtualDeviceEvent(item interface{}) error {
+	event := item.(*eventTY.Event)
+
+	vDevice := &vdTY.VirtualDevice{}
+	err := event.LoadEntity(vDevice)
+	if err != nil {
+		svc.logger.Warn("error on conversion", zap.Any("event", event), zap.Error(err))
+		return nil
+	}
+
+	if event.Type == eventTY.TypeDeleted {
+		svc.deviceIndex.Remove(vDevice.ID)
+	} else {
+		svc.deviceIndex.Update(vDevice)
+	}
+
+	for _, assistant := range svc.store.List() {
+		err = assistant.RequestSync(event.Type, vDevice)
+		if err != nil {
+			svc.logger.Error("error on request sync", zap.String("assistantId", assistant.Config().ID), zap.String("deviceId", vDevice.ID), zap.Error(err))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mycontroller-org/server/v2/pkg/types/cmap"
+	vdTY "github.com/mycontroller-org/server/v2/pkg/types/virtual_device"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeviceIndex(t *testing.T) {
+	index := NewDeviceIndex(nil)
+
+	lamp := &vdTY.VirtualDevice{
+		ID: "lamp",
+		Traits: []vdTY.Resource{
+			{TraitType: vdTY.DeviceTraitOnOff, ResourceType: "field", QuickID: "gw.node.source.power"},
+			{TraitType: vdTY.DeviceTraitBrightness, ResourceType: "field", QuickID: "gw.node.source.level"},
+			// same resource on more than one trait
+			{TraitType: vdTY.DeviceTraitOnOff, ResourceType: "field", QuickID: "gw.node.source.level"},
+		},
+	}
+	switchDevice := &vdTY.VirtualDevice{
+		ID:     "switch",
+		Traits: []vdTY.Resource{{TraitType: vdTY.DeviceTraitOnOff, ResourceType: "field", QuickID: "gw.node.source.power"}},
+	}
+	index.Update(lamp)
+	index.Update(switchDevice)
+
+	assert.Equal(t, []string{"lamp", "switch"}, index.GetDeviceIDs("field:gw.node.source.power"))
+	assert.Equal(t, []string{"lamp"}, index.GetDeviceIDs("field:gw.node.source.level"))
+	assert.Empty(t, index.GetDeviceIDs("field:gw.node.source.unknown"))
+
+	// updated device replaces the old resources
+	lamp.Traits = []vdTY.Resource{{TraitType: vdTY.DeviceTraitOnOff, ResourceType: "field", QuickID: "gw.node.source.relay"}}
+	index.Update(lamp)
+	assert.Equal(t, []string{"switch"}, index.GetDeviceIDs("field:gw.node.source.power"))
+	assert.Empty(t, index.GetDeviceIDs("field:gw.node.source.level"))
+	assert.Equal(t, []string{"lamp"}, index.GetDeviceIDs("field:gw.node.source.relay"))
+
+	index.Remove("switch")
+	assert.Empty(t, index.GetDeviceIDs("field:gw.node.source.power"))
+	assert.NotContains(t, index.resources, "field:gw.node.source.power")
+	assert.NotContains(t, index.devices, "switch")
+}
+
+func TestDeviceIndexSelectors(t *testing.T) {
+	// label value => resource quickId
+	available := map[string]string{}
+	index := NewDeviceIndex(func(vResource *vdTY.Resource) (string, error) {
+		if quickID, found := available[vResource.Labels.Get("location")]; found {
+			return quickID, nil
+		}
+		return "", errors.New("no resource matches the labels")
+	})
+
+	lamp := &vdTY.VirtualDevice{
+		ID: "lamp",
+		Traits: []vdTY.Resource{
+			{TraitType: vdTY.DeviceTraitOnOff, ResourceType: "field", QuickID: "gw.node.source.power"},
+			{TraitType: vdTY.DeviceTraitBrightness, ResourceType: "field", Labels: cmap.CustomStringMap{"location": "kitchen", vdTY.LabelTraitMax: "254"}},
+		},
+	}
+	// trait labels alone are not a selector
+	switchDevice := &vdTY.VirtualDevice{
+		ID:     "switch",
+		Traits: []vdTY.Resource{{TraitType: vdTY.DeviceTraitOnOff, ResourceType: "field", Labels: cmap.CustomStringMap{vdTY.LabelTraitMax: "1"}}},
+	}
+	index.Update(switchDevice)
+	assert.False(t, index.HasSelectors())
+
+	// selector not resolved, device kept for the refresh
+	index.Update(lamp)
+	assert.True(t, index.HasSelectors())
+	assert.Equal(t, []string{"lamp"}, index.GetDeviceIDs("field:gw.node.source.power"))
+	assert.Empty(t, index.GetDeviceIDs("field:gw.node.source.level"))
+
+	// labels not matching the selector
+	available["kitchen"] = "gw.node.source.level"
+	assert.Empty(t, index.RefreshSelectors("field", "field:gw.node.source.level", cmap.CustomStringMap{"location": "garage"}))
+	assert.Empty(t, index.RefreshSelectors("source", "source:gw.node.source", cmap.CustomStringMap{"location": "kitchen"}))
+	assert.Empty(t, index.GetDeviceIDs("field:gw.node.source.level"))
+
+	// new resource with matching labels
+	assert.Equal(t, []string{"lamp"}, index.RefreshSelectors("field", "field:gw.node.source.level", cmap.CustomStringMap{"location": "kitchen", "type": "dimmer"}))
+	assert.Equal(t, []string{"lamp"}, index.GetDeviceIDs("field:gw.node.source.level"))
+
+	// labels removed from the resource in use
+	delete(available, "kitchen")
+	assert.Equal(t, []string{"lamp"}, index.RefreshSelectors("field", "field:gw.node.source.level", nil))
+	assert.Empty(t, index.GetDeviceIDs("field:gw.node.source.level"))
+	assert.Equal(t, []string{"lamp"}, index.GetDeviceIDs("field:gw.node.source.power"))
+
+	index.Remove("lamp")
+	assert.False(t, index.HasSelectors())
+	assert.Empty(t, index.GetDeviceIDs("field:gw.node.source.power"))
+}
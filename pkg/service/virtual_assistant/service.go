@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/gorilla/mux"
+	entityAPI "github.com/mycontroller-org/server/v2/pkg/api/entities"
 	encryptionAPI "github.com/mycontroller-org/server/v2/pkg/encryption"
 	rsTY "github.com/mycontroller-org/server/v2/pkg/types/resource_service"
 	serviceTY "github.com/mycontroller-org/server/v2/pkg/types/service"
@@ -12,6 +13,7 @@ import (
 	loggerUtils "github.com/mycontroller-org/server/v2/pkg/utils/logger"
 	queueUtils "github.com/mycontroller-org/server/v2/pkg/utils/queue"
 	busTY "github.com/mycontroller-org/server/v2/plugin/bus/types"
+	deviceAPI "github.com/mycontroller-org/server/v2/plugin/virtual_assistant/device_api"
 	vaTY "github.com/mycontroller-org/server/v2/plugin/virtual_assistant/types"
 	"go.uber.org/zap"
 )
@@ -19,17 +21,24 @@ import (
 const (
 	defaultQueueSize = int(50)
 	defaultWorkers   = int(1)
+
+	defaultFieldEventsQueueSize = int(1000)
 )
 
 type VirtualAssistantService struct {
-	ctx         context.Context
-	logger      *zap.Logger
-	filter      *sfTY.ServiceFilter
-	store       *Store
-	bus         busTY.Plugin
-	enc         *encryptionAPI.Encryption
-	eventsQueue *queueUtils.QueueSpec
-	router      *mux.Router
+	ctx               context.Context
+	logger            *zap.Logger
+	filter            *sfTY.ServiceFilter
+	store             *Store
+	api               *entityAPI.API
+	bus               busTY.Plugin
+	enc               *encryptionAPI.Encryption
+	eventsQueue       *queueUtils.QueueSpec
+	fieldEventsQueue  *queueUtils.QueueSpec
+	deviceEventsQueue *queueUtils.QueueSpec
+	deviceIndex       *DeviceIndex
+	deviceAPI         *deviceAPI.DeviceAPI
+	router            *mux.Router
 }
 
 func New(ctx context.Context, filter *sfTY.ServiceFilter, router *mux.Router) (serviceTY.Service, error) {
@@ -45,17 +54,28 @@ func New(ctx context.Context, filter *sfTY.ServiceFilter, router *mux.Router) (s
 	if err != nil {
 		return nil, err
 	}
+	api, err := entityAPI.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	_deviceAPI, err := deviceAPI.New(ctx)
+	if err != nil {
+		return nil, err
+	}
 	if filter == nil {
 		filter = &sfTY.ServiceFilter{}
 	}
 
 	svc := &VirtualAssistantService{
-		ctx:    ctx,
-		logger: logger.Named("virtual_assistant_service"),
-		filter: filter,
-		bus:    bus,
-		enc:    enc,
-		router: router,
+		ctx:         ctx,
+		logger:      logger.Named("virtual_assistant_service"),
+		filter:      filter,
+		api:         api,
+		bus:         bus,
+		enc:         enc,
+		router:      router,
+		deviceIndex: NewDeviceIndex(_deviceAPI.GetResourceQuickID),
+		deviceAPI:   _deviceAPI,
 	}
 
 	svc.store = &Store{services: make(map[string]vaTY.Plugin)}
@@ -66,6 +86,18 @@ func New(ctx context.Context, filter *sfTY.ServiceFilter, router *mux.Router) (s
 		SubscriptionId: -1,
 	}
 
+	// field and virtual device events, used to report state and request sync
+	svc.fieldEventsQueue = &queueUtils.QueueSpec{
+		Queue:          queueUtils.New(svc.logger, "virtual_assistant_service_field_events", defaultFieldEventsQueueSize, svc.processFieldEvent, defaultWorkers),
+		Topic:          topic.TopicEventField,
+		SubscriptionId: -1,
+	}
+	svc.deviceEventsQueue = &queueUtils.QueueSpec{
+		Queue:          queueUtils.New(svc.logger, "virtual_assistant_service_device_events", defaultQueueSize, svc.processVirtualDeviceEvent, defaultWorkers),
+		Topic:          topic.TopicEventVirtualDevice,
+		SubscriptionId: -1,
+	}
+
 	// register handler path
 	// needs to be registered before passing into http_router
 	svc.registerServiceRoute()
@@ -98,6 +130,28 @@ func (svc *VirtualAssistantService) Start() error {
 	}
 	svc.eventsQueue.SubscriptionId = sId
 
+	// load virtual devices resources
+	err = svc.loadDeviceIndex()
+	if err != nil {
+		svc.logger.Error("error on loading virtual devices", zap.Error(err))
+		return err
+	}
+
+	// listen field and virtual device events
+	sId, err = svc.bus.Subscribe(svc.fieldEventsQueue.Topic, svc.onFieldEvent)
+	if err != nil {
+		svc.logger.Error("error on subscription", zap.String("topic", svc.fieldEventsQueue.Topic), zap.Error(err))
+		return err
+	}
+	svc.fieldEventsQueue.SubscriptionId = sId
+
+	sId, err = svc.bus.Subscribe(svc.deviceEventsQueue.Topic, svc.onVirtualDeviceEvent)
+	if err != nil {
+		svc.logger.Error("error on subscription", zap.String("topic", svc.deviceEventsQueue.Topic), zap.Error(err))
+		return err
+	}
+	svc.deviceEventsQueue.SubscriptionId = sId
+
 	// load virtual assistants
 	reqEvent := rsTY.ServiceEvent{
 		Type:    rsTY.TypeVirtualAssistant,
@@ -116,6 +170,13 @@ func (svc *VirtualAssistantService) Close() error {
 	if svc.filter.Disabled {
 		return nil
 	}
+	for _, queue := range []*queueUtils.QueueSpec{svc.fieldEventsQueue, svc.deviceEventsQueue} {
+		err := svc.bus.Unsubscribe(queue.Topic, queue.SubscriptionId)
+		if err != nil {
+			svc.logger.Error("error on unsubscription", zap.String("topic", queue.Topic), zap.Int64("subscriptionId", queue.SubscriptionId), zap.Error(err))
+		}
+		queue.Close()
+	}
 	svc.unloadAll()
 	svc.eventsQueue.Close()
 	return nil
@@ -48,3 +48,15 @@ func (s *Store) ListIDs() []string {
 	}
 	return ids
 }
+
+// List returns all the services
+func (s *Store) List() []vaTY.Plugin {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	services := make([]vaTY.Plugin, 0)
+	for _, service := range s.services {
+		services = append(services, service)
+	}
+	return services
+}
@@ -9,6 +9,7 @@ const (
 	ActionReload  = "reload"
 	ActionDelete  = "delete"
 	ActionToggle  = "toggle"
+	ActionTrigger = "trigger" // runs a task or a schedule now
 )
 
 // GetAction parse and rename if required
@@ -0,0 +1,96 @@
+package client
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	eventTY "github.com/mycontroller-org/server/v2/pkg/types/event"
+)
+
+// EventFilter used to filter the events received from the server
+// empty filter matches all the events
+type EventFilter struct {
+	EventTypes  []string          // created, updated, deleted
+	EntityTypes []string          // gateway, node, field, etc.,
+	QuickIDs    []string          // supports wildcard pattern. ex: "field:mysensors.1.*"
+	Labels      map[string]string // all the labels should match
+}
+
+// NewEventFilter returns a filter, labels in key=value format
+func NewEventFilter(eventTypes, entityTypes, quickIDs, labels []string) (*EventFilter, error) {
+	filter := &EventFilter{
+		EventTypes:  toLower(eventTypes),
+		EntityTypes: toLower(entityTypes),
+		QuickIDs:    quickIDs,
+		Labels:      map[string]string{},
+	}
+	for _, quickID := range quickIDs {
+		if _, err := path.Match(quickID, ""); err != nil {
+			return nil, fmt.Errorf("invalid quick id pattern '%s'. error:%w", quickID, err)
+		}
+	}
+	for _, label := range labels {
+		keyValue := strings.SplitN(label, "=", 2)
+		if len(keyValue) != 2 {
+			return nil, fmt.Errorf("invalid label '%s', should be in key=value format", label)
+		}
+		filter.Labels[strings.TrimSpace(keyValue[0])] = strings.TrimSpace(keyValue[1])
+	}
+	return filter, nil
+}
+
+// IsMatching returns true, if the event matches with the filter
+func (f *EventFilter) IsMatching(event *eventTY.Event) bool {
+	if len(f.EventTypes) > 0 && !contains(f.EventTypes, strings.ToLower(event.Type)) {
+		return false
+	}
+	if len(f.EntityTypes) > 0 && !contains(f.EntityTypes, strings.ToLower(event.EntityType)) {
+		return false
+	}
+	if len(f.QuickIDs) > 0 {
+		matched := false
+		for _, pattern := range f.QuickIDs {
+			if ok, _ := path.Match(pattern, event.EntityQuickID); ok {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(f.Labels) > 0 {
+		entity, ok := event.Entity.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		labels, ok := entity["labels"].(map[string]interface{})
+		if !ok {
+			return false
+		}
+		for key, value := range f.Labels {
+			if labelValue, found := labels[key]; !found || fmt.Sprintf("%v", labelValue) != value {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func contains(items []string, value string) bool {
+	for _, item := range items {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func toLower(items []string) []string {
+	lowered := make([]string, 0, len(items))
+	for _, item := range items {
+		lowered = append(lowered, strings.ToLower(strings.TrimSpace(item)))
+	}
+	return lowered
+}
@@ -7,7 +7,8 @@ type SystemStartupJobs struct {
 
 // StartupRestore loads data on startup
 type StartupRestore struct {
-	Enabled            bool   `json:"enabled" yaml:"enabled"`
-	ExtractedDirectory string `json:"extracted_directory" yaml:"extracted_directory"`
-	ClearDatabase      bool   `json:"clean_database" yaml:"clean_database"`
+	Enabled                bool     `json:"enabled" yaml:"enabled"`
+	ExtractedDirectory     string   `json:"extracted_directory" yaml:"extracted_directory"`
+	IncrementalDirectories []string `json:"incremental_directories" yaml:"incremental_directories"` // applied in order, after the full backup
+	ClearDatabase          bool     `json:"clean_database" yaml:"clean_database"`
 }
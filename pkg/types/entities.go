@@ -18,6 +18,7 @@ const (
 	EntityVirtualDevice    = "virtual_device"    // holds virtual devices
 	EntityVirtualAssistant = "virtual_assistant" // holds virtual assistants
 	EntityServiceToken     = "service_token"     // holds service token
+	EntityInboundWebhook   = "inbound_webhook"   // holds inbound webhook endpoints
 )
 
 // Entity field keys
@@ -56,6 +57,7 @@ const (
 	KeySchedule    = "schedule"
 	KeyEventType   = "eventType"
 	KeyTaskEvent   = "taskEvent"
+	KeyWebhook     = "webhook"
 	KeyPayload     = "payload"
 	KeyValue       = "value"
 	KeyMetricTypes = "metricTypes"
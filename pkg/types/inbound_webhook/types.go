@@ -0,0 +1,59 @@
+package inboundwebhook
+
+import (
+	"time"
+
+	"github.com/mycontroller-org/server/v2/pkg/types/cmap"
+	schedulerTY "github.com/mycontroller-org/server/v2/pkg/types/scheduler"
+	taskTY "github.com/mycontroller-org/server/v2/pkg/types/task"
+)
+
+// action types
+const (
+	ActionTask     = "task"     // executes the task evaluation, the request exposed as "webhook" variable
+	ActionSchedule = "schedule" // fires the schedule handlers immediately
+	ActionField    = "field"    // sets the request body into the field or data repository quick id
+)
+
+// token can be supplied on any one of these
+const (
+	HeaderToken     = "X-Webhook-Token"
+	QueryParamToken = "token"
+)
+
+// Config of an inbound webhook endpoint
+type Config struct {
+	ID          string               `json:"id" yaml:"id"`
+	Description string               `json:"description" yaml:"description"`
+	Enabled     bool                 `json:"enabled" yaml:"enabled"`
+	Labels      cmap.CustomStringMap `json:"labels" yaml:"labels"`
+	Token       string               `json:"token" yaml:"token"`
+	Action      string               `json:"action" yaml:"action"`
+	TargetID    string               `json:"targetId" yaml:"targetId"`       // task id, schedule id or the quick id
+	PayloadPath string               `json:"payloadPath" yaml:"payloadPath"` // field action, takes the value from the json body
+	ModifiedOn  time.Time            `json:"modifiedOn" yaml:"modifiedOn"`
+}
+
+// Request received on the inbound webhook
+type Request struct {
+	WebhookID     string                 `json:"webhookId" yaml:"webhookId"`
+	Method        string                 `json:"method" yaml:"method"`
+	Headers       map[string]string      `json:"headers" yaml:"headers"`
+	Query         map[string]interface{} `json:"query" yaml:"query"`
+	Body          string                 `json:"body" yaml:"body"`
+	Data          interface{}            `json:"data" yaml:"data"` // json body, if the body is a valid json
+	RemoteAddress string                 `json:"remoteAddress" yaml:"remoteAddress"`
+	Timestamp     time.Time              `json:"timestamp" yaml:"timestamp"`
+}
+
+// TaskTrigger posted to the task service
+type TaskTrigger struct {
+	Task    taskTY.Config `json:"task" yaml:"task"`
+	Request Request       `json:"request" yaml:"request"`
+}
+
+// ScheduleTrigger posted to the scheduler service
+type ScheduleTrigger struct {
+	Schedule schedulerTY.Config `json:"schedule" yaml:"schedule"`
+	Request  Request            `json:"request" yaml:"request"`
+}
@@ -0,0 +1,96 @@
+package manifest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// supported manifest file extensions
+var fileExtensions = []string{".yaml", ".yml", ".json"}
+
+// LoadPath loads manifests from a file or from all the files in a directory, recursively
+// a file can hold more than one manifest, separated by "---"
+func LoadPath(path string) ([]Manifest, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]string, 0)
+	if info.IsDir() {
+		err = filepath.WalkDir(path, func(filePath string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !entry.IsDir() && isManifestFile(filePath) {
+				files = append(files, filePath)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(files)
+	} else {
+		files = append(files, path)
+	}
+
+	manifests := make([]Manifest, 0)
+	for _, filename := range files {
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		fileManifests, err := Load(data)
+		if err != nil {
+			return nil, fmt.Errorf("error on loading file:%s, error:%w", filename, err)
+		}
+		manifests = append(manifests, fileManifests...)
+	}
+	return manifests, nil
+}
+
+// Load parses the manifests from yaml or json data
+func Load(data []byte) ([]Manifest, error) {
+	manifests := make([]Manifest, 0)
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		manifest := Manifest{}
+		err := decoder.Decode(&manifest)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+		// empty documents
+		if manifest.Kind == "" && len(manifest.Spec) == 0 {
+			continue
+		}
+		if manifest.Kind == "" {
+			return nil, fmt.Errorf("'kind' can not be empty, id:%s", manifest.GetID())
+		}
+		manifest.Kind = NormalizeKind(manifest.Kind)
+		manifests = append(manifests, manifest)
+	}
+	return manifests, nil
+}
+
+func isManifestFile(filename string) bool {
+	ext := strings.ToLower(filepath.Ext(filename))
+	for _, supported := range fileExtensions {
+		if ext == supported {
+			return true
+		}
+	}
+	return false
+}
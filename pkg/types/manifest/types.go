@@ -0,0 +1,209 @@
+package manifest
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	types "github.com/mycontroller-org/server/v2/pkg/types"
+	"github.com/mycontroller-org/server/v2/pkg/types/cmap"
+	"gopkg.in/yaml.v3"
+)
+
+// plan actions
+const (
+	ActionCreate    = "create"
+	ActionUpdate    = "update"
+	ActionUnchanged = "unchanged"
+	ActionDelete    = "delete"
+)
+
+// label added on the applied entities, prune considers only the entities with this label
+const (
+	LabelManagedBy = "managed_by"
+	ManagedByApply = "mc_apply"
+)
+
+// SecretEnvPrefix used to resolve the secret references from environment
+// example: "${secret:smtp_password}" resolves from "MYC_SECRET_SMTP_PASSWORD"
+const SecretEnvPrefix = "MYC_SECRET_"
+
+// secret reference format: ${secret:name}
+var secretReferenceRgx = regexp.MustCompile(`\$\{secret:([A-Za-z0-9_.-]+)\}`)
+
+// SupportedKinds can be managed via manifests
+var SupportedKinds = []string{
+	types.EntityGateway,
+	types.EntityHandler,
+	types.EntityTask,
+	types.EntitySchedule,
+	types.EntityForwardPayload,
+	types.EntityVirtualDevice,
+	types.EntityVirtualAssistant,
+	types.EntityDashboard,
+	types.EntityDataRepository,
+	types.EntityInboundWebhook,
+}
+
+// Manifest of an entity
+type Manifest struct {
+	Kind string         `json:"kind" yaml:"kind"`
+	Spec cmap.CustomMap `json:"spec" yaml:"spec"`
+}
+
+// GetID returns id of the entity
+func (m *Manifest) GetID() string {
+	if m.Spec == nil {
+		return ""
+	}
+	if id, ok := m.Spec["id"].(string); ok {
+		return id
+	}
+	return ""
+}
+
+// Key returns unique key of the manifest
+func (m *Manifest) Key() string {
+	return fmt.Sprintf("%s/%s", m.Kind, m.GetID())
+}
+
+// ApplyRequest of the manifests
+type ApplyRequest struct {
+	Manifests []Manifest `json:"manifests" yaml:"manifests"`
+	DryRun    bool       `json:"dryRun" yaml:"dryRun"`
+	Prune     bool       `json:"prune" yaml:"prune"`
+}
+
+// PlanItem describes an action on an entity
+type PlanItem struct {
+	Kind    string   `json:"kind" yaml:"kind"`
+	ID      string   `json:"id" yaml:"id"`
+	Action  string   `json:"action" yaml:"action"`
+	Changes []string `json:"changes" yaml:"changes"` // changed key paths
+	Error   string   `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// Plan of an apply request
+type Plan struct {
+	Items   []PlanItem `json:"items" yaml:"items"`
+	DryRun  bool       `json:"dryRun" yaml:"dryRun"`
+	Applied bool       `json:"applied" yaml:"applied"`
+}
+
+// HasChanges returns true, if the plan has any create, update or delete action
+func (p *Plan) HasChanges() bool {
+	for _, item := range p.Items {
+		if item.Action != ActionUnchanged {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeKind returns entity name of the kind
+// accepts "VirtualDevice", "virtual-device" and "virtual_device"
+func NormalizeKind(kind string) string {
+	kind = strings.TrimSpace(kind)
+	normalized := strings.ToLower(strings.NewReplacer("-", "_", " ", "_").Replace(kind))
+	for _, supported := range SupportedKinds {
+		if normalized == supported || normalized == strings.ReplaceAll(supported, "_", "") {
+			return supported
+		}
+	}
+	return normalized
+}
+
+// IsSupportedKind returns true, if the kind can be managed via manifest
+func IsSupportedKind(kind string) bool {
+	normalized := NormalizeKind(kind)
+	for _, supported := range SupportedKinds {
+		if normalized == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// SecretLookupFunc returns value of a secret
+type SecretLookupFunc func(name string) (string, bool)
+
+// EnvSecretLookup resolves the secrets from environment variables
+func EnvSecretLookup(name string) (string, bool) {
+	envName := SecretEnvPrefix + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(name))
+	return os.LookupEnv(envName)
+}
+
+// FileSecretLookup resolves the secrets from a yaml file, holds name and value pairs
+func FileSecretLookup(filename string) (SecretLookupFunc, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	secrets := map[string]string{}
+	err = yaml.Unmarshal(data, &secrets)
+	if err != nil {
+		return nil, fmt.Errorf("error on loading secrets file:%s, error:%w", filename, err)
+	}
+	return func(name string) (string, bool) {
+		value, found := secrets[name]
+		return value, found
+	}, nil
+}
+
+// ResolveSecrets replaces the secret references with the actual value
+// lookup functions are executed in the given order, first match wins
+func (m *Manifest) ResolveSecrets(lookupFuncs ...SecretLookupFunc) error {
+	resolved, err := resolveSecrets(map[string]interface{}(m.Spec), lookupFuncs)
+	if err != nil {
+		return fmt.Errorf("%s: %w", m.Key(), err)
+	}
+	if resolvedMap, ok := resolved.(map[string]interface{}); ok {
+		m.Spec = resolvedMap
+	}
+	return nil
+}
+
+func resolveSecrets(value interface{}, lookupFuncs []SecretLookupFunc) (interface{}, error) {
+	switch data := value.(type) {
+	case string:
+		var resolveErr error
+		resolved := secretReferenceRgx.ReplaceAllStringFunc(data, func(reference string) string {
+			name := secretReferenceRgx.FindStringSubmatch(reference)[1]
+			for _, lookupFn := range lookupFuncs {
+				if secret, found := lookupFn(name); found {
+					return secret
+				}
+			}
+			resolveErr = fmt.Errorf("secret '%s' not found", name)
+			return reference
+		})
+		return resolved, resolveErr
+
+	case map[string]interface{}:
+		for key, item := range data {
+			resolved, err := resolveSecrets(item, lookupFuncs)
+			if err != nil {
+				return nil, err
+			}
+			data[key] = resolved
+		}
+		return data, nil
+
+	case cmap.CustomMap:
+		return resolveSecrets(map[string]interface{}(data), lookupFuncs)
+
+	case []interface{}:
+		for index, item := range data {
+			resolved, err := resolveSecrets(item, lookupFuncs)
+			if err != nil {
+				return nil, err
+			}
+			data[index] = resolved
+		}
+		return data, nil
+
+	default:
+		return value, nil
+	}
+}
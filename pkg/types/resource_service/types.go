@@ -16,6 +16,8 @@ const (
 	TypeResourceAction   = "resource_action"
 	TypeSystemJobs       = "system_jobs"
 	TypeVirtualAssistant = "virtual_assistant"
+	TypeQuickID          = "quick_id"
+	TypeHandlerDelivery  = "handler_delivery"
 )
 
 // Command details
@@ -40,6 +42,9 @@ const (
 	CommandSetLabel           = "setLabel"
 	CommandGetSleepingQueue   = "getSleepingQueue"
 	CommandClearSleepingQueue = "clearSleepingQueue"
+	CommandRetry              = "retry"
+	CommandStats              = "stats"
+	CommandTrigger            = "trigger"
 )
 
 // sub commands, will be used in the data field
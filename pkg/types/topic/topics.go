@@ -26,6 +26,8 @@ const (
 	TopicEventDataRepository           = "event.data_repository"               // data repository events
 	TopicEventForwardPayload           = "event.forward_payload"               // forward payload events
 	TopicEventVirtualDevice            = "event.virtual_device"                // virtual device events
+	TopicEventVirtualDeviceState       = "event.virtual_device_state"          // virtual device state change events
 	TopicEventVirtualAssistant         = "event.virtual_assistant"             // virtual assistant events
+	TopicEventInboundWebhook           = "event.inbound_webhook"               // inbound webhook events
 	TopicFirmwareBlocks                = "firmware.blocks"                     // request to shutdown the server
 )
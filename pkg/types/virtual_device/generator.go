@@ -0,0 +1,29 @@
+package virtual_device
+
+// labels on the generated virtual devices
+const (
+	LabelGenerated    = "generated"     // device created by the generator, new fields are added automatically. set "false" to stop the updates
+	LabelGeneratorKey = "generator_key" // fields group used to generate the device, example: "gateway.node.source" or "gateway.node.source.field"
+)
+
+// proposal status
+const (
+	ProposalStatusNew       = "new"       // device not available, will be created
+	ProposalStatusUpdate    = "update"    // device available, new traits will be added
+	ProposalStatusUnchanged = "unchanged" // device available with all the traits
+)
+
+// GeneratorRequest limits the generator to the given gateway and nodes, all the nodes are included if empty
+type GeneratorRequest struct {
+	GatewayID        string   `json:"gatewayId" yaml:"gatewayId"`
+	NodeIDs          []string `json:"nodeIds" yaml:"nodeIds"`
+	IncludeUnchanged bool     `json:"includeUnchanged" yaml:"includeUnchanged"`
+}
+
+// Proposal is a virtual device suggested by the generator, saved only when accepted
+type Proposal struct {
+	Key       string        `json:"key" yaml:"key"`
+	Status    string        `json:"status" yaml:"status"`
+	NewTraits []string      `json:"newTraits" yaml:"newTraits"` // name of the trait resources added on this proposal
+	Device    VirtualDevice `json:"device" yaml:"device"`
+}
@@ -0,0 +1,45 @@
+package virtual_device
+
+import (
+	"time"
+
+	"github.com/mycontroller-org/server/v2/pkg/types/cmap"
+)
+
+// EntityDeviceState used as entity type on the device state events
+const EntityDeviceState = "virtual_device_state"
+
+// DeviceState is a virtual device with the current values of the traits
+type DeviceState struct {
+	ID          string               `json:"id" yaml:"id"`
+	Name        string               `json:"name" yaml:"name"`
+	Description string               `json:"description" yaml:"description"`
+	DeviceType  string               `json:"deviceType" yaml:"deviceType"`
+	Location    string               `json:"location" yaml:"location"`
+	Labels      cmap.CustomStringMap `json:"labels" yaml:"labels"`
+	Online      bool                 `json:"online" yaml:"online"`     // all the nodes of the resources seen within the inactive duration
+	LastSeen    time.Time            `json:"lastSeen" yaml:"lastSeen"` // oldest last seen of the nodes
+	Traits      []TraitState         `json:"traits" yaml:"traits"`
+}
+
+// TraitState is the current value of a trait resource
+type TraitState struct {
+	Name         string      `json:"name" yaml:"name"`
+	TraitType    string      `json:"traitType" yaml:"traitType"`
+	Parameter    string      `json:"parameter" yaml:"parameter"`
+	ResourceType string      `json:"resourceType" yaml:"resourceType"`
+	QuickID      string      `json:"quickId" yaml:"quickId"` // resolved quick id, for the resources selected by labels
+	Value        interface{} `json:"value" yaml:"value"`
+	Timestamp    time.Time   `json:"timestamp" yaml:"timestamp"`
+	Error        string      `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// DeviceCommand is a trait level command, example: {"trait":"brightness","value":40}
+// value is in the trait unit, percentage on brightness, open_close and fan_speed, celsius on temperature_setting,
+// rgb integer or hex on color_setting. the value is converted to the resource range and unit
+type DeviceCommand struct {
+	Trait     string      `json:"trait" yaml:"trait"`
+	Parameter string      `json:"parameter" yaml:"parameter"` // optional, default parameter of the trait is used
+	Name      string      `json:"name" yaml:"name"`           // optional, selects the resource by name, used on modes and sensor_state
+	Value     interface{} `json:"value" yaml:"value"`
+}
@@ -11,6 +11,36 @@ const (
 	ResourceByLabels  = "resource_by_labels"
 )
 
+// labels on a trait resource, describes how the resource value is mapped to the trait
+const (
+	LabelTraitParameter = "trait_parameter" // part of the trait handled by this resource, example: "setpoint" on temperature_setting
+	LabelTraitName      = "trait_name"      // name of the mode or sensor, used on modes and sensor_state traits
+	LabelTraitUnit      = "trait_unit"      // unit of the resource value, example: "celsius", "fahrenheit", "hex"
+	LabelTraitMin       = "trait_min"       // minimum value of the resource, percentages are scaled to min and max
+	LabelTraitMax       = "trait_max"       // maximum value of the resource
+	LabelTraitValues    = "trait_values"    // comma separated supported values, example: "off,heat,cool"
+)
+
+// trait parameters
+const (
+	TraitParameterMode             = "mode"
+	TraitParameterSetpoint         = "setpoint"
+	TraitParameterAmbient          = "ambient"
+	TraitParameterRGB              = "rgb"
+	TraitParameterColorTemperature = "temperature"
+	TraitParameterArm              = "arm"
+	TraitParameterArmLevel         = "level"
+	TraitParameterStart            = "start"
+	TraitParameterPause            = "pause"
+)
+
+// trait units
+const (
+	TraitUnitCelsius    = "celsius"
+	TraitUnitFahrenheit = "fahrenheit"
+	TraitUnitHex        = "hex"
+)
+
 // virtual device work is in progress
 // This actually created to address Google Assistant home graph map
 // Needs to be addressed lot of things
@@ -1,90 +1,54 @@
 package query
 
 import (
-	"context"
-	"fmt"
+	"errors"
 	"time"
 
 	rsTY "github.com/mycontroller-org/server/v2/pkg/types/resource_service"
 	"github.com/mycontroller-org/server/v2/pkg/types/topic"
-	"github.com/mycontroller-org/server/v2/pkg/utils"
-	busUtils "github.com/mycontroller-org/server/v2/pkg/utils/bus_utils"
-	"github.com/mycontroller-org/server/v2/pkg/utils/concurrency"
 	busTY "github.com/mycontroller-org/server/v2/plugin/bus/types"
 	"go.uber.org/zap"
 )
 
-// QueryResource posts as request on response calls the callback
-func QueryResource(logger *zap.Logger, bus busTY.Plugin, resourceID, resourceType, command string, data interface{}, callBack func(item interface{}) bool, out interface{}, timeout time.Duration) error {
-	return QueryService(logger, bus, topic.TopicServiceResourceServer, resourceID, resourceType, command, data, callBack, out, timeout)
+// QueryResource sends a request to the resource service and loads the response data into out
+func QueryResource(logger *zap.Logger, bus busTY.Plugin, resourceID, resourceType, command string, data interface{}, out interface{}, timeout time.Duration) error {
+	return QueryService(logger, bus, topic.TopicServiceResourceServer, resourceID, resourceType, command, data, out, timeout)
 }
 
-// QueryService posts as request to a service, on response calls the callback
-func QueryService(logger *zap.Logger, bus busTY.Plugin, serviceTopic, resourceID, resourceType, command string, data interface{}, callBack func(item interface{}) bool, out interface{}, timeout time.Duration) error {
-	// NOTE: when we receive response from multiple gateways, deadlock happens when the capacity is '0'
-	// for now changed the capacity to '20', it means supports up to 20 listening gateways
-	// TODO: This is not a right fix. introduce a permanent fix
-	closeChan := concurrency.NewChannel(1)
-	defer closeChan.SafeClose()
+// QueryService sends a request to a service and loads the response data into out.
+// returns an error, if the response not received within the timeout or the service responds with an error
+func QueryService(logger *zap.Logger, bus busTY.Plugin, serviceTopic, resourceID, resourceType, command string, data interface{}, out interface{}, timeout time.Duration) error {
+	request := &rsTY.ServiceEvent{
+		Type:    resourceType,
+		Command: command,
+		ID:      resourceID,
+	}
+	request.SetData(data)
 
-	replyTopic := fmt.Sprintf("internal_query_response_%s", utils.RandIDWithLength(5))
-	sID, err := bus.Subscribe(replyTopic, responseFunc(logger, closeChan, callBack, out))
+	reply, err := bus.Request(serviceTopic, request, timeout)
 	if err != nil {
+		logger.Debug("error on request", zap.String("topic", serviceTopic), zap.Any("request", request), zap.Error(err))
 		return err
 	}
 
-	defer func() {
-		err := bus.Unsubscribe(replyTopic, sID)
-		if err != nil {
-			logger.Error("error on unsubscribe", zap.Error(err), zap.String("topic", replyTopic))
-		}
-	}()
-
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
+	response := &rsTY.ServiceEvent{}
+	err = reply.LoadData(response)
+	if err != nil {
+		logger.Error("error on converting to event type", zap.Error(err))
+		return err
+	}
 
-	busUtils.PostToService(logger, bus, serviceTopic, resourceID, data, resourceType, command, replyTopic)
+	if response.Error != "" {
+		return errors.New(response.Error)
+	}
 
-	select {
-	case <-closeChan.CH:
+	if out == nil {
 		return nil
-	case <-ctx.Done():
-		return fmt.Errorf("reached timeout: %s", timeout.String())
 	}
-}
-
-func responseFunc(logger *zap.Logger, closeChan *concurrency.Channel, callBack func(item interface{}) bool, out interface{}) func(data *busTY.BusData) {
-	return func(busData *busTY.BusData) {
-		event := &rsTY.ServiceEvent{}
-		err := busData.LoadData(event)
-		if err != nil {
-			logger.Error("error on converting to event type", zap.Error(err))
-			closeChan.SafeSend(true)
-			return
-		}
-
-		if event.Error != "" {
-			logger.Error("error on response", zap.Any("response", event))
-			closeChan.SafeSend(true)
-			return
-		}
-
-		if callBack != nil {
-			// convert data to callback type
-			err := event.LoadData(out)
-			if err != nil {
-				logger.Error("error on converting to target type", zap.Error(err), zap.Any("event", event))
-				closeChan.SafeSend(true)
-				return
-			}
-
-			if !callBack(out) { // continue?
-				closeChan.SafeSend(true)
-				return
-			}
-			return
-		}
-
-		closeChan.SafeSend(true)
+	err = response.LoadData(out)
+	if err != nil {
+		logger.Error("error on converting to target type", zap.Error(err), zap.Any("response", response))
+		return err
 	}
+	return nil
 }
@@ -131,6 +131,13 @@ var (
 		"authentication",
 		"jwtaccesssecret",
 		"encryptionkey",
+		"clientsecret",
+		"refreshtoken",
+		"secret",
+		"privatekey",
+		"secretkey",
+		"passphrase",
+		"setupcode",
 	}
 )
 
@@ -261,11 +261,16 @@ func VerifyFloatSlice(value float64, operator string, expectedValue interface{})
 }
 
 // CompareTime compares time.Time values
+// expected value can be a time.Time or a string in RFC3339 format
 func CompareTime(value time.Time, operator string, expectedValue interface{}) bool {
-	expectedStr := converterUtils.ToString(expectedValue)
-	expected, err := time.Parse(time.RFC3339, expectedStr)
-	if err != nil {
-		return false
+	expected, ok := expectedValue.(time.Time)
+	if !ok {
+		expectedStr := converterUtils.ToString(expectedValue)
+		parsed, err := time.Parse(time.RFC3339, expectedStr)
+		if err != nil {
+			return false
+		}
+		expected = parsed
 	}
 	switch operator {
 	case storageTY.OperatorEqual, storageTY.OperatorNone:
@@ -43,19 +43,21 @@ func New(insecure bool, timeout string) *Client {
 	return GetClient(insecure, utils.ToDuration(timeout, DefaultTimeout))
 }
 
-// GetClient returns http client
+// GetClient returns http client, each client has its own transport and timeout
 func GetClient(insecure bool, timeout time.Duration) *Client {
-	var httpClient *http.Client
+	var tlsConfig *tls.Config
 	if insecure {
-		customTransport := http.DefaultTransport.(*http.Transport).Clone()
-		customTransport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
-		httpClient = &http.Client{Transport: customTransport}
-	} else {
-		httpClient = http.DefaultClient
+		tlsConfig = &tls.Config{InsecureSkipVerify: true}
 	}
-	if timeout > 0 {
-		httpClient.Timeout = timeout
-	} else {
+	return GetClientWithTLS(tlsConfig, timeout)
+}
+
+// GetClientWithTLS returns http client with the supplied tls config, used on the client certificate auth
+func GetClientWithTLS(tlsConfig *tls.Config, timeout time.Duration) *Client {
+	customTransport := http.DefaultTransport.(*http.Transport).Clone()
+	customTransport.TLSClientConfig = tlsConfig
+	httpClient := &http.Client{Transport: customTransport, Timeout: timeout}
+	if timeout <= 0 {
 		httpClient.Timeout = DefaultTimeout
 	}
 
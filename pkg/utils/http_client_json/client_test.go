@@ -0,0 +1,41 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientTimeoutsAreIndependent(t *testing.T) {
+	longPoll := GetClient(false, 200*time.Millisecond)
+	short := GetClient(false, 20*time.Millisecond)
+	insecure := GetClient(true, 0)
+
+	assert.Equal(t, 200*time.Millisecond, longPoll.httpClient.Timeout)
+	assert.Equal(t, 20*time.Millisecond, short.httpClient.Timeout)
+	assert.Equal(t, DefaultTimeout, insecure.httpClient.Timeout)
+	assert.NotSame(t, http.DefaultClient, longPoll.httpClient)
+	assert.NotSame(t, longPoll.httpClient, short.httpClient)
+	assert.NotSame(t, longPoll.httpClient.Transport, short.httpClient.Transport)
+	assert.Zero(t, http.DefaultClient.Timeout)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(80 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	// the short timeout of the other client does not apply to the long poll
+	_, err := longPoll.ExecuteJson(server.URL, http.MethodGet, nil, nil, nil, http.StatusOK)
+	require.NoError(t, err)
+	_, err = short.ExecuteJson(server.URL, http.MethodGet, nil, nil, nil, http.StatusOK)
+	assert.Error(t, err)
+
+	// updating a timeout does not change the other clients
+	short.UpdateTimeout("1s")
+	assert.Equal(t, 200*time.Millisecond, longPoll.httpClient.Timeout)
+}
@@ -0,0 +1,231 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	storageTY "github.com/mycontroller-org/server/v2/plugin/database/storage/types"
+)
+
+// content types
+const (
+	ContentTypeJSON = "application/json"
+	ContentTypeText = "text/plain"
+	ContentTypeHTML = "text/html"
+)
+
+// reusable parameter names, refers the storage query conventions
+const (
+	ParameterLimit  = "limit"
+	ParameterOffset = "offset"
+	ParameterSortBy = "sortBy"
+	ParameterFilter = "filter"
+
+	parameterRefRoute = "#/components/parameters/"
+	securityBearer    = "bearerAuth"
+)
+
+var pathParameterRgx = regexp.MustCompile(`{([^}:]+)(:[^}]+)?}`)
+
+// Route describes an api endpoint and the types used on it
+type Route struct {
+	Method      string
+	Path        string
+	Tag         string
+	Summary     string
+	Public      bool        // authentication not required
+	List        bool        // includes filter and pagination query parameters and returns storage.Result
+	Query       []Parameter // additional query parameters
+	Request     interface{} // sample of request body
+	Response    interface{} // sample of response body, for a list route sample of a item
+	ContentType string      // response content type, default application/json
+	RequestType string      // request content type, default application/json
+}
+
+// Key returns unique key of the route
+func (r Route) Key() string {
+	return fmt.Sprintf("%s %s", strings.ToUpper(r.Method), r.Path)
+}
+
+// QueryParameter returns a query parameter with string schema
+func QueryParameter(name, description string, required bool) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Required: required, Schema: &Schema{Type: "string"}}
+}
+
+// Build returns OpenAPI document for the given routes
+func Build(info Info, routes []Route) (*Document, error) {
+	sg := NewSchemaGenerator()
+
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   make(map[string]*PathItem),
+		Components: Components{
+			Parameters: storageParameters(sg),
+			SecuritySchemes: map[string]SecurityScheme{
+				securityBearer: {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+			},
+		},
+		Security: []map[string][]string{{securityBearer: {}}},
+	}
+
+	tags := map[string]bool{}
+	for _, route := range routes {
+		pathItem, found := doc.Paths[route.Path]
+		if !found {
+			pathItem = &PathItem{}
+			doc.Paths[route.Path] = pathItem
+		}
+
+		operation := buildOperation(sg, route)
+		switch strings.ToUpper(route.Method) {
+		case http.MethodGet:
+			if pathItem.Get != nil {
+				return nil, fmt.Errorf("duplicate route definition: %s", route.Key())
+			}
+			pathItem.Get = operation
+		case http.MethodPost:
+			if pathItem.Post != nil {
+				return nil, fmt.Errorf("duplicate route definition: %s", route.Key())
+			}
+			pathItem.Post = operation
+		case http.MethodPut:
+			if pathItem.Put != nil {
+				return nil, fmt.Errorf("duplicate route definition: %s", route.Key())
+			}
+			pathItem.Put = operation
+		case http.MethodDelete:
+			if pathItem.Delete != nil {
+				return nil, fmt.Errorf("duplicate route definition: %s", route.Key())
+			}
+			pathItem.Delete = operation
+		default:
+			return nil, fmt.Errorf("unsupported method on route definition: %s", route.Key())
+		}
+		if route.Tag != "" {
+			tags[route.Tag] = true
+		}
+	}
+
+	tagNames := make([]string, 0, len(tags))
+	for name := range tags {
+		tagNames = append(tagNames, name)
+	}
+	sort.Strings(tagNames)
+	for _, name := range tagNames {
+		doc.Tags = append(doc.Tags, Tag{Name: name})
+	}
+
+	doc.Components.Schemas = sg.Schemas()
+	return doc, nil
+}
+
+func buildOperation(sg *SchemaGenerator, route Route) *Operation {
+	operation := &Operation{
+		Summary:     route.Summary,
+		OperationID: operationID(route),
+		Responses:   map[string]*Response{},
+	}
+	if route.Tag != "" {
+		operation.Tags = []string{route.Tag}
+	}
+	if route.Public {
+		// overrides the global security
+		operation.Security = &[]map[string][]string{}
+	}
+
+	// path parameters
+	for _, match := range pathParameterRgx.FindAllStringSubmatch(route.Path, -1) {
+		operation.Parameters = append(operation.Parameters, Parameter{
+			Name: match[1], In: "path", Required: true, Schema: &Schema{Type: "string"},
+		})
+	}
+
+	// filter and pagination parameters
+	if route.List {
+		for _, name := range []string{ParameterFilter, ParameterLimit, ParameterOffset, ParameterSortBy} {
+			operation.Parameters = append(operation.Parameters, Parameter{Ref: parameterRefRoute + name})
+		}
+	}
+	operation.Parameters = append(operation.Parameters, route.Query...)
+
+	if route.Request != nil {
+		requestType := route.RequestType
+		if requestType == "" {
+			requestType = ContentTypeJSON
+		}
+		operation.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{requestType: {Schema: sg.SchemaOf(route.Request)}},
+		}
+	}
+
+	response := &Response{Description: "success"}
+	contentType := route.ContentType
+	if contentType == "" {
+		contentType = ContentTypeJSON
+	}
+	if route.List {
+		response.Content = map[string]MediaType{contentType: {Schema: listSchema(sg, route.Response)}}
+	} else if route.Response != nil {
+		response.Content = map[string]MediaType{contentType: {Schema: sg.SchemaOf(route.Response)}}
+	}
+	operation.Responses["200"] = response
+	operation.Responses["default"] = &Response{
+		Description: "error",
+		Content:     map[string]MediaType{ContentTypeText: {Schema: &Schema{Type: "string"}}},
+	}
+	if !route.Public {
+		operation.Responses["401"] = &Response{Description: "unauthorized"}
+	}
+	return operation
+}
+
+// listSchema returns storage.Result schema with the item type on data field
+func listSchema(sg *SchemaGenerator, item interface{}) *Schema {
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"count":  {Type: "integer", Format: "int64"},
+			"limit":  {Type: "integer", Format: "int64"},
+			"offset": {Type: "integer", Format: "int64"},
+			"data":   {Type: "array", Items: sg.SchemaOf(item)},
+		},
+	}
+}
+
+// storageParameters returns the query parameters used on list apis
+// see http_handler.Params for the implementation
+func storageParameters(sg *SchemaGenerator) map[string]Parameter {
+	return map[string]Parameter{
+		ParameterLimit: {
+			Name: ParameterLimit, In: "query", Description: "maximum number of items to return",
+			Schema: &Schema{Type: "integer", Format: "int64", Default: 50},
+		},
+		ParameterOffset: {
+			Name: ParameterOffset, In: "query", Description: "number of items to skip",
+			Schema: &Schema{Type: "integer", Format: "int64", Default: 0},
+		},
+		ParameterSortBy: {
+			Name: ParameterSortBy, In: "query", Description: "json encoded sort options",
+			Content: map[string]MediaType{ContentTypeJSON: {Schema: sg.SchemaOf([]storageTY.Sort{})}},
+		},
+		ParameterFilter: {
+			Name: ParameterFilter, In: "query",
+			Description: "json encoded filters. other query parameters are used as equal filter on that key",
+			Content:     map[string]MediaType{ContentTypeJSON: {Schema: sg.SchemaOf([]storageTY.Filter{})}},
+		},
+	}
+}
+
+// operationID derives an unique operation id from method and path
+// example: "GET /api/gateway/{id}" => "get_api_gateway_id"
+func operationID(route Route) string {
+	path := pathParameterRgx.ReplaceAllString(route.Path, "$1")
+	path = strings.Trim(path, "/")
+	path = strings.NewReplacer("/", "_", "-", "_", ".", "_").Replace(path)
+	return strings.ToLower(route.Method) + "_" + path
+}
@@ -0,0 +1,167 @@
+package openapi
+
+import (
+	"encoding"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	modulePath     = "github.com/mycontroller-org/server/v2/"
+	schemaRefRoute = "#/components/schemas/"
+)
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	invalidNameCharsRgx = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+)
+
+// SchemaGenerator creates schemas from go types by using reflection.
+// struct types are added into the components and referred by "$ref"
+type SchemaGenerator struct {
+	schemas map[string]*Schema
+}
+
+// NewSchemaGenerator returns a schema generator
+func NewSchemaGenerator() *SchemaGenerator {
+	return &SchemaGenerator{schemas: make(map[string]*Schema)}
+}
+
+// Schemas returns all the struct schemas created so far
+func (sg *SchemaGenerator) Schemas() map[string]*Schema {
+	return sg.schemas
+}
+
+// SchemaOf returns schema of the given value
+func (sg *SchemaGenerator) SchemaOf(value interface{}) *Schema {
+	if value == nil {
+		return &Schema{}
+	}
+	return sg.schemaOfType(reflect.TypeOf(value))
+}
+
+func (sg *SchemaGenerator) schemaOfType(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case durationType:
+		return &Schema{Type: "integer", Format: "int64", Description: "duration in nanoseconds"}
+	}
+
+	// text marshalled structs (ex: custom date and time) are represented as string
+	if t.Kind() == reflect.Struct && implementsTextMarshaler(t) {
+		return &Schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+
+	case reflect.String:
+		return &Schema{Type: "string"}
+
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: sg.schemaOfType(t.Elem())}
+
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: sg.schemaOfType(t.Elem())}
+
+	case reflect.Struct:
+		return sg.structRef(t)
+
+	default:
+		// interface, func, chan: any value
+		return &Schema{}
+	}
+}
+
+// structRef adds the struct schema into the components, if not available and returns the reference
+func (sg *SchemaGenerator) structRef(t reflect.Type) *Schema {
+	// anonymous structs are not added into the components
+	if t.Name() == "" {
+		schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+		sg.addProperties(schema, t)
+		return schema
+	}
+
+	name := TypeName(t)
+	if _, found := sg.schemas[name]; !found {
+		schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+		// register before looping the fields, to support recursive types
+		sg.schemas[name] = schema
+		sg.addProperties(schema, t)
+	}
+	return &Schema{Ref: schemaRefRoute + name}
+}
+
+func (sg *SchemaGenerator) addProperties(schema *Schema, t reflect.Type) {
+	for index := 0; index < t.NumField(); index++ {
+		field := t.Field(index)
+		if !field.IsExported() {
+			continue
+		}
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		// embedded structs without name are inlined, as encoding/json does
+		if field.Anonymous && name == "" {
+			fieldType := field.Type
+			if fieldType.Kind() == reflect.Pointer {
+				fieldType = fieldType.Elem()
+			}
+			if fieldType.Kind() == reflect.Struct && !implementsTextMarshaler(fieldType) {
+				sg.addProperties(schema, fieldType)
+				continue
+			}
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		schema.Properties[name] = sg.schemaOfType(field.Type)
+	}
+}
+
+// TypeName returns name of the type used in the components
+func TypeName(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	pkgPath := strings.TrimPrefix(t.PkgPath(), modulePath)
+	name := t.Name()
+	if pkgPath != "" {
+		name = strings.ReplaceAll(pkgPath, "/", ".") + "." + name
+	}
+	return invalidNameCharsRgx.ReplaceAllString(name, "_")
+}
+
+func implementsTextMarshaler(t reflect.Type) bool {
+	return t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType)
+}
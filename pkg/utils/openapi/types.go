@@ -0,0 +1,113 @@
+package openapi
+
+// Version of the OpenAPI specification produced by this package
+const Version = "3.0.3"
+
+// Document is the root object of an OpenAPI document
+type Document struct {
+	OpenAPI    string                `json:"openapi" yaml:"openapi"`
+	Info       Info                  `json:"info" yaml:"info"`
+	Servers    []Server              `json:"servers,omitempty" yaml:"servers,omitempty"`
+	Tags       []Tag                 `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Paths      map[string]*PathItem  `json:"paths" yaml:"paths"`
+	Components Components            `json:"components" yaml:"components"`
+	Security   []map[string][]string `json:"security,omitempty" yaml:"security,omitempty"`
+}
+
+// Info provides metadata about the api
+type Info struct {
+	Title       string `json:"title" yaml:"title"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Version     string `json:"version" yaml:"version"`
+}
+
+// Server represents a server url
+type Server struct {
+	URL         string `json:"url" yaml:"url"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+// Tag used to group the operations
+type Tag struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+// PathItem describes the operations available on a single path
+type PathItem struct {
+	Get        *Operation  `json:"get,omitempty" yaml:"get,omitempty"`
+	Post       *Operation  `json:"post,omitempty" yaml:"post,omitempty"`
+	Put        *Operation  `json:"put,omitempty" yaml:"put,omitempty"`
+	Delete     *Operation  `json:"delete,omitempty" yaml:"delete,omitempty"`
+	Parameters []Parameter `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+}
+
+// Operation describes a single api operation on a path
+type Operation struct {
+	Tags        []string               `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Summary     string                 `json:"summary,omitempty" yaml:"summary,omitempty"`
+	OperationID string                 `json:"operationId,omitempty" yaml:"operationId,omitempty"`
+	Parameters  []Parameter            `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	RequestBody *RequestBody           `json:"requestBody,omitempty" yaml:"requestBody,omitempty"`
+	Responses   map[string]*Response   `json:"responses" yaml:"responses"`
+	Security    *[]map[string][]string `json:"security,omitempty" yaml:"security,omitempty"` // pointer keeps the empty list, overrides the global security
+}
+
+// Parameter describes a single operation parameter
+type Parameter struct {
+	Ref         string               `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	Name        string               `json:"name,omitempty" yaml:"name,omitempty"`
+	In          string               `json:"in,omitempty" yaml:"in,omitempty"`
+	Description string               `json:"description,omitempty" yaml:"description,omitempty"`
+	Required    bool                 `json:"required,omitempty" yaml:"required,omitempty"`
+	Schema      *Schema              `json:"schema,omitempty" yaml:"schema,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty" yaml:"content,omitempty"`
+}
+
+// RequestBody describes a request body
+type RequestBody struct {
+	Description string               `json:"description,omitempty" yaml:"description,omitempty"`
+	Required    bool                 `json:"required,omitempty" yaml:"required,omitempty"`
+	Content     map[string]MediaType `json:"content" yaml:"content"`
+}
+
+// Response describes a single response from an api operation
+type Response struct {
+	Description string               `json:"description" yaml:"description"`
+	Content     map[string]MediaType `json:"content,omitempty" yaml:"content,omitempty"`
+}
+
+// MediaType provides schema for a content type
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty" yaml:"schema,omitempty"`
+}
+
+// Components holds reusable objects
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas,omitempty" yaml:"schemas,omitempty"`
+	Parameters      map[string]Parameter      `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty" yaml:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme defines a security scheme
+type SecurityScheme struct {
+	Type         string `json:"type" yaml:"type"`
+	Scheme       string `json:"scheme,omitempty" yaml:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty" yaml:"bearerFormat,omitempty"`
+	Name         string `json:"name,omitempty" yaml:"name,omitempty"`
+	In           string `json:"in,omitempty" yaml:"in,omitempty"`
+}
+
+// Schema is a subset of the OpenAPI schema object
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty" yaml:"type,omitempty"`
+	Format               string             `json:"format,omitempty" yaml:"format,omitempty"`
+	Description          string             `json:"description,omitempty" yaml:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty" yaml:"nullable,omitempty"`
+	Items                *Schema            `json:"items,omitempty" yaml:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty" yaml:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty" yaml:"additionalProperties,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty" yaml:"enum,omitempty"`
+	Default              interface{}        `json:"default,omitempty" yaml:"default,omitempty"`
+}
@@ -1,6 +1,7 @@
 package printer
 
 import (
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -22,6 +23,7 @@ const (
 	OutputConsoleWide = "wide" // same as console, prints additional headers
 	OutputYAML        = "yaml"
 	OutputJSON        = "json"
+	OutputCSV         = "csv"
 )
 
 type ValueFunc func(interface{}) string
@@ -32,6 +34,7 @@ type Header struct {
 	DisplayStyle string
 	IsWide       bool
 	ValueFunc    ValueFunc
+	Width        int // column width on the stream printer
 }
 
 const (
@@ -53,6 +56,15 @@ func Print(out io.Writer, headers []Header, data interface{}, hideHeader bool, o
 		PrintConsole(out, headers, dataConsole, hideHeader, wideEnabled)
 		return
 
+	case OutputCSV:
+		dataCSV, ok := data.([]interface{})
+		if !ok {
+			_, _ = fmt.Fprintln(out, "data not in table format")
+			return
+		}
+		PrintCSV(out, headers, dataCSV, hideHeader)
+		return
+
 	case OutputJSON:
 		var jsonBytes []byte
 		var err error
@@ -97,57 +109,7 @@ func PrintConsole(out io.Writer, headers []Header, data []interface{}, hideHeade
 				continue
 			}
 
-			var value interface{}
-			if header.ValueFunc != nil {
-				value = header.ValueFunc(structData)
-			} else {
-				valuePath := header.ValuePath
-				if valuePath == "" {
-					valuePath = header.Title
-				}
-				_, _value, err := filterUtils.GetValueByKeyPath(structData, valuePath)
-				if err != nil {
-					errValue := err.Error()
-					if strings.HasPrefix(err.Error(), "key not found") {
-						errValue = ""
-					}
-					row = append(row, errValue)
-					continue
-				}
-				value = _value
-			}
-
-			var rowValue interface{}
-			if value != nil {
-				switch _value := value.(type) {
-				case time.Time:
-					if !_value.IsZero() {
-						if header.DisplayStyle == DisplayStyleRelativeTime {
-							g, err := goment.New(_value.UnixNano())
-							if err != nil {
-								rowValue = err.Error()
-							} else {
-								rowValue = g.FromNow()
-							}
-						}
-					} else {
-						rowValue = ""
-					}
-
-				case cmap.CustomStringMap:
-					stringLabels := []string{}
-					for k, v := range _value {
-						stringLabels = append(stringLabels, fmt.Sprintf("%s=%s", k, v))
-					}
-					rowValue = strings.Join(stringLabels, ",")
-
-				}
-
-				if rowValue == nil {
-					rowValue = fmt.Sprintf("%v", value)
-				}
-			}
-			row = append(row, convertorUtils.ToString(rowValue))
+			row = append(row, getCellValue(header, structData))
 		}
 		rows = append(rows, row)
 	}
@@ -170,3 +132,80 @@ func PrintConsole(out io.Writer, headers []Header, data []interface{}, hideHeade
 	table.AppendBulk(rows) // Add Bulk Data
 	table.Render()
 }
+
+// PrintCSV prints the data in csv format, wide headers are included
+func PrintCSV(out io.Writer, headers []Header, data []interface{}, hideHeader bool) {
+	writer := csv.NewWriter(out)
+	if !hideHeader {
+		titles := make([]string, 0, len(headers))
+		for _, header := range headers {
+			titles = append(titles, header.Title)
+		}
+		_ = writer.Write(titles)
+	}
+	for index := range data {
+		row := make([]string, 0, len(headers))
+		for _, header := range headers {
+			row = append(row, getCellValue(header, data[index]))
+		}
+		_ = writer.Write(row)
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		_, _ = fmt.Fprintln(out, "error on writing csv", err)
+	}
+}
+
+// getCellValue returns the display value of the header from the data
+func getCellValue(header Header, structData interface{}) string {
+	var value interface{}
+	if header.ValueFunc != nil {
+		value = header.ValueFunc(structData)
+	} else {
+		valuePath := header.ValuePath
+		if valuePath == "" {
+			valuePath = header.Title
+		}
+		_, _value, err := filterUtils.GetValueByKeyPath(structData, valuePath)
+		if err != nil {
+			errValue := err.Error()
+			if strings.HasPrefix(err.Error(), "key not found") {
+				errValue = ""
+			}
+			return errValue
+		}
+		value = _value
+	}
+
+	var rowValue interface{}
+	if value != nil {
+		switch _value := value.(type) {
+		case time.Time:
+			if !_value.IsZero() {
+				if header.DisplayStyle == DisplayStyleRelativeTime {
+					g, err := goment.New(_value.UnixNano())
+					if err != nil {
+						rowValue = err.Error()
+					} else {
+						rowValue = g.FromNow()
+					}
+				}
+			} else {
+				rowValue = ""
+			}
+
+		case cmap.CustomStringMap:
+			stringLabels := []string{}
+			for k, v := range _value {
+				stringLabels = append(stringLabels, fmt.Sprintf("%s=%s", k, v))
+			}
+			rowValue = strings.Join(stringLabels, ",")
+
+		}
+
+		if rowValue == nil {
+			rowValue = fmt.Sprintf("%v", value)
+		}
+	}
+	return convertorUtils.ToString(rowValue)
+}
@@ -0,0 +1,130 @@
+package printer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+const streamColumnMinWidth = 10
+
+// StreamPrinter prints the items one by one as they arrive, used on watch commands.
+// console output uses fixed column widths, as the width of the later items not known in advance
+type StreamPrinter struct {
+	out           io.Writer
+	headers       []Header
+	hideHeader    bool
+	output        string
+	pretty        bool
+	widths        []int
+	headerPrinted bool
+	mutex         sync.Mutex
+}
+
+// NewStreamPrinter returns a stream printer
+func NewStreamPrinter(out io.Writer, headers []Header, hideHeader bool, output string, pretty bool) *StreamPrinter {
+	wideEnabled := output == OutputConsoleWide || output == OutputCSV
+	filtered := make([]Header, 0)
+	widths := make([]int, 0)
+	for _, header := range headers {
+		if !wideEnabled && header.IsWide {
+			continue
+		}
+		filtered = append(filtered, header)
+		width := header.Width
+		if width == 0 {
+			width = len(header.Title)
+		}
+		if width < streamColumnMinWidth {
+			width = streamColumnMinWidth
+		}
+		widths = append(widths, width)
+	}
+	return &StreamPrinter{
+		out:        out,
+		headers:    filtered,
+		hideHeader: hideHeader,
+		output:     output,
+		pretty:     pretty,
+		widths:     widths,
+	}
+}
+
+// Print prints an item
+func (sp *StreamPrinter) Print(data interface{}) {
+	sp.mutex.Lock()
+	defer sp.mutex.Unlock()
+
+	switch sp.output {
+	case OutputJSON:
+		var jsonBytes []byte
+		var err error
+		if sp.pretty {
+			jsonBytes, err = json.MarshalIndent(data, "", " ")
+		} else {
+			jsonBytes, err = json.Marshal(data)
+		}
+		if err != nil {
+			_, _ = fmt.Fprintln(sp.out, "error on converting to json", err)
+			return
+		}
+		_, _ = fmt.Fprintln(sp.out, string(jsonBytes))
+
+	case OutputYAML:
+		bytes, err := yaml.Marshal(data)
+		if err != nil {
+			_, _ = fmt.Fprintln(sp.out, "error on converting to yaml", err)
+			return
+		}
+		_, _ = fmt.Fprintf(sp.out, "---\n%s", string(bytes))
+
+	case OutputCSV:
+		writer := csv.NewWriter(sp.out)
+		if !sp.hideHeader && !sp.headerPrinted {
+			titles := make([]string, 0, len(sp.headers))
+			for _, header := range sp.headers {
+				titles = append(titles, header.Title)
+			}
+			_ = writer.Write(titles)
+			sp.headerPrinted = true
+		}
+		values := make([]string, 0, len(sp.headers))
+		for _, header := range sp.headers {
+			values = append(values, getCellValue(header, data))
+		}
+		_ = writer.Write(values)
+		writer.Flush()
+
+	default:
+		if !sp.hideHeader && !sp.headerPrinted {
+			titles := make([]string, 0, len(sp.headers))
+			for _, header := range sp.headers {
+				titles = append(titles, strings.ToUpper(header.Title))
+			}
+			sp.printRow(titles)
+			sp.headerPrinted = true
+		}
+		values := make([]string, 0, len(sp.headers))
+		for _, header := range sp.headers {
+			values = append(values, getCellValue(header, data))
+		}
+		sp.printRow(values)
+	}
+}
+
+func (sp *StreamPrinter) printRow(values []string) {
+	var sb strings.Builder
+	for index, value := range values {
+		if index == len(values)-1 {
+			sb.WriteString(value)
+			break
+		}
+		sb.WriteString(fmt.Sprintf("%-*s   ", sp.widths[index], value))
+	}
+	_, _ = fmt.Fprintln(sp.out, strings.TrimRight(sb.String(), " "))
+}
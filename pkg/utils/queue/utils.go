@@ -1,6 +1,7 @@
 package queue
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/mycontroller-org/server/v2/pkg/utils/concurrency"
@@ -35,7 +36,16 @@ func NewWithRetry(logger *zap.Logger, name string, limit int, consumer func(item
 		queue = NewBoundedQueue(limit, droppedItemHandler)
 	}
 
-	queue.StartConsumers(workers, consumer)
+	// items with acknowledgement are retried by the producer (example: message bus redelivery)
+	ackConsumer := func(item interface{}) error {
+		if ackItem, ok := item.(*itemWithAck); ok {
+			ackItem.ack(consumer(ackItem.item))
+			return nil
+		}
+		return consumer(item)
+	}
+
+	queue.StartConsumers(workers, ackConsumer)
 
 	return &Queue{
 		Name:    name,
@@ -62,11 +72,30 @@ func (q *Queue) Produce(item interface{}) bool {
 	return q.Queue.Produce(item)
 }
 
+// ProduceWithAck adds an item to the queue, ack func called with the consumer result.
+// if the item is not added, ack func called with an error
+func (q *Queue) ProduceWithAck(item interface{}, ack func(err error)) bool {
+	if ack == nil {
+		return q.Produce(item)
+	}
+	status := q.Produce(&itemWithAck{item: item, ack: ack})
+	if !status {
+		ack(fmt.Errorf("failed to add the item into queue:%s", q.Name))
+	}
+	return status
+}
+
 // Size returns current size of the queue
 func (q *Queue) Size() int {
 	return q.Queue.Size()
 }
 
+// item and the acknowledge func
+type itemWithAck struct {
+	item interface{}
+	ack  func(err error)
+}
+
 // used to hold queue and subscription details
 type QueueSpec struct {
 	Topic          string
@@ -82,6 +111,10 @@ func (qs *QueueSpec) Produce(item interface{}) bool {
 	return qs.Queue.Produce(item)
 }
 
+func (qs *QueueSpec) ProduceWithAck(item interface{}, ack func(err error)) bool {
+	return qs.Queue.ProduceWithAck(item, ack)
+}
+
 func (qs *QueueSpec) Size() int {
 	return qs.Queue.Size()
 }
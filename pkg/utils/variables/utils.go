@@ -1,6 +1,7 @@
 package variables
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/mycontroller-org/server/v2/pkg/json"
@@ -8,6 +9,7 @@ import (
 	"github.com/mycontroller-org/server/v2/pkg/types/cmap"
 	"github.com/mycontroller-org/server/v2/pkg/utils"
 	httpclient "github.com/mycontroller-org/server/v2/pkg/utils/http_client_json"
+	"github.com/mycontroller-org/server/v2/pkg/utils/javascript"
 	handlerTY "github.com/mycontroller-org/server/v2/plugin/handler/types"
 	"go.uber.org/zap"
 	"gopkg.in/yaml.v3"
@@ -20,6 +22,12 @@ func UpdateParameters(logger *zap.Logger, variables map[string]interface{}, para
 		// load supplied string, this will be passed, if there is an error
 		updatedParameters[name] = parameter
 
+		// body template of a webhook executed separately, keep it away from the generic template execution
+		var bodyTemplate, templateType string
+		if parameterMap, ok := parameter.(map[string]interface{}); ok {
+			bodyTemplate, templateType, parameter = extractBodyTemplate(parameterMap)
+		}
+
 		// convert it to yaml format
 		yamlBytes, err := yaml.Marshal(&parameter)
 		if err != nil {
@@ -59,6 +67,16 @@ func UpdateParameters(logger *zap.Logger, variables map[string]interface{}, para
 				webhookData.Data = string(yamlVariables)
 				updatedParameter = utils.StructToMap(&webhookData)
 			}
+
+			if bodyTemplate != "" {
+				body, err := executeBodyTemplate(logger, bodyTemplate, templateType, variables, templateEngine)
+				if err != nil {
+					logger.Error("error on executing webhook body template", zap.String("name", name), zap.String("templateType", templateType), zap.Error(err))
+					continue
+				}
+				webhookData.Body = body
+				updatedParameter = utils.StructToMap(&webhookData)
+			}
 		}
 		updatedParameters[name] = updatedParameter
 	}
@@ -66,6 +84,52 @@ func UpdateParameters(logger *zap.Logger, variables map[string]interface{}, para
 	return updatedParameters
 }
 
+// extractBodyTemplate returns the webhook body template and a copy of the parameter without it
+func extractBodyTemplate(parameter map[string]interface{}) (string, string, map[string]interface{}) {
+	if cmap.CustomMap(parameter).GetString(types.KeyType) != handlerTY.DataTypeWebhook {
+		return "", "", parameter
+	}
+	bodyTemplate, _ := parameter["bodyTemplate"].(string)
+	if bodyTemplate == "" {
+		return "", "", parameter
+	}
+	templateType, _ := parameter["templateType"].(string)
+
+	updated := make(map[string]interface{}, len(parameter))
+	for key, value := range parameter {
+		if key != "bodyTemplate" {
+			updated[key] = value
+		}
+	}
+	return bodyTemplate, templateType, updated
+}
+
+// executeBodyTemplate renders the webhook body with the variables
+func executeBodyTemplate(logger *zap.Logger, bodyTemplate, templateType string, variables map[string]interface{}, templateEngine types.TemplateEngine) (string, error) {
+	switch templateType {
+	case handlerTY.WebhookTemplateTypeGo, "":
+		return templateEngine.Execute(bodyTemplate, variables)
+
+	case handlerTY.WebhookTemplateTypeJavascript:
+		timeout := webhookTimeout
+		result, err := javascript.Execute(logger, bodyTemplate, variables, &timeout)
+		if err != nil {
+			return "", err
+		}
+		if stringResult, ok := result.(string); ok {
+			return stringResult, nil
+		}
+		bodyBytes, err := json.Marshal(result)
+		if err != nil {
+			return "", err
+		}
+		return string(bodyBytes), nil
+
+	default:
+		return "", fmt.Errorf("unknown template type: %s", templateType)
+	}
+}
+
 // Merge variables and extra variables
 func Merge(variables, extra map[string]interface{}) map[string]interface{} {
 	finalMap := make(map[string]interface{})
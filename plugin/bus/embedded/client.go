@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/mycontroller-org/server/v2/pkg/types/cmap"
 	"github.com/mycontroller-org/server/v2/pkg/utils"
@@ -18,23 +20,42 @@ import (
 const (
 	PluginEmbedded = "embedded"
 	loggerName     = "BUS:EMBEDDED"
+
+	defaultBufferSize            = 1000
+	defaultWorkers               = 1
+	defaultBlockTimeout          = time.Duration(0) // no limit, messages are not dropped by default
+	defaultSlowConsumerThreshold = 10 * time.Second
+	defaultMaxTopicStats         = 1000
+
+	otherTopicsStats = "other_topics" // stats of the topics beyond the limit
 )
 
 // Config details of the client
 type Config struct {
-	Type        string `yaml:"type"`
-	TopicPrefix string `yaml:"topic_prefix"`
+	Type                  string `yaml:"type"`
+	TopicPrefix           string `yaml:"topic_prefix"`
+	BufferSize            int    `yaml:"buffer_size"`             // buffer size of a subscription
+	Workers               int    `yaml:"workers"`                 // number of workers on a subscription
+	OverflowPolicy        string `yaml:"overflow_policy"`         // block, drop_oldest or drop_newest
+	BlockTimeout          string `yaml:"block_timeout"`           // maximum wait time on block policy, message dropped on timeout. waits without a limit, if not set
+	SlowConsumerThreshold string `yaml:"slow_consumer_threshold"` // a delivery takes longer than this duration, reported as slow consumer
+	MaxTopicStats         int    `yaml:"max_topic_stats"`         // maximum topics on the stats
+
+	blockTimeout          time.Duration
+	slowConsumerThreshold time.Duration
 }
 
 // Client struct
 type Client struct {
 	topics              map[string][]int64
-	subscriptions       map[int64]busTY.CallBackFunc
+	subscriptions       map[int64]*subscriber
 	subscriptionCounter int64
 	mutex               *sync.RWMutex
 	pauseFlag           concurrency.SafeBool
 	logger              *zap.Logger
 	config              *Config
+	stats               *stats
+	stopCh              chan struct{}
 }
 
 // NewClient func
@@ -50,15 +71,43 @@ func NewClient(ctx context.Context, config cmap.CustomMap) (busTY.Plugin, error)
 		return nil, err
 	}
 
+	// set default values, if non set
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = defaultBufferSize
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = defaultWorkers
+	}
+	switch cfg.OverflowPolicy {
+	case "":
+		cfg.OverflowPolicy = OverflowPolicyBlock
+	case OverflowPolicyBlock, OverflowPolicyDropOldest, OverflowPolicyDropNewest:
+	default:
+		return nil, fmt.Errorf("invalid overflow policy:%s", cfg.OverflowPolicy)
+	}
+	cfg.blockTimeout = utils.ToDuration(cfg.BlockTimeout, defaultBlockTimeout)
+	cfg.slowConsumerThreshold = utils.ToDuration(cfg.SlowConsumerThreshold, defaultSlowConsumerThreshold)
+	if cfg.slowConsumerThreshold <= 0 {
+		cfg.slowConsumerThreshold = defaultSlowConsumerThreshold
+	}
+	if cfg.MaxTopicStats <= 0 {
+		cfg.MaxTopicStats = defaultMaxTopicStats
+	}
+
 	client := &Client{
 		topics:              make(map[string][]int64),
-		subscriptions:       make(map[int64]busTY.CallBackFunc),
+		subscriptions:       make(map[int64]*subscriber),
 		subscriptionCounter: 0,
 		mutex:               &sync.RWMutex{},
 		pauseFlag:           concurrency.SafeBool{},
 		logger:              logger.Named(loggerName),
 		config:              cfg,
+		stats:               newStats(cfg.MaxTopicStats),
+		stopCh:              make(chan struct{}),
 	}
+
+	go client.monitorSlowConsumers()
+
 	return client, nil
 }
 
@@ -71,10 +120,20 @@ func (c *Client) Close() error {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
+	// stop the subscribers and the monitor
+	for _, sub := range c.subscriptions {
+		sub.stop()
+	}
+	select {
+	case <-c.stopCh:
+	default:
+		close(c.stopCh)
+	}
+
 	// clear all the call backs and topics
 	c.subscriptionCounter = 0
 	c.topics = make(map[string][]int64)
-	c.subscriptions = make(map[int64]busTY.CallBackFunc)
+	c.subscriptions = make(map[int64]*subscriber)
 	return nil
 }
 
@@ -90,46 +149,118 @@ func (c *Client) ResumePublish() {
 	c.pauseFlag.Reset()
 }
 
-// Publish a data to a topic
+// Publish a data to a topic, delivered to the buffer of the matching subscriptions
 func (c *Client) Publish(topic string, data interface{}) error {
 	if c.pauseFlag.IsSet() {
 		return nil
 	}
+	return c.publish(topic, data, "")
+}
 
-	c.mutex.Lock()
-	defer c.mutex.Unlock()
+// Request publishes a data to a topic and returns the first reply received within the timeout
+func (c *Client) Request(topic string, data interface{}, timeout time.Duration) (*busTY.BusData, error) {
+	if c.pauseFlag.IsSet() {
+		return nil, busTY.ErrPublishPaused
+	}
+
+	replies := make(chan *busTY.BusData, 1)
+	replyTopic := busTY.NewReplyTopic()
+	subscriptionID, err := c.Subscribe(replyTopic, func(reply *busTY.BusData) {
+		select {
+		case replies <- reply:
+		default: // reply already received
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err := c.Unsubscribe(replyTopic, subscriptionID)
+		if err != nil {
+			c.logger.Error("error on unsubscribe", zap.String("topic", replyTopic), zap.Error(err))
+		}
+	}()
+
+	err = c.publish(topic, data, replyTopic)
+	if err != nil {
+		return nil, err
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case reply := <-replies:
+		return reply, nil
+	case <-timer.C:
+		return nil, busTY.NewRequestTimeoutError(topic, timeout)
+	}
+}
+
+func (c *Client) publish(topic string, data interface{}, replyTopic string) error {
+	// reply topics are unique per request, not included in the stats
+	isReplyTopic := busTY.IsReplyTopic(topic)
 
 	// format topic with prefix
 	topic = c.formatTopic(topic)
 
 	c.logger.Debug("Posting message", zap.String("topic", topic))
 
-	for subscriptionTopic := range c.topics {
-		subscriptionIDs := c.topics[subscriptionTopic]
-		match, err := regexp.MatchString(subscriptionTopic, topic)
-		if err != nil {
-			c.logger.Error("error on matching topic", zap.String("publishTopic", topic), zap.String("subscriptionTopic", subscriptionTopic), zap.Error(err))
-			continue
-		}
+	message := &busTY.BusData{Topic: topic}
+	err := message.SetData(data)
+	if err != nil {
+		c.logger.Error("data conversion failed", zap.Error(err))
+		return err
+	}
 
-		if match {
-			for _, subscriptionID := range subscriptionIDs {
-				if callBack, ok := c.subscriptions[subscriptionID]; ok {
-					event := &busTY.BusData{Topic: topic}
-					err := event.SetData(data)
-					if err != nil {
-						c.logger.Error("data conversion failed", zap.Error(err))
-					} else {
-						go callBack(event)
-					}
+	// collect the subscribers, the lock is not held while adding into the buffers
+	subscribers := make([]*subscriber, 0)
+	c.mutex.RLock()
+	for subscriptionTopic := range c.topics {
+		if c.matchTopic(subscriptionTopic, topic) {
+			for _, subscriptionID := range c.topics[subscriptionTopic] {
+				if sub, ok := c.subscriptions[subscriptionID]; ok {
+					subscribers = append(subscribers, sub)
 				}
 			}
 		}
 	}
+	c.mutex.RUnlock()
+
+	// topics without subscriber are not included in the stats
+	withStats := !isReplyTopic && len(subscribers) > 0
+	if withStats {
+		c.stats.onPublish(topic)
+	}
+	publishedAt := time.Now()
+	for _, sub := range subscribers {
+		sub.add(&delivery{data: &busTY.BusData{Topic: topic, Data: message.Data, ReplyTopic: replyTopic}, publishedAt: publishedAt, withStats: withStats})
+	}
 
 	return nil
 }
 
+// matchTopic verifies the published topic against the subscription topic
+func (c *Client) matchTopic(subscriptionTopic, topic string) bool {
+	match, err := regexp.MatchString(subscriptionTopic, topic)
+	if err != nil {
+		c.logger.Error("error on matching topic", zap.String("publishTopic", topic), zap.String("subscriptionTopic", subscriptionTopic), zap.Error(err))
+		return false
+	}
+	return match
+}
+
+// removeUnusedTopicStats removes the stats of the topics those have no subscriber, caller should hold the lock
+func (c *Client) removeUnusedTopicStats() {
+	c.stats.retain(func(topic string) bool {
+		for subscriptionTopic, subscriptionIDs := range c.topics {
+			if len(subscriptionIDs) > 0 && c.matchTopic(subscriptionTopic, topic) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
 // Subscribe a topic
 func (c *Client) Subscribe(topic string, handler busTY.CallBackFunc) (int64, error) {
 	c.mutex.Lock()
@@ -146,7 +277,7 @@ func (c *Client) Subscribe(topic string, handler busTY.CallBackFunc) (int64, err
 	}
 
 	newSubscriptionID := c.generateSubscriptionID()
-	c.subscriptions[newSubscriptionID] = handler
+	c.subscriptions[newSubscriptionID] = newSubscriber(newSubscriptionID, topic, handler, c.config, c.stats, c.logger)
 	subscriptionIDs = append(subscriptionIDs, newSubscriptionID)
 	c.topics[topic] = subscriptionIDs
 	c.logger.Debug("Subscription created", zap.String("topic", topic), zap.Int64("subscriptionID", newSubscriptionID))
@@ -169,6 +300,9 @@ func (c *Client) Unsubscribe(topic string, subscriptionID int64) error {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
+	// reply topics are not included in the stats
+	isReplyTopic := busTY.IsReplyTopic(topic)
+
 	// format topic with prefix
 	topic = c.formatTopic(topic)
 
@@ -184,11 +318,20 @@ func (c *Client) Unsubscribe(topic string, subscriptionID int64) error {
 				break
 			}
 		}
+		if len(c.topics[topic]) == 0 {
+			delete(c.topics, topic)
+		}
 	}
 
 	// remove call back
-	delete(c.subscriptions, subscriptionID)
+	if sub, found := c.subscriptions[subscriptionID]; found {
+		sub.stop()
+		delete(c.subscriptions, subscriptionID)
+	}
 
+	if !isReplyTopic {
+		c.removeUnusedTopicStats()
+	}
 	return nil
 }
 
@@ -201,7 +344,10 @@ func (c *Client) UnsubscribeAll(topic string) error {
 	if subscriptionIDs, found := c.topics[topic]; found {
 		for _, subscriptionID := range subscriptionIDs {
 			// remove call back
-			delete(c.subscriptions, subscriptionID)
+			if sub, found := c.subscriptions[subscriptionID]; found {
+				sub.stop()
+				delete(c.subscriptions, subscriptionID)
+			}
 		}
 
 		// format topic with prefix
@@ -236,3 +382,38 @@ func (c *Client) formatTopicWideSubscription(topic string) string {
 	updatedTopic = fmt.Sprintf("^%s", updatedTopic)
 	return updatedTopic
 }
+
+// Stats returns the deliveries stats of the topics and subscriptions
+func (c *Client) Stats() *busTY.Stats {
+	c.mutex.RLock()
+	subscriptions := make([]busTY.SubscriptionStats, 0, len(c.subscriptions))
+	for _, sub := range c.subscriptions {
+		subscriptions = append(subscriptions, sub.getStats())
+	}
+	c.mutex.RUnlock()
+	sort.Slice(subscriptions, func(i, j int) bool { return subscriptions[i].ID < subscriptions[j].ID })
+
+	return &busTY.Stats{
+		Plugin:        PluginEmbedded,
+		Topics:        c.stats.list(),
+		Subscriptions: subscriptions,
+	}
+}
+
+// monitorSlowConsumers verifies the subscribers periodically
+func (c *Client) monitorSlowConsumers() {
+	ticker := time.NewTicker(c.config.slowConsumerThreshold / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stopCh:
+			return
+		case <-ticker.C:
+			c.mutex.RLock()
+			for _, sub := range c.subscriptions {
+				sub.checkSlow()
+			}
+			c.mutex.RUnlock()
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package embedded
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mycontroller-org/server/v2/pkg/types/cmap"
+	loggerUtils "github.com/mycontroller-org/server/v2/pkg/utils/logger"
+	busTY "github.com/mycontroller-org/server/v2/plugin/bus/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newTestClient(t *testing.T, config cmap.CustomMap) *Client {
+	ctx := loggerUtils.WithContext(context.Background(), zap.NewNop())
+	client, err := NewClient(ctx, config)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+	return client.(*Client)
+}
+
+// blockingHandler holds the deliveries till released
+type blockingHandler struct {
+	started  chan struct{}
+	release  chan struct{}
+	mutex    sync.Mutex
+	received []int
+}
+
+func newBlockingHandler() *blockingHandler {
+	return &blockingHandler{started: make(chan struct{}, 100), release: make(chan struct{})}
+}
+
+func (bh *blockingHandler) handle(data *busTY.BusData) {
+	bh.started <- struct{}{}
+	<-bh.release
+	value := 0
+	if err := data.LoadData(&value); err == nil {
+		bh.mutex.Lock()
+		bh.received = append(bh.received, value)
+		bh.mutex.Unlock()
+	}
+}
+
+func (bh *blockingHandler) getReceived() []int {
+	bh.mutex.Lock()
+	defer bh.mutex.Unlock()
+	return append([]int{}, bh.received...)
+}
+
+// publishes the first message and waits till it is taken by the worker
+func publishFirst(t *testing.T, client *Client, handler *blockingHandler) {
+	require.NoError(t, client.Publish("test", 0))
+	select {
+	case <-handler.started:
+	case <-time.After(2 * time.Second):
+		require.FailNow(t, "message not delivered")
+	}
+}
+
+func TestOverflowPolicy(t *testing.T) {
+	tests := []struct {
+		policy   string
+		received []int
+	}{
+		{policy: OverflowPolicyDropNewest, received: []int{0, 1, 2}},
+		{policy: OverflowPolicyDropOldest, received: []int{0, 3, 4}},
+	}
+	for _, test := range tests {
+		t.Run(test.policy, func(t *testing.T) {
+			client := newTestClient(t, cmap.CustomMap{"buffer_size": 2, "overflow_policy": test.policy})
+			handler := newBlockingHandler()
+			_, err := client.Subscribe("test", handler.handle)
+			require.NoError(t, err)
+
+			publishFirst(t, client, handler)
+			for value := 1; value < 5; value++ {
+				require.NoError(t, client.Publish("test", value))
+			}
+			close(handler.release)
+
+			require.Eventually(t, func() bool { return len(handler.getReceived()) == 3 }, 2*time.Second, 10*time.Millisecond)
+			assert.Equal(t, test.received, handler.getReceived())
+
+			stats := client.Stats()
+			require.Len(t, stats.Subscriptions, 1)
+			assert.Equal(t, uint64(2), stats.Subscriptions[0].Dropped)
+			require.Len(t, stats.Topics, 1)
+			assert.Equal(t, uint64(5), stats.Topics[0].Published)
+			assert.Equal(t, uint64(2), stats.Topics[0].Dropped)
+		})
+	}
+}
+
+func TestOverflowPolicyBlock(t *testing.T) {
+	client := newTestClient(t, cmap.CustomMap{"buffer_size": 1, "overflow_policy": OverflowPolicyBlock, "block_timeout": "50ms"})
+	handler := newBlockingHandler()
+	_, err := client.Subscribe("test", handler.handle)
+	require.NoError(t, err)
+
+	publishFirst(t, client, handler)
+	require.NoError(t, client.Publish("test", 1))
+
+	// dropped after the block timeout
+	start := time.Now()
+	require.NoError(t, client.Publish("test", 2))
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+	close(handler.release)
+
+	require.Eventually(t, func() bool { return len(handler.getReceived()) == 2 }, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []int{0, 1}, handler.getReceived())
+	assert.Equal(t, uint64(1), client.Stats().Subscriptions[0].Dropped)
+}
+
+func TestOverflowPolicyDefault(t *testing.T) {
+	// blocks without a limit, messages are not dropped
+	client := newTestClient(t, cmap.CustomMap{"buffer_size": 1})
+	assert.Equal(t, OverflowPolicyBlock, client.config.OverflowPolicy)
+	handler := newBlockingHandler()
+	_, err := client.Subscribe("test", handler.handle)
+	require.NoError(t, err)
+
+	publishFirst(t, client, handler)
+	require.NoError(t, client.Publish("test", 1))
+
+	published := make(chan struct{})
+	go func() {
+		_ = client.Publish("test", 2)
+		close(published)
+	}()
+
+	select {
+	case <-published:
+		require.FailNow(t, "publish not blocked")
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(handler.release)
+
+	select {
+	case <-published:
+	case <-time.After(2 * time.Second):
+		require.FailNow(t, "publish not released")
+	}
+	require.Eventually(t, func() bool { return len(handler.getReceived()) == 3 }, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []int{0, 1, 2}, handler.getReceived())
+	assert.Equal(t, uint64(0), client.Stats().Subscriptions[0].Dropped)
+}
+
+func TestSlowConsumer(t *testing.T) {
+	client := newTestClient(t, cmap.CustomMap{"slow_consumer_threshold": "50ms"})
+	handler := newBlockingHandler()
+	_, err := client.Subscribe("test", handler.handle)
+	require.NoError(t, err)
+
+	isSlow := func() bool { return client.Stats().Subscriptions[0].Slow }
+
+	publishFirst(t, client, handler)
+	require.Eventually(t, isSlow, 2*time.Second, 10*time.Millisecond)
+
+	close(handler.release)
+	require.Eventually(t, func() bool { return !isSlow() }, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestTopicStats(t *testing.T) {
+	client := newTestClient(t, cmap.CustomMap{"max_topic_stats": 3})
+
+	_, err := client.Subscribe("service", func(data *busTY.BusData) {
+		_ = client.Publish(data.ReplyTopic, "pong")
+	})
+	require.NoError(t, err)
+	subscriptionID, err := client.Subscribe("event.>", func(data *busTY.BusData) {})
+	require.NoError(t, err)
+
+	// reply topics are not included
+	reply, err := client.Request("service", "ping", time.Second)
+	require.NoError(t, err)
+	assert.True(t, busTY.IsReplyTopic(reply.Topic))
+
+	// topics without subscriber are not included
+	require.NoError(t, client.Publish("no_subscriber", 1))
+
+	// topics beyond the limit
+	for _, topic := range []string{"event.node", "event.field", "event.source", "event.gateway"} {
+		require.NoError(t, client.Publish(topic, 1))
+	}
+
+	topics := func() []string {
+		names := make([]string, 0)
+		for _, topicStats := range client.Stats().Topics {
+			names = append(names, topicStats.Topic)
+		}
+		return names
+	}
+	require.Eventually(t, func() bool { return len(topics()) == 4 }, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"event.field", "event.node", otherTopicsStats, "service"}, topics())
+	for _, topicStats := range client.Stats().Topics {
+		if topicStats.Topic == otherTopicsStats {
+			assert.Equal(t, uint64(2), topicStats.Published)
+		}
+	}
+
+	// removed along with the subscription
+	require.NoError(t, client.Unsubscribe("event.>", subscriptionID))
+	assert.Equal(t, []string{otherTopicsStats, "service"}, topics())
+	assert.Len(t, client.topics, 1)
+}
@@ -0,0 +1,107 @@
+package embedded
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	busTY "github.com/mycontroller-org/server/v2/plugin/bus/types"
+)
+
+// topicStats counters of a published topic
+type topicStats struct {
+	published    uint64
+	delivered    uint64
+	dropped      uint64
+	totalLatency time.Duration
+	maxLatency   time.Duration
+}
+
+// stats keeps the counters of the published topics.
+// topics are limited, the topics beyond the limit are counted together under the other topics
+type stats struct {
+	topics    map[string]*topicStats
+	maxTopics int
+	mutex     sync.Mutex
+}
+
+func newStats(maxTopics int) *stats {
+	return &stats{topics: make(map[string]*topicStats), maxTopics: maxTopics}
+}
+
+// returns the topic stats, caller should hold the lock
+func (s *stats) get(topic string) *topicStats {
+	ts, found := s.topics[topic]
+	if !found {
+		if len(s.topics) >= s.maxTopics {
+			topic = otherTopicsStats
+			if ts, found = s.topics[topic]; found {
+				return ts
+			}
+		}
+		ts = &topicStats{}
+		s.topics[topic] = ts
+	}
+	return ts
+}
+
+// retain removes the topics those are not matching the filter
+func (s *stats) retain(filter func(topic string) bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for topic := range s.topics {
+		if topic != otherTopicsStats && !filter(topic) {
+			delete(s.topics, topic)
+		}
+	}
+}
+
+func (s *stats) onPublish(topic string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.get(topic).published++
+}
+
+func (s *stats) onDeliver(topic string, latency time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	ts := s.get(topic)
+	ts.delivered++
+	ts.totalLatency += latency
+	if latency > ts.maxLatency {
+		ts.maxLatency = latency
+	}
+}
+
+func (s *stats) onDrop(topic string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.get(topic).dropped++
+}
+
+// list returns the stats of all the topics, sorted by topic
+func (s *stats) list() []busTY.TopicStats {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	items := make([]busTY.TopicStats, 0, len(s.topics))
+	for topic, ts := range s.topics {
+		item := busTY.TopicStats{
+			Topic:          topic,
+			Published:      ts.published,
+			Delivered:      ts.delivered,
+			Dropped:        ts.dropped,
+			MaximumLatency: toMilliseconds(ts.maxLatency),
+		}
+		if ts.delivered > 0 {
+			item.AverageLatency = toMilliseconds(ts.totalLatency / time.Duration(ts.delivered))
+		}
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Topic < items[j].Topic })
+	return items
+}
+
+func toMilliseconds(duration time.Duration) float64 {
+	return float64(duration.Microseconds()) / 1000
+}
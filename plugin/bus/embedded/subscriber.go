@@ -0,0 +1,174 @@
+package embedded
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	busTY "github.com/mycontroller-org/server/v2/plugin/bus/types"
+	"go.uber.org/zap"
+)
+
+// overflow policies, applied when the subscriber buffer is full
+const (
+	OverflowPolicyBlock      = "block"       // publisher waits till the buffer has space. if the block timeout is set, drops the message on timeout
+	OverflowPolicyDropOldest = "drop_oldest" // removes the oldest message from the buffer
+	OverflowPolicyDropNewest = "drop_newest" // drops the message being published
+)
+
+// delivery holds a message and the publish time
+type delivery struct {
+	data        *busTY.BusData
+	publishedAt time.Time
+	withStats   bool // included in the topic stats
+}
+
+// subscriber delivers the messages from the buffer to the call back func
+type subscriber struct {
+	id              int64
+	topic           string
+	callBack        busTY.CallBackFunc
+	items           chan *delivery
+	stopCh          chan struct{}
+	stopOnce        sync.Once
+	config          *Config
+	stats           *stats
+	logger          *zap.Logger
+	delivered       atomic.Uint64
+	dropped         atomic.Uint64
+	processingSince atomic.Int64 // unix nano time of the delivery in progress, zero if idle
+	slow            atomic.Bool
+	lastDropLog     atomic.Int64
+}
+
+func newSubscriber(id int64, topic string, callBack busTY.CallBackFunc, config *Config, stats *stats, logger *zap.Logger) *subscriber {
+	sub := &subscriber{
+		id:       id,
+		topic:    topic,
+		callBack: callBack,
+		items:    make(chan *delivery, config.BufferSize),
+		stopCh:   make(chan struct{}),
+		config:   config,
+		stats:    stats,
+		logger:   logger,
+	}
+	for index := 0; index < config.Workers; index++ {
+		go sub.run()
+	}
+	return sub
+}
+
+// add a message into the buffer, overflow policy applied if the buffer is full
+func (s *subscriber) add(item *delivery) {
+	select {
+	case <-s.stopCh:
+		return
+	case s.items <- item:
+		return
+	default:
+	}
+
+	// buffer is full
+	switch s.config.OverflowPolicy {
+	case OverflowPolicyDropOldest:
+		// a message is removed only if the buffer is still full, workers may take the messages meanwhile
+		for {
+			select {
+			case <-s.stopCh:
+				return
+			case s.items <- item:
+				return
+			default:
+			}
+			select {
+			case oldest := <-s.items:
+				s.drop(oldest)
+			default:
+			}
+		}
+
+	case OverflowPolicyDropNewest:
+		s.drop(item)
+
+	default: // block
+		if s.config.blockTimeout <= 0 {
+			select {
+			case <-s.stopCh:
+			case s.items <- item:
+			}
+			return
+		}
+		timer := time.NewTimer(s.config.blockTimeout)
+		defer timer.Stop()
+		select {
+		case <-s.stopCh:
+		case s.items <- item:
+		case <-timer.C:
+			s.drop(item)
+		}
+	}
+}
+
+func (s *subscriber) drop(item *delivery) {
+	s.dropped.Add(1)
+	if item.withStats {
+		s.stats.onDrop(item.data.Topic)
+	}
+
+	// log once in a slow consumer threshold duration
+	now := time.Now()
+	lastLog := s.lastDropLog.Load()
+	if now.UnixNano()-lastLog > s.config.slowConsumerThreshold.Nanoseconds() && s.lastDropLog.CompareAndSwap(lastLog, now.UnixNano()) {
+		s.logger.Warn("subscriber buffer is full, message dropped", zap.Int64("subscriptionId", s.id), zap.String("subscriptionTopic", s.topic),
+			zap.String("topic", item.data.Topic), zap.String("overflowPolicy", s.config.OverflowPolicy), zap.Uint64("dropped", s.dropped.Load()))
+	}
+}
+
+func (s *subscriber) run() {
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		case item := <-s.items:
+			s.processingSince.Store(time.Now().UnixNano())
+			s.callBack(item.data)
+			s.processingSince.Store(0)
+			s.delivered.Add(1)
+			if item.withStats {
+				s.stats.onDeliver(item.data.Topic, time.Since(item.publishedAt))
+			}
+		}
+	}
+}
+
+// checkSlow marks the subscriber as slow, if a delivery is in progress beyond the threshold
+func (s *subscriber) checkSlow() {
+	since := s.processingSince.Load()
+	isSlow := since != 0 && time.Since(time.Unix(0, since)) > s.config.slowConsumerThreshold
+	if isSlow && !s.slow.Load() {
+		s.logger.Warn("slow consumer detected", zap.Int64("subscriptionId", s.id), zap.String("subscriptionTopic", s.topic),
+			zap.String("processingSince", time.Since(time.Unix(0, since)).String()), zap.Int("pending", len(s.items)))
+	} else if !isSlow && s.slow.Load() {
+		s.logger.Info("slow consumer recovered", zap.Int64("subscriptionId", s.id), zap.String("subscriptionTopic", s.topic))
+	}
+	s.slow.Store(isSlow)
+}
+
+// stop the workers, pending messages are discarded
+func (s *subscriber) stop() {
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+	})
+}
+
+func (s *subscriber) getStats() busTY.SubscriptionStats {
+	return busTY.SubscriptionStats{
+		ID:        s.id,
+		Topic:     s.topic,
+		Pending:   len(s.items),
+		Capacity:  cap(s.items),
+		Delivered: s.delivered.Load(),
+		Dropped:   s.dropped.Load(),
+		Slow:      s.slow.Load(),
+	}
+}
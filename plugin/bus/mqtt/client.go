@@ -0,0 +1,546 @@
+package mqtt
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/mycontroller-org/server/v2/pkg/json"
+	"github.com/mycontroller-org/server/v2/pkg/types/cmap"
+	"github.com/mycontroller-org/server/v2/pkg/utils"
+	"github.com/mycontroller-org/server/v2/pkg/utils/concurrency"
+	loggerUtils "github.com/mycontroller-org/server/v2/pkg/utils/logger"
+	busTY "github.com/mycontroller-org/server/v2/plugin/bus/types"
+	"go.uber.org/zap"
+)
+
+const (
+	PluginMQTT = "mqtt"
+
+	loggerName               = "BUS:MQTT"
+	defaultBroker            = "tcp://127.0.0.1:1883"
+	defaultQoS               = 1
+	defaultReconnectWait     = 5 * time.Second
+	defaultConnectionTimeout = 10 * time.Second
+	defaultSubscribeTimeout  = 10 * time.Second
+
+	sharedSubscriptionPrefix = "$share"
+)
+
+// requestEnvelopePrefix identifies a request on the received payload
+var requestEnvelopePrefix = []byte(`{"mcReplyTopic":`)
+
+// requestEnvelope carries the reply topic along with the data, MQTT 3.1.1 has no message properties
+type requestEnvelope struct {
+	ReplyTopic string `json:"mcReplyTopic"`
+	Data       []byte `json:"mcData"`
+}
+
+// Config details of the client
+type Config struct {
+	Type              string `yaml:"type"`
+	Broker            string `yaml:"broker"` // tcp://, ssl://, ws:// or wss://
+	Username          string `yaml:"username"`
+	Password          string `yaml:"password"`
+	ClientID          string `yaml:"client_id"` // random id used if empty
+	QoS               int    `yaml:"qos"`
+	Insecure          bool   `yaml:"insecure"`
+	CAFile            string `yaml:"ca_file"`
+	CertFile          string `yaml:"cert_file"`
+	KeyFile           string `yaml:"key_file"`
+	ConnectionTimeout string `yaml:"connection_timeout"`
+	ReconnectWait     string `yaml:"reconnect_wait"`
+	TopicPrefix       string `yaml:"topic_prefix"`
+}
+
+// subscription details of a topic filter
+// MQTT 3.1.1 publish packet has no subscription identifier, a message matching more than one
+// subscription of a connection can not be associated with a subscription.
+// hence a shared subscription gets a dedicated connection, otherwise a message received via
+// a regular subscription would be delivered to the shared subscription too
+type subscription struct {
+	filter   string      // mqtt topic filter, includes the shared subscription prefix
+	client   paho.Client // dedicated connection of a shared subscription, nil on regular subscriptions
+	handlers map[int64]busTY.CallBackFunc
+	next     int // round robin index, used on shared subscriptions
+}
+
+// Client struct
+type Client struct {
+	client              paho.Client
+	subscriptions       map[string]*subscription // mqtt topic filter => subscription
+	subscriptionCounter int64
+	mutex               *sync.RWMutex
+	config              *Config
+	pauseFlag           concurrency.SafeBool
+	logger              *zap.Logger
+}
+
+// NewClient mqtt client
+func NewClient(ctx context.Context, config cmap.CustomMap) (busTY.Plugin, error) {
+	logger, err := loggerUtils.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &Config{}
+	err = utils.MapToStruct(utils.TagNameYaml, config, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// set default values, if non set
+	if cfg.Broker == "" {
+		cfg.Broker = defaultBroker
+	}
+	if cfg.QoS == 0 {
+		cfg.QoS = defaultQoS
+	}
+	if cfg.ClientID == "" {
+		cfg.ClientID = fmt.Sprintf("mycontroller-%s", utils.RandIDWithLength(8))
+	}
+
+	client := &Client{
+		subscriptions:       make(map[string]*subscription),
+		subscriptionCounter: 0,
+		mutex:               &sync.RWMutex{},
+		config:              cfg,
+		pauseFlag:           concurrency.SafeBool{},
+		logger:              logger.Named(loggerName),
+	}
+
+	client.client, err = client.connect(cfg.ClientID)
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// connect creates a connection to the broker
+func (c *Client) connect(clientID string) (paho.Client, error) {
+	tlsConfig, err := c.config.getTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	opts := paho.NewClientOptions()
+	opts.AddBroker(c.config.Broker)
+	opts.SetUsername(c.config.Username)
+	opts.SetPassword(c.config.Password)
+	opts.SetClientID(clientID)
+	opts.SetCleanSession(true)
+	opts.SetAutoReconnect(true)
+	opts.SetConnectTimeout(utils.ToDuration(c.config.ConnectionTimeout, defaultConnectionTimeout))
+	opts.SetMaxReconnectInterval(utils.ToDuration(c.config.ReconnectWait, defaultReconnectWait))
+	opts.SetTLSConfig(tlsConfig)
+	opts.SetOrderMatters(false)
+	opts.SetDefaultPublishHandler(c.onUnknownMessage)
+	opts.SetOnConnectHandler(c.onConnect)
+	opts.SetConnectionLostHandler(c.onConnectionLost)
+
+	client := paho.NewClient(opts)
+	token := client.Connect()
+	if !token.WaitTimeout(utils.ToDuration(c.config.ConnectionTimeout, defaultConnectionTimeout)) {
+		client.Disconnect(0)
+		return nil, fmt.Errorf("timeout on connecting to the broker:%s", c.config.Broker)
+	}
+	if token.Error() != nil {
+		return nil, token.Error()
+	}
+	return client, nil
+}
+
+func (c *Client) Name() string {
+	return PluginMQTT
+}
+
+// Close implementation
+func (c *Client) Close() error {
+	c.mutex.Lock()
+	for _, sub := range c.subscriptions {
+		if sub.client != nil {
+			sub.client.Disconnect(250)
+		}
+	}
+	c.mutex.Unlock()
+
+	if c.client != nil && c.client.IsConnected() {
+		c.client.Disconnect(250)
+	}
+	return nil
+}
+
+func (c *Client) TopicPrefix() string {
+	return c.config.TopicPrefix
+}
+
+func (c *Client) PausePublish() {
+	c.pauseFlag.Set()
+}
+
+func (c *Client) ResumePublish() {
+	c.pauseFlag.Reset()
+}
+
+// Publish a data to a topic
+func (c *Client) Publish(topic string, data interface{}) error {
+	if c.pauseFlag.IsSet() {
+		return nil
+	}
+
+	// format topic with prefix
+	mqttTopic := toMqttTopic(c.formatTopic(topic))
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	c.logger.Debug("posting message", zap.String("topic", mqttTopic))
+	token := c.client.Publish(mqttTopic, byte(c.config.QoS), false, payload)
+	return token.Error()
+}
+
+// Request publishes a data to a topic and returns the first reply received within the timeout
+func (c *Client) Request(topic string, data interface{}, timeout time.Duration) (*busTY.BusData, error) {
+	if c.pauseFlag.IsSet() {
+		return nil, busTY.ErrPublishPaused
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	replies := make(chan *busTY.BusData, 1)
+	replyTopic := busTY.NewReplyTopic()
+	subscriptionID, err := c.Subscribe(replyTopic, func(reply *busTY.BusData) {
+		select {
+		case replies <- reply:
+		default: // reply already received
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err := c.Unsubscribe(replyTopic, subscriptionID)
+		if err != nil {
+			c.logger.Error("error on unsubscribe", zap.String("topic", replyTopic), zap.Error(err))
+		}
+	}()
+
+	envelope, err := json.Marshal(&requestEnvelope{ReplyTopic: replyTopic, Data: payload})
+	if err != nil {
+		return nil, err
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	mqttTopic := toMqttTopic(c.formatTopic(topic))
+	c.logger.Debug("posting request", zap.String("topic", mqttTopic), zap.String("replyTopic", replyTopic))
+	token := c.client.Publish(mqttTopic, byte(c.config.QoS), false, envelope)
+	select {
+	case <-token.Done():
+	case <-timer.C:
+		return nil, busTY.NewRequestTimeoutError(topic, timeout)
+	}
+	if token.Error() != nil {
+		return nil, token.Error()
+	}
+
+	select {
+	case reply := <-replies:
+		return reply, nil
+	case <-timer.C:
+		return nil, busTY.NewRequestTimeoutError(topic, timeout)
+	}
+}
+
+// Subscribe a topic
+func (c *Client) Subscribe(topic string, handler busTY.CallBackFunc) (int64, error) {
+	return c.QueueSubscribe(topic, "", handler)
+}
+
+// QueueSubscribe a topic with queue name, uses shared subscription
+func (c *Client) QueueSubscribe(topic, queueName string, handler busTY.CallBackFunc) (int64, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	filter := c.getFilter(topic, queueName)
+
+	sub, found := c.subscriptions[filter]
+	if !found {
+		sub = &subscription{filter: filter, handlers: make(map[int64]busTY.CallBackFunc)}
+		// shared subscription on a dedicated connection
+		if queueName != "" {
+			client, err := c.connect(fmt.Sprintf("%s-%s", c.config.ClientID, utils.RandIDWithLength(8)))
+			if err != nil {
+				return -1, err
+			}
+			sub.client = client
+		}
+		err := c.subscribe(sub)
+		if err != nil {
+			if sub.client != nil {
+				sub.client.Disconnect(0)
+			}
+			return -1, err
+		}
+		c.subscriptions[filter] = sub
+	}
+
+	newSubscriptionID := c.generateSubscriptionID()
+	sub.handlers[newSubscriptionID] = handler
+	c.logger.Debug("subscription created", zap.String("filter", filter), zap.String("queueName", queueName), zap.Int64("subscriptionId", newSubscriptionID))
+	return newSubscriptionID, nil
+}
+
+// Unsubscribe a topic
+func (c *Client) Unsubscribe(topic string, subscriptionID int64) error {
+	return c.QueueUnsubscribe(topic, "", subscriptionID)
+}
+
+// QueueUnsubscribe a topic
+func (c *Client) QueueUnsubscribe(topic, queueName string, subscriptionID int64) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	filter := c.getFilter(topic, queueName)
+	sub, found := c.subscriptions[filter]
+	if !found {
+		return nil
+	}
+
+	delete(sub.handlers, subscriptionID)
+	c.logger.Debug("subscription removed", zap.String("filter", filter), zap.String("queueName", queueName), zap.Int64("subscriptionId", subscriptionID))
+	if len(sub.handlers) > 0 {
+		return nil
+	}
+
+	// no more handlers, remove the subscription from the broker
+	delete(c.subscriptions, filter)
+	return c.unsubscribe(sub)
+}
+
+// UnsubscribeAll removes all the subscriptions of the topic, includes queue subscriptions
+func (c *Client) UnsubscribeAll(topic string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	mqttTopic := toMqttTopic(c.formatTopic(topic))
+	for filter, sub := range c.subscriptions {
+		if stripSharedPrefix(filter) != mqttTopic {
+			continue
+		}
+		delete(c.subscriptions, filter)
+		err := c.unsubscribe(sub)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getMessageHandler returns the paho callback of a subscription
+// the received message is delivered only to the handlers of the subscription
+func (c *Client) getMessageHandler(filter string) paho.MessageHandler {
+	return func(_ paho.Client, message paho.Message) {
+		c.onMessage(filter, message)
+	}
+}
+
+// onMessage dispatches the received message to the handlers of the subscription
+func (c *Client) onMessage(filter string, message paho.Message) {
+	c.logger.Debug("receiving message", zap.String("topic", message.Topic()), zap.String("filter", filter))
+
+	c.mutex.Lock()
+	handlers := make([]busTY.CallBackFunc, 0)
+	if sub, found := c.subscriptions[filter]; found && len(sub.handlers) > 0 {
+		if isSharedFilter(sub.filter) {
+			// message delivered to one of the handlers on the queue
+			handlers = append(handlers, sub.getNextHandler())
+		} else {
+			for _, handler := range sub.handlers {
+				handlers = append(handlers, handler)
+			}
+		}
+	}
+	c.mutex.Unlock()
+
+	topic := fromMqttTopic(message.Topic())
+	payload := message.Payload()
+	replyTopic := ""
+	if bytes.HasPrefix(payload, requestEnvelopePrefix) {
+		envelope := &requestEnvelope{}
+		err := json.Unmarshal(payload, envelope)
+		if err != nil {
+			c.logger.Error("error on parsing request", zap.String("topic", topic), zap.Error(err))
+			return
+		}
+		payload = envelope.Data
+		replyTopic = envelope.ReplyTopic
+	}
+
+	for _, handler := range handlers {
+		handler(&busTY.BusData{Topic: topic, Data: payload, ReplyTopic: replyTopic})
+	}
+}
+
+// onUnknownMessage receives the messages not matching any of the subscriptions
+func (c *Client) onUnknownMessage(_ paho.Client, message paho.Message) {
+	c.logger.Debug("message without subscription", zap.String("topic", message.Topic()))
+}
+
+// onConnect subscribes the topics of the connection again, session is not kept on the broker
+func (c *Client) onConnect(client paho.Client) {
+	c.logger.Info("connected", zap.String("broker", c.config.Broker))
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	for filter, sub := range c.subscriptions {
+		if c.getClient(sub) != client {
+			continue
+		}
+		err := c.subscribe(sub)
+		if err != nil {
+			c.logger.Error("error on subscription", zap.String("filter", filter), zap.Error(err))
+		}
+	}
+}
+
+func (c *Client) onConnectionLost(_ paho.Client, err error) {
+	c.logger.Error("connection lost", zap.String("broker", c.config.Broker), zap.Error(err))
+}
+
+// returns the connection of the subscription
+func (c *Client) getClient(sub *subscription) paho.Client {
+	if sub.client != nil {
+		return sub.client
+	}
+	return c.client
+}
+
+// subscribes on the broker, messages are received on the subscription callback
+func (c *Client) subscribe(sub *subscription) error {
+	token := c.getClient(sub).Subscribe(sub.filter, byte(c.config.QoS), c.getMessageHandler(sub.filter))
+	if !token.WaitTimeout(defaultSubscribeTimeout) {
+		return fmt.Errorf("timeout on subscription:%s", sub.filter)
+	}
+	return token.Error()
+}
+
+// unsubscribes on the broker, the dedicated connection of a shared subscription is closed
+func (c *Client) unsubscribe(sub *subscription) error {
+	if sub.client != nil {
+		sub.client.Disconnect(250)
+		return nil
+	}
+	token := c.client.Unsubscribe(sub.filter)
+	if !token.WaitTimeout(defaultSubscribeTimeout) {
+		return fmt.Errorf("timeout on unsubscription:%s", sub.filter)
+	}
+	return token.Error()
+}
+
+func (c *Client) generateSubscriptionID() int64 {
+	// increment counter id
+	c.subscriptionCounter++
+	return c.subscriptionCounter
+}
+
+// returns mqtt topic filter, queue name used as share name
+func (c *Client) getFilter(topic, queueName string) string {
+	filter := toMqttTopic(c.formatTopic(topic))
+	if queueName != "" {
+		return fmt.Sprintf("%s/%s/%s", sharedSubscriptionPrefix, queueName, filter)
+	}
+	return filter
+}
+
+func (c *Client) formatTopic(topic string) string {
+	if c.config.TopicPrefix != "" {
+		return fmt.Sprintf("%s.%s", c.config.TopicPrefix, topic)
+	}
+	return topic
+}
+
+// returns the handlers in round robin
+func (s *subscription) getNextHandler() busTY.CallBackFunc {
+	ids := make([]int64, 0, len(s.handlers))
+	for id := range s.handlers {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	s.next = (s.next + 1) % len(ids)
+	return s.handlers[ids[s.next]]
+}
+
+// returns tls config with the client certificate and CA, if supplied
+func (cfg *Config) getTLSConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: cfg.Insecure}
+
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		certificate, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("error on loading client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	if cfg.CAFile != "" {
+		caPEM, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, errors.New("no valid certificate found on the CA")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return tlsConfig, nil
+}
+
+// toMqttTopic converts bus topic to mqtt topic, "." => "/", "*" => "+" and ">" => "#"
+func toMqttTopic(topic string) string {
+	tokens := strings.Split(topic, ".")
+	for index, token := range tokens {
+		switch token {
+		case "*":
+			tokens[index] = "+"
+		case ">":
+			tokens[index] = "#"
+		}
+	}
+	return strings.Join(tokens, "/")
+}
+
+// fromMqttTopic converts mqtt topic to bus topic
+func fromMqttTopic(topic string) string {
+	return strings.ReplaceAll(topic, "/", ".")
+}
+
+func isSharedFilter(filter string) bool {
+	return strings.HasPrefix(filter, sharedSubscriptionPrefix+"/")
+}
+
+// removes "$share/<queue>/" from the filter
+func stripSharedPrefix(filter string) string {
+	if !isSharedFilter(filter) {
+		return filter
+	}
+	tokens := strings.SplitN(filter, "/", 3)
+	if len(tokens) != 3 {
+		return filter
+	}
+	return tokens[2]
+}
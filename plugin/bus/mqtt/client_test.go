@@ -0,0 +1,220 @@
+package mqtt
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	mochi "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/hooks/auth"
+	"github.com/mochi-mqtt/server/v2/listeners"
+	"github.com/mycontroller-org/server/v2/pkg/types/cmap"
+	loggerUtils "github.com/mycontroller-org/server/v2/pkg/utils/logger"
+	busTY "github.com/mycontroller-org/server/v2/plugin/bus/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const (
+	testUsername = "mycontroller"
+	testPassword = "secret"
+	testTimeout  = 3 * time.Second
+)
+
+// startBroker starts an embedded broker and returns the address
+func startBroker(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	server := mochi.New(&mochi.Options{InlineClient: false})
+	err = server.AddHook(new(auth.Hook), &auth.Options{
+		Ledger: &auth.Ledger{
+			Auth: auth.AuthRules{{Username: testUsername, Password: testPassword, Allow: true}},
+			ACL:  auth.ACLRules{{}},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, server.AddListener(listeners.NewTCP(listeners.Config{ID: "test", Address: address})))
+	go func() {
+		_ = server.Serve()
+	}()
+	t.Cleanup(func() { _ = server.Close() })
+	return fmt.Sprintf("tcp://%s", address)
+}
+
+func newTestClient(t *testing.T, broker string, config cmap.CustomMap) busTY.Plugin {
+	ctx := loggerUtils.WithContext(context.Background(), zap.NewNop())
+	cfg := cmap.CustomMap{
+		"broker":       broker,
+		"username":     testUsername,
+		"password":     testPassword,
+		"topic_prefix": "mc_test",
+	}
+	for key, value := range config {
+		cfg[key] = value
+	}
+	client, err := NewClient(ctx, cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+// receive returns the received bus data or nil on timeout
+func receive(received chan *busTY.BusData) *busTY.BusData {
+	select {
+	case data := <-received:
+		return data
+	case <-time.After(testTimeout):
+		return nil
+	}
+}
+
+func TestTopicConversion(t *testing.T) {
+	assert.Equal(t, "mc/event/#", toMqttTopic("mc.event.>"))
+	assert.Equal(t, "message/to_provider/+", toMqttTopic("message.to_provider.*"))
+	assert.Equal(t, "mc.message.to_provider.gw1", fromMqttTopic("mc/message/to_provider/gw1"))
+	assert.Equal(t, "event/#", stripSharedPrefix("$share/group1/event/#"))
+}
+
+func TestPublishSubscribe(t *testing.T) {
+	broker := startBroker(t)
+	client := newTestClient(t, broker, nil)
+
+	received := make(chan *busTY.BusData, 10)
+	subscriptionID, err := client.Subscribe("event.>", func(data *busTY.BusData) { received <- data })
+	require.NoError(t, err)
+
+	require.NoError(t, client.Publish("event.field", map[string]string{"id": "f1"}))
+	data := receive(received)
+	require.NotNil(t, data)
+	assert.Equal(t, "mc_test.event.field", data.Topic)
+	out := map[string]string{}
+	require.NoError(t, data.LoadData(&out))
+	assert.Equal(t, "f1", out["id"])
+
+	// no messages after unsubscribe
+	require.NoError(t, client.Unsubscribe("event.>", subscriptionID))
+	require.NoError(t, client.Publish("event.field", map[string]string{"id": "f2"}))
+	assert.Nil(t, receive(received))
+}
+
+func TestQueueSubscribe(t *testing.T) {
+	broker := startBroker(t)
+	publisher := newTestClient(t, broker, nil)
+	consumer1 := newTestClient(t, broker, nil)
+	consumer2 := newTestClient(t, broker, nil)
+
+	count := atomic.Int32{}
+	handler := func(data *busTY.BusData) { count.Add(1) }
+	_, err := consumer1.QueueSubscribe("message.to_message_processor", "processor", handler)
+	require.NoError(t, err)
+	_, err = consumer2.QueueSubscribe("message.to_message_processor", "processor", handler)
+	require.NoError(t, err)
+
+	messages := 10
+	for index := 0; index < messages; index++ {
+		require.NoError(t, publisher.Publish("message.to_message_processor", index))
+	}
+
+	// each message delivered to one of the consumers
+	assert.Eventually(t, func() bool { return count.Load() == int32(messages) }, testTimeout, 50*time.Millisecond)
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, int32(messages), count.Load())
+}
+
+func TestOverlappingSubscriptions(t *testing.T) {
+	broker := startBroker(t)
+	client := newTestClient(t, broker, nil)
+
+	allEvents := atomic.Int32{}
+	nodeEvents := atomic.Int32{}
+	_, err := client.Subscribe("event.>", func(data *busTY.BusData) { allEvents.Add(1) })
+	require.NoError(t, err)
+	_, err = client.Subscribe("event.node", func(data *busTY.BusData) { nodeEvents.Add(1) })
+	require.NoError(t, err)
+
+	require.NoError(t, client.Publish("event.node", "n1"))
+	require.NoError(t, client.Publish("event.field", "f1"))
+
+	// each subscription receives the message once
+	assert.Eventually(t, func() bool { return allEvents.Load() == 2 && nodeEvents.Load() == 1 }, testTimeout, 50*time.Millisecond)
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, int32(2), allEvents.Load())
+	assert.Equal(t, int32(1), nodeEvents.Load())
+}
+
+// MQTT 3.1.1 publish has no subscription identifier, a shared subscription
+// on the connection of a regular subscription would receive the copies of the regular subscription
+func TestQueueSubscribeWithRegularSubscription(t *testing.T) {
+	broker := startBroker(t)
+	publisher := newTestClient(t, broker, nil)
+	consumer1 := newTestClient(t, broker, nil)
+	consumer2 := newTestClient(t, broker, nil)
+
+	all := atomic.Int32{}
+	queue := atomic.Int32{}
+	_, err := consumer1.Subscribe("message.>", func(data *busTY.BusData) { all.Add(1) })
+	require.NoError(t, err)
+	subscriptionID, err := consumer1.QueueSubscribe("message.to_message_processor", "processor", func(data *busTY.BusData) { queue.Add(1) })
+	require.NoError(t, err)
+	_, err = consumer2.QueueSubscribe("message.to_message_processor", "processor", func(data *busTY.BusData) { queue.Add(1) })
+	require.NoError(t, err)
+
+	messages := 10
+	for index := 0; index < messages; index++ {
+		require.NoError(t, publisher.Publish("message.to_message_processor", index))
+	}
+
+	// regular subscription receives all the messages, queue members receive each message once
+	assert.Eventually(t, func() bool { return all.Load() == int32(messages) && queue.Load() == int32(messages) }, testTimeout, 50*time.Millisecond)
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, int32(messages), all.Load())
+	assert.Equal(t, int32(messages), queue.Load())
+
+	// dedicated connection closed along with the last handler
+	mqttClient := consumer1.(*Client)
+	mqttClient.mutex.RLock()
+	sub := mqttClient.subscriptions[mqttClient.getFilter("message.to_message_processor", "processor")]
+	mqttClient.mutex.RUnlock()
+	require.NotNil(t, sub)
+	require.NotNil(t, sub.client)
+	require.NoError(t, consumer1.QueueUnsubscribe("message.to_message_processor", "processor", subscriptionID))
+	assert.False(t, sub.client.IsConnected())
+}
+
+func TestRequest(t *testing.T) {
+	broker := startBroker(t)
+	requester := newTestClient(t, broker, nil)
+	responder := newTestClient(t, broker, nil)
+
+	_, err := responder.QueueSubscribe("service.resource_server", "resource", func(data *busTY.BusData) {
+		request := map[string]string{}
+		require.NoError(t, data.LoadData(&request))
+		require.NotEmpty(t, data.ReplyTopic)
+		assert.NoError(t, responder.Publish(data.ReplyTopic, map[string]string{"reply": request["id"]}))
+	})
+	require.NoError(t, err)
+
+	reply, err := requester.Request("service.resource_server", map[string]string{"id": "n1"}, testTimeout)
+	require.NoError(t, err)
+	out := map[string]string{}
+	require.NoError(t, reply.LoadData(&out))
+	assert.Equal(t, "n1", out["reply"])
+
+	// no responder
+	_, err = requester.Request("service.unknown", map[string]string{"id": "n1"}, 200*time.Millisecond)
+	assert.ErrorIs(t, err, busTY.ErrRequestTimeout)
+}
+
+func TestAuthentication(t *testing.T) {
+	broker := startBroker(t)
+	ctx := loggerUtils.WithContext(context.Background(), zap.NewNop())
+	_, err := NewClient(ctx, cmap.CustomMap{"broker": broker, "username": testUsername, "password": "invalid", "connection_timeout": "2s"})
+	assert.Error(t, err)
+}
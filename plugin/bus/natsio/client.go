@@ -16,6 +16,7 @@ import (
 	loggerUtils "github.com/mycontroller-org/server/v2/pkg/utils/logger"
 	busTY "github.com/mycontroller-org/server/v2/plugin/bus/types"
 	natsIO "github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
 	"go.uber.org/zap"
 )
 
@@ -27,12 +28,16 @@ const (
 	defaultConnectionTimeout = 10 * time.Second
 	defaultMaximumReconnect  = 60
 	defaultBufferSize        = 4194304 // 4MB
+
+	// reply topic carried on the header, as the reply subject is used by jetstream for the acknowledgement
+	headerReplyTopic = "Mc-Reply-Topic"
 )
 
 // Config details of the client
 type Config struct {
 	Type                 string            `yaml:"type"`
 	ServerURL            string            `yaml:"server_url"`
+	ServerURLs           []string          `yaml:"server_urls"` // cluster servers, used along with server_url
 	Token                string            `yaml:"token"`
 	Username             string            `yaml:"username"`
 	Password             string            `yaml:"password"`
@@ -44,6 +49,7 @@ type Config struct {
 	ReconnectWait        string            `yaml:"reconnect_wait"`
 	WebsocketOptions     *WebsocketOptions `yaml:"websocket_options"`
 	TopicPrefix          string            `yaml:"topic_prefix"`
+	JetStream            *JetStreamConfig  `yaml:"jetstream"`
 }
 
 // WebsocketOptions are config options for a websocket dialer
@@ -58,12 +64,19 @@ type Client struct {
 	ctx                 context.Context
 	natConn             *natsIO.Conn
 	topics              map[string][]int64
-	subscriptions       map[int64]*natsIO.Subscription
+	subscriptions       map[int64]subscription
 	subscriptionCounter int64
 	mutex               *sync.RWMutex
 	config              *Config
 	pauseFlag           concurrency.SafeBool
 	logger              *zap.Logger
+	jetStream           jetstream.JetStream
+	streamSubjects      []string
+}
+
+// subscription of core nats or jetstream
+type subscription interface {
+	Unsubscribe() error
 }
 
 // NewClient nats.io client
@@ -86,22 +99,26 @@ func NewClient(ctx context.Context, config cmap.CustomMap) (busTY.Plugin, error)
 	if cfg.MaximumReconnect == 0 {
 		cfg.MaximumReconnect = defaultMaximumReconnect
 	}
-	if cfg.ServerURL == "" {
+	if cfg.ServerURL == "" && len(cfg.ServerURLs) == 0 {
 		cfg.ServerURL = natsIO.DefaultURL
 	}
 
 	// we handle tls with our custom dialer
 	// say we are using "nats" protocol to nats.io client
-	fakeServerURI, err := url.Parse(cfg.ServerURL)
-	if err != nil {
-		return nil, err
+	fakeServers := make([]string, 0)
+	for _, serverURL := range cfg.getServerURLs() {
+		fakeServerURI, err := url.Parse(serverURL)
+		if err != nil {
+			return nil, err
+		}
+		fakeServerURI.Scheme = "nats"
+		fakeServers = append(fakeServers, fakeServerURI.String())
 	}
-	fakeServerURI.Scheme = "nats"
 
 	client := Client{
 		ctx:                 context.TODO(),
 		topics:              make(map[string][]int64),
-		subscriptions:       make(map[int64]*natsIO.Subscription),
+		subscriptions:       make(map[int64]subscription),
 		subscriptionCounter: 0,
 		config:              cfg,
 		mutex:               &sync.RWMutex{},
@@ -110,7 +127,8 @@ func NewClient(ctx context.Context, config cmap.CustomMap) (busTY.Plugin, error)
 	}
 
 	opts := natsIO.Options{
-		Url:                         fakeServerURI.String(),
+		Url:                         fakeServers[0],
+		Servers:                     fakeServers,
 		Secure:                      false, // will be handled by our custom dialer
 		Verbose:                     true,
 		RetryOnFailedConnect:        cfg.RetryOnFailedConnect,
@@ -148,6 +166,14 @@ func NewClient(ctx context.Context, config cmap.CustomMap) (busTY.Plugin, error)
 		return nil, err
 	}
 	client.natConn = nc
+
+	if cfg.JetStream != nil && cfg.JetStream.Enabled {
+		err = client.initJetStream(nc)
+		if err != nil {
+			nc.Close()
+			return nil, err
+		}
+	}
 	return &client, nil
 }
 
@@ -192,6 +218,48 @@ func (c *Client) Publish(topic string, data interface{}) error {
 	return c.natConn.Publish(topic, bytes)
 }
 
+// Request publishes a data to a topic and returns the first reply received within the timeout
+func (c *Client) Request(topic string, data interface{}, timeout time.Duration) (*busTY.BusData, error) {
+	if c.pauseFlag.IsSet() {
+		return nil, busTY.ErrPublishPaused
+	}
+
+	bytes, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	replyTopic := busTY.NewReplyTopic()
+	replySubscription, err := c.natConn.SubscribeSync(c.formatTopic(replyTopic))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err := replySubscription.Unsubscribe()
+		if err != nil {
+			c.logger.Error("error on unsubscribe", zap.String("topic", replyTopic), zap.Error(err))
+		}
+	}()
+
+	msg := natsIO.NewMsg(c.formatTopic(topic))
+	msg.Data = bytes
+	msg.Header.Set(headerReplyTopic, replyTopic)
+	c.logger.Debug("posting request", zap.String("topic", msg.Subject), zap.String("replyTopic", replyTopic))
+	err = c.natConn.PublishMsg(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := replySubscription.NextMsg(timeout)
+	if err != nil {
+		if errors.Is(err, natsIO.ErrTimeout) {
+			return nil, busTY.NewRequestTimeoutError(topic, timeout)
+		}
+		return nil, err
+	}
+	return &busTY.BusData{Topic: reply.Subject, Data: reply.Data}, nil
+}
+
 // Subscribe a topic
 func (c *Client) Subscribe(topic string, handler busTY.CallBackFunc) (int64, error) {
 	return c.QueueSubscribe(topic, "", handler)
@@ -215,8 +283,14 @@ func (c *Client) QueueSubscribe(topic, queueName string, handler busTY.CallBackF
 	newSubscriptionID := c.generateSubscriptionID()
 	wrappedHandler := c.handlerWrapper(handler)
 
-	var subscription *natsIO.Subscription
-	if queueName != "" {
+	var subscription subscription
+	if c.isStreamTopic(topic) {
+		jetStreamSubscription, err := c.jetStreamSubscribe(topic, queueName, handler)
+		if err != nil {
+			return -1, err
+		}
+		subscription = jetStreamSubscription
+	} else if queueName != "" {
 		queueSubscription, err := c.natConn.QueueSubscribe(topic, queueName, wrappedHandler)
 		if err != nil {
 			return -1, err
@@ -233,14 +307,14 @@ func (c *Client) QueueSubscribe(topic, queueName string, handler busTY.CallBackF
 	c.subscriptions[newSubscriptionID] = subscription
 	subscriptionIDs = append(subscriptionIDs, newSubscriptionID)
 	c.topics[topicName] = subscriptionIDs
-	c.logger.Debug("subscription created", zap.String("topic", subscription.Subject), zap.String("queueName", queueName), zap.Int64("subscriptionId", newSubscriptionID))
+	c.logger.Debug("subscription created", zap.String("topic", topic), zap.String("queueName", queueName), zap.Int64("subscriptionId", newSubscriptionID))
 	return newSubscriptionID, nil
 }
 
 func (c *Client) handlerWrapper(handler busTY.CallBackFunc) func(natsMsg *natsIO.Msg) {
 	return func(natsMsg *natsIO.Msg) {
 		c.logger.Debug("receiving message", zap.String("topic", natsMsg.Sub.Subject))
-		handler(&busTY.BusData{Topic: natsMsg.Subject, Data: natsMsg.Data})
+		handler(&busTY.BusData{Topic: natsMsg.Subject, Data: natsMsg.Data, ReplyTopic: natsMsg.Header.Get(headerReplyTopic)})
 	}
 }
 
@@ -259,7 +333,7 @@ func (c *Client) QueueUnsubscribe(topic, queueName string, subscriptionID int64)
 
 	topicName := getTopicName(topic, queueName)
 
-	var subscription *natsIO.Subscription
+	var subscription subscription
 	// remove subscription id
 	if subscriptionIDs, found := c.topics[topicName]; found {
 		for index, id := range subscriptionIDs {
@@ -317,6 +391,20 @@ func (c *Client) generateSubscriptionID() int64 {
 	return c.subscriptionCounter
 }
 
+// returns server_url and server_urls
+func (cfg *Config) getServerURLs() []string {
+	serverURLs := make([]string, 0)
+	if cfg.ServerURL != "" {
+		serverURLs = append(serverURLs, cfg.ServerURL)
+	}
+	for _, serverURL := range cfg.ServerURLs {
+		if serverURL != "" && serverURL != cfg.ServerURL {
+			serverURLs = append(serverURLs, serverURL)
+		}
+	}
+	return serverURLs
+}
+
 func getTopicName(topic, queueName string) string {
 	return fmt.Sprintf("%s_%s", topic, queueName)
 }
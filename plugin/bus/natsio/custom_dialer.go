@@ -12,42 +12,57 @@ import (
 
 // CustomDialer struct
 type CustomDialer struct {
-	uri    *url.URL
+	uris   []*url.URL
 	config *Config
 	logger *zap.Logger
 }
 
 // NewCustomDialer returns a custom dialer
 func NewCustomDialer(cfg *Config, logger *zap.Logger) (*CustomDialer, error) {
-	uri, err := url.ParseRequestURI(cfg.ServerURL)
-	if err != nil {
-		return nil, err
+	uris := make([]*url.URL, 0)
+	for _, serverURL := range cfg.getServerURLs() {
+		uri, err := url.ParseRequestURI(serverURL)
+		if err != nil {
+			return nil, err
+		}
+		uris = append(uris, uri)
 	}
 	cd := &CustomDialer{
-		uri:    uri,
+		uris:   uris,
 		config: cfg,
 		logger: logger,
 	}
 	return cd, nil
 }
 
+// returns the configured server of the address, nats.io client selects the server from the cluster
+func (cd *CustomDialer) getServerURI(address string) *url.URL {
+	for _, uri := range cd.uris {
+		if uri.Host == address {
+			return uri
+		}
+	}
+	return cd.uris[0]
+}
+
 // Dial implementation
 func (cd *CustomDialer) Dial(network, address string) (net.Conn, error) {
-	cd.logger.Debug("connecting via custom dialer", zap.String("server", cd.uri.String()))
+	uri := cd.getServerURI(address)
+	cd.logger.Debug("connecting via custom dialer", zap.String("server", uri.String()))
 
 	timeout := utils.ToDuration(cd.config.ConnectionTimeout, defaultConnectionTimeout)
 	tlsConfig := &tls.Config{
 		InsecureSkipVerify: cd.config.Insecure,
 	}
-	switch cd.uri.Scheme {
+	switch uri.Scheme {
 	case "ws":
-		return NewWebsocket(cd.uri.String(), nil, timeout, cd.config.WebsocketOptions)
+		return NewWebsocket(uri.String(), nil, timeout, cd.config.WebsocketOptions)
 
 	case "wss":
-		return NewWebsocket(cd.uri.String(), tlsConfig, timeout, cd.config.WebsocketOptions)
+		return NewWebsocket(uri.String(), tlsConfig, timeout, cd.config.WebsocketOptions)
 
 	case "tcp", "http", "nats":
-		conn, err := net.DialTimeout("tcp", cd.uri.Host, timeout)
+		conn, err := net.DialTimeout("tcp", uri.Host, timeout)
 		if err != nil {
 			cd.logger.Debug("dialer error", zap.Error(err))
 			return nil, err
@@ -55,13 +70,13 @@ func (cd *CustomDialer) Dial(network, address string) (net.Conn, error) {
 		return conn, nil
 
 	case "tls", "nats+tls":
-		conn, err := tls.DialWithDialer(&net.Dialer{Timeout: timeout}, "tcp", cd.uri.Host, tlsConfig)
+		conn, err := tls.DialWithDialer(&net.Dialer{Timeout: timeout}, "tcp", uri.Host, tlsConfig)
 		if err != nil {
 			cd.logger.Debug("dialer error", zap.Error(err))
 			return nil, err
 		}
 		return conn, nil
 	}
-	cd.logger.Debug("unknown protocol", zap.String("protocol", cd.uri.Scheme))
-	return nil, fmt.Errorf("[BUS:NATS.IO] unknown protocol:%s", cd.uri.Scheme)
+	cd.logger.Debug("unknown protocol", zap.String("protocol", uri.Scheme))
+	return nil, fmt.Errorf("[BUS:NATS.IO] unknown protocol:%s", uri.Scheme)
 }
@@ -0,0 +1,271 @@
+package natsio
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	topicTY "github.com/mycontroller-org/server/v2/pkg/types/topic"
+	"github.com/mycontroller-org/server/v2/pkg/utils"
+	busTY "github.com/mycontroller-org/server/v2/plugin/bus/types"
+	natsIO "github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultStreamName     = "mycontroller"
+	defaultStreamMaxAge   = 24 * time.Hour
+	defaultAckWait        = 30 * time.Second
+	defaultRedeliverDelay = 5 * time.Second
+	defaultMaxDeliver     = 10
+	defaultRequestTimeout = 10 * time.Second
+
+	retentionLimits    = "limits"
+	retentionInterest  = "interest"
+	retentionWorkQueue = "workqueue"
+
+	storageFile   = "file"
+	storageMemory = "memory"
+)
+
+// default topics persisted on the stream, messages between the components
+var defaultStreamTopics = []string{
+	fmt.Sprintf("%s.>", topicTY.TopicPostMessageToProvider),
+	topicTY.TopicPostMessageToProcessor,
+	topicTY.TopicPostMessageNotifyHandler,
+}
+
+// JetStreamConfig persists the messages on a stream, delivered via durable consumers
+type JetStreamConfig struct {
+	Enabled        bool     `yaml:"enabled"`
+	StreamName     string   `yaml:"stream_name"`
+	Topics         []string `yaml:"topics"`          // topics without prefix, wildcards supported
+	Retention      string   `yaml:"retention"`       // limits, interest or workqueue
+	Storage        string   `yaml:"storage"`         // file or memory
+	Replicas       int      `yaml:"replicas"`        // number of replicas on the cluster
+	MaxAge         string   `yaml:"max_age"`         // maximum age of a message on the stream
+	MaxMessages    int64    `yaml:"max_messages"`    // maximum messages on the stream
+	MaxBytes       int64    `yaml:"max_bytes"`       // maximum size of the stream
+	ConsumerName   string   `yaml:"consumer_name"`   // durable name prefix for the subscriptions without queue name, should be unique per component
+	AckWait        string   `yaml:"ack_wait"`        // redelivered, if not acknowledged within this duration
+	MaxDeliver     int      `yaml:"max_deliver"`     // maximum delivery attempts, defaults to 10, unlimited if -1
+	RedeliverDelay string   `yaml:"redeliver_delay"` // redelivery delay, on processing error
+}
+
+// jetStreamSubscription to hold a consumer
+type jetStreamSubscription struct {
+	consumeContext jetstream.ConsumeContext
+}
+
+func (jss *jetStreamSubscription) Unsubscribe() error {
+	jss.consumeContext.Stop()
+	return nil
+}
+
+// creates or updates the stream
+func (c *Client) initJetStream(nc *natsIO.Conn) error {
+	cfg := c.config.JetStream
+
+	if cfg.StreamName == "" {
+		cfg.StreamName = defaultStreamName
+		if c.config.TopicPrefix != "" {
+			cfg.StreamName = normalizeName(fmt.Sprintf("%s_%s", c.config.TopicPrefix, defaultStreamName))
+		}
+	}
+	if len(cfg.Topics) == 0 {
+		cfg.Topics = defaultStreamTopics
+	}
+
+	retention := jetstream.LimitsPolicy
+	switch strings.ToLower(cfg.Retention) {
+	case "", retentionLimits:
+	case retentionInterest:
+		retention = jetstream.InterestPolicy
+	case retentionWorkQueue:
+		retention = jetstream.WorkQueuePolicy
+	default:
+		return fmt.Errorf("invalid retention:%s", cfg.Retention)
+	}
+
+	storage := jetstream.FileStorage
+	switch strings.ToLower(cfg.Storage) {
+	case "", storageFile:
+	case storageMemory:
+		storage = jetstream.MemoryStorage
+	default:
+		return fmt.Errorf("invalid storage:%s", cfg.Storage)
+	}
+
+	js, err := jetstream.New(nc)
+	if err != nil {
+		return err
+	}
+
+	// subjects with topic prefix
+	subjects := make([]string, 0)
+	for _, topic := range cfg.Topics {
+		subjects = append(subjects, c.formatTopic(topic))
+	}
+
+	streamConfig := jetstream.StreamConfig{
+		Name:      cfg.StreamName,
+		Subjects:  subjects,
+		Retention: retention,
+		Storage:   storage,
+		Replicas:  cfg.Replicas,
+		MaxAge:    utils.ToDuration(cfg.MaxAge, defaultStreamMaxAge),
+		MaxMsgs:   cfg.MaxMessages,
+		MaxBytes:  cfg.MaxBytes,
+	}
+	if streamConfig.MaxMsgs == 0 {
+		streamConfig.MaxMsgs = -1
+	}
+	if streamConfig.MaxBytes == 0 {
+		streamConfig.MaxBytes = -1
+	}
+
+	ctx, cancel := context.WithTimeout(c.ctx, defaultRequestTimeout)
+	defer cancel()
+	_, err = js.CreateOrUpdateStream(ctx, streamConfig)
+	if err != nil {
+		return err
+	}
+
+	c.jetStream = js
+	c.streamSubjects = subjects
+	c.logger.Info("jetstream enabled", zap.String("stream", cfg.StreamName), zap.Strings("subjects", subjects))
+	return nil
+}
+
+// isStreamTopic returns true, if the topic is persisted on the stream
+func (c *Client) isStreamTopic(topic string) bool {
+	if c.jetStream == nil {
+		return false
+	}
+	for _, subject := range c.streamSubjects {
+		if subjectMatches(subject, topic) {
+			return true
+		}
+	}
+	return false
+}
+
+// jetStreamSubscribe creates a durable consumer and consumes the messages.
+// subscriptions with the same queue name shares the consumer.
+// subscriptions without queue name, uses the consumer name as prefix.
+// if consumer name not set, allowed only on the gateway topics, the gateway id is part of the topic
+func (c *Client) jetStreamSubscribe(topic, queueName string, handler busTY.CallBackFunc) (*jetStreamSubscription, error) {
+	cfg := c.config.JetStream
+
+	durableName, err := c.getDurableName(topic, queueName)
+	if err != nil {
+		return nil, err
+	}
+
+	consumerConfig := jetstream.ConsumerConfig{
+		Durable:       durableName,
+		FilterSubject: topic,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		DeliverPolicy: jetstream.DeliverNewPolicy,
+		AckWait:       utils.ToDuration(cfg.AckWait, defaultAckWait),
+		MaxDeliver:    cfg.MaxDeliver,
+	}
+	if consumerConfig.MaxDeliver == 0 {
+		consumerConfig.MaxDeliver = defaultMaxDeliver
+	}
+
+	ctx, cancel := context.WithTimeout(c.ctx, defaultRequestTimeout)
+	defer cancel()
+	consumer, err := c.jetStream.CreateOrUpdateConsumer(ctx, cfg.StreamName, consumerConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	redeliverDelay := utils.ToDuration(cfg.RedeliverDelay, defaultRedeliverDelay)
+	consumeContext, err := consumer.Consume(func(msg jetstream.Msg) {
+		c.logger.Debug("receiving message", zap.String("topic", msg.Subject()), zap.String("consumer", durableName))
+		ackFunc := func(err error) {
+			var ackErr error
+			if err != nil && isLastDelivery(msg, consumerConfig.MaxDeliver) {
+				c.logger.Error("message dropped, maximum delivery attempts reached", zap.String("topic", msg.Subject()), zap.Int("maxDeliver", consumerConfig.MaxDeliver), zap.Error(err))
+				ackErr = msg.Term()
+			} else if err != nil {
+				c.logger.Debug("message will be redelivered", zap.String("topic", msg.Subject()), zap.Error(err))
+				ackErr = msg.NakWithDelay(redeliverDelay)
+			} else {
+				ackErr = msg.Ack()
+			}
+			if ackErr != nil {
+				c.logger.Error("error on acknowledging a message", zap.String("topic", msg.Subject()), zap.Error(ackErr))
+			}
+		}
+		busData := busTY.NewBusDataWithAck(msg.Subject(), msg.Data(), ackFunc)
+		busData.ReplyTopic = msg.Headers().Get(headerReplyTopic)
+		handler(busData)
+		// acknowledgement not taken by the handler
+		if ack := busData.TakeAck(); ack != nil {
+			ack(nil)
+		}
+	}, jetstream.ConsumeErrHandler(func(consumeCtx jetstream.ConsumeContext, err error) {
+		c.logger.Debug("error on consumer", zap.String("topic", topic), zap.String("consumer", durableName), zap.Error(err))
+	}))
+	if err != nil {
+		return nil, err
+	}
+	return &jetStreamSubscription{consumeContext: consumeContext}, nil
+}
+
+// getDurableName returns the durable consumer name of the subscription.
+// an ephemeral consumer loses the messages published while the component is down, hence not used
+func (c *Client) getDurableName(topic, queueName string) (string, error) {
+	cfg := c.config.JetStream
+	switch {
+	case queueName != "":
+		return normalizeName(fmt.Sprintf("%s_%s", queueName, topic)), nil
+
+	case cfg.ConsumerName != "":
+		return normalizeName(fmt.Sprintf("%s_%s", cfg.ConsumerName, topic)), nil
+
+	case subjectMatches(c.formatTopic(fmt.Sprintf("%s.*", topicTY.TopicPostMessageToProvider)), topic):
+		// a gateway is served by a single component
+		return normalizeName(topic), nil
+	}
+	return "", fmt.Errorf("jetstream consumer_name is required to subscribe the topic:%s", topic)
+}
+
+// isLastDelivery returns true, if the message will not be redelivered
+func isLastDelivery(msg jetstream.Msg, maxDeliver int) bool {
+	if maxDeliver < 1 {
+		return false
+	}
+	metadata, err := msg.Metadata()
+	if err != nil {
+		return false
+	}
+	return metadata.NumDelivered >= uint64(maxDeliver)
+}
+
+// subjectMatches verifies the subject against the pattern, supports "*" and ">" wildcards
+func subjectMatches(pattern, subject string) bool {
+	patternTokens := strings.Split(pattern, ".")
+	subjectTokens := strings.Split(subject, ".")
+	for index, token := range patternTokens {
+		if token == ">" {
+			return len(subjectTokens) > index
+		}
+		if index >= len(subjectTokens) {
+			return false
+		}
+		if token != "*" && token != subjectTokens[index] {
+			return false
+		}
+	}
+	return len(patternTokens) == len(subjectTokens)
+}
+
+// stream and consumer names can not contain ".", "*", ">" and whitespaces
+func normalizeName(name string) string {
+	return strings.NewReplacer(".", "_", "*", "_", ">", "_", " ", "_").Replace(name)
+}
@@ -0,0 +1,218 @@
+package natsio
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mycontroller-org/server/v2/pkg/types/cmap"
+	loggerUtils "github.com/mycontroller-org/server/v2/pkg/utils/logger"
+	busTY "github.com/mycontroller-org/server/v2/plugin/bus/types"
+	natsServer "github.com/nats-io/nats-server/v2/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// starts an in-process nats server with jetstream
+func startServer(t *testing.T) *natsServer.Server {
+	server, err := natsServer.NewServer(&natsServer.Options{
+		Host:      "127.0.0.1",
+		Port:      -1,
+		NoLog:     true,
+		NoSigs:    true,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+	})
+	require.NoError(t, err)
+	go server.Start()
+	require.True(t, server.ReadyForConnections(5*time.Second), "nats server not ready")
+	t.Cleanup(server.Shutdown)
+	return server
+}
+
+func newTestClient(t *testing.T, server *natsServer.Server, jetStream cmap.CustomMap) busTY.Plugin {
+	ctx := loggerUtils.WithContext(context.Background(), zap.NewNop())
+	jetStream["enabled"] = true
+	jetStream["storage"] = storageMemory
+	client, err := NewClient(ctx, cmap.CustomMap{
+		"server_url":   server.ClientURL(),
+		"topic_prefix": "mc",
+		"jetstream":    jetStream,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+func TestSubjectMatches(t *testing.T) {
+	tests := []struct {
+		pattern string
+		subject string
+		matches bool
+	}{
+		{pattern: "mc.message.to_provider.>", subject: "mc.message.to_provider.gw1", matches: true},
+		{pattern: "mc.message.to_provider.>", subject: "mc.message.to_provider", matches: false},
+		{pattern: "mc.message.to_provider.*", subject: "mc.message.to_provider.gw1", matches: true},
+		{pattern: "mc.message.to_provider.*", subject: "mc.message.to_provider.gw1.node", matches: false},
+		{pattern: "mc.message.notify_handler", subject: "mc.message.notify_handler", matches: true},
+		{pattern: "mc.message.notify_handler", subject: "mc.message.to_processor", matches: false},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.matches, subjectMatches(test.pattern, test.subject), "pattern:%s, subject:%s", test.pattern, test.subject)
+	}
+}
+
+func TestGetDurableName(t *testing.T) {
+	tests := []struct {
+		name         string
+		consumerName string
+		topic        string
+		queueName    string
+		durableName  string
+		hasError     bool
+	}{
+		{name: "queue name", topic: "mc.message.to_processor", queueName: "processor", durableName: "processor_mc_message_to_processor"},
+		{name: "consumer name", consumerName: "external_1", topic: "mc.message.notify_handler", durableName: "external_1_mc_message_notify_handler"},
+		{name: "gateway topic", topic: "mc.message.to_provider.gw1", durableName: "mc_message_to_provider_gw1"},
+		{name: "consumer name required", topic: "mc.message.notify_handler", hasError: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := &Client{config: &Config{TopicPrefix: "mc", JetStream: &JetStreamConfig{ConsumerName: test.consumerName}}}
+			durableName, err := client.getDurableName(test.topic, test.queueName)
+			if test.hasError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.durableName, durableName)
+		})
+	}
+}
+
+func TestJetStreamGatewayTopicIsDurable(t *testing.T) {
+	server := startServer(t)
+	client := newTestClient(t, server, cmap.CustomMap{})
+
+	received := make(chan string, 10)
+	handler := func(data *busTY.BusData) {
+		value := ""
+		if err := data.LoadData(&value); err == nil {
+			received <- value
+		}
+	}
+
+	subscriptionID, err := client.Subscribe("message.to_provider.gw1", handler)
+	require.NoError(t, err)
+	require.NoError(t, client.Publish("message.to_provider.gw1", "first"))
+	assert.Equal(t, "first", waitForValue(t, received))
+
+	// published while the gateway is not subscribed
+	require.NoError(t, client.Unsubscribe("message.to_provider.gw1", subscriptionID))
+	require.NoError(t, client.Publish("message.to_provider.gw1", "second"))
+
+	_, err = client.Subscribe("message.to_provider.gw1", handler)
+	require.NoError(t, err)
+	assert.Equal(t, "second", waitForValue(t, received))
+}
+
+func TestJetStreamConsumerNameRequired(t *testing.T) {
+	server := startServer(t)
+	client := newTestClient(t, server, cmap.CustomMap{})
+
+	_, err := client.Subscribe("message.notify_handler", func(data *busTY.BusData) {})
+	assert.ErrorContains(t, err, "consumer_name is required")
+
+	// core nats subscription, not a stream topic
+	_, err = client.Subscribe("event.node", func(data *busTY.BusData) {})
+	assert.NoError(t, err)
+}
+
+func TestJetStreamMaxDeliver(t *testing.T) {
+	server := startServer(t)
+	client := newTestClient(t, server, cmap.CustomMap{
+		"consumer_name":   "handler",
+		"max_deliver":     3,
+		"redeliver_delay": "10ms",
+	})
+
+	var attempts atomic.Int32
+	_, err := client.Subscribe("message.notify_handler", func(data *busTY.BusData) {
+		attempts.Add(1)
+		ack := data.TakeAck()
+		if !assert.NotNil(t, ack) {
+			return
+		}
+		go ack(errors.New("handler not ready"))
+	})
+	require.NoError(t, err)
+	require.NoError(t, client.Publish("message.notify_handler", "data"))
+
+	require.Eventually(t, func() bool { return attempts.Load() == 3 }, 5*time.Second, 10*time.Millisecond)
+	// not redelivered after the maximum attempts
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, int32(3), attempts.Load())
+}
+
+func TestJetStreamAcknowledgement(t *testing.T) {
+	server := startServer(t)
+	client := newTestClient(t, server, cmap.CustomMap{
+		"consumer_name":   "handler",
+		"redeliver_delay": "10ms",
+	})
+
+	var attempts atomic.Int32
+	_, err := client.Subscribe("message.notify_handler", func(data *busTY.BusData) {
+		ack := data.TakeAck()
+		if !assert.NotNil(t, ack) {
+			return
+		}
+		// fails on the first attempt
+		if attempts.Add(1) == 1 {
+			go ack(errors.New("queue full"))
+		} else {
+			go ack(nil)
+		}
+	})
+	require.NoError(t, err)
+	require.NoError(t, client.Publish("message.notify_handler", "data"))
+
+	require.Eventually(t, func() bool { return attempts.Load() == 2 }, 5*time.Second, 10*time.Millisecond)
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, int32(2), attempts.Load())
+}
+
+func TestJetStreamQueueSubscription(t *testing.T) {
+	server := startServer(t)
+	clientA := newTestClient(t, server, cmap.CustomMap{})
+	clientB := newTestClient(t, server, cmap.CustomMap{})
+
+	var received atomic.Int32
+	handler := func(data *busTY.BusData) { received.Add(1) }
+	_, err := clientA.QueueSubscribe("message.to_processor", "processor", handler)
+	require.NoError(t, err)
+	_, err = clientB.QueueSubscribe("message.to_processor", "processor", handler)
+	require.NoError(t, err)
+
+	for index := 0; index < 10; index++ {
+		require.NoError(t, clientA.Publish("message.to_processor", index))
+	}
+
+	// shared consumer, delivered once
+	require.Eventually(t, func() bool { return received.Load() == 10 }, 5*time.Second, 10*time.Millisecond)
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, int32(10), received.Load())
+}
+
+func waitForValue(t *testing.T, received chan string) string {
+	select {
+	case value := <-received:
+		return value
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "message not received")
+	}
+	return ""
+}
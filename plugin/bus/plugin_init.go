@@ -2,10 +2,12 @@ package bus
 
 import (
 	embedded "github.com/mycontroller-org/server/v2/plugin/bus/embedded"
+	mqtt "github.com/mycontroller-org/server/v2/plugin/bus/mqtt"
 	natsIO "github.com/mycontroller-org/server/v2/plugin/bus/natsio"
 )
 
 func init() {
 	Register(embedded.PluginEmbedded, embedded.NewClient)
 	Register(natsIO.PluginNATSIO, natsIO.NewClient)
+	Register(mqtt.PluginMQTT, mqtt.NewClient)
 }
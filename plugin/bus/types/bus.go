@@ -3,13 +3,26 @@ package bus
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/mycontroller-org/server/v2/pkg/json"
 	"github.com/mycontroller-org/server/v2/pkg/types"
+	"github.com/mycontroller-org/server/v2/pkg/utils"
 )
 
 const (
 	contextKey types.ContextKey = "bus_plugin"
+
+	replyTopicPrefix = "internal.reply"
+)
+
+var (
+	// ErrRequestTimeout returned when the reply not received within the timeout
+	ErrRequestTimeout = errors.New("request timeout")
+	// ErrPublishPaused returned when a request made on paused publish
+	ErrPublishPaused = errors.New("publish paused")
 )
 
 // Plugin interface
@@ -25,6 +38,7 @@ type Plugin interface {
 	PausePublish()
 	ResumePublish()
 	TopicPrefix() string
+	Request(topic string, data interface{}, timeout time.Duration) (*BusData, error)
 }
 
 func FromContext(ctx context.Context) (Plugin, error) {
@@ -45,10 +59,44 @@ func WithContext(ctx context.Context, bus Plugin) context.Context {
 // CallBackFunc message passed to this func
 type CallBackFunc func(data *BusData)
 
+// AckFunc acknowledges a received message to the bus, the message will be redelivered on error
+type AckFunc func(err error)
+
 // BusData struct
 type BusData struct {
-	Topic string `json:"topic" yaml:"topic"`
-	Data  []byte `json:"data" yaml:"data"`
+	Topic      string `json:"topic" yaml:"topic"`
+	Data       []byte `json:"data" yaml:"data"`
+	ReplyTopic string `json:"replyTopic,omitempty" yaml:"replyTopic,omitempty"` // set on a request, the reply has to be published on this topic
+	ack        AckFunc
+}
+
+// NewReplyTopic returns a unique reply topic for a request, the correlation id is part of the topic
+func NewReplyTopic() string {
+	return fmt.Sprintf("%s.%s", replyTopicPrefix, utils.RandUUID())
+}
+
+// IsReplyTopic returns true, if the topic is a reply topic of a request. topic without prefix
+func IsReplyTopic(topic string) bool {
+	return strings.HasPrefix(topic, replyTopicPrefix+".")
+}
+
+// NewRequestTimeoutError returns ErrRequestTimeout with the request details
+func NewRequestTimeoutError(topic string, timeout time.Duration) error {
+	return fmt.Errorf("%w, topic:%s, timeout:%s", ErrRequestTimeout, topic, timeout.String())
+}
+
+// NewBusDataWithAck returns bus data, which has to be acknowledged
+func NewBusDataWithAck(topic string, data []byte, ack AckFunc) *BusData {
+	return &BusData{Topic: topic, Data: data, ack: ack}
+}
+
+// TakeAck returns the acknowledge func and the caller becomes responsible for the acknowledgement.
+// returns nil, if the bus has no acknowledgement support or already taken.
+// not taken messages are acknowledged by the bus, once the CallBackFunc returns
+func (e *BusData) TakeAck() AckFunc {
+	ack := e.ack
+	e.ack = nil
+	return ack
 }
 
 // SetData updates data in []byte format
@@ -69,3 +117,36 @@ func (e *BusData) LoadData(out interface{}) error {
 	err := json.Unmarshal(e.Data, out)
 	return err
 }
+
+// StatsProvider implemented by the bus plugins, those are tracking the deliveries
+type StatsProvider interface {
+	Stats() *Stats
+}
+
+// Stats of the bus
+type Stats struct {
+	Plugin        string              `json:"plugin" yaml:"plugin"`
+	Topics        []TopicStats        `json:"topics" yaml:"topics"`
+	Subscriptions []SubscriptionStats `json:"subscriptions" yaml:"subscriptions"`
+}
+
+// TopicStats of a published topic, latency is from publish to the end of the delivery
+type TopicStats struct {
+	Topic          string  `json:"topic" yaml:"topic"`
+	Published      uint64  `json:"published" yaml:"published"`
+	Delivered      uint64  `json:"delivered" yaml:"delivered"`
+	Dropped        uint64  `json:"dropped" yaml:"dropped"`
+	AverageLatency float64 `json:"averageLatency" yaml:"averageLatency"` // in milliseconds
+	MaximumLatency float64 `json:"maximumLatency" yaml:"maximumLatency"` // in milliseconds
+}
+
+// SubscriptionStats of a subscriber
+type SubscriptionStats struct {
+	ID        int64  `json:"id" yaml:"id"`
+	Topic     string `json:"topic" yaml:"topic"`
+	Pending   int    `json:"pending" yaml:"pending"`
+	Capacity  int    `json:"capacity" yaml:"capacity"`
+	Delivered uint64 `json:"delivered" yaml:"delivered"`
+	Dropped   uint64 `json:"dropped" yaml:"dropped"`
+	Slow      bool   `json:"slow" yaml:"slow"` // consumer is slow or stuck
+}
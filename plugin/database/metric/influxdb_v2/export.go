@@ -0,0 +1,86 @@
+package influx
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxdb-client-go/v2/api/write"
+	metricTY "github.com/mycontroller-org/server/v2/plugin/database/metric/types"
+	"go.uber.org/zap"
+)
+
+const (
+	exportWindow = 7 * 24 * time.Hour // limits the number of points loaded on a query
+)
+
+// exported metric types
+var exportMetricTypes = []string{
+	metricTY.MetricTypeBinary,
+	metricTY.MetricTypeGauge,
+	metricTY.MetricTypeGaugeFloat,
+	metricTY.MetricTypeCounter,
+	metricTY.MetricTypeString,
+	metricTY.MetricTypeGEO,
+}
+
+// Export raw metric data, writeFn called on each measurement and export window
+func (c *Client) Export(exportConfig *metricTY.ExportConfig, writeFn metricTY.ExportWriteFunc) error {
+	stop := exportConfig.Stop
+	if stop.IsZero() {
+		stop = time.Now()
+	}
+	if !exportConfig.Start.Before(stop) {
+		return fmt.Errorf("export start should be before stop, start:%v, stop:%v", exportConfig.Start, stop)
+	}
+
+	for _, metricType := range exportMetricTypes {
+		measurement, err := c.getMeasurementName(metricType)
+		if err != nil {
+			return err
+		}
+		for windowStart := exportConfig.Start; windowStart.Before(stop); windowStart = windowStart.Add(exportWindow) {
+			windowStop := windowStart.Add(exportWindow)
+			if windowStop.After(stop) {
+				windowStop = stop
+			}
+			points, err := c.queryClient.ExportRaw(measurement, windowStart, windowStop)
+			if err != nil {
+				c.logger.Error("error on exporting metric data", zap.String("measurement", measurement), zap.Time("start", windowStart), zap.Time("stop", windowStop), zap.Error(err))
+				return err
+			}
+			if len(points) == 0 {
+				continue
+			}
+			for index := range points {
+				points[index].MetricType = metricType
+				points[index].NormalizeFields()
+			}
+			err = writeFn(points)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Import writes the metric data, existing points with the same timestamp and tags are overwritten
+func (c *Client) Import(data []metricTY.InputData) error {
+	points := make([]*write.Point, 0, len(data))
+	for index := range data {
+		if data[index].MetricType == metricTY.MetricTypeNone {
+			continue
+		}
+		data[index].NormalizeFields()
+		p, err := c.getPoint(&data[index])
+		if err != nil {
+			return err
+		}
+		points = append(points, p)
+	}
+	if len(points) == 0 {
+		return nil
+	}
+	wb := c.Client.WriteAPIBlocking(c.Config.OrganizationName, c.Config.BucketName)
+	return wb.WritePoint(ctx, points...)
+}
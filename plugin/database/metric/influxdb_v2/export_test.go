@@ -0,0 +1,181 @@
+package influx
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
+	metricTY "github.com/mycontroller-org/server/v2/plugin/database/metric/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type exportCall struct {
+	measurement string
+	start       time.Time
+	stop        time.Time
+}
+
+// fakeQueryClient returns the points of the measurement on each window
+type fakeQueryClient struct {
+	points map[string][]metricTY.InputData
+	err    error
+	calls  []exportCall
+}
+
+func (fq *fakeQueryClient) ExecuteQuery(queryConfig *metricTY.Query, measurement string) ([]metricTY.ResponseData, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (fq *fakeQueryClient) ExportRaw(measurement string, start, stop time.Time) ([]metricTY.InputData, error) {
+	fq.calls = append(fq.calls, exportCall{measurement: measurement, start: start, stop: stop})
+	if fq.err != nil {
+		return nil, fq.err
+	}
+	points := make([]metricTY.InputData, 0)
+	for _, point := range fq.points[measurement] {
+		fields := map[string]interface{}{}
+		for name, value := range point.Fields {
+			fields[name] = value
+		}
+		points = append(points, metricTY.InputData{Time: point.Time, Tags: point.Tags, Fields: fields})
+	}
+	return points, nil
+}
+
+func TestExport(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	stop := start.Add(10 * 24 * time.Hour)
+	tags := map[string]string{"node_id": "n1"}
+	queryClient := &fakeQueryClient{points: map[string][]metricTY.InputData{
+		"mc_gauge_int_data": {{Time: start, Tags: tags, Fields: map[string]interface{}{"value": float64(21)}}},
+		"mc_binary_data":    {{Time: start, Tags: tags, Fields: map[string]interface{}{"value": "true"}}},
+	}}
+	c := &Client{queryClient: queryClient, logger: zap.NewNop(), Config: Config{MeasurementPrefix: "mc"}}
+
+	exported := make([]metricTY.InputData, 0)
+	writeCalls := 0
+	err := c.Export(&metricTY.ExportConfig{Start: start, Stop: stop}, func(data []metricTY.InputData) error {
+		writeCalls++
+		exported = append(exported, data...)
+		return nil
+	})
+	require.NoError(t, err)
+
+	// a query per measurement and window
+	windows := []exportCall{{start: start, stop: start.Add(exportWindow)}, {start: start.Add(exportWindow), stop: stop}}
+	expectedCalls := make([]exportCall, 0)
+	for _, measurement := range []string{"mc_binary_data", "mc_gauge_int_data", "mc_gauge_float_data", "mc_counter_data", "mc_string_data", "mc_geo_data"} {
+		for _, window := range windows {
+			expectedCalls = append(expectedCalls, exportCall{measurement: measurement, start: window.start, stop: window.stop})
+		}
+	}
+	assert.Equal(t, expectedCalls, queryClient.calls)
+
+	// empty windows are not written, metric type updated and fields normalized
+	assert.Equal(t, 4, writeCalls)
+	assert.Equal(t, []metricTY.InputData{
+		{MetricType: metricTY.MetricTypeBinary, Time: start, Tags: tags, Fields: map[string]interface{}{"value": true}},
+		{MetricType: metricTY.MetricTypeBinary, Time: start, Tags: tags, Fields: map[string]interface{}{"value": true}},
+		{MetricType: metricTY.MetricTypeGauge, Time: start, Tags: tags, Fields: map[string]interface{}{"value": int64(21)}},
+		{MetricType: metricTY.MetricTypeGauge, Time: start, Tags: tags, Fields: map[string]interface{}{"value": int64(21)}},
+	}, exported)
+}
+
+func TestExportErrors(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	points := map[string][]metricTY.InputData{"mc_binary_data": {{Time: start, Fields: map[string]interface{}{"value": true}}}}
+	noopWriteFn := func(data []metricTY.InputData) error { return nil }
+
+	tests := []struct {
+		name        string
+		config      metricTY.ExportConfig
+		queryClient *fakeQueryClient
+		writeFn     metricTY.ExportWriteFunc
+		err         string
+	}{
+		{
+			name:        "start equals stop",
+			config:      metricTY.ExportConfig{Start: start, Stop: start},
+			queryClient: &fakeQueryClient{},
+			writeFn:     noopWriteFn,
+			err:         "export start should be before stop",
+		},
+		{
+			name:        "start in future",
+			config:      metricTY.ExportConfig{Start: time.Now().Add(time.Hour)},
+			queryClient: &fakeQueryClient{},
+			writeFn:     noopWriteFn,
+			err:         "export start should be before stop",
+		},
+		{
+			name:        "query error",
+			config:      metricTY.ExportConfig{Start: start, Stop: start.Add(time.Hour)},
+			queryClient: &fakeQueryClient{err: errors.New("connection refused")},
+			writeFn:     noopWriteFn,
+			err:         "connection refused",
+		},
+		{
+			name:        "write error",
+			config:      metricTY.ExportConfig{Start: start, Stop: start.Add(time.Hour)},
+			queryClient: &fakeQueryClient{points: points},
+			writeFn:     func(data []metricTY.InputData) error { return errors.New("disk full") },
+			err:         "disk full",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := &Client{queryClient: test.queryClient, logger: zap.NewNop(), Config: Config{MeasurementPrefix: "mc"}}
+			err := c.Export(&test.config, test.writeFn)
+			assert.ErrorContains(t, err, test.err)
+		})
+	}
+}
+
+func TestImport(t *testing.T) {
+	var body string
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+
+	client := influxdb2.NewClient(server.URL, "token")
+	t.Cleanup(client.Close)
+	c := &Client{Client: client, logger: zap.NewNop(), Config: Config{OrganizationName: "mc", BucketName: "mc_bucket", MeasurementPrefix: "mc"}}
+
+	timestamp := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	// values loaded from a yaml or json file
+	data := []metricTY.InputData{
+		{MetricType: metricTY.MetricTypeGauge, Time: timestamp, Tags: map[string]string{"Node_ID": "n1"}, Fields: map[string]interface{}{"value": float64(21)}},
+		{MetricType: metricTY.MetricTypeGaugeFloat, Time: timestamp, Tags: map[string]string{"node_id": "n1"}, Fields: map[string]interface{}{"value": 21}},
+		{MetricType: metricTY.MetricTypeBinary, Time: timestamp, Tags: map[string]string{"node_id": "n1"}, Fields: map[string]interface{}{"value": "true"}},
+		{MetricType: metricTY.MetricTypeNone, Time: timestamp, Tags: map[string]string{"node_id": "n1"}, Fields: map[string]interface{}{"value": "10"}},
+		{MetricType: metricTY.MetricTypeString, Time: timestamp, Tags: map[string]string{"node_id": "n1"}, Fields: map[string]interface{}{"value": 10}},
+	}
+	require.NoError(t, c.Import(data))
+	assert.Equal(t, 1, requests)
+	assert.Equal(t, []string{
+		"mc_gauge_int_data,node_id=n1 value=21i 1704067200000000000",
+		"mc_gauge_float_data,node_id=n1 value=21 1704067200000000000",
+		"mc_binary_data,node_id=n1 value=true 1704067200000000000",
+		`mc_string_data,node_id=n1 value="10" 1704067200000000000`,
+	}, strings.Split(strings.TrimSpace(body), "\n"))
+
+	// nothing to write
+	require.NoError(t, c.Import([]metricTY.InputData{{MetricType: metricTY.MetricTypeNone}}))
+	assert.Equal(t, 1, requests)
+
+	err := c.Import([]metricTY.InputData{{MetricType: "unknown", Fields: map[string]interface{}{"value": 1}}})
+	assert.EqualError(t, err, "unknown metric type: unknown")
+	assert.Equal(t, 1, requests)
+}
@@ -1,6 +1,8 @@
 package query
 
 import (
+	"time"
+
 	metricTY "github.com/mycontroller-org/server/v2/plugin/database/metric/types"
 )
 
@@ -18,6 +20,7 @@ const (
 // QueryAPI interface
 type QueryAPI interface {
 	ExecuteQuery(queryConfig *metricTY.Query, measurement string) ([]metricTY.ResponseData, error)
+	ExportRaw(measurement string, start, stop time.Time) ([]metricTY.InputData, error)
 }
 
 // AdminAPI interface
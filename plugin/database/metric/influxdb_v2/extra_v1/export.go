@@ -0,0 +1,76 @@
+package extrav1
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/mycontroller-org/server/v2/pkg/json"
+	cloneUtils "github.com/mycontroller-org/server/v2/pkg/utils/clone"
+	converterUtils "github.com/mycontroller-org/server/v2/pkg/utils/convertor"
+	metricType "github.com/mycontroller-org/server/v2/plugin/database/metric/types"
+	"go.uber.org/zap"
+)
+
+// ExportRaw returns the raw points of the measurement between start and stop
+func (qv1 *QueryV1) ExportRaw(measurement string, start, stop time.Time) ([]metricType.InputData, error) {
+	queryParams, _ := cloneUtils.Clone(qv1.queryParams).(map[string]interface{})
+	// nanoseconds lose precision on float64 json number
+	queryParams["epoch"] = "u"
+
+	// tags are returned on series with "GROUP BY *"
+	queryString := fmt.Sprintf(`SELECT * FROM "%s" WHERE time >= '%s' AND time < '%s' GROUP BY *`,
+		measurement, start.UTC().Format(time.RFC3339Nano), stop.UTC().Format(time.RFC3339Nano))
+	queryParams["q"] = queryString
+
+	qv1.logger.Debug("export query", zap.String("query", queryString))
+
+	response, err := qv1.client.ExecuteJson(qv1.url, http.MethodGet, qv1.headers, queryParams, nil, 0)
+	if err != nil {
+		qv1.logger.Error("error on calling api", zap.Error(err))
+		return nil, err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("invalid status code:%v", response.StatusCode)
+	}
+
+	queryResult := QueryResult{}
+	err = json.Unmarshal(response.Body, &queryResult)
+	if err != nil {
+		return nil, err
+	}
+	if queryResult.Error != "" {
+		return nil, errors.New(queryResult.Error)
+	}
+
+	points := make([]metricType.InputData, 0)
+	for _, result := range queryResult.Results {
+		if result.Error != "" {
+			return nil, errors.New(result.Error)
+		}
+		for _, series := range result.Series {
+			for _, values := range series.Values {
+				if len(series.Columns) != len(values) {
+					continue
+				}
+				point := metricType.InputData{Tags: map[string]string{}, Fields: map[string]interface{}{}}
+				for tag, value := range series.Tags {
+					if value != "" {
+						point.Tags[tag] = value
+					}
+				}
+				for index, column := range series.Columns {
+					if column == "time" {
+						point.Time = time.UnixMicro(converterUtils.ToInteger(values[index]))
+					} else if values[index] != nil {
+						point.Fields[column] = values[index]
+					}
+				}
+				points = append(points, point)
+			}
+		}
+	}
+	return points, nil
+}
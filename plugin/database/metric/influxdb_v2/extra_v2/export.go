@@ -0,0 +1,65 @@
+package extrav2
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	metricTY "github.com/mycontroller-org/server/v2/plugin/database/metric/types"
+	"go.uber.org/zap"
+)
+
+// ExportRaw returns the raw points of the measurement between start and stop
+func (qv2 *QueryV2) ExportRaw(measurement string, start, stop time.Time) ([]metricTY.InputData, error) {
+	query := fmt.Sprintf(`from(bucket: "%s") |> range(start: %s, stop: %s) |> filter(fn: (r) => r["_measurement"] == "%s")`,
+		qv2.bucket, start.UTC().Format(time.RFC3339Nano), stop.UTC().Format(time.RFC3339Nano), measurement)
+
+	qv2.logger.Debug("export query", zap.String("query", query))
+
+	tableResult, err := qv2.api.Query(context.Background(), query)
+	if err != nil {
+		return nil, err
+	}
+	defer tableResult.Close()
+
+	// a record holds a single field, merges the fields of a point
+	points := make([]metricTY.InputData, 0)
+	pointIndex := map[string]int{}
+	for tableResult.Next() {
+		record := tableResult.Record()
+		tags := make(map[string]string)
+		for key, value := range record.Values() {
+			if strings.HasPrefix(key, "_") || key == "result" || key == "table" {
+				continue
+			}
+			if value != nil {
+				tags[key] = fmt.Sprintf("%v", value)
+			}
+		}
+
+		key := pointKey(record.Time(), tags)
+		index, found := pointIndex[key]
+		if !found {
+			points = append(points, metricTY.InputData{Time: record.Time(), Tags: tags, Fields: map[string]interface{}{}})
+			index = len(points) - 1
+			pointIndex[key] = index
+		}
+		points[index].Fields[record.Field()] = record.Value()
+	}
+	if tableResult.Err() != nil {
+		return nil, tableResult.Err()
+	}
+	return points, nil
+}
+
+// returns unique key of a point
+func pointKey(timestamp time.Time, tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for key, value := range tags {
+		keys = append(keys, fmt.Sprintf("%s=%s", key, value))
+	}
+	sort.Strings(keys)
+	return fmt.Sprintf("%d,%s", timestamp.UnixNano(), strings.Join(keys, ","))
+}
@@ -0,0 +1,107 @@
+package extrav2
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
+	metricTY "github.com/mycontroller-org/server/v2/plugin/database/metric/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestPointKey(t *testing.T) {
+	timestamp := time.Date(2024, 1, 1, 0, 0, 10, 0, time.UTC)
+	key := pointKey(timestamp, map[string]string{"node_id": "n1", "sensor_id": "s1"})
+
+	tests := []struct {
+		name      string
+		timestamp time.Time
+		tags      map[string]string
+		same      bool
+	}{
+		{name: "same point", timestamp: timestamp, tags: map[string]string{"sensor_id": "s1", "node_id": "n1"}, same: true},
+		{name: "same time on another location", timestamp: timestamp.In(time.FixedZone("IST", 19800)), tags: map[string]string{"node_id": "n1", "sensor_id": "s1"}, same: true},
+		{name: "another time", timestamp: timestamp.Add(time.Nanosecond), tags: map[string]string{"node_id": "n1", "sensor_id": "s1"}},
+		{name: "another tag value", timestamp: timestamp, tags: map[string]string{"node_id": "n1", "sensor_id": "s2"}},
+		{name: "additional tag", timestamp: timestamp, tags: map[string]string{"node_id": "n1", "sensor_id": "s1", "gateway_id": "g1"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.same, key == pointKey(test.timestamp, test.tags))
+		})
+	}
+}
+
+// flux annotated csv, a table per field
+const exportResponse = `#datatype,string,long,dateTime:RFC3339,dateTime:RFC3339,dateTime:RFC3339,double,string,string,string,string
+#group,false,false,true,true,false,false,true,true,true,true
+#default,_result,,,,,,,,,
+,result,table,_start,_stop,_time,_value,_field,_measurement,node_id,sensor_id
+,,0,2024-01-01T00:00:00Z,2024-01-08T00:00:00Z,2024-01-01T00:00:10Z,12.5,latitude,mc_geo_data,n1,s1
+,,0,2024-01-01T00:00:00Z,2024-01-08T00:00:00Z,2024-01-01T00:00:20Z,12.6,latitude,mc_geo_data,n1,s1
+,,1,2024-01-01T00:00:00Z,2024-01-08T00:00:00Z,2024-01-01T00:00:10Z,77.5,longitude,mc_geo_data,n1,s1
+,,1,2024-01-01T00:00:00Z,2024-01-08T00:00:00Z,2024-01-01T00:00:20Z,77.6,longitude,mc_geo_data,n1,s1
+,,2,2024-01-01T00:00:00Z,2024-01-08T00:00:00Z,2024-01-01T00:00:10Z,13.5,latitude,mc_geo_data,n1,s2
+,,3,2024-01-01T00:00:00Z,2024-01-08T00:00:00Z,2024-01-01T00:00:10Z,78.5,longitude,mc_geo_data,n1,s2
+
+`
+
+func TestExportRaw(t *testing.T) {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := map[string]interface{}{}
+		if err := json.NewDecoder(r.Body).Decode(&request); err == nil {
+			query, _ = request["query"].(string)
+		}
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		_, _ = w.Write([]byte(exportResponse))
+	}))
+	t.Cleanup(server.Close)
+
+	client := influxdb2.NewClient(server.URL, "token")
+	t.Cleanup(client.Close)
+	qv2 := NewQueryClient(zap.NewNop(), client.QueryAPI("mc"), "mc_bucket")
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	points, err := qv2.ExportRaw("mc_geo_data", start, start.Add(7*24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, `from(bucket: "mc_bucket") |> range(start: 2024-01-01T00:00:00Z, stop: 2024-01-08T00:00:00Z) |> filter(fn: (r) => r["_measurement"] == "mc_geo_data")`, query)
+
+	// fields of a point merged, internal columns are not tags
+	s1Tags := map[string]string{"node_id": "n1", "sensor_id": "s1"}
+	expected := []metricTY.InputData{
+		{Time: start.Add(10 * time.Second), Tags: s1Tags, Fields: map[string]interface{}{"latitude": 12.5, "longitude": 77.5}},
+		{Time: start.Add(20 * time.Second), Tags: s1Tags, Fields: map[string]interface{}{"latitude": 12.6, "longitude": 77.6}},
+		{Time: start.Add(10 * time.Second), Tags: map[string]string{"node_id": "n1", "sensor_id": "s2"}, Fields: map[string]interface{}{"latitude": 13.5, "longitude": 78.5}},
+	}
+	require.Len(t, points, len(expected))
+	for index := range expected {
+		assert.True(t, expected[index].Time.Equal(points[index].Time), "index:%d", index)
+		assert.Equal(t, expected[index].Tags, points[index].Tags, "index:%d", index)
+		assert.Equal(t, expected[index].Fields, points[index].Fields, "index:%d", index)
+		// metric type updated by the caller
+		assert.Empty(t, points[index].MetricType)
+	}
+}
+
+func TestExportRawError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"code":"invalid","message":"bucket not found"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	client := influxdb2.NewClient(server.URL, "token")
+	t.Cleanup(client.Close)
+	qv2 := NewQueryClient(zap.NewNop(), client.QueryAPI("mc"), "mc_bucket")
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	_, err := qv2.ExportRaw("mc_geo_data", start, start.Add(time.Hour))
+	assert.ErrorContains(t, err, "bucket not found")
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/mycontroller-org/server/v2/pkg/types"
+	converterUtils "github.com/mycontroller-org/server/v2/pkg/utils/convertor"
 )
 
 const (
@@ -20,6 +21,8 @@ type Plugin interface {
 	Write(data *InputData) error
 	WriteBlocking(data *InputData) error
 	Query(queryConfig *QueryConfig) (map[string][]ResponseData, error)
+	Export(exportConfig *ExportConfig, writeFn ExportWriteFunc) error
+	Import(data []InputData) error
 }
 
 func FromContext(ctx context.Context) (Plugin, error) {
@@ -75,6 +78,35 @@ type InputData struct {
 	Fields     map[string]interface{} `json:"fields"`
 }
 
+// ExportConfig of raw metric data export
+type ExportConfig struct {
+	Start time.Time `json:"start" yaml:"start"`
+	Stop  time.Time `json:"stop" yaml:"stop"`
+}
+
+// ExportWriteFunc receives the exported metric data in chunks
+type ExportWriteFunc func(data []InputData) error
+
+// NormalizeFields converts the field values to the type of the metric
+// values loaded from a json or yaml file may not be on the written type
+func (d *InputData) NormalizeFields() {
+	for name, value := range d.Fields {
+		switch d.MetricType {
+		case MetricTypeBinary:
+			d.Fields[name] = converterUtils.ToBool(value)
+
+		case MetricTypeGauge, MetricTypeCounter:
+			d.Fields[name] = converterUtils.ToInteger(value)
+
+		case MetricTypeGaugeFloat, MetricTypeGEO:
+			d.Fields[name] = converterUtils.ToFloat(value)
+
+		case MetricTypeString:
+			d.Fields[name] = converterUtils.ToString(value)
+		}
+	}
+}
+
 // QueryConfig parameters
 type QueryConfig struct {
 	Global     Query   `json:"global"`
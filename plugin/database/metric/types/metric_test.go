@@ -0,0 +1,70 @@
+package metric
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeFields(t *testing.T) {
+	tests := []struct {
+		name       string
+		metricType string
+		fields     map[string]interface{}
+		expected   map[string]interface{}
+	}{
+		{
+			name:       "binary",
+			metricType: MetricTypeBinary,
+			fields:     map[string]interface{}{"a": true, "b": "true", "c": "on", "d": "false", "e": float64(0)},
+			expected:   map[string]interface{}{"a": true, "b": true, "c": true, "d": false, "e": false},
+		},
+		{
+			name:       "gauge",
+			metricType: MetricTypeGauge,
+			fields:     map[string]interface{}{"a": float64(21), "b": "22", "c": int64(23), "d": 24, "e": 25.9},
+			expected:   map[string]interface{}{"a": int64(21), "b": int64(22), "c": int64(23), "d": int64(24), "e": int64(25)},
+		},
+		{
+			name:       "counter",
+			metricType: MetricTypeCounter,
+			fields:     map[string]interface{}{FieldValue: uint64(100)},
+			expected:   map[string]interface{}{FieldValue: int64(100)},
+		},
+		{
+			name:       "gauge float",
+			metricType: MetricTypeGaugeFloat,
+			fields:     map[string]interface{}{"a": 21, "b": "21.5", "c": int64(22), "d": 22.5, "e": "invalid"},
+			expected:   map[string]interface{}{"a": float64(21), "b": 21.5, "c": float64(22), "d": 22.5, "e": float64(0)},
+		},
+		{
+			name:       "geo",
+			metricType: MetricTypeGEO,
+			fields:     map[string]interface{}{FieldLatitude: "12.5", FieldLongitude: 77.25, FieldAltitude: 900},
+			expected:   map[string]interface{}{FieldLatitude: 12.5, FieldLongitude: 77.25, FieldAltitude: float64(900)},
+		},
+		{
+			name:       "string",
+			metricType: MetricTypeString,
+			fields:     map[string]interface{}{"a": "text", "b": 10, "c": true},
+			expected:   map[string]interface{}{"a": "text", "b": "10", "c": "true"},
+		},
+		{
+			name:       "none",
+			metricType: MetricTypeNone,
+			fields:     map[string]interface{}{"a": "10", "b": float64(1)},
+			expected:   map[string]interface{}{"a": "10", "b": float64(1)},
+		},
+		{
+			name:       "without fields",
+			metricType: MetricTypeGauge,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data := &InputData{MetricType: test.metricType, Fields: test.fields}
+			data.NormalizeFields()
+			assert.Equal(t, test.expected, data.Fields)
+		})
+	}
+}
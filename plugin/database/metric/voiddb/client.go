@@ -40,3 +40,11 @@ func (c *Client) WriteBlocking(data *metricTY.InputData) error { return nil }
 func (c *Client) Query(queryConfig *metricTY.QueryConfig) (map[string][]metricTY.ResponseData, error) {
 	return nil, nil
 }
+
+// Export function
+func (c *Client) Export(exportConfig *metricTY.ExportConfig, writeFn metricTY.ExportWriteFunc) error {
+	return nil
+}
+
+// Import function
+func (c *Client) Import(data []metricTY.InputData) error { return nil }
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"path"
+	"reflect"
 	"strings"
 	"time"
 
@@ -12,9 +13,11 @@ import (
 	types "github.com/mycontroller-org/server/v2/pkg/types"
 	"github.com/mycontroller-org/server/v2/pkg/utils"
 	"github.com/mycontroller-org/server/v2/pkg/utils/concurrency"
+	filterUtils "github.com/mycontroller-org/server/v2/pkg/utils/filter_sort"
 	loggerUtils "github.com/mycontroller-org/server/v2/pkg/utils/logger"
 	"github.com/mycontroller-org/server/v2/pkg/version"
 	busTY "github.com/mycontroller-org/server/v2/plugin/bus/types"
+	metricTY "github.com/mycontroller-org/server/v2/plugin/database/metric/types"
 	storageTY "github.com/mycontroller-org/server/v2/plugin/database/storage/types"
 	"go.uber.org/zap"
 	"gopkg.in/yaml.v3"
@@ -30,6 +33,7 @@ type BackupRestore struct {
 	ctx         context.Context
 	logger      *zap.Logger
 	storage     storageTY.Plugin
+	metric      metricTY.Plugin // optional, used on metric data backup and restore
 	bus         busTY.Plugin
 	directories map[string]string
 }
@@ -48,17 +52,26 @@ func New(ctx context.Context, directories map[string]string) (*BackupRestore, er
 		return nil, err
 	}
 
+	// metric database not available on all the callers
+	metric, err := metricTY.FromContext(ctx)
+	if err != nil {
+		metric = nil
+	}
+
 	return &BackupRestore{
 		ctx:         ctx,
 		logger:      logger,
 		storage:     storage,
+		metric:      metric,
 		bus:         bus,
 		directories: directories,
 	}, nil
 }
 
 // exports data from database to disk
-func (br *BackupRestore) ExportStorage(exportMap map[string]Backup, transformerFunc DataTransformerFunc, targetDir, exportFormat string) error {
+// options.Since is optional, if set exports only the entities and files modified on or after since (incremental backup)
+// options.Metric is optional, if set exports the metric data too
+func (br *BackupRestore) ExportStorage(exportMap map[string]Backup, transformerFunc DataTransformerFunc, targetDir, exportFormat string, options ExportOptions) error {
 	if isBackupRunning.IsSet() {
 		return errors.New("there is a exporter job in progress")
 	}
@@ -66,28 +79,48 @@ func (br *BackupRestore) ExportStorage(exportMap map[string]Backup, transformerF
 	defer isBackupRunning.Reset()
 
 	// include version details
-	err := br.addBackupInformation(targetDir, exportFormat)
+	since := options.Since
+	if options.Metric != nil && br.metric == nil {
+		return errors.New("metric database not available to export metric data")
+	}
+
+	err := br.addBackupInformation(targetDir, exportFormat, options)
 	if err != nil {
 		return err
 	}
 
 	// export directories
-	err = br.exportDirectories(targetDir)
+	err = br.exportDirectories(targetDir, since)
 	if err != nil {
 		return err
 	}
 
 	// export database
 	targetDirFullPath := fmt.Sprintf("%s/%s", targetDir, StorageBackupDirectoryName)
+	entityIDs := map[string][]string{}
 	for entityName := range exportMap {
 		storageApi := exportMap[entityName]
+		// incremental backup keeps the available ids, used to remove the deleted entities on restore
+		if since != nil {
+			ids, err := br.listIDs(storageApi)
+			if err != nil {
+				br.logger.Error("failed to get entity ids", zap.String("entityName", entityName), zap.Error(err))
+				return err
+			}
+			entityIDs[entityName] = ids
+		}
 		p := &storageTY.Pagination{
 			Limit: LimitPerFile, SortBy: []storageTY.Sort{{Field: types.KeyFieldID, OrderBy: "asc"}}, Offset: 0,
 		}
+		// entities without modified timestamp are exported fully on incremental backup too
+		var filters []storageTY.Filter
+		if since != nil && hasModifiedOn(storageApi.GetEntityInterface()) {
+			filters = []storageTY.Filter{{Key: KeyModifiedOn, Operator: storageTY.OperatorGreaterThanEqual, Value: *since}}
+		}
 		offset := int64(0)
 		for {
 			p.Offset = offset
-			result, err := storageApi.List(nil, p)
+			result, err := storageApi.List(filters, p)
 			if err != nil {
 				br.logger.Error("failed to get entities", zap.String("entityName", entityName), zap.Error(err))
 				return err
@@ -104,18 +137,117 @@ func (br *BackupRestore) ExportStorage(exportMap map[string]Backup, transformerF
 			}
 		}
 	}
+
+	if since != nil {
+		dataBytes, err := br.marshal(exportFormat, entityIDs)
+		if err != nil {
+			return err
+		}
+		filename := fmt.Sprintf("%s.%s", EntityIDsFilename, exportFormat)
+		err = utils.WriteFile(targetDir, filename, dataBytes)
+		if err != nil {
+			br.logger.Error("failed to write data to disk", zap.String("directory", targetDir), zap.String("filename", filename), zap.Error(err))
+			return err
+		}
+	}
+
+	// export metric data
+	if options.Metric != nil {
+		err = br.exportMetric(targetDir, exportFormat, options.Metric)
+		if err != nil {
+			return err
+		}
+	}
+
+	// integrity manifest, verified on restore
+	return WriteChecksums(targetDir)
+}
+
+// returns ids of all the entities
+func (br *BackupRestore) listIDs(storageApi Backup) ([]string, error) {
+	ids := make([]string, 0)
+	p := &storageTY.Pagination{
+		Limit: LimitPerFile, SortBy: []storageTY.Sort{{Field: types.KeyFieldID, OrderBy: "asc"}}, Offset: 0,
+	}
+	offset := int64(0)
+	for {
+		p.Offset = offset
+		result, err := storageApi.List(nil, p)
+		if err != nil {
+			return nil, err
+		}
+		entities := reflect.Indirect(reflect.ValueOf(result.Data))
+		if entities.Kind() == reflect.Slice {
+			for index := 0; index < entities.Len(); index++ {
+				ids = append(ids, filterUtils.GetID(entities.Index(index).Interface()))
+			}
+		}
+
+		offset += LimitPerFile
+		if result.Count < offset {
+			break
+		}
+	}
+	return ids, nil
+}
+
+// exports metric data, a file per chunk received from the metric database
+func (br *BackupRestore) exportMetric(targetDir, exportFormat string, exportConfig *metricTY.ExportConfig) error {
+	start := time.Now()
+	targetDirFullPath := path.Join(targetDir, MetricBackupDirectoryName)
+	index := 0
+	count := 0
+	writeFn := func(data []metricTY.InputData) error {
+		dataBytes, err := br.marshal(exportFormat, data)
+		if err != nil {
+			return err
+		}
+		filename := fmt.Sprintf("%s%s%d.%s", MetricFilePrefix, EntityNameIndexSplit, index, exportFormat)
+		err = utils.WriteFile(targetDirFullPath, filename, dataBytes)
+		if err != nil {
+			br.logger.Error("failed to write data to disk", zap.String("directory", targetDirFullPath), zap.String("filename", filename), zap.Error(err))
+			return err
+		}
+		index++
+		count += len(data)
+		return nil
+	}
+
+	err := br.metric.Export(exportConfig, writeFn)
+	if err != nil {
+		br.logger.Error("error on exporting metric data", zap.Error(err))
+		return err
+	}
+	br.logger.Debug("metric data exported", zap.Int("count", count), zap.String("timeTaken", time.Since(start).String()))
 	return nil
 }
 
+func (br *BackupRestore) marshal(exportFormat string, data interface{}) ([]byte, error) {
+	switch exportFormat {
+	case TypeJSON:
+		return json.Marshal(data)
+	case TypeYAML:
+		return yaml.Marshal(data)
+	default:
+		return nil, fmt.Errorf("unknown format:%s", exportFormat)
+	}
+}
+
 // updates backup information
-func (br *BackupRestore) addBackupInformation(targetDir, storageExportType string) error {
+func (br *BackupRestore) addBackupInformation(targetDir, storageExportType string, options ExportOptions) error {
 	backupDetails := &BackupDetails{
 		Filename:          path.Base(targetDir),
 		StorageExportType: storageExportType,
 		CreatedOn:         time.Now(),
 		Version:           version.Get(),
 		Directories:       br.directories,
+		Mode:              ModeFull,
+	}
+	if options.Since != nil {
+		backupDetails.Mode = ModeIncremental
+		backupDetails.Since = options.Since
 	}
+	backupDetails.Metric = options.Metric
 
 	dataBytes, err := yaml.Marshal(backupDetails)
 	if err != nil {
@@ -170,13 +302,13 @@ func (br *BackupRestore) exportEntity(targetDir, entityName string, index int, d
 	return nil
 }
 
-func (br *BackupRestore) exportDirectories(targetDir string) error {
+func (br *BackupRestore) exportDirectories(targetDir string, since *time.Time) error {
 	for name, path := range br.directories {
 		if !br.isValidDirectoryName(name) {
-			return fmt.Errorf("name '%s' is reserved for storage database backup", StorageBackupDirectoryName)
+			return fmt.Errorf("name '%s' is reserved for database backup", name)
 		}
 		targetDirFullPath := fmt.Sprintf("%s/%s", targetDir, name)
-		err := CopyFiles(br.logger, path, targetDirFullPath, false)
+		err := copyFiles(br.logger, path, targetDirFullPath, false, since)
 		if err != nil {
 			br.logger.Error("error on copying a directory", zap.String("name", name), zap.String("path", path), zap.Error(err))
 			return err
@@ -185,8 +317,24 @@ func (br *BackupRestore) exportDirectories(targetDir string) error {
 	return nil
 }
 
+// returns true, if the entity has ModifiedOn timestamp
+func hasModifiedOn(entity interface{}) bool {
+	entityType := reflect.TypeOf(entity)
+	if entityType == nil {
+		return false
+	}
+	for entityType.Kind() == reflect.Ptr {
+		entityType = entityType.Elem()
+	}
+	if entityType.Kind() != reflect.Struct {
+		return false
+	}
+	field, found := entityType.FieldByName("ModifiedOn")
+	return found && field.Type == reflect.TypeOf(time.Time{})
+}
+
 func (br *BackupRestore) isValidDirectoryName(name string) bool {
 	// remove '/' from prefix
 	name = strings.TrimPrefix(name, "/")
-	return name != StorageBackupDirectoryName
+	return name != StorageBackupDirectoryName && name != MetricBackupDirectoryName
 }
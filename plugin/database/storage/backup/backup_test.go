@@ -0,0 +1,257 @@
+package backup_test
+
+import (
+	"context"
+	"path"
+	"sort"
+	"testing"
+	"time"
+
+	coreScheduler "github.com/mycontroller-org/server/v2/pkg/service/core_scheduler"
+	types "github.com/mycontroller-org/server/v2/pkg/types"
+	"github.com/mycontroller-org/server/v2/pkg/types/cmap"
+	schedulerTY "github.com/mycontroller-org/server/v2/pkg/types/scheduler"
+	"github.com/mycontroller-org/server/v2/pkg/utils"
+	loggerUtils "github.com/mycontroller-org/server/v2/pkg/utils/logger"
+	"github.com/mycontroller-org/server/v2/plugin/bus/embedded"
+	busTY "github.com/mycontroller-org/server/v2/plugin/bus/types"
+	metricTY "github.com/mycontroller-org/server/v2/plugin/database/metric/types"
+	backupAPI "github.com/mycontroller-org/server/v2/plugin/database/storage/backup"
+	"github.com/mycontroller-org/server/v2/plugin/database/storage/memory"
+	storageTY "github.com/mycontroller-org/server/v2/plugin/database/storage/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+const testEntityName = "test_entity"
+
+type testEntity struct {
+	ID         string    `json:"id" yaml:"id"`
+	Value      string    `json:"value" yaml:"value"`
+	ModifiedOn time.Time `json:"modifiedOn" yaml:"modifiedOn"`
+}
+
+// testAPI updates the modified timestamp as the entity apis do
+type testAPI struct {
+	storage storageTY.Plugin
+}
+
+func (ta *testAPI) save(entity testEntity) error {
+	entity.ModifiedOn = time.Now()
+	return ta.storage.Upsert(testEntityName, &entity, []storageTY.Filter{{Key: types.KeyID, Value: entity.ID}})
+}
+
+func (ta *testAPI) Import(data interface{}) error {
+	return ta.save(data.(testEntity))
+}
+
+func (ta *testAPI) List(filters []storageTY.Filter, pagination *storageTY.Pagination) (*storageTY.Result, error) {
+	result := make([]testEntity, 0)
+	return ta.storage.Find(testEntityName, &result, filters, pagination)
+}
+
+func (ta *testAPI) GetEntityInterface() interface{} {
+	return testEntity{}
+}
+
+func (ta *testAPI) values(t *testing.T) map[string]string {
+	result, err := ta.List(nil, nil)
+	require.NoError(t, err)
+	values := map[string]string{}
+	for _, entity := range *result.Data.(*[]testEntity) {
+		values[entity.ID] = entity.Value
+	}
+	return values
+}
+
+// metric is optional
+// fakeMetric exports the data in chunks and keeps the imported data
+type fakeMetric struct {
+	chunks       [][]metricTY.InputData
+	exportConfig *metricTY.ExportConfig
+	imported     []metricTY.InputData
+}
+
+func (fm *fakeMetric) Name() string                                 { return "fake" }
+func (fm *fakeMetric) Close() error                                 { return nil }
+func (fm *fakeMetric) Ping() error                                  { return nil }
+func (fm *fakeMetric) Write(data *metricTY.InputData) error         { return nil }
+func (fm *fakeMetric) WriteBlocking(data *metricTY.InputData) error { return nil }
+
+func (fm *fakeMetric) Query(queryConfig *metricTY.QueryConfig) (map[string][]metricTY.ResponseData, error) {
+	return nil, nil
+}
+
+func (fm *fakeMetric) Export(exportConfig *metricTY.ExportConfig, writeFn metricTY.ExportWriteFunc) error {
+	fm.exportConfig = exportConfig
+	for _, chunk := range fm.chunks {
+		if err := writeFn(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (fm *fakeMetric) Import(data []metricTY.InputData) error {
+	fm.imported = append(fm.imported, data...)
+	return nil
+}
+
+func newTestBackupRestore(t *testing.T, metric metricTY.Plugin) (*backupAPI.BackupRestore, *testAPI) {
+	ctx := loggerUtils.WithContext(context.Background(), zap.NewNop())
+	ctx = schedulerTY.WithContext(ctx, coreScheduler.New())
+	if metric != nil {
+		ctx = metricTY.WithContext(ctx, metric)
+	}
+
+	storage, err := memory.New(ctx, cmap.CustomMap{})
+	require.NoError(t, err)
+	ctx = storageTY.WithContext(ctx, storage)
+
+	bus, err := embedded.NewClient(ctx, cmap.CustomMap{})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = bus.Close() })
+	ctx = busTY.WithContext(ctx, bus)
+
+	br, err := backupAPI.New(ctx, map[string]string{})
+	require.NoError(t, err)
+	return br, &testAPI{storage: storage}
+}
+
+func TestIncrementalRoundTrip(t *testing.T) {
+	br, api := newTestBackupRestore(t, nil)
+	apiMap := map[string]backupAPI.Backup{testEntityName: api}
+	sameApiMap := func(storage storageTY.Plugin, backupVersion string, apiMap map[string]backupAPI.Backup) (map[string]backupAPI.Backup, error) {
+		return apiMap, nil
+	}
+
+	for _, id := range []string{"a", "b", "c"} {
+		require.NoError(t, api.save(testEntity{ID: id, Value: "1"}))
+	}
+	fullDir := path.Join(t.TempDir(), "full")
+	require.NoError(t, br.ExportStorage(apiMap, nil, fullDir, backupAPI.TypeJSON, backupAPI.ExportOptions{}))
+
+	// changes after the full backup
+	since := time.Now()
+	time.Sleep(10 * time.Millisecond)
+	require.NoError(t, api.save(testEntity{ID: "b", Value: "2"}))
+	require.NoError(t, api.save(testEntity{ID: "d", Value: "1"}))
+	_, err := api.storage.Delete(testEntityName, []storageTY.Filter{{Key: types.KeyID, Value: "c"}})
+	require.NoError(t, err)
+
+	incrementalDir := path.Join(t.TempDir(), "incremental")
+	require.NoError(t, br.ExportStorage(apiMap, nil, incrementalDir, backupAPI.TypeYAML, backupAPI.ExportOptions{Since: &since}))
+	require.NoError(t, backupAPI.VerifyChecksums(zap.NewNop(), incrementalDir))
+
+	// only the modified entities exported, all the available ids listed
+	dataBytes, err := utils.ReadFile(path.Join(incrementalDir, backupAPI.StorageBackupDirectoryName), testEntityName+"__0.yaml")
+	require.NoError(t, err)
+	exported := make([]testEntity, 0)
+	require.NoError(t, yaml.Unmarshal(dataBytes, &exported))
+	exportedIDs := []string{}
+	for _, entity := range exported {
+		exportedIDs = append(exportedIDs, entity.ID)
+	}
+	sort.Strings(exportedIDs)
+	assert.Equal(t, []string{"b", "d"}, exportedIDs)
+
+	dataBytes, err = utils.ReadFile(incrementalDir, backupAPI.EntityIDsFilename+".yaml")
+	require.NoError(t, err)
+	entityIDs := map[string][]string{}
+	require.NoError(t, yaml.Unmarshal(dataBytes, &entityIDs))
+	assert.Equal(t, map[string][]string{testEntityName: {"a", "b", "d"}}, entityIDs)
+
+	// incremental backup can not be restored on an empty database
+	err = br.ExecuteRestore(api.storage, apiMap, incrementalDir, sameApiMap)
+	assert.ErrorContains(t, err, "restore the full backup first")
+
+	// full backup followed by the incremental backup
+	require.NoError(t, br.ExecuteRestore(api.storage, apiMap, fullDir, sameApiMap))
+	assert.Equal(t, map[string]string{"a": "1", "b": "1", "c": "1"}, api.values(t))
+
+	require.NoError(t, br.ExecuteIncrementalRestore(api.storage, apiMap, incrementalDir, sameApiMap))
+	assert.Equal(t, map[string]string{"a": "1", "b": "2", "d": "1"}, api.values(t))
+}
+
+func TestMetricRoundTrip(t *testing.T) {
+	timestamp := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tags := map[string]string{"node_id": "n1"}
+	chunks := [][]metricTY.InputData{
+		{
+			{MetricType: metricTY.MetricTypeGauge, Time: timestamp, Tags: tags, Fields: map[string]interface{}{"value": 21}},
+			{MetricType: metricTY.MetricTypeGaugeFloat, Time: timestamp, Tags: tags, Fields: map[string]interface{}{"value": 21.5}},
+		},
+		{
+			{MetricType: metricTY.MetricTypeBinary, Time: timestamp.Add(time.Minute), Tags: tags, Fields: map[string]interface{}{"value": true}},
+		},
+	}
+	exportConfig := &metricTY.ExportConfig{Start: timestamp.Add(-time.Hour), Stop: timestamp.Add(time.Hour)}
+	sameApiMap := func(storage storageTY.Plugin, backupVersion string, apiMap map[string]backupAPI.Backup) (map[string]backupAPI.Backup, error) {
+		return apiMap, nil
+	}
+
+	tests := []struct {
+		exportFormat string
+		gaugeValue   interface{} // the plugin normalizes the loaded values on import
+	}{
+		{exportFormat: backupAPI.TypeYAML, gaugeValue: 21},
+		{exportFormat: backupAPI.TypeJSON, gaugeValue: float64(21)},
+	}
+	for _, test := range tests {
+		t.Run(test.exportFormat, func(t *testing.T) {
+			metric := &fakeMetric{chunks: chunks}
+			br, api := newTestBackupRestore(t, metric)
+			apiMap := map[string]backupAPI.Backup{testEntityName: api}
+			require.NoError(t, api.save(testEntity{ID: "a", Value: "1"}))
+
+			backupDir := path.Join(t.TempDir(), "backup")
+			require.NoError(t, br.ExportStorage(apiMap, nil, backupDir, test.exportFormat, backupAPI.ExportOptions{Metric: exportConfig}))
+			assert.Equal(t, exportConfig, metric.exportConfig)
+			require.NoError(t, backupAPI.VerifyChecksums(zap.NewNop(), backupDir))
+
+			// a file per chunk
+			files, err := utils.ListFiles(path.Join(backupDir, backupAPI.MetricBackupDirectoryName))
+			require.NoError(t, err)
+			names := make([]string, 0)
+			for _, file := range files {
+				names = append(names, file.Name)
+			}
+			sort.Strings(names)
+			assert.Equal(t, []string{"metric__0." + test.exportFormat, "metric__1." + test.exportFormat}, names)
+
+			require.NoError(t, br.ExecuteRestore(api.storage, apiMap, backupDir, sameApiMap))
+			assert.Equal(t, map[string]string{"a": "1"}, api.values(t))
+			require.Len(t, metric.imported, 3)
+			expectedFields := []interface{}{test.gaugeValue, 21.5, true}
+			for index, data := range metric.imported {
+				expected := chunks[index/2][index%2]
+				assert.Equal(t, expected.MetricType, data.MetricType)
+				assert.True(t, expected.Time.Equal(data.Time), "expected:%s, actual:%s", expected.Time, data.Time)
+				assert.Equal(t, expected.Tags, data.Tags)
+				assert.Equal(t, map[string]interface{}{"value": expectedFields[index]}, data.Fields)
+			}
+		})
+	}
+}
+
+func TestMetricNotAvailable(t *testing.T) {
+	br, api := newTestBackupRestore(t, nil)
+	apiMap := map[string]backupAPI.Backup{testEntityName: api}
+	sameApiMap := func(storage storageTY.Plugin, backupVersion string, apiMap map[string]backupAPI.Backup) (map[string]backupAPI.Backup, error) {
+		return apiMap, nil
+	}
+
+	backupDir := path.Join(t.TempDir(), "backup")
+	err := br.ExportStorage(apiMap, nil, backupDir, backupAPI.TypeYAML, backupAPI.ExportOptions{Metric: &metricTY.ExportConfig{}})
+	assert.EqualError(t, err, "metric database not available to export metric data")
+
+	// metric data skipped on restore
+	metric := &fakeMetric{chunks: [][]metricTY.InputData{{{MetricType: metricTY.MetricTypeGauge, Fields: map[string]interface{}{"value": 1}}}}}
+	brWithMetric, _ := newTestBackupRestore(t, metric)
+	require.NoError(t, api.save(testEntity{ID: "a", Value: "1"}))
+	require.NoError(t, brWithMetric.ExportStorage(apiMap, nil, backupDir, backupAPI.TypeYAML, backupAPI.ExportOptions{Metric: &metricTY.ExportConfig{}}))
+	require.NoError(t, br.ExecuteRestore(api.storage, apiMap, backupDir, sameApiMap))
+	assert.Equal(t, map[string]string{"a": "1"}, api.values(t))
+}
@@ -9,10 +9,12 @@ import (
 	"time"
 
 	json "github.com/mycontroller-org/server/v2/pkg/json"
+	types "github.com/mycontroller-org/server/v2/pkg/types"
 	"github.com/mycontroller-org/server/v2/pkg/types/config"
 	"github.com/mycontroller-org/server/v2/pkg/utils"
 	"github.com/mycontroller-org/server/v2/pkg/utils/concurrency"
 	"github.com/mycontroller-org/server/v2/pkg/utils/ziputils"
+	metricTY "github.com/mycontroller-org/server/v2/plugin/database/metric/types"
 	storageTY "github.com/mycontroller-org/server/v2/plugin/database/storage/types"
 	"go.uber.org/zap"
 	"gopkg.in/yaml.v3"
@@ -26,37 +28,56 @@ var (
 
 type updateRestoreApiMap func(storage storageTY.Plugin, backupVersion string, apiMap map[string]Backup) (map[string]Backup, error)
 
+// ExecuteRestore clears the database and restores a full backup
 func (br *BackupRestore) ExecuteRestore(storage storageTY.Plugin, apiMap map[string]Backup, extractedDir string, updateRestoreApiMapFn updateRestoreApiMap) error {
+	return br.executeRestore(storage, apiMap, extractedDir, updateRestoreApiMapFn, true)
+}
+
+// ExecuteIncrementalRestore applies an incremental backup on top of the existing data
+// entities on the backup are updated, others are untouched
+func (br *BackupRestore) ExecuteIncrementalRestore(storage storageTY.Plugin, apiMap map[string]Backup, extractedDir string, updateRestoreApiMapFn updateRestoreApiMap) error {
+	return br.executeRestore(storage, apiMap, extractedDir, updateRestoreApiMapFn, false)
+}
+
+func (br *BackupRestore) executeRestore(storage storageTY.Plugin, apiMap map[string]Backup, extractedDir string, updateRestoreApiMapFn updateRestoreApiMap, clearDatabase bool) error {
 	start := time.Now()
-	br.logger.Info("restore job triggered", zap.String("extractedDirectory", extractedDir))
+	br.logger.Info("restore job triggered", zap.String("extractedDirectory", extractedDir), zap.Bool("clearDatabase", clearDatabase))
 
-	err := storage.Pause()
+	dataBytes, err := utils.ReadFile(extractedDir, BackupDetailsFilename)
 	if err != nil {
-		br.logger.Fatal("error on pause a database", zap.Error(err))
+		br.logger.Fatal("error on reading export details", zap.String("dir", extractedDir), zap.String("filename", BackupDetailsFilename), zap.Error(err))
 		return err
 	}
 
-	err = storage.ClearDatabase()
+	exportDetails := &BackupDetails{}
+	err = yaml.Unmarshal(dataBytes, exportDetails)
 	if err != nil {
-		br.logger.Fatal("error on emptying database", zap.Error(err))
+		br.logger.Fatal("error on loading export details", zap.Error(err))
 		return err
 	}
 
-	storageDir := path.Join(extractedDir, StorageBackupDirectoryName)
+	// an incremental backup can not be restored on an empty database
+	// verified before clearing the database
+	if clearDatabase && exportDetails.IsIncremental() {
+		return fmt.Errorf("'%s' is an incremental backup, restore the full backup first", exportDetails.Filename)
+	}
 
-	dataBytes, err := utils.ReadFile(extractedDir, BackupDetailsFilename)
+	err = storage.Pause()
 	if err != nil {
-		br.logger.Fatal("error on reading export details", zap.String("dir", extractedDir), zap.String("filename", BackupDetailsFilename), zap.Error(err))
+		br.logger.Fatal("error on pause a database", zap.Error(err))
 		return err
 	}
 
-	exportDetails := &BackupDetails{}
-	err = yaml.Unmarshal(dataBytes, exportDetails)
-	if err != nil {
-		br.logger.Fatal("error on loading export details", zap.Error(err))
-		return err
+	if clearDatabase {
+		err = storage.ClearDatabase()
+		if err != nil {
+			br.logger.Fatal("error on emptying database", zap.Error(err))
+			return err
+		}
 	}
 
+	storageDir := path.Join(extractedDir, StorageBackupDirectoryName)
+
 	// update restore api with actual backed up server version
 	_updateApiMap, err := updateRestoreApiMapFn(storage, exportDetails.Version.Version, apiMap)
 	if err != nil {
@@ -64,12 +85,22 @@ func (br *BackupRestore) ExecuteRestore(storage storageTY.Plugin, apiMap map[str
 	}
 	apiMap = _updateApiMap
 
-	err = br.ExecuteImportStorage(apiMap, storageDir, exportDetails.StorageExportType, false)
+	// an incremental backup may not have any changes in the storage
+	err = br.ExecuteImportStorage(apiMap, storageDir, exportDetails.StorageExportType, exportDetails.IsIncremental())
 	if err != nil {
 		br.logger.Fatal("error on importing storage files", zap.Error(err))
 		return err
 	}
 
+	// removes the entities deleted after the previous backup
+	if exportDetails.IsIncremental() {
+		err = br.removeDeletedEntities(storage, extractedDir, exportDetails.StorageExportType)
+		if err != nil {
+			br.logger.Fatal("error on removing deleted entities", zap.Error(err))
+			return err
+		}
+	}
+
 	err = storage.Resume()
 	if err != nil {
 		br.logger.Fatal("error on resume a database service", zap.Error(err))
@@ -77,6 +108,13 @@ func (br *BackupRestore) ExecuteRestore(storage storageTY.Plugin, apiMap map[str
 	}
 	br.logger.Info("import database completed", zap.String("timeTaken", time.Since(start).String()))
 
+	// restore metric data, if available in the backup
+	err = br.importMetric(path.Join(extractedDir, MetricBackupDirectoryName), exportDetails.StorageExportType)
+	if err != nil {
+		br.logger.Fatal("error on importing metric data", zap.Error(err))
+		return err
+	}
+
 	// restore directories
 	br.logger.Info("restore directories started")
 	err = br.restoreDirectories(extractedDir, br.directories)
@@ -88,12 +126,41 @@ func (br *BackupRestore) ExecuteRestore(storage storageTY.Plugin, apiMap map[str
 	return nil
 }
 
+// removes the entities not listed on the incremental backup entity ids file
+func (br *BackupRestore) removeDeletedEntities(storage storageTY.Plugin, extractedDir, fileType string) error {
+	filename := fmt.Sprintf("%s.%s", EntityIDsFilename, fileType)
+	if !utils.IsFileExists(path.Join(extractedDir, filename)) {
+		br.logger.Warn("entity ids file not available on the incremental backup, deleted entities are not removed", zap.String("extractedDir", extractedDir), zap.String("filename", filename))
+		return nil
+	}
+	fileBytes, err := utils.ReadFile(extractedDir, filename)
+	if err != nil {
+		return err
+	}
+	entityIDs := map[string][]string{}
+	err = br.unmarshal(fileType, fileBytes, &entityIDs)
+	if err != nil {
+		return err
+	}
+	for entityName, ids := range entityIDs {
+		filters := []storageTY.Filter{{Key: types.KeyID, Operator: storageTY.OperatorNotIn, Value: ids}}
+		deleted, err := storage.Delete(entityName, filters)
+		if err != nil {
+			return err
+		}
+		if deleted > 0 {
+			br.logger.Debug("removed deleted entities", zap.String("entityName", entityName), zap.Int64("count", deleted))
+		}
+	}
+	return nil
+}
+
 // restores the secure and insecure shares, if available in the backup
 // overwrites if the file exists on the destination directory
 func (br *BackupRestore) restoreDirectories(extractedBaseDir string, directories map[string]string) error {
 	for name, dstDirFullPath := range directories {
-		if name == StorageBackupDirectoryName {
-			return fmt.Errorf("name '%s' is reserved for storage database backup", StorageBackupDirectoryName)
+		if !br.isValidDirectoryName(name) {
+			return fmt.Errorf("name '%s' is reserved for database backup", name)
 		}
 
 		// copy a directory if available in the backup
@@ -168,6 +235,48 @@ func (br *BackupRestore) ExecuteImportStorage(apiMap map[string]Backup, sourceDi
 	return nil
 }
 
+// imports metric data into the configured metric database
+func (br *BackupRestore) importMetric(sourceDir, fileType string) error {
+	if !utils.IsDirExists(sourceDir) {
+		return nil
+	}
+	if br.metric == nil {
+		br.logger.Warn("metric database not available, skipping metric data restore", zap.String("sourceDir", sourceDir))
+		return nil
+	}
+
+	start := time.Now()
+	br.logger.Info("importing metric data", zap.String("sourceDir", sourceDir), zap.String("metricDatabase", br.metric.Name()))
+	files, err := utils.ListFiles(sourceDir)
+	if err != nil {
+		return err
+	}
+	count := 0
+	for _, file := range files {
+		if !strings.HasSuffix(file.Name, fileType) {
+			continue
+		}
+		fileBytes, err := utils.ReadFile(sourceDir, file.Name)
+		if err != nil {
+			br.logger.Error("error on reading a file", zap.String("fileName", file.FullPath), zap.Error(err))
+			return err
+		}
+		data := make([]metricTY.InputData, 0)
+		err = br.unmarshal(fileType, fileBytes, &data)
+		if err != nil {
+			return err
+		}
+		err = br.metric.Import(data)
+		if err != nil {
+			br.logger.Error("error on importing metric data", zap.String("fileName", file.FullPath), zap.Error(err))
+			return err
+		}
+		count += len(data)
+	}
+	br.logger.Info("import metric data completed", zap.Int("count", count), zap.String("timeTaken", time.Since(start).String()))
+	return nil
+}
+
 func (br *BackupRestore) updateEntities(api Backup, fileBytes []byte, fileFormat string) error {
 	// get actual type
 	entityType := reflect.TypeOf(api.GetEntityInterface())
@@ -218,29 +327,64 @@ func (br *BackupRestore) getEntityName(filename string) string {
 }
 
 func (br *BackupRestore) ExtractExportedZipFile(exportedZipFile, targetDir, restoreReferenceDir, restoreReferenceFilename string) error {
+	return br.ExtractExportedZipFiles([]string{exportedZipFile}, targetDir, restoreReferenceDir, restoreReferenceFilename)
+}
+
+// ExtractExportedZipFiles extracts and verifies a full backup followed by the incremental backups
+// the files should be in the order of restore, the first one should be a full backup
+func (br *BackupRestore) ExtractExportedZipFiles(exportedZipFiles []string, targetDir, restoreReferenceDir, restoreReferenceFilename string) error {
 	if isRestoreRunning.IsSet() {
 		return errors.New("there is an import job is in progress")
 	}
 	isRestoreRunning.Set()
 	defer isRestoreRunning.Reset()
 
-	zipFilename := path.Base(exportedZipFile)
-	baseDir := strings.TrimSuffix(zipFilename, path.Ext(zipFilename))
-	extractFullPath := path.Join(targetDir, baseDir)
-
-	err := ziputils.Unzip(exportedZipFile, extractFullPath)
-	if err != nil {
-		br.logger.Error("error on unzip", zap.String("exportedZipfile", exportedZipFile), zap.String("extractLocation", extractFullPath), zap.Error(err))
-		return err
+	if len(exportedZipFiles) == 0 {
+		return errors.New("no backup file supplied")
 	}
 
-	// TODO: verify extracted file
+	extractedDirs := make([]string, 0, len(exportedZipFiles))
+	for index, exportedZipFile := range exportedZipFiles {
+		zipFilename := path.Base(exportedZipFile)
+		baseDir := strings.TrimSuffix(zipFilename, path.Ext(zipFilename))
+		extractFullPath := path.Join(targetDir, baseDir)
+
+		err := ziputils.Unzip(exportedZipFile, extractFullPath)
+		if err != nil {
+			br.logger.Error("error on unzip", zap.String("exportedZipfile", exportedZipFile), zap.String("extractLocation", extractFullPath), zap.Error(err))
+			return err
+		}
+		extractedDirs = append(extractedDirs, extractFullPath)
+
+		// verify extracted files
+		err = VerifyChecksums(br.logger, extractFullPath)
+		if err != nil {
+			br.logger.Error("error on verifying backup integrity", zap.String("exportedZipfile", exportedZipFile), zap.Error(err))
+			br.removeDirs(extractedDirs)
+			return err
+		}
+
+		details, err := br.readBackupDetails(extractFullPath)
+		if err != nil {
+			br.removeDirs(extractedDirs)
+			return err
+		}
+		if index == 0 && details.IsIncremental() {
+			br.removeDirs(extractedDirs)
+			return fmt.Errorf("'%s' is an incremental backup, restore should start with a full backup", zipFilename)
+		}
+		if index > 0 && !details.IsIncremental() {
+			br.removeDirs(extractedDirs)
+			return fmt.Errorf("'%s' is not an incremental backup", zipFilename)
+		}
+	}
 
 	systemStartJobs := &config.SystemStartupJobs{
 		Restore: config.StartupRestore{
-			Enabled:            true,
-			ExtractedDirectory: extractFullPath,
-			ClearDatabase:      true,
+			Enabled:                true,
+			ExtractedDirectory:     extractedDirs[0],
+			IncrementalDirectories: extractedDirs[1:],
+			ClearDatabase:          true,
 		},
 	}
 
@@ -258,3 +402,25 @@ func (br *BackupRestore) ExtractExportedZipFile(exportedZipFile, targetDir, rest
 
 	return nil
 }
+
+// returns backup details from a extracted backup
+func (br *BackupRestore) readBackupDetails(extractedDir string) (*BackupDetails, error) {
+	dataBytes, err := utils.ReadFile(extractedDir, BackupDetailsFilename)
+	if err != nil {
+		return nil, err
+	}
+	details := &BackupDetails{}
+	err = yaml.Unmarshal(dataBytes, details)
+	if err != nil {
+		return nil, err
+	}
+	return details, nil
+}
+
+func (br *BackupRestore) removeDirs(dirs []string) {
+	for _, dir := range dirs {
+		if err := utils.RemoveDir(dir); err != nil {
+			br.logger.Error("error on removing a directory", zap.String("directory", dir), zap.Error(err))
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/mycontroller-org/server/v2/pkg/version"
+	metricTY "github.com/mycontroller-org/server/v2/plugin/database/metric/types"
 	storageTY "github.com/mycontroller-org/server/v2/plugin/database/storage/types"
 	"go.uber.org/zap"
 )
@@ -22,15 +23,46 @@ const (
 
 	// storage backup directory name
 	StorageBackupDirectoryName = "storage"
+	// metric backup directory name
+	MetricBackupDirectoryName = "metric"
+	MetricFilePrefix          = "metric"
+
+	// integrity manifest, sha256sum format
+	ChecksumsFilename = "checksums.sha256"
+
+	// ids of all the entities available on an incremental backup, entities not listed are deleted on restore
+	EntityIDsFilename = "entity_ids"
+)
+
+// backup modes
+const (
+	ModeFull        = "full"
+	ModeIncremental = "incremental" // only the entities and files changed since the previous backup
+
+	KeyModifiedOn = "modifiedOn"
 )
 
 // BackupDetails of a export
 type BackupDetails struct {
-	Filename          string            `json:"filename" yaml:"filename"`
-	StorageExportType string            `json:"storage_export_type" yaml:"storage_export_type"`
-	CreatedOn         time.Time         `json:"created_on" yaml:"created_on"`
-	Version           version.Version   `json:"version" yaml:"version"`
-	Directories       map[string]string `json:"directories" yaml:"directories"`
+	Filename          string                 `json:"filename" yaml:"filename"`
+	StorageExportType string                 `json:"storage_export_type" yaml:"storage_export_type"`
+	CreatedOn         time.Time              `json:"created_on" yaml:"created_on"`
+	Version           version.Version        `json:"version" yaml:"version"`
+	Directories       map[string]string      `json:"directories" yaml:"directories"`
+	Mode              string                 `json:"mode" yaml:"mode"`
+	Since             *time.Time             `json:"since,omitempty" yaml:"since,omitempty"`
+	Metric            *metricTY.ExportConfig `json:"metric,omitempty" yaml:"metric,omitempty"` // time range of the metric data, if included
+}
+
+// ExportOptions of a backup
+type ExportOptions struct {
+	Since  *time.Time             // if set, exports only the entities and files modified on or after since
+	Metric *metricTY.ExportConfig // if set, exports the metric data of the time range
+}
+
+// IsIncremental returns true, if the backup contains only the changes since the previous backup
+func (bd *BackupDetails) IsIncremental() bool {
+	return bd.Mode == ModeIncremental
 }
 
 // BackupFile details
@@ -43,6 +75,8 @@ type BackupFile struct {
 	FileSize     int64     `json:"fileSize" yaml:"fileSize"`
 	FullPath     string    `json:"fullPath" yaml:"fullPath"`
 	ModifiedOn   time.Time `json:"modifiedOn" yaml:"modifiedOn"`
+	Mode         string    `json:"mode" yaml:"mode"`
+	Encrypted    bool      `json:"encrypted" yaml:"encrypted"`
 }
 
 // OnDemandBackupConfig config
@@ -53,6 +87,14 @@ type OnDemandBackupConfig struct {
 	Handler           string `json:"handler" yaml:"handler"`
 }
 
+// RestoreConfig details
+// passphrase or identity required to restore an encrypted backup
+type RestoreConfig struct {
+	ID         string `json:"id" yaml:"id"`
+	Passphrase string `json:"passphrase" yaml:"passphrase"`
+	Identity   string `json:"identity" yaml:"identity"` // age identity, ex: AGE-SECRET-KEY-1...
+}
+
 // BackupLocationDisk details
 type BackupLocationDisk struct {
 	TargetDirectory string
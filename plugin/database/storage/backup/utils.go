@@ -1,8 +1,17 @@
 package backup
 
 import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
 	"path"
 	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/mycontroller-org/server/v2/pkg/utils"
 	"go.uber.org/zap"
@@ -10,6 +19,12 @@ import (
 
 // copies files from a location to another location
 func CopyFiles(logger *zap.Logger, sourceDir, dstDir string, overwrite bool) error {
+	return copyFiles(logger, sourceDir, dstDir, overwrite, nil)
+}
+
+// copies files from a location to another location
+// if since is set, copies only the files modified on or after since
+func copyFiles(logger *zap.Logger, sourceDir, dstDir string, overwrite bool, since *time.Time) error {
 	err := utils.CreateDir(dstDir)
 	if err != nil {
 		return err
@@ -21,6 +36,9 @@ func CopyFiles(logger *zap.Logger, sourceDir, dstDir string, overwrite bool) err
 	}
 
 	for _, file := range files {
+		if since != nil && file.ModifiedTime.Before(*since) {
+			continue
+		}
 		// have to copy recursive directories too
 		// get relative path and append with target directory
 		relativePath, err := filepath.Rel(sourceDir, file.FullPath)
@@ -38,3 +56,111 @@ func CopyFiles(logger *zap.Logger, sourceDir, dstDir string, overwrite bool) err
 
 	return nil
 }
+
+// WriteChecksums writes sha256 checksum of all the files from the directory
+// uses sha256sum format, can be verified with "sha256sum -c checksums.sha256"
+func WriteChecksums(dir string) error {
+	files, err := utils.ListFiles(dir)
+	if err != nil {
+		return err
+	}
+
+	lines := make([]string, 0, len(files))
+	for _, file := range files {
+		relativePath, err := filepath.Rel(dir, file.FullPath)
+		if err != nil {
+			return err
+		}
+		if relativePath == ChecksumsFilename {
+			continue
+		}
+		checksum, err := fileChecksum(file.FullPath)
+		if err != nil {
+			return err
+		}
+		lines = append(lines, fmt.Sprintf("%s  %s", checksum, filepath.ToSlash(relativePath)))
+	}
+	sort.Strings(lines)
+
+	return utils.WriteFile(dir, ChecksumsFilename, []byte(strings.Join(lines, "\n")+"\n"))
+}
+
+// VerifyChecksums verifies the files from the directory against the integrity manifest
+// backups taken before the integrity manifest introduced are not verified
+func VerifyChecksums(logger *zap.Logger, dir string) error {
+	checksumsFile := path.Join(dir, ChecksumsFilename)
+	if !utils.IsFileExists(checksumsFile) {
+		logger.Warn("integrity manifest not found on the backup, skipping verification", zap.String("directory", dir))
+		return nil
+	}
+
+	file, err := os.Open(checksumsFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	expected := map[string]string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		checksum, name, found := strings.Cut(line, "  ")
+		if !found {
+			return fmt.Errorf("invalid entry on integrity manifest: %s", line)
+		}
+		expected[name] = checksum
+	}
+	if err = scanner.Err(); err != nil {
+		return err
+	}
+
+	files, err := utils.ListFiles(dir)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		relativePath, err := filepath.Rel(dir, file.FullPath)
+		if err != nil {
+			return err
+		}
+		relativePath = filepath.ToSlash(relativePath)
+		if relativePath == ChecksumsFilename {
+			continue
+		}
+		expectedChecksum, found := expected[relativePath]
+		if !found {
+			return fmt.Errorf("file '%s' not listed on the integrity manifest", relativePath)
+		}
+		checksum, err := fileChecksum(file.FullPath)
+		if err != nil {
+			return err
+		}
+		if checksum != expectedChecksum {
+			return fmt.Errorf("checksum mismatch on file '%s'", relativePath)
+		}
+		delete(expected, relativePath)
+	}
+	for name := range expected {
+		return fmt.Errorf("file '%s' missing on the backup", name)
+	}
+	return nil
+}
+
+// returns sha256 checksum of a file in hex format
+func fileChecksum(filename string) (string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	_, err = io.Copy(hash, file)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -130,18 +130,8 @@ func (p *Provider) getTimestamp(gwCfg *gwTY.Config) string {
 // get node id
 func (p *Provider) getNodeID(gatewayID string) string {
 	var reservedIDsString []string
-	updateNodeIDs := func(item interface{}) bool {
-		ids, ok := item.(*[]string)
-		if !ok {
-			p.logger.Error("error on data conversion", zap.String("receivedType", fmt.Sprintf("%T", item)))
-			return false
-		}
-		reservedIDsString = *ids
-		return false
-	}
 	filter := map[string]interface{}{types.KeyGatewayID: gatewayID}
-	out := make([]string, 0)
-	err := query.QueryResource(p.logger, p.bus, "", rsTY.TypeNode, rsTY.CommandGetIds, filter, updateNodeIDs, &out, queryTimeout)
+	err := query.QueryResource(p.logger, p.bus, "", rsTY.TypeNode, rsTY.CommandGetIds, filter, &reservedIDsString, queryTimeout)
 	if err != nil {
 		p.logger.Error("error on finding list of nodes", zap.String("gatewayId", gatewayID), zap.Error(err))
 		return ""
@@ -37,7 +37,6 @@ const (
 	firmwarePurgeJobCron      = "0 */5 * * * *"            // purge loaded firmware, if not used for a while
 	firmwarePurgeInactiveTime = 15 * time.Minute           // firmware inactive time, eligible for purging
 	queryTimeout              = 2 * time.Second            // query timout
-	queryFirmwareFileTimeout  = 10 * time.Second           // query timout for firmware file
 	OTABlockOrderForward      = "forward"                  // forward order of block will be asked, 0,1,2...
 	OTABlockOrderReverse      = "reverse"                  // reverse order of block will be asked, ...3,2,1,0
 
@@ -95,29 +95,23 @@ func (p *Provider) updateNode(gatewayID, nodeID string) error {
 		types.KeyNodeID:    nodeID,
 	}
 
-	addToStore := func(item interface{}) bool {
-		node, ok := item.(*nodeTY.Node)
-		if !ok {
-			p.logger.Error("error on data conversion", zap.String("receivedType", fmt.Sprintf("%T", item)))
-			return false
-		}
-		nodeStore.Add(p.getNodeStoreID(node.GatewayID, node.NodeID), node)
-		return false
+	node := &nodeTY.Node{}
+	err := query.QueryResource(p.logger, p.bus, "", rsTY.TypeNode, rsTY.CommandGet, ids, node, queryTimeout)
+	if err != nil {
+		return err
 	}
-	return query.QueryResource(p.logger, p.bus, "", rsTY.TypeNode, rsTY.CommandGet, ids, addToStore, &nodeTY.Node{}, queryTimeout)
+	nodeStore.Add(p.getNodeStoreID(node.GatewayID, node.NodeID), node)
+	return nil
 }
 
 func (p *Provider) updateFirmware(id string) error {
-	addToStore := func(item interface{}) bool {
-		firmware, ok := item.(*firmwareTY.Firmware)
-		if !ok {
-			p.logger.Error("error on data conversion", zap.String("receivedType", fmt.Sprintf("%T", item)))
-			return false
-		}
-		fwStore.Add(firmware.ID, firmware)
-		return false
+	firmware := &firmwareTY.Firmware{}
+	err := query.QueryResource(p.logger, p.bus, id, rsTY.TypeFirmware, rsTY.CommandGet, nil, firmware, queryTimeout)
+	if err != nil {
+		return err
 	}
-	return query.QueryResource(p.logger, p.bus, id, rsTY.TypeFirmware, rsTY.CommandGet, nil, addToStore, &firmwareTY.Firmware{}, queryTimeout)
+	fwStore.Add(firmware.ID, firmware)
+	return nil
 }
 
 // getFirmwareRaw func
@@ -145,43 +139,45 @@ func (p *Provider) getFirmwareRaw(id string, fwTypeID, fwVersionID uint16) (*fir
 	return nil, fmt.Errorf("firmware not available. id:%v", id)
 }
 
+// updateFirmwareFile fetches the firmware file block by block, each block is a request to the resource service
 func (p *Provider) updateFirmwareFile(id string, fwTypeID, fwVersionID uint16) error {
 	var hexBytes []byte
-	addToStore := func(item interface{}) bool {
-		fwBlock, ok := item.(*firmwareTY.FirmwareBlock)
-		if !ok {
-			p.logger.Error("error on data conversion", zap.String("receivedType", fmt.Sprintf("%T", item)))
-			return false
+	for blockNumber := 0; ; blockNumber++ {
+		fwBlock := &firmwareTY.FirmwareBlock{}
+		err := query.QueryResource(p.logger, p.bus, id, rsTY.TypeFirmware, rsTY.CommandBlocks, blockNumber, fwBlock, queryTimeout)
+		if err != nil {
+			return fmt.Errorf("error on getting firmware block. firmwareId:%s, blockNumber:%d, error:%w", id, blockNumber, err)
 		}
 		if hexBytes == nil {
 			hexBytes = make([]byte, fwBlock.TotalBytes)
 		}
 		startPos := int(firmwareTY.BlockSize * fwBlock.BlockNumber)
-		for offset, byteData := range fwBlock.Data {
-			hexBytes[startPos+offset] = byteData
+		if startPos+len(fwBlock.Data) > len(hexBytes) {
+			return fmt.Errorf("invalid firmware block received. firmwareId:%s, blockNumber:%d", id, fwBlock.BlockNumber)
 		}
+		copy(hexBytes[startPos:], fwBlock.Data)
 		if fwBlock.IsFinal {
-			receivedCheckSum := fmt.Sprintf("sha256:%x", sha256.Sum256(hexBytes))
-			fw, err := p.getFirmware(id)
-			if err != nil {
-				p.logger.Error("error on getting firmare config", zap.Error(err), zap.String("firmwareId", id))
-				return false
-			}
-			if fw.File.Checksum == receivedCheckSum {
-				// convert the hex file to raw format
-				fwRaw, err := p.hexByteToLocalFormat(fwTypeID, fwVersionID, hexBytes, firmwareBlockSize)
-				if err != nil {
-					p.logger.Error("error on converting hex to local format", zap.String("firmwareId", id), zap.Error(err))
-					return false
-				}
-				fwRawStore.Add(id, fwRaw)
-			} else {
-				p.logger.Info("received firmware checksum mismatch", zap.String("fwID", fw.ID), zap.String("remote", fw.File.Checksum), zap.String("received", receivedCheckSum))
-			}
-			return false
+			break
 		}
-		return true // continue
 	}
 
-	return query.QueryResource(p.logger, p.bus, id, rsTY.TypeFirmware, rsTY.CommandBlocks, nil, addToStore, &firmwareTY.FirmwareBlock{}, queryFirmwareFileTimeout)
+	receivedCheckSum := fmt.Sprintf("sha256:%x", sha256.Sum256(hexBytes))
+	fw, err := p.getFirmware(id)
+	if err != nil {
+		p.logger.Error("error on getting firmare config", zap.Error(err), zap.String("firmwareId", id))
+		return err
+	}
+	if fw.File.Checksum != receivedCheckSum {
+		p.logger.Info("received firmware checksum mismatch", zap.String("fwID", fw.ID), zap.String("remote", fw.File.Checksum), zap.String("received", receivedCheckSum))
+		return fmt.Errorf("firmware checksum mismatch. firmwareId:%s", id)
+	}
+
+	// convert the hex file to raw format
+	fwRaw, err := p.hexByteToLocalFormat(fwTypeID, fwVersionID, hexBytes, firmwareBlockSize)
+	if err != nil {
+		p.logger.Error("error on converting hex to local format", zap.String("firmwareId", id), zap.Error(err))
+		return err
+	}
+	fwRawStore.Add(id, fwRaw)
+	return nil
 }
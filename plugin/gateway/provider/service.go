@@ -163,7 +163,8 @@ func (s *Service) startMessageListener() {
 			s.logger.Warn("received message with empty gatewayId", zap.Any("message", msg))
 			return
 		}
-		s.messageQueue.Produce(msg)
+		// acknowledged to the bus, once the message posted to the provider
+		s.messageQueue.ProduceWithAck(msg, event.TakeAck())
 	})
 
 	if err != nil {
@@ -9,7 +9,6 @@ import (
 
 	"github.com/mycontroller-org/server/v2/pkg/types"
 	"github.com/mycontroller-org/server/v2/pkg/utils"
-	helper "github.com/mycontroller-org/server/v2/pkg/utils/filter_sort"
 	loggerUtils "github.com/mycontroller-org/server/v2/pkg/utils/logger"
 	busTY "github.com/mycontroller-org/server/v2/plugin/bus/types"
 	storageTY "github.com/mycontroller-org/server/v2/plugin/database/storage/types"
@@ -32,8 +31,12 @@ type Config struct {
 	StorageExportType    string
 	TargetDirectory      string
 	RetentionCount       int
-	IncludeSecureShare   bool // include secure directory on the backup
-	IncludeInsecureShare bool // include insecure directory on the backup
+	IncludeSecureShare   bool   // include secure directory on the backup
+	IncludeInsecureShare bool   // include insecure directory on the backup
+	Mode                 string // full or incremental, default full
+	FullBackupEvery      int    // incremental mode, takes a full backup after these many incremental backups
+	Encryption           *backupUtil.EncryptionConfig
+	Metric               *backupUtil.MetricConfig // include metric data on the backup
 }
 
 // Client struct
@@ -177,8 +180,13 @@ func (c *Client) triggerBackup(spec map[string]interface{}) error {
 		return fmt.Errorf("environment '%s' not set", types.ENV_DIR_DATA_STORAGE)
 	}
 
+	options, err := c.getOptions(targetDirectory, prefix, targetExportType)
+	if err != nil {
+		return err
+	}
+
 	// start backup
-	filename, err := backupUtil.Backup(c.ctx, c.logger, baseDir, prefix, targetExportType, c.cfg.IncludeSecureShare, c.cfg.IncludeInsecureShare, c.storage, c.bus)
+	filename, err := backupUtil.Backup(c.ctx, c.logger, baseDir, prefix, targetExportType, c.cfg.IncludeSecureShare, c.cfg.IncludeInsecureShare, c.storage, c.bus, options)
 	if err != nil {
 		return err
 	}
@@ -216,31 +224,29 @@ func (c *Client) executeRetentionCount(targetDir, prefix, targetExportType strin
 		return err
 	}
 
-	prefix = fmt.Sprintf("%s_%s_%s", prefix, backupUtil.BackupIdentifier, targetExportType)
-	matchingFiles := make([]interface{}, 0)
-	for _, file := range files {
-		if strings.HasPrefix(file.Name, prefix) {
-			matchingFiles = append(matchingFiles, file)
-		}
-	}
-
-	// sort by filename
-	sortBy := []storageTY.Sort{{Field: "name", OrderBy: storageTY.SortByDESC}}
-	ordered, _ := helper.Sort(matchingFiles, &storageTY.Pagination{SortBy: sortBy, Limit: -1, Offset: 0})
-
-	if len(ordered) > retentionCount {
-		deleteFiles := ordered[retentionCount:]
-		for _, f := range deleteFiles {
-			if file, ok := f.(types.File); ok {
-				c.logger.Debug("deleting a file", zap.Any("file", file))
-				filename := fmt.Sprintf("%s/%s", targetDir, file.Name)
-				err = utils.RemoveFileOrEmptyDir(filename)
-				if err != nil {
-					c.logger.Error("error on deleting a file", zap.Any("file", file), zap.Error(err))
-				}
-			}
+	deleteFiles := backupUtil.GetRetentionDeleteList(files, backupUtil.GetSeries(prefix, targetExportType), retentionCount)
+	for _, file := range deleteFiles {
+		c.logger.Debug("deleting a file", zap.Any("file", file))
+		filename := fmt.Sprintf("%s/%s", targetDir, file.Name)
+		err = utils.RemoveFileOrEmptyDir(filename)
+		if err != nil {
+			c.logger.Error("error on deleting a file", zap.Any("file", file), zap.Error(err))
 		}
 	}
 
 	return nil
 }
+
+// returns backup options based on the existing backups on the target location
+func (c *Client) getOptions(targetDir, prefix, targetExportType string) (backupUtil.Options, error) {
+	options := backupUtil.Options{Encryption: c.cfg.Encryption, Metric: c.cfg.Metric}
+	if c.cfg.Mode != backupUtil.ModeIncremental {
+		return options, nil
+	}
+	files, err := utils.ListFiles(targetDir)
+	if err != nil {
+		return options, err
+	}
+	options.Since = backupUtil.GetIncrementalSince(files, backupUtil.GetSeries(prefix, targetExportType), c.cfg.FullBackupEvery)
+	return options, nil
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/mycontroller-org/server/v2/pkg/types"
 	"github.com/mycontroller-org/server/v2/plugin/handler/backup/disk"
+	"github.com/mycontroller-org/server/v2/plugin/handler/backup/remote"
 	backupUtils "github.com/mycontroller-org/server/v2/plugin/handler/backup/util"
 	handlerTY "github.com/mycontroller-org/server/v2/plugin/handler/types"
 )
@@ -33,6 +34,9 @@ func New(ctx context.Context, cfg *handlerTY.Config) (handlerTY.Plugin, error) {
 	case backupUtils.ProviderDisk:
 		return disk.New(ctx, cfg)
 
+	case backupUtils.ProviderS3, backupUtils.ProviderSFTP, backupUtils.ProviderWebDAV:
+		return remote.New(ctx, cfg)
+
 	default:
 		return nil, fmt.Errorf("unknown backup provider:%s", providerType)
 	}
@@ -0,0 +1,250 @@
+package remote
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/mycontroller-org/server/v2/pkg/types"
+	"github.com/mycontroller-org/server/v2/pkg/utils"
+	loggerUtils "github.com/mycontroller-org/server/v2/pkg/utils/logger"
+	busTY "github.com/mycontroller-org/server/v2/plugin/bus/types"
+	storageTY "github.com/mycontroller-org/server/v2/plugin/database/storage/types"
+	backupUtil "github.com/mycontroller-org/server/v2/plugin/handler/backup/util"
+	handlerTY "github.com/mycontroller-org/server/v2/plugin/handler/types"
+	"go.uber.org/zap"
+)
+
+const (
+	loggerName = "handler_backup_remote"
+)
+
+// Config of remote backup client
+// provider specific fields are defined on S3Config, SFTPConfig and WebDAVConfig
+type Config struct {
+	Disabled             string // used globally
+	Type                 string // used globally
+	ProviderType         string // used globally
+	Prefix               string
+	StorageExportType    string
+	RetentionCount       int
+	IncludeSecureShare   bool   // include secure directory on the backup
+	IncludeInsecureShare bool   // include insecure directory on the backup
+	Mode                 string // full or incremental, default full
+	FullBackupEvery      int    // incremental mode, takes a full backup after these many incremental backups
+	Encryption           *backupUtil.EncryptionConfig
+	Metric               *backupUtil.MetricConfig // include metric data on the backup
+}
+
+// Client struct
+type Client struct {
+	ctx        context.Context
+	handlerCfg *handlerTY.Config
+	cfg        *Config
+	logger     *zap.Logger
+	storage    storageTY.Plugin
+	bus        busTY.Plugin
+}
+
+// remote backup client, used for s3, sftp and webdav providers
+func New(ctx context.Context, cfg *handlerTY.Config) (*Client, error) {
+	logger, err := loggerUtils.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	storage, err := storageTY.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	bus, err := busTY.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &Config{}
+	err = utils.MapToStruct(utils.TagNameNone, cfg.Spec, config)
+	if err != nil {
+		return nil, err
+	}
+
+	if !IsRemoteProvider(config.ProviderType) {
+		return nil, fmt.Errorf("unknown remote backup provider:%s", config.ProviderType)
+	}
+
+	client := &Client{
+		ctx:        ctx,
+		handlerCfg: cfg,
+		cfg:        config,
+		logger:     logger.Named(loggerName),
+		storage:    storage,
+		bus:        bus,
+	}
+
+	return client, nil
+}
+
+func (c *Client) Name() string {
+	return fmt.Sprintf("backup_%s", c.cfg.ProviderType)
+}
+
+// Start func
+func (c *Client) Start() error {
+	return nil
+}
+
+// Close Func
+func (c *Client) Close() error {
+	return nil
+}
+
+// State func
+func (c *Client) State() *types.State {
+	if c.handlerCfg != nil {
+		if c.handlerCfg.State == nil {
+			c.handlerCfg.State = &types.State{}
+		}
+		return c.handlerCfg.State
+	}
+	return &types.State{}
+}
+
+// Post func
+func (c *Client) Post(parameters map[string]interface{}) error {
+	for name, rawParameter := range parameters {
+		parameter, ok := handlerTY.IsTypeOf(rawParameter, handlerTY.DataTypeBackup)
+		if !ok {
+			continue
+		}
+		c.logger.Debug("data", zap.Any("name", name), zap.Any("parameter", parameter))
+
+		backupConfigData := handlerTY.BackupData{}
+		err := utils.MapToStruct(utils.TagNameNone, parameter, &backupConfigData)
+		if err != nil {
+			c.logger.Error("error on converting backup config data", zap.Error(err), zap.String("name", name), zap.Any("parameter", parameter))
+			continue
+		}
+
+		if backupConfigData.ProviderType != c.cfg.ProviderType {
+			continue
+		}
+
+		err = c.triggerBackup(parameter)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Client) triggerBackup(spec map[string]interface{}) error {
+	newConfig := &Config{}
+	err := utils.MapToStruct(utils.TagNameNone, spec, newConfig)
+	if err != nil {
+		return err
+	}
+
+	c.logger.Debug("data", zap.Any("config", newConfig))
+
+	targetExportType := c.cfg.StorageExportType
+	prefix := c.cfg.Prefix
+	retentionCount := c.cfg.RetentionCount
+
+	if newConfig.StorageExportType != "" {
+		targetExportType = newConfig.StorageExportType
+	}
+
+	if newConfig.Prefix != "" {
+		prefix = newConfig.Prefix
+	}
+
+	if prefix == "" {
+		prefix = c.handlerCfg.ID
+	}
+
+	if newConfig.RetentionCount != 0 {
+		retentionCount = newConfig.RetentionCount
+	}
+
+	start := time.Now()
+	c.logger.Debug("Backup job triggered", zap.String("handler", c.handlerCfg.ID), zap.String("provider", c.cfg.ProviderType))
+
+	// get base directory for storage
+	baseDir := types.GetEnvString(types.ENV_DIR_DATA_STORAGE)
+	if baseDir == "" {
+		return fmt.Errorf("environment '%s' not set", types.ENV_DIR_DATA_STORAGE)
+	}
+
+	// connect to the remote location before taking the backup
+	remoteStorage, err := NewStorage(c.cfg.ProviderType, c.handlerCfg.Spec)
+	if err != nil {
+		return err
+	}
+	defer remoteStorage.Close()
+
+	options, err := c.getOptions(remoteStorage, prefix, targetExportType)
+	if err != nil {
+		return err
+	}
+
+	// start backup
+	filename, err := backupUtil.Backup(c.ctx, c.logger, baseDir, prefix, targetExportType, c.cfg.IncludeSecureShare, c.cfg.IncludeInsecureShare, c.storage, c.bus, options)
+	if err != nil {
+		return err
+	}
+
+	// upload the file to the remote location and remove the local copy
+	err = remoteStorage.Upload(filename, filepath.Base(filename))
+	removeErr := utils.RemoveFileOrEmptyDir(filename)
+	if err != nil {
+		return err
+	}
+	if removeErr != nil {
+		return removeErr
+	}
+
+	c.logger.Debug("Export job completed", zap.String("handler", c.handlerCfg.ID), zap.String("timeTaken", time.Since(start).String()))
+
+	err = c.executeRetentionCount(remoteStorage, prefix, targetExportType, retentionCount)
+	if err != nil {
+		c.logger.Error("error on executing retention count", zap.String("handler", c.handlerCfg.ID), zap.Error(err))
+	}
+
+	return nil
+}
+
+func (c *Client) executeRetentionCount(remoteStorage Storage, prefix, targetExportType string, retentionCount int) error {
+	if retentionCount <= 0 {
+		return nil
+	}
+
+	files, err := remoteStorage.List()
+	if err != nil {
+		return err
+	}
+
+	deleteFiles := backupUtil.GetRetentionDeleteList(files, backupUtil.GetSeries(prefix, targetExportType), retentionCount)
+	for _, file := range deleteFiles {
+		c.logger.Debug("deleting a file", zap.Any("file", file))
+		err = remoteStorage.Delete(file.Name)
+		if err != nil {
+			c.logger.Error("error on deleting a file", zap.Any("file", file), zap.Error(err))
+		}
+	}
+
+	return nil
+}
+
+// returns backup options based on the existing backups on the remote location
+func (c *Client) getOptions(remoteStorage Storage, prefix, targetExportType string) (backupUtil.Options, error) {
+	options := backupUtil.Options{Encryption: c.cfg.Encryption, Metric: c.cfg.Metric}
+	if c.cfg.Mode != backupUtil.ModeIncremental {
+		return options, nil
+	}
+	files, err := remoteStorage.List()
+	if err != nil {
+		return options, err
+	}
+	options.Since = backupUtil.GetIncrementalSince(files, backupUtil.GetSeries(prefix, targetExportType), c.cfg.FullBackupEvery)
+	return options, nil
+}
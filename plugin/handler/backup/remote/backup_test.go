@@ -0,0 +1,180 @@
+package remote
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	entityAPI "github.com/mycontroller-org/server/v2/pkg/api/entities"
+	encryptionAPI "github.com/mycontroller-org/server/v2/pkg/encryption"
+	coreScheduler "github.com/mycontroller-org/server/v2/pkg/service/core_scheduler"
+	"github.com/mycontroller-org/server/v2/pkg/types"
+	"github.com/mycontroller-org/server/v2/pkg/types/cmap"
+	dashboardTY "github.com/mycontroller-org/server/v2/pkg/types/dashboard"
+	schedulerTY "github.com/mycontroller-org/server/v2/pkg/types/scheduler"
+	loggerUtils "github.com/mycontroller-org/server/v2/pkg/utils/logger"
+	"github.com/mycontroller-org/server/v2/pkg/utils/ziputils"
+	"github.com/mycontroller-org/server/v2/plugin/bus/embedded"
+	busTY "github.com/mycontroller-org/server/v2/plugin/bus/types"
+	"github.com/mycontroller-org/server/v2/plugin/database/storage/memory"
+	storageTY "github.com/mycontroller-org/server/v2/plugin/database/storage/types"
+	backupUtil "github.com/mycontroller-org/server/v2/plugin/handler/backup/util"
+	handlerTY "github.com/mycontroller-org/server/v2/plugin/handler/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newTestContext(t *testing.T) (context.Context, *entityAPI.API) {
+	ctx := loggerUtils.WithContext(context.Background(), zap.NewNop())
+	ctx = schedulerTY.WithContext(ctx, coreScheduler.New())
+
+	storage, err := memory.New(ctx, cmap.CustomMap{})
+	require.NoError(t, err)
+	ctx = storageTY.WithContext(ctx, storage)
+
+	bus, err := embedded.NewClient(ctx, cmap.CustomMap{})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = bus.Close() })
+	ctx = busTY.WithContext(ctx, bus)
+	enc := encryptionAPI.New(zap.NewNop(), "0123456789abcdef0123456789abcdef", nil, "")
+	ctx = encryptionAPI.WithContext(ctx, enc)
+
+	entities, err := entityAPI.New(ctx)
+	require.NoError(t, err)
+	return entityAPI.WithContext(ctx, entities), entities
+}
+
+// returns the content of the exported storage files
+func getExportedContent(t *testing.T, remoteStorage Storage, name, passphrase string) string {
+	tmpDir := t.TempDir()
+	zipFile := path.Join(tmpDir, name)
+	require.NoError(t, remoteStorage.Download(name, zipFile))
+	if passphrase != "" {
+		decryptedFile := strings.TrimSuffix(zipFile, backupUtil.EncryptedFileExtension)
+		require.NoError(t, backupUtil.DecryptFile(zipFile, decryptedFile, passphrase, ""))
+		zipFile = decryptedFile
+	}
+	extractedDir := path.Join(tmpDir, "extracted")
+	require.NoError(t, ziputils.Unzip(zipFile, extractedDir))
+
+	content := ""
+	err := filepath.WalkDir(extractedDir, func(filename string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(filename)
+		content += string(data)
+		return err
+	})
+	require.NoError(t, err)
+	return content
+}
+
+func TestClientIncrementalBackup(t *testing.T) {
+	tests := []struct {
+		name         string
+		providerType string
+		passphrase   string
+	}{
+		{name: "s3", providerType: backupUtil.ProviderS3},
+		{name: "sftp", providerType: backupUtil.ProviderSFTP},
+		{name: "webdav", providerType: backupUtil.ProviderWebDAV},
+		{name: "webdav encrypted", providerType: backupUtil.ProviderWebDAV, passphrase: "backup_passphrase"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			baseDir := t.TempDir()
+			t.Setenv(types.ENV_DIR_DATA_STORAGE, baseDir)
+			t.Setenv(types.ENV_DIR_DATA_FIRMWARE, t.TempDir())
+			ctx, entities := newTestContext(t)
+
+			spec := cmap.CustomMap(startServer(t, test.providerType))
+			spec["directory"] = "mc"
+			spec[backupUtil.KeyProviderType] = test.providerType
+			spec["prefix"] = "test"
+			spec["storageExportType"] = "yaml"
+			spec["mode"] = backupUtil.ModeIncremental
+			if test.passphrase != "" {
+				spec["encryption"] = map[string]interface{}{"passphrase": test.passphrase}
+			}
+			client, err := New(ctx, &handlerTY.Config{ID: "backup", Spec: spec})
+			require.NoError(t, err)
+			remoteStorage, err := NewStorage(test.providerType, spec)
+			require.NoError(t, err)
+			t.Cleanup(func() { _ = remoteStorage.Close() })
+
+			require.NoError(t, entities.Dashboard().Save(&dashboardTY.Config{ID: "first_dashboard"}))
+			require.NoError(t, client.triggerBackup(map[string]interface{}{}))
+			require.NoError(t, entities.Dashboard().Save(&dashboardTY.Config{ID: "second_dashboard"}))
+			require.NoError(t, client.triggerBackup(map[string]interface{}{}))
+
+			names := listNames(t, remoteStorage)
+			require.Len(t, names, 2)
+			details := make([]*backupUtil.FileDetails, 0)
+			for _, name := range names {
+				fileDetails, ok := backupUtil.ParseFilename(name)
+				require.True(t, ok, name)
+				assert.Equal(t, "test_mc_backup_yaml", fileDetails.Series)
+				assert.Equal(t, test.passphrase != "", fileDetails.Encrypted, name)
+				details = append(details, fileDetails)
+			}
+			// full backup followed by the incremental backup
+			assert.False(t, details[0].Incremental)
+			assert.True(t, details[1].Incremental)
+
+			assert.Contains(t, getExportedContent(t, remoteStorage, names[0], test.passphrase), "first_dashboard")
+			assert.Contains(t, getExportedContent(t, remoteStorage, names[1], test.passphrase), "second_dashboard")
+
+			// local copies removed
+			localFiles, err := os.ReadDir(baseDir)
+			require.NoError(t, err)
+			assert.Empty(t, localFiles)
+		})
+	}
+}
+
+func TestClientRetentionCount(t *testing.T) {
+	files := []string{
+		"test_mc_backup_yaml_20240101_000000.zip",
+		"test_mc_backup_yaml_20240102_000000_incremental.zip",
+		"test_mc_backup_yaml_20240103_000000.zip.age",
+		"test_mc_backup_yaml_20240104_000000_incremental.zip.age",
+		"test_mc_backup_json_20240101_000000.zip",  // another export type
+		"other_mc_backup_yaml_20240101_000000.zip", // another prefix
+		"notes.txt",
+	}
+	expected := []string{
+		"notes.txt",
+		"other_mc_backup_yaml_20240101_000000.zip",
+		"test_mc_backup_json_20240101_000000.zip",
+		"test_mc_backup_yaml_20240103_000000.zip.age",
+		"test_mc_backup_yaml_20240104_000000_incremental.zip.age",
+	}
+
+	for _, providerType := range testProviders {
+		t.Run(providerType, func(t *testing.T) {
+			remoteStorage, err := NewStorage(providerType, startServer(t, providerType))
+			require.NoError(t, err)
+			t.Cleanup(func() { _ = remoteStorage.Close() })
+
+			localFile := path.Join(t.TempDir(), "backup.zip")
+			require.NoError(t, os.WriteFile(localFile, []byte("data"), 0600))
+			for _, name := range files {
+				require.NoError(t, remoteStorage.Upload(localFile, name))
+			}
+
+			client := &Client{logger: zap.NewNop(), handlerCfg: &handlerTY.Config{ID: "backup"}}
+			// retention count disabled
+			require.NoError(t, client.executeRetentionCount(remoteStorage, "test", "yaml", 0))
+			assert.Len(t, listNames(t, remoteStorage), len(files))
+
+			require.NoError(t, client.executeRetentionCount(remoteStorage, "test", "yaml", 1))
+			assert.Equal(t, expected, listNames(t, remoteStorage))
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package remote
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/mycontroller-org/server/v2/pkg/types"
+)
+
+// S3Config of S3 compatible storage. ex: AWS S3, MinIO
+type S3Config struct {
+	Endpoint   string // host:port, ex: s3.amazonaws.com, localhost:9000
+	Region     string
+	Bucket     string
+	AccessKey  string
+	SecretKey  string
+	Directory  string // object key prefix
+	DisableTLS bool
+	Insecure   bool
+	PathStyle  bool // required on MinIO without the DNS bucket lookup
+}
+
+type s3Storage struct {
+	cfg    *S3Config
+	client *minio.Client
+}
+
+func newS3Storage(cfg *S3Config) (Storage, error) {
+	if cfg.Endpoint == "" || cfg.Bucket == "" {
+		return nil, errors.New("s3 endpoint and bucket are required")
+	}
+	options := &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: !cfg.DisableTLS,
+		Region: cfg.Region,
+	}
+	if cfg.PathStyle {
+		options.BucketLookup = minio.BucketLookupPath
+	}
+	if cfg.Insecure {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+		options.Transport = transport
+	}
+	client, err := minio.New(cfg.Endpoint, options)
+	if err != nil {
+		return nil, err
+	}
+	return &s3Storage{cfg: cfg, client: client}, nil
+}
+
+func (s *s3Storage) Upload(localFile, name string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	_, err := s.client.FPutObject(ctx, s.cfg.Bucket, joinKey(s.cfg.Directory, name), localFile, minio.PutObjectOptions{ContentType: "application/zip"})
+	return err
+}
+
+func (s *s3Storage) Download(name, localFile string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return s.client.FGetObject(ctx, s.cfg.Bucket, joinKey(s.cfg.Directory, name), localFile, minio.GetObjectOptions{})
+}
+
+func (s *s3Storage) List() ([]types.File, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	prefix := joinKey(s.cfg.Directory, "")
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	files := make([]types.File, 0)
+	for object := range s.client.ListObjects(ctx, s.cfg.Bucket, minio.ListObjectsOptions{Prefix: prefix}) {
+		if object.Err != nil {
+			return nil, object.Err
+		}
+		if strings.HasSuffix(object.Key, "/") { // common prefix, a directory
+			continue
+		}
+		files = append(files, types.File{
+			Name:         path.Base(object.Key),
+			Size:         object.Size,
+			ModifiedTime: object.LastModified,
+			FullPath:     path.Join(s.cfg.Bucket, object.Key),
+		})
+	}
+	return files, nil
+}
+
+func (s *s3Storage) Delete(name string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return s.client.RemoveObject(ctx, s.cfg.Bucket, joinKey(s.cfg.Directory, name), minio.RemoveObjectOptions{})
+}
+
+func (s *s3Storage) Close() error {
+	return nil
+}
@@ -0,0 +1,169 @@
+package remote
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"strconv"
+
+	"github.com/mycontroller-org/server/v2/pkg/types"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// SFTPConfig of sftp storage
+type SFTPConfig struct {
+	Host       string
+	Port       int // default 22
+	Username   string
+	Password   string
+	PrivateKey string // PEM content
+	Passphrase string // passphrase of the private key, if any
+	HostKey    string // server public key in authorized_keys format, ex: "ssh-ed25519 AAAA..."
+	Insecure   bool   // skips the host key verification
+	Directory  string
+}
+
+type sftpStorage struct {
+	cfg        *SFTPConfig
+	sshClient  *ssh.Client
+	sftpClient *sftp.Client
+}
+
+func newSFTPStorage(cfg *SFTPConfig) (Storage, error) {
+	if cfg.Host == "" {
+		return nil, errors.New("sftp host is required")
+	}
+	port := cfg.Port
+	if port == 0 {
+		port = 22
+	}
+
+	auth := make([]ssh.AuthMethod, 0)
+	if cfg.PrivateKey != "" {
+		var signer ssh.Signer
+		var err error
+		if cfg.Passphrase != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase([]byte(cfg.PrivateKey), []byte(cfg.Passphrase))
+		} else {
+			signer, err = ssh.ParsePrivateKey([]byte(cfg.PrivateKey))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error on parsing private key: %w", err)
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+	if cfg.Password != "" {
+		auth = append(auth, ssh.Password(cfg.Password))
+	}
+
+	var hostKeyCallback ssh.HostKeyCallback
+	switch {
+	case cfg.HostKey != "":
+		hostKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(cfg.HostKey))
+		if err != nil {
+			return nil, fmt.Errorf("error on parsing host key: %w", err)
+		}
+		hostKeyCallback = ssh.FixedHostKey(hostKey)
+	case cfg.Insecure:
+		hostKeyCallback = ssh.InsecureIgnoreHostKey()
+	default:
+		return nil, errors.New("sftp host key is required, set insecure to skip the verification")
+	}
+
+	sshConfig := &ssh.ClientConfig{
+		User:            cfg.Username,
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         timeout,
+	}
+	sshClient, err := ssh.Dial("tcp", net.JoinHostPort(cfg.Host, strconv.Itoa(port)), sshConfig)
+	if err != nil {
+		return nil, err
+	}
+	sftpClient, err := sftp.NewClient(sshClient)
+	if err != nil {
+		_ = sshClient.Close()
+		return nil, err
+	}
+	return &sftpStorage{cfg: cfg, sshClient: sshClient, sftpClient: sftpClient}, nil
+}
+
+func (s *sftpStorage) Upload(localFile, name string) error {
+	if s.cfg.Directory != "" {
+		err := s.sftpClient.MkdirAll(s.cfg.Directory)
+		if err != nil {
+			return err
+		}
+	}
+
+	source, err := os.Open(localFile)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	target, err := s.sftpClient.Create(path.Join(s.cfg.Directory, name))
+	if err != nil {
+		return err
+	}
+	defer target.Close()
+
+	_, err = io.Copy(target, source)
+	return err
+}
+
+func (s *sftpStorage) Download(name, localFile string) error {
+	source, err := s.sftpClient.Open(path.Join(s.cfg.Directory, name))
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	target, err := os.Create(localFile)
+	if err != nil {
+		return err
+	}
+	defer target.Close()
+
+	_, err = io.Copy(target, source)
+	return err
+}
+
+func (s *sftpStorage) List() ([]types.File, error) {
+	directory := s.cfg.Directory
+	if directory == "" {
+		directory = "."
+	}
+	entries, err := s.sftpClient.ReadDir(directory)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []types.File{}, nil
+		}
+		return nil, err
+	}
+	files := make([]types.File, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		files = append(files, types.File{
+			Name:         entry.Name(),
+			Size:         entry.Size(),
+			ModifiedTime: entry.ModTime(),
+			FullPath:     path.Join(s.cfg.Directory, entry.Name()),
+		})
+	}
+	return files, nil
+}
+
+func (s *sftpStorage) Delete(name string) error {
+	return s.sftpClient.Remove(path.Join(s.cfg.Directory, name))
+}
+
+func (s *sftpStorage) Close() error {
+	return errors.Join(s.sftpClient.Close(), s.sshClient.Close())
+}
@@ -0,0 +1,73 @@
+package remote
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/mycontroller-org/server/v2/pkg/types"
+	"github.com/mycontroller-org/server/v2/pkg/utils"
+	backupUtil "github.com/mycontroller-org/server/v2/plugin/handler/backup/util"
+)
+
+const (
+	timeout = time.Minute * 5 // includes upload and download of the backup file
+)
+
+// Storage of the backup files on a remote location.
+// names are relative to the configured directory
+type Storage interface {
+	Upload(localFile, name string) error
+	Download(name, localFile string) error
+	List() ([]types.File, error)
+	Delete(name string) error
+	Close() error
+}
+
+// NewStorage returns the remote storage client of the provider type.
+// config is the handler spec or the backup location config
+func NewStorage(providerType string, config map[string]interface{}) (Storage, error) {
+	switch providerType {
+	case backupUtil.ProviderS3:
+		cfg := &S3Config{}
+		err := utils.MapToStruct(utils.TagNameNone, config, cfg)
+		if err != nil {
+			return nil, err
+		}
+		return newS3Storage(cfg)
+
+	case backupUtil.ProviderSFTP:
+		cfg := &SFTPConfig{}
+		err := utils.MapToStruct(utils.TagNameNone, config, cfg)
+		if err != nil {
+			return nil, err
+		}
+		return newSFTPStorage(cfg)
+
+	case backupUtil.ProviderWebDAV:
+		cfg := &WebDAVConfig{}
+		err := utils.MapToStruct(utils.TagNameNone, config, cfg)
+		if err != nil {
+			return nil, err
+		}
+		return newWebDAVStorage(cfg)
+
+	default:
+		return nil, fmt.Errorf("unknown remote backup provider:%s", providerType)
+	}
+}
+
+// IsRemoteProvider returns true, if the provider stores the files on a remote location
+func IsRemoteProvider(providerType string) bool {
+	switch providerType {
+	case backupUtil.ProviderS3, backupUtil.ProviderSFTP, backupUtil.ProviderWebDAV:
+		return true
+	}
+	return false
+}
+
+// joins the directory and the name, removes the leading slash
+func joinKey(directory, name string) string {
+	return strings.TrimPrefix(path.Join(directory, name), "/")
+}
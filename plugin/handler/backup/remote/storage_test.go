@@ -0,0 +1,253 @@
+package remote
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/johannesboyne/gofakes3"
+	"github.com/johannesboyne/gofakes3/backend/s3mem"
+	backupUtil "github.com/mycontroller-org/server/v2/plugin/handler/backup/util"
+	"github.com/pkg/sftp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/net/webdav"
+)
+
+const (
+	testUsername = "backup"
+	testPassword = "secret"
+	testBucket   = "backups"
+)
+
+// starts an in-process s3 server, returns the provider config
+func startS3Server(t *testing.T) map[string]interface{} {
+	backend := s3mem.New()
+	require.NoError(t, backend.CreateBucket(testBucket))
+	server := httptest.NewServer(gofakes3.New(backend).Server())
+	t.Cleanup(server.Close)
+	return map[string]interface{}{
+		"endpoint":   strings.TrimPrefix(server.URL, "http://"),
+		"region":     "us-east-1",
+		"bucket":     testBucket,
+		"accessKey":  testUsername,
+		"secretKey":  testPassword,
+		"disableTLS": true,
+		"pathStyle":  true,
+	}
+}
+
+// starts an in-process webdav server with basic auth, returns the provider config
+func startWebDAVServer(t *testing.T) map[string]interface{} {
+	fileSystem := webdav.NewMemFS()
+	// base url is available on the server
+	require.NoError(t, fileSystem.Mkdir(context.Background(), "/dav", 0700))
+	handler := &webdav.Handler{FileSystem: fileSystem, LockSystem: webdav.NewMemLS()}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok || username != testUsername || password != testPassword {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return map[string]interface{}{
+		"url":      server.URL + "/dav",
+		"username": testUsername,
+		"password": testPassword,
+	}
+}
+
+// starts an in-process sftp server with password auth, returns the provider config
+func startSFTPServer(t *testing.T) map[string]interface{} {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(privateKey)
+	require.NoError(t, err)
+
+	serverConfig := &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if conn.User() == testUsername && string(password) == testPassword {
+				return nil, nil
+			}
+			return nil, errors.New("invalid credential")
+		},
+	}
+	serverConfig.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	// files are retained across the connections
+	handlers := sftp.InMemHandler()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveSFTP(conn, serverConfig, handlers)
+		}
+	}()
+
+	address := listener.Addr().(*net.TCPAddr)
+	return map[string]interface{}{
+		"host":     address.IP.String(),
+		"port":     address.Port,
+		"username": testUsername,
+		"password": testPassword,
+		"hostKey":  string(ssh.MarshalAuthorizedKey(signer.PublicKey())),
+	}
+}
+
+func serveSFTP(conn net.Conn, serverConfig *ssh.ServerConfig, handlers sftp.Handlers) {
+	_, channels, requests, err := ssh.NewServerConn(conn, serverConfig)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(requests)
+	for newChannel := range channels {
+		if newChannel.ChannelType() != "session" {
+			_ = newChannel.Reject(ssh.UnknownChannelType, "unsupported channel type")
+			continue
+		}
+		channel, channelRequests, err := newChannel.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			for request := range channelRequests {
+				// payload: length prefixed subsystem name
+				isSFTP := request.Type == "subsystem" && len(request.Payload) > 4 &&
+					string(request.Payload[4:4+binary.BigEndian.Uint32(request.Payload)]) == "sftp"
+				_ = request.Reply(isSFTP, nil)
+				if isSFTP {
+					server := sftp.NewRequestServer(channel, handlers)
+					_ = server.Serve()
+					_ = server.Close()
+				}
+			}
+		}()
+	}
+}
+
+// returns the provider config with the in-process server
+func startServer(t *testing.T, providerType string) map[string]interface{} {
+	switch providerType {
+	case backupUtil.ProviderS3:
+		return startS3Server(t)
+	case backupUtil.ProviderSFTP:
+		return startSFTPServer(t)
+	case backupUtil.ProviderWebDAV:
+		return startWebDAVServer(t)
+	}
+	require.FailNow(t, "unknown provider", providerType)
+	return nil
+}
+
+var testProviders = []string{backupUtil.ProviderS3, backupUtil.ProviderSFTP, backupUtil.ProviderWebDAV}
+
+// returns the file names on the remote storage
+func listNames(t *testing.T, storage Storage) []string {
+	files, err := storage.List()
+	require.NoError(t, err)
+	names := make([]string, 0)
+	for _, file := range files {
+		names = append(names, file.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestStorageRoundTrip(t *testing.T) {
+	for _, providerType := range testProviders {
+		t.Run(providerType, func(t *testing.T) {
+			config := startServer(t, providerType)
+			config["directory"] = "mc/backups"
+
+			storage, err := NewStorage(providerType, config)
+			require.NoError(t, err)
+			t.Cleanup(func() { _ = storage.Close() })
+
+			// directory not available yet
+			assert.Empty(t, listNames(t, storage))
+
+			content := []byte("backup file content")
+			localFile := path.Join(t.TempDir(), "upload.zip")
+			require.NoError(t, os.WriteFile(localFile, content, 0600))
+			require.NoError(t, storage.Upload(localFile, "first.zip"))
+			require.NoError(t, storage.Upload(localFile, "second.zip"))
+
+			files, err := storage.List()
+			require.NoError(t, err)
+			require.Len(t, files, 2)
+			for _, file := range files {
+				assert.Equal(t, int64(len(content)), file.Size, file.Name)
+			}
+			assert.Equal(t, []string{"first.zip", "second.zip"}, listNames(t, storage))
+
+			downloadedFile := path.Join(t.TempDir(), "download.zip")
+			require.NoError(t, storage.Download("first.zip", downloadedFile))
+			downloaded, err := os.ReadFile(downloadedFile)
+			require.NoError(t, err)
+			assert.Equal(t, content, downloaded)
+
+			require.NoError(t, storage.Delete("first.zip"))
+			assert.Equal(t, []string{"second.zip"}, listNames(t, storage))
+
+			assert.Error(t, storage.Download("first.zip", downloadedFile))
+		})
+	}
+}
+
+func TestStorageAuthentication(t *testing.T) {
+	// the s3 fake does not verify the signature
+	for _, providerType := range []string{backupUtil.ProviderSFTP, backupUtil.ProviderWebDAV} {
+		t.Run(providerType, func(t *testing.T) {
+			config := startServer(t, providerType)
+			config["password"] = "invalid"
+
+			storage, err := NewStorage(providerType, config)
+			if err != nil {
+				return // sftp fails on the connect
+			}
+			t.Cleanup(func() { _ = storage.Close() })
+			_, err = storage.List()
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestNewStorageValidation(t *testing.T) {
+	tests := []struct {
+		name         string
+		providerType string
+		config       map[string]interface{}
+		err          string
+	}{
+		{name: "unknown provider", providerType: "ftp", err: "unknown remote backup provider:ftp"},
+		{name: "s3 bucket", providerType: backupUtil.ProviderS3, config: map[string]interface{}{"endpoint": "localhost:9000"}, err: "s3 endpoint and bucket are required"},
+		{name: "sftp host", providerType: backupUtil.ProviderSFTP, err: "sftp host is required"},
+		{name: "sftp host key", providerType: backupUtil.ProviderSFTP, config: map[string]interface{}{"host": "localhost"}, err: "sftp host key is required"},
+		{name: "webdav url", providerType: backupUtil.ProviderWebDAV, err: "webdav url is required"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := NewStorage(test.providerType, test.config)
+			assert.ErrorContains(t, err, test.err)
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package remote
+
+import (
+	"crypto/tls"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/mycontroller-org/server/v2/pkg/types"
+)
+
+// WebDAVConfig of webdav storage. ex: Nextcloud, ownCloud, Apache mod_dav
+type WebDAVConfig struct {
+	URL       string // ex: https://cloud.example.com/remote.php/dav/files/user
+	Username  string
+	Password  string
+	Insecure  bool
+	Directory string
+}
+
+type webdavStorage struct {
+	cfg     *WebDAVConfig
+	baseURL *url.URL
+	client  *http.Client
+}
+
+// PROPFIND response
+type multiStatus struct {
+	Responses []struct {
+		Href     string `xml:"href"`
+		PropStat []struct {
+			Prop struct {
+				ContentLength int64  `xml:"getcontentlength"`
+				LastModified  string `xml:"getlastmodified"`
+				ResourceType  struct {
+					Collection *struct{} `xml:"collection"`
+				} `xml:"resourcetype"`
+			} `xml:"prop"`
+		} `xml:"propstat"`
+	} `xml:"response"`
+}
+
+const propfindBody = `<?xml version="1.0" encoding="utf-8"?>
+<d:propfind xmlns:d="DAV:"><d:prop><d:getcontentlength/><d:getlastmodified/><d:resourcetype/></d:prop></d:propfind>`
+
+func newWebDAVStorage(cfg *WebDAVConfig) (Storage, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("webdav url is required")
+	}
+	baseURL, err := url.Parse(strings.TrimSuffix(cfg.URL, "/"))
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.Insecure {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	return &webdavStorage{
+		cfg:     cfg,
+		baseURL: baseURL,
+		client:  &http.Client{Transport: transport, Timeout: timeout},
+	}, nil
+}
+
+// returns url of the name inside the configured directory
+func (w *webdavStorage) getURL(name string) string {
+	target := *w.baseURL
+	target.Path = path.Join(w.baseURL.Path, w.cfg.Directory, name)
+	if name == "" {
+		target.Path += "/"
+	}
+	return target.String()
+}
+
+func (w *webdavStorage) do(method, targetURL string, body io.Reader, headers map[string]string) (*http.Response, error) {
+	req, err := http.NewRequest(method, targetURL, body)
+	if err != nil {
+		return nil, err
+	}
+	if w.cfg.Username != "" || w.cfg.Password != "" {
+		req.SetBasicAuth(w.cfg.Username, w.cfg.Password)
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	return w.client.Do(req)
+}
+
+// creates the configured directory tree, existing directories are ignored
+func (w *webdavStorage) createDirectory() error {
+	directory := ""
+	for _, part := range strings.Split(strings.Trim(w.cfg.Directory, "/"), "/") {
+		if part == "" {
+			continue
+		}
+		directory = path.Join(directory, part)
+		target := *w.baseURL
+		target.Path = path.Join(w.baseURL.Path, directory) + "/"
+		resp, err := w.do("MKCOL", target.String(), nil, nil)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		// 405: already exists
+		if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusMethodNotAllowed {
+			return fmt.Errorf("error on creating directory '%s', status:%s", directory, resp.Status)
+		}
+	}
+	return nil
+}
+
+func (w *webdavStorage) Upload(localFile, name string) error {
+	err := w.createDirectory()
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(localFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	resp, err := w.do(http.MethodPut, w.getURL(name), file, map[string]string{"Content-Type": "application/zip"})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("error on uploading file '%s', status:%s", name, resp.Status)
+	}
+	return nil
+}
+
+func (w *webdavStorage) Download(name, localFile string) error {
+	resp, err := w.do(http.MethodGet, w.getURL(name), nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("error on downloading file '%s', status:%s", name, resp.Status)
+	}
+
+	file, err := os.Create(localFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = io.Copy(file, resp.Body)
+	return err
+}
+
+func (w *webdavStorage) List() ([]types.File, error) {
+	resp, err := w.do("PROPFIND", w.getURL(""), strings.NewReader(propfindBody), map[string]string{"Depth": "1", "Content-Type": "application/xml"})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return []types.File{}, nil
+	}
+	if resp.StatusCode != http.StatusMultiStatus {
+		return nil, fmt.Errorf("error on listing files, status:%s", resp.Status)
+	}
+
+	status := multiStatus{}
+	err = xml.NewDecoder(resp.Body).Decode(&status)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]types.File, 0)
+	for _, response := range status.Responses {
+		if len(response.PropStat) == 0 {
+			continue
+		}
+		prop := response.PropStat[0].Prop
+		if prop.ResourceType.Collection != nil {
+			continue
+		}
+		href, err := url.PathUnescape(response.Href)
+		if err != nil {
+			href = response.Href
+		}
+		name := path.Base(href)
+		modifiedTime, _ := time.Parse(http.TimeFormat, prop.LastModified)
+		files = append(files, types.File{
+			Name:         name,
+			Size:         prop.ContentLength,
+			ModifiedTime: modifiedTime,
+			FullPath:     path.Join(w.cfg.Directory, name),
+		})
+	}
+	return files, nil
+}
+
+func (w *webdavStorage) Delete(name string) error {
+	resp, err := w.do(http.MethodDelete, w.getURL(name), nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("error on deleting file '%s', status:%s", name, resp.Status)
+	}
+	return nil
+}
+
+func (w *webdavStorage) Close() error {
+	return nil
+}
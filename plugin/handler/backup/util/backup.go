@@ -9,17 +9,80 @@ import (
 	"github.com/mycontroller-org/server/v2/pkg/utils"
 	"github.com/mycontroller-org/server/v2/pkg/utils/ziputils"
 	busTY "github.com/mycontroller-org/server/v2/plugin/bus/types"
+	metricTY "github.com/mycontroller-org/server/v2/plugin/database/metric/types"
 	backupAPI "github.com/mycontroller-org/server/v2/plugin/database/storage/backup"
 	storageTY "github.com/mycontroller-org/server/v2/plugin/database/storage/types"
 	"go.uber.org/zap"
 )
 
+// Options of a backup
+type Options struct {
+	Since      *time.Time        // if set, takes incremental backup
+	Encryption *EncryptionConfig // if set, encrypts the zip file
+	Metric     *MetricConfig     // if enabled, includes metric data
+}
+
+// MetricConfig of metric data backup
+type MetricConfig struct {
+	Enabled bool
+	Start   string // duration relative to now or RFC3339 timestamp, ex: -720h, 2024-01-01T00:00:00Z. default: -720h
+	Stop    string // duration relative to now or RFC3339 timestamp. default: now
+}
+
+// default metric data export range
+const defaultMetricStart = "-720h"
+
+// returns the export range of the metric data
+// incremental backup exports the metric data received since the previous backup
+func (mc *MetricConfig) getExportConfig(now time.Time, since *time.Time) (*metricTY.ExportConfig, error) {
+	if mc == nil || !mc.Enabled {
+		return nil, nil
+	}
+	startValue := mc.Start
+	if startValue == "" {
+		startValue = defaultMetricStart
+	}
+	start, err := parseTime(now, startValue)
+	if err != nil {
+		return nil, fmt.Errorf("invalid metric start '%s': %w", startValue, err)
+	}
+	stop := now
+	if mc.Stop != "" {
+		stop, err = parseTime(now, mc.Stop)
+		if err != nil {
+			return nil, fmt.Errorf("invalid metric stop '%s': %w", mc.Stop, err)
+		}
+	}
+	if since != nil && since.After(start) {
+		start = *since
+	}
+	return &metricTY.ExportConfig{Start: start, Stop: stop}, nil
+}
+
+// parses a duration relative to now or a RFC3339 timestamp
+func parseTime(now time.Time, value string) (time.Time, error) {
+	duration, err := time.ParseDuration(value)
+	if err == nil {
+		return now.Add(duration), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
 // Backup creates zip file on a tmp location and returns the location details
-func Backup(ctx context.Context, logger *zap.Logger, baseDir, prefix, storageExportType string, includeSecureShare, includeInsecureShare bool, storage storageTY.Plugin, bus busTY.Plugin) (string, error) {
-	timestamp := time.Now().Format("20060102_150405")
+func Backup(ctx context.Context, logger *zap.Logger, baseDir, prefix, storageExportType string, includeSecureShare, includeInsecureShare bool, storage storageTY.Plugin, bus busTY.Plugin, options Options) (string, error) {
+	now := time.Now()
+	timestamp := now.Format(backupAPI.DateSuffixLayout)
 	dstDir := fmt.Sprintf("%s/%s_%s_%s_%s", baseDir, prefix, BackupIdentifier, storageExportType, timestamp)
+	if options.Since != nil {
+		dstDir = fmt.Sprintf("%s%s", dstDir, IncrementalSuffix)
+	}
 	zipFilename := fmt.Sprintf("%s.zip", dstDir)
 
+	metricExportConfig, err := options.Metric.getExportConfig(now, options.Since)
+	if err != nil {
+		return "", err
+	}
+
 	// get backup directories
 	bkpDirectories, err := bkpMap.GetDirectories(includeSecureShare, includeInsecureShare)
 	if err != nil {
@@ -32,13 +95,15 @@ func Backup(ctx context.Context, logger *zap.Logger, baseDir, prefix, storageExp
 	if err != nil {
 		return "", err
 	}
+
 	exportFuncMap, err := bkpMap.GetStorageApiMap(ctx)
 	if err != nil {
 		return "", err
 	}
 
 	// exports storage and directories
-	err = backupRestore.ExportStorage(exportFuncMap, bkpMap.MyControllerDataTransformationExportFunc, dstDir, storageExportType)
+	exportOptions := backupAPI.ExportOptions{Since: options.Since, Metric: metricExportConfig}
+	err = backupRestore.ExportStorage(exportFuncMap, bkpMap.MyControllerDataTransformationExportFunc, dstDir, storageExportType, exportOptions)
 	if err != nil {
 		return "", err
 	}
@@ -55,5 +120,19 @@ func Backup(ctx context.Context, logger *zap.Logger, baseDir, prefix, storageExp
 		logger.Error("error on removing backup tmp location", zap.Error(err), zap.String("backupTmpLocation", dstDir))
 	}
 
+	if options.Encryption.IsEnabled() {
+		encryptedFilename := fmt.Sprintf("%s%s", zipFilename, EncryptedFileExtension)
+		err = options.Encryption.EncryptFile(zipFilename, encryptedFilename)
+		// plain zip file should not be left on the disk
+		removeErr := utils.RemoveFileOrEmptyDir(zipFilename)
+		if err != nil {
+			return "", err
+		}
+		if removeErr != nil {
+			return "", removeErr
+		}
+		return encryptedFilename, nil
+	}
+
 	return zipFilename, nil
 }
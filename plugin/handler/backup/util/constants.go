@@ -4,10 +4,22 @@ package backup
 const (
 	KeyProviderType = "providerType"
 
-	ProviderDisk = "disk"
+	ProviderDisk   = "disk"
+	ProviderS3     = "s3"
+	ProviderSFTP   = "sftp"
+	ProviderWebDAV = "webdav"
 )
 
 // backup identifier prefix
 const (
-	BackupIdentifier = "mc_backup"
+	BackupIdentifier  = "mc_backup"
+	IncrementalSuffix = "_incremental"
+)
+
+// backup modes
+const (
+	ModeFull        = "full"
+	ModeIncremental = "incremental"
+
+	DefaultFullBackupEvery = 7 // number of incremental backups between the full backups
 )
@@ -0,0 +1,119 @@
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+)
+
+// encrypted backup file extension
+const EncryptedFileExtension = ".age"
+
+// EncryptionConfig of backup file, uses age file encryption format
+// passphrase and recipients can not be used together
+type EncryptionConfig struct {
+	Passphrase string
+	Recipients []string // age public keys, ex: age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p
+}
+
+// IsEnabled returns true, if passphrase or recipients configured
+func (ec *EncryptionConfig) IsEnabled() bool {
+	return ec != nil && (ec.Passphrase != "" || len(ec.Recipients) > 0)
+}
+
+func (ec *EncryptionConfig) getRecipients() ([]age.Recipient, error) {
+	if ec.Passphrase != "" && len(ec.Recipients) > 0 {
+		return nil, errors.New("passphrase and recipients can not be used together")
+	}
+	if ec.Passphrase != "" {
+		recipient, err := age.NewScryptRecipient(ec.Passphrase)
+		if err != nil {
+			return nil, err
+		}
+		return []age.Recipient{recipient}, nil
+	}
+	recipients, err := age.ParseRecipients(strings.NewReader(strings.Join(ec.Recipients, "\n")))
+	if err != nil {
+		return nil, fmt.Errorf("error on parsing recipients: %w", err)
+	}
+	return recipients, nil
+}
+
+// EncryptFile encrypts the source file into the destination file
+func (ec *EncryptionConfig) EncryptFile(sourceFile, dstFile string) error {
+	recipients, err := ec.getRecipients()
+	if err != nil {
+		return err
+	}
+
+	source, err := os.Open(sourceFile)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	dst, err := os.Create(dstFile)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	writer, err := age.Encrypt(dst, recipients...)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(writer, source)
+	if err != nil {
+		return err
+	}
+	// flushes the last chunk
+	return writer.Close()
+}
+
+// DecryptFile decrypts the source file into the destination file
+// identity is an age secret key or identity file content, used when the file encrypted with recipients
+func DecryptFile(sourceFile, dstFile, passphrase, identity string) error {
+	if passphrase == "" && identity == "" {
+		return errors.New("passphrase or identity required to decrypt the backup file")
+	}
+
+	identities := make([]age.Identity, 0)
+	if passphrase != "" {
+		scryptIdentity, err := age.NewScryptIdentity(passphrase)
+		if err != nil {
+			return err
+		}
+		identities = append(identities, scryptIdentity)
+	}
+	if identity != "" {
+		parsed, err := age.ParseIdentities(strings.NewReader(identity))
+		if err != nil {
+			return fmt.Errorf("error on parsing identity: %w", err)
+		}
+		identities = append(identities, parsed...)
+	}
+
+	source, err := os.Open(sourceFile)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	reader, err := age.Decrypt(source, identities...)
+	if err != nil {
+		return err
+	}
+
+	dst, err := os.Create(dstFile)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	_, err = io.Copy(dst, reader)
+	return err
+}
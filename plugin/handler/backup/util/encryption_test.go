@@ -0,0 +1,76 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptionRoundTrip(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	otherIdentity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	sourceFile := filepath.Join(dir, "backup.zip")
+	content := []byte("backup content")
+	require.NoError(t, os.WriteFile(sourceFile, content, os.ModePerm))
+
+	tests := []struct {
+		name         string
+		config       EncryptionConfig
+		passphrase   string
+		identity     string
+		decryptError bool
+	}{
+		{name: "passphrase", config: EncryptionConfig{Passphrase: "secret"}, passphrase: "secret"},
+		{name: "wrong passphrase", config: EncryptionConfig{Passphrase: "secret"}, passphrase: "wrong", decryptError: true},
+		{name: "recipient", config: EncryptionConfig{Recipients: []string{identity.Recipient().String()}}, identity: identity.String()},
+		{name: "other identity", config: EncryptionConfig{Recipients: []string{identity.Recipient().String()}}, identity: otherIdentity.String(), decryptError: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			encryptedFile := filepath.Join(dir, test.name+EncryptedFileExtension)
+			decryptedFile := filepath.Join(dir, test.name+".zip")
+
+			require.True(t, test.config.IsEnabled())
+			require.NoError(t, test.config.EncryptFile(sourceFile, encryptedFile))
+			encrypted, err := os.ReadFile(encryptedFile)
+			require.NoError(t, err)
+			assert.NotContains(t, string(encrypted), string(content))
+
+			err = DecryptFile(encryptedFile, decryptedFile, test.passphrase, test.identity)
+			if test.decryptError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			decrypted, err := os.ReadFile(decryptedFile)
+			require.NoError(t, err)
+			assert.Equal(t, content, decrypted)
+		})
+	}
+}
+
+func TestEncryptionConfig(t *testing.T) {
+	var config *EncryptionConfig
+	assert.False(t, config.IsEnabled())
+	assert.False(t, (&EncryptionConfig{}).IsEnabled())
+
+	dir := t.TempDir()
+	sourceFile := filepath.Join(dir, "backup.zip")
+	require.NoError(t, os.WriteFile(sourceFile, []byte("content"), os.ModePerm))
+
+	both := &EncryptionConfig{Passphrase: "secret", Recipients: []string{"age1invalid"}}
+	assert.ErrorContains(t, both.EncryptFile(sourceFile, filepath.Join(dir, "both.age")), "can not be used together")
+
+	invalid := &EncryptionConfig{Recipients: []string{"age1invalid"}}
+	assert.ErrorContains(t, invalid.EncryptFile(sourceFile, filepath.Join(dir, "invalid.age")), "error on parsing recipients")
+
+	assert.ErrorContains(t, DecryptFile(sourceFile, filepath.Join(dir, "out.zip"), "", ""), "passphrase or identity required")
+}
@@ -0,0 +1,137 @@
+package backup
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/mycontroller-org/server/v2/pkg/types"
+	backupAPI "github.com/mycontroller-org/server/v2/plugin/database/storage/backup"
+)
+
+// <prefix>_mc_backup_<exportType>_<timestamp>[_incremental].zip[.age]
+var filenameRegex = regexp.MustCompile(fmt.Sprintf(`^(.+_%s_[a-z]+)_(\d{8}_\d{6})(%s)?\.zip(%s)?$`,
+	BackupIdentifier, IncrementalSuffix, regexp.QuoteMeta(EncryptedFileExtension)))
+
+// FileDetails of a backup file, derived from the filename
+type FileDetails struct {
+	Name        string
+	Series      string // <prefix>_mc_backup_<exportType>
+	Timestamp   time.Time
+	Incremental bool
+	Encrypted   bool
+}
+
+// ParseFilename returns the details of a backup file
+func ParseFilename(name string) (*FileDetails, bool) {
+	matches := filenameRegex.FindStringSubmatch(name)
+	if matches == nil {
+		return nil, false
+	}
+	timestamp, err := time.ParseInLocation(backupAPI.DateSuffixLayout, matches[2], time.Local)
+	if err != nil {
+		return nil, false
+	}
+	return &FileDetails{
+		Name:        name,
+		Series:      matches[1],
+		Timestamp:   timestamp,
+		Incremental: matches[3] != "",
+		Encrypted:   matches[4] != "",
+	}, true
+}
+
+// GetSeries returns the filename prefix of the backup files of the prefix and export type
+func GetSeries(prefix, storageExportType string) string {
+	return fmt.Sprintf("%s_%s_%s", prefix, BackupIdentifier, storageExportType)
+}
+
+// returns backup files of the series, newest first
+func getSeriesFiles(files []types.File, series string) []*FileDetails {
+	seriesFiles := make([]*FileDetails, 0)
+	for _, file := range files {
+		details, ok := ParseFilename(file.Name)
+		if ok && details.Series == series {
+			seriesFiles = append(seriesFiles, details)
+		}
+	}
+	// timestamp is part of the name
+	sort.Slice(seriesFiles, func(i, j int) bool { return seriesFiles[i].Name > seriesFiles[j].Name })
+	return seriesFiles
+}
+
+// GetIncrementalSince returns the timestamp of the previous backup of the series
+// returns nil, if a full backup required
+func GetIncrementalSince(files []types.File, series string, fullBackupEvery int) *time.Time {
+	if fullBackupEvery <= 0 {
+		fullBackupEvery = DefaultFullBackupEvery
+	}
+	seriesFiles := getSeriesFiles(files, series)
+	if len(seriesFiles) == 0 {
+		return nil
+	}
+
+	incrementalCount := 0
+	for _, file := range seriesFiles {
+		if !file.Incremental {
+			if incrementalCount >= fullBackupEvery {
+				return nil
+			}
+			since := seriesFiles[0].Timestamp
+			return &since
+		}
+		incrementalCount++
+	}
+	// no full backup available
+	return nil
+}
+
+// GetRestoreChain returns the files required to restore the target file
+// the latest full backup followed by the incremental backups up to the target file
+func GetRestoreChain(files []types.File, targetFile string) ([]string, error) {
+	target, ok := ParseFilename(targetFile)
+	if !ok {
+		return nil, fmt.Errorf("invalid backup filename: %s", targetFile)
+	}
+	if !target.Incremental {
+		return []string{target.Name}, nil
+	}
+
+	chain := make([]string, 0)
+	for _, file := range getSeriesFiles(files, target.Series) {
+		if file.Name > target.Name {
+			continue
+		}
+		chain = append([]string{file.Name}, chain...)
+		if !file.Incremental {
+			return chain, nil
+		}
+	}
+	return nil, fmt.Errorf("full backup not found for the incremental backup: %s", targetFile)
+}
+
+// GetRetentionDeleteList returns the files to be deleted to keep the retention count
+// retention count applied on the full backups, incremental backups depend on a deleted full backup are removed too
+func GetRetentionDeleteList(files []types.File, series string, retentionCount int) []types.File {
+	if retentionCount <= 0 {
+		return nil
+	}
+	filesMap := map[string]types.File{}
+	for _, file := range files {
+		filesMap[file.Name] = file
+	}
+
+	deleteFiles := make([]types.File, 0)
+	fullCount := 0
+	for _, file := range getSeriesFiles(files, series) {
+		if fullCount >= retentionCount {
+			deleteFiles = append(deleteFiles, filesMap[file.Name])
+			continue
+		}
+		if !file.Incremental {
+			fullCount++
+		}
+	}
+	return deleteFiles
+}
@@ -0,0 +1,237 @@
+package backup
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mycontroller-org/server/v2/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSeries = "test_mc_backup_yaml"
+
+// returns backup filename of the test series, day of January 2024
+func full(day int) string {
+	return fmt.Sprintf("%s_202401%02d_000000.zip", testSeries, day)
+}
+
+func incremental(day int) string {
+	return fmt.Sprintf("%s_202401%02d_000000%s.zip", testSeries, day, IncrementalSuffix)
+}
+
+func toFiles(names ...string) []types.File {
+	files := make([]types.File, 0, len(names))
+	for _, name := range names {
+		files = append(files, types.File{Name: name})
+	}
+	return files
+}
+
+func toNames(files []types.File) []string {
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		names = append(names, file.Name)
+	}
+	return names
+}
+
+func day(value int) *time.Time {
+	timestamp := time.Date(2024, 1, value, 0, 0, 0, 0, time.Local)
+	return &timestamp
+}
+
+func TestParseFilename(t *testing.T) {
+	tests := []struct {
+		name    string
+		valid   bool
+		details FileDetails
+	}{
+		{
+			name:    "test_mc_backup_yaml_20240102_030405.zip",
+			valid:   true,
+			details: FileDetails{Series: testSeries, Timestamp: time.Date(2024, 1, 2, 3, 4, 5, 0, time.Local)},
+		},
+		{
+			name:    "test_mc_backup_json_20240102_030405_incremental.zip",
+			valid:   true,
+			details: FileDetails{Series: "test_mc_backup_json", Timestamp: time.Date(2024, 1, 2, 3, 4, 5, 0, time.Local), Incremental: true},
+		},
+		{
+			name:    "test_mc_backup_yaml_20240102_030405.zip.age",
+			valid:   true,
+			details: FileDetails{Series: testSeries, Timestamp: time.Date(2024, 1, 2, 3, 4, 5, 0, time.Local), Encrypted: true},
+		},
+		{
+			name:    "daily_on_demand_mc_backup_yaml_20240102_030405_incremental.zip.age",
+			valid:   true,
+			details: FileDetails{Series: "daily_on_demand_mc_backup_yaml", Timestamp: time.Date(2024, 1, 2, 3, 4, 5, 0, time.Local), Incremental: true, Encrypted: true},
+		},
+		{name: "test_mc_backup_yaml_20240102_030405.tar"},
+		{name: "test_mc_backup_yaml_20240102_030405.zip.gpg"},
+		{name: "test_mc_backup_yaml_20240102.zip"},
+		{name: "test_mc_backup_yaml_20241302_030405.zip"}, // invalid month
+		{name: "test_mc_backup_20240102_030405.zip"},      // export type missing
+		{name: "test_backup_yaml_20240102_030405.zip"},
+		{name: "notes.txt"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			details, ok := ParseFilename(test.name)
+			require.Equal(t, test.valid, ok)
+			if !test.valid {
+				assert.Nil(t, details)
+				return
+			}
+			test.details.Name = test.name
+			assert.Equal(t, test.details, *details)
+		})
+	}
+}
+
+func TestGetIncrementalSince(t *testing.T) {
+	tests := []struct {
+		name            string
+		files           []string
+		fullBackupEvery int
+		since           *time.Time
+	}{
+		{name: "no backup", fullBackupEvery: 3},
+		{name: "other series only", files: []string{"other_mc_backup_yaml_20240101_000000.zip", "test_mc_backup_json_20240101_000000.zip"}, fullBackupEvery: 3},
+		{name: "incremental without full backup", files: []string{incremental(2), incremental(3)}, fullBackupEvery: 3},
+		{name: "full backup", files: []string{full(1)}, fullBackupEvery: 3, since: day(1)},
+		{name: "incremental after full backup", files: []string{full(1), incremental(2), incremental(3)}, fullBackupEvery: 3, since: day(3)},
+		{name: "unordered files", files: []string{incremental(3), full(1), incremental(2)}, fullBackupEvery: 3, since: day(3)},
+		{name: "full backup every rollover", files: []string{full(1), incremental(2), incremental(3), incremental(4)}, fullBackupEvery: 3},
+		{name: "after the rollover", files: []string{full(1), incremental(2), incremental(3), incremental(4), full(5)}, fullBackupEvery: 3, since: day(5)},
+		{name: "counts the latest chain only", files: []string{full(1), incremental(2), incremental(3), full(4), incremental(5)}, fullBackupEvery: 2, since: day(5)},
+		{name: "full backup every one", files: []string{full(1), incremental(2)}, fullBackupEvery: 1},
+		{
+			name:  "default full backup every",
+			files: []string{full(1), incremental(2), incremental(3), incremental(4), incremental(5), incremental(6), incremental(7)},
+			since: day(7),
+		},
+		{
+			name:  "default full backup every rollover",
+			files: []string{full(1), incremental(2), incremental(3), incremental(4), incremental(5), incremental(6), incremental(7), incremental(8)},
+		},
+		{
+			name:            "encrypted backups",
+			files:           []string{full(1) + EncryptedFileExtension, incremental(2) + EncryptedFileExtension},
+			fullBackupEvery: 3,
+			since:           day(2),
+		},
+		{
+			name:            "other series ignored",
+			files:           []string{full(1), incremental(2), "test_mc_backup_json_20240105_000000.zip", "other_mc_backup_yaml_20240106_000000_incremental.zip"},
+			fullBackupEvery: 3,
+			since:           day(2),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			since := GetIncrementalSince(toFiles(test.files...), testSeries, test.fullBackupEvery)
+			if test.since == nil {
+				assert.Nil(t, since)
+				return
+			}
+			require.NotNil(t, since)
+			assert.True(t, test.since.Equal(*since), "expected:%s, actual:%s", test.since, since)
+		})
+	}
+}
+
+func TestGetRestoreChain(t *testing.T) {
+	files := []string{
+		full(1), incremental(2), incremental(3),
+		full(4) + EncryptedFileExtension, incremental(5) + EncryptedFileExtension, incremental(6) + EncryptedFileExtension,
+		"test_mc_backup_json_20240105_000000.zip",
+		"other_mc_backup_yaml_20240105_000000_incremental.zip",
+	}
+	tests := []struct {
+		name   string
+		files  []string
+		target string
+		chain  []string
+		err    string
+	}{
+		{name: "full backup", files: files, target: full(1), chain: []string{full(1)}},
+		{name: "full backup not listed", target: full(1), chain: []string{full(1)}},
+		{name: "first incremental", files: files, target: incremental(2), chain: []string{full(1), incremental(2)}},
+		{name: "last incremental of a chain", files: files, target: incremental(3), chain: []string{full(1), incremental(2), incremental(3)}},
+		{
+			name:   "encrypted chain",
+			files:  files,
+			target: incremental(5) + EncryptedFileExtension,
+			chain:  []string{full(4) + EncryptedFileExtension, incremental(5) + EncryptedFileExtension},
+		},
+		{
+			name:   "latest incremental",
+			files:  files,
+			target: incremental(6) + EncryptedFileExtension,
+			chain:  []string{full(4) + EncryptedFileExtension, incremental(5) + EncryptedFileExtension, incremental(6) + EncryptedFileExtension},
+		},
+		{name: "full backup missing", files: []string{incremental(2), incremental(3)}, target: incremental(3), err: "full backup not found for the incremental backup: " + incremental(3)},
+		{name: "invalid filename", files: files, target: "backup.zip", err: "invalid backup filename: backup.zip"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			chain, err := GetRestoreChain(toFiles(test.files...), test.target)
+			if test.err != "" {
+				assert.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.chain, chain)
+		})
+	}
+}
+
+func TestGetRetentionDeleteList(t *testing.T) {
+	others := []string{"test_mc_backup_json_20240101_000000.zip", "other_mc_backup_yaml_20240101_000000.zip", "notes.txt"}
+	tests := []struct {
+		name           string
+		files          []string
+		retentionCount int
+		deleted        []string // newest first
+	}{
+		{name: "disabled", files: []string{full(1), full(2), full(3)}, retentionCount: 0, deleted: nil},
+		{name: "within the retention count", files: []string{full(1), full(2)}, retentionCount: 2, deleted: []string{}},
+		{name: "full backups", files: append([]string{full(1), full(2), full(3)}, others...), retentionCount: 2, deleted: []string{full(1)}},
+		{
+			name:           "removes the dependent incremental backups",
+			files:          append([]string{full(1), incremental(2), incremental(3), full(4), incremental(5)}, others...),
+			retentionCount: 1,
+			deleted:        []string{incremental(3), incremental(2), full(1)},
+		},
+		{
+			name:           "incremental backups are not counted",
+			files:          []string{full(1), incremental(2), incremental(3), incremental(4)},
+			retentionCount: 1,
+			deleted:        []string{},
+		},
+		{
+			name:           "incremental backups without full backup",
+			files:          []string{incremental(1), full(2), incremental(3)},
+			retentionCount: 1,
+			deleted:        []string{incremental(1)},
+		},
+		{
+			name:           "encrypted backups",
+			files:          []string{full(1) + EncryptedFileExtension, incremental(2) + EncryptedFileExtension, full(3), full(4) + EncryptedFileExtension},
+			retentionCount: 2,
+			deleted:        []string{incremental(2) + EncryptedFileExtension, full(1) + EncryptedFileExtension},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			deleteFiles := GetRetentionDeleteList(toFiles(test.files...), testSeries, test.retentionCount)
+			if test.deleted == nil {
+				assert.Nil(t, deleteFiles)
+				return
+			}
+			assert.Equal(t, test.deleted, toNames(deleteFiles))
+		})
+	}
+}
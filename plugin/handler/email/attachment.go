@@ -0,0 +1,256 @@
+package email
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mycontroller-org/server/v2/pkg/types"
+	rsTY "github.com/mycontroller-org/server/v2/pkg/types/resource_service"
+	"github.com/mycontroller-org/server/v2/pkg/utils/bus_utils/query"
+	httpclient "github.com/mycontroller-org/server/v2/pkg/utils/http_client_json"
+	quickIdUtils "github.com/mycontroller-org/server/v2/pkg/utils/quick_id"
+	metricTY "github.com/mycontroller-org/server/v2/plugin/database/metric/types"
+	handlerTY "github.com/mycontroller-org/server/v2/plugin/handler/types"
+	"go.uber.org/zap"
+)
+
+const (
+	maxAttachmentSize  = 20 * 1024 * 1024 // 20 MB, total size of the attachments
+	defaultMetricStart = "-24h"
+	metricQueryName    = "metric"
+	queryTimeout       = time.Second * 5
+)
+
+// loadAttachments loads the attachments from the sources
+func (sc *SmtpClient) loadAttachments(sources []handlerTY.EmailAttachment) ([]*Attachment, error) {
+	attachments := make([]*Attachment, 0, len(sources))
+	totalSize := 0
+	for index := range sources {
+		source := &sources[index]
+
+		var attachment *Attachment
+		var err error
+		switch {
+		case source.Content != "":
+			attachment = &Attachment{Name: source.Name, Data: []byte(source.Content)}
+		case source.Path != "":
+			attachment, err = loadFileAttachment(sc.cfg.AttachmentsDir, source.Path)
+		case source.URL != "":
+			attachment, err = sc.loadURLAttachment(source.URL)
+		case source.Metric != nil:
+			attachment, err = sc.loadMetricAttachment(source.Metric)
+		default:
+			err = errors.New("attachment source not supplied")
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// overwrite the name and content type, if supplied
+		if source.Name != "" {
+			attachment.Name = source.Name
+		}
+		if attachment.Name == "" {
+			attachment.Name = fmt.Sprintf("attachment_%d", index+1)
+		}
+		if source.ContentType != "" {
+			attachment.ContentType = source.ContentType
+		}
+		if attachment.ContentType == "" {
+			attachment.ContentType = mime.TypeByExtension(filepath.Ext(attachment.Name))
+		}
+		if attachment.ContentType == "" {
+			attachment.ContentType = http.DetectContentType(attachment.Data)
+		}
+
+		totalSize += len(attachment.Data)
+		if totalSize > maxAttachmentSize {
+			return nil, fmt.Errorf("attachments size exceeds the limit. [limit: %d bytes]", maxAttachmentSize)
+		}
+		attachments = append(attachments, attachment)
+	}
+	return attachments, nil
+}
+
+// loadFileAttachment loads the file, on a pattern the latest modified file is used.
+// the path is relative to the base directory, files outside of the base directory are not allowed
+func loadFileAttachment(baseDir, pattern string) (*Attachment, error) {
+	if baseDir == "" {
+		return nil, errors.New("file attachments not allowed, attachments directory not configured")
+	}
+	baseDir, err := filepath.Abs(baseDir)
+	if err != nil {
+		return nil, err
+	}
+	// symbolic links are resolved to verify the location of the files
+	resolvedBaseDir, err := filepath.EvalSymlinks(baseDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(baseDir, pattern)
+	}
+	pattern = filepath.Clean(pattern)
+	if !isWithinDir(baseDir, pattern) && !isWithinDir(resolvedBaseDir, pattern) {
+		return nil, fmt.Errorf("file not allowed, outside of the attachments directory. [path: %s]", pattern)
+	}
+
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	var latest string
+	var latestModTime time.Time
+	for _, file := range files {
+		resolved, err := filepath.EvalSymlinks(file)
+		if err != nil || !isWithinDir(resolvedBaseDir, resolved) {
+			continue
+		}
+		info, err := os.Stat(resolved)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		if latest == "" || info.ModTime().After(latestModTime) {
+			latest = resolved
+			latestModTime = info.ModTime()
+		}
+	}
+	if latest == "" {
+		return nil, fmt.Errorf("file not available. [path: %s]", pattern)
+	}
+
+	data, err := os.ReadFile(latest)
+	if err != nil {
+		return nil, err
+	}
+	return &Attachment{Name: filepath.Base(latest), Data: data}, nil
+}
+
+// isWithinDir returns true, if the path is inside of the directory
+func isWithinDir(dir, path string) bool {
+	relative, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return relative != ".." && !strings.HasPrefix(relative, ".."+string(filepath.Separator)) && relative != "."
+}
+
+func (sc *SmtpClient) loadURLAttachment(url string) (*Attachment, error) {
+	client := httpclient.GetClient(sc.cfg.Insecure, timeout)
+	response, err := client.Execute(url, http.MethodGet, nil, nil, "", http.StatusOK)
+	if err != nil {
+		return nil, fmt.Errorf("error on downloading attachment: %w", err)
+	}
+	name := filepath.Base(strings.SplitN(url, "?", 2)[0])
+	return &Attachment{Name: name, ContentType: response.Headers["Content-Type"], Data: response.Body}, nil
+}
+
+// loadMetricAttachment returns the metric data of a field as csv
+func (sc *SmtpClient) loadMetricAttachment(source *handlerTY.EmailMetricAttachment) (*Attachment, error) {
+	if sc.metric == nil {
+		return nil, errors.New("metric database not available on this handler service")
+	}
+
+	resourceType, _, err := quickIdUtils.EntityKeyValueMap(source.QuickID)
+	if err != nil {
+		return nil, err
+	}
+	if resourceType != quickIdUtils.QuickIdField {
+		return nil, fmt.Errorf("metric attachment supports only the field quick id. [quickId: %s]", source.QuickID)
+	}
+
+	// get the field details
+	var field map[string]interface{}
+	err = query.QueryResource(sc.logger, sc.bus, source.QuickID, rsTY.TypeQuickID, rsTY.CommandGet, nil, &field, queryTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if field == nil {
+		return nil, fmt.Errorf("field not available. [quickId: %s]", source.QuickID)
+	}
+	fieldID, _ := field["id"].(string)
+	metricType, _ := field["metricType"].(string)
+	if metricType == "" || metricType == metricTY.MetricTypeNone {
+		return nil, fmt.Errorf("metric not enabled on the field. [quickId: %s]", source.QuickID)
+	}
+
+	start := source.Start
+	if start == "" {
+		start = defaultMetricStart
+	}
+	queryConfig := &metricTY.QueryConfig{
+		Global: metricTY.Query{
+			Start:     start,
+			Stop:      source.Stop,
+			Window:    source.Window,
+			Functions: source.Functions,
+		},
+		Individual: []metricTY.Query{{
+			Name:       metricQueryName,
+			MetricType: metricType,
+			Tags:       map[string]string{types.KeyID: fieldID},
+		}},
+	}
+	result, err := sc.metric.Query(queryConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := toCSV(result[metricQueryName])
+	if err != nil {
+		return nil, err
+	}
+	sc.logger.Debug("metric attachment", zap.String("quickId", source.QuickID), zap.Int("rows", len(result[metricQueryName])))
+	name := fmt.Sprintf("%s.csv", strings.NewReplacer(":", "_", "/", "_").Replace(source.QuickID))
+	return &Attachment{Name: name, ContentType: "text/csv", Data: data}, nil
+}
+
+// toCSV converts the metric data to csv, the first column is the timestamp
+func toCSV(rows []metricTY.ResponseData) ([]byte, error) {
+	columnsMap := map[string]bool{}
+	for _, row := range rows {
+		for key := range row.Metric {
+			columnsMap[key] = true
+		}
+	}
+	columns := make([]string, 0, len(columnsMap))
+	for key := range columnsMap {
+		columns = append(columns, key)
+	}
+	sort.Strings(columns)
+
+	buffer := &bytes.Buffer{}
+	writer := csv.NewWriter(buffer)
+	err := writer.Write(append([]string{"timestamp"}, columns...))
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		record := []string{row.Time.Format(time.RFC3339)}
+		for _, column := range columns {
+			value, found := row.Metric[column]
+			if !found || value == nil {
+				record = append(record, "")
+				continue
+			}
+			record = append(record, fmt.Sprintf("%v", value))
+		}
+		err = writer.Write(record)
+		if err != nil {
+			return nil, err
+		}
+	}
+	writer.Flush()
+	return buffer.Bytes(), writer.Error()
+}
@@ -0,0 +1,52 @@
+package email
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadFileAttachment(t *testing.T) {
+	root := t.TempDir()
+	baseDir := filepath.Join(root, "attachments")
+	require.NoError(t, os.MkdirAll(filepath.Join(baseDir, "backup"), os.ModePerm))
+
+	writeFile := func(path, content string, modTime time.Time) {
+		require.NoError(t, os.WriteFile(path, []byte(content), os.ModePerm))
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+	now := time.Now()
+	writeFile(filepath.Join(baseDir, "backup", "old.zip"), "old", now.Add(-time.Hour))
+	writeFile(filepath.Join(baseDir, "backup", "new.zip"), "new", now)
+	writeFile(filepath.Join(root, "secret.txt"), "secret", now)
+	require.NoError(t, os.Symlink(filepath.Join(root, "secret.txt"), filepath.Join(baseDir, "backup", "link.zip")))
+
+	// relative pattern, the latest modified file
+	attachment, err := loadFileAttachment(baseDir, "backup/*.zip")
+	require.NoError(t, err)
+	assert.Equal(t, "new.zip", attachment.Name)
+	assert.Equal(t, "new", string(attachment.Data))
+
+	// absolute path inside of the base directory
+	attachment, err = loadFileAttachment(baseDir, filepath.Join(baseDir, "backup", "old.zip"))
+	require.NoError(t, err)
+	assert.Equal(t, "old", string(attachment.Data))
+
+	// not configured
+	_, err = loadFileAttachment("", "backup/*.zip")
+	assert.ErrorContains(t, err, "attachments directory not configured")
+
+	// outside of the base directory
+	for _, pattern := range []string{"../secret.txt", filepath.Join(root, "*.txt"), "backup/../../secret.txt", "/etc/passwd"} {
+		_, err = loadFileAttachment(baseDir, pattern)
+		assert.ErrorContains(t, err, "outside of the attachments directory", pattern)
+	}
+
+	// symbolic link to a file outside of the base directory
+	_, err = loadFileAttachment(baseDir, "backup/link.zip")
+	assert.ErrorContains(t, err, "file not available")
+}
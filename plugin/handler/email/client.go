@@ -13,15 +13,44 @@ import (
 
 // Config of email service
 type Config struct {
-	Type      string
-	Host      string
-	Port      int
-	AuthType  string
-	Username  string
-	Password  string `json:"-" yaml:"-"`
-	FromEmail string
-	ToEmails  string // comma separated
-	Insecure  bool
+	Type           string
+	Host           string
+	Port           int
+	Security       string // ssl (implicit tls), starttls or none
+	AuthType       string
+	Username       string
+	Password       string `json:"-" yaml:"-"` // on xoauth2 without oauth2 config, used as access token
+	OAuth2         *OAuth2Config
+	FromEmail      string
+	ToEmails       string // comma separated
+	Insecure       bool
+	AttachmentsDir string // base directory of the file attachments, file attachments are not allowed if empty
+	Inbound        *InboundConfig
+}
+
+// OAuth2Config to get the access token for xoauth2 auth
+type OAuth2Config struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string `json:"-" yaml:"-"`
+	RefreshToken string `json:"-" yaml:"-"`
+	Scopes       []string
+}
+
+// InboundConfig polls the mailbox via IMAP and executes the commands from the allowed senders.
+// sender address can be forged, hence the token and the allowed quick ids are mandatory
+type InboundConfig struct {
+	Enabled        bool
+	Host           string
+	Port           int
+	Security       string // ssl (implicit tls), starttls or none
+	Username       string // uses the smtp username, if empty
+	Password       string `json:"-" yaml:"-"` // uses the smtp password, if empty
+	Mailbox        string
+	PollInterval   string
+	AllowedSenders []string // wildcard supported, ex: *@example.com
+	QuickIDs       []string // allowed quick ids, wildcard supported, ex: field:mysensors.1.*
+	Token          string   `json:"-" yaml:"-"` // the command should start with this token
 }
 
 const (
@@ -36,7 +65,7 @@ type Client interface {
 	Close() error
 	Post(variables map[string]interface{}) error
 	State() *types.State
-	Send(from string, to []string, subject, body string) error
+	Send(msg *Message) error
 }
 
 // service provider types
@@ -49,6 +78,15 @@ const (
 const (
 	AuthTypePlain   = "plain"
 	AuthTypeCRAMMD5 = "crammd5"
+	AuthTypeXOAUTH2 = "xoauth2"
+	AuthTypeNone    = "none"
+)
+
+// connection security options
+const (
+	SecuritySSL      = "ssl"
+	SecurityStartTLS = "starttls"
+	SecurityNone     = "none"
 )
 
 // email client
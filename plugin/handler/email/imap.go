@@ -0,0 +1,235 @@
+package email
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// minimal imap client, supports only the commands used by the inbound poller
+
+const maxLiteralSize = 50 * 1024 * 1024 // 50 MB, larger literals are rejected, not to allocate the size announced by a broken server
+
+var literalRegex = regexp.MustCompile(`\{(\d+)\}$`)
+
+type imapClient struct {
+	conn    net.Conn
+	reader  *bufio.Reader
+	tagID   int
+	timeout time.Duration // deadline of a command
+}
+
+// imapResponse untagged response, literals are kept separately
+type imapResponse struct {
+	Text     string
+	Literals [][]byte
+}
+
+// dialIMAP connects to the server with the security and reads the greeting
+func dialIMAP(host string, port int, security string, insecure bool) (*imapClient, error) {
+	address := net.JoinHostPort(host, strconv.Itoa(port))
+	tlsConfig := &tls.Config{InsecureSkipVerify: insecure, ServerName: host}
+	dialer := &net.Dialer{Timeout: timeout}
+
+	var conn net.Conn
+	var err error
+	if security == SecuritySSL || security == "" {
+		conn, err = tls.DialWithDialer(dialer, "tcp", address, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", address)
+	}
+	if err != nil {
+		return nil, err
+	}
+	client := &imapClient{conn: conn, reader: bufio.NewReader(conn), timeout: timeout}
+	err = client.setDeadline()
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	greeting, err := client.readResponse()
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	if !strings.HasPrefix(greeting.Text, "* OK") && !strings.HasPrefix(greeting.Text, "* PREAUTH") {
+		_ = conn.Close()
+		return nil, fmt.Errorf("unexpected imap greeting: %s", greeting.Text)
+	}
+
+	if security == SecurityStartTLS {
+		_, err = client.execute("STARTTLS")
+		if err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+		err = client.setDeadline()
+		if err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+		tlsConn := tls.Client(conn, tlsConfig)
+		err = tlsConn.Handshake()
+		if err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+		client.conn = tlsConn
+		client.reader = bufio.NewReader(tlsConn)
+	}
+	return client, nil
+}
+
+// setDeadline extends the deadline of the connection for the next command
+func (c *imapClient) setDeadline() error {
+	return c.conn.SetDeadline(time.Now().Add(c.timeout))
+}
+
+func (c *imapClient) Close() error {
+	return c.conn.Close()
+}
+
+// Login with username and password
+func (c *imapClient) Login(username, password string) error {
+	_, err := c.execute(fmt.Sprintf("LOGIN %s %s", quote(username), quote(password)))
+	return err
+}
+
+// AuthenticateXOAUTH2 with the access token
+func (c *imapClient) AuthenticateXOAUTH2(username, accessToken string) error {
+	response := base64.StdEncoding.EncodeToString(xoauth2Response(username, accessToken))
+	_, err := c.execute(fmt.Sprintf("AUTHENTICATE XOAUTH2 %s", response))
+	return err
+}
+
+// Select the mailbox
+func (c *imapClient) Select(mailbox string) error {
+	_, err := c.execute(fmt.Sprintf("SELECT %s", quote(mailbox)))
+	return err
+}
+
+// SearchUnseen returns the uids of the unseen messages
+func (c *imapClient) SearchUnseen() ([]string, error) {
+	responses, err := c.execute("UID SEARCH UNSEEN")
+	if err != nil {
+		return nil, err
+	}
+	uids := make([]string, 0)
+	for _, response := range responses {
+		if strings.HasPrefix(response.Text, "* SEARCH") {
+			uids = append(uids, strings.Fields(strings.TrimPrefix(response.Text, "* SEARCH"))...)
+		}
+	}
+	return uids, nil
+}
+
+// Fetch returns the complete message, without updating the seen flag
+func (c *imapClient) Fetch(uid string) ([]byte, error) {
+	responses, err := c.execute(fmt.Sprintf("UID FETCH %s BODY.PEEK[]", uid))
+	if err != nil {
+		return nil, err
+	}
+	for _, response := range responses {
+		if strings.Contains(response.Text, "FETCH") && len(response.Literals) > 0 {
+			return response.Literals[0], nil
+		}
+	}
+	return nil, fmt.Errorf("message not available. [uid: %s]", uid)
+}
+
+// MarkSeen updates the seen flag of the message
+func (c *imapClient) MarkSeen(uid string) error {
+	_, err := c.execute(fmt.Sprintf(`UID STORE %s +FLAGS.SILENT (\Seen)`, uid))
+	return err
+}
+
+// Logout from the server
+func (c *imapClient) Logout() error {
+	_, err := c.execute("LOGOUT")
+	return err
+}
+
+// execute the command and returns the untagged responses
+func (c *imapClient) execute(command string) ([]*imapResponse, error) {
+	err := c.setDeadline()
+	if err != nil {
+		return nil, err
+	}
+
+	c.tagID++
+	tag := fmt.Sprintf("A%03d", c.tagID)
+	_, err = fmt.Fprintf(c.conn, "%s %s\r\n", tag, command)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*imapResponse, 0)
+	for {
+		response, err := c.readResponse()
+		if err != nil {
+			return nil, err
+		}
+		// continuation request, cancels the command. ex: failure details on authenticate
+		if strings.HasPrefix(response.Text, "+") {
+			_, err = fmt.Fprint(c.conn, "\r\n")
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if !strings.HasPrefix(response.Text, tag+" ") {
+			responses = append(responses, response)
+			continue
+		}
+		status := strings.TrimPrefix(response.Text, tag+" ")
+		if !strings.HasPrefix(status, "OK") {
+			return nil, fmt.Errorf("imap command failed: %s", status)
+		}
+		return responses, nil
+	}
+}
+
+// readResponse reads a response line with the literals
+func (c *imapClient) readResponse() (*imapResponse, error) {
+	response := &imapResponse{}
+	for {
+		line, err := c.reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		response.Text += line
+
+		matches := literalRegex.FindStringSubmatch(line)
+		if matches == nil {
+			return response, nil
+		}
+		size, err := strconv.Atoi(matches[1])
+		if err != nil {
+			return nil, err
+		}
+		if size > maxLiteralSize {
+			return nil, fmt.Errorf("imap literal size exceeds the limit. [size: %d, limit: %d]", size, maxLiteralSize)
+		}
+		literal := make([]byte, size)
+		_, err = io.ReadFull(c.reader, literal)
+		if err != nil {
+			return nil, err
+		}
+		response.Literals = append(response.Literals, literal)
+	}
+}
+
+func quote(value string) string {
+	if strings.ContainsAny(value, "\r\n") {
+		value = strings.NewReplacer("\r", "", "\n", "").Replace(value)
+	}
+	return fmt.Sprintf(`"%s"`, strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value))
+}
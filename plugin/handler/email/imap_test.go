@@ -0,0 +1,68 @@
+package email
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newPipeClient returns a client connected to the server func over a pipe
+func newPipeClient(t *testing.T, timeout time.Duration, serve func(reader *bufio.Reader, conn net.Conn)) *imapClient {
+	clientConn, serverConn := net.Pipe()
+	t.Cleanup(func() {
+		_ = clientConn.Close()
+		_ = serverConn.Close()
+	})
+	go serve(bufio.NewReader(serverConn), serverConn)
+	return &imapClient{conn: clientConn, reader: bufio.NewReader(clientConn), timeout: timeout}
+}
+
+func TestIMAPLiteralLimit(t *testing.T) {
+	client := newPipeClient(t, time.Second, func(reader *bufio.Reader, conn net.Conn) {
+		line, _ := reader.ReadString('\n')
+		tag := strings.Fields(line)[0]
+		_, _ = fmt.Fprintf(conn, "* 1 FETCH (UID 1 BODY[] {%d}\r\n", maxLiteralSize+1)
+		_, _ = fmt.Fprintf(conn, "%s OK done\r\n", tag)
+	})
+
+	_, err := client.Fetch("1")
+	assert.ErrorContains(t, err, "imap literal size exceeds the limit")
+}
+
+func TestIMAPCommandDeadline(t *testing.T) {
+	commandTimeout := 100 * time.Millisecond
+	client := newPipeClient(t, commandTimeout, func(reader *bufio.Reader, conn net.Conn) {
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			fields := strings.Fields(line)
+			if fields[1] == "NOOP" {
+				// no reply, the command times out
+				continue
+			}
+			// each reply is within the command deadline, all together beyond it
+			time.Sleep(commandTimeout / 2)
+			_, _ = fmt.Fprintf(conn, "%s OK done\r\n", fields[0])
+		}
+	})
+
+	for index := 0; index < 4; index++ {
+		require.NoError(t, client.Select("INBOX"))
+	}
+
+	start := time.Now()
+	_, err := client.execute("NOOP")
+	require.Error(t, err)
+	var netErr net.Error
+	require.ErrorAs(t, err, &netErr)
+	assert.True(t, netErr.Timeout())
+	assert.Less(t, time.Since(start), 10*commandTimeout)
+}
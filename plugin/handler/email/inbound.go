@@ -0,0 +1,378 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mycontroller-org/server/v2/pkg/types"
+	rsTY "github.com/mycontroller-org/server/v2/pkg/types/resource_service"
+	"github.com/mycontroller-org/server/v2/pkg/utils"
+	busUtils "github.com/mycontroller-org/server/v2/pkg/utils/bus_utils"
+	"github.com/mycontroller-org/server/v2/pkg/utils/bus_utils/query"
+	quickIdUtils "github.com/mycontroller-org/server/v2/pkg/utils/quick_id"
+	handlerTY "github.com/mycontroller-org/server/v2/plugin/handler/types"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultPollInterval = time.Minute
+	defaultMailbox      = "INBOX"
+	defaultIMAPPort     = 993
+	maxTextSize         = 64 * 1024
+)
+
+// inbound commands
+const (
+	CommandHelp     = "help"
+	CommandGet      = "get"
+	CommandSet      = "set"
+	CommandTask     = "task"
+	CommandSchedule = "schedule"
+)
+
+const inboundHelpText = `available commands, on the subject or on the first line of the body:
+get <quickId> - details of a resource, ex: get field:mysensors.1.2.V_STATUS
+set <quickId> <value> - executes the value on a resource, ex: set field:mysensors.1.2.V_STATUS 1
+task <id> <enable|disable|reload|trigger> - executes the action on a task
+schedule <id> <enable|disable|reload|trigger> - executes the action on a schedule`
+
+var replyPrefixRegex = regexp.MustCompile(`(?i)^((re|fw|fwd)\s*:\s*)+`)
+
+// pollInbound checks the mailbox for the commands, till the context is cancelled
+func (sc *SmtpClient) pollInbound(ctx context.Context) {
+	interval := utils.ToDuration(sc.cfg.Inbound.PollInterval, defaultPollInterval)
+	sc.logger.Info("email inbound poller started", zap.String("handlerID", sc.handlerCfg.ID), zap.String("pollInterval", interval.String()))
+	for {
+		err := sc.checkInbound(ctx)
+		if err != nil {
+			sc.logger.Error("error on checking inbound emails", zap.String("handlerID", sc.handlerCfg.ID), zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			sc.logger.Info("email inbound poller stopped", zap.String("handlerID", sc.handlerCfg.ID))
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// checkInbound executes the commands from the unseen messages
+func (sc *SmtpClient) checkInbound(ctx context.Context) error {
+	inbound := sc.cfg.Inbound
+	port := inbound.Port
+	if port == 0 {
+		port = defaultIMAPPort
+	}
+	username := inbound.Username
+	password := inbound.Password
+	if username == "" {
+		username = sc.cfg.Username
+		password = sc.cfg.Password
+	}
+	mailbox := inbound.Mailbox
+	if mailbox == "" {
+		mailbox = defaultMailbox
+	}
+
+	client, err := dialIMAP(inbound.Host, port, inbound.Security, sc.cfg.Insecure)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if sc.tokens != nil {
+		accessToken, err := sc.tokens.Token()
+		if err != nil {
+			return err
+		}
+		err = client.AuthenticateXOAUTH2(username, accessToken)
+		if err != nil {
+			return err
+		}
+	} else {
+		err = client.Login(username, password)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = client.Select(mailbox)
+	if err != nil {
+		return err
+	}
+
+	uids, err := client.SearchUnseen()
+	if err != nil {
+		return err
+	}
+
+	for _, uid := range uids {
+		if ctx.Err() != nil {
+			break
+		}
+		rawMessage, err := client.Fetch(uid)
+		if err != nil {
+			return err
+		}
+		// marks as seen before the execution, a failure should not execute the command again
+		err = client.MarkSeen(uid)
+		if err != nil {
+			return err
+		}
+		sc.onInboundMessage(rawMessage)
+	}
+	return client.Logout()
+}
+
+// onInboundMessage executes the command and replies to the sender
+func (sc *SmtpClient) onInboundMessage(rawMessage []byte) {
+	msg, err := mail.ReadMessage(bytes.NewReader(rawMessage))
+	if err != nil {
+		sc.logger.Error("error on parsing inbound email", zap.Error(err))
+		return
+	}
+
+	from, err := mail.ParseAddress(msg.Header.Get("From"))
+	if err != nil {
+		sc.logger.Warn("invalid sender on inbound email", zap.String("from", msg.Header.Get("From")), zap.Error(err))
+		return
+	}
+	sender := strings.ToLower(from.Address)
+
+	// not replied to the senders not allowed, avoids the backscatter
+	if !sc.isSenderAllowed(sender) {
+		sc.logger.Warn("inbound email rejected, sender not allowed", zap.String("sender", sender))
+		return
+	}
+
+	decoder := &mime.WordDecoder{}
+	subject, err := decoder.DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		subject = msg.Header.Get("Subject")
+	}
+
+	commandText := strings.TrimSpace(replyPrefixRegex.ReplaceAllString(subject, ""))
+	if commandText == "" {
+		text, err := getTextBody(msg.Header.Get("Content-Type"), msg.Header.Get("Content-Transfer-Encoding"), msg.Body)
+		if err != nil {
+			sc.logger.Error("error on reading inbound email body", zap.String("sender", sender), zap.Error(err))
+			return
+		}
+		commandText = firstLine(text)
+	}
+
+	// the sender address can be forged, the token is verified on all the commands
+	fields := strings.Fields(commandText)
+	if len(fields) == 0 || sc.cfg.Inbound.Token == "" || subtle.ConstantTimeCompare([]byte(fields[0]), []byte(sc.cfg.Inbound.Token)) != 1 {
+		sc.logger.Warn("inbound email rejected, invalid token", zap.String("sender", sender))
+		return
+	}
+	commandText = strings.TrimSpace(strings.TrimPrefix(commandText, fields[0]))
+
+	sc.logger.Info("executing inbound email command", zap.String("sender", sender), zap.String("command", commandText))
+	replyText := sc.executeCommand(commandText)
+
+	// the subject without the token
+	reply := &Message{
+		From:    sc.cfg.FromEmail,
+		To:      []string{from.Address},
+		Subject: fmt.Sprintf("Re: %s", commandText),
+		Text:    replyText,
+		Headers: map[string]string{},
+	}
+	if messageID := msg.Header.Get("Message-ID"); messageID != "" {
+		reply.Headers["In-Reply-To"] = messageID
+		reply.Headers["References"] = messageID
+	}
+	err = sc.Send(reply)
+	if err != nil {
+		sc.logger.Error("error on replying to inbound email", zap.String("sender", sender), zap.Error(err))
+	}
+}
+
+func (sc *SmtpClient) isSenderAllowed(sender string) bool {
+	for _, pattern := range sc.cfg.Inbound.AllowedSenders {
+		if matched, err := path.Match(strings.ToLower(pattern), sender); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (sc *SmtpClient) isQuickIDAllowed(quickID string) bool {
+	for _, pattern := range sc.cfg.Inbound.QuickIDs {
+		if matched, err := path.Match(pattern, quickID); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// executeCommand executes the command and returns the reply text
+func (sc *SmtpClient) executeCommand(text string) string {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return inboundHelpText
+	}
+	command := strings.ToLower(fields[0])
+	args := fields[1:]
+
+	switch command {
+	case CommandHelp:
+		return inboundHelpText
+
+	case CommandGet:
+		if len(args) != 1 {
+			return "usage: get <quickId>"
+		}
+		if !sc.isQuickIDAllowed(args[0]) {
+			return fmt.Sprintf("'%s' is not allowed", args[0])
+		}
+		return sc.getResourceText(args[0])
+
+	case CommandSet:
+		if len(args) < 2 {
+			return "usage: set <quickId> <value>"
+		}
+		return sc.executeAction(args[0], strings.Join(args[1:], " "))
+
+	case CommandTask, CommandSchedule:
+		if len(args) != 2 {
+			return fmt.Sprintf("usage: %s <id> <enable|disable|reload|trigger>", command)
+		}
+		resourceType := quickIdUtils.QuickIdTask
+		if command == CommandSchedule {
+			resourceType = quickIdUtils.QuickIdSchedule
+		}
+		action := types.GetAction(args[1])
+		if action != types.ActionEnable && action != types.ActionDisable && action != types.ActionReload && action != types.ActionTrigger {
+			return fmt.Sprintf("unknown action '%s'. usage: %s <id> <enable|disable|reload|trigger>", args[1], command)
+		}
+		return sc.executeAction(fmt.Sprintf("%s:%s", resourceType, args[0]), action)
+
+	default:
+		return fmt.Sprintf("unknown command '%s'\n\n%s", command, inboundHelpText)
+	}
+}
+
+// executeAction posts the action to the resource service, executed via the action api
+func (sc *SmtpClient) executeAction(quickID, payload string) string {
+	if !sc.isQuickIDAllowed(quickID) {
+		return fmt.Sprintf("'%s' is not allowed", quickID)
+	}
+	if !quickIdUtils.IsValidQuickID(quickID) {
+		return fmt.Sprintf("invalid quick id '%s'", quickID)
+	}
+	typeID := strings.SplitN(quickID, ":", 2)
+	rsData := &handlerTY.ResourceData{
+		ResourceType: typeID[0],
+		QuickID:      typeID[1],
+		Payload:      payload,
+	}
+	sc.logger.Debug("executing an action", zap.Any("data", rsData))
+	busUtils.PostToResourceService(sc.logger, sc.bus, sc.handlerCfg.ID, rsData, rsTY.TypeResourceAction, rsTY.CommandSet, "")
+	return fmt.Sprintf("request sent. %s => %s", quickID, payload)
+}
+
+// getResourceText returns the details of the resource as text
+func (sc *SmtpClient) getResourceText(quickID string) string {
+	if !quickIdUtils.IsValidQuickID(quickID) {
+		return fmt.Sprintf("invalid quick id '%s'", quickID)
+	}
+
+	var resource map[string]interface{}
+	err := query.QueryResource(sc.logger, sc.bus, quickID, rsTY.TypeQuickID, rsTY.CommandGet, nil, &resource, queryTimeout)
+	if err != nil {
+		sc.logger.Error("error on getting a resource", zap.String("quickID", quickID), zap.Error(err))
+		return fmt.Sprintf("%s: %s", quickID, err.Error())
+	}
+	if resource == nil {
+		return fmt.Sprintf("%s: resource not available", quickID)
+	}
+
+	lines := []string{quickID}
+	for _, keyPath := range []string{"name", "current.value", "unit", "current.timestamp", "enabled", "state.status", "state.message"} {
+		if value := getValue(resource, keyPath); value != nil && value != "" {
+			lines = append(lines, fmt.Sprintf("%s: %v", keyPath, value))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// getValue returns the value of the key path from the map, nil if not available
+func getValue(data map[string]interface{}, keyPath string) interface{} {
+	var value interface{} = data
+	for _, key := range strings.Split(keyPath, ".") {
+		valueMap, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = valueMap[key]
+	}
+	return value
+}
+
+// getTextBody returns the text/plain content, looks into the multipart messages
+func getTextBody(contentType, transferEncoding string, body io.Reader) (string, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = "text/plain"
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextRawPart()
+			if err == io.EOF {
+				return "", nil
+			}
+			if err != nil {
+				return "", err
+			}
+			text, err := getTextBody(part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part)
+			if err != nil {
+				return "", err
+			}
+			if text != "" {
+				return text, nil
+			}
+		}
+	}
+
+	if mediaType != "text/plain" {
+		return "", nil
+	}
+
+	switch strings.ToLower(transferEncoding) {
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	}
+	data, err := io.ReadAll(io.LimitReader(body, maxTextSize))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func firstLine(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
@@ -0,0 +1,428 @@
+package email
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/mail"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mycontroller-org/server/v2/pkg/types/cmap"
+	rsTY "github.com/mycontroller-org/server/v2/pkg/types/resource_service"
+	"github.com/mycontroller-org/server/v2/pkg/types/topic"
+	loggerUtils "github.com/mycontroller-org/server/v2/pkg/utils/logger"
+	"github.com/mycontroller-org/server/v2/plugin/bus/embedded"
+	busTY "github.com/mycontroller-org/server/v2/plugin/bus/types"
+	handlerTY "github.com/mycontroller-org/server/v2/plugin/handler/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const testToken = "s3cret"
+
+// fakeIMAPServer serves the messages to the minimal imap client
+type fakeIMAPServer struct {
+	listener net.Listener
+	mutex    sync.Mutex
+	messages []string
+	seen     map[int]bool
+}
+
+func newFakeIMAPServer(t *testing.T, messages ...string) *fakeIMAPServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := &fakeIMAPServer{listener: listener, messages: messages, seen: map[int]bool{}}
+	t.Cleanup(func() { _ = listener.Close() })
+	go server.serve()
+	return server
+}
+
+func (s *fakeIMAPServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *fakeIMAPServer) seenCount() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.seen)
+}
+
+func (s *fakeIMAPServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeIMAPServer) handle(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	fmt.Fprint(conn, "* OK fake imap ready\r\n")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(strings.TrimSpace(line))
+		if len(fields) < 2 {
+			return
+		}
+		tag, command := fields[0], strings.ToUpper(strings.Join(fields[1:], " "))
+
+		s.mutex.Lock()
+		switch {
+		case strings.HasPrefix(command, "UID SEARCH UNSEEN"):
+			uids := []string{}
+			for index := range s.messages {
+				if !s.seen[index+1] {
+					uids = append(uids, strconv.Itoa(index+1))
+				}
+			}
+			fmt.Fprintf(conn, "* SEARCH %s\r\n", strings.Join(uids, " "))
+
+		case strings.HasPrefix(command, "UID FETCH"):
+			uid, _ := strconv.Atoi(fields[3])
+			message := s.messages[uid-1]
+			fmt.Fprintf(conn, "* %d FETCH (UID %d BODY[] {%d}\r\n%s)\r\n", uid, uid, len(message), message)
+
+		case strings.HasPrefix(command, "UID STORE"):
+			uid, _ := strconv.Atoi(fields[3])
+			s.seen[uid] = true
+
+		case strings.HasPrefix(command, "LOGOUT"):
+			fmt.Fprint(conn, "* BYE\r\n")
+		}
+		s.mutex.Unlock()
+		fmt.Fprintf(conn, "%s OK done\r\n", tag)
+	}
+}
+
+// fakeSMTPServer receives the reply emails
+type fakeSMTPServer struct {
+	listener net.Listener
+	received chan string
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := &fakeSMTPServer{listener: listener, received: make(chan string, 10)}
+	t.Cleanup(func() { _ = listener.Close() })
+	go server.serve()
+	return server
+}
+
+func (s *fakeSMTPServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *fakeSMTPServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeSMTPServer) handle(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	fmt.Fprint(conn, "220 fake smtp ready\r\n")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			fmt.Fprint(conn, "250 fake\r\n")
+		case command == "DATA":
+			fmt.Fprint(conn, "354 go ahead\r\n")
+			data := &strings.Builder{}
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(dataLine)
+			}
+			s.received <- data.String()
+			fmt.Fprint(conn, "250 queued\r\n")
+		case command == "QUIT":
+			fmt.Fprint(conn, "221 bye\r\n")
+			return
+		default:
+			fmt.Fprint(conn, "250 ok\r\n")
+		}
+	}
+}
+
+func (s *fakeSMTPServer) next(t *testing.T) string {
+	select {
+	case message := <-s.received:
+		return message
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "reply email not received")
+		return ""
+	}
+}
+
+func (s *fakeSMTPServer) assertEmpty(t *testing.T) {
+	select {
+	case message := <-s.received:
+		assert.Fail(t, "unexpected reply email", message)
+	default:
+	}
+}
+
+func newTestBus(t *testing.T) (busTY.Plugin, chan *handlerTY.ResourceData) {
+	ctx := loggerUtils.WithContext(context.Background(), zap.NewNop())
+	bus, err := embedded.NewClient(ctx, cmap.CustomMap{})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = bus.Close() })
+
+	actions := make(chan *handlerTY.ResourceData, 10)
+	_, err = bus.Subscribe(topic.TopicServiceResourceServer, func(data *busTY.BusData) {
+		event := &rsTY.ServiceEvent{}
+		if data.LoadData(event) != nil || event.Type != rsTY.TypeResourceAction {
+			return
+		}
+		rsData := &handlerTY.ResourceData{}
+		if event.LoadData(rsData) == nil {
+			actions <- rsData
+		}
+	})
+	require.NoError(t, err)
+	return bus, actions
+}
+
+func newTestInboundClient(t *testing.T, imapServer *fakeIMAPServer, smtpServer *fakeSMTPServer, bus busTY.Plugin) *SmtpClient {
+	return &SmtpClient{
+		handlerCfg: &handlerTY.Config{ID: "email_test"},
+		cfg: &Config{
+			Host:      "127.0.0.1",
+			Port:      smtpServer.port(),
+			Security:  SecurityNone,
+			FromEmail: "controller@example.com",
+			Inbound: &InboundConfig{
+				Enabled:        true,
+				Host:           "127.0.0.1",
+				Port:           imapServer.port(),
+				Security:       SecurityNone,
+				Username:       "controller",
+				Password:       "password",
+				AllowedSenders: []string{"*@example.com"},
+				QuickIDs:       []string{"field:mysensors.1.*", "task:*"},
+				Token:          testToken,
+			},
+		},
+		logger: zap.NewNop(),
+		bus:    bus,
+	}
+}
+
+func testEmail(from, subject string) string {
+	return strings.Join([]string{
+		fmt.Sprintf("From: %s", from),
+		"To: controller@example.com",
+		fmt.Sprintf("Subject: %s", subject),
+		"Message-ID: <test@example.com>",
+		"Content-Type: text/plain",
+		"",
+		"",
+	}, "\r\n")
+}
+
+func nextAction(t *testing.T, actions chan *handlerTY.ResourceData) *handlerTY.ResourceData {
+	select {
+	case action := <-actions:
+		return action
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "action not received")
+		return nil
+	}
+}
+
+func TestInboundCommands(t *testing.T) {
+	tests := []struct {
+		name         string
+		email        string
+		replyContain string
+		action       *handlerTY.ResourceData
+	}{
+		{
+			name:         "set on allowed quick id",
+			email:        testEmail("user@example.com", fmt.Sprintf("%s set field:mysensors.1.2.V_STATUS 1", testToken)),
+			replyContain: "request sent. field:mysensors.1.2.V_STATUS => 1",
+			action:       &handlerTY.ResourceData{ResourceType: "field", QuickID: "mysensors.1.2.V_STATUS", Payload: "1"},
+		},
+		{
+			name:         "command from the body with reply prefix",
+			email:        testEmail("User <USER@example.com>", "Re: ") + fmt.Sprintf("\r\n%s task backup trigger\r\n", testToken),
+			replyContain: "request sent. task:backup => trigger",
+			action:       &handlerTY.ResourceData{ResourceType: "task", QuickID: "backup", Payload: "trigger"},
+		},
+		{
+			name:         "help",
+			email:        testEmail("user@example.com", fmt.Sprintf("%s help", testToken)),
+			replyContain: "task <id> <enable|disable|reload|trigger>",
+		},
+		{
+			name:         "quick id not allowed",
+			email:        testEmail("user@example.com", fmt.Sprintf("%s set field:mysensors.2.1.V_STATUS 1", testToken)),
+			replyContain: "'field:mysensors.2.1.V_STATUS' is not allowed",
+		},
+		{
+			name:         "schedule not allowed",
+			email:        testEmail("user@example.com", fmt.Sprintf("%s schedule morning trigger", testToken)),
+			replyContain: "'schedule:morning' is not allowed",
+		},
+		{
+			name:         "unknown task action",
+			email:        testEmail("user@example.com", fmt.Sprintf("%s task backup delete", testToken)),
+			replyContain: "unknown action 'delete'",
+		},
+		{name: "missing token", email: testEmail("user@example.com", "set field:mysensors.1.2.V_STATUS 1")},
+		{name: "invalid token", email: testEmail("user@example.com", "wrong set field:mysensors.1.2.V_STATUS 1")},
+		{name: "sender not allowed", email: testEmail("user@other.com", fmt.Sprintf("%s set field:mysensors.1.2.V_STATUS 1", testToken))},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			imapServer := newFakeIMAPServer(t, test.email)
+			smtpServer := newFakeSMTPServer(t)
+			bus, actions := newTestBus(t)
+			client := newTestInboundClient(t, imapServer, smtpServer, bus)
+
+			require.NoError(t, client.checkInbound(context.Background()))
+			assert.Equal(t, 1, imapServer.seenCount())
+
+			if test.replyContain == "" {
+				smtpServer.assertEmpty(t)
+			} else {
+				reply, err := mail.ReadMessage(strings.NewReader(smtpServer.next(t)))
+				require.NoError(t, err)
+				assert.Equal(t, "<test@example.com>", reply.Header.Get("In-Reply-To"))
+				assert.NotContains(t, reply.Header.Get("Subject"), testToken)
+				text, err := getTextBody(reply.Header.Get("Content-Type"), reply.Header.Get("Content-Transfer-Encoding"), reply.Body)
+				require.NoError(t, err)
+				assert.Contains(t, text, test.replyContain)
+			}
+
+			if test.action == nil {
+				select {
+				case action := <-actions:
+					assert.Fail(t, "unexpected action", action)
+				case <-time.After(100 * time.Millisecond):
+				}
+				return
+			}
+			action := nextAction(t, actions)
+			assert.Equal(t, test.action.ResourceType, action.ResourceType)
+			assert.Equal(t, test.action.QuickID, action.QuickID)
+			assert.Equal(t, test.action.Payload, action.Payload)
+		})
+	}
+}
+
+func TestInboundMarksSeenOnce(t *testing.T) {
+	imapServer := newFakeIMAPServer(t,
+		testEmail("user@example.com", fmt.Sprintf("%s set field:mysensors.1.2.V_STATUS 1", testToken)),
+		testEmail("user@example.com", fmt.Sprintf("%s set field:mysensors.1.2.V_STATUS 0", testToken)),
+	)
+	smtpServer := newFakeSMTPServer(t)
+	bus, actions := newTestBus(t)
+	client := newTestInboundClient(t, imapServer, smtpServer, bus)
+
+	require.NoError(t, client.checkInbound(context.Background()))
+	assert.Equal(t, 2, imapServer.seenCount())
+	assert.Equal(t, "1", nextAction(t, actions).Payload)
+	assert.Equal(t, "0", nextAction(t, actions).Payload)
+
+	// already seen messages are not executed again
+	require.NoError(t, client.checkInbound(context.Background()))
+	select {
+	case action := <-actions:
+		assert.Fail(t, "unexpected action", action)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestInboundConfigValidation(t *testing.T) {
+	valid := func() *InboundConfig {
+		return &InboundConfig{
+			Enabled:        true,
+			Host:           "imap.example.com",
+			AllowedSenders: []string{"user@example.com"},
+			QuickIDs:       []string{"field:*"},
+			Token:          testToken,
+		}
+	}
+
+	tests := []struct {
+		name   string
+		update func(cfg *InboundConfig)
+		err    string
+	}{
+		{name: "without host", update: func(cfg *InboundConfig) { cfg.Host = "" }, err: "inbound enabled without imap host"},
+		{name: "without senders", update: func(cfg *InboundConfig) { cfg.AllowedSenders = nil }, err: "inbound enabled without allowed senders"},
+		{name: "without token", update: func(cfg *InboundConfig) { cfg.Token = "" }, err: "inbound enabled without token"},
+		{name: "without quick ids", update: func(cfg *InboundConfig) { cfg.QuickIDs = nil }, err: "inbound enabled without allowed quick ids"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			inbound := valid()
+			test.update(inbound)
+			client := &SmtpClient{cfg: &Config{Inbound: inbound}, logger: zap.NewNop()}
+			assert.EqualError(t, client.Start(), test.err)
+		})
+	}
+
+	// disabled inbound is not validated
+	client := &SmtpClient{cfg: &Config{Inbound: &InboundConfig{}}, logger: zap.NewNop()}
+	assert.NoError(t, client.Start())
+}
+
+func TestGetTextBody(t *testing.T) {
+	multipartBody := strings.Join([]string{
+		"--boundary",
+		"Content-Type: text/html",
+		"",
+		"<b>html</b>",
+		"--boundary",
+		"Content-Type: text/plain",
+		"Content-Transfer-Encoding: quoted-printable",
+		"",
+		"token get field:mysensors.1.2.V=",
+		"_STATUS",
+		"--boundary--",
+		"",
+	}, "\r\n")
+	text, err := getTextBody(`multipart/alternative; boundary="boundary"`, "", strings.NewReader(multipartBody))
+	require.NoError(t, err)
+	assert.Equal(t, "token get field:mysensors.1.2.V_STATUS", firstLine(text))
+
+	text, err = getTextBody("text/plain", "base64", strings.NewReader("aGVsbG8="))
+	require.NoError(t, err)
+	assert.Equal(t, "hello", text)
+
+	text, err = getTextBody("text/html", "", io.LimitReader(strings.NewReader("<b>hi</b>"), 100))
+	require.NoError(t, err)
+	assert.Empty(t, text)
+}
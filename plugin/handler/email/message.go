@@ -0,0 +1,184 @@
+package email
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/mycontroller-org/server/v2/pkg/utils"
+)
+
+const base64LineLength = 76
+
+// Message to send
+type Message struct {
+	From        string
+	To          []string
+	Subject     string
+	Text        string
+	HTML        string
+	Headers     map[string]string // additional headers, ex: In-Reply-To
+	Attachments []*Attachment
+}
+
+// Attachment of a message
+type Attachment struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// Bytes returns the message in MIME format.
+// the text and html bodies are sent as alternatives, with attachments as mixed
+func (m *Message) Bytes() ([]byte, error) {
+	buffer := &bytes.Buffer{}
+
+	headers := []string{
+		fmt.Sprintf("From: %s", m.From),
+		fmt.Sprintf("To: %s", strings.Join(m.To, ", ")),
+		fmt.Sprintf("Subject: %s", mime.QEncoding.Encode("utf-8", m.Subject)),
+		fmt.Sprintf("Date: %s", time.Now().Format(time.RFC1123Z)),
+		fmt.Sprintf("Message-ID: <%s@%s>", utils.RandUUID(), domainOf(m.From)),
+		"MIME-Version: 1.0",
+	}
+	for key, value := range m.Headers {
+		headers = append(headers, fmt.Sprintf("%s: %s", key, value))
+	}
+
+	bodyHeader, body, err := m.body()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(m.Attachments) == 0 {
+		for key := range bodyHeader {
+			headers = append(headers, fmt.Sprintf("%s: %s", key, bodyHeader.Get(key)))
+		}
+		buffer.WriteString(strings.Join(headers, "\r\n"))
+		buffer.WriteString("\r\n\r\n")
+		buffer.Write(body)
+		return buffer.Bytes(), nil
+	}
+
+	writer := multipart.NewWriter(buffer)
+	headers = append(headers, fmt.Sprintf("Content-Type: multipart/mixed; boundary=%q", writer.Boundary()))
+	buffer.WriteString(strings.Join(headers, "\r\n"))
+	buffer.WriteString("\r\n\r\n")
+
+	bodyPart, err := writer.CreatePart(bodyHeader)
+	if err != nil {
+		return nil, err
+	}
+	_, err = bodyPart.Write(body)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, attachment := range m.Attachments {
+		// content type may have parameters, ex: text/csv; charset=utf-8
+		mediaType, params, err := mime.ParseMediaType(attachment.ContentType)
+		if err != nil {
+			mediaType = "application/octet-stream"
+			params = map[string]string{}
+		}
+		params["name"] = attachment.Name
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", mime.FormatMediaType(mediaType, params))
+		header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Name}))
+		header.Set("Content-Transfer-Encoding", "base64")
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			return nil, err
+		}
+		_, err = part.Write(toBase64Lines(attachment.Data))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// body returns the header and content of the body, html is sent with the text as alternatives
+func (m *Message) body() (textproto.MIMEHeader, []byte, error) {
+	if m.HTML == "" {
+		return textPart("text/plain", m.Text)
+	}
+
+	buffer := &bytes.Buffer{}
+	writer := multipart.NewWriter(buffer)
+	for _, body := range []struct{ contentType, content string }{{"text/plain", m.Text}, {"text/html", m.HTML}} {
+		if body.content == "" {
+			continue
+		}
+		header, content, err := textPart(body.contentType, body.content)
+		if err != nil {
+			return nil, nil, err
+		}
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			return nil, nil, err
+		}
+		_, err = part.Write(content)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	err := writer.Close()
+	if err != nil {
+		return nil, nil, err
+	}
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", fmt.Sprintf("multipart/alternative; boundary=%q", writer.Boundary()))
+	return header, buffer.Bytes(), nil
+}
+
+func textPart(contentType, content string) (textproto.MIMEHeader, []byte, error) {
+	buffer := &bytes.Buffer{}
+	writer := quotedprintable.NewWriter(buffer)
+	_, err := writer.Write([]byte(content))
+	if err != nil {
+		return nil, nil, err
+	}
+	err = writer.Close()
+	if err != nil {
+		return nil, nil, err
+	}
+	buffer.WriteString("\r\n")
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", fmt.Sprintf("%s; charset=\"UTF-8\"", contentType))
+	header.Set("Content-Transfer-Encoding", "quoted-printable")
+	return header, buffer.Bytes(), nil
+}
+
+func toBase64Lines(data []byte) []byte {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	buffer := &bytes.Buffer{}
+	for len(encoded) > base64LineLength {
+		buffer.WriteString(encoded[:base64LineLength])
+		buffer.WriteString("\r\n")
+		encoded = encoded[base64LineLength:]
+	}
+	buffer.WriteString(encoded)
+	buffer.WriteString("\r\n")
+	return buffer.Bytes()
+}
+
+func domainOf(address string) string {
+	address = strings.Trim(address, " <>")
+	if index := strings.LastIndex(address, "@"); index != -1 {
+		return strings.Trim(address[index+1:], " <>")
+	}
+	return "localhost"
+}
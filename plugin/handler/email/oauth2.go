@@ -0,0 +1,121 @@
+package email
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mycontroller-org/server/v2/pkg/json"
+	httpclient "github.com/mycontroller-org/server/v2/pkg/utils/http_client_json"
+)
+
+const (
+	tokenExpiryMargin = time.Minute
+)
+
+// tokenSource returns the access token for xoauth2.
+// refreshes the token via oauth2 config, if available, else the static access token is used
+type tokenSource struct {
+	mutex       sync.Mutex
+	config      *OAuth2Config
+	accessToken string
+	expiry      time.Time
+	httpClient  *httpclient.Client
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+func newTokenSource(cfg *Config) *tokenSource {
+	return &tokenSource{
+		config:      cfg.OAuth2,
+		accessToken: cfg.Password,
+		httpClient:  httpclient.GetClient(cfg.Insecure, timeout),
+	}
+}
+
+// Token returns the cached token, refreshes when expired
+func (ts *tokenSource) Token() (string, error) {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	if ts.config == nil {
+		if ts.accessToken == "" {
+			return "", errors.New("access token not supplied")
+		}
+		return ts.accessToken, nil
+	}
+
+	if ts.accessToken != "" && time.Now().Before(ts.expiry) {
+		return ts.accessToken, nil
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "refresh_token")
+	form.Set("refresh_token", ts.config.RefreshToken)
+	form.Set("client_id", ts.config.ClientID)
+	if ts.config.ClientSecret != "" {
+		form.Set("client_secret", ts.config.ClientSecret)
+	}
+	if len(ts.config.Scopes) > 0 {
+		form.Set("scope", strings.Join(ts.config.Scopes, " "))
+	}
+	headers := map[string]string{"Content-Type": "application/x-www-form-urlencoded"}
+	response, err := ts.httpClient.Execute(ts.config.TokenURL, http.MethodPost, headers, nil, form.Encode(), http.StatusOK)
+	if err != nil {
+		return "", fmt.Errorf("error on refreshing oauth2 token: %w", err)
+	}
+
+	token := &tokenResponse{}
+	err = json.Unmarshal(response.Body, token)
+	if err != nil {
+		return "", err
+	}
+	if token.AccessToken == "" {
+		return "", errors.New("access token not available on the oauth2 token response")
+	}
+	ts.accessToken = token.AccessToken
+	ts.expiry = time.Now().Add(time.Duration(token.ExpiresIn)*time.Second - tokenExpiryMargin)
+	return ts.accessToken, nil
+}
+
+// xoauth2Response returns the initial client response of the SASL XOAUTH2 mechanism
+func xoauth2Response(username, accessToken string) []byte {
+	return []byte(fmt.Sprintf("user=%s\x01auth=Bearer %s\x01\x01", username, accessToken))
+}
+
+// xoauth2Auth implements smtp.Auth
+type xoauth2Auth struct {
+	username string
+	tokens   *tokenSource
+}
+
+func (a *xoauth2Auth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS && !isLocalhost(server.Name) {
+		return "", nil, errors.New("unencrypted connection")
+	}
+	accessToken, err := a.tokens.Token()
+	if err != nil {
+		return "", nil, err
+	}
+	return "XOAUTH2", xoauth2Response(a.username, accessToken), nil
+}
+
+// Next on a failure the server sends the error details as challenge, an empty response ends the exchange
+func (a *xoauth2Auth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if more {
+		return []byte{}, nil
+	}
+	return nil, nil
+}
+
+func isLocalhost(name string) bool {
+	return name == "localhost" || name == "127.0.0.1" || name == "::1"
+}
@@ -3,6 +3,7 @@ package email
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"net"
 	"net/smtp"
@@ -11,39 +12,77 @@ import (
 
 	"github.com/mycontroller-org/server/v2/pkg/types"
 	"github.com/mycontroller-org/server/v2/pkg/utils"
+	busTY "github.com/mycontroller-org/server/v2/plugin/bus/types"
+	metricTY "github.com/mycontroller-org/server/v2/plugin/database/metric/types"
 	handlerTY "github.com/mycontroller-org/server/v2/plugin/handler/types"
 	"go.uber.org/zap"
 )
 
-const loggerNameSMTP = "handler_email_smtp"
+const (
+	loggerNameSMTP = "handler_email_smtp"
+	timeout        = time.Second * 30
+)
 
 // smtp client
 type SmtpClient struct {
 	handlerCfg *handlerTY.Config
 	cfg        *Config
 	auth       smtp.Auth
+	tokens     *tokenSource
 	logger     *zap.Logger
+	bus        busTY.Plugin
+	metric     metricTY.Plugin // not available on the standalone handler service
+	cancelPoll context.CancelFunc
 }
 
 // init smtp client
 func NewSMTPClient(ctx context.Context, logger *zap.Logger, handlerCfg *handlerTY.Config, cfg *Config) (Client, error) {
+	bus, err := busTY.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	var auth smtp.Auth
+	var tokens *tokenSource
 
 	switch cfg.AuthType {
 	case AuthTypePlain, "":
 		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
 	case AuthTypeCRAMMD5:
 		auth = smtp.CRAMMD5Auth(cfg.Username, cfg.Password)
+	case AuthTypeXOAUTH2:
+		if cfg.OAuth2 == nil && cfg.Password == "" {
+			return nil, errors.New("xoauth2 needs oauth2 config or access token on the password")
+		}
+		tokens = newTokenSource(cfg)
+		auth = &xoauth2Auth{username: cfg.Username, tokens: tokens}
+	case AuthTypeNone:
+		auth = nil
 	default:
 		return nil, fmt.Errorf("unknown auth type:%s", cfg.AuthType)
 	}
 
+	switch cfg.Security {
+	case SecuritySSL, SecurityStartTLS, SecurityNone, "":
+	default:
+		return nil, fmt.Errorf("unknown security type:%s", cfg.Security)
+	}
+
 	client := &SmtpClient{
 		handlerCfg: handlerCfg,
 		cfg:        cfg,
 		auth:       auth,
+		tokens:     tokens,
 		logger:     logger.Named(loggerNameSMTP),
+		bus:        bus,
 	}
+
+	// metric plugin used on the metric attachments
+	metric, err := metricTY.FromContext(ctx)
+	if err == nil {
+		client.metric = metric
+	}
+
 	client.logger.Info("init smtp email client success", zap.Any("handlerID", handlerCfg.ID))
 	return client, nil
 }
@@ -52,13 +91,34 @@ func (sc *SmtpClient) Name() string {
 	return PluginEmail
 }
 
+// Start the inbound poller, if enabled
 func (sc *SmtpClient) Start() error {
-	// nothing to do here
+	if sc.cfg.Inbound == nil || !sc.cfg.Inbound.Enabled {
+		return nil
+	}
+	if sc.cfg.Inbound.Host == "" {
+		return errors.New("inbound enabled without imap host")
+	}
+	if len(sc.cfg.Inbound.AllowedSenders) == 0 {
+		return errors.New("inbound enabled without allowed senders")
+	}
+	if sc.cfg.Inbound.Token == "" {
+		return errors.New("inbound enabled without token")
+	}
+	if len(sc.cfg.Inbound.QuickIDs) == 0 {
+		return errors.New("inbound enabled without allowed quick ids")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	sc.cancelPoll = cancel
+	go sc.pollInbound(ctx)
 	return nil
 }
 
 // Close func implementation
 func (sc *SmtpClient) Close() error {
+	if sc.cancelPoll != nil {
+		sc.cancelPoll()
+	}
 	return nil
 }
 
@@ -73,55 +133,34 @@ func (sc *SmtpClient) State() *types.State {
 }
 
 // Send func implementation
-func (sc *SmtpClient) Send(from string, to []string, subject, body string) error {
-	// set from address as username if non set
-	if from == "" {
-		from = sc.cfg.Username
-	}
-
-	addr := fmt.Sprintf("%s:%d", sc.cfg.Host, sc.cfg.Port)
-	mime := "MIME-version: 1.0;\nContent-Type: text/plain; charset=\"UTF-8\";\n\n"
-	msg := []byte(subject + "\n" + mime + "\n" + body)
-	return smtp.SendMail(addr, sc.auth, from, to, msg)
-}
-
-func (sc *SmtpClient) sendEmailSSL(from string, to []string, subject, body string) error {
+func (sc *SmtpClient) Send(msg *Message) error {
 	// set from address as username if non set
-	if from == "" {
-		from = sc.cfg.Username
+	if msg.From == "" {
+		msg.From = sc.cfg.Username
 	}
 
-	servername := fmt.Sprintf("%s:%d", sc.cfg.Host, sc.cfg.Port)
-	host, _, err := net.SplitHostPort(servername)
+	data, err := msg.Bytes()
 	if err != nil {
 		return err
 	}
 
-	// TLS config
-	tlsconfig := &tls.Config{
-		InsecureSkipVerify: sc.cfg.Insecure,
-		ServerName:         host,
-	}
-
-	conn, err := tls.Dial("tcp", servername, tlsconfig)
+	client, err := sc.connect()
 	if err != nil {
 		return err
 	}
+	defer client.Close()
 
-	client, err := smtp.NewClient(conn, host)
-	if err != nil {
-		return err
-	}
-
-	if err = client.Auth(sc.auth); err != nil {
-		return err
+	if sc.auth != nil {
+		if err = client.Auth(sc.auth); err != nil {
+			return err
+		}
 	}
 
-	if err = client.Mail(from); err != nil {
+	if err = client.Mail(msg.From); err != nil {
 		return err
 	}
 
-	for _, toAddr := range to {
+	for _, toAddr := range msg.To {
 		if err = client.Rcpt(toAddr); err != nil {
 			return err
 		}
@@ -132,17 +171,7 @@ func (sc *SmtpClient) sendEmailSSL(from string, to []string, subject, body strin
 		return err
 	}
 
-	headers := make(map[string]string)
-	headers["From"] = from
-	headers["To"] = strings.Join(to, ",")
-	headers["Subject"] = subject
-	message := ""
-	for k, v := range headers {
-		message += fmt.Sprintf("%s: %s\r\n", k, v)
-	}
-	message += "\r\n" + body
-
-	if _, err = write.Write([]byte(message)); err != nil {
+	if _, err = write.Write(data); err != nil {
 		return err
 	}
 
@@ -153,8 +182,53 @@ func (sc *SmtpClient) sendEmailSSL(from string, to []string, subject, body strin
 	return client.Quit()
 }
 
+// connect returns the smtp client with the configured security
+func (sc *SmtpClient) connect() (*smtp.Client, error) {
+	address := net.JoinHostPort(sc.cfg.Host, fmt.Sprintf("%d", sc.cfg.Port))
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: sc.cfg.Insecure,
+		ServerName:         sc.cfg.Host,
+	}
+	dialer := &net.Dialer{Timeout: timeout}
+
+	var conn net.Conn
+	var err error
+	if sc.cfg.Security == SecuritySSL || sc.cfg.Security == "" {
+		conn, err = tls.DialWithDialer(dialer, "tcp", address, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", address)
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = conn.SetDeadline(time.Now().Add(timeout * 2))
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	client, err := smtp.NewClient(conn, sc.cfg.Host)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	if sc.cfg.Security == SecurityStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			_ = client.Close()
+			return nil, errors.New("smtp server does not support STARTTLS")
+		}
+		if err = client.StartTLS(tlsConfig); err != nil {
+			_ = client.Close()
+			return nil, err
+		}
+	}
+	return client, nil
+}
+
 // Post performs send operation
 func (sc *SmtpClient) Post(parameters map[string]interface{}) error {
+	errs := make([]error, 0)
 	for name, rawParameter := range parameters {
 		parameter, ok := handlerTY.IsTypeOf(rawParameter, handlerTY.DataTypeEmail)
 		if !ok {
@@ -172,7 +246,6 @@ func (sc *SmtpClient) Post(parameters map[string]interface{}) error {
 		fromEmail := sc.cfg.FromEmail
 		toEmails := sc.cfg.ToEmails
 		subject := defaultSubject
-		body := ""
 
 		if emailData.From != "" {
 			fromEmail = emailData.From
@@ -185,17 +258,37 @@ func (sc *SmtpClient) Post(parameters map[string]interface{}) error {
 		if emailData.Subject != "" {
 			subject = emailData.Subject
 		}
-		if emailData.Body != "" {
-			body = emailData.Body
+		to := make([]string, 0)
+		for _, toEmail := range strings.Split(toEmails, ",") {
+			if toEmail = strings.TrimSpace(toEmail); toEmail != "" {
+				to = append(to, toEmail)
+			}
+		}
+
+		attachments, err := sc.loadAttachments(emailData.Attachments)
+		if err != nil {
+			sc.logger.Error("error on loading attachments", zap.String("id", sc.handlerCfg.ID), zap.String("name", name), zap.Error(err))
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+
+		msg := &Message{
+			From:        fromEmail,
+			To:          to,
+			Subject:     subject,
+			Text:        emailData.Body,
+			HTML:        emailData.HTML,
+			Attachments: attachments,
 		}
-		to := strings.Split(toEmails, ",")
 
 		start := time.Now()
-		err = sc.sendEmailSSL(fromEmail, to, subject, body)
+		err = sc.Send(msg)
 		if err != nil {
-			sc.logger.Error("error on email sent", zap.Error(err))
+			sc.logger.Error("error on email sent", zap.String("id", sc.handlerCfg.ID), zap.String("name", name), zap.Error(err))
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
 		}
 		sc.logger.Debug("email sent", zap.String("id", sc.handlerCfg.ID), zap.String("timeTaken", time.Since(start).String()))
 	}
-	return nil
+	return errors.Join(errs...)
 }
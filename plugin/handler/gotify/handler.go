@@ -0,0 +1,134 @@
+package gotify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/mycontroller-org/server/v2/pkg/types"
+	"github.com/mycontroller-org/server/v2/pkg/utils"
+	httpclient "github.com/mycontroller-org/server/v2/pkg/utils/http_client_json"
+	loggerUtils "github.com/mycontroller-org/server/v2/pkg/utils/logger"
+	notification "github.com/mycontroller-org/server/v2/plugin/handler/notification"
+	handlerTY "github.com/mycontroller-org/server/v2/plugin/handler/types"
+	"go.uber.org/zap"
+)
+
+const (
+	PluginGotify = "gotify"
+
+	loggerName = "handler_gotify"
+	apiMessage = "/message"
+)
+
+// gotify priorities, mapped from level 1 (min) to 5 (urgent)
+var priorities = map[int]int{1: 0, 2: 2, 3: 5, 4: 8, 5: 10}
+
+// Config for gotify
+type Config struct {
+	Server   string
+	Token    string `json:"-" yaml:"-"` // application token, ignore on logger
+	Insecure bool
+}
+
+// message to publish
+type message struct {
+	Title    string                 `json:"title,omitempty"`
+	Message  string                 `json:"message"`
+	Priority int                    `json:"priority"`
+	Extras   map[string]interface{} `json:"extras,omitempty"`
+}
+
+// GotifyClient struct
+type GotifyClient struct {
+	HandlerCfg *handlerTY.Config
+	Config     *Config
+	httpClient *httpclient.Client
+	logger     *zap.Logger
+}
+
+func New(ctx context.Context, handlerCfg *handlerTY.Config) (handlerTY.Plugin, error) {
+	logger, err := loggerUtils.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &Config{}
+	err = utils.MapToStruct(utils.TagNameNone, handlerCfg.Spec, config)
+	if err != nil {
+		return nil, err
+	}
+	if config.Server == "" {
+		return nil, errors.New("gotify server address can not be empty")
+	}
+
+	client := &GotifyClient{
+		HandlerCfg: handlerCfg,
+		Config:     config,
+		httpClient: httpclient.GetClient(config.Insecure, notification.DefaultTimeout),
+		logger:     logger.Named(loggerName),
+	}
+	client.logger.Debug("gotify client", zap.String("ID", handlerCfg.ID), zap.Any("config", config))
+	return client, nil
+}
+
+func (p *GotifyClient) Name() string {
+	return PluginGotify
+}
+
+// Start handler implementation
+func (c *GotifyClient) Start() error { return nil }
+
+// Close handler implementation
+func (c *GotifyClient) Close() error { return nil }
+
+// State implementation
+func (c *GotifyClient) State() *types.State {
+	if c.HandlerCfg != nil {
+		if c.HandlerCfg.State == nil {
+			c.HandlerCfg.State = &types.State{}
+		}
+		return c.HandlerCfg.State
+	}
+	return &types.State{}
+}
+
+// Post handler implementation
+func (c *GotifyClient) Post(parameters map[string]interface{}) error {
+	return notification.Post(c.logger, c.HandlerCfg.ID, handlerTY.DataTypeGotify, c.State(), parameters, c.send)
+}
+
+// send the message, the targets are not supported, gotify delivers to all the clients of the user
+func (c *GotifyClient) send(data *handlerTY.NotificationData) error {
+	msg := &message{
+		Title:    data.Title,
+		Message:  notification.TextWithTags(data.Message, data.Tags),
+		Priority: priorities[notification.PriorityLevel(data.Priority)],
+		Extras:   map[string]interface{}{},
+	}
+
+	if strings.EqualFold(data.Format, notification.FormatMarkdown) {
+		msg.Extras["client::display"] = map[string]interface{}{"contentType": "text/markdown"}
+	}
+
+	notificationExtras := map[string]interface{}{}
+	if data.ClickURL != "" {
+		notificationExtras["click"] = map[string]interface{}{"url": data.ClickURL}
+	}
+	if data.AttachmentURL != "" {
+		notificationExtras["bigImageUrl"] = data.AttachmentURL
+	}
+	if len(notificationExtras) > 0 {
+		msg.Extras["client::notification"] = notificationExtras
+	}
+	if len(msg.Extras) == 0 {
+		msg.Extras = nil
+	}
+
+	headers := map[string]string{"X-Gotify-Key": c.Config.Token}
+	url := fmt.Sprintf("%s%s", strings.TrimSuffix(c.Config.Server, "/"), apiMessage)
+	_, err := notification.Execute(c.httpClient, url, http.MethodPost, headers, nil, msg)
+	return err
+}
@@ -0,0 +1,240 @@
+package matrix
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/mycontroller-org/server/v2/pkg/json"
+	"github.com/mycontroller-org/server/v2/pkg/types"
+	"github.com/mycontroller-org/server/v2/pkg/utils"
+	httpclient "github.com/mycontroller-org/server/v2/pkg/utils/http_client_json"
+	loggerUtils "github.com/mycontroller-org/server/v2/pkg/utils/logger"
+	notification "github.com/mycontroller-org/server/v2/plugin/handler/notification"
+	handlerTY "github.com/mycontroller-org/server/v2/plugin/handler/types"
+	"go.uber.org/zap"
+)
+
+const (
+	PluginMatrix = "matrix"
+
+	loggerName        = "handler_matrix"
+	apiSendMessage    = "/_matrix/client/v3/rooms/%s/send/m.room.message/%s"
+	apiUpload         = "/_matrix/media/v3/upload"
+	maxAttachmentSize = 10 * 1024 * 1024 // 10 MB
+
+	formatHTML = "org.matrix.custom.html"
+)
+
+// message types
+const (
+	msgTypeText   = "m.text"
+	msgTypeNotice = "m.notice"
+	msgTypeImage  = "m.image"
+	msgTypeFile   = "m.file"
+)
+
+// Config for matrix
+type Config struct {
+	HomeServer  string
+	AccessToken string `json:"-" yaml:"-"` // ignore access token on logger
+	Rooms       []string
+	Insecure    bool
+}
+
+// message event content
+type message struct {
+	MsgType       string                 `json:"msgtype"`
+	Body          string                 `json:"body"`
+	Format        string                 `json:"format,omitempty"`
+	FormattedBody string                 `json:"formatted_body,omitempty"`
+	URL           string                 `json:"url,omitempty"`
+	Info          map[string]interface{} `json:"info,omitempty"`
+}
+
+// MatrixClient struct
+type MatrixClient struct {
+	HandlerCfg *handlerTY.Config
+	Config     *Config
+	httpClient *httpclient.Client
+	logger     *zap.Logger
+}
+
+func New(ctx context.Context, handlerCfg *handlerTY.Config) (handlerTY.Plugin, error) {
+	logger, err := loggerUtils.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &Config{}
+	err = utils.MapToStruct(utils.TagNameNone, handlerCfg.Spec, config)
+	if err != nil {
+		return nil, err
+	}
+	if config.HomeServer == "" || config.AccessToken == "" {
+		return nil, errors.New("matrix homeServer and accessToken can not be empty")
+	}
+	config.HomeServer = strings.TrimSuffix(config.HomeServer, "/")
+
+	client := &MatrixClient{
+		HandlerCfg: handlerCfg,
+		Config:     config,
+		httpClient: httpclient.GetClient(config.Insecure, notification.DefaultTimeout),
+		logger:     logger.Named(loggerName),
+	}
+	client.logger.Debug("matrix client", zap.String("ID", handlerCfg.ID), zap.Any("config", config))
+	return client, nil
+}
+
+func (p *MatrixClient) Name() string {
+	return PluginMatrix
+}
+
+// Start handler implementation
+func (c *MatrixClient) Start() error { return nil }
+
+// Close handler implementation
+func (c *MatrixClient) Close() error { return nil }
+
+// State implementation
+func (c *MatrixClient) State() *types.State {
+	if c.HandlerCfg != nil {
+		if c.HandlerCfg.State == nil {
+			c.HandlerCfg.State = &types.State{}
+		}
+		return c.HandlerCfg.State
+	}
+	return &types.State{}
+}
+
+// Post handler implementation
+func (c *MatrixClient) Post(parameters map[string]interface{}) error {
+	return notification.Post(c.logger, c.HandlerCfg.ID, handlerTY.DataTypeMatrix, c.State(), parameters, c.send)
+}
+
+func (c *MatrixClient) send(data *handlerTY.NotificationData) error {
+	rooms := c.Config.Rooms
+	if len(data.Targets) > 0 {
+		rooms = data.Targets
+	}
+	if len(rooms) == 0 {
+		return errors.New("room not supplied")
+	}
+
+	msg := toMessage(data)
+
+	// upload the attachment once and share it on all the rooms
+	var attachmentMsg *message
+	if data.AttachmentURL != "" {
+		_attachmentMsg, err := c.uploadAttachment(data)
+		if err != nil {
+			return err
+		}
+		attachmentMsg = _attachmentMsg
+	}
+
+	failed := make([]string, 0)
+	errs := make([]error, 0)
+	for _, room := range rooms {
+		err := c.sendMessage(room, msg)
+		if err == nil && attachmentMsg != nil {
+			err = c.sendMessage(room, attachmentMsg)
+		}
+		if err != nil {
+			failed = append(failed, room)
+			errs = append(errs, fmt.Errorf("room:%s, %w", room, err))
+		}
+	}
+	return notification.NewTargetsError(failed, errs)
+}
+
+// toMessage converts the notification data to message.
+// low priorities are sent as notice, urgent priority mentions the room
+func toMessage(data *handlerTY.NotificationData) *message {
+	level := notification.PriorityLevel(data.Priority)
+	msg := &message{MsgType: msgTypeText}
+	if level <= notification.PriorityLevel(notification.PriorityLow) {
+		msg.MsgType = msgTypeNotice
+	}
+
+	prefix := ""
+	if level == notification.PriorityLevel(notification.PriorityUrgent) {
+		prefix = "@room "
+	}
+
+	text := notification.TextWithTags(data.Message, data.Tags)
+	lines := make([]string, 0)
+	if data.Title != "" {
+		lines = append(lines, data.Title)
+	}
+	lines = append(lines, text)
+	if data.ClickURL != "" {
+		lines = append(lines, data.ClickURL)
+	}
+	msg.Body = prefix + strings.Join(lines, "\n")
+
+	if strings.EqualFold(data.Format, notification.FormatHTML) {
+		htmlLines := make([]string, 0)
+		if data.Title != "" {
+			htmlLines = append(htmlLines, fmt.Sprintf("<b>%s</b>", html.EscapeString(data.Title)))
+		}
+		htmlLines = append(htmlLines, notification.TextWithTags(data.Message, data.Tags))
+		if data.ClickURL != "" {
+			htmlLines = append(htmlLines, fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(data.ClickURL), html.EscapeString(data.ClickURL)))
+		}
+		msg.Format = formatHTML
+		msg.FormattedBody = prefix + strings.Join(htmlLines, "<br>")
+	}
+	return msg
+}
+
+// uploadAttachment uploads the attachment to the media repository and returns the message
+func (c *MatrixClient) uploadAttachment(data *handlerTY.NotificationData) (*message, error) {
+	attachment, err := notification.DownloadAttachment(c.httpClient, data.AttachmentURL, data.AttachmentName, maxAttachmentSize)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := map[string]string{
+		"Authorization": fmt.Sprintf("Bearer %s", c.Config.AccessToken),
+		"Content-Type":  attachment.ContentType,
+	}
+	queryParams := map[string]interface{}{"filename": attachment.Name}
+	response, err := c.httpClient.Execute(c.Config.HomeServer+apiUpload, http.MethodPost, headers, queryParams, string(attachment.Data), 0)
+	err = notification.CheckResponse(response, err)
+	if err != nil {
+		return nil, fmt.Errorf("error on uploading attachment: %w", err)
+	}
+
+	uploadResponse := map[string]interface{}{}
+	err = json.Unmarshal(response.Body, &uploadResponse)
+	if err != nil {
+		return nil, err
+	}
+	contentURI, ok := uploadResponse["content_uri"].(string)
+	if !ok || contentURI == "" {
+		return nil, fmt.Errorf("content_uri not available on the upload response: %s", response.StringBody())
+	}
+
+	msgType := msgTypeFile
+	if strings.HasPrefix(attachment.ContentType, "image/") {
+		msgType = msgTypeImage
+	}
+	return &message{
+		MsgType: msgType,
+		Body:    attachment.Name,
+		URL:     contentURI,
+		Info:    map[string]interface{}{"mimetype": attachment.ContentType, "size": len(attachment.Data)},
+	}, nil
+}
+
+func (c *MatrixClient) sendMessage(room string, msg *message) error {
+	api := fmt.Sprintf(apiSendMessage, url.PathEscape(room), utils.RandUUID())
+	headers := map[string]string{"Authorization": fmt.Sprintf("Bearer %s", c.Config.AccessToken)}
+	_, err := notification.Execute(c.httpClient, c.Config.HomeServer+api, http.MethodPut, headers, nil, msg)
+	return err
+}
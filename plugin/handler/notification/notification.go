@@ -0,0 +1,223 @@
+package notification
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mycontroller-org/server/v2/pkg/types"
+	"github.com/mycontroller-org/server/v2/pkg/utils"
+	httpclient "github.com/mycontroller-org/server/v2/pkg/utils/http_client_json"
+	handlerTY "github.com/mycontroller-org/server/v2/plugin/handler/types"
+	"go.uber.org/zap"
+)
+
+// common helpers of the push notification handlers
+
+const (
+	DefaultTimeout = time.Second * 15
+)
+
+// priorities
+const (
+	PriorityMin     = "min"
+	PriorityLow     = "low"
+	PriorityDefault = "default"
+	PriorityHigh    = "high"
+	PriorityUrgent  = "urgent"
+)
+
+// message formats
+const (
+	FormatText     = "text"
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+)
+
+var (
+	// ErrTransient failure, can be retried later
+	ErrTransient = errors.New("transient failure")
+
+	priorityLevels = map[string]int{
+		PriorityMin:     1,
+		PriorityLow:     2,
+		PriorityDefault: 3,
+		PriorityHigh:    4,
+		PriorityUrgent:  5,
+	}
+)
+
+// PriorityLevel returns the priority level from 1 (min) to 5 (urgent), 3 is the default
+func PriorityLevel(priority string) int {
+	priority = strings.ToLower(strings.TrimSpace(priority))
+	if level, found := priorityLevels[priority]; found {
+		return level
+	}
+	if level, err := strconv.Atoi(priority); err == nil && level >= 1 && level <= 5 {
+		return level
+	}
+	return priorityLevels[PriorityDefault]
+}
+
+// TextWithTags adds the tags at the end of the text, used on the services not supporting tags natively
+func TextWithTags(text string, tags []string) string {
+	if len(tags) == 0 {
+		return text
+	}
+	hashTags := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag != "" {
+			hashTags = append(hashTags, fmt.Sprintf("#%s", tag))
+		}
+	}
+	if len(hashTags) == 0 {
+		return text
+	}
+	return fmt.Sprintf("%s\n%s", text, strings.Join(hashTags, " "))
+}
+
+// Execute executes the json request and verifies the response.
+// network failures, too many requests and server errors are reported as ErrTransient
+func Execute(client *httpclient.Client, url, method string, headers map[string]string, queryParams map[string]interface{}, body interface{}) (*httpclient.ResponseConfig, error) {
+	response, err := client.ExecuteJson(url, method, headers, queryParams, body, 0)
+	return response, CheckResponse(response, err)
+}
+
+// CheckResponse verifies the response status code.
+// network failures, too many requests and server errors are reported as ErrTransient, other failures as permanent
+func CheckResponse(response *httpclient.ResponseConfig, err error) error {
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrTransient, err.Error())
+	}
+	if response.StatusCode == http.StatusTooManyRequests || response.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("%w: [statusCode: %d, body: %s]", ErrTransient, response.StatusCode, response.StringBody())
+	}
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("%w: failed with status code. [statusCode: %d, body: %s]", handlerTY.ErrPermanent, response.StatusCode, response.StringBody())
+	}
+	return nil
+}
+
+// TargetsError reports the targets failed on a send, the other targets received the message
+type TargetsError struct {
+	Failed []string
+	Err    error
+}
+
+func (e *TargetsError) Error() string {
+	return fmt.Sprintf("failed targets:%v, %s", e.Failed, e.Err.Error())
+}
+
+func (e *TargetsError) Unwrap() error {
+	return e.Err
+}
+
+// NewTargetsError returns nil, if there is no failed target
+func NewTargetsError(failed []string, errs []error) error {
+	if len(failed) == 0 {
+		return nil
+	}
+	// a transient error on any of the targets makes the send retryable
+	for _, err := range errs {
+		if errors.Is(err, ErrTransient) {
+			return &TargetsError{Failed: failed, Err: err}
+		}
+	}
+	return &TargetsError{Failed: failed, Err: errs[0]}
+}
+
+// Post sends the parameters of the data type via send func.
+// the sent parameters are removed from the parameters and the failed ones keep only the failed targets,
+// so that a retry of the parameters does not send again to the targets already received the message.
+// returns handlerTY.ErrReQueue, when a failure is transient and keeps the failure on the state,
+// other failures are reported as handlerTY.ErrPermanent
+func Post(logger *zap.Logger, handlerID, dataType string, state *types.State, parameters map[string]interface{}, send func(data *handlerTY.NotificationData) error) error {
+	errs := make([]error, 0)
+	for name, rawParameter := range parameters {
+		parameter, ok := handlerTY.IsTypeOf(rawParameter, dataType)
+		if !ok {
+			continue
+		}
+		logger.Debug("data", zap.Any("name", name), zap.Any("parameter", parameter))
+
+		data := &handlerTY.NotificationData{}
+		err := utils.MapToStruct(utils.TagNameNone, parameter, data)
+		if err != nil {
+			logger.Error("error on converting notification data", zap.Error(err), zap.String("name", name), zap.Any("parameter", parameter))
+			continue
+		}
+
+		start := time.Now()
+		err = send(data)
+		if err != nil {
+			logger.Error("error on sending notification", zap.String("id", handlerID), zap.String("name", name), zap.Error(err))
+			errs = append(errs, err)
+			targetsErr := &TargetsError{}
+			if errors.As(err, &targetsErr) {
+				setTargets(parameter, targetsErr.Failed)
+			}
+			continue
+		}
+		delete(parameters, name)
+		logger.Debug("notification sent", zap.String("id", handlerID), zap.String("name", name), zap.String("timeTaken", time.Since(start).String()))
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	for _, err := range errs {
+		if errors.Is(err, ErrTransient) {
+			state.Status = types.StatusError
+			state.Message = fmt.Sprintf("retrying, %s", err.Error())
+			state.Since = time.Now()
+			return handlerTY.ErrReQueue
+		}
+	}
+	if errors.Is(errs[0], handlerTY.ErrPermanent) {
+		return errs[0]
+	}
+	return fmt.Errorf("%w: %w", handlerTY.ErrPermanent, errs[0])
+}
+
+// setTargets updates the targets on the parameter, the keys are matched case insensitively on the data conversion
+func setTargets(parameter map[string]interface{}, targets []string) {
+	for key := range parameter {
+		if strings.EqualFold(key, "targets") {
+			delete(parameter, key)
+		}
+	}
+	parameter["targets"] = targets
+}
+
+// Attachment details
+type Attachment struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// DownloadAttachment downloads the attachment from the url, used on the services accepting only the file
+func DownloadAttachment(client *httpclient.Client, url, name string, maxSize int) (*Attachment, error) {
+	response, err := client.Execute(url, http.MethodGet, nil, nil, "", 0)
+	err = CheckResponse(response, err)
+	if err != nil {
+		return nil, fmt.Errorf("error on downloading attachment: %w", err)
+	}
+	if maxSize > 0 && len(response.Body) > maxSize {
+		return nil, fmt.Errorf("attachment size exceeds the limit. [size: %d, limit: %d]", len(response.Body), maxSize)
+	}
+
+	if name == "" {
+		name = path.Base(strings.SplitN(url, "?", 2)[0])
+	}
+	contentType := response.Headers["Content-Type"]
+	if contentType == "" {
+		contentType = http.DetectContentType(response.Body)
+	}
+	return &Attachment{Name: name, ContentType: contentType, Data: response.Body}, nil
+}
@@ -0,0 +1,70 @@
+package notification
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/mycontroller-org/server/v2/pkg/types"
+	handlerTY "github.com/mycontroller-org/server/v2/plugin/handler/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestPostKeepsOnlyFailedTargets(t *testing.T) {
+	parameters := map[string]interface{}{
+		"sent":    map[string]interface{}{types.KeyType: "ntfy", "Message": "hello"},
+		"partial": map[string]interface{}{types.KeyType: "ntfy", "Message": "hello", "Targets": []string{"a", "b", "c"}},
+		"other":   "not a notification",
+	}
+
+	received := map[string][]string{}
+	send := func(data *handlerTY.NotificationData) error {
+		if len(data.Targets) == 0 {
+			return nil
+		}
+		failed := make([]string, 0)
+		errs := make([]error, 0)
+		for _, target := range data.Targets {
+			received[target] = append(received[target], data.Message)
+			if target != "a" {
+				failed = append(failed, target)
+				errs = append(errs, fmt.Errorf("target:%s, %w", target, ErrTransient))
+			}
+		}
+		return NewTargetsError(failed, errs)
+	}
+
+	state := &types.State{}
+	err := Post(zap.NewNop(), "test", "ntfy", state, parameters, send)
+	require.ErrorIs(t, err, handlerTY.ErrReQueue)
+	assert.Equal(t, types.StatusError, state.Status)
+
+	// sent parameter removed, the failed one keeps only the failed targets
+	assert.NotContains(t, parameters, "sent")
+	assert.Contains(t, parameters, "other")
+	partial := parameters["partial"].(map[string]interface{})
+	assert.NotContains(t, partial, "Targets")
+	assert.Equal(t, []string{"b", "c"}, partial["targets"])
+
+	// retry sends only to the failed targets
+	err = Post(zap.NewNop(), "test", "ntfy", state, parameters, send)
+	require.ErrorIs(t, err, handlerTY.ErrReQueue)
+	assert.Equal(t, map[string][]string{"a": {"hello"}, "b": {"hello", "hello"}, "c": {"hello", "hello"}}, received)
+}
+
+func TestNewTargetsError(t *testing.T) {
+	assert.NoError(t, NewTargetsError(nil, nil))
+
+	permanent := errors.New("bad request")
+	err := NewTargetsError([]string{"a", "b"}, []error{permanent, fmt.Errorf("b, %w", ErrTransient)})
+	targetsErr := &TargetsError{}
+	require.ErrorAs(t, err, &targetsErr)
+	assert.Equal(t, []string{"a", "b"}, targetsErr.Failed)
+	assert.ErrorIs(t, err, ErrTransient)
+
+	err = NewTargetsError([]string{"a"}, []error{permanent})
+	assert.ErrorIs(t, err, permanent)
+	assert.NotErrorIs(t, err, ErrTransient)
+}
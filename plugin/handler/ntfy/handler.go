@@ -0,0 +1,146 @@
+package ntfy
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/mycontroller-org/server/v2/pkg/types"
+	"github.com/mycontroller-org/server/v2/pkg/utils"
+	httpclient "github.com/mycontroller-org/server/v2/pkg/utils/http_client_json"
+	loggerUtils "github.com/mycontroller-org/server/v2/pkg/utils/logger"
+	notification "github.com/mycontroller-org/server/v2/plugin/handler/notification"
+	handlerTY "github.com/mycontroller-org/server/v2/plugin/handler/types"
+	"go.uber.org/zap"
+)
+
+const (
+	PluginNtfy = "ntfy"
+
+	loggerName    = "handler_ntfy"
+	defaultServer = "https://ntfy.sh"
+)
+
+// Config for ntfy
+type Config struct {
+	Server   string
+	Topics   []string
+	Token    string `json:"-" yaml:"-"` // access token, ignore on logger
+	Username string
+	Password string `json:"-" yaml:"-"` // ignore password on logger
+	Insecure bool
+}
+
+// message to publish, json format
+type message struct {
+	Topic    string   `json:"topic"`
+	Message  string   `json:"message"`
+	Title    string   `json:"title,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Priority int      `json:"priority"`
+	Click    string   `json:"click,omitempty"`
+	Attach   string   `json:"attach,omitempty"`
+	Filename string   `json:"filename,omitempty"`
+	Markdown bool     `json:"markdown,omitempty"`
+}
+
+// NtfyClient struct
+type NtfyClient struct {
+	HandlerCfg *handlerTY.Config
+	Config     *Config
+	httpClient *httpclient.Client
+	logger     *zap.Logger
+}
+
+func New(ctx context.Context, handlerCfg *handlerTY.Config) (handlerTY.Plugin, error) {
+	logger, err := loggerUtils.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &Config{}
+	err = utils.MapToStruct(utils.TagNameNone, handlerCfg.Spec, config)
+	if err != nil {
+		return nil, err
+	}
+	if config.Server == "" {
+		config.Server = defaultServer
+	}
+
+	client := &NtfyClient{
+		HandlerCfg: handlerCfg,
+		Config:     config,
+		httpClient: httpclient.GetClient(config.Insecure, notification.DefaultTimeout),
+		logger:     logger.Named(loggerName),
+	}
+	client.logger.Debug("ntfy client", zap.String("ID", handlerCfg.ID), zap.Any("config", config))
+	return client, nil
+}
+
+func (p *NtfyClient) Name() string {
+	return PluginNtfy
+}
+
+// Start handler implementation
+func (c *NtfyClient) Start() error { return nil }
+
+// Close handler implementation
+func (c *NtfyClient) Close() error { return nil }
+
+// State implementation
+func (c *NtfyClient) State() *types.State {
+	if c.HandlerCfg != nil {
+		if c.HandlerCfg.State == nil {
+			c.HandlerCfg.State = &types.State{}
+		}
+		return c.HandlerCfg.State
+	}
+	return &types.State{}
+}
+
+// Post handler implementation
+func (c *NtfyClient) Post(parameters map[string]interface{}) error {
+	return notification.Post(c.logger, c.HandlerCfg.ID, handlerTY.DataTypeNtfy, c.State(), parameters, c.send)
+}
+
+func (c *NtfyClient) send(data *handlerTY.NotificationData) error {
+	topics := c.Config.Topics
+	if len(data.Targets) > 0 {
+		topics = data.Targets
+	}
+	if len(topics) == 0 {
+		return errors.New("topic not supplied")
+	}
+
+	headers := map[string]string{}
+	if c.Config.Token != "" {
+		headers["Authorization"] = fmt.Sprintf("Bearer %s", c.Config.Token)
+	} else if c.Config.Username != "" {
+		headers["Authorization"] = fmt.Sprintf("Basic %s", base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", c.Config.Username, c.Config.Password))))
+	}
+
+	failed := make([]string, 0)
+	errs := make([]error, 0)
+	for _, topic := range topics {
+		msg := &message{
+			Topic:    topic,
+			Message:  data.Message,
+			Title:    data.Title,
+			Tags:     data.Tags,
+			Priority: notification.PriorityLevel(data.Priority),
+			Click:    data.ClickURL,
+			Attach:   data.AttachmentURL,
+			Filename: data.AttachmentName,
+			Markdown: strings.EqualFold(data.Format, notification.FormatMarkdown),
+		}
+		_, err := notification.Execute(c.httpClient, c.Config.Server, http.MethodPost, headers, nil, msg)
+		if err != nil {
+			failed = append(failed, topic)
+			errs = append(errs, fmt.Errorf("topic:%s, %w", topic, err))
+		}
+	}
+	return notification.NewTargetsError(failed, errs)
+}
@@ -2,14 +2,24 @@ package handler
 
 import (
 	emailPlugin "github.com/mycontroller-org/server/v2/plugin/handler/email"
+	gotify "github.com/mycontroller-org/server/v2/plugin/handler/gotify"
+	matrix "github.com/mycontroller-org/server/v2/plugin/handler/matrix"
+	ntfy "github.com/mycontroller-org/server/v2/plugin/handler/ntfy"
+	pushover "github.com/mycontroller-org/server/v2/plugin/handler/pushover"
 	resource "github.com/mycontroller-org/server/v2/plugin/handler/resource"
+	slack "github.com/mycontroller-org/server/v2/plugin/handler/slack"
 	telegram "github.com/mycontroller-org/server/v2/plugin/handler/telegram"
 	webhook "github.com/mycontroller-org/server/v2/plugin/handler/webhook"
 )
 
 func init() {
 	Register(emailPlugin.PluginEmail, emailPlugin.New)
+	Register(gotify.PluginGotify, gotify.New)
+	Register(matrix.PluginMatrix, matrix.New)
+	Register(ntfy.PluginNtfy, ntfy.New)
+	Register(pushover.PluginPushover, pushover.New)
 	Register(resource.PluginResourceHandler, resource.NewResourcePlugin)
+	Register(slack.PluginSlack, slack.New)
 	Register(telegram.PluginTelegram, telegram.New)
 	Register(webhook.PluginWebhook, webhook.New)
 }
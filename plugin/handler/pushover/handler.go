@@ -0,0 +1,156 @@
+package pushover
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mycontroller-org/server/v2/pkg/types"
+	"github.com/mycontroller-org/server/v2/pkg/utils"
+	httpclient "github.com/mycontroller-org/server/v2/pkg/utils/http_client_json"
+	loggerUtils "github.com/mycontroller-org/server/v2/pkg/utils/logger"
+	notification "github.com/mycontroller-org/server/v2/plugin/handler/notification"
+	handlerTY "github.com/mycontroller-org/server/v2/plugin/handler/types"
+	"go.uber.org/zap"
+)
+
+const (
+	PluginPushover = "pushover"
+
+	loggerName        = "handler_pushover"
+	serverURL         = "https://api.pushover.net/1/messages.json"
+	maxAttachmentSize = 5 * 1024 * 1024 // 5 MB
+	priorityEmergency = 2
+
+	defaultRetry  = time.Minute
+	defaultExpire = time.Hour
+)
+
+// pushover priorities, mapped from level 1 (min) to 5 (urgent)
+var priorities = map[int]int{1: -2, 2: -1, 3: 0, 4: 1, 5: priorityEmergency}
+
+// Config for pushover
+type Config struct {
+	Token    string `json:"-" yaml:"-"` // application token, ignore on logger
+	UserKey  string // user or group key
+	Devices  []string
+	Retry    string // emergency priority, retry interval till acknowledged
+	Expire   string // emergency priority, stops the retry after
+	Insecure bool
+}
+
+// message to publish
+type message struct {
+	Token            string `json:"token"`
+	User             string `json:"user"`
+	Message          string `json:"message"`
+	Title            string `json:"title,omitempty"`
+	Priority         int    `json:"priority"`
+	Retry            int    `json:"retry,omitempty"`
+	Expire           int    `json:"expire,omitempty"`
+	URL              string `json:"url,omitempty"`
+	HTML             int    `json:"html,omitempty"`
+	Device           string `json:"device,omitempty"`
+	AttachmentBase64 string `json:"attachment_base64,omitempty"`
+	AttachmentType   string `json:"attachment_type,omitempty"`
+}
+
+// PushoverClient struct
+type PushoverClient struct {
+	HandlerCfg *handlerTY.Config
+	Config     *Config
+	httpClient *httpclient.Client
+	logger     *zap.Logger
+}
+
+func New(ctx context.Context, handlerCfg *handlerTY.Config) (handlerTY.Plugin, error) {
+	logger, err := loggerUtils.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &Config{}
+	err = utils.MapToStruct(utils.TagNameNone, handlerCfg.Spec, config)
+	if err != nil {
+		return nil, err
+	}
+	if config.Token == "" || config.UserKey == "" {
+		return nil, errors.New("pushover token and userKey can not be empty")
+	}
+
+	client := &PushoverClient{
+		HandlerCfg: handlerCfg,
+		Config:     config,
+		httpClient: httpclient.GetClient(config.Insecure, notification.DefaultTimeout),
+		logger:     logger.Named(loggerName),
+	}
+	client.logger.Debug("pushover client", zap.String("ID", handlerCfg.ID), zap.Any("config", config))
+	return client, nil
+}
+
+func (p *PushoverClient) Name() string {
+	return PluginPushover
+}
+
+// Start handler implementation
+func (c *PushoverClient) Start() error { return nil }
+
+// Close handler implementation
+func (c *PushoverClient) Close() error { return nil }
+
+// State implementation
+func (c *PushoverClient) State() *types.State {
+	if c.HandlerCfg != nil {
+		if c.HandlerCfg.State == nil {
+			c.HandlerCfg.State = &types.State{}
+		}
+		return c.HandlerCfg.State
+	}
+	return &types.State{}
+}
+
+// Post handler implementation
+func (c *PushoverClient) Post(parameters map[string]interface{}) error {
+	return notification.Post(c.logger, c.HandlerCfg.ID, handlerTY.DataTypePushover, c.State(), parameters, c.send)
+}
+
+func (c *PushoverClient) send(data *handlerTY.NotificationData) error {
+	devices := c.Config.Devices
+	if len(data.Targets) > 0 {
+		devices = data.Targets
+	}
+
+	msg := &message{
+		Token:    c.Config.Token,
+		User:     c.Config.UserKey,
+		Message:  notification.TextWithTags(data.Message, data.Tags),
+		Title:    data.Title,
+		Priority: priorities[notification.PriorityLevel(data.Priority)],
+		URL:      data.ClickURL,
+		Device:   strings.Join(devices, ","),
+	}
+	if strings.EqualFold(data.Format, notification.FormatHTML) {
+		msg.HTML = 1
+	}
+	// emergency priority needs retry and expire
+	if msg.Priority == priorityEmergency {
+		msg.Retry = int(utils.ToDuration(c.Config.Retry, defaultRetry).Seconds())
+		msg.Expire = int(utils.ToDuration(c.Config.Expire, defaultExpire).Seconds())
+	}
+
+	// pushover accepts only the attachment file
+	if data.AttachmentURL != "" {
+		attachment, err := notification.DownloadAttachment(c.httpClient, data.AttachmentURL, data.AttachmentName, maxAttachmentSize)
+		if err != nil {
+			return err
+		}
+		msg.AttachmentBase64 = base64.StdEncoding.EncodeToString(attachment.Data)
+		msg.AttachmentType = attachment.ContentType
+	}
+
+	_, err := notification.Execute(c.httpClient, serverURL, http.MethodPost, nil, nil, msg)
+	return err
+}
@@ -0,0 +1,157 @@
+package slack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/mycontroller-org/server/v2/pkg/types"
+	"github.com/mycontroller-org/server/v2/pkg/utils"
+	httpclient "github.com/mycontroller-org/server/v2/pkg/utils/http_client_json"
+	loggerUtils "github.com/mycontroller-org/server/v2/pkg/utils/logger"
+	notification "github.com/mycontroller-org/server/v2/plugin/handler/notification"
+	handlerTY "github.com/mycontroller-org/server/v2/plugin/handler/types"
+	"go.uber.org/zap"
+)
+
+// slack and mattermost incoming webhooks
+
+const (
+	PluginSlack = "slack"
+
+	loggerName = "handler_slack"
+)
+
+// attachment colors, mapped from priority level 1 (min) to 5 (urgent)
+var colors = map[int]string{1: "#9e9e9e", 2: "#2196f3", 3: "#4caf50", 4: "#ff9800", 5: "#f44336"}
+
+// Config for slack and mattermost
+type Config struct {
+	WebhookURL string `json:"-" yaml:"-"` // includes the secret, ignore on logger
+	Channel    string
+	Username   string
+	IconURL    string
+	IconEmoji  string
+	Insecure   bool
+}
+
+// message to publish
+type message struct {
+	Channel     string       `json:"channel,omitempty"`
+	Username    string       `json:"username,omitempty"`
+	IconURL     string       `json:"icon_url,omitempty"`
+	IconEmoji   string       `json:"icon_emoji,omitempty"`
+	Attachments []attachment `json:"attachments"`
+}
+
+type attachment struct {
+	Fallback  string   `json:"fallback"`
+	Color     string   `json:"color"`
+	Title     string   `json:"title,omitempty"`
+	TitleLink string   `json:"title_link,omitempty"`
+	Text      string   `json:"text"`
+	ImageURL  string   `json:"image_url,omitempty"`
+	Footer    string   `json:"footer,omitempty"`
+	MrkdwnIn  []string `json:"mrkdwn_in,omitempty"`
+}
+
+// SlackClient struct
+type SlackClient struct {
+	HandlerCfg *handlerTY.Config
+	Config     *Config
+	httpClient *httpclient.Client
+	logger     *zap.Logger
+}
+
+func New(ctx context.Context, handlerCfg *handlerTY.Config) (handlerTY.Plugin, error) {
+	logger, err := loggerUtils.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &Config{}
+	err = utils.MapToStruct(utils.TagNameNone, handlerCfg.Spec, config)
+	if err != nil {
+		return nil, err
+	}
+	if config.WebhookURL == "" {
+		return nil, errors.New("webhookUrl can not be empty")
+	}
+
+	client := &SlackClient{
+		HandlerCfg: handlerCfg,
+		Config:     config,
+		httpClient: httpclient.GetClient(config.Insecure, notification.DefaultTimeout),
+		logger:     logger.Named(loggerName),
+	}
+	client.logger.Debug("slack client", zap.String("ID", handlerCfg.ID), zap.Any("config", config))
+	return client, nil
+}
+
+func (p *SlackClient) Name() string {
+	return PluginSlack
+}
+
+// Start handler implementation
+func (c *SlackClient) Start() error { return nil }
+
+// Close handler implementation
+func (c *SlackClient) Close() error { return nil }
+
+// State implementation
+func (c *SlackClient) State() *types.State {
+	if c.HandlerCfg != nil {
+		if c.HandlerCfg.State == nil {
+			c.HandlerCfg.State = &types.State{}
+		}
+		return c.HandlerCfg.State
+	}
+	return &types.State{}
+}
+
+// Post handler implementation
+func (c *SlackClient) Post(parameters map[string]interface{}) error {
+	return notification.Post(c.logger, c.HandlerCfg.ID, handlerTY.DataTypeSlack, c.State(), parameters, c.send)
+}
+
+func (c *SlackClient) send(data *handlerTY.NotificationData) error {
+	channels := []string{c.Config.Channel}
+	if len(data.Targets) > 0 {
+		channels = data.Targets
+	}
+
+	_attachment := attachment{
+		Fallback:  strings.TrimSpace(fmt.Sprintf("%s %s", data.Title, data.Message)),
+		Color:     colors[notification.PriorityLevel(data.Priority)],
+		Title:     data.Title,
+		TitleLink: data.ClickURL,
+		Text:      data.Message,
+		ImageURL:  data.AttachmentURL,
+	}
+	if len(data.Tags) > 0 {
+		_attachment.Footer = strings.TrimSpace(notification.TextWithTags("", data.Tags))
+	}
+	if strings.EqualFold(data.Format, notification.FormatMarkdown) {
+		_attachment.MrkdwnIn = []string{"text"}
+	}
+
+	failed := make([]string, 0)
+	errs := make([]error, 0)
+	for _, channel := range channels {
+		msg := &message{
+			Channel:     channel,
+			Username:    c.Config.Username,
+			IconURL:     c.Config.IconURL,
+			IconEmoji:   c.Config.IconEmoji,
+			Attachments: []attachment{_attachment},
+		}
+		_, err := notification.Execute(c.httpClient, c.Config.WebhookURL, http.MethodPost, nil, nil, msg)
+		if err != nil {
+			failed = append(failed, channel)
+			errs = append(errs, fmt.Errorf("channel:%s, %w", channel, err))
+		}
+	}
+	return notification.NewTargetsError(failed, errs)
+}
@@ -0,0 +1,460 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mycontroller-org/server/v2/pkg/json"
+	"github.com/mycontroller-org/server/v2/pkg/types"
+	rsTY "github.com/mycontroller-org/server/v2/pkg/types/resource_service"
+	"github.com/mycontroller-org/server/v2/pkg/utils"
+	busUtils "github.com/mycontroller-org/server/v2/pkg/utils/bus_utils"
+	"github.com/mycontroller-org/server/v2/pkg/utils/bus_utils/query"
+	httpClient "github.com/mycontroller-org/server/v2/pkg/utils/http_client_json"
+	quickIdUtils "github.com/mycontroller-org/server/v2/pkg/utils/quick_id"
+	handlerTY "github.com/mycontroller-org/server/v2/plugin/handler/types"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultPollTimeout = time.Second * 30
+	pollRetryDelay     = time.Second * 5
+	queryTimeout       = time.Second * 5
+	callbackExpiry     = time.Hour * 24
+	callbackIDLength   = 16
+)
+
+const helpText = `available commands:
+/status - status of the configured resources
+/get <quickId> - details of a resource, ex: /get field:mysensors.1.2.V_STATUS
+/set <quickId> <value> - executes the value on a resource, ex: /set field:mysensors.1.2.V_STATUS 1
+/task <id> <enable|disable|reload|trigger> - executes the action on a task, trigger runs it now
+/schedule <id> <enable|disable|reload|trigger> - executes the action on a schedule, trigger runs it now`
+
+// callbackAction of an inline keyboard button
+type callbackAction struct {
+	Text      string
+	QuickID   string
+	Payload   string
+	CreatedOn time.Time
+}
+
+// callbackStore keeps the inline keyboard button actions in memory,
+// buttons sent before a restart are reported as expired
+type callbackStore struct {
+	mutex   sync.Mutex
+	actions map[string]callbackAction
+}
+
+func (s *callbackStore) add(button handlerTY.TelegramButton) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// remove expired actions
+	for id, action := range s.actions {
+		if time.Since(action.CreatedOn) > callbackExpiry {
+			delete(s.actions, id)
+		}
+	}
+
+	id := utils.RandIDWithLength(callbackIDLength)
+	s.actions[id] = callbackAction{Text: button.Text, QuickID: button.QuickID, Payload: button.Payload, CreatedOn: time.Now()}
+	return id
+}
+
+func (s *callbackStore) get(id string) (*callbackAction, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	action, found := s.actions[id]
+	if !found || time.Since(action.CreatedOn) > callbackExpiry {
+		return nil, false
+	}
+	return &action, true
+}
+
+// isCommandAllowed verifies the command permission of the chat
+func (cc *ChatConfig) isCommandAllowed(command string) bool {
+	if command == CommandStart || command == CommandHelp || len(cc.Commands) == 0 {
+		return true
+	}
+	for _, allowed := range cc.Commands {
+		if strings.EqualFold(strings.TrimPrefix(allowed, "/"), command) {
+			return true
+		}
+	}
+	return false
+}
+
+// isQuickIDAllowed verifies the resource permission of the chat
+func (cc *ChatConfig) isQuickIDAllowed(quickID string) bool {
+	if len(cc.QuickIDs) == 0 {
+		return true
+	}
+	for _, pattern := range cc.QuickIDs {
+		if matched, err := path.Match(pattern, quickID); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// getChat returns the chat config, nil if the chat is not allowed
+func (c *TelegramClient) getChat(chatID string) *ChatConfig {
+	for index := range c.Config.Bot.Chats {
+		if c.Config.Bot.Chats[index].ChatID == chatID {
+			return &c.Config.Bot.Chats[index]
+		}
+	}
+	return nil
+}
+
+// pollUpdates receives the messages and inline keyboard button presses, till the context is cancelled
+func (c *TelegramClient) pollUpdates(ctx context.Context) {
+	pollTimeout := utils.ToDuration(c.Config.Bot.PollTimeout, defaultPollTimeout)
+	pollClient := httpClient.GetClient(false, pollTimeout+timeout)
+	offset := int64(0)
+
+	c.logger.Info("telegram bot started", zap.String("handlerID", c.handlerCfg.ID), zap.String("pollTimeout", pollTimeout.String()))
+	for {
+		if ctx.Err() != nil {
+			c.logger.Info("telegram bot stopped", zap.String("handlerID", c.handlerCfg.ID))
+			return
+		}
+
+		updates, err := c.GetUpdates(pollClient, offset, pollTimeout)
+		if err != nil {
+			c.logger.Error("error on telegram getUpdates", zap.String("handlerID", c.handlerCfg.ID), zap.Error(err))
+			select {
+			case <-ctx.Done():
+			case <-time.After(pollRetryDelay):
+			}
+			continue
+		}
+
+		// handler closed while waiting for the updates, leave them to the next instance
+		if ctx.Err() != nil {
+			continue
+		}
+
+		for index := range updates {
+			update := updates[index]
+			if update.UpdateID >= offset {
+				offset = update.UpdateID + 1
+			}
+			c.onUpdate(&update)
+		}
+	}
+}
+
+func (c *TelegramClient) onUpdate(update *Update) {
+	if update.CallbackQuery != nil {
+		c.onCallbackQuery(update.CallbackQuery)
+		return
+	}
+
+	msg := update.Message
+	if msg == nil || !strings.HasPrefix(msg.Text, "/") {
+		return
+	}
+	chatID := strconv.FormatInt(msg.Chat.ID, 10)
+	c.logger.Debug("received a command", zap.String("chatID", chatID), zap.String("text", msg.Text))
+
+	reply := c.executeCommand(chatID, msg.Text)
+	err := c.SendMessage(&Message{ChatID: chatID, Text: reply, ReplyToMessageID: int(msg.MessageID)})
+	if err != nil {
+		c.logger.Error("error on sending command reply", zap.String("chatID", chatID), zap.Error(err))
+	}
+}
+
+func (c *TelegramClient) onCallbackQuery(callback *CallbackQuery) {
+	answer := &CallbackAnswer{CallbackQueryID: callback.ID}
+	defer func() {
+		err := c.AnswerCallbackQuery(answer)
+		if err != nil {
+			c.logger.Error("error on telegram answerCallbackQuery", zap.Error(err))
+		}
+	}()
+
+	if callback.Message == nil {
+		answer.Text = "message not available"
+		return
+	}
+	chatID := strconv.FormatInt(callback.Message.Chat.ID, 10)
+
+	chat := c.getChat(chatID)
+	if chat == nil || !chat.isCommandAllowed(CommandButton) {
+		c.logger.Warn("button press rejected", zap.String("chatID", chatID))
+		answer.Text = "not allowed on this chat"
+		answer.ShowAlert = true
+		return
+	}
+
+	action, found := c.callbacks.get(callback.Data)
+	if !found {
+		answer.Text = "button expired"
+		answer.ShowAlert = true
+		return
+	}
+
+	if !chat.isQuickIDAllowed(action.QuickID) {
+		c.logger.Warn("button press rejected", zap.String("chatID", chatID), zap.String("quickID", action.QuickID))
+		answer.Text = fmt.Sprintf("'%s' is not allowed on this chat", action.QuickID)
+		answer.ShowAlert = true
+		return
+	}
+
+	err := c.executeAction(action.QuickID, action.Payload)
+	if err != nil {
+		answer.Text = err.Error()
+		answer.ShowAlert = true
+		return
+	}
+	answer.Text = fmt.Sprintf("%s: request sent", action.Text)
+}
+
+// executeCommand executes the command and returns the reply text
+func (c *TelegramClient) executeCommand(chatID, text string) string {
+	chat := c.getChat(chatID)
+	if chat == nil {
+		c.logger.Warn("command rejected, chat not allowed", zap.String("chatID", chatID), zap.String("text", text))
+		return fmt.Sprintf("chat '%s' is not allowed to run commands", chatID)
+	}
+
+	command, args := parseCommand(text)
+	if !chat.isCommandAllowed(command) {
+		return fmt.Sprintf("command '/%s' is not allowed on this chat", command)
+	}
+
+	switch command {
+	case CommandStart, CommandHelp:
+		return helpText
+
+	case CommandStatus:
+		return c.getStatus(chat)
+
+	case CommandGet:
+		if len(args) != 1 {
+			return "usage: /get <quickId>"
+		}
+		if !chat.isQuickIDAllowed(args[0]) {
+			return fmt.Sprintf("'%s' is not allowed on this chat", args[0])
+		}
+		return c.getResourceText(args[0])
+
+	case CommandSet:
+		if len(args) < 2 {
+			return "usage: /set <quickId> <value>"
+		}
+		return c.executeCommandAction(chat, args[0], strings.Join(args[1:], " "))
+
+	case CommandTask, CommandSchedule:
+		if len(args) != 2 {
+			return fmt.Sprintf("usage: /%s <id> <enable|disable|reload|trigger>", command)
+		}
+		resourceType := quickIdUtils.QuickIdTask
+		if command == CommandSchedule {
+			resourceType = quickIdUtils.QuickIdSchedule
+		}
+		action := types.GetAction(args[1])
+		if action != types.ActionEnable && action != types.ActionDisable && action != types.ActionReload && action != types.ActionTrigger {
+			return fmt.Sprintf("unknown action '%s'. usage: /%s <id> <enable|disable|reload|trigger>", args[1], command)
+		}
+		return c.executeCommandAction(chat, fmt.Sprintf("%s:%s", resourceType, args[0]), action)
+
+	default:
+		return fmt.Sprintf("unknown command '/%s'\n%s", command, helpText)
+	}
+}
+
+func (c *TelegramClient) executeCommandAction(chat *ChatConfig, quickID, payload string) string {
+	if !chat.isQuickIDAllowed(quickID) {
+		return fmt.Sprintf("'%s' is not allowed on this chat", quickID)
+	}
+	err := c.executeAction(quickID, payload)
+	if err != nil {
+		return err.Error()
+	}
+	return fmt.Sprintf("request sent. %s => %s", quickID, payload)
+}
+
+// executeAction posts the action to the resource service, executed via the action api
+func (c *TelegramClient) executeAction(quickID, payload string) error {
+	if !quickIdUtils.IsValidQuickID(quickID) {
+		return fmt.Errorf("invalid quick id '%s'", quickID)
+	}
+	typeID := strings.SplitN(quickID, ":", 2)
+	rsData := &handlerTY.ResourceData{
+		ResourceType: typeID[0],
+		QuickID:      typeID[1],
+		Payload:      payload,
+	}
+	c.logger.Debug("executing an action", zap.Any("data", rsData))
+	busUtils.PostToResourceService(c.logger, c.bus, c.handlerCfg.ID, rsData, rsTY.TypeResourceAction, rsTY.CommandSet, "")
+	return nil
+}
+
+// getStatus returns the details of the status quick ids
+func (c *TelegramClient) getStatus(chat *ChatConfig) string {
+	lines := make([]string, 0)
+	for _, quickID := range c.Config.Bot.StatusQuickIDs {
+		if !chat.isQuickIDAllowed(quickID) {
+			continue
+		}
+		lines = append(lines, c.getResourceText(quickID))
+	}
+	if len(lines) == 0 {
+		return "no status resources configured for this chat"
+	}
+	return strings.Join(lines, "\n\n")
+}
+
+// getResourceText returns the details of the resource as text
+func (c *TelegramClient) getResourceText(quickID string) string {
+	if !quickIdUtils.IsValidQuickID(quickID) {
+		return fmt.Sprintf("invalid quick id '%s'", quickID)
+	}
+
+	var resource map[string]interface{}
+	err := query.QueryResource(c.logger, c.bus, quickID, rsTY.TypeQuickID, rsTY.CommandGet, nil, &resource, queryTimeout)
+	if err != nil {
+		c.logger.Error("error on getting a resource", zap.String("quickID", quickID), zap.Error(err))
+		return fmt.Sprintf("%s: %s", quickID, err.Error())
+	}
+	if resource == nil {
+		return fmt.Sprintf("%s: resource not available", quickID)
+	}
+	return formatResource(quickID, resource)
+}
+
+// toReplyMarkup converts the buttons to inline keyboard
+func (c *TelegramClient) toReplyMarkup(buttons [][]handlerTY.TelegramButton) *InlineKeyboardMarkup {
+	keyboard := make([][]InlineKeyboardButton, 0)
+	for _, row := range buttons {
+		keyboardRow := make([]InlineKeyboardButton, 0)
+		for _, button := range row {
+			if button.Text == "" {
+				continue
+			}
+			if button.URL != "" {
+				keyboardRow = append(keyboardRow, InlineKeyboardButton{Text: button.Text, URL: button.URL})
+				continue
+			}
+			if button.QuickID == "" {
+				continue
+			}
+			// button presses are received only when the bot is enabled
+			if !c.Config.Bot.Enabled {
+				c.logger.Warn("bot not enabled, skipping a button", zap.String("handlerID", c.handlerCfg.ID), zap.String("button", button.Text))
+				continue
+			}
+			keyboardRow = append(keyboardRow, InlineKeyboardButton{Text: button.Text, CallbackData: c.callbacks.add(button)})
+		}
+		if len(keyboardRow) > 0 {
+			keyboard = append(keyboard, keyboardRow)
+		}
+	}
+	if len(keyboard) == 0 {
+		return nil
+	}
+	return &InlineKeyboardMarkup{InlineKeyboard: keyboard}
+}
+
+// GetUpdates returns the updates, waits till the poll timeout if there is no update
+func (c *TelegramClient) GetUpdates(client *httpClient.Client, offset int64, pollTimeout time.Duration) ([]Update, error) {
+	request := &GetUpdatesRequest{
+		Offset:         offset,
+		Timeout:        int(pollTimeout.Seconds()),
+		AllowedUpdates: []string{"message", "callback_query"},
+	}
+	response, err := client.ExecuteJson(c.getURL(APIGetUpdates), http.MethodPost, nil, nil, request, 200)
+	if err != nil {
+		return nil, err
+	}
+	resp := &UpdatesResponse{}
+	err = json.Unmarshal(response.Body, resp)
+	if err != nil {
+		return nil, err
+	}
+	if !resp.IsOK {
+		return nil, fmt.Errorf("request failed: %+v", resp)
+	}
+	return resp.Result, nil
+}
+
+// AnswerCallbackQuery func
+func (c *TelegramClient) AnswerCallbackQuery(answer *CallbackAnswer) error {
+	response, err := c.httpClient.ExecuteJson(c.getURL(APIAnswerCallbackQuery), http.MethodPost, nil, nil, answer, 200)
+	if err != nil {
+		return err
+	}
+	resp := &Response{}
+	err = json.Unmarshal(response.Body, resp)
+	if err != nil {
+		return err
+	}
+	if !resp.IsOK {
+		return fmt.Errorf("request failed: %+v", resp)
+	}
+	return nil
+}
+
+// parseCommand returns the command and the arguments
+// removes the bot name from the command, ex: /status@my_bot
+func parseCommand(text string) (string, []string) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return "", nil
+	}
+	command := strings.TrimPrefix(fields[0], "/")
+	command = strings.SplitN(command, "@", 2)[0]
+	return strings.ToLower(command), fields[1:]
+}
+
+// formatResource returns the important details of the resource
+func formatResource(quickID string, resource map[string]interface{}) string {
+	lines := []string{quickID}
+	if name := getValue(resource, "name"); name != nil && name != "" {
+		lines = append(lines, fmt.Sprintf("name: %v", name))
+	}
+	if value := getValue(resource, "current.value"); value != nil {
+		unit := getValue(resource, "unit")
+		if unit != nil && unit != "" {
+			lines = append(lines, fmt.Sprintf("value: %v %v", value, unit))
+		} else {
+			lines = append(lines, fmt.Sprintf("value: %v", value))
+		}
+		if timestamp := getValue(resource, "current.timestamp"); timestamp != nil {
+			lines = append(lines, fmt.Sprintf("updated: %v", timestamp))
+		}
+	}
+	if enabled := getValue(resource, "enabled"); enabled != nil {
+		lines = append(lines, fmt.Sprintf("enabled: %v", enabled))
+	}
+	if status := getValue(resource, "state.status"); status != nil && status != "" {
+		lines = append(lines, fmt.Sprintf("status: %v", status))
+		if message := getValue(resource, "state.message"); message != nil && message != "" {
+			lines = append(lines, fmt.Sprintf("message: %v", message))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// getValue returns the value of the key path from the map, nil if not available
+func getValue(data map[string]interface{}, keyPath string) interface{} {
+	var value interface{} = data
+	for _, key := range strings.Split(keyPath, ".") {
+		valueMap, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = valueMap[key]
+	}
+	return value
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/mycontroller-org/server/v2/pkg/json"
@@ -11,6 +12,7 @@ import (
 	"github.com/mycontroller-org/server/v2/pkg/utils"
 	httpClient "github.com/mycontroller-org/server/v2/pkg/utils/http_client_json"
 	loggerUtils "github.com/mycontroller-org/server/v2/pkg/utils/logger"
+	busTY "github.com/mycontroller-org/server/v2/plugin/bus/types"
 	handlerTY "github.com/mycontroller-org/server/v2/plugin/handler/types"
 	"go.uber.org/zap"
 )
@@ -28,6 +30,9 @@ type TelegramClient struct {
 	Config     *Config
 	httpClient *httpClient.Client
 	logger     *zap.Logger
+	bus        busTY.Plugin
+	callbacks  *callbackStore
+	cancelPoll context.CancelFunc
 }
 
 // telegram handler
@@ -36,6 +41,10 @@ func New(ctx context.Context, cfg *handlerTY.Config) (handlerTY.Plugin, error) {
 	if err != nil {
 		return nil, err
 	}
+	bus, err := busTY.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	config := &Config{}
 	err = utils.MapToStruct(utils.TagNameNone, cfg.Spec, config)
@@ -48,6 +57,8 @@ func New(ctx context.Context, cfg *handlerTY.Config) (handlerTY.Plugin, error) {
 		handlerCfg: cfg,
 		Config:     config,
 		logger:     logger.Named(loggerName),
+		bus:        bus,
+		callbacks:  &callbackStore{mutex: sync.Mutex{}, actions: map[string]callbackAction{}},
 	}
 
 	user, err := client.GetMe()
@@ -64,10 +75,23 @@ func (p *TelegramClient) Name() string {
 }
 
 // Start handler implementation
-func (c *TelegramClient) Start() error { return nil }
+func (c *TelegramClient) Start() error {
+	if !c.Config.Bot.Enabled {
+		return nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancelPoll = cancel
+	go c.pollUpdates(ctx)
+	return nil
+}
 
 // Close handler implementation
-func (c *TelegramClient) Close() error { return nil }
+func (c *TelegramClient) Close() error {
+	if c.cancelPoll != nil {
+		c.cancelPoll()
+	}
+	return nil
+}
 
 // State implementation
 func (c *TelegramClient) State() *types.State {
@@ -112,13 +136,16 @@ func (c *TelegramClient) Post(parameters map[string]interface{}) error {
 			continue
 		}
 
+		replyMarkup := c.toReplyMarkup(telegramData.Buttons)
+
 		start := time.Now()
 		errors := make([]error, 0)
 		for _, chatID := range chatIDs {
 			msg := &Message{
-				ChatID:    chatID,
-				ParseMode: parseMode,
-				Text:      telegramData.Text,
+				ChatID:      chatID,
+				ParseMode:   parseMode,
+				Text:        telegramData.Text,
+				ReplyMarkup: replyMarkup,
 			}
 			err = c.SendMessage(msg)
 			if err != nil {
@@ -4,6 +4,22 @@ package telegram
 type Config struct {
 	Token   string `json:"-"`
 	ChatIDs []string
+	Bot     BotConfig
+}
+
+// BotConfig to receive commands from the chats
+type BotConfig struct {
+	Enabled        bool
+	PollTimeout    string // long polling timeout, default 30s
+	StatusQuickIDs []string
+	Chats          []ChatConfig
+}
+
+// ChatConfig permissions of a chat, commands from the chats not listed here are rejected
+type ChatConfig struct {
+	ChatID   string
+	Commands []string // allowed commands, empty allows all the commands
+	QuickIDs []string // allowed quick id patterns (ex: field:mysensors.1.*), empty allows all the resources
 }
 
 // User struct
@@ -26,19 +42,100 @@ type Response struct {
 
 // Message struct
 type Message struct {
-	ChatID                string `json:"chat_id"`
-	Text                  string `json:"text"`
-	ParseMode             string `json:"parse_mode"`
-	DisableWebPagePreview bool   `json:"disable_web_page_preview"`
-	DisableNotification   bool   `json:"disable_notification"`
-	ReplyToMessageID      int    `json:"reply_to_message_id"`
+	ChatID                string                `json:"chat_id"`
+	Text                  string                `json:"text"`
+	ParseMode             string                `json:"parse_mode"`
+	DisableWebPagePreview bool                  `json:"disable_web_page_preview"`
+	DisableNotification   bool                  `json:"disable_notification"`
+	ReplyToMessageID      int                   `json:"reply_to_message_id"`
+	ReplyMarkup           *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+}
+
+// InlineKeyboardMarkup struct
+type InlineKeyboardMarkup struct {
+	InlineKeyboard [][]InlineKeyboardButton `json:"inline_keyboard"`
+}
+
+// InlineKeyboardButton struct
+type InlineKeyboardButton struct {
+	Text         string `json:"text"`
+	URL          string `json:"url,omitempty"`
+	CallbackData string `json:"callback_data,omitempty"`
+}
+
+// GetUpdatesRequest struct
+type GetUpdatesRequest struct {
+	Offset         int64    `json:"offset"`
+	Timeout        int      `json:"timeout"`
+	AllowedUpdates []string `json:"allowed_updates"`
+}
+
+// UpdatesResponse struct
+type UpdatesResponse struct {
+	IsOK        bool     `json:"ok"`
+	Result      []Update `json:"result"`
+	ErrorCode   int      `json:"error_code"`
+	Description string   `json:"description"`
+}
+
+// Update struct
+type Update struct {
+	UpdateID      int64            `json:"update_id"`
+	Message       *IncomingMessage `json:"message"`
+	CallbackQuery *CallbackQuery   `json:"callback_query"`
+}
+
+// IncomingMessage struct
+type IncomingMessage struct {
+	MessageID int64  `json:"message_id"`
+	From      *User  `json:"from"`
+	Chat      Chat   `json:"chat"`
+	Date      int64  `json:"date"`
+	Text      string `json:"text"`
+}
+
+// Chat struct
+type Chat struct {
+	ID       int64  `json:"id"`
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Username string `json:"username"`
+}
+
+// CallbackQuery struct
+type CallbackQuery struct {
+	ID      string           `json:"id"`
+	From    *User            `json:"from"`
+	Message *IncomingMessage `json:"message"`
+	Data    string           `json:"data"`
+}
+
+// CallbackAnswer struct
+type CallbackAnswer struct {
+	CallbackQueryID string `json:"callback_query_id"`
+	Text            string `json:"text"`
+	ShowAlert       bool   `json:"show_alert"`
 }
 
 // Telegram server api details
 const (
-	ServerURL      = "https://api.telegram.org"
-	APIGetMe       = "/getMe"
-	APISendMessage = "/sendMessage"
+	ServerURL              = "https://api.telegram.org"
+	APIGetMe               = "/getMe"
+	APISendMessage         = "/sendMessage"
+	APIGetUpdates          = "/getUpdates"
+	APIAnswerCallbackQuery = "/answerCallbackQuery"
+)
+
+// Bot commands
+const (
+	CommandStart    = "start"
+	CommandHelp     = "help"
+	CommandStatus   = "status"
+	CommandGet      = "get"
+	CommandSet      = "set"
+	CommandTask     = "task"
+	CommandSchedule = "schedule"
+	CommandButton   = "button" // inline keyboard button press
 )
 
 // Message types
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"errors"
+	"time"
+)
+
+// default retry policy
+const (
+	DefaultRetryMaxAttempts = 5
+	DefaultRetryDelay       = time.Second * 10
+	DefaultRetryMaxDelay    = time.Minute * 10
+)
+
+// ErrPermanent failure, wrapped by the handlers when a retry can not succeed (bad request, invalid config, etc.,).
+// the delivery is moved to the dead letters without further attempts
+var ErrPermanent = errors.New("permanent failure")
+
+// RetryPolicy of a handler, the delay is doubled on each attempt till the max delay
+type RetryPolicy struct {
+	MaxAttempts int    `json:"maxAttempts" yaml:"maxAttempts"` // including the first attempt
+	Delay       string `json:"delay" yaml:"delay"`
+	MaxDelay    string `json:"maxDelay" yaml:"maxDelay"`
+}
+
+// GetMaxAttempts returns the max attempts, default if not configured
+func (rp *RetryPolicy) GetMaxAttempts() int {
+	if rp == nil || rp.MaxAttempts <= 0 {
+		return DefaultRetryMaxAttempts
+	}
+	return rp.MaxAttempts
+}
+
+// GetBackoff returns the delay before the next attempt
+func (rp *RetryPolicy) GetBackoff(attempts int) time.Duration {
+	delay := DefaultRetryDelay
+	maxDelay := DefaultRetryMaxDelay
+	if rp != nil {
+		delay = toDuration(rp.Delay, DefaultRetryDelay)
+		maxDelay = toDuration(rp.MaxDelay, DefaultRetryMaxDelay)
+	}
+	for attempt := 1; attempt < attempts && delay < maxDelay; attempt++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay
+}
+
+func toDuration(duration string, defaultDuration time.Duration) time.Duration {
+	parsed, err := time.ParseDuration(duration)
+	if err != nil || parsed <= 0 {
+		return defaultDuration
+	}
+	return parsed
+}
+
+// Delivery of a message to a handler
+type Delivery struct {
+	ID          string                 `json:"id" yaml:"id"`
+	HandlerID   string                 `json:"handlerId" yaml:"handlerId"`
+	Data        map[string]interface{} `json:"data" yaml:"data"`
+	Attempts    int                    `json:"attempts" yaml:"attempts"`
+	LastError   string                 `json:"lastError" yaml:"lastError"`
+	CreatedOn   time.Time              `json:"createdOn" yaml:"createdOn"`
+	LastAttempt time.Time              `json:"lastAttempt" yaml:"lastAttempt"`
+	NextAttempt time.Time              `json:"nextAttempt" yaml:"nextAttempt"`
+}
+
+// DeliveryStats of a handler
+type DeliveryStats struct {
+	HandlerID        string    `json:"handlerId" yaml:"handlerId"`
+	Sent             int64     `json:"sent" yaml:"sent"`
+	Failed           int64     `json:"failed" yaml:"failed"` // failed attempts
+	DeadLettered     int64     `json:"deadLettered" yaml:"deadLettered"`
+	Deduplicated     int64     `json:"deduplicated" yaml:"deduplicated"`
+	Held             int       `json:"held" yaml:"held"` // held by the notify policy
+	Pending          int       `json:"pending" yaml:"pending"`
+	DeadLetters      int       `json:"deadLetters" yaml:"deadLetters"`
+	LastLatencyMs    float64   `json:"lastLatencyMs" yaml:"lastLatencyMs"`
+	AverageLatencyMs float64   `json:"averageLatencyMs" yaml:"averageLatencyMs"`
+	MaxLatencyMs     float64   `json:"maxLatencyMs" yaml:"maxLatencyMs"`
+	LastSuccess      time.Time `json:"lastSuccess" yaml:"lastSuccess"`
+	LastFailure      time.Time `json:"lastFailure" yaml:"lastFailure"`
+}
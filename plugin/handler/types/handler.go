@@ -17,6 +17,11 @@ const (
 	DataTypeWebhook  = "webhook"
 	DataTypeMqtt     = "mqtt"
 	DataTypeBackup   = "backup"
+	DataTypeNtfy     = "ntfy"
+	DataTypeGotify   = "gotify"
+	DataTypePushover = "pushover"
+	DataTypeMatrix   = "matrix"
+	DataTypeSlack    = "slack"
 )
 
 var (
@@ -34,14 +39,16 @@ type Plugin interface {
 
 // Config struct
 type Config struct {
-	ID          string               `json:"id" yaml:"id"`
-	Description string               `json:"description" yaml:"description"`
-	Enabled     bool                 `json:"enabled" yaml:"enabled"`
-	Labels      cmap.CustomStringMap `json:"labels" yaml:"labels"`
-	Type        string               `json:"type" yaml:"type"`
-	Spec        cmap.CustomMap       `json:"spec" yaml:"spec"`
-	ModifiedOn  time.Time            `json:"modifiedOn" yaml:"modifiedOn"`
-	State       *types.State         `json:"state" yaml:"state"`
+	ID           string               `json:"id" yaml:"id"`
+	Description  string               `json:"description" yaml:"description"`
+	Enabled      bool                 `json:"enabled" yaml:"enabled"`
+	Labels       cmap.CustomStringMap `json:"labels" yaml:"labels"`
+	Type         string               `json:"type" yaml:"type"`
+	Spec         cmap.CustomMap       `json:"spec" yaml:"spec"`
+	RetryPolicy  *RetryPolicy         `json:"retryPolicy" yaml:"retryPolicy"`
+	NotifyPolicy *NotifyPolicy        `json:"notifyPolicy" yaml:"notifyPolicy"`
+	ModifiedOn   time.Time            `json:"modifiedOn" yaml:"modifiedOn"`
+	State        *types.State         `json:"state" yaml:"state"`
 }
 
 // Clone config
@@ -54,6 +61,11 @@ func (hdr *Config) Clone() Config {
 		Labels:      hdr.Labels.Clone(),
 		Spec:        hdr.Spec.Clone(),
 	}
+	if hdr.RetryPolicy != nil {
+		retryPolicy := *hdr.RetryPolicy
+		clonedConfig.RetryPolicy = &retryPolicy
+	}
+	clonedConfig.NotifyPolicy = hdr.NotifyPolicy.Clone()
 	return clonedConfig
 }
 
@@ -109,25 +121,88 @@ type WebhookData struct {
 	Data            interface{}            `json:"data" yaml:"data"`
 	CustomData      bool                   `json:"customData" yaml:"customData"`
 	ResponseCode    int                    `json:"responseCode" yaml:"responseCode"`
+	BodyTemplate    string                 `json:"bodyTemplate" yaml:"bodyTemplate"` // rendered per call with the task variables
+	TemplateType    string                 `json:"templateType" yaml:"templateType"` // go or javascript, default go
+	Body            string                 `json:"body" yaml:"body"`                 // rendered body template, sent as is
+	StoreResponse   *WebhookStoreResponse  `json:"storeResponse" yaml:"storeResponse"`
+}
+
+// webhook body template types
+const (
+	WebhookTemplateTypeGo         = "go"
+	WebhookTemplateTypeJavascript = "javascript"
+)
+
+// WebhookStoreResponse stores the webhook response body into a data repository entry or into a field
+// quickId examples: "data_repository:my_repo", "field:gw.node.source.field"
+type WebhookStoreResponse struct {
+	QuickID      string `json:"quickId" yaml:"quickId"`
+	KeyPath      string `json:"keyPath" yaml:"keyPath"`           // key path on the data repository
+	ResponsePath string `json:"responsePath" yaml:"responsePath"` // optional, stores only the value from the json response
 }
 
 // EmailData struct
 type EmailData struct {
-	Disabled string   `json:"disabled" yaml:"disabled"`
-	Type     string   `json:"type" yaml:"type"`
-	From     string   `json:"from" yaml:"from"`
-	To       []string `json:"to" yaml:"to"`
-	Subject  string   `json:"subject" yaml:"subject"`
-	Body     string   `json:"body" yaml:"body"`
+	Disabled    string            `json:"disabled" yaml:"disabled"`
+	Type        string            `json:"type" yaml:"type"`
+	From        string            `json:"from" yaml:"from"`
+	To          []string          `json:"to" yaml:"to"`
+	Subject     string            `json:"subject" yaml:"subject"`
+	Body        string            `json:"body" yaml:"body"`
+	HTML        string            `json:"html" yaml:"html"` // html body, the body is used as the text fallback
+	Attachments []EmailAttachment `json:"attachments" yaml:"attachments"`
+}
+
+// EmailAttachment loaded from one of the sources: content, path, url or metric
+type EmailAttachment struct {
+	Name        string                 `json:"name" yaml:"name"`
+	ContentType string                 `json:"contentType" yaml:"contentType"`
+	Content     string                 `json:"content" yaml:"content"` // text content
+	Path        string                 `json:"path" yaml:"path"`       // local file inside of the attachments directory, on a pattern the latest modified file is used. ex: backup/*.zip
+	URL         string                 `json:"url" yaml:"url"`
+	Metric      *EmailMetricAttachment `json:"metric" yaml:"metric"`
+}
+
+// EmailMetricAttachment metric data of a field as csv
+type EmailMetricAttachment struct {
+	QuickID   string   `json:"quickId" yaml:"quickId"` // field quick id
+	Start     string   `json:"start" yaml:"start"`     // default -24h
+	Stop      string   `json:"stop" yaml:"stop"`
+	Window    string   `json:"window" yaml:"window"`
+	Functions []string `json:"functions" yaml:"functions"`
 }
 
 // TelegramData struct
 type TelegramData struct {
-	Disabled  string   `json:"disabled" yaml:"disabled"`
-	Type      string   `json:"type" yaml:"type"`
-	ChatIDs   []string `json:"chatIds" yaml:"chatIds"`
-	ParseMode string   `json:"parseMode" yaml:"parseMode"`
-	Text      string   `json:"text" yaml:"text"`
+	Disabled  string             `json:"disabled" yaml:"disabled"`
+	Type      string             `json:"type" yaml:"type"`
+	ChatIDs   []string           `json:"chatIds" yaml:"chatIds"`
+	ParseMode string             `json:"parseMode" yaml:"parseMode"`
+	Text      string             `json:"text" yaml:"text"`
+	Buttons   [][]TelegramButton `json:"buttons" yaml:"buttons"`
+}
+
+// TelegramButton inline keyboard button, executes the payload on the quick id resource when pressed
+type TelegramButton struct {
+	Text    string `json:"text" yaml:"text"`
+	QuickID string `json:"quickId" yaml:"quickId"`
+	Payload string `json:"payload" yaml:"payload"`
+	URL     string `json:"url" yaml:"url"`
+}
+
+// NotificationData struct, used on ntfy, gotify, pushover, matrix and slack handlers
+type NotificationData struct {
+	Disabled       string   `json:"disabled" yaml:"disabled"`
+	Type           string   `json:"type" yaml:"type"`
+	Title          string   `json:"title" yaml:"title"`
+	Message        string   `json:"message" yaml:"message"`
+	Priority       string   `json:"priority" yaml:"priority"` // min, low, default, high, urgent or 1 to 5
+	Tags           []string `json:"tags" yaml:"tags"`
+	Format         string   `json:"format" yaml:"format"` // text, markdown, html
+	ClickURL       string   `json:"clickUrl" yaml:"clickUrl"`
+	AttachmentURL  string   `json:"attachmentUrl" yaml:"attachmentUrl"`
+	AttachmentName string   `json:"attachmentName" yaml:"attachmentName"`
+	Targets        []string `json:"targets" yaml:"targets"` // overwrites the configured topics, rooms, channels or devices
 }
 
 // MqttData struct
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"time"
+)
+
+// reserved parameter keys, used by the notify policy
+const (
+	KeyCritical = "critical" // critical messages skip the quiet hours, rate limit and digest
+	KeyDedupKey = "dedupKey" // messages with the same key are deduplicated
+)
+
+// quiet hours sun based times, can have an offset. ex: sunset+30m, sunrise-1h
+const (
+	QuietHoursSunrise = "sunrise"
+	QuietHoursSunset  = "sunset"
+)
+
+// NotifyPolicy of a handler, applied on the messages before posting to the handler
+type NotifyPolicy struct {
+	RateLimit   *RateLimit   `json:"rateLimit" yaml:"rateLimit"`
+	Digest      *Digest      `json:"digest" yaml:"digest"`
+	Deduplicate *Deduplicate `json:"deduplicate" yaml:"deduplicate"`
+	QuietHours  []QuietHours `json:"quietHours" yaml:"quietHours"`
+}
+
+// RateLimit allows the limit messages in the period,
+// the exceeding messages are held and delivered as a digest when the limit allows
+type RateLimit struct {
+	Limit  int    `json:"limit" yaml:"limit"`
+	Period string `json:"period" yaml:"period"`
+}
+
+// Digest coalesces the messages received within the window into a single message
+type Digest struct {
+	Window      string `json:"window" yaml:"window"`
+	MaxMessages int    `json:"maxMessages" yaml:"maxMessages"` // delivers before the window ends, on reaching this count
+}
+
+// Deduplicate drops the messages with the same key within the window.
+// the key is taken from the "dedupKey" parameter field, if not available the complete message is the key
+type Deduplicate struct {
+	Window string `json:"window" yaml:"window"`
+}
+
+// QuietHours holds the non-critical messages from the start till the end time.
+// time formats: 22:00, 22:00:00, sunrise, sunset, sunset+30m
+type QuietHours struct {
+	From string   `json:"from" yaml:"from"`
+	To   string   `json:"to" yaml:"to"`
+	Days []string `json:"days" yaml:"days"` // day of the start time, ex: mon, tue. empty for all the days
+}
+
+// GetPeriod returns the rate limit period
+func (rl *RateLimit) GetPeriod() time.Duration {
+	return toDuration(rl.Period, time.Minute)
+}
+
+// GetWindow returns the digest window
+func (d *Digest) GetWindow() time.Duration {
+	return toDuration(d.Window, time.Minute*5)
+}
+
+// GetWindow returns the deduplicate window
+func (d *Deduplicate) GetWindow() time.Duration {
+	return toDuration(d.Window, time.Minute*5)
+}
+
+// Clone notify policy
+func (np *NotifyPolicy) Clone() *NotifyPolicy {
+	if np == nil {
+		return nil
+	}
+	cloned := &NotifyPolicy{}
+	if np.RateLimit != nil {
+		rateLimit := *np.RateLimit
+		cloned.RateLimit = &rateLimit
+	}
+	if np.Digest != nil {
+		digest := *np.Digest
+		cloned.Digest = &digest
+	}
+	if np.Deduplicate != nil {
+		deduplicate := *np.Deduplicate
+		cloned.Deduplicate = &deduplicate
+	}
+	for _, quietHours := range np.QuietHours {
+		quietHours.Days = append([]string{}, quietHours.Days...)
+		cloned.QuietHours = append(cloned.QuietHours, quietHours)
+	}
+	return cloned
+}
@@ -2,14 +2,22 @@ package webhook
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/mycontroller-org/server/v2/pkg/json"
 	"github.com/mycontroller-org/server/v2/pkg/types"
+	rsTY "github.com/mycontroller-org/server/v2/pkg/types/resource_service"
 	"github.com/mycontroller-org/server/v2/pkg/utils"
+	busUtils "github.com/mycontroller-org/server/v2/pkg/utils/bus_utils"
+	filterUtils "github.com/mycontroller-org/server/v2/pkg/utils/filter_sort"
 	httpclient "github.com/mycontroller-org/server/v2/pkg/utils/http_client_json"
 	loggerUtils "github.com/mycontroller-org/server/v2/pkg/utils/logger"
+	quickIdUtils "github.com/mycontroller-org/server/v2/pkg/utils/quick_id"
+	busTY "github.com/mycontroller-org/server/v2/plugin/bus/types"
 	handlerTY "github.com/mycontroller-org/server/v2/plugin/handler/types"
 	"go.uber.org/zap"
 )
@@ -26,7 +34,7 @@ const (
 	// DataTypeText = "text"
 )
 
-// WebhookConfig for webhook
+// WebhookConfig for webhook, failures are retried by the retry policy of the handler
 type WebhookConfig struct {
 	Server          string
 	API             string
@@ -36,6 +44,10 @@ type WebhookConfig struct {
 	QueryParameters map[string]interface{}
 	ResponseCode    int
 	AllowOverwrite  bool
+	Timeout         string
+	Signing         *SigningConfig
+	TLS             *TLSConfig
+	StoreResponse   *handlerTY.WebhookStoreResponse
 }
 
 // Clone config data
@@ -47,21 +59,22 @@ func (cfg *WebhookConfig) Clone() *WebhookConfig {
 		Method:          cfg.Method,
 		QueryParameters: make(map[string]interface{}),
 		Headers:         make(map[string]string),
+		ResponseCode:    cfg.ResponseCode,
 		AllowOverwrite:  cfg.AllowOverwrite,
+		Timeout:         cfg.Timeout,
+		Signing:         cfg.Signing,
+		TLS:             cfg.TLS,
+		StoreResponse:   cfg.StoreResponse,
 	}
 
 	// update query parameters
-	if len(cfg.QueryParameters) > 0 {
-		for key, value := range cfg.Headers {
-			config.Headers[key] = value
-		}
+	for key, value := range cfg.QueryParameters {
+		config.QueryParameters[key] = value
 	}
 
 	// update headers
-	if len(cfg.Headers) > 0 {
-		for key, value := range cfg.QueryParameters {
-			config.QueryParameters[key] = value
-		}
+	for key, value := range cfg.Headers {
+		config.Headers[key] = value
 	}
 	return config
 }
@@ -72,6 +85,7 @@ type WebhookClient struct {
 	Config     *WebhookConfig
 	httpClient *httpclient.Client
 	logger     *zap.Logger
+	bus        busTY.Plugin
 }
 
 func New(ctx context.Context, handlerCfg *handlerTY.Config) (handlerTY.Plugin, error) {
@@ -79,6 +93,10 @@ func New(ctx context.Context, handlerCfg *handlerTY.Config) (handlerTY.Plugin, e
 	if err != nil {
 		return nil, err
 	}
+	bus, err := busTY.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	config := &WebhookConfig{}
 	err = utils.MapToStruct(utils.TagNameNone, handlerCfg.Spec, config)
@@ -93,6 +111,7 @@ func New(ctx context.Context, handlerCfg *handlerTY.Config) (handlerTY.Plugin, e
 		HandlerCfg: handlerCfg,
 		Config:     config,
 		logger:     namedLogger,
+		bus:        bus,
 	}
 	return client, nil
 }
@@ -104,7 +123,16 @@ func (p *WebhookClient) Name() string {
 // Start handler implementation
 func (c *WebhookClient) Start() error {
 	if c.httpClient == nil {
-		c.httpClient = httpclient.GetClient(c.Config.Insecure, timeout)
+		requestTimeout := utils.ToDuration(c.Config.Timeout, timeout)
+		if c.Config.TLS != nil {
+			tlsConfig, err := c.Config.TLS.Get(c.Config.Insecure)
+			if err != nil {
+				return err
+			}
+			c.httpClient = httpclient.GetClientWithTLS(tlsConfig, requestTimeout)
+		} else {
+			c.httpClient = httpclient.GetClient(c.Config.Insecure, requestTimeout)
+		}
 	}
 
 	return nil
@@ -128,10 +156,9 @@ func (c *WebhookClient) State() *types.State {
 	return &types.State{}
 }
 
-// Post handler implementation
+// Post handler implementation.
+// the sent parameters are removed from the parameters, not to send them again on a retry
 func (c *WebhookClient) Post(parameters map[string]interface{}) error {
-	config := c.Config.Clone()
-
 	for name, rawParameter := range parameters {
 		parameter, ok := handlerTY.IsTypeOf(rawParameter, handlerTY.DataTypeWebhook)
 		if !ok {
@@ -146,7 +173,8 @@ func (c *WebhookClient) Post(parameters map[string]interface{}) error {
 			continue
 		}
 
-		// overide basic config, if any
+		// overide basic config, if any. cloned per parameter, not to carry the overrides and the headers to the next parameter
+		config := c.Config.Clone()
 		if config.AllowOverwrite {
 			if webhookData.Server != "" {
 				config.Server = webhookData.Server
@@ -164,16 +192,12 @@ func (c *WebhookClient) Post(parameters map[string]interface{}) error {
 				config.ResponseCode = webhookData.ResponseCode
 			}
 
-			if len(webhookData.QueryParameters) > 0 {
-				for key, value := range webhookData.Headers {
-					config.Headers[key] = value
-				}
+			for key, value := range webhookData.Headers {
+				config.Headers[key] = value
 			}
 
-			if len(webhookData.Headers) > 0 {
-				for key, value := range webhookData.QueryParameters {
-					config.QueryParameters[key] = value
-				}
+			for key, value := range webhookData.QueryParameters {
+				config.QueryParameters[key] = value
 			}
 		}
 
@@ -183,16 +207,141 @@ func (c *WebhookClient) Post(parameters map[string]interface{}) error {
 
 		url := fmt.Sprintf("%s%s", config.Server, config.API)
 
-		respose, err := c.httpClient.ExecuteJson(url, config.Method, config.Headers, config.QueryParameters, webhookData.Data, config.ResponseCode)
-		responseCode := 0
-		if respose != nil {
-			responseCode = respose.StatusCode
+		body, err := c.getBody(config, &webhookData)
+		if err != nil {
+			c.logger.Error("error on webhook body", zap.String("name", name), zap.Error(err))
+			return fmt.Errorf("%w: %w", handlerTY.ErrPermanent, err)
+		}
+
+		response, err := c.execute(url, config, body)
+		if err != nil {
+			c.logger.Error("error on webhook handler call", zap.String("name", name), zap.Error(err))
+			return err
+		}
+
+		storeResponse := config.StoreResponse
+		if webhookData.StoreResponse != nil {
+			storeResponse = webhookData.StoreResponse
+		}
+		if storeResponse != nil {
+			err = c.storeResponse(storeResponse, response)
+			if err != nil {
+				c.logger.Error("error on storing webhook response", zap.String("name", name), zap.Error(err))
+				// the webhook is called, calling again does not fix the response
+				return fmt.Errorf("%w: %w", handlerTY.ErrPermanent, err)
+			}
+		}
+		delete(parameters, name)
+	}
+
+	return nil
+}
+
+// getBody returns the rendered body template, if available, else the data as json
+func (c *WebhookClient) getBody(config *WebhookConfig, webhookData *handlerTY.WebhookData) (string, error) {
+	if webhookData.Body != "" {
+		if _, found := getHeader(config.Headers, "Content-Type"); !found {
+			config.Headers["Content-Type"] = "application/json"
+		}
+		return webhookData.Body, nil
+	}
+
+	if config.Method == http.MethodGet || webhookData.Data == nil {
+		return "", nil
+	}
+	bodyBytes, err := json.Marshal(webhookData.Data)
+	if err != nil {
+		return "", err
+	}
+	if _, found := getHeader(config.Headers, "Content-Type"); !found {
+		config.Headers["Content-Type"] = "application/json"
+	}
+	return string(bodyBytes), nil
+}
+
+// execute calls the webhook.
+// network failures, too many requests and server errors are retried by the retry policy, other failures are permanent
+func (c *WebhookClient) execute(url string, config *WebhookConfig, body string) (*httpclient.ResponseConfig, error) {
+	if _, found := getHeader(config.Headers, "Accept"); !found {
+		config.Headers["Accept"] = "application/json"
+	}
+
+	// signature includes the timestamp, signed on each attempt
+	if config.Signing != nil {
+		err := config.Signing.Sign(config.Headers, body)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", handlerTY.ErrPermanent, err)
 		}
+	}
+
+	response, err := c.httpClient.Execute(url, config.Method, config.Headers, config.QueryParameters, body, 0)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode == http.StatusTooManyRequests || response.StatusCode >= http.StatusInternalServerError {
+		return nil, fmt.Errorf("failed with status code. [statusCode: %v, body: %s]", response.StatusCode, response.StringBody())
+	}
+	if config.ResponseCode > 0 && response.StatusCode != config.ResponseCode {
+		return nil, fmt.Errorf("%w: failed with status code. [statusCode: %v, body: %s]", handlerTY.ErrPermanent, response.StatusCode, response.StringBody())
+	}
+	// response code not configured, expects a success status code
+	if config.ResponseCode <= 0 && (response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices) {
+		return nil, fmt.Errorf("%w: failed with status code. [statusCode: %v, body: %s]", handlerTY.ErrPermanent, response.StatusCode, response.StringBody())
+	}
+	return response, nil
+}
+
+// storeResponse updates the response body into data repository or field via resource action
+func (c *WebhookClient) storeResponse(storeCfg *handlerTY.WebhookStoreResponse, response *httpclient.ResponseConfig) error {
+	if storeCfg.QuickID == "" {
+		return errors.New("quickId not supplied on store response")
+	}
+
+	payload := response.StringBody()
+	if storeCfg.ResponsePath != "" {
+		// key path lookup starts from a struct
+		responseData := struct{ Response interface{} }{}
+		err := json.Unmarshal(response.Body, &responseData.Response)
+		if err != nil {
+			return fmt.Errorf("response is not a json: %w", err)
+		}
+		_, value, err := filterUtils.GetValueByKeyPath(responseData, "response."+storeCfg.ResponsePath)
 		if err != nil {
-			c.logger.Error("error on webhook handler call", zap.Int("responseStatusCode", responseCode), zap.Error(err))
 			return err
 		}
+		if stringValue, ok := value.(string); ok {
+			payload = stringValue
+		} else {
+			valueBytes, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+			payload = string(valueBytes)
+		}
 	}
 
+	// resource type is supplied separately, ex: data_repository:my_repo
+	resourceType, _, err := quickIdUtils.EntityKeyValueMap(storeCfg.QuickID)
+	if err != nil {
+		return err
+	}
+	rsData := handlerTY.ResourceData{
+		ResourceType: resourceType,
+		QuickID:      strings.SplitN(storeCfg.QuickID, ":", 2)[1],
+		KeyPath:      storeCfg.KeyPath,
+		Payload:      payload,
+	}
+	c.logger.Debug("storing webhook response", zap.String("id", c.HandlerCfg.ID), zap.Any("resourceData", rsData))
+	busUtils.PostToResourceService(c.logger, c.bus, c.HandlerCfg.ID, rsData, rsTY.TypeResourceAction, rsTY.CommandSet, "")
 	return nil
 }
+
+// getHeader returns the header value, header names are case insensitive
+func getHeader(headers map[string]string, name string) (string, bool) {
+	for key, value := range headers {
+		if strings.EqualFold(key, name) {
+			return value, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,263 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mycontroller-org/server/v2/pkg/types"
+	"github.com/mycontroller-org/server/v2/pkg/types/cmap"
+	rsTY "github.com/mycontroller-org/server/v2/pkg/types/resource_service"
+	"github.com/mycontroller-org/server/v2/pkg/types/topic"
+	loggerUtils "github.com/mycontroller-org/server/v2/pkg/utils/logger"
+	"github.com/mycontroller-org/server/v2/plugin/bus/embedded"
+	busTY "github.com/mycontroller-org/server/v2/plugin/bus/types"
+	handlerTY "github.com/mycontroller-org/server/v2/plugin/handler/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func hmacHex(secret, content string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(content))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestSign(t *testing.T) {
+	body := `{"value":1}`
+
+	headers := map[string]string{}
+	require.NoError(t, (&SigningConfig{Secret: "secret"}).Sign(headers, body))
+	assert.Equal(t, map[string]string{"X-Signature-256": "sha256=" + hmacHex("secret", body)}, headers)
+
+	// timestamp is included on the signed content
+	emptyPrefix := ""
+	headers = map[string]string{}
+	signing := &SigningConfig{Secret: "secret", Header: "X-Hub-Signature", TimestampHeader: "X-Timestamp", Prefix: &emptyPrefix}
+	require.NoError(t, signing.Sign(headers, body))
+	timestamp := headers["X-Timestamp"]
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), time.Unix(unix, 0), 2*time.Second)
+	assert.Equal(t, hmacHex("secret", timestamp+"."+body), headers["X-Hub-Signature"])
+
+	assert.EqualError(t, (&SigningConfig{}).Sign(map[string]string{}, body), "signing secret not supplied")
+}
+
+// testServer responds with the status codes in order, the last one is repeated
+type testServer struct {
+	*httptest.Server
+	mutex    sync.Mutex
+	statuses []int
+	response string
+	requests []*http.Request
+	bodies   []string
+}
+
+func newTestServer(t *testing.T, response string, statuses ...int) *testServer {
+	server := &testServer{statuses: statuses, response: response}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		server.mutex.Lock()
+		defer server.mutex.Unlock()
+		server.requests = append(server.requests, r)
+		server.bodies = append(server.bodies, string(body))
+		status := server.statuses[0]
+		if len(server.statuses) > 1 {
+			server.statuses = server.statuses[1:]
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(server.response))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func (s *testServer) count() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.requests)
+}
+
+func newTestClient(t *testing.T, config *WebhookConfig) (*WebhookClient, chan *handlerTY.ResourceData) {
+	ctx := loggerUtils.WithContext(context.Background(), zap.NewNop())
+	bus, err := embedded.NewClient(ctx, cmap.CustomMap{})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = bus.Close() })
+
+	actions := make(chan *handlerTY.ResourceData, 10)
+	_, err = bus.Subscribe(topic.TopicServiceResourceServer, func(data *busTY.BusData) {
+		event := &rsTY.ServiceEvent{}
+		if data.LoadData(event) != nil || event.Type != rsTY.TypeResourceAction {
+			return
+		}
+		rsData := &handlerTY.ResourceData{}
+		if event.LoadData(rsData) == nil {
+			actions <- rsData
+		}
+	})
+	require.NoError(t, err)
+
+	client := &WebhookClient{
+		HandlerCfg: &handlerTY.Config{ID: "webhook_test"},
+		Config:     config,
+		logger:     zap.NewNop(),
+		bus:        bus,
+	}
+	require.NoError(t, client.Start())
+	return client, actions
+}
+
+func webhookParameter(data interface{}) map[string]interface{} {
+	return map[string]interface{}{types.KeyType: handlerTY.DataTypeWebhook, "Data": data}
+}
+
+func TestExecuteStatusCodes(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		responseCode int
+		err          bool
+		permanent    bool
+	}{
+		{name: "ok", status: http.StatusOK},
+		{name: "created without response code", status: http.StatusCreated},
+		{name: "client error without response code", status: http.StatusNotFound, err: true, permanent: true},
+		{name: "unauthorized without response code", status: http.StatusUnauthorized, err: true, permanent: true},
+		{name: "expected response code", status: http.StatusCreated, responseCode: http.StatusCreated},
+		{name: "too many requests", status: http.StatusTooManyRequests, err: true},
+		{name: "server error", status: http.StatusServiceUnavailable, err: true},
+		{name: "server error with response code", status: http.StatusBadGateway, responseCode: http.StatusOK, err: true},
+		{name: "unexpected response code", status: http.StatusBadRequest, responseCode: http.StatusOK, err: true, permanent: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := newTestServer(t, "{}", test.status)
+			client, _ := newTestClient(t, &WebhookConfig{Server: server.URL, ResponseCode: test.responseCode})
+
+			parameters := map[string]interface{}{"call": webhookParameter(map[string]interface{}{"value": 1})}
+			err := client.Post(parameters)
+			// called only once, retries are handled by the handler retry policy
+			assert.Equal(t, 1, server.count())
+			if !test.err {
+				require.NoError(t, err)
+				assert.Empty(t, parameters)
+				return
+			}
+			require.Error(t, err)
+			assert.Equal(t, test.permanent, errors.Is(err, handlerTY.ErrPermanent))
+			assert.Contains(t, parameters, "call")
+		})
+	}
+}
+
+func TestPostRetryAndSigning(t *testing.T) {
+	server := newTestServer(t, "{}", http.StatusServiceUnavailable, http.StatusOK)
+	client, _ := newTestClient(t, &WebhookConfig{
+		Server:  server.URL,
+		API:     "/hook",
+		Signing: &SigningConfig{Secret: "secret", TimestampHeader: "X-Timestamp"},
+	})
+
+	parameters := map[string]interface{}{
+		"first":  webhookParameter(map[string]interface{}{"value": 1}),
+		"second": webhookParameter(map[string]interface{}{"value": 2}),
+	}
+
+	// retried till all the parameters are sent, sent parameters are not sent again
+	var err error
+	for attempt := 0; attempt < 3 && len(parameters) > 0; attempt++ {
+		err = client.Post(parameters)
+	}
+	require.NoError(t, err)
+	assert.Empty(t, parameters)
+	require.Equal(t, 3, server.count())
+
+	for index, request := range server.requests {
+		assert.Equal(t, http.MethodPost, request.Method)
+		assert.Equal(t, "/hook", request.URL.Path)
+		assert.Equal(t, "application/json", request.Header.Get("Content-Type"))
+		timestamp := request.Header.Get("X-Timestamp")
+		require.NotEmpty(t, timestamp)
+		assert.Equal(t, "sha256="+hmacHex("secret", timestamp+"."+server.bodies[index]), request.Header.Get("X-Signature-256"))
+	}
+	// the failed body sent again, parameters are sent in the map order
+	assert.Contains(t, server.bodies[1:], server.bodies[0])
+	assert.NotEqual(t, server.bodies[1], server.bodies[2])
+}
+
+func TestPostOverwritePerParameter(t *testing.T) {
+	server := newTestServer(t, "{}", http.StatusOK)
+	client, _ := newTestClient(t, &WebhookConfig{
+		Server:         server.URL,
+		API:            "/hook",
+		AllowOverwrite: true,
+		Headers:        map[string]string{"X-Source": "config"},
+	})
+
+	overwrite := webhookParameter(map[string]interface{}{"value": "overwrite"})
+	overwrite["API"] = "/other"
+	overwrite["Method"] = http.MethodPut
+	overwrite["Headers"] = map[string]string{"X-Source": "parameter", "Content-Type": "text/plain"}
+	parameters := map[string]interface{}{"overwrite": overwrite}
+	for index := 0; index < 5; index++ {
+		parameters[strconv.Itoa(index)] = webhookParameter(map[string]interface{}{"value": index})
+	}
+
+	require.NoError(t, client.Post(parameters))
+	require.Equal(t, 6, server.count())
+
+	// overrides are applied only on the parameter
+	for index, request := range server.requests {
+		if server.bodies[index] == `{"value":"overwrite"}` {
+			assert.Equal(t, "/other", request.URL.Path)
+			assert.Equal(t, http.MethodPut, request.Method)
+			assert.Equal(t, "parameter", request.Header.Get("X-Source"))
+			assert.Equal(t, "text/plain", request.Header.Get("Content-Type"))
+			continue
+		}
+		assert.Equal(t, "/hook", request.URL.Path)
+		assert.Equal(t, http.MethodPost, request.Method)
+		assert.Equal(t, "config", request.Header.Get("X-Source"))
+		assert.Equal(t, "application/json", request.Header.Get("Content-Type"))
+	}
+	assert.Equal(t, map[string]string{"X-Source": "config"}, client.Config.Headers)
+}
+
+func TestStoreResponse(t *testing.T) {
+	server := newTestServer(t, `{"sensor":{"temperature":21.5}}`, http.StatusOK)
+	client, actions := newTestClient(t, &WebhookConfig{
+		Server: server.URL,
+		StoreResponse: &handlerTY.WebhookStoreResponse{
+			QuickID:      "data_repository:weather",
+			KeyPath:      "today.temperature",
+			ResponsePath: "sensor.temperature",
+		},
+	})
+
+	require.NoError(t, client.Post(map[string]interface{}{"call": webhookParameter(nil)}))
+	select {
+	case action := <-actions:
+		assert.Equal(t, "data_repository", action.ResourceType)
+		assert.Equal(t, "weather", action.QuickID)
+		assert.Equal(t, "today.temperature", action.KeyPath)
+		assert.Equal(t, "21.5", action.Payload)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "store response action not received")
+	}
+
+	// invalid quick id is a permanent failure
+	client.Config.StoreResponse = &handlerTY.WebhookStoreResponse{QuickID: "weather"}
+	err := client.Post(map[string]interface{}{"call": webhookParameter(nil)})
+	assert.ErrorIs(t, err, handlerTY.ErrPermanent)
+}
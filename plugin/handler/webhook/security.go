@@ -0,0 +1,112 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultSignatureHeader = "X-Signature-256"
+	defaultSignaturePrefix = "sha256="
+)
+
+// SigningConfig signs the request body with HMAC-SHA256.
+// when the timestamp header is set, the signed content is "<timestamp>.<body>"
+type SigningConfig struct {
+	Secret          string
+	Header          string
+	TimestampHeader string
+	Prefix          *string // nil uses the default prefix
+}
+
+// Sign updates the signature headers
+func (sc *SigningConfig) Sign(headers map[string]string, body string) error {
+	if sc.Secret == "" {
+		return errors.New("signing secret not supplied")
+	}
+
+	content := body
+	if sc.TimestampHeader != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		headers[sc.TimestampHeader] = timestamp
+		content = fmt.Sprintf("%s.%s", timestamp, body)
+	}
+
+	mac := hmac.New(sha256.New, []byte(sc.Secret))
+	mac.Write([]byte(content))
+
+	header := sc.Header
+	if header == "" {
+		header = defaultSignatureHeader
+	}
+	prefix := defaultSignaturePrefix
+	if sc.Prefix != nil {
+		prefix = *sc.Prefix
+	}
+	headers[header] = prefix + hex.EncodeToString(mac.Sum(nil))
+	return nil
+}
+
+// TLSConfig client certificate and custom CA.
+// certificates can be supplied as file path or as PEM content
+type TLSConfig struct {
+	CertFile      string
+	KeyFile       string
+	CAFile        string
+	Certificate   string
+	PrivateKey    string
+	CACertificate string
+}
+
+// Get returns the tls config
+func (tc *TLSConfig) Get(insecure bool) (*tls.Config, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: insecure}
+
+	certPEM, err := readPEM(tc.Certificate, tc.CertFile)
+	if err != nil {
+		return nil, err
+	}
+	keyPEM, err := readPEM(tc.PrivateKey, tc.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	if len(certPEM) > 0 || len(keyPEM) > 0 {
+		certificate, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("error on loading client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	caPEM, err := readPEM(tc.CACertificate, tc.CAFile)
+	if err != nil {
+		return nil, err
+	}
+	if len(caPEM) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, errors.New("no valid certificate found on the CA")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return tlsConfig, nil
+}
+
+// readPEM returns the content, if available, else reads the file
+func readPEM(content, file string) ([]byte, error) {
+	if content != "" {
+		return []byte(content), nil
+	}
+	if file == "" {
+		return nil, nil
+	}
+	return os.ReadFile(file)
+}
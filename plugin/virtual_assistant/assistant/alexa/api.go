@@ -7,7 +7,9 @@ import (
 	"io"
 	"net/http"
 
+	entityAPI "github.com/mycontroller-org/server/v2/pkg/api/entities"
 	"github.com/mycontroller-org/server/v2/pkg/json"
+	"github.com/mycontroller-org/server/v2/pkg/utils"
 	handlerUtils "github.com/mycontroller-org/server/v2/pkg/utils/http_handler"
 	loggerUtils "github.com/mycontroller-org/server/v2/pkg/utils/logger"
 	alexaTY "github.com/mycontroller-org/server/v2/plugin/virtual_assistant/assistant/alexa/types"
@@ -22,10 +24,12 @@ const (
 )
 
 type Assistant struct {
-	ctx       context.Context
-	logger    *zap.Logger
-	cfg       *vaTY.Config
-	deviceAPI *deviceAPI.DeviceAPI
+	ctx          context.Context
+	logger       *zap.Logger
+	cfg          *vaTY.Config
+	api          *entityAPI.API
+	deviceAPI    *deviceAPI.DeviceAPI
+	eventGateway *eventGatewayClient
 }
 
 func New(ctx context.Context, cfg *vaTY.Config) (vaTY.Plugin, error) {
@@ -34,16 +38,39 @@ func New(ctx context.Context, cfg *vaTY.Config) (vaTY.Plugin, error) {
 		return nil, err
 	}
 
+	api, err := entityAPI.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	_deviceAPI, err := deviceAPI.New(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return &Assistant{
+
+	alexaCfg := &alexaTY.Config{}
+	err = utils.MapToStruct(utils.TagNameNone, cfg.Config, alexaCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	assistant := &Assistant{
 		ctx:       ctx,
 		logger:    logger.Named(loggerName),
 		cfg:       cfg,
+		api:       api,
 		deviceAPI: _deviceAPI,
-	}, nil
+	}
+
+	if alexaCfg.ProactiveReport {
+		eventGateway, err := newEventGatewayClient(alexaCfg)
+		if err != nil {
+			return nil, err
+		}
+		assistant.eventGateway = eventGateway
+	}
+
+	return assistant, nil
 }
 func (a *Assistant) Name() string {
 	return PluginAlexaAssistant
@@ -88,6 +115,8 @@ func (a *Assistant) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		response = _response
+	} else if request.Directive.Header.Namespace == alexaTY.NamespaceAuthorization && request.Directive.Header.Name == alexaTY.NameAcceptGrant {
+		response = a.executeAcceptGrant(request.Directive)
 	} else if request.Directive.Header.Namespace == alexaTY.NamespaceDiscovery {
 		_response, err := a.executeDiscover(request.Directive)
 		if err != nil {
@@ -2,6 +2,7 @@ package alexa
 
 import (
 	"github.com/mycontroller-org/server/v2/pkg/types/cmap"
+	vdTY "github.com/mycontroller-org/server/v2/pkg/types/virtual_device"
 	"github.com/mycontroller-org/server/v2/pkg/version"
 	alexaTY "github.com/mycontroller-org/server/v2/plugin/virtual_assistant/assistant/alexa/types"
 	"go.uber.org/zap"
@@ -19,45 +20,8 @@ func (a *Assistant) executeDiscover(directive alexaTY.DirectiveOrEvent) (interfa
 	}
 
 	endpoints := make([]alexaTY.Endpoint, 0)
-
-	ver := version.Get()
-	for _, vDevice := range vDevices {
-		capabilities := make([]alexaTY.Capability, 0)
-		for _, vResource := range vDevice.Traits {
-			if aInterface, found := alexaTY.TraitControllerMap[vResource.TraitType]; found {
-				properties := alexaTY.GetInterfaceProperties(aInterface)
-				capabilities = append(capabilities, alexaTY.Capability{
-					Type:       "AlexaInterface",
-					Interface:  aInterface,
-					Version:    "3",
-					Properties: &properties,
-				})
-
-			} else {
-				a.logger.Info("trait not found in the defined map", zap.String("virtualDeviceId", vDevice.ID), zap.String("virtualDeviceName", vDevice.Name), zap.String("trait", vResource.TraitType))
-			}
-		}
-
-		// add alex capability
-		capabilities = append(capabilities, alexaTY.Capability{
-			Type:      "AlexaInterface",
-			Interface: "Alexa",
-			Version:   "3",
-		})
-
-		endpoints = append(endpoints, alexaTY.Endpoint{
-			EndpointID:        vDevice.ID,
-			ManufacturerName:  "MyController",
-			Description:       vDevice.Description,
-			FriendlyName:      vDevice.Name,
-			DisplayCategories: alexaTY.GetDisplayCategory(vDevice.DeviceType),
-			AdditionalAttributes: &alexaTY.AdditionalAttributes{
-				Manufacturer:    "MyController",
-				SoftwareVersion: ver.Version,
-			},
-			Capabilities: capabilities,
-			Cookie:       cmap.CustomStringMap{},
-		})
+	for index := range vDevices {
+		endpoints = append(endpoints, a.getEndpoint(&vDevices[index]))
 	}
 
 	response := alexaTY.Response{
@@ -76,3 +40,44 @@ func (a *Assistant) executeDiscover(directive alexaTY.DirectiveOrEvent) (interfa
 
 	return &response, nil
 }
+
+// returns alexa endpoint of the virtual device
+func (a *Assistant) getEndpoint(vDevice *vdTY.VirtualDevice) alexaTY.Endpoint {
+	capabilities := make([]alexaTY.Capability, 0)
+	for _, vResource := range vDevice.Traits {
+		if aInterface, found := alexaTY.TraitControllerMap[vResource.TraitType]; found {
+			properties := alexaTY.GetInterfaceProperties(aInterface)
+			capabilities = append(capabilities, alexaTY.Capability{
+				Type:       "AlexaInterface",
+				Interface:  aInterface,
+				Version:    "3",
+				Properties: &properties,
+			})
+
+		} else {
+			a.logger.Info("trait not found in the defined map", zap.String("virtualDeviceId", vDevice.ID), zap.String("virtualDeviceName", vDevice.Name), zap.String("trait", vResource.TraitType))
+		}
+	}
+
+	// add alex capability
+	capabilities = append(capabilities, alexaTY.Capability{
+		Type:      "AlexaInterface",
+		Interface: "Alexa",
+		Version:   "3",
+	})
+
+	ver := version.Get()
+	return alexaTY.Endpoint{
+		EndpointID:        vDevice.ID,
+		ManufacturerName:  "MyController",
+		Description:       vDevice.Description,
+		FriendlyName:      vDevice.Name,
+		DisplayCategories: alexaTY.GetDisplayCategory(vDevice.DeviceType),
+		AdditionalAttributes: &alexaTY.AdditionalAttributes{
+			Manufacturer:    "MyController",
+			SoftwareVersion: ver.Version,
+		},
+		Capabilities: capabilities,
+		Cookie:       cmap.CustomStringMap{},
+	}
+}
@@ -0,0 +1,125 @@
+package alexa
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mycontroller-org/server/v2/pkg/json"
+	httpClient "github.com/mycontroller-org/server/v2/pkg/utils/http_client_json"
+	alexaTY "github.com/mycontroller-org/server/v2/plugin/virtual_assistant/assistant/alexa/types"
+)
+
+const (
+	tokenRenewalAhead = time.Minute
+)
+
+// eventGatewayClient sends events to alexa event gateway
+// https://developer.amazon.com/en-US/docs/alexa/smarthome/send-events-to-the-alexa-event-gateway.html
+type eventGatewayClient struct {
+	cfg          *alexaTY.Config
+	client       *httpClient.Client
+	accessToken  string
+	refreshToken string
+	expiresAt    time.Time
+	mutex        sync.Mutex
+}
+
+func newEventGatewayClient(cfg *alexaTY.Config) (*eventGatewayClient, error) {
+	if cfg.ClientID == "" || cfg.ClientSecret == "" {
+		return nil, errors.New("clientId and clientSecret are required to send proactive reports")
+	}
+	if cfg.TokenURL == "" {
+		cfg.TokenURL = alexaTY.DefaultTokenURL
+	}
+	if cfg.EventGatewayURL == "" {
+		cfg.EventGatewayURL = alexaTY.DefaultEventGatewayURL
+	}
+	if cfg.Timeout == "" {
+		cfg.Timeout = alexaTY.DefaultTimeout
+	}
+
+	return &eventGatewayClient{
+		cfg:          cfg,
+		client:       httpClient.New(cfg.Insecure, cfg.Timeout),
+		refreshToken: cfg.RefreshToken,
+	}, nil
+}
+
+// sendEvent posts the event to the event gateway
+func (eg *eventGatewayClient) sendEvent(event *alexaTY.Response, token string) error {
+	headers := map[string]string{"Authorization": fmt.Sprintf("Bearer %s", token)}
+	_, err := eg.client.ExecuteJson(eg.cfg.EventGatewayURL, http.MethodPost, headers, nil, event, http.StatusAccepted)
+	return err
+}
+
+// acceptGrant exchanges the authorization code and returns the refresh token
+// https://developer.amazon.com/en-US/docs/alexa/authorization/obtain-customer-tokens-grant-code.html
+func (eg *eventGatewayClient) acceptGrant(code string) (string, error) {
+	eg.mutex.Lock()
+	defer eg.mutex.Unlock()
+
+	formData := url.Values{}
+	formData.Set("grant_type", "authorization_code")
+	formData.Set("code", code)
+	err := eg.updateToken(formData)
+	if err != nil {
+		return "", err
+	}
+	return eg.refreshToken, nil
+}
+
+// returns access token, renews it when expired
+func (eg *eventGatewayClient) getAccessToken() (string, error) {
+	eg.mutex.Lock()
+	defer eg.mutex.Unlock()
+
+	if eg.accessToken != "" && time.Now().Before(eg.expiresAt) {
+		return eg.accessToken, nil
+	}
+
+	if eg.refreshToken == "" {
+		return "", errors.New("refresh token not available, enable the skill again to receive AcceptGrant directive")
+	}
+
+	formData := url.Values{}
+	formData.Set("grant_type", "refresh_token")
+	formData.Set("refresh_token", eg.refreshToken)
+	err := eg.updateToken(formData)
+	if err != nil {
+		return "", err
+	}
+	return eg.accessToken, nil
+}
+
+func (eg *eventGatewayClient) updateToken(formData url.Values) error {
+	formData.Set("client_id", eg.cfg.ClientID)
+	formData.Set("client_secret", eg.cfg.ClientSecret)
+	headers := map[string]string{"Content-Type": "application/x-www-form-urlencoded;charset=UTF-8"}
+
+	now := time.Now()
+	response, err := eg.client.Execute(eg.cfg.TokenURL, http.MethodPost, headers, nil, formData.Encode(), http.StatusOK)
+	if err != nil {
+		return err
+	}
+
+	tokenResponse := alexaTY.TokenResponse{}
+	err = json.Unmarshal(response.Body, &tokenResponse)
+	if err != nil {
+		return err
+	}
+	if tokenResponse.AccessToken == "" {
+		return errors.New("access token not received")
+	}
+
+	eg.accessToken = tokenResponse.AccessToken
+	if strings.TrimSpace(tokenResponse.RefreshToken) != "" {
+		eg.refreshToken = tokenResponse.RefreshToken
+	}
+	eg.expiresAt = now.Add(time.Duration(tokenResponse.ExpiresIn)*time.Second - tokenRenewalAhead)
+	return nil
+}
@@ -0,0 +1,235 @@
+package alexa
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	entityAPI "github.com/mycontroller-org/server/v2/pkg/api/entities"
+	encryptionAPI "github.com/mycontroller-org/server/v2/pkg/encryption"
+	"github.com/mycontroller-org/server/v2/pkg/json"
+	coreScheduler "github.com/mycontroller-org/server/v2/pkg/service/core_scheduler"
+	"github.com/mycontroller-org/server/v2/pkg/types/cmap"
+	eventTY "github.com/mycontroller-org/server/v2/pkg/types/event"
+	schedulerTY "github.com/mycontroller-org/server/v2/pkg/types/scheduler"
+	vdTY "github.com/mycontroller-org/server/v2/pkg/types/virtual_device"
+	loggerUtils "github.com/mycontroller-org/server/v2/pkg/utils/logger"
+	"github.com/mycontroller-org/server/v2/plugin/bus/embedded"
+	busTY "github.com/mycontroller-org/server/v2/plugin/bus/types"
+	"github.com/mycontroller-org/server/v2/plugin/database/storage/memory"
+	storageTY "github.com/mycontroller-org/server/v2/plugin/database/storage/types"
+	alexaTY "github.com/mycontroller-org/server/v2/plugin/virtual_assistant/assistant/alexa/types"
+	deviceAPI "github.com/mycontroller-org/server/v2/plugin/virtual_assistant/device_api"
+	vaTY "github.com/mycontroller-org/server/v2/plugin/virtual_assistant/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// eventGatewayServer serves the login with amazon token and the event gateway api
+type eventGatewayServer struct {
+	*httptest.Server
+	mutex       sync.Mutex
+	tokenForms  []url.Values
+	events      []map[string]interface{}
+	authHeaders []string
+}
+
+func newEventGatewayServer(t *testing.T) *eventGatewayServer {
+	server := &eventGatewayServer{}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		server.mutex.Lock()
+		defer server.mutex.Unlock()
+
+		if r.URL.Path == "/token" {
+			form, _ := url.ParseQuery(string(body))
+			server.tokenForms = append(server.tokenForms, form)
+			if form.Get("client_id") != "client_id" || form.Get("client_secret") != "client_secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			response := `{"access_token":"access_token_1","refresh_token":"refresh_token_1","token_type":"bearer","expires_in":3600}`
+			if form.Get("grant_type") == "refresh_token" {
+				// refresh token not returned on renewal
+				response = `{"access_token":"access_token_2","token_type":"bearer","expires_in":3600}`
+			}
+			_, _ = w.Write([]byte(response))
+			return
+		}
+
+		event := map[string]interface{}{}
+		_ = json.Unmarshal(body, &event)
+		server.events = append(server.events, event)
+		server.authHeaders = append(server.authHeaders, r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func (s *eventGatewayServer) getEvents() []map[string]interface{} {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.events
+}
+
+func newTestEventGateway(t *testing.T, server *eventGatewayServer, refreshToken string) *eventGatewayClient {
+	eventGateway, err := newEventGatewayClient(&alexaTY.Config{
+		ClientID:        "client_id",
+		ClientSecret:    "client_secret",
+		RefreshToken:    refreshToken,
+		TokenURL:        server.URL + "/token",
+		EventGatewayURL: server.URL + "/v3/events",
+	})
+	require.NoError(t, err)
+	return eventGateway
+}
+
+func TestEventGatewayTokenExchange(t *testing.T) {
+	server := newEventGatewayServer(t)
+	eventGateway := newTestEventGateway(t, server, "")
+
+	_, err := eventGateway.getAccessToken()
+	assert.ErrorContains(t, err, "refresh token not available")
+
+	// authorization code exchanged on accept grant
+	refreshToken, err := eventGateway.acceptGrant("code_1")
+	require.NoError(t, err)
+	assert.Equal(t, "refresh_token_1", refreshToken)
+	require.Len(t, server.tokenForms, 1)
+	assert.Equal(t, "authorization_code", server.tokenForms[0].Get("grant_type"))
+	assert.Equal(t, "code_1", server.tokenForms[0].Get("code"))
+
+	// cached till the expiry
+	token, err := eventGateway.getAccessToken()
+	require.NoError(t, err)
+	assert.Equal(t, "access_token_1", token)
+	assert.Len(t, server.tokenForms, 1)
+
+	// renewed with the refresh token, the existing refresh token retained
+	eventGateway.accessToken = ""
+	token, err = eventGateway.getAccessToken()
+	require.NoError(t, err)
+	assert.Equal(t, "access_token_2", token)
+	require.Len(t, server.tokenForms, 2)
+	assert.Equal(t, "refresh_token", server.tokenForms[1].Get("grant_type"))
+	assert.Equal(t, "refresh_token_1", server.tokenForms[1].Get("refresh_token"))
+	assert.Equal(t, "refresh_token_1", eventGateway.refreshToken)
+
+	// invalid client credentials
+	_, err = newEventGatewayClient(&alexaTY.Config{ClientID: "client_id"})
+	assert.Error(t, err)
+	invalid := newTestEventGateway(t, server, "refresh_token_1")
+	invalid.cfg.ClientSecret = "invalid"
+	_, err = invalid.getAccessToken()
+	assert.Error(t, err)
+}
+
+func newTestAssistant(t *testing.T, server *eventGatewayServer) (*Assistant, *entityAPI.API) {
+	ctx := loggerUtils.WithContext(context.Background(), zap.NewNop())
+	ctx = schedulerTY.WithContext(ctx, coreScheduler.New())
+
+	storage, err := memory.New(ctx, cmap.CustomMap{})
+	require.NoError(t, err)
+	ctx = storageTY.WithContext(ctx, storage)
+
+	bus, err := embedded.NewClient(ctx, cmap.CustomMap{})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = bus.Close() })
+	ctx = busTY.WithContext(ctx, bus)
+	ctx = encryptionAPI.WithContext(ctx, encryptionAPI.New(zap.NewNop(), "0123456789abcdef0123456789abcdef", nil, ""))
+
+	api, err := entityAPI.New(ctx)
+	require.NoError(t, err)
+	ctx = entityAPI.WithContext(ctx, api)
+
+	_deviceAPI, err := deviceAPI.New(ctx)
+	require.NoError(t, err)
+
+	return &Assistant{
+		ctx:          ctx,
+		logger:       zap.NewNop(),
+		cfg:          &vaTY.Config{ID: "alexa", DeviceFilter: cmap.CustomStringMap{"location": "home"}},
+		api:          api,
+		deviceAPI:    _deviceAPI,
+		eventGateway: newTestEventGateway(t, server, "refresh_token_1"),
+	}, api
+}
+
+func TestRequestSyncReports(t *testing.T) {
+	server := newEventGatewayServer(t)
+	assistant, api := newTestAssistant(t, server)
+
+	lamp := &vdTY.VirtualDevice{
+		ID:         "lamp",
+		Name:       "Lamp",
+		Enabled:    true,
+		DeviceType: vdTY.DeviceTypeLight,
+		Labels:     cmap.CustomStringMap{"location": "home"},
+		Traits: []vdTY.Resource{
+			{Name: "power", TraitType: vdTY.DeviceTraitOnOff, ResourceType: "field", QuickID: "gw.node.source.power"},
+		},
+	}
+	require.NoError(t, api.VirtualDevice().Save(lamp))
+
+	// add or update report with the endpoint details
+	require.NoError(t, assistant.RequestSync(eventTY.TypeCreated, lamp))
+	events := server.getEvents()
+	require.Len(t, events, 1)
+	event := events[0]["event"].(map[string]interface{})
+	header := event["header"].(map[string]interface{})
+	assert.Equal(t, alexaTY.NamespaceDiscovery, header["namespace"])
+	assert.Equal(t, alexaTY.NameAddOrUpdateReport, header["name"])
+	assert.Equal(t, "3", header["payloadVersion"])
+	assert.NotEmpty(t, header["messageId"])
+
+	payload := event["payload"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"type": alexaTY.ScopeTypeBearerToken, "token": "access_token_2"}, payload["scope"])
+	endpoints := payload["endpoints"].([]interface{})
+	require.Len(t, endpoints, 1)
+	endpoint := endpoints[0].(map[string]interface{})
+	assert.Equal(t, "lamp", endpoint["endpointId"])
+	assert.Equal(t, "Lamp", endpoint["friendlyName"])
+	interfaces := []string{}
+	for _, capability := range endpoint["capabilities"].([]interface{}) {
+		interfaces = append(interfaces, capability.(map[string]interface{})["interface"].(string))
+	}
+	assert.Equal(t, []string{alexaTY.NamespacePowerController, "Alexa"}, interfaces)
+	assert.Equal(t, "Bearer access_token_2", server.authHeaders[0])
+
+	// disabled device removed with delete report
+	lamp.Enabled = false
+	require.NoError(t, api.VirtualDevice().Save(lamp))
+	require.NoError(t, assistant.RequestSync(eventTY.TypeUpdated, lamp))
+	events = server.getEvents()
+	require.Len(t, events, 2)
+	assertDeleteReport(t, events[1], "lamp")
+
+	// deleted device
+	require.NoError(t, assistant.RequestSync(eventTY.TypeDeleted, lamp))
+	events = server.getEvents()
+	require.Len(t, events, 3)
+	assertDeleteReport(t, events[2], "lamp")
+
+	// devices out of the device filter are not reported
+	other := &vdTY.VirtualDevice{ID: "other", Enabled: true, DeviceType: vdTY.DeviceTypeLight}
+	require.NoError(t, api.VirtualDevice().Save(other))
+	require.NoError(t, assistant.RequestSync(eventTY.TypeCreated, other))
+	require.NoError(t, assistant.RequestSync(eventTY.TypeDeleted, other))
+	assert.Len(t, server.getEvents(), 3)
+}
+
+func assertDeleteReport(t *testing.T, data map[string]interface{}, endpointID string) {
+	event := data["event"].(map[string]interface{})
+	header := event["header"].(map[string]interface{})
+	assert.Equal(t, alexaTY.NamespaceDiscovery, header["namespace"])
+	assert.Equal(t, alexaTY.NameDeleteReport, header["name"])
+	payload := event["payload"].(map[string]interface{})
+	assert.Equal(t, []interface{}{map[string]interface{}{"endpointId": endpointID}}, payload["endpoints"])
+	assert.Equal(t, map[string]interface{}{"type": alexaTY.ScopeTypeBearerToken, "token": "access_token_2"}, payload["scope"])
+}
@@ -0,0 +1,200 @@
+package alexa
+
+import (
+	"strings"
+
+	"github.com/mycontroller-org/server/v2/pkg/types"
+	eventTY "github.com/mycontroller-org/server/v2/pkg/types/event"
+	vdTY "github.com/mycontroller-org/server/v2/pkg/types/virtual_device"
+	"github.com/mycontroller-org/server/v2/pkg/utils"
+	storageTY "github.com/mycontroller-org/server/v2/plugin/database/storage/types"
+	alexaTY "github.com/mycontroller-org/server/v2/plugin/virtual_assistant/assistant/alexa/types"
+	deviceAPI "github.com/mycontroller-org/server/v2/plugin/virtual_assistant/device_api"
+	"go.uber.org/zap"
+)
+
+// ReportState sends ChangeReport event for the devices
+// https://developer.amazon.com/en-US/docs/alexa/smarthome/state-reporting-for-a-smart-home-skill.html
+func (a *Assistant) ReportState(deviceIDs []string) error {
+	if a.eventGateway == nil || len(deviceIDs) == 0 {
+		return nil
+	}
+
+	filters := []storageTY.Filter{{Key: types.KeyID, Operator: storageTY.OperatorIn, Value: deviceIDs}}
+	vDevices, err := a.deviceAPI.ListDevices(filters, int64(len(deviceIDs)), 0, a.cfg.DeviceFilter)
+	if err != nil {
+		return err
+	}
+	if len(vDevices) == 0 {
+		return nil
+	}
+
+	// update resource state
+	err = a.deviceAPI.UpdateDeviceState(vDevices)
+	if err != nil {
+		return err
+	}
+
+	token, err := a.eventGateway.getAccessToken()
+	if err != nil {
+		return err
+	}
+
+	for index := range vDevices {
+		vDevice := &vDevices[index]
+		properties := a.getProperties(vDevice)
+		if len(properties) == 0 {
+			continue
+		}
+		event := &alexaTY.Response{
+			Event: alexaTY.DirectiveOrEvent{
+				Header: getEventHeader(alexaTY.NamespaceAlexa, alexaTY.NameChangeReport),
+				Endpoint: &alexaTY.DirectiveEndpoint{
+					Scope:      alexaTY.Scope{Type: alexaTY.ScopeTypeBearerToken, Token: token},
+					EndpointID: vDevice.ID,
+				},
+				Payload: map[string]interface{}{
+					"change": map[string]interface{}{
+						"cause":      map[string]interface{}{"type": alexaTY.CauseTypePhysicalInteraction},
+						"properties": properties,
+					},
+				},
+			},
+			Context: &alexaTY.Context{Properties: []alexaTY.Property{}},
+		}
+		err = a.eventGateway.sendEvent(event, token)
+		if err != nil {
+			a.logger.Error("error on sending change report", zap.String("deviceId", vDevice.ID), zap.String("deviceName", vDevice.Name), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// RequestSync sends AddOrUpdateReport or DeleteReport event for the device
+// https://developer.amazon.com/en-US/docs/alexa/device-apis/alexa-discovery.html#add-or-update-report
+func (a *Assistant) RequestSync(eventType string, vDevice *vdTY.VirtualDevice) error {
+	if a.eventGateway == nil {
+		return nil
+	}
+
+	deleted := eventType == eventTY.TypeDeleted
+	var endpoint interface{}
+
+	if deleted {
+		if !deviceAPI.IsDeviceFilterMatching(a.cfg.DeviceFilter, vDevice) {
+			return nil
+		}
+		endpoint = map[string]interface{}{"endpointId": vDevice.ID}
+	} else {
+		filters := []storageTY.Filter{{Key: types.KeyID, Operator: storageTY.OperatorEqual, Value: vDevice.ID}}
+		vDevices, err := a.deviceAPI.ListDevices(filters, 1, 0, a.cfg.DeviceFilter)
+		if err != nil {
+			return err
+		}
+		if len(vDevices) == 0 {
+			// device disabled or moved out of the device filter
+			if eventType != eventTY.TypeUpdated {
+				return nil
+			}
+			deleted = true
+			endpoint = map[string]interface{}{"endpointId": vDevice.ID}
+		} else {
+			endpoint = a.getEndpoint(&vDevices[0])
+		}
+	}
+
+	token, err := a.eventGateway.getAccessToken()
+	if err != nil {
+		return err
+	}
+
+	name := alexaTY.NameAddOrUpdateReport
+	if deleted {
+		name = alexaTY.NameDeleteReport
+	}
+
+	event := &alexaTY.Response{
+		Event: alexaTY.DirectiveOrEvent{
+			Header: getEventHeader(alexaTY.NamespaceDiscovery, name),
+			Payload: map[string]interface{}{
+				"endpoints": []interface{}{endpoint},
+				"scope":     alexaTY.Scope{Type: alexaTY.ScopeTypeBearerToken, Token: token},
+			},
+		},
+	}
+	return a.eventGateway.sendEvent(event, token)
+}
+
+// executeAcceptGrant receives the authorization code, when the skill is enabled.
+// the code is exchanged for tokens and the refresh token is stored on the assistant config
+// https://developer.amazon.com/en-US/docs/alexa/device-apis/alexa-authorization.html
+func (a *Assistant) executeAcceptGrant(directive alexaTY.DirectiveOrEvent) *alexaTY.Response {
+	if a.eventGateway == nil {
+		return getAcceptGrantErrorResponse("proactive report is not enabled")
+	}
+
+	grant, _ := directive.Payload["grant"].(map[string]interface{})
+	code, _ := grant["code"].(string)
+	if code == "" {
+		return getAcceptGrantErrorResponse("authorization code not received")
+	}
+
+	refreshToken, err := a.eventGateway.acceptGrant(code)
+	if err != nil {
+		a.logger.Error("error on getting tokens", zap.Error(err))
+		return getAcceptGrantErrorResponse("failed to get tokens")
+	}
+
+	err = a.saveRefreshToken(refreshToken)
+	if err != nil {
+		a.logger.Error("error on saving refresh token", zap.Error(err))
+		return getAcceptGrantErrorResponse("failed to save refresh token")
+	}
+
+	return &alexaTY.Response{
+		Event: alexaTY.DirectiveOrEvent{
+			Header:  getEventHeader(alexaTY.NamespaceAuthorization, alexaTY.NameAcceptGrantResponse),
+			Payload: map[string]interface{}{},
+		},
+	}
+}
+
+// stores the refresh token on the assistant config, to be used after restart
+func (a *Assistant) saveRefreshToken(refreshToken string) error {
+	cfg, err := a.api.VirtualAssistant().GetByID(a.cfg.ID)
+	if err != nil {
+		return err
+	}
+	if cfg.Config == nil {
+		cfg.Config = make(map[string]interface{})
+	}
+	// remove the existing entry, in any case
+	for key := range cfg.Config {
+		if strings.EqualFold(key, alexaTY.ConfigKeyRefreshToken) {
+			delete(cfg.Config, key)
+		}
+	}
+	cfg.Config[alexaTY.ConfigKeyRefreshToken] = refreshToken
+	return a.api.VirtualAssistant().Save(cfg)
+}
+
+func getEventHeader(namespace, name string) alexaTY.Header {
+	return alexaTY.Header{
+		Namespace:      namespace,
+		Name:           name,
+		MessageID:      utils.RandUUID(),
+		PayloadVersion: "3",
+	}
+}
+
+func getAcceptGrantErrorResponse(message string) *alexaTY.Response {
+	return &alexaTY.Response{
+		Event: alexaTY.DirectiveOrEvent{
+			Header: getEventHeader(alexaTY.NamespaceAuthorization, alexaTY.NameAcceptGrantErrorResponse),
+			Payload: map[string]interface{}{
+				"type":    alexaTY.ErrorTypeAcceptGrantFailed,
+				"message": message,
+			},
+		},
+	}
+}
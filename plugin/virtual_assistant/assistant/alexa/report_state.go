@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/mycontroller-org/server/v2/pkg/types"
+	vdTY "github.com/mycontroller-org/server/v2/pkg/types/virtual_device"
 	"github.com/mycontroller-org/server/v2/pkg/utils"
 	storageTY "github.com/mycontroller-org/server/v2/plugin/database/storage/types"
 	alexaTY "github.com/mycontroller-org/server/v2/plugin/virtual_assistant/assistant/alexa/types"
@@ -32,6 +33,27 @@ func (a *Assistant) reportState(directive alexaTY.DirectiveOrEvent) (interface{}
 
 	vDevice := vDevices[0]
 
+	properties := a.getProperties(&vDevice)
+
+	response := alexaTY.Response{
+		Event: alexaTY.DirectiveOrEvent{
+			Header:   directive.Header,
+			Endpoint: directive.Endpoint,
+		},
+		Context: &alexaTY.Context{Properties: properties},
+	}
+
+	// update header name
+	response.Event.Header.Name = alexaTY.ResponseStateReport
+
+	// update message id
+	response.Event.Header.MessageID = utils.RandUUID()
+
+	return &response, nil
+}
+
+// returns properties of the virtual device, resource values should be updated before calling this
+func (a *Assistant) getProperties(vDevice *vdTY.VirtualDevice) []alexaTY.Property {
 	properties := make([]alexaTY.Property, 0)
 
 	for _, vResource := range vDevice.Traits {
@@ -55,20 +77,5 @@ func (a *Assistant) reportState(directive alexaTY.DirectiveOrEvent) (interface{}
 			TimeOfSample: vResource.ValueTimestamp.Format(time.RFC3339),
 		})
 	}
-
-	response := alexaTY.Response{
-		Event: alexaTY.DirectiveOrEvent{
-			Header:   directive.Header,
-			Endpoint: directive.Endpoint,
-		},
-		Context: &alexaTY.Context{Properties: properties},
-	}
-
-	// update header name
-	response.Event.Header.Name = alexaTY.ResponseStateReport
-
-	// update message id
-	response.Event.Header.MessageID = utils.RandUUID()
-
-	return &response, nil
+	return properties
 }
@@ -0,0 +1,49 @@
+package types
+
+// defaults
+const (
+	DefaultTokenURL        = "https://api.amazon.com/auth/o2/token"
+	DefaultEventGatewayURL = "https://api.amazonalexa.com/v3/events" // north america, for europe: https://api.eu.amazonalexa.com/v3/events
+	DefaultTimeout         = "30s"
+)
+
+const (
+	NamespaceAlexa         = "Alexa"
+	NamespaceAuthorization = "Alexa.Authorization"
+
+	NameChangeReport             = "ChangeReport"
+	NameAcceptGrant              = "AcceptGrant"
+	NameAcceptGrantResponse      = "AcceptGrant.Response"
+	NameAcceptGrantErrorResponse = "AcceptGrant.ErrorResponse"
+	NameAddOrUpdateReport        = "AddOrUpdateReport"
+	NameDeleteReport             = "DeleteReport"
+
+	ErrorTypeAcceptGrantFailed = "ACCEPT_GRANT_FAILED"
+
+	ScopeTypeBearerToken = "BearerToken"
+
+	CauseTypePhysicalInteraction = "PHYSICAL_INTERACTION"
+
+	ConfigKeyRefreshToken = "refreshToken"
+)
+
+// Config of alexa assistant
+type Config struct {
+	ProactiveReport bool   `json:"proactiveReport" yaml:"proactiveReport"` // enables change report, add or update report and delete report events
+	ClientID        string `json:"clientId" yaml:"clientId"`               // skill messaging client id
+	ClientSecret    string `json:"clientSecret" yaml:"clientSecret"`       // skill messaging client secret
+	RefreshToken    string `json:"refreshToken" yaml:"refreshToken"`       // updated on AcceptGrant directive
+	TokenURL        string `json:"tokenUrl" yaml:"tokenUrl"`               // defaults to login with amazon token url
+	EventGatewayURL string `json:"eventGatewayUrl" yaml:"eventGatewayUrl"` // defaults to north america event gateway url
+	Insecure        bool   `json:"insecure" yaml:"insecure"`               // skips the certificate verification on the above urls
+	Timeout         string `json:"timeout" yaml:"timeout"`                 // http request timeout
+}
+
+// TokenResponse struct, from login with amazon
+// https://developer.amazon.com/en-US/docs/alexa/authorization/obtain-customer-tokens-grant-code.html
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
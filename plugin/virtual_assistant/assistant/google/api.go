@@ -4,8 +4,11 @@ import (
 	"context"
 	"io"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/mycontroller-org/server/v2/pkg/json"
+	"github.com/mycontroller-org/server/v2/pkg/utils"
 	handlerUtils "github.com/mycontroller-org/server/v2/pkg/utils/http_handler"
 	loggerUtils "github.com/mycontroller-org/server/v2/pkg/utils/logger"
 	gaTY "github.com/mycontroller-org/server/v2/plugin/virtual_assistant/assistant/google/types"
@@ -27,7 +30,11 @@ type Assistant struct {
 	ctx       context.Context
 	logger    *zap.Logger
 	cfg       *vaTY.Config
+	gaCfg     *gaTY.Config
 	deviceAPI *deviceAPI.DeviceAPI
+	homeGraph *homeGraphClient
+	syncTimer *time.Timer
+	syncMutex sync.Mutex
 }
 
 func New(ctx context.Context, cfg *vaTY.Config) (vaTY.Plugin, error) {
@@ -40,12 +47,33 @@ func New(ctx context.Context, cfg *vaTY.Config) (vaTY.Plugin, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &Assistant{
+
+	gaCfg := &gaTY.Config{}
+	err = utils.MapToStruct(utils.TagNameNone, cfg.Config, gaCfg)
+	if err != nil {
+		return nil, err
+	}
+	if gaCfg.AgentUserID == "" {
+		gaCfg.AgentUserID = AgentUserId
+	}
+
+	assistant := &Assistant{
 		ctx:       ctx,
 		logger:    logger.Named(loggerName),
 		cfg:       cfg,
+		gaCfg:     gaCfg,
 		deviceAPI: _deviceAPI,
-	}, nil
+	}
+
+	if gaCfg.ReportState {
+		homeGraph, err := newHomeGraphClient(gaCfg)
+		if err != nil {
+			return nil, err
+		}
+		assistant.homeGraph = homeGraph
+	}
+
+	return assistant, nil
 }
 
 func (a *Assistant) Name() string {
@@ -57,6 +85,7 @@ func (a *Assistant) Start() error {
 }
 
 func (a *Assistant) Stop() error {
+	a.stopRequestSync()
 	return nil
 }
 
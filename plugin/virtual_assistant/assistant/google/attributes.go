@@ -0,0 +1,124 @@
+package google_assistant
+
+import (
+	"strings"
+
+	vdTY "github.com/mycontroller-org/server/v2/pkg/types/virtual_device"
+	deviceAPI "github.com/mycontroller-org/server/v2/plugin/virtual_assistant/device_api"
+)
+
+const (
+	defaultThermostatMode = "heat"
+)
+
+// returns the sync attributes of the device traits
+func getDeviceAttributes(vDevice *vdTY.VirtualDevice) map[string]interface{} {
+	attributes := make(map[string]interface{})
+	updatedTraits := make(map[string]bool)
+
+	for index := range vDevice.Traits {
+		resource := &vDevice.Traits[index]
+		switch resource.TraitType {
+		case vdTY.DeviceTraitColorSetting: // https://developers.google.com/assistant/smarthome/traits/colorsetting#device-attributes
+			switch deviceAPI.GetTraitParameter(resource) {
+			case vdTY.TraitParameterRGB:
+				attributes["colorModel"] = "rgb"
+			case vdTY.TraitParameterColorTemperature:
+				minK, maxK := getColorTemperatureRange(resource)
+				attributes["colorTemperatureRange"] = map[string]interface{}{"temperatureMinK": minK, "temperatureMaxK": maxK}
+			}
+
+		case vdTY.DeviceTraitFanSpeed: // https://developers.google.com/assistant/smarthome/traits/fanspeed#device-attributes
+			values := deviceAPI.GetTraitValues(resource)
+			if len(values) > 0 {
+				speeds := make([]map[string]interface{}, 0)
+				for _, value := range values {
+					speeds = append(speeds, map[string]interface{}{"speed_name": value, "speed_values": getSynonyms("speed_synonym", value)})
+				}
+				attributes["availableFanSpeeds"] = map[string]interface{}{"speeds": speeds, "ordered": true}
+			} else {
+				attributes["supportsFanSpeedPercent"] = true
+			}
+
+		case vdTY.DeviceTraitArmDisarm: // https://developers.google.com/assistant/smarthome/traits/armdisarm#device-attributes
+			if deviceAPI.GetTraitParameter(resource) == vdTY.TraitParameterArmLevel {
+				levels := make([]map[string]interface{}, 0)
+				for _, value := range deviceAPI.GetTraitValues(resource) {
+					levels = append(levels, map[string]interface{}{"level_name": value, "level_values": getSynonyms("level_synonym", value)})
+				}
+				attributes["availableArmLevels"] = map[string]interface{}{"levels": levels, "ordered": true}
+			}
+
+		case vdTY.DeviceTraitStartStop: // https://developers.google.com/assistant/smarthome/traits/startstop#device-attributes
+			if deviceAPI.GetTraitParameter(resource) == vdTY.TraitParameterPause {
+				attributes["pausable"] = true
+			}
+
+		case vdTY.DeviceTraitModes: // https://developers.google.com/assistant/smarthome/traits/modes#device-attributes
+			settings := make([]map[string]interface{}, 0)
+			for _, value := range deviceAPI.GetTraitValues(resource) {
+				settings = append(settings, map[string]interface{}{"setting_name": value, "setting_values": getSynonyms("setting_synonym", value)})
+			}
+			name := deviceAPI.GetTraitName(resource)
+			mode := map[string]interface{}{
+				"name":        name,
+				"name_values": getSynonyms("name_synonym", name),
+				"settings":    settings,
+				"ordered":     false,
+			}
+			modes, _ := attributes["availableModes"].([]map[string]interface{})
+			attributes["availableModes"] = append(modes, mode)
+
+		case vdTY.DeviceTraitSensorState: // https://developers.google.com/assistant/smarthome/traits/sensorstate#device-attributes
+			sensor := map[string]interface{}{"name": deviceAPI.GetTraitName(resource)}
+			if values := deviceAPI.GetTraitValues(resource); len(values) > 0 {
+				sensor["descriptiveCapabilities"] = map[string]interface{}{"availableStates": values}
+			} else {
+				sensor["numericCapabilities"] = map[string]interface{}{"rawValueUnit": resource.Labels.Get(vdTY.LabelTraitUnit)}
+			}
+			sensors, _ := attributes["sensorStatesSupported"].([]map[string]interface{})
+			attributes["sensorStatesSupported"] = append(sensors, sensor)
+
+		case vdTY.DeviceTraitTemperatureSetting: // https://developers.google.com/assistant/smarthome/traits/temperaturesetting#device-attributes
+			if updatedTraits[resource.TraitType] {
+				continue
+			}
+			updatedTraits[resource.TraitType] = true
+			updateTemperatureSettingAttributes(vDevice, attributes)
+		}
+	}
+
+	if len(attributes) == 0 {
+		return nil
+	}
+	return attributes
+}
+
+func updateTemperatureSettingAttributes(vDevice *vdTY.VirtualDevice, attributes map[string]interface{}) {
+	trait := vdTY.DeviceTraitTemperatureSetting
+
+	modes := []string{defaultThermostatMode}
+	if resource := deviceAPI.GetTraitResource(vDevice, trait, vdTY.TraitParameterMode); resource != nil {
+		if values := deviceAPI.GetTraitValues(resource); len(values) > 0 {
+			modes = values
+		}
+	}
+	attributes["availableThermostatModes"] = modes
+
+	temperatureResource := deviceAPI.GetTraitResource(vDevice, trait, vdTY.TraitParameterSetpoint)
+	if temperatureResource == nil {
+		attributes["queryOnlyTemperatureSetting"] = true
+		temperatureResource = deviceAPI.GetTraitResource(vDevice, trait, vdTY.TraitParameterAmbient)
+	} else if min, max, found := deviceAPI.GetTraitRange(temperatureResource); found {
+		attributes["thermostatTemperatureRange"] = map[string]interface{}{
+			"minThresholdCelsius": deviceAPI.ValueToCelsius(temperatureResource, min),
+			"maxThresholdCelsius": deviceAPI.ValueToCelsius(temperatureResource, max),
+		}
+	}
+
+	unit := "C"
+	if temperatureResource != nil && strings.EqualFold(temperatureResource.Labels.Get(vdTY.LabelTraitUnit), vdTY.TraitUnitFahrenheit) {
+		unit = "F"
+	}
+	attributes["thermostatTemperatureUnit"] = unit
+}
@@ -3,9 +3,12 @@ package google_assistant
 import (
 	"fmt"
 
+	"github.com/mycontroller-org/server/v2/pkg/types/cmap"
 	vdTY "github.com/mycontroller-org/server/v2/pkg/types/virtual_device"
 	"github.com/mycontroller-org/server/v2/pkg/utils"
+	convertorUtil "github.com/mycontroller-org/server/v2/pkg/utils/convertor"
 	gaTY "github.com/mycontroller-org/server/v2/plugin/virtual_assistant/assistant/google/types"
+	deviceAPI "github.com/mycontroller-org/server/v2/plugin/virtual_assistant/device_api"
 	"go.uber.org/zap"
 )
 
@@ -39,58 +42,242 @@ func (a *Assistant) executeCommand(device gaTY.ExecuteRequestDevice, executions
 
 	vDevice, err := a.deviceAPI.GetByID(device.ID)
 	if err != nil {
-		return nil
+		a.logger.Warn("error on getting virtual device", zap.String("virtualDeviceId", device.ID), zap.Error(err))
+		return []gaTY.ExecuteResponseCommand{getExecuteErrorResponse(device.ID, gaTY.ErrorCodeDeviceNotFound)}
 	}
 
 	for _, execution := range executions {
-		for key, val := range execution.Params {
-			// if it is in ignore list, keep continue
-			if utils.ContainsString(gaTY.IgnoreParamsList, key) {
-				continue
-			}
+		responseCmd := a.executeOnDevice(vDevice, execution)
+		responseCommands = append(responseCommands, responseCmd)
+	}
+	return responseCommands
+}
 
-			trait, found := gaTY.CommandParamsMap[key]
-			if !found {
-				a.logger.Warn("trait not found on the command params map", zap.String("virtualDeviceId", vDevice.ID), zap.String("virtualDeviceName", vDevice.Name), zap.String("paramsKey", key))
-				continue
-			}
+// resourceAction holds the payload to be posted on a trait resource
+type resourceAction struct {
+	resource *vdTY.Resource
+	payload  interface{}
+}
 
-			var resource vdTY.Resource
-			found = false
+func (a *Assistant) executeOnDevice(vDevice *vdTY.VirtualDevice, execution gaTY.ExecuteRequestExecution) gaTY.ExecuteResponseCommand {
+	actions, states, errorCode := getExecuteActions(vDevice, execution)
+	if errorCode != "" {
+		a.logger.Warn("unable to execute the command", zap.String("virtualDeviceId", vDevice.ID), zap.String("virtualDeviceName", vDevice.Name), zap.String("command", execution.Command), zap.String("errorCode", errorCode))
+		return getExecuteErrorResponse(vDevice.ID, errorCode)
+	}
 
-			for _, vResource := range vDevice.Traits {
-				if vResource.TraitType == trait {
-					resource = vResource
-					found = true
-					break
-				}
+	for _, action := range actions {
+		// post data to the actual resource
+		quickId := fmt.Sprintf("%s:%s", action.resource.ResourceType, action.resource.QuickID)
+		err := a.deviceAPI.PostActionOnResourceByQuickID(action.resource.ResourceType, quickId, action.payload)
+		if err != nil {
+			a.logger.Error("error on executing", zap.String("virtualDeviceId", vDevice.ID), zap.String("virtualDeviceName", vDevice.Name), zap.String("quickId", quickId), zap.Error(err))
+			return getExecuteErrorResponse(vDevice.ID, gaTY.ErrorCodeTransientError)
+		}
+	}
+
+	return gaTY.ExecuteResponseCommand{
+		IDs:    []string{vDevice.ID},
+		Status: gaTY.ExecutionStatusSuccess,
+		States: gaTY.ExecuteResponseState{Online: true, Others: states},
+	}
+}
+
+func getExecuteErrorResponse(deviceID, errorCode string) gaTY.ExecuteResponseCommand {
+	return gaTY.ExecuteResponseCommand{
+		IDs:       []string{deviceID},
+		Status:    gaTY.ExecutionStatusError,
+		ErrorCode: errorCode,
+	}
+}
+
+// returns actions to be executed on the resources, the states to be reported and error code, if any
+func getExecuteActions(vDevice *vdTY.VirtualDevice, execution gaTY.ExecuteRequestExecution) ([]resourceAction, map[string]interface{}, string) {
+	trait, found := gaTY.CommandTraitMap[execution.Command]
+	if !found {
+		return nil, nil, gaTY.ErrorCodeNotSupported
+	}
+
+	params := cmap.CustomMap(execution.Params).Init()
+	states := make(map[string]interface{})
+	actions := make([]resourceAction, 0)
+
+	// adds an action for the trait parameter
+	addAction := func(parameter string, payload interface{}) bool {
+		resource := deviceAPI.GetTraitResource(vDevice, trait, parameter)
+		if resource == nil {
+			return false
+		}
+		actions = append(actions, resourceAction{resource: resource, payload: payload})
+		return true
+	}
+
+	switch execution.Command {
+	case gaTY.CommandOnOff: // https://developers.google.com/assistant/smarthome/traits/onoff#device-commands
+		on := params.GetBool("on")
+		if !addAction("", on) {
+			return nil, nil, gaTY.ErrorCodeFunctionNotSupported
+		}
+		states["on"] = on
+
+	case gaTY.CommandBrightnessAbsolute: // https://developers.google.com/assistant/smarthome/traits/brightness#device-commands
+		resource := deviceAPI.GetTraitResource(vDevice, trait, "")
+		if resource == nil {
+			return nil, nil, gaTY.ErrorCodeFunctionNotSupported
+		}
+		payload, err := deviceAPI.PercentToValue(resource, params.Get("brightness"))
+		if err != nil {
+			return nil, nil, gaTY.ErrorCodeValueOutOfRange
+		}
+		actions = append(actions, resourceAction{resource: resource, payload: payload})
+		states["brightness"] = convertorUtil.ToInteger(params.Get("brightness"))
+
+	case gaTY.CommandColorAbsolute: // https://developers.google.com/assistant/smarthome/traits/colorsetting#device-commands
+		color := cmap.CustomMap(toMap(params.Get("color"))).Init()
+		if color.Get("spectrumRGB") != nil {
+			resource := deviceAPI.GetTraitResource(vDevice, trait, vdTY.TraitParameterRGB)
+			if resource == nil {
+				return nil, nil, gaTY.ErrorCodeFunctionNotSupported
+			}
+			actions = append(actions, resourceAction{resource: resource, payload: deviceAPI.RGBToValue(resource, color.Get("spectrumRGB"))})
+			states["color"] = map[string]interface{}{"spectrumRGB": convertorUtil.ToInteger(color.Get("spectrumRGB"))}
+		} else if color.Get("temperature") != nil {
+			resource := deviceAPI.GetTraitResource(vDevice, trait, vdTY.TraitParameterColorTemperature)
+			if resource == nil {
+				return nil, nil, gaTY.ErrorCodeFunctionNotSupported
 			}
-			if !found {
-				a.logger.Warn("trait not found on the traits map", zap.String("virtualDeviceId", vDevice.ID), zap.String("virtualDeviceName", vDevice.Name), zap.String("trait", trait))
-				continue
+			temperature := convertorUtil.ToInteger(color.Get("temperature"))
+			minK, maxK := getColorTemperatureRange(resource)
+			if temperature < minK || temperature > maxK {
+				return nil, nil, gaTY.ErrorCodeValueOutOfRange
 			}
-			// post data to the actual resource
-			quickId := fmt.Sprintf("%s:%s", resource.ResourceType, resource.QuickID)
-			err = a.deviceAPI.PostActionOnResourceByQuickID(resource.ResourceType, quickId, val)
+			actions = append(actions, resourceAction{resource: resource, payload: temperature})
+			states["color"] = map[string]interface{}{"temperatureK": temperature}
+		} else {
+			return nil, nil, gaTY.ErrorCodeNotSupported
+		}
 
-			statusString := gaTY.ExecutionStatusSuccess
-			params := execution.Params
-			// TODO: include error code
+	case gaTY.CommandOpenClose: // https://developers.google.com/assistant/smarthome/traits/openclose#device-commands
+		resource := deviceAPI.GetTraitResource(vDevice, trait, "")
+		if resource == nil {
+			return nil, nil, gaTY.ErrorCodeFunctionNotSupported
+		}
+		payload, err := deviceAPI.PercentToValue(resource, params.Get("openPercent"))
+		if err != nil {
+			return nil, nil, gaTY.ErrorCodeValueOutOfRange
+		}
+		actions = append(actions, resourceAction{resource: resource, payload: payload})
+		states["openPercent"] = convertorUtil.ToInteger(params.Get("openPercent"))
+
+	case gaTY.CommandThermostatTemperatureSetpoint: // https://developers.google.com/assistant/smarthome/traits/temperaturesetting#device-commands
+		resource := deviceAPI.GetTraitResource(vDevice, trait, vdTY.TraitParameterSetpoint)
+		if resource == nil {
+			return nil, nil, gaTY.ErrorCodeFunctionNotSupported
+		}
+		setpoint := params.Get("thermostatTemperatureSetpoint")
+		actions = append(actions, resourceAction{resource: resource, payload: deviceAPI.CelsiusToValue(resource, setpoint)})
+		states["thermostatTemperatureSetpoint"] = convertorUtil.ToFloat(setpoint)
+
+	case gaTY.CommandThermostatSetMode:
+		resource := deviceAPI.GetTraitResource(vDevice, trait, vdTY.TraitParameterMode)
+		if resource == nil {
+			return nil, nil, gaTY.ErrorCodeFunctionNotSupported
+		}
+		mode := params.GetString("thermostatMode")
+		if !isSupportedValue(resource, mode) {
+			return nil, nil, gaTY.ErrorCodeNotSupported
+		}
+		actions = append(actions, resourceAction{resource: resource, payload: mode})
+		states["thermostatMode"] = mode
+
+	case gaTY.CommandSetFanSpeed: // https://developers.google.com/assistant/smarthome/traits/fanspeed#device-commands
+		resource := deviceAPI.GetTraitResource(vDevice, trait, "")
+		if resource == nil {
+			return nil, nil, gaTY.ErrorCodeFunctionNotSupported
+		}
+		if params.Get("fanSpeed") != nil {
+			fanSpeed := params.GetString("fanSpeed")
+			if !isSupportedValue(resource, fanSpeed) {
+				return nil, nil, gaTY.ErrorCodeNotSupported
+			}
+			actions = append(actions, resourceAction{resource: resource, payload: fanSpeed})
+			states["currentFanSpeedSetting"] = fanSpeed
+		} else {
+			payload, err := deviceAPI.PercentToValue(resource, params.Get("fanSpeedPercent"))
 			if err != nil {
-				a.logger.Error("error on executing", zap.Error(err))
-				statusString = gaTY.ExecutionStatusError
+				return nil, nil, gaTY.ErrorCodeValueOutOfRange
 			}
-			params[key] = val
-			params["online"] = true
-			responseCmd := gaTY.ExecuteResponseCommand{
-				IDs:    []string{device.ID},
-				Status: statusString,
-				States: gaTY.ExecuteResponseState{Online: true, Others: params},
+			actions = append(actions, resourceAction{resource: resource, payload: payload})
+			states["currentFanSpeedPercent"] = convertorUtil.ToInteger(params.Get("fanSpeedPercent"))
+		}
+
+	case gaTY.CommandLockUnlock: // https://developers.google.com/assistant/smarthome/traits/lockunlock#device-commands
+		lock := params.GetBool("lock")
+		if !addAction("", lock) {
+			return nil, nil, gaTY.ErrorCodeFunctionNotSupported
+		}
+		states["isLocked"] = lock
+		states["isJammed"] = false
+
+	case gaTY.CommandArmDisarm: // https://developers.google.com/assistant/smarthome/traits/armdisarm#device-commands
+		arm := params.GetBool("arm")
+		if !addAction(vdTY.TraitParameterArm, arm) {
+			return nil, nil, gaTY.ErrorCodeFunctionNotSupported
+		}
+		states["isArmed"] = arm
+		if armLevel := params.GetString("armLevel"); arm && armLevel != "" {
+			resource := deviceAPI.GetTraitResource(vDevice, trait, vdTY.TraitParameterArmLevel)
+			if resource == nil || !isSupportedValue(resource, armLevel) {
+				return nil, nil, gaTY.ErrorCodeNotSupported
 			}
-			responseCommands = append(responseCommands, responseCmd)
+			actions = append(actions, resourceAction{resource: resource, payload: armLevel})
+			states["currentArmLevel"] = armLevel
+		}
+
+	case gaTY.CommandStartStop: // https://developers.google.com/assistant/smarthome/traits/startstop#device-commands
+		start := params.GetBool("start")
+		if !addAction(vdTY.TraitParameterStart, start) {
+			return nil, nil, gaTY.ErrorCodeFunctionNotSupported
+		}
+		states["isRunning"] = start
 
+	case gaTY.CommandPauseUnpause:
+		pause := params.GetBool("pause")
+		if !addAction(vdTY.TraitParameterPause, pause) {
+			return nil, nil, gaTY.ErrorCodeFunctionNotSupported
+		}
+		states["isPaused"] = pause
+
+	case gaTY.CommandSetModes: // https://developers.google.com/assistant/smarthome/traits/modes#device-commands
+		modeSettings := toMap(params.Get("updateModeSettings"))
+		currentModeSettings := make(map[string]interface{})
+		for name, rawValue := range modeSettings {
+			value := convertorUtil.ToString(rawValue)
+			resource := deviceAPI.GetTraitResourceByName(vDevice, trait, name)
+			if resource == nil || !isSupportedValue(resource, value) {
+				return nil, nil, gaTY.ErrorCodeNotSupported
+			}
+			actions = append(actions, resourceAction{resource: resource, payload: value})
+			currentModeSettings[name] = value
 		}
+		states["currentModeSettings"] = currentModeSettings
 
+	default:
+		return nil, nil, gaTY.ErrorCodeNotSupported
 	}
-	return responseCommands
+
+	return actions, states, ""
+}
+
+// returns true, if the value is in supported values list or the list is not defined
+func isSupportedValue(resource *vdTY.Resource, value string) bool {
+	values := deviceAPI.GetTraitValues(resource)
+	return len(values) == 0 || utils.ContainsString(values, value)
+}
+
+func toMap(data interface{}) map[string]interface{} {
+	if mapData, ok := data.(map[string]interface{}); ok {
+		return mapData
+	}
+	return nil
 }
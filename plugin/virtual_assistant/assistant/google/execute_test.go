@@ -0,0 +1,305 @@
+package google_assistant
+
+import (
+	"testing"
+
+	"github.com/mycontroller-org/server/v2/pkg/types/cmap"
+	vdTY "github.com/mycontroller-org/server/v2/pkg/types/virtual_device"
+	gaTY "github.com/mycontroller-org/server/v2/plugin/virtual_assistant/assistant/google/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// returns a virtual device with a resource on each supported trait
+func newTestVirtualDevice() *vdTY.VirtualDevice {
+	resource := func(name, trait string, labels cmap.CustomStringMap) vdTY.Resource {
+		return vdTY.Resource{Name: name, TraitType: trait, ResourceType: "field", QuickID: "gw.node.source." + name, Labels: labels}
+	}
+	return &vdTY.VirtualDevice{ID: "device", Traits: []vdTY.Resource{
+		resource("power", vdTY.DeviceTraitOnOff, nil),
+		resource("dimmer", vdTY.DeviceTraitBrightness, cmap.CustomStringMap{vdTY.LabelTraitMin: "0", vdTY.LabelTraitMax: "255"}),
+		resource("rgb", vdTY.DeviceTraitColorSetting, cmap.CustomStringMap{vdTY.LabelTraitUnit: vdTY.TraitUnitHex}),
+		resource("color_temperature", vdTY.DeviceTraitColorSetting, cmap.CustomStringMap{
+			vdTY.LabelTraitParameter: vdTY.TraitParameterColorTemperature, vdTY.LabelTraitMin: "2700", vdTY.LabelTraitMax: "6500",
+		}),
+		resource("blind", vdTY.DeviceTraitOpenClose, nil),
+		resource("setpoint", vdTY.DeviceTraitTemperatureSetting, cmap.CustomStringMap{vdTY.LabelTraitUnit: vdTY.TraitUnitFahrenheit}),
+		resource("thermostat_mode", vdTY.DeviceTraitTemperatureSetting, cmap.CustomStringMap{
+			vdTY.LabelTraitParameter: vdTY.TraitParameterMode, vdTY.LabelTraitValues: "off,heat,cool",
+		}),
+		resource("fan", vdTY.DeviceTraitFanSpeed, cmap.CustomStringMap{vdTY.LabelTraitValues: "low,medium,high"}),
+		resource("lock", vdTY.DeviceTraitLockUnlock, nil),
+		resource("arm", vdTY.DeviceTraitArmDisarm, nil),
+		resource("arm_level", vdTY.DeviceTraitArmDisarm, cmap.CustomStringMap{
+			vdTY.LabelTraitParameter: vdTY.TraitParameterArmLevel, vdTY.LabelTraitValues: "home,away",
+		}),
+		resource("start", vdTY.DeviceTraitStartStop, nil),
+		resource("pause", vdTY.DeviceTraitStartStop, cmap.CustomStringMap{vdTY.LabelTraitParameter: vdTY.TraitParameterPause}),
+		resource("wash_mode", vdTY.DeviceTraitModes, cmap.CustomStringMap{vdTY.LabelTraitName: "wash", vdTY.LabelTraitValues: "quick,normal"}),
+		resource("dry_mode", vdTY.DeviceTraitModes, cmap.CustomStringMap{vdTY.LabelTraitName: "dry"}),
+	}}
+}
+
+func TestGetExecuteActions(t *testing.T) {
+	vDevice := newTestVirtualDevice()
+	// fan speed and color temperature without values and range
+	percentDevice := &vdTY.VirtualDevice{ID: "percent_device", Traits: []vdTY.Resource{
+		{Name: "fan", TraitType: vdTY.DeviceTraitFanSpeed, Labels: cmap.CustomStringMap{vdTY.LabelTraitMin: "0", vdTY.LabelTraitMax: "4"}},
+		{Name: "color_temperature", TraitType: vdTY.DeviceTraitColorSetting, Labels: cmap.CustomStringMap{vdTY.LabelTraitParameter: vdTY.TraitParameterColorTemperature}},
+		{Name: "thermostat_mode", TraitType: vdTY.DeviceTraitTemperatureSetting, Labels: cmap.CustomStringMap{vdTY.LabelTraitParameter: vdTY.TraitParameterMode}},
+		{Name: "arm", TraitType: vdTY.DeviceTraitArmDisarm},
+	}}
+	emptyDevice := &vdTY.VirtualDevice{ID: "empty_device"}
+
+	tests := []struct {
+		name      string
+		device    *vdTY.VirtualDevice
+		command   string
+		params    map[string]interface{}
+		actions   map[string]interface{} // resource name and payload
+		states    map[string]interface{}
+		errorCode string
+	}{
+		{
+			name:    "on",
+			command: gaTY.CommandOnOff,
+			params:  map[string]interface{}{"on": true},
+			actions: map[string]interface{}{"power": true},
+			states:  map[string]interface{}{"on": true},
+		},
+		{
+			name:    "brightness scaled to range",
+			command: gaTY.CommandBrightnessAbsolute,
+			params:  map[string]interface{}{"brightness": float64(50)},
+			actions: map[string]interface{}{"dimmer": int64(128)},
+			states:  map[string]interface{}{"brightness": int64(50)},
+		},
+		{
+			name:      "brightness out of range",
+			command:   gaTY.CommandBrightnessAbsolute,
+			params:    map[string]interface{}{"brightness": float64(120)},
+			errorCode: gaTY.ErrorCodeValueOutOfRange,
+		},
+		{
+			name:    "spectrum rgb to hex",
+			command: gaTY.CommandColorAbsolute,
+			params:  map[string]interface{}{"color": map[string]interface{}{"spectrumRGB": float64(16711680)}},
+			actions: map[string]interface{}{"rgb": "ff0000"},
+			states:  map[string]interface{}{"color": map[string]interface{}{"spectrumRGB": int64(16711680)}},
+		},
+		{
+			name:    "color temperature",
+			command: gaTY.CommandColorAbsolute,
+			params:  map[string]interface{}{"color": map[string]interface{}{"temperature": float64(3000)}},
+			actions: map[string]interface{}{"color_temperature": int64(3000)},
+			states:  map[string]interface{}{"color": map[string]interface{}{"temperatureK": int64(3000)}},
+		},
+		{
+			name:      "color temperature below min",
+			command:   gaTY.CommandColorAbsolute,
+			params:    map[string]interface{}{"color": map[string]interface{}{"temperature": float64(2000)}},
+			errorCode: gaTY.ErrorCodeValueOutOfRange,
+		},
+		{
+			name:    "color temperature default range",
+			device:  percentDevice,
+			command: gaTY.CommandColorAbsolute,
+			params:  map[string]interface{}{"color": map[string]interface{}{"temperature": float64(2000)}},
+			actions: map[string]interface{}{"color_temperature": int64(2000)},
+			states:  map[string]interface{}{"color": map[string]interface{}{"temperatureK": int64(2000)}},
+		},
+		{
+			name:      "color temperature above default range",
+			device:    percentDevice,
+			command:   gaTY.CommandColorAbsolute,
+			params:    map[string]interface{}{"color": map[string]interface{}{"temperature": float64(9500)}},
+			errorCode: gaTY.ErrorCodeValueOutOfRange,
+		},
+		{
+			name:      "spectrum hsv",
+			command:   gaTY.CommandColorAbsolute,
+			params:    map[string]interface{}{"color": map[string]interface{}{"spectrumHSV": map[string]interface{}{"hue": float64(120)}}},
+			errorCode: gaTY.ErrorCodeNotSupported,
+		},
+		{
+			name:      "spectrum rgb without resource",
+			device:    percentDevice,
+			command:   gaTY.CommandColorAbsolute,
+			params:    map[string]interface{}{"color": map[string]interface{}{"spectrumRGB": float64(255)}},
+			errorCode: gaTY.ErrorCodeFunctionNotSupported,
+		},
+		{
+			name:    "open percent",
+			command: gaTY.CommandOpenClose,
+			params:  map[string]interface{}{"openPercent": float64(30)},
+			actions: map[string]interface{}{"blind": int64(30)},
+			states:  map[string]interface{}{"openPercent": int64(30)},
+		},
+		{
+			name:      "open percent out of range",
+			command:   gaTY.CommandOpenClose,
+			params:    map[string]interface{}{"openPercent": float64(-10)},
+			errorCode: gaTY.ErrorCodeValueOutOfRange,
+		},
+		{
+			name:    "setpoint celsius to fahrenheit",
+			command: gaTY.CommandThermostatTemperatureSetpoint,
+			params:  map[string]interface{}{"thermostatTemperatureSetpoint": float64(20)},
+			actions: map[string]interface{}{"setpoint": float64(68)},
+			states:  map[string]interface{}{"thermostatTemperatureSetpoint": float64(20)},
+		},
+		{
+			name:    "thermostat mode",
+			command: gaTY.CommandThermostatSetMode,
+			params:  map[string]interface{}{"thermostatMode": "cool"},
+			actions: map[string]interface{}{"thermostat_mode": "cool"},
+			states:  map[string]interface{}{"thermostatMode": "cool"},
+		},
+		{
+			name:      "unsupported thermostat mode",
+			command:   gaTY.CommandThermostatSetMode,
+			params:    map[string]interface{}{"thermostatMode": "eco"},
+			errorCode: gaTY.ErrorCodeNotSupported,
+		},
+		{
+			name:    "thermostat mode without values",
+			device:  percentDevice,
+			command: gaTY.CommandThermostatSetMode,
+			params:  map[string]interface{}{"thermostatMode": "eco"},
+			actions: map[string]interface{}{"thermostat_mode": "eco"},
+			states:  map[string]interface{}{"thermostatMode": "eco"},
+		},
+		{
+			name:    "fan speed",
+			command: gaTY.CommandSetFanSpeed,
+			params:  map[string]interface{}{"fanSpeed": "high"},
+			actions: map[string]interface{}{"fan": "high"},
+			states:  map[string]interface{}{"currentFanSpeedSetting": "high"},
+		},
+		{
+			name:      "unsupported fan speed",
+			command:   gaTY.CommandSetFanSpeed,
+			params:    map[string]interface{}{"fanSpeed": "turbo"},
+			errorCode: gaTY.ErrorCodeNotSupported,
+		},
+		{
+			name:    "fan speed percent",
+			device:  percentDevice,
+			command: gaTY.CommandSetFanSpeed,
+			params:  map[string]interface{}{"fanSpeedPercent": float64(50)},
+			actions: map[string]interface{}{"fan": int64(2)},
+			states:  map[string]interface{}{"currentFanSpeedPercent": int64(50)},
+		},
+		{
+			name:      "fan speed percent out of range",
+			device:    percentDevice,
+			command:   gaTY.CommandSetFanSpeed,
+			params:    map[string]interface{}{"fanSpeedPercent": float64(150)},
+			errorCode: gaTY.ErrorCodeValueOutOfRange,
+		},
+		{
+			name:    "lock",
+			command: gaTY.CommandLockUnlock,
+			params:  map[string]interface{}{"lock": true},
+			actions: map[string]interface{}{"lock": true},
+			states:  map[string]interface{}{"isLocked": true, "isJammed": false},
+		},
+		{
+			name:    "arm with level",
+			command: gaTY.CommandArmDisarm,
+			params:  map[string]interface{}{"arm": true, "armLevel": "away"},
+			actions: map[string]interface{}{"arm": true, "arm_level": "away"},
+			states:  map[string]interface{}{"isArmed": true, "currentArmLevel": "away"},
+		},
+		{
+			name:      "unsupported arm level",
+			command:   gaTY.CommandArmDisarm,
+			params:    map[string]interface{}{"arm": true, "armLevel": "night"},
+			errorCode: gaTY.ErrorCodeNotSupported,
+		},
+		{
+			name:      "arm level without resource",
+			device:    percentDevice,
+			command:   gaTY.CommandArmDisarm,
+			params:    map[string]interface{}{"arm": true, "armLevel": "away"},
+			errorCode: gaTY.ErrorCodeNotSupported,
+		},
+		{
+			name:    "disarm ignores level",
+			command: gaTY.CommandArmDisarm,
+			params:  map[string]interface{}{"arm": false, "armLevel": "night"},
+			actions: map[string]interface{}{"arm": false},
+			states:  map[string]interface{}{"isArmed": false},
+		},
+		{
+			name:    "start",
+			command: gaTY.CommandStartStop,
+			params:  map[string]interface{}{"start": true},
+			actions: map[string]interface{}{"start": true},
+			states:  map[string]interface{}{"isRunning": true},
+		},
+		{
+			name:    "pause",
+			command: gaTY.CommandPauseUnpause,
+			params:  map[string]interface{}{"pause": true},
+			actions: map[string]interface{}{"pause": true},
+			states:  map[string]interface{}{"isPaused": true},
+		},
+		{
+			name:    "modes",
+			command: gaTY.CommandSetModes,
+			params:  map[string]interface{}{"updateModeSettings": map[string]interface{}{"wash": "quick", "dry": "extra"}},
+			actions: map[string]interface{}{"wash_mode": "quick", "dry_mode": "extra"},
+			states:  map[string]interface{}{"currentModeSettings": map[string]interface{}{"wash": "quick", "dry": "extra"}},
+		},
+		{
+			name:      "unsupported mode value",
+			command:   gaTY.CommandSetModes,
+			params:    map[string]interface{}{"updateModeSettings": map[string]interface{}{"wash": "slow"}},
+			errorCode: gaTY.ErrorCodeNotSupported,
+		},
+		{
+			name:      "unknown mode",
+			command:   gaTY.CommandSetModes,
+			params:    map[string]interface{}{"updateModeSettings": map[string]interface{}{"spin": "fast"}},
+			errorCode: gaTY.ErrorCodeNotSupported,
+		},
+		{
+			name:      "unknown command",
+			command:   "action.devices.commands.Reboot",
+			errorCode: gaTY.ErrorCodeNotSupported,
+		},
+		{name: "on without resource", device: emptyDevice, command: gaTY.CommandOnOff, params: map[string]interface{}{"on": true}, errorCode: gaTY.ErrorCodeFunctionNotSupported},
+		{name: "brightness without resource", device: emptyDevice, command: gaTY.CommandBrightnessAbsolute, params: map[string]interface{}{"brightness": 10}, errorCode: gaTY.ErrorCodeFunctionNotSupported},
+		{name: "open close without resource", device: emptyDevice, command: gaTY.CommandOpenClose, params: map[string]interface{}{"openPercent": 10}, errorCode: gaTY.ErrorCodeFunctionNotSupported},
+		{name: "setpoint without resource", device: emptyDevice, command: gaTY.CommandThermostatTemperatureSetpoint, params: map[string]interface{}{"thermostatTemperatureSetpoint": 20}, errorCode: gaTY.ErrorCodeFunctionNotSupported},
+		{name: "thermostat mode without resource", device: emptyDevice, command: gaTY.CommandThermostatSetMode, params: map[string]interface{}{"thermostatMode": "heat"}, errorCode: gaTY.ErrorCodeFunctionNotSupported},
+		{name: "fan speed without resource", device: emptyDevice, command: gaTY.CommandSetFanSpeed, params: map[string]interface{}{"fanSpeed": "low"}, errorCode: gaTY.ErrorCodeFunctionNotSupported},
+		{name: "lock without resource", device: emptyDevice, command: gaTY.CommandLockUnlock, params: map[string]interface{}{"lock": true}, errorCode: gaTY.ErrorCodeFunctionNotSupported},
+		{name: "arm without resource", device: emptyDevice, command: gaTY.CommandArmDisarm, params: map[string]interface{}{"arm": true}, errorCode: gaTY.ErrorCodeFunctionNotSupported},
+		{name: "start without resource", device: emptyDevice, command: gaTY.CommandStartStop, params: map[string]interface{}{"start": true}, errorCode: gaTY.ErrorCodeFunctionNotSupported},
+		{name: "pause without resource", device: emptyDevice, command: gaTY.CommandPauseUnpause, params: map[string]interface{}{"pause": true}, errorCode: gaTY.ErrorCodeFunctionNotSupported},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			device := test.device
+			if device == nil {
+				device = vDevice
+			}
+			actions, states, errorCode := getExecuteActions(device, gaTY.ExecuteRequestExecution{Command: test.command, Params: test.params})
+			assert.Equal(t, test.errorCode, errorCode)
+			if test.errorCode != "" {
+				assert.Nil(t, actions)
+				assert.Nil(t, states)
+				return
+			}
+
+			payloads := make(map[string]interface{})
+			for _, action := range actions {
+				payloads[action.resource.Name] = action.payload
+			}
+			assert.Len(t, actions, len(test.actions))
+			assert.Equal(t, test.actions, payloads)
+			assert.Equal(t, test.states, states)
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package google_assistant
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/mycontroller-org/server/v2/pkg/json"
+	"github.com/mycontroller-org/server/v2/pkg/utils"
+	httpClient "github.com/mycontroller-org/server/v2/pkg/utils/http_client_json"
+	gaTY "github.com/mycontroller-org/server/v2/plugin/virtual_assistant/assistant/google/types"
+)
+
+const (
+	tokenLifetime     = time.Hour
+	tokenRenewalAhead = time.Minute
+)
+
+// homeGraphClient calls google home graph api with service account credentials
+type homeGraphClient struct {
+	cfg         *gaTY.Config
+	client      *httpClient.Client
+	accessToken string
+	expiresAt   time.Time
+	mutex       sync.Mutex
+}
+
+func newHomeGraphClient(cfg *gaTY.Config) (*homeGraphClient, error) {
+	if cfg.ClientEmail == "" || cfg.PrivateKey == "" {
+		return nil, errors.New("clientEmail and privateKey are required to report state")
+	}
+	if cfg.TokenURL == "" {
+		cfg.TokenURL = gaTY.DefaultTokenURL
+	}
+	if cfg.HomeGraphURL == "" {
+		cfg.HomeGraphURL = gaTY.DefaultHomeGraphURL
+	}
+	if cfg.Timeout == "" {
+		cfg.Timeout = gaTY.DefaultTimeout
+	}
+
+	return &homeGraphClient{
+		cfg:    cfg,
+		client: httpClient.New(cfg.Insecure, cfg.Timeout),
+	}, nil
+}
+
+// reportState posts the device states to home graph
+func (hg *homeGraphClient) reportState(states map[string]interface{}) error {
+	request := gaTY.ReportStateRequest{
+		RequestID:   utils.RandUUID(),
+		AgentUserID: hg.cfg.AgentUserID,
+		Payload: gaTY.ReportStatePayload{
+			Devices: gaTY.ReportStateDevices{States: states},
+		},
+	}
+	return hg.post("/v1/devices:reportStateAndNotification", request)
+}
+
+// requestSync asks google to send a sync request
+func (hg *homeGraphClient) requestSync() error {
+	request := gaTY.RequestSyncRequest{
+		AgentUserID: hg.cfg.AgentUserID,
+		Async:       true,
+	}
+	return hg.post("/v1/devices:requestSync", request)
+}
+
+func (hg *homeGraphClient) post(path string, body interface{}) error {
+	token, err := hg.getAccessToken()
+	if err != nil {
+		return err
+	}
+	headers := map[string]string{"Authorization": fmt.Sprintf("Bearer %s", token)}
+	apiURL := fmt.Sprintf("%s%s", strings.TrimSuffix(hg.cfg.HomeGraphURL, "/"), path)
+	_, err = hg.client.ExecuteJson(apiURL, http.MethodPost, headers, nil, body, http.StatusOK)
+	return err
+}
+
+// returns access token, renews it when expired
+// https://developers.google.com/identity/protocols/oauth2/service-account#httprest
+func (hg *homeGraphClient) getAccessToken() (string, error) {
+	hg.mutex.Lock()
+	defer hg.mutex.Unlock()
+
+	if hg.accessToken != "" && time.Now().Before(hg.expiresAt) {
+		return hg.accessToken, nil
+	}
+
+	// private key from the service account json file will have escaped new lines
+	privateKey, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(strings.ReplaceAll(hg.cfg.PrivateKey, `\n`, "\n")))
+	if err != nil {
+		return "", fmt.Errorf("error on parsing private key: %w", err)
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":   hg.cfg.ClientEmail,
+		"scope": gaTY.HomeGraphScope,
+		"aud":   hg.cfg.TokenURL,
+		"iat":   now.Unix(),
+		"exp":   now.Add(tokenLifetime).Unix(),
+	}
+	assertion, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(privateKey)
+	if err != nil {
+		return "", err
+	}
+
+	formData := url.Values{}
+	formData.Set("grant_type", "urn:ietf:params:oauth:grant-type:jwt-bearer")
+	formData.Set("assertion", assertion)
+	headers := map[string]string{"Content-Type": "application/x-www-form-urlencoded"}
+	response, err := hg.client.Execute(hg.cfg.TokenURL, http.MethodPost, headers, nil, formData.Encode(), http.StatusOK)
+	if err != nil {
+		return "", err
+	}
+
+	tokenResponse := gaTY.TokenResponse{}
+	err = json.Unmarshal(response.Body, &tokenResponse)
+	if err != nil {
+		return "", err
+	}
+	if tokenResponse.AccessToken == "" {
+		return "", errors.New("access token not received")
+	}
+
+	hg.accessToken = tokenResponse.AccessToken
+	hg.expiresAt = now.Add(time.Duration(tokenResponse.ExpiresIn)*time.Second - tokenRenewalAhead)
+	return hg.accessToken, nil
+}
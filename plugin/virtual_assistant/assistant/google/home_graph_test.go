@@ -0,0 +1,190 @@
+package google_assistant
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/mycontroller-org/server/v2/pkg/json"
+	"github.com/mycontroller-org/server/v2/pkg/types/cmap"
+	eventTY "github.com/mycontroller-org/server/v2/pkg/types/event"
+	vdTY "github.com/mycontroller-org/server/v2/pkg/types/virtual_device"
+	gaTY "github.com/mycontroller-org/server/v2/plugin/virtual_assistant/assistant/google/types"
+	vaTY "github.com/mycontroller-org/server/v2/plugin/virtual_assistant/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// homeGraphServer serves the token and the home graph api
+type homeGraphServer struct {
+	*httptest.Server
+	mutex      sync.Mutex
+	publicKey  *rsa.PublicKey
+	tokenCalls int
+	assertions []jwt.MapClaims
+	requests   map[string][]map[string]interface{}
+	authHeader []string
+}
+
+func newHomeGraphServer(t *testing.T, publicKey *rsa.PublicKey) *homeGraphServer {
+	server := &homeGraphServer{publicKey: publicKey, requests: map[string][]map[string]interface{}{}}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		server.mutex.Lock()
+		defer server.mutex.Unlock()
+
+		if r.URL.Path == "/token" {
+			server.tokenCalls++
+			form, err := url.ParseQuery(string(body))
+			if err != nil || form.Get("grant_type") != "urn:ietf:params:oauth:grant-type:jwt-bearer" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			claims := jwt.MapClaims{}
+			_, err = jwt.ParseWithClaims(form.Get("assertion"), claims, func(token *jwt.Token) (interface{}, error) {
+				return server.publicKey, nil
+			}, jwt.WithValidMethods([]string{"RS256"}))
+			if err != nil {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			server.assertions = append(server.assertions, claims)
+			_, _ = w.Write([]byte(`{"access_token":"access_token_1","token_type":"Bearer","expires_in":3600}`))
+			return
+		}
+
+		request := map[string]interface{}{}
+		_ = json.Unmarshal(body, &request)
+		server.requests[r.URL.Path] = append(server.requests[r.URL.Path], request)
+		server.authHeader = append(server.authHeader, r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func (s *homeGraphServer) getRequests(path string) []map[string]interface{} {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.requests[path]
+}
+
+func newTestHomeGraph(t *testing.T) (*homeGraphClient, *homeGraphServer) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	privateKeyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)})
+
+	server := newHomeGraphServer(t, &privateKey.PublicKey)
+	// escaped new lines, as on the service account json file
+	homeGraph, err := newHomeGraphClient(&gaTY.Config{
+		AgentUserID:  "agent_1",
+		ClientEmail:  "mycontroller@example.iam.gserviceaccount.com",
+		PrivateKey:   strings.ReplaceAll(string(privateKeyPEM), "\n", `\n`),
+		TokenURL:     server.URL + "/token",
+		HomeGraphURL: server.URL + "/",
+	})
+	require.NoError(t, err)
+	return homeGraph, server
+}
+
+func TestHomeGraphTokenExchange(t *testing.T) {
+	homeGraph, server := newTestHomeGraph(t)
+
+	token, err := homeGraph.getAccessToken()
+	require.NoError(t, err)
+	assert.Equal(t, "access_token_1", token)
+
+	require.Len(t, server.assertions, 1)
+	claims := server.assertions[0]
+	assert.Equal(t, "mycontroller@example.iam.gserviceaccount.com", claims["iss"])
+	assert.Equal(t, gaTY.HomeGraphScope, claims["scope"])
+	assert.Equal(t, server.URL+"/token", claims["aud"])
+	issuedAt, err := claims.GetIssuedAt()
+	require.NoError(t, err)
+	expiresAt, err := claims.GetExpirationTime()
+	require.NoError(t, err)
+	assert.Equal(t, tokenLifetime, expiresAt.Sub(issuedAt.Time))
+
+	// cached till the expiry
+	_, err = homeGraph.getAccessToken()
+	require.NoError(t, err)
+	assert.Equal(t, 1, server.tokenCalls)
+	assert.WithinDuration(t, time.Now().Add(time.Hour-tokenRenewalAhead), homeGraph.expiresAt, 5*time.Second)
+
+	// renewed after the expiry
+	homeGraph.expiresAt = time.Now().Add(-time.Second)
+	_, err = homeGraph.getAccessToken()
+	require.NoError(t, err)
+	assert.Equal(t, 2, server.tokenCalls)
+
+	// invalid private key
+	_, err = newHomeGraphClient(&gaTY.Config{ClientEmail: "a@b.c"})
+	assert.Error(t, err)
+	invalidKey, err := newHomeGraphClient(&gaTY.Config{ClientEmail: "a@b.c", PrivateKey: "invalid", TokenURL: server.URL + "/token"})
+	require.NoError(t, err)
+	_, err = invalidKey.getAccessToken()
+	assert.ErrorContains(t, err, "error on parsing private key")
+}
+
+func TestHomeGraphReportState(t *testing.T) {
+	homeGraph, server := newTestHomeGraph(t)
+
+	states := map[string]interface{}{
+		"device_1": map[string]interface{}{"online": true, "on": true},
+	}
+	require.NoError(t, homeGraph.reportState(states))
+
+	requests := server.getRequests("/v1/devices:reportStateAndNotification")
+	require.Len(t, requests, 1)
+	request := requests[0]
+	assert.NotEmpty(t, request["requestId"])
+	assert.Equal(t, "agent_1", request["agentUserId"])
+	expected := map[string]interface{}{
+		"devices": map[string]interface{}{
+			"states": map[string]interface{}{"device_1": map[string]interface{}{"online": true, "on": true}},
+		},
+	}
+	assert.Equal(t, expected, request["payload"])
+	assert.Equal(t, []string{"Bearer access_token_1"}, server.authHeader)
+}
+
+func TestHomeGraphRequestSync(t *testing.T) {
+	homeGraph, server := newTestHomeGraph(t)
+	require.NoError(t, homeGraph.requestSync())
+
+	requests := server.getRequests("/v1/devices:requestSync")
+	require.Len(t, requests, 1)
+	assert.Equal(t, map[string]interface{}{"agentUserId": "agent_1", "async": true}, requests[0])
+
+	// device changes within the delay merged into a single request sync
+	assistant := &Assistant{
+		logger:    zap.NewNop(),
+		cfg:       &vaTY.Config{DeviceFilter: cmap.CustomStringMap{"location": "home"}},
+		gaCfg:     &gaTY.Config{RequestSyncDelay: "100ms"},
+		homeGraph: homeGraph,
+	}
+	matching := &vdTY.VirtualDevice{ID: "device_1", Labels: cmap.CustomStringMap{"location": "home"}}
+	notMatching := &vdTY.VirtualDevice{ID: "device_2"}
+
+	require.NoError(t, assistant.RequestSync(eventTY.TypeCreated, notMatching))
+	time.Sleep(200 * time.Millisecond)
+	assert.Len(t, server.getRequests("/v1/devices:requestSync"), 1)
+
+	require.NoError(t, assistant.RequestSync(eventTY.TypeCreated, matching))
+	require.NoError(t, assistant.RequestSync(eventTY.TypeUpdated, notMatching))
+	require.NoError(t, assistant.RequestSync(eventTY.TypeDeleted, matching))
+	assert.Eventually(t, func() bool { return len(server.getRequests("/v1/devices:requestSync")) == 2 }, 5*time.Second, 20*time.Millisecond)
+	time.Sleep(200 * time.Millisecond)
+	assert.Len(t, server.getRequests("/v1/devices:requestSync"), 2)
+}
@@ -3,10 +3,10 @@ package google_assistant
 import (
 	"github.com/mycontroller-org/server/v2/pkg/types"
 	vdTY "github.com/mycontroller-org/server/v2/pkg/types/virtual_device"
-	"github.com/mycontroller-org/server/v2/pkg/utils"
 	convertorUtil "github.com/mycontroller-org/server/v2/pkg/utils/convertor"
 	storageTY "github.com/mycontroller-org/server/v2/plugin/database/storage/types"
 	gaTY "github.com/mycontroller-org/server/v2/plugin/virtual_assistant/assistant/google/types"
+	deviceAPI "github.com/mycontroller-org/server/v2/plugin/virtual_assistant/device_api"
 	"go.uber.org/zap"
 )
 
@@ -70,13 +70,8 @@ func (a *Assistant) queryDevices(devices []gaTY.QueryRequestDevice) (map[string]
 
 func (a *Assistant) queryDeviceState(vDevice vdTY.VirtualDevice) (*gaTY.QueryResponseDevice, error) {
 	params := make(map[string]interface{})
-	for _, vResource := range vDevice.Traits {
-		_params, err := a.getResourceParams(vResource.TraitType, vResource)
-		if err != nil {
-			return nil, err
-		}
-		utils.JoinMap(params, _params)
-
+	for index := range vDevice.Traits {
+		a.updateResourceParams(params, &vDevice.Traits[index])
 	}
 
 	response := gaTY.QueryResponseDevice{
@@ -88,21 +83,89 @@ func (a *Assistant) queryDeviceState(vDevice vdTY.VirtualDevice) (*gaTY.QueryRes
 	return &response, nil
 }
 
-func (a *Assistant) getResourceParams(trait string, resource vdTY.Resource) (map[string]interface{}, error) {
-	// a.logger.Info("requested trait", zap.String("trait", trait))
-	params := make(map[string]interface{})
-	switch trait {
+// updates the device state params from the resource value
+func (a *Assistant) updateResourceParams(params map[string]interface{}, resource *vdTY.Resource) {
+	// a.logger.Info("requested trait", zap.String("trait", resource.TraitType))
+	switch resource.TraitType {
 	case vdTY.DeviceTraitOnOff: // https://developers.google.com/assistant/smarthome/traits/onoff#device-states
 		params["on"] = convertorUtil.ToBool(resource.Value)
 
 	case vdTY.DeviceTraitBrightness: // https://developers.google.com/assistant/smarthome/traits/brightness#device-states
-		params["brightness"] = convertorUtil.ToInteger(resource.Value)
+		params["brightness"] = deviceAPI.ValueToPercent(resource, resource.Value)
+
+	case vdTY.DeviceTraitColorSetting: // https://developers.google.com/assistant/smarthome/traits/colorsetting#device-states
+		switch deviceAPI.GetTraitParameter(resource) {
+		case vdTY.TraitParameterRGB:
+			params["color"] = map[string]interface{}{"spectrumRGB": deviceAPI.ValueToRGB(resource, resource.Value)}
+		case vdTY.TraitParameterColorTemperature:
+			// rgb takes the precedence, if both available
+			if _, found := params["color"]; !found {
+				params["color"] = map[string]interface{}{"temperatureK": convertorUtil.ToInteger(resource.Value)}
+			}
+		}
+
+	case vdTY.DeviceTraitOpenClose: // https://developers.google.com/assistant/smarthome/traits/openclose#device-states
+		params["openPercent"] = deviceAPI.ValueToPercent(resource, resource.Value)
+
+	case vdTY.DeviceTraitTemperatureSetting: // https://developers.google.com/assistant/smarthome/traits/temperaturesetting#device-states
+		switch deviceAPI.GetTraitParameter(resource) {
+		case vdTY.TraitParameterMode:
+			params["thermostatMode"] = convertorUtil.ToString(resource.Value)
+		case vdTY.TraitParameterSetpoint:
+			params["thermostatTemperatureSetpoint"] = deviceAPI.ValueToCelsius(resource, resource.Value)
+		case vdTY.TraitParameterAmbient:
+			params["thermostatTemperatureAmbient"] = deviceAPI.ValueToCelsius(resource, resource.Value)
+		}
+		if _, found := params["thermostatMode"]; !found {
+			params["thermostatMode"] = defaultThermostatMode
+		}
 
-		// case vdTY.DeviceTraitColorSetting: // https://developers.google.com/assistant/smarthome/traits/colorsetting#device-states
-		// 	params["color"] = ""
+	case vdTY.DeviceTraitFanSpeed: // https://developers.google.com/assistant/smarthome/traits/fanspeed#device-states
+		if len(deviceAPI.GetTraitValues(resource)) > 0 {
+			params["currentFanSpeedSetting"] = convertorUtil.ToString(resource.Value)
+		} else {
+			params["currentFanSpeedPercent"] = deviceAPI.ValueToPercent(resource, resource.Value)
+		}
+
+	case vdTY.DeviceTraitLockUnlock: // https://developers.google.com/assistant/smarthome/traits/lockunlock#device-states
+		params["isLocked"] = convertorUtil.ToBool(resource.Value)
+		params["isJammed"] = false
+
+	case vdTY.DeviceTraitArmDisarm: // https://developers.google.com/assistant/smarthome/traits/armdisarm#device-states
+		switch deviceAPI.GetTraitParameter(resource) {
+		case vdTY.TraitParameterArm:
+			params["isArmed"] = convertorUtil.ToBool(resource.Value)
+		case vdTY.TraitParameterArmLevel:
+			params["currentArmLevel"] = convertorUtil.ToString(resource.Value)
+		}
+
+	case vdTY.DeviceTraitStartStop: // https://developers.google.com/assistant/smarthome/traits/startstop#device-states
+		switch deviceAPI.GetTraitParameter(resource) {
+		case vdTY.TraitParameterStart:
+			params["isRunning"] = convertorUtil.ToBool(resource.Value)
+		case vdTY.TraitParameterPause:
+			params["isPaused"] = convertorUtil.ToBool(resource.Value)
+		}
+
+	case vdTY.DeviceTraitModes: // https://developers.google.com/assistant/smarthome/traits/modes#device-states
+		modeSettings, ok := params["currentModeSettings"].(map[string]interface{})
+		if !ok {
+			modeSettings = make(map[string]interface{})
+			params["currentModeSettings"] = modeSettings
+		}
+		modeSettings[deviceAPI.GetTraitName(resource)] = convertorUtil.ToString(resource.Value)
+
+	case vdTY.DeviceTraitSensorState: // https://developers.google.com/assistant/smarthome/traits/sensorstate#device-states
+		sensorState := map[string]interface{}{"name": deviceAPI.GetTraitName(resource)}
+		if len(deviceAPI.GetTraitValues(resource)) > 0 {
+			sensorState["currentSensorState"] = convertorUtil.ToString(resource.Value)
+		} else {
+			sensorState["rawValue"] = convertorUtil.ToFloat(resource.Value)
+		}
+		sensorStates, _ := params["currentSensorStateData"].([]map[string]interface{})
+		params["currentSensorStateData"] = append(sensorStates, sensorState)
 
 	default:
-		a.logger.Info("support not implemented for this trait", zap.String("trait", trait))
+		a.logger.Info("support not implemented for this trait", zap.String("trait", resource.TraitType))
 	}
-	return params, nil
 }
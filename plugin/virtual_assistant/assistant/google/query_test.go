@@ -0,0 +1,143 @@
+package google_assistant
+
+import (
+	"testing"
+
+	"github.com/mycontroller-org/server/v2/pkg/types/cmap"
+	vdTY "github.com/mycontroller-org/server/v2/pkg/types/virtual_device"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestUpdateResourceParams(t *testing.T) {
+	resource := func(name, trait string, labels cmap.CustomStringMap, value interface{}) vdTY.Resource {
+		return vdTY.Resource{Name: name, TraitType: trait, Labels: labels, Value: value}
+	}
+
+	tests := []struct {
+		name      string
+		resources []vdTY.Resource
+		params    map[string]interface{}
+	}{
+		{
+			name:      "on off",
+			resources: []vdTY.Resource{resource("power", vdTY.DeviceTraitOnOff, nil, "on")},
+			params:    map[string]interface{}{"on": true},
+		},
+		{
+			name:      "brightness from range",
+			resources: []vdTY.Resource{resource("dimmer", vdTY.DeviceTraitBrightness, cmap.CustomStringMap{vdTY.LabelTraitMin: "0", vdTY.LabelTraitMax: "255"}, "128")},
+			params:    map[string]interface{}{"brightness": int64(50)},
+		},
+		{
+			name:      "hex rgb",
+			resources: []vdTY.Resource{resource("rgb", vdTY.DeviceTraitColorSetting, cmap.CustomStringMap{vdTY.LabelTraitUnit: vdTY.TraitUnitHex}, "#ff0000")},
+			params:    map[string]interface{}{"color": map[string]interface{}{"spectrumRGB": int64(16711680)}},
+		},
+		{
+			name:      "color temperature",
+			resources: []vdTY.Resource{resource("ct", vdTY.DeviceTraitColorSetting, cmap.CustomStringMap{vdTY.LabelTraitParameter: vdTY.TraitParameterColorTemperature}, 3000)},
+			params:    map[string]interface{}{"color": map[string]interface{}{"temperatureK": int64(3000)}},
+		},
+		{
+			name: "rgb takes the precedence over color temperature",
+			resources: []vdTY.Resource{
+				resource("rgb", vdTY.DeviceTraitColorSetting, nil, 255),
+				resource("ct", vdTY.DeviceTraitColorSetting, cmap.CustomStringMap{vdTY.LabelTraitParameter: vdTY.TraitParameterColorTemperature}, 3000),
+			},
+			params: map[string]interface{}{"color": map[string]interface{}{"spectrumRGB": int64(255)}},
+		},
+		{
+			name: "color temperature replaced by rgb",
+			resources: []vdTY.Resource{
+				resource("ct", vdTY.DeviceTraitColorSetting, cmap.CustomStringMap{vdTY.LabelTraitParameter: vdTY.TraitParameterColorTemperature}, 3000),
+				resource("rgb", vdTY.DeviceTraitColorSetting, nil, 255),
+			},
+			params: map[string]interface{}{"color": map[string]interface{}{"spectrumRGB": int64(255)}},
+		},
+		{
+			name:      "open close",
+			resources: []vdTY.Resource{resource("blind", vdTY.DeviceTraitOpenClose, nil, 30)},
+			params:    map[string]interface{}{"openPercent": int64(30)},
+		},
+		{
+			name: "thermostat in fahrenheit",
+			resources: []vdTY.Resource{
+				resource("setpoint", vdTY.DeviceTraitTemperatureSetting, cmap.CustomStringMap{vdTY.LabelTraitUnit: vdTY.TraitUnitFahrenheit}, 68),
+				resource("ambient", vdTY.DeviceTraitTemperatureSetting, cmap.CustomStringMap{vdTY.LabelTraitParameter: vdTY.TraitParameterAmbient, vdTY.LabelTraitUnit: vdTY.TraitUnitFahrenheit}, "70"),
+				resource("mode", vdTY.DeviceTraitTemperatureSetting, cmap.CustomStringMap{vdTY.LabelTraitParameter: vdTY.TraitParameterMode}, "cool"),
+			},
+			params: map[string]interface{}{"thermostatTemperatureSetpoint": float64(20), "thermostatTemperatureAmbient": 21.1, "thermostatMode": "cool"},
+		},
+		{
+			name:      "default thermostat mode",
+			resources: []vdTY.Resource{resource("setpoint", vdTY.DeviceTraitTemperatureSetting, nil, 21.5)},
+			params:    map[string]interface{}{"thermostatTemperatureSetpoint": 21.5, "thermostatMode": defaultThermostatMode},
+		},
+		{
+			name:      "fan speed setting",
+			resources: []vdTY.Resource{resource("fan", vdTY.DeviceTraitFanSpeed, cmap.CustomStringMap{vdTY.LabelTraitValues: "low,high"}, "high")},
+			params:    map[string]interface{}{"currentFanSpeedSetting": "high"},
+		},
+		{
+			name:      "fan speed percent",
+			resources: []vdTY.Resource{resource("fan", vdTY.DeviceTraitFanSpeed, cmap.CustomStringMap{vdTY.LabelTraitMin: "0", vdTY.LabelTraitMax: "4"}, 1)},
+			params:    map[string]interface{}{"currentFanSpeedPercent": int64(25)},
+		},
+		{
+			name:      "lock",
+			resources: []vdTY.Resource{resource("lock", vdTY.DeviceTraitLockUnlock, nil, "true")},
+			params:    map[string]interface{}{"isLocked": true, "isJammed": false},
+		},
+		{
+			name: "arm with level",
+			resources: []vdTY.Resource{
+				resource("arm", vdTY.DeviceTraitArmDisarm, nil, 1),
+				resource("level", vdTY.DeviceTraitArmDisarm, cmap.CustomStringMap{vdTY.LabelTraitParameter: vdTY.TraitParameterArmLevel}, "away"),
+			},
+			params: map[string]interface{}{"isArmed": true, "currentArmLevel": "away"},
+		},
+		{
+			name: "start and pause",
+			resources: []vdTY.Resource{
+				resource("start", vdTY.DeviceTraitStartStop, nil, true),
+				resource("pause", vdTY.DeviceTraitStartStop, cmap.CustomStringMap{vdTY.LabelTraitParameter: vdTY.TraitParameterPause}, false),
+			},
+			params: map[string]interface{}{"isRunning": true, "isPaused": false},
+		},
+		{
+			name: "modes",
+			resources: []vdTY.Resource{
+				resource("wash_mode", vdTY.DeviceTraitModes, cmap.CustomStringMap{vdTY.LabelTraitName: "wash"}, "quick"),
+				resource("dry", vdTY.DeviceTraitModes, nil, "extra"),
+			},
+			params: map[string]interface{}{"currentModeSettings": map[string]interface{}{"wash": "quick", "dry": "extra"}},
+		},
+		{
+			name: "sensor states",
+			resources: []vdTY.Resource{
+				resource("air", vdTY.DeviceTraitSensorState, cmap.CustomStringMap{vdTY.LabelTraitName: "AirQuality", vdTY.LabelTraitValues: "healthy,unhealthy"}, "healthy"),
+				resource("co2", vdTY.DeviceTraitSensorState, cmap.CustomStringMap{vdTY.LabelTraitName: "CarbonDioxideLevel"}, "420"),
+			},
+			params: map[string]interface{}{"currentSensorStateData": []map[string]interface{}{
+				{"name": "AirQuality", "currentSensorState": "healthy"},
+				{"name": "CarbonDioxideLevel", "rawValue": float64(420)},
+			}},
+		},
+		{
+			name:      "unsupported trait",
+			resources: []vdTY.Resource{resource("volume", vdTY.DeviceTraitVolume, nil, 10)},
+			params:    map[string]interface{}{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a := &Assistant{logger: zap.NewNop()}
+			params := make(map[string]interface{})
+			for index := range test.resources {
+				a.updateResourceParams(params, &test.resources[index])
+			}
+			assert.Equal(t, test.params, params)
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package google_assistant
+
+import (
+	"time"
+
+	"github.com/mycontroller-org/server/v2/pkg/types"
+	eventTY "github.com/mycontroller-org/server/v2/pkg/types/event"
+	vdTY "github.com/mycontroller-org/server/v2/pkg/types/virtual_device"
+	"github.com/mycontroller-org/server/v2/pkg/utils"
+	storageTY "github.com/mycontroller-org/server/v2/plugin/database/storage/types"
+	gaTY "github.com/mycontroller-org/server/v2/plugin/virtual_assistant/assistant/google/types"
+	deviceAPI "github.com/mycontroller-org/server/v2/plugin/virtual_assistant/device_api"
+	"go.uber.org/zap"
+)
+
+// ReportState posts the current state of the devices to home graph
+// https://developers.google.com/assistant/smarthome/develop/report-state
+func (a *Assistant) ReportState(deviceIDs []string) error {
+	if a.homeGraph == nil || len(deviceIDs) == 0 {
+		return nil
+	}
+
+	filters := []storageTY.Filter{{Key: types.KeyID, Operator: storageTY.OperatorIn, Value: deviceIDs}}
+	vDevices, err := a.deviceAPI.ListDevices(filters, int64(len(deviceIDs)), 0, a.cfg.DeviceFilter)
+	if err != nil {
+		return err
+	}
+	if len(vDevices) == 0 {
+		return nil
+	}
+
+	// update resource state
+	err = a.deviceAPI.UpdateDeviceState(vDevices)
+	if err != nil {
+		return err
+	}
+
+	states := make(map[string]interface{})
+	for _, vDevice := range vDevices {
+		if _, found := gaTY.DeviceMap[vDevice.DeviceType]; !found {
+			continue
+		}
+		response, err := a.queryDeviceState(vDevice)
+		if err != nil {
+			return err
+		}
+		state := map[string]interface{}{"online": response.Online}
+		utils.JoinMap(state, response.Others)
+		states[vDevice.ID] = state
+	}
+	if len(states) == 0 {
+		return nil
+	}
+
+	return a.homeGraph.reportState(states)
+}
+
+// RequestSync asks google to sync the devices, when a device created, updated or deleted.
+// changes within the request sync delay are merged into a single request
+// https://developers.google.com/assistant/smarthome/develop/request-sync
+func (a *Assistant) RequestSync(eventType string, vDevice *vdTY.VirtualDevice) error {
+	if a.homeGraph == nil {
+		return nil
+	}
+
+	// updated device may moved out of the device filter, sync on all the updates
+	if eventType != eventTY.TypeUpdated && !deviceAPI.IsDeviceFilterMatching(a.cfg.DeviceFilter, vDevice) {
+		return nil
+	}
+
+	a.syncMutex.Lock()
+	defer a.syncMutex.Unlock()
+
+	if a.syncTimer != nil { // already scheduled
+		return nil
+	}
+
+	delay := utils.ToDuration(a.gaCfg.RequestSyncDelay, utils.ToDuration(gaTY.DefaultRequestSyncDelay, 0))
+	a.syncTimer = time.AfterFunc(delay, func() {
+		a.syncMutex.Lock()
+		a.syncTimer = nil
+		a.syncMutex.Unlock()
+
+		err := a.homeGraph.requestSync()
+		if err != nil {
+			a.logger.Error("error on request sync", zap.Error(err))
+		}
+	})
+	return nil
+}
+
+// stops the scheduled request sync, if any
+func (a *Assistant) stopRequestSync() {
+	a.syncMutex.Lock()
+	defer a.syncMutex.Unlock()
+
+	if a.syncTimer != nil {
+		a.syncTimer.Stop()
+		a.syncTimer = nil
+	}
+}
@@ -19,7 +19,7 @@ func (a *Assistant) runSyncRequest(request gaTY.Request) *gaTY.SyncResponse {
 	response := gaTY.SyncResponse{
 		RequestID: request.RequestID,
 		Payload: gaTY.SyncResponsePayload{
-			AgentUserId: AgentUserId,
+			AgentUserId: a.gaCfg.AgentUserID,
 			Devices:     devices,
 		},
 	}
@@ -51,8 +51,8 @@ func (a *Assistant) runSyncRequest(request gaTY.Request) *gaTY.SyncResponse {
 				Type:                         deviceType,
 				Traits:                       traits,
 				Name:                         gaTY.NameData{Name: vDevice.Name},
-				Attributes:                   nil,
-				WillReportState:              false,
+				Attributes:                   getDeviceAttributes(&vDevice),
+				WillReportState:              a.homeGraph != nil,
 				DeviceInfo:                   gaTY.DeviceInfo{Manufacturer: "MyController", SwVersion: ver.Version},
 				NotificationSupportedByAgent: false,
 				RoomHint:                     vDevice.Labels.Get(types.LabelRoom),
@@ -0,0 +1,26 @@
+package google_assistant
+
+import (
+	vdTY "github.com/mycontroller-org/server/v2/pkg/types/virtual_device"
+	deviceAPI "github.com/mycontroller-org/server/v2/plugin/virtual_assistant/device_api"
+)
+
+const (
+	defaultColorTemperatureMinK = 2000
+	defaultColorTemperatureMaxK = 9000
+	defaultLanguage             = "en"
+)
+
+// returns color temperature range in kelvin
+func getColorTemperatureRange(resource *vdTY.Resource) (int64, int64) {
+	min, max, found := deviceAPI.GetTraitRange(resource)
+	if !found {
+		return defaultColorTemperatureMinK, defaultColorTemperatureMaxK
+	}
+	return int64(min), int64(max)
+}
+
+// returns google synonym values, used on modes and arm levels
+func getSynonyms(key string, value string) []map[string]interface{} {
+	return []map[string]interface{}{{key: []string{value}, "lang": defaultLanguage}}
+}
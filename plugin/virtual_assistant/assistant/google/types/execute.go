@@ -15,6 +15,33 @@ const (
 	ExecutionStatusError      = "ERROR"
 )
 
+// Execution commands
+// https://developers.google.com/assistant/smarthome/traits
+const (
+	CommandOnOff                         = "action.devices.commands.OnOff"
+	CommandBrightnessAbsolute            = "action.devices.commands.BrightnessAbsolute"
+	CommandColorAbsolute                 = "action.devices.commands.ColorAbsolute"
+	CommandOpenClose                     = "action.devices.commands.OpenClose"
+	CommandThermostatTemperatureSetpoint = "action.devices.commands.ThermostatTemperatureSetpoint"
+	CommandThermostatSetMode             = "action.devices.commands.ThermostatSetMode"
+	CommandSetFanSpeed                   = "action.devices.commands.SetFanSpeed"
+	CommandLockUnlock                    = "action.devices.commands.LockUnlock"
+	CommandArmDisarm                     = "action.devices.commands.ArmDisarm"
+	CommandStartStop                     = "action.devices.commands.StartStop"
+	CommandPauseUnpause                  = "action.devices.commands.PauseUnpause"
+	CommandSetModes                      = "action.devices.commands.SetModes"
+)
+
+// Error codes
+// https://developers.google.com/assistant/smarthome/reference/errors-exceptions
+const (
+	ErrorCodeDeviceNotFound       = "deviceNotFound"
+	ErrorCodeFunctionNotSupported = "functionNotSupported"
+	ErrorCodeNotSupported         = "notSupported"
+	ErrorCodeValueOutOfRange      = "valueOutOfRange"
+	ErrorCodeTransientError       = "transientError"
+)
+
 // ExecuteRequest struct
 // https://developers.google.com/assistant/smarthome/reference/intent/execute#request
 type ExecuteRequest struct {
@@ -60,10 +87,10 @@ type ExecuteResponsePayload struct {
 }
 
 type ExecuteResponseCommand struct {
-	IDs       []string             `json:"ids"`              // required
-	Status    string               `json:"status"`           // required
-	States    ExecuteResponseState `json:"states,omitempty"` // optional
-	ErrorCode string               `json:"errorCode"`        // optional
+	IDs       []string             `json:"ids"`                 // required
+	Status    string               `json:"status"`              // required
+	States    ExecuteResponseState `json:"states,omitempty"`    // optional
+	ErrorCode string               `json:"errorCode,omitempty"` // optional
 }
 
 type ExecuteResponseState struct {
@@ -15,8 +15,8 @@ var (
 		vdTY.DeviceTypeAwning:                 "action.devices.types.AWNING",
 		vdTY.DeviceTypeBathtub:                "action.devices.types.BATHTUB",
 		vdTY.DeviceTypeBed:                    "action.devices.types.BED",
-		vdTY.DeviceTypeBlinds:                 "action.devices.types.BLENDER",
-		vdTY.DeviceTypeBlender:                "action.devices.types.BLINDS",
+		vdTY.DeviceTypeBlinds:                 "action.devices.types.BLINDS",
+		vdTY.DeviceTypeBlender:                "action.devices.types.BLENDER",
 		vdTY.DeviceTypeBoiler:                 "action.devices.types.BOILER",
 		vdTY.DeviceTypeCamera:                 "action.devices.types.CAMERA",
 		vdTY.DeviceTypeCarbonMonoxideDetector: "action.devices.types.CARBON_MONOXIDE_DETECTOR",
@@ -130,12 +130,19 @@ var (
 		vdTY.DeviceTraitVolume:             "action.devices.traits.Volume",
 	}
 
-	CommandParamsMap = map[string]string{
-		"on":         vdTY.DeviceTraitOnOff,
-		"brightness": vdTY.DeviceTraitBrightness,
-	}
-
-	IgnoreParamsList = []string{
-		"online",
+	// https://developers.google.com/assistant/smarthome/traits
+	CommandTraitMap = map[string]string{
+		CommandOnOff:                         vdTY.DeviceTraitOnOff,
+		CommandBrightnessAbsolute:            vdTY.DeviceTraitBrightness,
+		CommandColorAbsolute:                 vdTY.DeviceTraitColorSetting,
+		CommandOpenClose:                     vdTY.DeviceTraitOpenClose,
+		CommandThermostatTemperatureSetpoint: vdTY.DeviceTraitTemperatureSetting,
+		CommandThermostatSetMode:             vdTY.DeviceTraitTemperatureSetting,
+		CommandSetFanSpeed:                   vdTY.DeviceTraitFanSpeed,
+		CommandLockUnlock:                    vdTY.DeviceTraitLockUnlock,
+		CommandArmDisarm:                     vdTY.DeviceTraitArmDisarm,
+		CommandStartStop:                     vdTY.DeviceTraitStartStop,
+		CommandPauseUnpause:                  vdTY.DeviceTraitStartStop,
+		CommandSetModes:                      vdTY.DeviceTraitModes,
 	}
 )
@@ -0,0 +1,54 @@
+package types
+
+// defaults
+const (
+	DefaultTokenURL         = "https://oauth2.googleapis.com/token"
+	DefaultHomeGraphURL     = "https://homegraph.googleapis.com"
+	DefaultRequestSyncDelay = "5s"
+	DefaultTimeout          = "30s"
+
+	HomeGraphScope = "https://www.googleapis.com/auth/homegraph"
+)
+
+// Config of google assistant
+type Config struct {
+	AgentUserID      string `json:"agentUserId" yaml:"agentUserId"`
+	ReportState      bool   `json:"reportState" yaml:"reportState"`           // enables report state and request sync
+	ClientEmail      string `json:"clientEmail" yaml:"clientEmail"`           // service account client email
+	PrivateKey       string `json:"privateKey" yaml:"privateKey"`             // service account private key, in pem format
+	TokenURL         string `json:"tokenUrl" yaml:"tokenUrl"`                 // defaults to google oauth2 token url
+	HomeGraphURL     string `json:"homeGraphUrl" yaml:"homeGraphUrl"`         // defaults to google home graph url
+	Insecure         bool   `json:"insecure" yaml:"insecure"`                 // skips the certificate verification on the above urls
+	Timeout          string `json:"timeout" yaml:"timeout"`                   // http request timeout
+	RequestSyncDelay string `json:"requestSyncDelay" yaml:"requestSyncDelay"` // merges the device changes within this delay into a single request sync
+}
+
+// ReportStateRequest struct
+// https://developers.google.com/assistant/smarthome/reference/rest/v1/devices/reportStateAndNotification
+type ReportStateRequest struct {
+	RequestID   string             `json:"requestId"`
+	AgentUserID string             `json:"agentUserId"`
+	Payload     ReportStatePayload `json:"payload"`
+}
+
+type ReportStatePayload struct {
+	Devices ReportStateDevices `json:"devices"`
+}
+
+type ReportStateDevices struct {
+	States map[string]interface{} `json:"states"`
+}
+
+// RequestSyncRequest struct
+// https://developers.google.com/assistant/smarthome/reference/rest/v1/devices/requestSync
+type RequestSyncRequest struct {
+	AgentUserID string `json:"agentUserId"`
+	Async       bool   `json:"async"`
+}
+
+// TokenResponse struct, from oauth2 token url
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
@@ -0,0 +1,573 @@
+package homekit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/mycontroller-org/server/v2/pkg/json"
+	vdTY "github.com/mycontroller-org/server/v2/pkg/types/virtual_device"
+	convertorUtil "github.com/mycontroller-org/server/v2/pkg/utils/convertor"
+	"github.com/mycontroller-org/server/v2/pkg/version"
+	hkTY "github.com/mycontroller-org/server/v2/plugin/virtual_assistant/assistant/homekit/types"
+	deviceAPI "github.com/mycontroller-org/server/v2/plugin/virtual_assistant/device_api"
+)
+
+const (
+	defaultColorTemperatureMinK = 2000
+	defaultColorTemperatureMaxK = 6500
+)
+
+var (
+	errInvalidValue = errors.New("invalid value")
+)
+
+// resourceAction holds the payload to be posted on a trait resource
+type resourceAction struct {
+	resource *vdTY.Resource
+	payload  interface{}
+}
+
+// writeFunc converts the characteristic value into resource actions
+type writeFunc func(wc *writeContext, value interface{}) error
+
+// accessory of a virtual device with the write handlers of the characteristics
+type accessory struct {
+	aid      uint64
+	vDevice  *vdTY.VirtualDevice
+	services []hkTY.Service
+	writers  map[uint64]writeFunc
+}
+
+// returns the characteristic of the instance id
+func (ac *accessory) get(iid uint64) *hkTY.Characteristic {
+	for serviceIndex := range ac.services {
+		service := &ac.services[serviceIndex]
+		for index := range service.Characteristics {
+			if service.Characteristics[index].InstanceID == iid {
+				return &service.Characteristics[index]
+			}
+		}
+	}
+	return nil
+}
+
+func (ac *accessory) toAccessory() hkTY.Accessory {
+	return hkTY.Accessory{AccessoryID: ac.aid, Services: ac.services}
+}
+
+// accessoryBuilder assigns the instance ids in the order of services and characteristics
+type accessoryBuilder struct {
+	accessory *accessory
+	nextIID   uint64
+}
+
+func newAccessoryBuilder(aid uint64, vDevice *vdTY.VirtualDevice) *accessoryBuilder {
+	return &accessoryBuilder{
+		accessory: &accessory{aid: aid, vDevice: vDevice, services: make([]hkTY.Service, 0), writers: make(map[uint64]writeFunc)},
+		nextIID:   1,
+	}
+}
+
+func (b *accessoryBuilder) addService(serviceType string) {
+	b.accessory.services = append(b.accessory.services, hkTY.Service{
+		InstanceID:      b.nextIID,
+		Type:            serviceType,
+		Characteristics: make([]hkTY.Characteristic, 0),
+	})
+	b.nextIID++
+}
+
+// adds the characteristic into the last service, writable if the write function supplied
+func (b *accessoryBuilder) addCharacteristic(characteristic hkTY.Characteristic, write writeFunc) {
+	characteristic.InstanceID = b.nextIID
+	b.nextIID++
+	if len(characteristic.Perms) == 0 {
+		characteristic.Perms = []string{hkTY.PermRead, hkTY.PermEvents}
+		if write != nil {
+			characteristic.Perms = append(characteristic.Perms, hkTY.PermWrite)
+		}
+	}
+	if write != nil {
+		b.accessory.writers[characteristic.InstanceID] = write
+	}
+	service := &b.accessory.services[len(b.accessory.services)-1]
+	service.Characteristics = append(service.Characteristics, characteristic)
+}
+
+func (b *accessoryBuilder) hasServices() bool {
+	// accessory information service is always available
+	return len(b.accessory.services) > 1
+}
+
+// sets the primary service, should be called after adding all the services
+func (b *accessoryBuilder) build() *accessory {
+	services := b.accessory.services
+	for index := 1; index < len(services); index++ {
+		if services[index].Type != hkTY.ServiceProtocolInformation {
+			services[index].Primary = true
+			break
+		}
+	}
+	return b.accessory
+}
+
+// adds accessory information service
+func (b *accessoryBuilder) addInformation(name, model, serialNumber string) {
+	b.addService(hkTY.ServiceAccessoryInformation)
+	b.addCharacteristic(hkTY.Characteristic{Type: hkTY.CharacteristicIdentify, Format: hkTY.FormatBool, Perms: []string{hkTY.PermWrite}}, writeIdentify)
+	b.addCharacteristic(stringCharacteristic(hkTY.CharacteristicManufacturer, hkTY.DefaultManufacturer), nil)
+	b.addCharacteristic(stringCharacteristic(hkTY.CharacteristicModel, model), nil)
+	b.addCharacteristic(stringCharacteristic(hkTY.CharacteristicName, name), nil)
+	b.addCharacteristic(stringCharacteristic(hkTY.CharacteristicSerialNumber, serialNumber), nil)
+	b.addCharacteristic(stringCharacteristic(hkTY.CharacteristicFirmwareRevision, getFirmwareRevision()), nil)
+}
+
+// getBridgeAccessory returns the bridge accessory, always the first accessory on the database
+func getBridgeAccessory(name, accessoryID string) *accessory {
+	b := newAccessoryBuilder(hkTY.BridgeAccessoryID, nil)
+	b.addInformation(name, hkTY.DefaultModel, accessoryID)
+	b.addService(hkTY.ServiceProtocolInformation)
+	b.addCharacteristic(stringCharacteristic(hkTY.CharacteristicVersion, hkTY.ProtocolVersion), nil)
+	return b.build()
+}
+
+// getAccessory returns bridged accessory of the virtual device, returns nil if none of the traits supported.
+// resource values should be updated before calling this
+func getAccessory(aid uint64, vDevice *vdTY.VirtualDevice) *accessory {
+	b := newAccessoryBuilder(aid, vDevice)
+	model := vDevice.DeviceType
+	if model == "" {
+		model = hkTY.DefaultModel
+	}
+	b.addInformation(vDevice.Name, model, vDevice.ID)
+
+	switch {
+	case hasTrait(vDevice, vdTY.DeviceTraitTemperatureSetting):
+		addThermostat(b, vDevice)
+
+	case hasTrait(vDevice, vdTY.DeviceTraitLockUnlock):
+		addLock(b, vDevice)
+
+	case hasTrait(vDevice, vdTY.DeviceTraitOpenClose):
+		switch vDevice.DeviceType {
+		case vdTY.DeviceTypeGarageDoor, vdTY.DeviceTypeGate:
+			addGarageDoor(b, vDevice)
+		case vdTY.DeviceTypeDoor:
+			addPosition(b, vDevice, hkTY.ServiceDoor)
+		case vdTY.DeviceTypeWindow:
+			addPosition(b, vDevice, hkTY.ServiceWindow)
+		default:
+			addPosition(b, vDevice, hkTY.ServiceWindowCovering)
+		}
+
+	case hasTrait(vDevice, vdTY.DeviceTraitFanSpeed) || vDevice.DeviceType == vdTY.DeviceTypeFan:
+		addFan(b, vDevice)
+
+	case vDevice.DeviceType == vdTY.DeviceTypeLight,
+		hasTrait(vDevice, vdTY.DeviceTraitBrightness),
+		hasTrait(vDevice, vdTY.DeviceTraitColorSetting):
+		addLightbulb(b, vDevice)
+
+	case hasTrait(vDevice, vdTY.DeviceTraitOnOff):
+		if vDevice.DeviceType == vdTY.DeviceTypeOutlet {
+			addOutlet(b, vDevice)
+		} else {
+			addSwitch(b, vDevice)
+		}
+	}
+
+	// sensors are added as additional services
+	for index := range vDevice.Traits {
+		resource := &vDevice.Traits[index]
+		if resource.TraitType == vdTY.DeviceTraitSensorState {
+			addSensor(b, resource)
+		}
+	}
+
+	if !b.hasServices() {
+		return nil
+	}
+	return b.build()
+}
+
+func addSwitch(b *accessoryBuilder, vDevice *vdTY.VirtualDevice) {
+	b.addService(hkTY.ServiceSwitch)
+	b.addCharacteristic(onCharacteristic(vDevice), writeOn)
+}
+
+func addOutlet(b *accessoryBuilder, vDevice *vdTY.VirtualDevice) {
+	b.addService(hkTY.ServiceOutlet)
+	on := onCharacteristic(vDevice)
+	b.addCharacteristic(on, writeOn)
+	b.addCharacteristic(hkTY.Characteristic{Type: hkTY.CharacteristicOutletInUse, Format: hkTY.FormatBool, Value: on.Value}, nil)
+}
+
+func addLightbulb(b *accessoryBuilder, vDevice *vdTY.VirtualDevice) {
+	b.addService(hkTY.ServiceLightbulb)
+	b.addCharacteristic(onCharacteristic(vDevice), writeOn)
+
+	if resource := deviceAPI.GetTraitResource(vDevice, vdTY.DeviceTraitBrightness, ""); resource != nil {
+		b.addCharacteristic(percentCharacteristic(hkTY.CharacteristicBrightness, hkTY.FormatInt, deviceAPI.ValueToPercent(resource, resource.Value)), writeBrightness)
+	}
+
+	if resource := deviceAPI.GetTraitResource(vDevice, vdTY.DeviceTraitColorSetting, vdTY.TraitParameterRGB); resource != nil {
+		hue, saturation := deviceAPI.RGBToHueSaturation(deviceAPI.ValueToRGB(resource, resource.Value))
+		b.addCharacteristic(hkTY.Characteristic{
+			Type: hkTY.CharacteristicHue, Format: hkTY.FormatFloat, Unit: hkTY.UnitArcDegrees,
+			MinValue: toFloat(0), MaxValue: toFloat(360), MinStep: toFloat(1), Value: math.Round(hue),
+		}, writeHue)
+		b.addCharacteristic(hkTY.Characteristic{
+			Type: hkTY.CharacteristicSaturation, Format: hkTY.FormatFloat, Unit: hkTY.UnitPercentage,
+			MinValue: toFloat(0), MaxValue: toFloat(100), MinStep: toFloat(1), Value: math.Round(saturation * 100),
+		}, writeSaturation)
+	}
+
+	if resource := deviceAPI.GetTraitResource(vDevice, vdTY.DeviceTraitColorSetting, vdTY.TraitParameterColorTemperature); resource != nil {
+		minK, maxK := getColorTemperatureRange(resource)
+		b.addCharacteristic(hkTY.Characteristic{
+			Type: hkTY.CharacteristicColorTemperature, Format: hkTY.FormatUInt32,
+			MinValue: toFloat(float64(kelvinToMireds(maxK))), MaxValue: toFloat(float64(kelvinToMireds(minK))), MinStep: toFloat(1),
+			Value: kelvinToMireds(convertorUtil.ToInteger(resource.Value)),
+		}, writeColorTemperature)
+	}
+}
+
+func addFan(b *accessoryBuilder, vDevice *vdTY.VirtualDevice) {
+	b.addService(hkTY.ServiceFan)
+	active := int64(0)
+	if convertorUtil.ToBool(onCharacteristic(vDevice).Value) {
+		active = 1
+	}
+	b.addCharacteristic(hkTY.Characteristic{
+		Type: hkTY.CharacteristicActive, Format: hkTY.FormatUInt8,
+		MinValue: toFloat(0), MaxValue: toFloat(1), MinStep: toFloat(1), Value: active,
+	}, writeActive)
+	if resource := deviceAPI.GetTraitResource(vDevice, vdTY.DeviceTraitFanSpeed, ""); resource != nil {
+		b.addCharacteristic(percentCharacteristic(hkTY.CharacteristicRotationSpeed, hkTY.FormatFloat, deviceAPI.ValueToSpeedPercent(resource, resource.Value)), writeRotationSpeed)
+	}
+}
+
+func addPosition(b *accessoryBuilder, vDevice *vdTY.VirtualDevice, serviceType string) {
+	resource := deviceAPI.GetTraitResource(vDevice, vdTY.DeviceTraitOpenClose, "")
+	position := deviceAPI.ValueToPercent(resource, resource.Value)
+	b.addService(serviceType)
+	b.addCharacteristic(percentCharacteristic(hkTY.CharacteristicCurrentPosition, hkTY.FormatUInt8, position), nil)
+	b.addCharacteristic(percentCharacteristic(hkTY.CharacteristicTargetPosition, hkTY.FormatUInt8, position), writeTargetPosition)
+	b.addCharacteristic(hkTY.Characteristic{
+		Type: hkTY.CharacteristicPositionState, Format: hkTY.FormatUInt8,
+		MinValue: toFloat(0), MaxValue: toFloat(2), MinStep: toFloat(1), Value: hkTY.PositionStateStopped,
+	}, nil)
+}
+
+func addGarageDoor(b *accessoryBuilder, vDevice *vdTY.VirtualDevice) {
+	resource := deviceAPI.GetTraitResource(vDevice, vdTY.DeviceTraitOpenClose, "")
+	doorState := hkTY.DoorStateClosed
+	if deviceAPI.ValueToPercent(resource, resource.Value) > 0 {
+		doorState = hkTY.DoorStateOpen
+	}
+	b.addService(hkTY.ServiceGarageDoorOpener)
+	b.addCharacteristic(hkTY.Characteristic{
+		Type: hkTY.CharacteristicCurrentDoorState, Format: hkTY.FormatUInt8,
+		MinValue: toFloat(0), MaxValue: toFloat(4), MinStep: toFloat(1), Value: doorState,
+	}, nil)
+	b.addCharacteristic(hkTY.Characteristic{
+		Type: hkTY.CharacteristicTargetDoorState, Format: hkTY.FormatUInt8,
+		MinValue: toFloat(0), MaxValue: toFloat(1), MinStep: toFloat(1), Value: doorState,
+	}, writeTargetDoorState)
+	b.addCharacteristic(hkTY.Characteristic{Type: hkTY.CharacteristicObstructionDetected, Format: hkTY.FormatBool, Value: false}, nil)
+}
+
+func addLock(b *accessoryBuilder, vDevice *vdTY.VirtualDevice) {
+	resource := deviceAPI.GetTraitResource(vDevice, vdTY.DeviceTraitLockUnlock, "")
+	lockState := hkTY.LockUnsecured
+	if convertorUtil.ToBool(resource.Value) {
+		lockState = hkTY.LockSecured
+	}
+	b.addService(hkTY.ServiceLockMechanism)
+	b.addCharacteristic(hkTY.Characteristic{
+		Type: hkTY.CharacteristicLockCurrentState, Format: hkTY.FormatUInt8,
+		MinValue: toFloat(0), MaxValue: toFloat(3), MinStep: toFloat(1), Value: lockState,
+	}, nil)
+	b.addCharacteristic(hkTY.Characteristic{
+		Type: hkTY.CharacteristicLockTargetState, Format: hkTY.FormatUInt8,
+		MinValue: toFloat(0), MaxValue: toFloat(1), MinStep: toFloat(1), Value: lockState,
+	}, writeLockTargetState)
+}
+
+func addThermostat(b *accessoryBuilder, vDevice *vdTY.VirtualDevice) {
+	modeResource := deviceAPI.GetTraitResource(vDevice, vdTY.DeviceTraitTemperatureSetting, vdTY.TraitParameterMode)
+	setpointResource := deviceAPI.GetTraitResource(vDevice, vdTY.DeviceTraitTemperatureSetting, vdTY.TraitParameterSetpoint)
+	ambientResource := deviceAPI.GetTraitResource(vDevice, vdTY.DeviceTraitTemperatureSetting, vdTY.TraitParameterAmbient)
+
+	setpoint := 0.0
+	if setpointResource != nil {
+		setpoint = deviceAPI.ValueToCelsius(setpointResource, setpointResource.Value)
+	}
+	ambient := setpoint
+	if ambientResource != nil {
+		ambient = deviceAPI.ValueToCelsius(ambientResource, ambientResource.Value)
+	}
+
+	targetMode := int64(hkTY.HeatingCoolingAuto)
+	validValues := []int{hkTY.HeatingCoolingOff, hkTY.HeatingCoolingHeat, hkTY.HeatingCoolingCool, hkTY.HeatingCoolingAuto}
+	if modeResource != nil {
+		targetMode = toHeatingCooling(convertorUtil.ToString(modeResource.Value))
+		if values := getHeatingCoolingValues(modeResource); len(values) > 0 {
+			validValues = values
+		}
+	}
+	// current state supports only off, heat and cool
+	currentMode := targetMode
+	if currentMode == hkTY.HeatingCoolingAuto {
+		currentMode = hkTY.HeatingCoolingHeat
+		if ambient > setpoint {
+			currentMode = hkTY.HeatingCoolingCool
+		}
+	}
+
+	var writeTargetMode writeFunc
+	if modeResource != nil {
+		writeTargetMode = writeTargetHeatingCoolingState
+	}
+	var writeSetpoint writeFunc
+	if setpointResource != nil {
+		writeSetpoint = writeTargetTemperature
+	}
+
+	b.addService(hkTY.ServiceThermostat)
+	b.addCharacteristic(hkTY.Characteristic{
+		Type: hkTY.CharacteristicCurrentHeatingCoolingState, Format: hkTY.FormatUInt8,
+		MinValue: toFloat(0), MaxValue: toFloat(2), MinStep: toFloat(1), Value: currentMode,
+	}, nil)
+	b.addCharacteristic(hkTY.Characteristic{
+		Type: hkTY.CharacteristicTargetHeatingCoolingState, Format: hkTY.FormatUInt8,
+		MinValue: toFloat(0), MaxValue: toFloat(3), MinStep: toFloat(1), ValidValues: validValues, Value: targetMode,
+	}, writeTargetMode)
+	b.addCharacteristic(temperatureCharacteristic(hkTY.CharacteristicCurrentTemperature, ambient, -270, 100), nil)
+	b.addCharacteristic(temperatureCharacteristic(hkTY.CharacteristicTargetTemperature, clampFloat(setpoint, 10, 38), 10, 38), writeSetpoint)
+	b.addCharacteristic(hkTY.Characteristic{
+		Type: hkTY.CharacteristicTemperatureDisplayUnits, Format: hkTY.FormatUInt8,
+		MinValue: toFloat(0), MaxValue: toFloat(1), MinStep: toFloat(1), Value: hkTY.TemperatureDisplayCelsius,
+	}, writeTemperatureDisplayUnits)
+}
+
+// adds the sensor service, based on the name of the sensor_state resource
+func addSensor(b *accessoryBuilder, resource *vdTY.Resource) {
+	serviceType, found := hkTY.SensorServiceMap[strings.ToLower(deviceAPI.GetTraitName(resource))]
+	if !found {
+		return
+	}
+	b.addService(serviceType)
+	switch serviceType {
+	case hkTY.ServiceTemperatureSensor:
+		b.addCharacteristic(temperatureCharacteristic(hkTY.CharacteristicCurrentTemperature, deviceAPI.ValueToCelsius(resource, resource.Value), -270, 100), nil)
+
+	case hkTY.ServiceHumiditySensor:
+		humidity := clampFloat(math.Round(convertorUtil.ToFloat(resource.Value)), 0, 100)
+		b.addCharacteristic(percentCharacteristic(hkTY.CharacteristicCurrentRelativeHumidity, hkTY.FormatFloat, int64(humidity)), nil)
+
+	case hkTY.ServiceLightSensor:
+		b.addCharacteristic(hkTY.Characteristic{
+			Type: hkTY.CharacteristicCurrentAmbientLightLevel, Format: hkTY.FormatFloat, Unit: hkTY.UnitLux,
+			MinValue: toFloat(0.0001), MaxValue: toFloat(100000),
+			Value: clampFloat(convertorUtil.ToFloat(resource.Value), 0.0001, 100000),
+		}, nil)
+
+	case hkTY.ServiceMotionSensor:
+		b.addCharacteristic(hkTY.Characteristic{Type: hkTY.CharacteristicMotionDetected, Format: hkTY.FormatBool, Value: convertorUtil.ToBool(resource.Value)}, nil)
+
+	case hkTY.ServiceOccupancySensor:
+		b.addCharacteristic(boolUInt8Characteristic(hkTY.CharacteristicOccupancyDetected, convertorUtil.ToBool(resource.Value)), nil)
+
+	case hkTY.ServiceContactSensor:
+		// true on contact, that is closed
+		b.addCharacteristic(boolUInt8Characteristic(hkTY.CharacteristicContactSensorState, !convertorUtil.ToBool(resource.Value)), nil)
+	}
+}
+
+func hasTrait(vDevice *vdTY.VirtualDevice, trait string) bool {
+	for index := range vDevice.Traits {
+		if vDevice.Traits[index].TraitType == trait {
+			return true
+		}
+	}
+	return false
+}
+
+// returns "on" characteristic, takes brightness if on off resource not available
+func onCharacteristic(vDevice *vdTY.VirtualDevice) hkTY.Characteristic {
+	on := false
+	if resource := deviceAPI.GetTraitResource(vDevice, vdTY.DeviceTraitOnOff, ""); resource != nil {
+		on = convertorUtil.ToBool(resource.Value)
+	} else if resource := deviceAPI.GetTraitResource(vDevice, vdTY.DeviceTraitBrightness, ""); resource != nil {
+		on = deviceAPI.ValueToPercent(resource, resource.Value) > 0
+	} else if resource := deviceAPI.GetTraitResource(vDevice, vdTY.DeviceTraitFanSpeed, ""); resource != nil {
+		on = deviceAPI.ValueToSpeedPercent(resource, resource.Value) > 0
+	}
+	return hkTY.Characteristic{Type: hkTY.CharacteristicOn, Format: hkTY.FormatBool, Value: on}
+}
+
+func stringCharacteristic(characteristicType, value string) hkTY.Characteristic {
+	return hkTY.Characteristic{Type: characteristicType, Format: hkTY.FormatString, Perms: []string{hkTY.PermRead}, Value: value}
+}
+
+func percentCharacteristic(characteristicType, format string, percent int64) hkTY.Characteristic {
+	return hkTY.Characteristic{
+		Type: characteristicType, Format: format, Unit: hkTY.UnitPercentage,
+		MinValue: toFloat(0), MaxValue: toFloat(100), MinStep: toFloat(1), Value: clampInt(percent, 0, 100),
+	}
+}
+
+func temperatureCharacteristic(characteristicType string, celsius, min, max float64) hkTY.Characteristic {
+	return hkTY.Characteristic{
+		Type: characteristicType, Format: hkTY.FormatFloat, Unit: hkTY.UnitCelsius,
+		MinValue: toFloat(min), MaxValue: toFloat(max), MinStep: toFloat(0.1), Value: math.Round(celsius*10) / 10,
+	}
+}
+
+func boolUInt8Characteristic(characteristicType string, value bool) hkTY.Characteristic {
+	intValue := 0
+	if value {
+		intValue = 1
+	}
+	return hkTY.Characteristic{
+		Type: characteristicType, Format: hkTY.FormatUInt8,
+		MinValue: toFloat(0), MaxValue: toFloat(1), MinStep: toFloat(1), Value: intValue,
+	}
+}
+
+// getDatabaseHash returns hash of the accessory database without the values,
+// used to detect the configuration change
+func getDatabaseHash(accessories []hkTY.Accessory) (string, error) {
+	items := make([]hkTY.Accessory, 0, len(accessories))
+	for _, item := range accessories {
+		services := make([]hkTY.Service, 0, len(item.Services))
+		for _, service := range item.Services {
+			characteristics := make([]hkTY.Characteristic, 0, len(service.Characteristics))
+			for _, characteristic := range service.Characteristics {
+				// static values, like the name, are part of the configuration
+				if characteristic.Format != hkTY.FormatString {
+					characteristic.Value = nil
+				}
+				characteristics = append(characteristics, characteristic)
+			}
+			service.Characteristics = characteristics
+			services = append(services, service)
+		}
+		items = append(items, hkTY.Accessory{AccessoryID: item.AccessoryID, Services: services})
+	}
+	dataBytes, err := json.Marshal(items)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(dataBytes)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// returns the supported heating cooling states of the mode resource
+func getHeatingCoolingValues(resource *vdTY.Resource) []int {
+	values := make([]int, 0)
+	added := make(map[int64]bool)
+	for _, value := range deviceAPI.GetTraitValues(resource) {
+		if state, found := hkTY.HeatingCoolingMap[strings.ToLower(value)]; found && !added[state] {
+			added[state] = true
+			values = append(values, int(state))
+		}
+	}
+	return values
+}
+
+func toHeatingCooling(mode string) int64 {
+	state, found := hkTY.HeatingCoolingMap[strings.ToLower(mode)]
+	if !found {
+		return hkTY.HeatingCoolingAuto
+	}
+	return state
+}
+
+// returns thermostat mode value for the heating cooling state.
+// takes the supported values of the resource, if defined
+func getThermostatMode(resource *vdTY.Resource, state int64) (string, bool) {
+	values := deviceAPI.GetTraitValues(resource)
+	if len(values) == 0 {
+		mode, found := hkTY.HeatingCoolingNames[state]
+		return mode, found
+	}
+	for _, value := range values {
+		if mappedState, found := hkTY.HeatingCoolingMap[strings.ToLower(value)]; found && mappedState == state {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// returns color temperature range in kelvin
+func getColorTemperatureRange(resource *vdTY.Resource) (int64, int64) {
+	min, max, found := deviceAPI.GetTraitRange(resource)
+	if !found {
+		return defaultColorTemperatureMinK, defaultColorTemperatureMaxK
+	}
+	return int64(min), int64(max)
+}
+
+// converts kelvin into mireds and vice versa
+func kelvinToMireds(value int64) int64 {
+	if value <= 0 {
+		return 0
+	}
+	return int64(math.Round(1000000 / float64(value)))
+}
+
+func getFirmwareRevision() string {
+	// firmware revision should be in the format x[.y[.z]]
+	revision := strings.TrimPrefix(version.Get().Version, "v")
+	if index := strings.IndexFunc(revision, func(r rune) bool { return (r < '0' || r > '9') && r != '.' }); index >= 0 {
+		revision = revision[:index]
+	}
+	revision = strings.Trim(revision, ".")
+	if revision == "" {
+		return "1.0.0"
+	}
+	return revision
+}
+
+func toFloat(value float64) *float64 {
+	return &value
+}
+
+func clampInt(value, min, max int64) int64 {
+	if value < min {
+		return min
+	}
+	if value > max {
+		return max
+	}
+	return value
+}
+
+func clampFloat(value, min, max float64) float64 {
+	return math.Max(min, math.Min(max, value))
+}
+
+// returns the characteristic key, "aid.iid"
+func characteristicKey(aid, iid uint64) string {
+	return fmt.Sprintf("%d.%d", aid, iid)
+}
+
+// parses the characteristic key
+func parseCharacteristicKey(key string) (uint64, uint64, error) {
+	parts := strings.Split(key, ".")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid characteristic id: %s", key)
+	}
+	aid := uint64(convertorUtil.ToInteger(parts[0]))
+	iid := uint64(convertorUtil.ToInteger(parts[1]))
+	if aid == 0 || iid == 0 {
+		return 0, 0, fmt.Errorf("invalid characteristic id: %s", key)
+	}
+	return aid, iid, nil
+}
@@ -0,0 +1,206 @@
+package homekit
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	entityAPI "github.com/mycontroller-org/server/v2/pkg/api/entities"
+	"github.com/mycontroller-org/server/v2/pkg/utils"
+	handlerUtils "github.com/mycontroller-org/server/v2/pkg/utils/http_handler"
+	loggerUtils "github.com/mycontroller-org/server/v2/pkg/utils/logger"
+	hkTY "github.com/mycontroller-org/server/v2/plugin/virtual_assistant/assistant/homekit/types"
+	deviceAPI "github.com/mycontroller-org/server/v2/plugin/virtual_assistant/device_api"
+	vaTY "github.com/mycontroller-org/server/v2/plugin/virtual_assistant/types"
+	"go.uber.org/zap"
+)
+
+// HomeKit bridge exposes the virtual devices as bridged accessories, implements the HomeKit Accessory Protocol over IP.
+// the accessory server listens on the configured port and advertised over mdns.
+// the following routes are available under the assistant path
+//   GET  setup - setup code and setup uri (used to generate QR code) with pairing status
+//   POST reset - removes all the pairings and the pair setup attempts, the bridge can be paired again
+
+const (
+	PluginHomeKitBridge = "homekit_bridge"
+	loggerName          = "virtual_assistant_homekit"
+
+	pathSetup = "setup"
+	pathReset = "reset"
+)
+
+type Assistant struct {
+	ctx       context.Context
+	logger    *zap.Logger
+	cfg       *vaTY.Config
+	hkCfg     *hkTY.Config
+	api       *entityAPI.API
+	deviceAPI *deviceAPI.DeviceAPI
+	state     *stateStore
+	mdns      *mdnsResponder
+
+	pairSetupMutex sync.Mutex
+	pairSetupOwner *connection
+
+	listener         net.Listener
+	connections      map[*connection]bool
+	connectionsMutex sync.RWMutex
+
+	values      map[string]string // last known characteristic values, used to send the events only on change
+	valuesMutex sync.Mutex
+}
+
+func New(ctx context.Context, cfg *vaTY.Config) (vaTY.Plugin, error) {
+	logger, err := loggerUtils.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	api, err := entityAPI.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	_deviceAPI, err := deviceAPI.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	hkCfg := &hkTY.Config{}
+	err = utils.MapToStruct(utils.TagNameNone, cfg.Config, hkCfg)
+	if err != nil {
+		return nil, err
+	}
+	if hkCfg.Name == "" {
+		hkCfg.Name = hkTY.DefaultName
+	}
+	if hkCfg.Port <= 0 {
+		hkCfg.Port = hkTY.DefaultPort
+	}
+
+	return &Assistant{
+		ctx:         ctx,
+		logger:      logger.Named(loggerName),
+		cfg:         cfg,
+		hkCfg:       hkCfg,
+		api:         api,
+		deviceAPI:   _deviceAPI,
+		connections: make(map[*connection]bool),
+		values:      make(map[string]string),
+	}, nil
+}
+
+func (a *Assistant) Name() string {
+	return PluginHomeKitBridge
+}
+
+func (a *Assistant) Start() error {
+	// generates the bridge identity on the first start
+	state, err := newStateStore(a.api, a.cfg.ID, a.hkCfg)
+	if err != nil {
+		return err
+	}
+	a.state = state
+
+	// updates the configuration number, if devices changed when the bridge was not running
+	_, err = a.getAccessoryDatabase()
+	if err != nil {
+		return err
+	}
+
+	err = a.startServer()
+	if err != nil {
+		return err
+	}
+
+	mdns, err := newMDNSResponder(a)
+	if err != nil {
+		a.stopServer()
+		return err
+	}
+	a.mdns = mdns
+	a.announce()
+
+	a.logger.Info("homekit bridge started", zap.String("name", a.hkCfg.Name), zap.Int("port", a.hkCfg.Port), zap.Bool("paired", a.state.isPaired()))
+	return nil
+}
+
+func (a *Assistant) Stop() error {
+	if a.mdns != nil {
+		a.mdns.goodbye()
+		_ = a.mdns.close()
+	}
+	a.stopServer()
+	return nil
+}
+
+func (a *Assistant) Config() *vaTY.Config {
+	return a.cfg
+}
+
+// announce advertises the updated txt record, pairing status and configuration number
+func (a *Assistant) announce() {
+	if a.mdns != nil {
+		a.mdns.announce()
+	}
+}
+
+func (a *Assistant) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if a.state == nil {
+		http.Error(w, "bridge not started", http.StatusServiceUnavailable)
+		return
+	}
+
+	switch strings.Trim(r.URL.Path, "/") {
+	case pathSetup:
+		handlerUtils.PostSuccessResponse(w, a.getSetupInfo())
+
+	case pathReset:
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		err := a.state.removeAllPairings()
+		if err != nil {
+			a.logger.Error("error on removing pairings", zap.Error(err))
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		a.closeUnpairedConnections()
+		a.announce()
+		a.logger.Info("removed all the pairings")
+		handlerUtils.PostSuccessResponse(w, a.getSetupInfo())
+
+	default:
+		http.Error(w, "unknown path", http.StatusNotFound)
+	}
+}
+
+func (a *Assistant) getSetupInfo() *hkTY.SetupInfo {
+	pairings := a.state.listPairings()
+	return &hkTY.SetupInfo{
+		Name:              a.hkCfg.Name,
+		AccessoryID:       a.hkCfg.AccessoryID,
+		SetupCode:         a.hkCfg.SetupCode,
+		SetupURI:          getSetupURI(a.hkCfg.SetupCode, a.hkCfg.SetupID, hkTY.CategoryBridge),
+		Port:              a.hkCfg.Port,
+		Paired:            len(pairings) > 0,
+		Pairings:          len(pairings),
+		PairSetupAttempts: a.state.pairSetupAttempts(),
+	}
+}
+
+// getSetupURI returns the uri encoded on the QR code, example: "X-HM://0023ISYWYABCD"
+func getSetupURI(setupCode, setupID string, category uint64) string {
+	code, err := strconv.ParseUint(strings.ReplaceAll(setupCode, "-", ""), 10, 64)
+	if err != nil {
+		return ""
+	}
+	// category, ip transport flag and setup code
+	payload := category<<31 | 1<<28 | code
+	return fmt.Sprintf("X-HM://%09s%s", strings.ToUpper(strconv.FormatUint(payload, 36)), setupID)
+}
@@ -0,0 +1,258 @@
+package homekit
+
+import (
+	"fmt"
+	"math"
+
+	vdTY "github.com/mycontroller-org/server/v2/pkg/types/virtual_device"
+	convertorUtil "github.com/mycontroller-org/server/v2/pkg/utils/convertor"
+	hkTY "github.com/mycontroller-org/server/v2/plugin/virtual_assistant/assistant/homekit/types"
+	deviceAPI "github.com/mycontroller-org/server/v2/plugin/virtual_assistant/device_api"
+)
+
+// writeContext collects the resource actions of the characteristics written on a single request.
+// hue and saturation are received as separate characteristics, combined into a rgb value
+type writeContext struct {
+	vDevice    *vdTY.VirtualDevice
+	actions    []resourceAction
+	on         *bool
+	brightness bool
+	hue        *float64
+	saturation *float64
+	identify   bool
+}
+
+func newWriteContext(vDevice *vdTY.VirtualDevice) *writeContext {
+	return &writeContext{vDevice: vDevice, actions: make([]resourceAction, 0)}
+}
+
+func (wc *writeContext) add(resource *vdTY.Resource, payload interface{}) {
+	wc.actions = append(wc.actions, resourceAction{resource: resource, payload: payload})
+}
+
+// adds percentage action, scaled to the resource range
+func (wc *writeContext) addPercent(trait string, percent int64) error {
+	resource := deviceAPI.GetTraitResource(wc.vDevice, trait, "")
+	if resource == nil {
+		return fmt.Errorf("%s resource not available", trait)
+	}
+	payload, err := deviceAPI.PercentToValue(resource, percent)
+	if err != nil {
+		return err
+	}
+	wc.add(resource, payload)
+	return nil
+}
+
+// finish adds the pending actions, should be called after all the characteristics of the accessory written
+func (wc *writeContext) finish() error {
+	// devices without on off resource, brightness takes the precedence
+	if wc.on != nil && !wc.brightness {
+		if resource := deviceAPI.GetTraitResource(wc.vDevice, vdTY.DeviceTraitBrightness, ""); resource != nil {
+			isOn := deviceAPI.ValueToPercent(resource, resource.Value) > 0
+			if *wc.on != isOn {
+				percent := int64(0)
+				if *wc.on {
+					percent = 100
+				}
+				if err := wc.addPercent(vdTY.DeviceTraitBrightness, percent); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if wc.hue != nil || wc.saturation != nil {
+		resource := deviceAPI.GetTraitResource(wc.vDevice, vdTY.DeviceTraitColorSetting, vdTY.TraitParameterRGB)
+		if resource == nil {
+			return fmt.Errorf("rgb resource not available")
+		}
+		// keeps the current value, if only one of them received
+		hue, saturation := deviceAPI.RGBToHueSaturation(deviceAPI.ValueToRGB(resource, resource.Value))
+		if wc.hue != nil {
+			hue = *wc.hue
+		}
+		if wc.saturation != nil {
+			saturation = *wc.saturation / 100
+		}
+		wc.add(resource, deviceAPI.RGBToValue(resource, deviceAPI.HueSaturationToRGB(hue, saturation)))
+	}
+	return nil
+}
+
+func writeIdentify(wc *writeContext, value interface{}) error {
+	wc.identify = convertorUtil.ToBool(value)
+	return nil
+}
+
+func writeOn(wc *writeContext, value interface{}) error {
+	on := convertorUtil.ToBool(value)
+	if resource := deviceAPI.GetTraitResource(wc.vDevice, vdTY.DeviceTraitOnOff, ""); resource != nil {
+		wc.add(resource, on)
+		return nil
+	}
+	if deviceAPI.GetTraitResource(wc.vDevice, vdTY.DeviceTraitBrightness, "") == nil {
+		return fmt.Errorf("%s resource not available", vdTY.DeviceTraitOnOff)
+	}
+	wc.on = &on
+	return nil
+}
+
+func writeBrightness(wc *writeContext, value interface{}) error {
+	percent, err := toPercent(value)
+	if err != nil {
+		return err
+	}
+	wc.brightness = true
+	return wc.addPercent(vdTY.DeviceTraitBrightness, percent)
+}
+
+func writeHue(wc *writeContext, value interface{}) error {
+	hue := convertorUtil.ToFloat(value)
+	if hue < 0 || hue > 360 {
+		return errInvalidValue
+	}
+	wc.hue = &hue
+	return nil
+}
+
+func writeSaturation(wc *writeContext, value interface{}) error {
+	saturation := convertorUtil.ToFloat(value)
+	if saturation < 0 || saturation > 100 {
+		return errInvalidValue
+	}
+	wc.saturation = &saturation
+	return nil
+}
+
+func writeColorTemperature(wc *writeContext, value interface{}) error {
+	resource := deviceAPI.GetTraitResource(wc.vDevice, vdTY.DeviceTraitColorSetting, vdTY.TraitParameterColorTemperature)
+	if resource == nil {
+		return fmt.Errorf("color temperature resource not available")
+	}
+	kelvin := kelvinToMireds(convertorUtil.ToInteger(value))
+	minK, maxK := getColorTemperatureRange(resource)
+	wc.add(resource, clampInt(kelvin, minK, maxK))
+	return nil
+}
+
+// fan without on off resource is turned on with full speed
+func writeActive(wc *writeContext, value interface{}) error {
+	active := convertorUtil.ToInteger(value)
+	if active != 0 && active != 1 {
+		return errInvalidValue
+	}
+	if resource := deviceAPI.GetTraitResource(wc.vDevice, vdTY.DeviceTraitOnOff, ""); resource != nil {
+		wc.add(resource, active == 1)
+		return nil
+	}
+	resource := deviceAPI.GetTraitResource(wc.vDevice, vdTY.DeviceTraitFanSpeed, "")
+	if resource == nil {
+		return fmt.Errorf("%s resource not available", vdTY.DeviceTraitFanSpeed)
+	}
+	isActive := deviceAPI.ValueToSpeedPercent(resource, resource.Value) > 0
+	if isActive == (active == 1) {
+		return nil
+	}
+	payload, err := deviceAPI.SpeedPercentToValue(resource, active*100)
+	if err != nil {
+		return err
+	}
+	wc.add(resource, payload)
+	return nil
+}
+
+func writeRotationSpeed(wc *writeContext, value interface{}) error {
+	resource := deviceAPI.GetTraitResource(wc.vDevice, vdTY.DeviceTraitFanSpeed, "")
+	if resource == nil {
+		return fmt.Errorf("%s resource not available", vdTY.DeviceTraitFanSpeed)
+	}
+	percent, err := toPercent(value)
+	if err != nil {
+		return err
+	}
+	payload, err := deviceAPI.SpeedPercentToValue(resource, percent)
+	if err != nil {
+		return err
+	}
+	wc.add(resource, payload)
+	return nil
+}
+
+func writeTargetPosition(wc *writeContext, value interface{}) error {
+	percent, err := toPercent(value)
+	if err != nil {
+		return err
+	}
+	return wc.addPercent(vdTY.DeviceTraitOpenClose, percent)
+}
+
+func writeTargetDoorState(wc *writeContext, value interface{}) error {
+	switch convertorUtil.ToInteger(value) {
+	case hkTY.DoorStateOpen:
+		return wc.addPercent(vdTY.DeviceTraitOpenClose, 100)
+	case hkTY.DoorStateClosed:
+		return wc.addPercent(vdTY.DeviceTraitOpenClose, 0)
+	default:
+		return errInvalidValue
+	}
+}
+
+func writeLockTargetState(wc *writeContext, value interface{}) error {
+	resource := deviceAPI.GetTraitResource(wc.vDevice, vdTY.DeviceTraitLockUnlock, "")
+	if resource == nil {
+		return fmt.Errorf("%s resource not available", vdTY.DeviceTraitLockUnlock)
+	}
+	switch convertorUtil.ToInteger(value) {
+	case hkTY.LockSecured:
+		wc.add(resource, true)
+	case hkTY.LockUnsecured:
+		wc.add(resource, false)
+	default:
+		return errInvalidValue
+	}
+	return nil
+}
+
+func writeTargetHeatingCoolingState(wc *writeContext, value interface{}) error {
+	resource := deviceAPI.GetTraitResource(wc.vDevice, vdTY.DeviceTraitTemperatureSetting, vdTY.TraitParameterMode)
+	if resource == nil {
+		return fmt.Errorf("mode resource not available")
+	}
+	mode, found := getThermostatMode(resource, convertorUtil.ToInteger(value))
+	if !found {
+		return errInvalidValue
+	}
+	wc.add(resource, mode)
+	return nil
+}
+
+func writeTargetTemperature(wc *writeContext, value interface{}) error {
+	resource := deviceAPI.GetTraitResource(wc.vDevice, vdTY.DeviceTraitTemperatureSetting, vdTY.TraitParameterSetpoint)
+	if resource == nil {
+		return fmt.Errorf("setpoint resource not available")
+	}
+	celsius := convertorUtil.ToFloat(value)
+	if celsius < 10 || celsius > 38 {
+		return errInvalidValue
+	}
+	wc.add(resource, deviceAPI.CelsiusToValue(resource, celsius))
+	return nil
+}
+
+// display unit is not stored, celsius is always reported
+func writeTemperatureDisplayUnits(wc *writeContext, value interface{}) error {
+	unit := convertorUtil.ToInteger(value)
+	if unit != hkTY.TemperatureDisplayCelsius && unit != hkTY.TemperatureDisplayFahrenheit {
+		return errInvalidValue
+	}
+	return nil
+}
+
+func toPercent(value interface{}) (int64, error) {
+	percent := int64(math.Round(convertorUtil.ToFloat(value)))
+	if percent < 0 || percent > 100 {
+		return 0, errInvalidValue
+	}
+	return percent, nil
+}
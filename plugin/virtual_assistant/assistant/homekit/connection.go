@@ -0,0 +1,214 @@
+package homekit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+
+	hkTY "github.com/mycontroller-org/server/v2/plugin/virtual_assistant/assistant/homekit/types"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+const (
+	statusConnectionAuthorizationRequired = 470
+
+	maxFrameLength = 1024
+	frameTagLength = 16
+)
+
+// connection of a controller.
+// after pair verify, all the data encrypted in frames with the session keys
+// https://github.com/homebridge/HAP-NodeJS/blob/master/src/lib/util/hapCrypto.ts
+type connection struct {
+	conn   net.Conn
+	reader *bufio.Reader
+
+	writeMutex   sync.Mutex
+	readKey      []byte // controller to accessory
+	writeKey     []byte // accessory to controller
+	readCounter  uint64
+	writeCounter uint64
+	readBuffer   bytes.Buffer
+
+	pairingID      string // controller id, filled on pair verify
+	verify         *pairVerifySession
+	setup          *pairSetupSession
+	subscriptions  map[string]bool // "aid.iid" of the characteristics
+	subscribeMutex sync.RWMutex
+}
+
+func newConnection(conn net.Conn) *connection {
+	c := &connection{conn: conn, subscriptions: make(map[string]bool)}
+	c.reader = bufio.NewReader(readerFunc(c.read))
+	return c
+}
+
+type readerFunc func(p []byte) (int, error)
+
+func (f readerFunc) Read(p []byte) (int, error) { return f(p) }
+
+// isVerified returns true, if the connection is encrypted
+func (c *connection) isVerified() bool {
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+	return c.writeKey != nil
+}
+
+// getPairingID returns the controller id of the verified connection
+func (c *connection) getPairingID() string {
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+	return c.pairingID
+}
+
+// enables the encryption, should be called after the pair verify response sent
+func (c *connection) setSession(pairingID string, sharedSecret []byte) error {
+	readKey, err := deriveKey(sharedSecret, saltControl, infoControlWrite)
+	if err != nil {
+		return err
+	}
+	writeKey, err := deriveKey(sharedSecret, saltControl, infoControlRead)
+	if err != nil {
+		return err
+	}
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+	c.pairingID = pairingID
+	c.readKey = readKey
+	c.writeKey = writeKey
+	return nil
+}
+
+// reads the raw data or decrypted frames
+func (c *connection) read(p []byte) (int, error) {
+	if c.readBuffer.Len() > 0 {
+		return c.readBuffer.Read(p)
+	}
+
+	c.writeMutex.Lock()
+	readKey := c.readKey
+	c.writeMutex.Unlock()
+	if readKey == nil {
+		return c.conn.Read(p)
+	}
+
+	// length of the frame is used as additional data
+	lengthBytes := make([]byte, 2)
+	if _, err := io.ReadFull(c.conn, lengthBytes); err != nil {
+		return 0, err
+	}
+	length := int(binary.LittleEndian.Uint16(lengthBytes))
+	if length > maxFrameLength {
+		return 0, fmt.Errorf("invalid frame length: %d", length)
+	}
+	cipherText := make([]byte, length+frameTagLength)
+	if _, err := io.ReadFull(c.conn, cipherText); err != nil {
+		return 0, err
+	}
+
+	aead, err := chacha20poly1305.New(readKey)
+	if err != nil {
+		return 0, err
+	}
+	plainText, err := aead.Open(nil, counterNonce(c.readCounter), cipherText, lengthBytes)
+	if err != nil {
+		return 0, errors.New("error on decrypting a frame")
+	}
+	c.readCounter++
+
+	c.readBuffer.Write(plainText)
+	return c.readBuffer.Read(p)
+}
+
+// write sends the data as a single message, encrypts if the session established
+func (c *connection) write(data []byte) error {
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+
+	if c.writeKey == nil {
+		_, err := c.conn.Write(data)
+		return err
+	}
+
+	aead, err := chacha20poly1305.New(c.writeKey)
+	if err != nil {
+		return err
+	}
+	var buffer bytes.Buffer
+	for offset := 0; offset < len(data); offset += maxFrameLength {
+		end := offset + maxFrameLength
+		if end > len(data) {
+			end = len(data)
+		}
+		lengthBytes := make([]byte, 2)
+		binary.LittleEndian.PutUint16(lengthBytes, uint16(end-offset))
+		buffer.Write(lengthBytes)
+		buffer.Write(aead.Seal(nil, counterNonce(c.writeCounter), data[offset:end], lengthBytes))
+		c.writeCounter++
+	}
+	_, err = c.conn.Write(buffer.Bytes())
+	return err
+}
+
+// writeResponse sends http response
+func (c *connection) writeResponse(statusCode int, contentType string, body []byte) error {
+	var buffer bytes.Buffer
+	fmt.Fprintf(&buffer, "HTTP/1.1 %d %s\r\n", statusCode, statusText(statusCode))
+	if contentType != "" {
+		fmt.Fprintf(&buffer, "Content-Type: %s\r\n", contentType)
+	}
+	fmt.Fprintf(&buffer, "Content-Length: %d\r\n\r\n", len(body))
+	buffer.Write(body)
+	return c.write(buffer.Bytes())
+}
+
+// writeEvent sends characteristic change event
+func (c *connection) writeEvent(body []byte) error {
+	var buffer bytes.Buffer
+	buffer.WriteString("EVENT/1.0 200 OK\r\n")
+	fmt.Fprintf(&buffer, "Content-Type: %s\r\n", hkTY.ContentTypeHAPJSON)
+	fmt.Fprintf(&buffer, "Content-Length: %d\r\n\r\n", len(body))
+	buffer.Write(body)
+	return c.write(buffer.Bytes())
+}
+
+func (c *connection) subscribe(key string, enabled bool) {
+	c.subscribeMutex.Lock()
+	defer c.subscribeMutex.Unlock()
+	if enabled {
+		c.subscriptions[key] = true
+	} else {
+		delete(c.subscriptions, key)
+	}
+}
+
+func (c *connection) isSubscribed(key string) bool {
+	c.subscribeMutex.RLock()
+	defer c.subscribeMutex.RUnlock()
+	return c.subscriptions[key]
+}
+
+func (c *connection) close() error {
+	return c.conn.Close()
+}
+
+// returns status text, includes the hap specific status
+func statusText(statusCode int) string {
+	if statusCode == statusConnectionAuthorizationRequired {
+		return "Connection Authorization Required"
+	}
+	return http.StatusText(statusCode)
+}
+
+// nonce of the frame, 4 zero bytes followed by little endian counter
+func counterNonce(counter uint64) []byte {
+	nonce := make([]byte, chacha20poly1305.NonceSize)
+	binary.LittleEndian.PutUint64(nonce[4:], counter)
+	return nonce
+}
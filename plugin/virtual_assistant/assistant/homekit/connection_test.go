@@ -0,0 +1,135 @@
+package homekit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// returns the accessory connection with the session keys and the controller side of the pipe
+func newTestSession(t *testing.T) (*connection, net.Conn, []byte, []byte) {
+	accessoryConn, controllerConn := net.Pipe()
+	t.Cleanup(func() {
+		_ = accessoryConn.Close()
+		_ = controllerConn.Close()
+	})
+
+	sharedSecret := bytes.Repeat([]byte{7}, 32)
+	c := newConnection(accessoryConn)
+	require.False(t, c.isVerified())
+	require.NoError(t, c.setSession("controller_1", sharedSecret))
+	require.True(t, c.isVerified())
+	assert.Equal(t, "controller_1", c.getPairingID())
+
+	// keys of the controller
+	writeKey, err := deriveKey(sharedSecret, saltControl, infoControlWrite)
+	require.NoError(t, err)
+	readKey, err := deriveKey(sharedSecret, saltControl, infoControlRead)
+	require.NoError(t, err)
+	return c, controllerConn, writeKey, readKey
+}
+
+// sealFrame encrypts the data as a controller
+func sealFrame(t *testing.T, key []byte, counter uint64, data []byte) []byte {
+	aead, err := chacha20poly1305.New(key)
+	require.NoError(t, err)
+	lengthBytes := make([]byte, 2)
+	binary.LittleEndian.PutUint16(lengthBytes, uint16(len(data)))
+	return append(lengthBytes, aead.Seal(nil, counterNonce(counter), data, lengthBytes)...)
+}
+
+func TestFrameWrite(t *testing.T) {
+	c, controllerConn, _, readKey := newTestSession(t)
+
+	data := make([]byte, 2500)
+	for index := range data {
+		data[index] = byte(index)
+	}
+	go func() { _ = c.write(data) }()
+
+	aead, err := chacha20poly1305.New(readKey)
+	require.NoError(t, err)
+	received := make([]byte, 0)
+	for counter, expectedLength := range []int{1024, 1024, 452} {
+		lengthBytes := make([]byte, 2)
+		_, err = io.ReadFull(controllerConn, lengthBytes)
+		require.NoError(t, err)
+		length := int(binary.LittleEndian.Uint16(lengthBytes))
+		require.Equal(t, expectedLength, length)
+
+		cipherText := make([]byte, length+frameTagLength)
+		_, err = io.ReadFull(controllerConn, cipherText)
+		require.NoError(t, err)
+		plainText, err := aead.Open(nil, counterNonce(uint64(counter)), cipherText, lengthBytes)
+		require.NoError(t, err)
+		received = append(received, plainText...)
+	}
+	assert.Equal(t, data, received)
+	assert.Equal(t, uint64(3), c.writeCounter)
+}
+
+func TestFrameRead(t *testing.T) {
+	c, controllerConn, writeKey, _ := newTestSession(t)
+
+	first := []byte("GET /accessories HTTP/1.1\r\n")
+	second := []byte("Host: bridge\r\n\r\n")
+	frames := append(sealFrame(t, writeKey, 0, first), sealFrame(t, writeKey, 1, second)...)
+	go func() { _, _ = controllerConn.Write(frames) }()
+
+	received := make([]byte, len(first)+len(second))
+	_, err := io.ReadFull(c.reader, received)
+	require.NoError(t, err)
+	assert.Equal(t, append(first, second...), received)
+	assert.Equal(t, uint64(2), c.readCounter)
+
+	// replayed frame, counter mismatch
+	replayed := sealFrame(t, writeKey, 0, first)
+	go func() { _, _ = controllerConn.Write(replayed) }()
+	_, err = c.read(make([]byte, 10))
+	assert.ErrorContains(t, err, "error on decrypting a frame")
+}
+
+func TestFrameReadInvalid(t *testing.T) {
+	c, controllerConn, writeKey, _ := newTestSession(t)
+
+	// modified cipher text
+	modified := sealFrame(t, writeKey, 0, []byte("data"))
+	modified[3] ^= 0xFF
+	go func() { _, _ = controllerConn.Write(modified) }()
+	_, err := c.read(make([]byte, 10))
+	assert.ErrorContains(t, err, "error on decrypting a frame")
+
+	// modified length, used as additional data
+	modifiedLength := append(sealFrame(t, writeKey, 0, []byte("data")), 0)
+	modifiedLength[0]++
+	go func() { _, _ = controllerConn.Write(modifiedLength) }()
+	_, err = c.read(make([]byte, 10))
+	assert.ErrorContains(t, err, "error on decrypting a frame")
+
+	// frame length above the limit
+	lengthBytes := make([]byte, 2)
+	binary.LittleEndian.PutUint16(lengthBytes, maxFrameLength+1)
+	go func() { _, _ = controllerConn.Write(lengthBytes) }()
+	_, err = c.read(make([]byte, 10))
+	assert.ErrorContains(t, err, "invalid frame length")
+}
+
+func TestFrameBeforeSession(t *testing.T) {
+	accessoryConn, controllerConn := net.Pipe()
+	defer accessoryConn.Close()
+	defer controllerConn.Close()
+
+	// plain data before pair verify
+	c := newConnection(accessoryConn)
+	go func() { _ = c.write([]byte("plain")) }()
+	received := make([]byte, 5)
+	_, err := io.ReadFull(controllerConn, received)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("plain"), received)
+}
@@ -0,0 +1,52 @@
+package homekit
+
+import (
+	"crypto/hkdf"
+	"crypto/sha512"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// hkdf salt and info values, defined on pairing and session security
+const (
+	saltPairSetupEncrypt    = "Pair-Setup-Encrypt-Salt"
+	infoPairSetupEncrypt    = "Pair-Setup-Encrypt-Info"
+	saltPairSetupController = "Pair-Setup-Controller-Sign-Salt"
+	infoPairSetupController = "Pair-Setup-Controller-Sign-Info"
+	saltPairSetupAccessory  = "Pair-Setup-Accessory-Sign-Salt"
+	infoPairSetupAccessory  = "Pair-Setup-Accessory-Sign-Info"
+	saltPairVerifyEncrypt   = "Pair-Verify-Encrypt-Salt"
+	infoPairVerifyEncrypt   = "Pair-Verify-Encrypt-Info"
+	saltControl             = "Control-Salt"
+	infoControlRead         = "Control-Read-Encryption-Key"
+	infoControlWrite        = "Control-Write-Encryption-Key"
+
+	keyLength = 32
+)
+
+func deriveKey(secret []byte, salt, info string) ([]byte, error) {
+	return hkdf.Key(sha512.New, secret, []byte(salt), info, keyLength)
+}
+
+// returns the nonce for the pairing messages, example: "PS-Msg05"
+func messageNonce(name string) []byte {
+	nonce := make([]byte, chacha20poly1305.NonceSize)
+	copy(nonce[4:], name)
+	return nonce
+}
+
+func encrypt(key []byte, nonceName string, plainText []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil, err
+	}
+	return aead.Seal(nil, messageNonce(nonceName), plainText, nil), nil
+}
+
+func decrypt(key []byte, nonceName string, cipherText []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil, err
+	}
+	return aead.Open(nil, messageNonce(nonceName), cipherText, nil)
+}
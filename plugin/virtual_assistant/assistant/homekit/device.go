@@ -0,0 +1,175 @@
+package homekit
+
+import (
+	"fmt"
+
+	"github.com/mycontroller-org/server/v2/pkg/types"
+	vdTY "github.com/mycontroller-org/server/v2/pkg/types/virtual_device"
+	storageTY "github.com/mycontroller-org/server/v2/plugin/database/storage/types"
+	hkTY "github.com/mycontroller-org/server/v2/plugin/virtual_assistant/assistant/homekit/types"
+	"go.uber.org/zap"
+)
+
+// getAccessoryDatabase returns the bridge and the bridged accessories.
+// increments the configuration number and announces, if the database changed
+func (a *Assistant) getAccessoryDatabase() ([]*accessory, error) {
+	vDevices, err := a.deviceAPI.ListDevices(nil, hkTY.DefaultDeviceLimit, 0, a.cfg.DeviceFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	// update resource state
+	err = a.deviceAPI.UpdateDeviceState(vDevices)
+	if err != nil {
+		return nil, err
+	}
+
+	accessories := []*accessory{getBridgeAccessory(a.hkCfg.Name, a.hkCfg.AccessoryID)}
+	deviceIDs := make([]string, 0)
+	for index := range vDevices {
+		vDevice := &vDevices[index]
+		// accessory id assigned only for the supported devices
+		if getAccessory(0, vDevice) == nil {
+			continue
+		}
+		aid, _ := a.state.getAccessoryID(vDevice.ID)
+		accessories = append(accessories, getAccessory(aid, vDevice))
+		deviceIDs = append(deviceIDs, vDevice.ID)
+	}
+
+	items := make([]hkTY.Accessory, 0, len(accessories))
+	for _, item := range accessories {
+		items = append(items, item.toAccessory())
+	}
+	hash, err := getDatabaseHash(items)
+	if err != nil {
+		return nil, err
+	}
+	changed, err := a.state.updateConfigHash(hash, deviceIDs)
+	if err != nil {
+		return nil, err
+	}
+	if changed {
+		a.logger.Info("accessory database updated", zap.Uint64("configNumber", a.state.configNumber()), zap.Int("accessories", len(accessories)))
+		a.announce()
+	}
+	return accessories, nil
+}
+
+// getAccessoriesByAID returns the accessories of the accessory ids, unknown ids are ignored
+func (a *Assistant) getAccessoriesByAID(aids []uint64) ([]*accessory, error) {
+	accessories := make([]*accessory, 0)
+	for _, aid := range aids {
+		if aid == hkTY.BridgeAccessoryID {
+			accessories = append(accessories, getBridgeAccessory(a.hkCfg.Name, a.hkCfg.AccessoryID))
+			break
+		}
+	}
+
+	bridgedAccessories, err := a.getAccessories(a.state.getDeviceIDs(aids))
+	if err != nil {
+		return nil, err
+	}
+	return append(accessories, bridgedAccessories...), nil
+}
+
+// getAccessories returns the accessories of the virtual devices, which are part of the accessory database
+func (a *Assistant) getAccessories(deviceIDs []string) ([]*accessory, error) {
+	accessories := make([]*accessory, 0)
+	if len(deviceIDs) == 0 {
+		return accessories, nil
+	}
+
+	filters := []storageTY.Filter{{Key: types.KeyID, Operator: storageTY.OperatorIn, Value: deviceIDs}}
+	vDevices, err := a.deviceAPI.ListDevices(filters, int64(len(deviceIDs)), 0, a.cfg.DeviceFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	// update resource state
+	err = a.deviceAPI.UpdateDeviceState(vDevices)
+	if err != nil {
+		return nil, err
+	}
+
+	for index := range vDevices {
+		vDevice := &vDevices[index]
+		aid, found := a.state.lookupAccessoryID(vDevice.ID)
+		if !found {
+			continue
+		}
+		if item := getAccessory(aid, vDevice); item != nil {
+			accessories = append(accessories, item)
+		}
+	}
+	return accessories, nil
+}
+
+// updateValues stores the characteristic values, returns the changed values which supports events
+func (a *Assistant) updateValues(accessories []*accessory) []hkTY.Characteristic {
+	a.valuesMutex.Lock()
+	defer a.valuesMutex.Unlock()
+
+	changed := make([]hkTY.Characteristic, 0)
+	for _, item := range accessories {
+		for _, service := range item.services {
+			for _, characteristic := range service.Characteristics {
+				if !hasPerm(&characteristic, hkTY.PermEvents) {
+					continue
+				}
+				key := characteristicKey(item.aid, characteristic.InstanceID)
+				value := fmt.Sprintf("%v", characteristic.Value)
+				if previousValue, found := a.values[key]; found && previousValue == value {
+					continue
+				}
+				a.values[key] = value
+				changed = append(changed, hkTY.Characteristic{AccessoryID: item.aid, InstanceID: characteristic.InstanceID, Value: characteristic.Value})
+			}
+		}
+	}
+	return changed
+}
+
+func (a *Assistant) postActions(vDevice *vdTY.VirtualDevice, actions []resourceAction) error {
+	for _, action := range actions {
+		quickId := fmt.Sprintf("%s:%s", action.resource.ResourceType, action.resource.QuickID)
+		err := a.deviceAPI.PostActionOnResourceByQuickID(action.resource.ResourceType, quickId, action.payload)
+		if err != nil {
+			a.logger.Error("error on executing", zap.String("virtualDeviceId", vDevice.ID), zap.String("virtualDeviceName", vDevice.Name), zap.String("quickId", quickId), zap.Error(err))
+			return err
+		}
+	}
+	return nil
+}
+
+func findAccessory(accessories []*accessory, aid uint64) *accessory {
+	for _, item := range accessories {
+		if item.aid == aid {
+			return item
+		}
+	}
+	return nil
+}
+
+// ReportState sends the changed characteristic values to the subscribed controllers
+func (a *Assistant) ReportState(deviceIDs []string) error {
+	if len(deviceIDs) == 0 {
+		return nil
+	}
+	accessories, err := a.getAccessories(deviceIDs)
+	if err != nil {
+		return err
+	}
+	a.sendEvents(a.updateValues(accessories))
+	return nil
+}
+
+// RequestSync updates the accessory database on device add, update and remove.
+// controllers reload the database, when the configuration number changed
+func (a *Assistant) RequestSync(eventType string, vDevice *vdTY.VirtualDevice) error {
+	_, err := a.getAccessoryDatabase()
+	if err != nil {
+		return err
+	}
+	return a.ReportState([]string{vDevice.ID})
+}
@@ -0,0 +1,284 @@
+package homekit
+
+import (
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	hkTY "github.com/mycontroller-org/server/v2/plugin/virtual_assistant/assistant/homekit/types"
+	"go.uber.org/zap"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	mdnsAddress       = "224.0.0.251:5353"
+	mdnsPort          = 5353
+	mdnsTTL           = 4500
+	mdnsHostTTL       = 120
+	mdnsCacheFlush    = 1 << 15
+	mdnsUnicastBit    = 1 << 15
+	mdnsMaxPacketSize = 9000
+
+	serviceName          = "_hap._tcp.local."
+	serviceEnumerateName = "_services._dns-sd._udp.local."
+)
+
+// mdnsResponder advertises the bridge as "_hap._tcp" service, controllers discover the bridge with this
+type mdnsResponder struct {
+	a         *Assistant
+	conn      *net.UDPConn
+	groupAddr *net.UDPAddr
+}
+
+func newMDNSResponder(a *Assistant) (*mdnsResponder, error) {
+	groupAddr, err := net.ResolveUDPAddr("udp4", mdnsAddress)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenMulticastUDP("udp4", nil, groupAddr)
+	if err != nil {
+		return nil, err
+	}
+	m := &mdnsResponder{a: a, conn: conn, groupAddr: groupAddr}
+	go m.serve()
+	return m, nil
+}
+
+// answers the queries of the bridge records
+func (m *mdnsResponder) serve() {
+	buffer := make([]byte, mdnsMaxPacketSize)
+	for {
+		n, from, err := m.conn.ReadFromUDP(buffer)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			m.a.logger.Debug("error on reading mdns packet", zap.Error(err))
+			continue
+		}
+
+		var parser dnsmessage.Parser
+		header, err := parser.Start(buffer[:n])
+		if err != nil || header.Response {
+			continue
+		}
+		questions, err := parser.AllQuestions()
+		if err != nil {
+			continue
+		}
+
+		matched := false
+		unicast := false
+		for _, question := range questions {
+			if m.isMatching(question) {
+				matched = true
+				unicast = unicast || uint16(question.Class)&mdnsUnicastBit != 0
+			}
+		}
+		if !matched {
+			continue
+		}
+
+		// legacy unicast queries are not sent from the mdns port, response should have the query id
+		legacy := from.Port != mdnsPort
+		id := uint16(0)
+		if legacy {
+			id = header.ID
+		}
+		response, err := m.buildResponse(id, mdnsTTL, !legacy)
+		if err != nil {
+			m.a.logger.Error("error on building mdns response", zap.Error(err))
+			continue
+		}
+		to := m.groupAddr
+		if legacy || unicast {
+			to = from
+		}
+		if _, err := m.conn.WriteToUDP(response, to); err != nil {
+			m.a.logger.Debug("error on sending mdns response", zap.Error(err))
+		}
+	}
+}
+
+func (m *mdnsResponder) isMatching(question dnsmessage.Question) bool {
+	name := strings.ToLower(question.Name.String())
+	switch name {
+	case serviceName, serviceEnumerateName:
+		return question.Type == dnsmessage.TypePTR || question.Type == dnsmessage.TypeALL
+	case strings.ToLower(m.instanceName()), strings.ToLower(m.hostName()):
+		return true
+	default:
+		return false
+	}
+}
+
+// announce sends the records, used on start and when the txt record changes
+func (m *mdnsResponder) announce() {
+	m.send(mdnsTTL)
+}
+
+// goodbye removes the records from the controllers cache
+func (m *mdnsResponder) goodbye() {
+	m.send(0)
+}
+
+func (m *mdnsResponder) send(ttl uint32) {
+	response, err := m.buildResponse(0, ttl, true)
+	if err != nil {
+		m.a.logger.Error("error on building mdns response", zap.Error(err))
+		return
+	}
+	if _, err := m.conn.WriteToUDP(response, m.groupAddr); err != nil {
+		m.a.logger.Debug("error on sending mdns announcement", zap.Error(err))
+	}
+}
+
+func (m *mdnsResponder) close() error {
+	return m.conn.Close()
+}
+
+// builds the ptr, srv, txt and a records of the bridge
+func (m *mdnsResponder) buildResponse(id uint16, ttl uint32, cacheFlush bool) ([]byte, error) {
+	serviceType, err := dnsmessage.NewName(serviceName)
+	if err != nil {
+		return nil, err
+	}
+	serviceEnumerate, err := dnsmessage.NewName(serviceEnumerateName)
+	if err != nil {
+		return nil, err
+	}
+	instance, err := dnsmessage.NewName(m.instanceName())
+	if err != nil {
+		return nil, err
+	}
+	host, err := dnsmessage.NewName(m.hostName())
+	if err != nil {
+		return nil, err
+	}
+
+	uniqueClass := dnsmessage.ClassINET
+	if cacheFlush {
+		uniqueClass |= mdnsCacheFlush
+	}
+	hostTTL := ttl
+	if hostTTL > mdnsHostTTL {
+		hostTTL = mdnsHostTTL
+	}
+
+	builder := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: id, Response: true, Authoritative: true})
+	builder.EnableCompression()
+	if err := builder.StartAnswers(); err != nil {
+		return nil, err
+	}
+	err = builder.PTRResource(
+		dnsmessage.ResourceHeader{Name: serviceEnumerate, Type: dnsmessage.TypePTR, Class: dnsmessage.ClassINET, TTL: ttl},
+		dnsmessage.PTRResource{PTR: serviceType},
+	)
+	if err != nil {
+		return nil, err
+	}
+	err = builder.PTRResource(
+		dnsmessage.ResourceHeader{Name: serviceType, Type: dnsmessage.TypePTR, Class: dnsmessage.ClassINET, TTL: ttl},
+		dnsmessage.PTRResource{PTR: instance},
+	)
+	if err != nil {
+		return nil, err
+	}
+	err = builder.SRVResource(
+		dnsmessage.ResourceHeader{Name: instance, Type: dnsmessage.TypeSRV, Class: uniqueClass, TTL: hostTTL},
+		dnsmessage.SRVResource{Target: host, Port: uint16(m.a.hkCfg.Port)},
+	)
+	if err != nil {
+		return nil, err
+	}
+	err = builder.TXTResource(
+		dnsmessage.ResourceHeader{Name: instance, Type: dnsmessage.TypeTXT, Class: uniqueClass, TTL: ttl},
+		dnsmessage.TXTResource{TXT: m.txtRecords()},
+	)
+	if err != nil {
+		return nil, err
+	}
+	for _, ip := range m.addresses() {
+		var address [4]byte
+		copy(address[:], ip.To4())
+		err = builder.AResource(
+			dnsmessage.ResourceHeader{Name: host, Type: dnsmessage.TypeA, Class: uniqueClass, TTL: hostTTL},
+			dnsmessage.AResource{A: address},
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return builder.Finish()
+}
+
+// txt record of the bridge, defined on the hap specification
+func (m *mdnsResponder) txtRecords() []string {
+	cfg := m.a.hkCfg
+	statusFlag := 1 // not paired
+	if m.a.state.isPaired() {
+		statusFlag = 0
+	}
+	setupHash := sha512.Sum512([]byte(cfg.SetupID + cfg.AccessoryID))
+	return []string{
+		fmt.Sprintf("c#=%d", m.a.state.configNumber()),
+		"ff=0",
+		fmt.Sprintf("id=%s", cfg.AccessoryID),
+		fmt.Sprintf("md=%s", cfg.Name),
+		"pv=1.1",
+		"s#=1",
+		fmt.Sprintf("sf=%d", statusFlag),
+		fmt.Sprintf("ci=%d", hkTY.CategoryBridge),
+		fmt.Sprintf("sh=%s", base64.StdEncoding.EncodeToString(setupHash[:4])),
+	}
+}
+
+// instance name, dots are not allowed on the label
+func (m *mdnsResponder) instanceName() string {
+	return fmt.Sprintf("%s.%s", strings.ReplaceAll(m.a.hkCfg.Name, ".", " "), serviceName)
+}
+
+// host name, example: "MyController-Bridge-AABBCCDDEEFF.local."
+func (m *mdnsResponder) hostName() string {
+	name := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '-'
+	}, m.a.hkCfg.Name)
+	host := fmt.Sprintf("%s-%s", name, strings.ReplaceAll(m.a.hkCfg.AccessoryID, ":", ""))
+	if len(host) > 63 {
+		host = host[len(host)-63:]
+	}
+	return fmt.Sprintf("%s.local.", strings.Trim(host, "-"))
+}
+
+// returns the configured address or the ipv4 addresses of the interfaces
+func (m *mdnsResponder) addresses() []net.IP {
+	if ip := net.ParseIP(m.a.hkCfg.Address); ip != nil && ip.To4() != nil {
+		return []net.IP{ip}
+	}
+	addresses := make([]net.IP, 0)
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return addresses
+	}
+	for _, networkInterface := range interfaces {
+		if networkInterface.Flags&net.FlagUp == 0 || networkInterface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		interfaceAddresses, err := networkInterface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, address := range interfaceAddresses {
+			if ipNet, ok := address.(*net.IPNet); ok && ipNet.IP.To4() != nil {
+				addresses = append(addresses, ipNet.IP)
+			}
+		}
+	}
+	return addresses
+}
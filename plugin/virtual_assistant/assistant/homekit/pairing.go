@@ -0,0 +1,337 @@
+package homekit
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+
+	hkTY "github.com/mycontroller-org/server/v2/plugin/virtual_assistant/assistant/homekit/types"
+	"go.uber.org/zap"
+)
+
+const (
+	maxPairSetupAttempts = 100
+)
+
+// pairSetupSession holds the srp session of a pair setup
+type pairSetupSession struct {
+	srp *srpSession
+}
+
+// pairVerifySession holds the ephemeral keys of a pair verify
+type pairVerifySession struct {
+	publicKey           []byte
+	controllerPublicKey []byte
+	sharedSecret        []byte
+	sessionKey          []byte
+}
+
+// pairing response, afterWrite is executed after the response sent to the controller
+type pairingResponse struct {
+	tlv        *tlv8
+	afterWrite func()
+}
+
+func errorResponse(state, errorCode byte) *pairingResponse {
+	return &pairingResponse{tlv: newTLV8().addByte(tlvState, state).addByte(tlvError, errorCode)}
+}
+
+// handlePairSetup executes pair setup, the controller becomes an admin on success
+func (a *Assistant) handlePairSetup(c *connection, request *tlv8) *pairingResponse {
+	state, _ := request.getByte(tlvState)
+	switch state {
+	case 1:
+		return a.pairSetupStart(c)
+	case 3:
+		return a.pairSetupVerify(c, request)
+	case 5:
+		return a.pairSetupExchange(c, request)
+	default:
+		return errorResponse(state+1, tlvErrorUnknown)
+	}
+}
+
+// M1 -> M2, sends salt and srp public key
+func (a *Assistant) pairSetupStart(c *connection) *pairingResponse {
+	if a.state.isPaired() {
+		return errorResponse(2, tlvErrorUnavailable)
+	}
+
+	a.pairSetupMutex.Lock()
+	defer a.pairSetupMutex.Unlock()
+	if a.state.pairSetupAttempts() >= maxPairSetupAttempts {
+		return errorResponse(2, tlvErrorMaxTries)
+	}
+	if a.pairSetupOwner != nil && a.pairSetupOwner != c {
+		return errorResponse(2, tlvErrorBusy)
+	}
+
+	srp, err := newSRPSession(a.hkCfg.SetupCode)
+	if err != nil {
+		a.logger.Error("error on creating srp session", zap.Error(err))
+		return errorResponse(2, tlvErrorUnknown)
+	}
+	a.pairSetupOwner = c
+	c.setup = &pairSetupSession{srp: srp}
+
+	return &pairingResponse{tlv: newTLV8().
+		addByte(tlvState, 2).
+		add(tlvSalt, srp.salt).
+		add(tlvPublicKey, srp.publicKey)}
+}
+
+// M3 -> M4, verifies the setup code proof
+func (a *Assistant) pairSetupVerify(c *connection, request *tlv8) *pairingResponse {
+	if c.setup == nil {
+		return errorResponse(4, tlvErrorUnknown)
+	}
+	serverProof, err := c.setup.srp.verifyClient(request.get(tlvPublicKey), request.get(tlvProof))
+	if err != nil {
+		a.logger.Info("pair setup failed, invalid setup code", zap.String("remoteAddress", c.conn.RemoteAddr().String()))
+		if err := a.state.addPairSetupAttempt(); err != nil {
+			a.logger.Error("error on saving pair setup attempts", zap.Error(err))
+		}
+		a.releasePairSetup(c)
+		return errorResponse(4, tlvErrorAuthentication)
+	}
+	return &pairingResponse{tlv: newTLV8().addByte(tlvState, 4).add(tlvProof, serverProof)}
+}
+
+// M5 -> M6, exchanges the long term public keys
+func (a *Assistant) pairSetupExchange(c *connection, request *tlv8) *pairingResponse {
+	if c.setup == nil || c.setup.srp.key == nil {
+		return errorResponse(6, tlvErrorUnknown)
+	}
+	defer a.releasePairSetup(c)
+	srpKey := c.setup.srp.key
+
+	sessionKey, err := deriveKey(srpKey, saltPairSetupEncrypt, infoPairSetupEncrypt)
+	if err != nil {
+		return errorResponse(6, tlvErrorUnknown)
+	}
+	plainText, err := decrypt(sessionKey, "PS-Msg05", request.get(tlvEncryptedData))
+	if err != nil {
+		return errorResponse(6, tlvErrorAuthentication)
+	}
+	subTLV, err := decodeTLV8(plainText)
+	if err != nil {
+		return errorResponse(6, tlvErrorUnknown)
+	}
+
+	controllerID := subTLV.get(tlvIdentifier)
+	controllerPublicKey := subTLV.get(tlvPublicKey)
+	if len(controllerPublicKey) != ed25519.PublicKeySize {
+		return errorResponse(6, tlvErrorAuthentication)
+	}
+	controllerX, err := deriveKey(srpKey, saltPairSetupController, infoPairSetupController)
+	if err != nil {
+		return errorResponse(6, tlvErrorUnknown)
+	}
+	controllerInfo := concat(controllerX, controllerID, controllerPublicKey)
+	if !ed25519.Verify(controllerPublicKey, controllerInfo, subTLV.get(tlvSignature)) {
+		return errorResponse(6, tlvErrorAuthentication)
+	}
+
+	err = a.state.addPairing(hkTY.Pairing{ID: string(controllerID), PublicKey: hex.EncodeToString(controllerPublicKey), Admin: true})
+	if err != nil {
+		a.logger.Error("error on saving pairing", zap.Error(err))
+		return errorResponse(6, tlvErrorUnknown)
+	}
+
+	accessoryX, err := deriveKey(srpKey, saltPairSetupAccessory, infoPairSetupAccessory)
+	if err != nil {
+		return errorResponse(6, tlvErrorUnknown)
+	}
+	accessoryID := []byte(a.hkCfg.AccessoryID)
+	accessoryPublicKey := a.state.publicKey()
+	signature := a.state.sign(concat(accessoryX, accessoryID, accessoryPublicKey))
+
+	encryptedData, err := encrypt(sessionKey, "PS-Msg06", newTLV8().
+		add(tlvIdentifier, accessoryID).
+		add(tlvPublicKey, accessoryPublicKey).
+		add(tlvSignature, signature).encode())
+	if err != nil {
+		return errorResponse(6, tlvErrorUnknown)
+	}
+
+	a.logger.Info("paired with a controller", zap.String("controllerId", string(controllerID)))
+	return &pairingResponse{
+		tlv:        newTLV8().addByte(tlvState, 6).add(tlvEncryptedData, encryptedData),
+		afterWrite: a.announce,
+	}
+}
+
+// releases the pair setup lock, if owned by the connection
+func (a *Assistant) releasePairSetup(c *connection) {
+	a.pairSetupMutex.Lock()
+	defer a.pairSetupMutex.Unlock()
+	if a.pairSetupOwner == c {
+		a.pairSetupOwner = nil
+	}
+	c.setup = nil
+}
+
+// handlePairVerify establishes the encrypted session with a paired controller
+func (a *Assistant) handlePairVerify(c *connection, request *tlv8) *pairingResponse {
+	state, _ := request.getByte(tlvState)
+	switch state {
+	case 1:
+		return a.pairVerifyStart(c, request)
+	case 3:
+		return a.pairVerifyFinish(c, request)
+	default:
+		return errorResponse(state+1, tlvErrorUnknown)
+	}
+}
+
+// M1 -> M2, sends the ephemeral public key with signature
+func (a *Assistant) pairVerifyStart(c *connection, request *tlv8) *pairingResponse {
+	controllerPublicKey := request.get(tlvPublicKey)
+	controllerKey, err := ecdh.X25519().NewPublicKey(controllerPublicKey)
+	if err != nil {
+		return errorResponse(2, tlvErrorAuthentication)
+	}
+	privateKey, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return errorResponse(2, tlvErrorUnknown)
+	}
+	sharedSecret, err := privateKey.ECDH(controllerKey)
+	if err != nil {
+		return errorResponse(2, tlvErrorAuthentication)
+	}
+	sessionKey, err := deriveKey(sharedSecret, saltPairVerifyEncrypt, infoPairVerifyEncrypt)
+	if err != nil {
+		return errorResponse(2, tlvErrorUnknown)
+	}
+
+	publicKey := privateKey.PublicKey().Bytes()
+	accessoryID := []byte(a.hkCfg.AccessoryID)
+	signature := a.state.sign(concat(publicKey, accessoryID, controllerPublicKey))
+	encryptedData, err := encrypt(sessionKey, "PV-Msg02", newTLV8().
+		add(tlvIdentifier, accessoryID).
+		add(tlvSignature, signature).encode())
+	if err != nil {
+		return errorResponse(2, tlvErrorUnknown)
+	}
+
+	c.verify = &pairVerifySession{
+		publicKey:           publicKey,
+		controllerPublicKey: controllerPublicKey,
+		sharedSecret:        sharedSecret,
+		sessionKey:          sessionKey,
+	}
+	return &pairingResponse{tlv: newTLV8().
+		addByte(tlvState, 2).
+		add(tlvPublicKey, publicKey).
+		add(tlvEncryptedData, encryptedData)}
+}
+
+// M3 -> M4, verifies the controller signature and enables the encryption
+func (a *Assistant) pairVerifyFinish(c *connection, request *tlv8) *pairingResponse {
+	session := c.verify
+	c.verify = nil
+	if session == nil {
+		return errorResponse(4, tlvErrorUnknown)
+	}
+
+	plainText, err := decrypt(session.sessionKey, "PV-Msg03", request.get(tlvEncryptedData))
+	if err != nil {
+		return errorResponse(4, tlvErrorAuthentication)
+	}
+	subTLV, err := decodeTLV8(plainText)
+	if err != nil {
+		return errorResponse(4, tlvErrorUnknown)
+	}
+
+	controllerID := subTLV.get(tlvIdentifier)
+	pairing := a.state.getPairing(string(controllerID))
+	if pairing == nil {
+		a.logger.Info("pair verify failed, unknown controller", zap.String("controllerId", string(controllerID)))
+		return errorResponse(4, tlvErrorAuthentication)
+	}
+	controllerLTPK, err := hex.DecodeString(pairing.PublicKey)
+	if err != nil || len(controllerLTPK) != ed25519.PublicKeySize {
+		return errorResponse(4, tlvErrorAuthentication)
+	}
+	controllerInfo := concat(session.controllerPublicKey, controllerID, session.publicKey)
+	if !ed25519.Verify(controllerLTPK, controllerInfo, subTLV.get(tlvSignature)) {
+		return errorResponse(4, tlvErrorAuthentication)
+	}
+
+	return &pairingResponse{
+		tlv: newTLV8().addByte(tlvState, 4),
+		afterWrite: func() {
+			err := c.setSession(pairing.ID, session.sharedSecret)
+			if err != nil {
+				a.logger.Error("error on enabling session encryption", zap.Error(err))
+				_ = c.close()
+			}
+		},
+	}
+}
+
+// handlePairings adds, removes and lists the pairings, allowed only for admin controllers
+func (a *Assistant) handlePairings(c *connection, request *tlv8) *pairingResponse {
+	pairing := a.state.getPairing(c.getPairingID())
+	if pairing == nil || !pairing.Admin {
+		return errorResponse(2, tlvErrorAuthentication)
+	}
+
+	method, _ := request.getByte(tlvMethod)
+	switch method {
+	case methodAddPairing:
+		publicKey := request.get(tlvPublicKey)
+		if len(publicKey) != ed25519.PublicKeySize {
+			return errorResponse(2, tlvErrorUnknown)
+		}
+		permissions, _ := request.getByte(tlvPermissions)
+		newPairing := hkTY.Pairing{ID: string(request.get(tlvIdentifier)), PublicKey: hex.EncodeToString(publicKey), Admin: permissions == 1}
+		existing := a.state.getPairing(newPairing.ID)
+		if existing != nil && existing.PublicKey != newPairing.PublicKey {
+			return errorResponse(2, tlvErrorUnknown)
+		}
+		if err := a.state.addPairing(newPairing); err != nil {
+			a.logger.Error("error on adding pairing", zap.Error(err))
+			return errorResponse(2, tlvErrorUnknown)
+		}
+		return &pairingResponse{tlv: newTLV8().addByte(tlvState, 2)}
+
+	case methodRemovePairing:
+		controllerID := string(request.get(tlvIdentifier))
+		if err := a.state.removePairing(controllerID); err != nil {
+			a.logger.Error("error on removing pairing", zap.Error(err))
+			return errorResponse(2, tlvErrorUnknown)
+		}
+		return &pairingResponse{
+			tlv: newTLV8().addByte(tlvState, 2),
+			afterWrite: func() {
+				a.closeUnpairedConnections()
+				a.announce()
+			},
+		}
+
+	case methodListPairings:
+		response := newTLV8().addByte(tlvState, 2)
+		for index, item := range a.state.listPairings() {
+			if index > 0 {
+				response.add(tlvSeparator, nil)
+			}
+			publicKey, _ := hex.DecodeString(item.PublicKey)
+			permissions := byte(0)
+			if item.Admin {
+				permissions = 1
+			}
+			response.add(tlvIdentifier, []byte(item.ID)).add(tlvPublicKey, publicKey).addByte(tlvPermissions, permissions)
+		}
+		return &pairingResponse{tlv: response}
+
+	default:
+		return errorResponse(2, tlvErrorUnknown)
+	}
+}
+
+func concat(values ...[]byte) []byte {
+	return bytes.Join(values, nil)
+}
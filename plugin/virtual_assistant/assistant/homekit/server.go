@@ -0,0 +1,447 @@
+package homekit
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mycontroller-org/server/v2/pkg/json"
+	hkTY "github.com/mycontroller-org/server/v2/plugin/virtual_assistant/assistant/homekit/types"
+	"go.uber.org/zap"
+)
+
+// accessory server paths
+const (
+	pathPairSetup       = "/pair-setup"
+	pathPairVerify      = "/pair-verify"
+	pathPairings        = "/pairings"
+	pathAccessories     = "/accessories"
+	pathCharacteristics = "/characteristics"
+	pathIdentify        = "/identify"
+)
+
+// response of the accessory server, afterWrite is executed after the response sent
+type serverResponse struct {
+	statusCode  int
+	contentType string
+	body        []byte
+	afterWrite  func()
+}
+
+func jsonResponse(statusCode int, data interface{}) *serverResponse {
+	if data == nil {
+		return &serverResponse{statusCode: statusCode}
+	}
+	body, err := json.Marshal(data)
+	if err != nil {
+		return &serverResponse{statusCode: http.StatusInternalServerError}
+	}
+	return &serverResponse{statusCode: statusCode, contentType: hkTY.ContentTypeHAPJSON, body: body}
+}
+
+func statusResponse(statusCode, status int) *serverResponse {
+	return jsonResponse(statusCode, &hkTY.Status{Status: status})
+}
+
+// startServer listens for the controller connections.
+// the connections are handled without net/http server, as the encryption is enabled in the middle of a connection
+// and the events are sent on the same connection
+func (a *Assistant) startServer() error {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", a.hkCfg.Port))
+	if err != nil {
+		return err
+	}
+	a.listener = listener
+	go a.acceptConnections()
+	return nil
+}
+
+func (a *Assistant) acceptConnections() {
+	for {
+		conn, err := a.listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			a.logger.Error("error on accepting a connection", zap.Error(err))
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+		c := newConnection(conn)
+		a.addConnection(c)
+		go a.serveConnection(c)
+	}
+}
+
+func (a *Assistant) serveConnection(c *connection) {
+	remoteAddress := c.conn.RemoteAddr().String()
+	a.logger.Debug("controller connected", zap.String("remoteAddress", remoteAddress))
+	defer func() {
+		a.removeConnection(c)
+		a.releasePairSetup(c)
+		_ = c.close()
+		a.logger.Debug("controller disconnected", zap.String("remoteAddress", remoteAddress))
+	}()
+
+	for {
+		request, err := http.ReadRequest(c.reader)
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				a.logger.Debug("error on reading a request", zap.String("remoteAddress", remoteAddress), zap.Error(err))
+			}
+			return
+		}
+		body, err := io.ReadAll(request.Body)
+		_ = request.Body.Close()
+		if err != nil {
+			a.logger.Debug("error on reading the body", zap.String("remoteAddress", remoteAddress), zap.Error(err))
+			return
+		}
+
+		response := a.handleRequest(c, request, body)
+		err = c.writeResponse(response.statusCode, response.contentType, response.body)
+		if err != nil {
+			a.logger.Debug("error on writing a response", zap.String("remoteAddress", remoteAddress), zap.Error(err))
+			return
+		}
+		if response.afterWrite != nil {
+			response.afterWrite()
+		}
+	}
+}
+
+func (a *Assistant) handleRequest(c *connection, r *http.Request, body []byte) *serverResponse {
+	path := r.URL.Path
+
+	// pairing requests are allowed without encryption
+	switch path {
+	case pathPairSetup, pathPairVerify:
+		if r.Method != http.MethodPost {
+			return &serverResponse{statusCode: http.StatusMethodNotAllowed}
+		}
+		request, err := decodeTLV8(body)
+		if err != nil {
+			return &serverResponse{statusCode: http.StatusBadRequest}
+		}
+		if path == pathPairSetup {
+			return toServerResponse(a.handlePairSetup(c, request))
+		}
+		return toServerResponse(a.handlePairVerify(c, request))
+
+	case pathIdentify:
+		if a.state.isPaired() {
+			return statusResponse(http.StatusBadRequest, hkTY.StatusInsufficientPrivileges)
+		}
+		a.logger.Info("identify requested on the bridge")
+		return &serverResponse{statusCode: http.StatusNoContent}
+	}
+
+	if !c.isVerified() {
+		return statusResponse(statusConnectionAuthorizationRequired, hkTY.StatusInsufficientPrivileges)
+	}
+
+	switch {
+	case path == pathPairings && r.Method == http.MethodPost:
+		request, err := decodeTLV8(body)
+		if err != nil {
+			return &serverResponse{statusCode: http.StatusBadRequest}
+		}
+		return toServerResponse(a.handlePairings(c, request))
+
+	case path == pathAccessories && r.Method == http.MethodGet:
+		accessories, err := a.getAccessoryDatabase()
+		if err != nil {
+			a.logger.Error("error on getting accessories", zap.Error(err))
+			return statusResponse(http.StatusServiceUnavailable, hkTY.StatusServiceCommunication)
+		}
+		a.updateValues(accessories)
+		items := make([]hkTY.Accessory, 0, len(accessories))
+		for _, item := range accessories {
+			items = append(items, item.toAccessory())
+		}
+		return jsonResponse(http.StatusOK, &hkTY.Accessories{Accessories: items})
+
+	case path == pathCharacteristics && r.Method == http.MethodGet:
+		return a.readCharacteristics(c, r)
+
+	case path == pathCharacteristics && r.Method == http.MethodPut:
+		request := &hkTY.Characteristics{}
+		if err := json.Unmarshal(body, request); err != nil {
+			return statusResponse(http.StatusBadRequest, hkTY.StatusInvalidValue)
+		}
+		return a.writeCharacteristics(c, request)
+
+	default:
+		return &serverResponse{statusCode: http.StatusNotFound}
+	}
+}
+
+func toServerResponse(response *pairingResponse) *serverResponse {
+	return &serverResponse{
+		statusCode:  http.StatusOK,
+		contentType: hkTY.ContentTypePairing,
+		body:        response.tlv.encode(),
+		afterWrite:  response.afterWrite,
+	}
+}
+
+// readCharacteristics returns values of the characteristics, example: "id=1.2,3.4&meta=1&ev=1"
+func (a *Assistant) readCharacteristics(c *connection, r *http.Request) *serverResponse {
+	query := r.URL.Query()
+	ids := strings.Split(query.Get("id"), ",")
+	withMeta := query.Get("meta") == "1"
+	withPerms := query.Get("perms") == "1"
+	withType := query.Get("type") == "1"
+	withEvents := query.Get("ev") == "1"
+
+	keys := make([][2]uint64, 0)
+	aids := make([]uint64, 0)
+	for _, id := range ids {
+		aid, iid, err := parseCharacteristicKey(id)
+		if err != nil {
+			return statusResponse(http.StatusBadRequest, hkTY.StatusInvalidValue)
+		}
+		keys = append(keys, [2]uint64{aid, iid})
+		aids = append(aids, aid)
+	}
+
+	accessories, err := a.getAccessoriesByAID(aids)
+	if err != nil {
+		a.logger.Error("error on getting accessories", zap.Error(err))
+		return statusResponse(http.StatusServiceUnavailable, hkTY.StatusServiceCommunication)
+	}
+	a.updateValues(accessories)
+
+	hasError := false
+	results := make([]hkTY.Characteristic, 0, len(keys))
+	for _, key := range keys {
+		result := hkTY.Characteristic{AccessoryID: key[0], InstanceID: key[1]}
+		status := hkTY.StatusSuccess
+
+		var characteristic *hkTY.Characteristic
+		if item := findAccessory(accessories, key[0]); item != nil {
+			characteristic = item.get(key[1])
+		}
+		switch {
+		case characteristic == nil:
+			status = hkTY.StatusResourceDoesNotExist
+		case !hasPerm(characteristic, hkTY.PermRead):
+			status = hkTY.StatusWriteOnly
+		default:
+			result.Value = characteristic.Value
+			if withMeta {
+				result.Format = characteristic.Format
+				result.Unit = characteristic.Unit
+				result.MinValue = characteristic.MinValue
+				result.MaxValue = characteristic.MaxValue
+				result.MinStep = characteristic.MinStep
+				result.ValidValues = characteristic.ValidValues
+			}
+			if withPerms {
+				result.Perms = characteristic.Perms
+			}
+			if withType {
+				result.Type = characteristic.Type
+			}
+			if withEvents {
+				subscribed := c.isSubscribed(characteristicKey(key[0], key[1]))
+				result.Events = &subscribed
+			}
+		}
+		if status != hkTY.StatusSuccess {
+			hasError = true
+		}
+		result.Status = &status
+		results = append(results, result)
+	}
+
+	if !hasError {
+		for index := range results {
+			results[index].Status = nil
+		}
+		return jsonResponse(http.StatusOK, &hkTY.Characteristics{Characteristics: results})
+	}
+	return jsonResponse(http.StatusMultiStatus, &hkTY.Characteristics{Characteristics: results})
+}
+
+// writeCharacteristics updates the values and event subscriptions
+func (a *Assistant) writeCharacteristics(c *connection, request *hkTY.Characteristics) *serverResponse {
+	aids := make([]uint64, 0)
+	for _, item := range request.Characteristics {
+		aids = append(aids, item.AccessoryID)
+	}
+	accessories, err := a.getAccessoriesByAID(aids)
+	if err != nil {
+		a.logger.Error("error on getting accessories", zap.Error(err))
+		return statusResponse(http.StatusServiceUnavailable, hkTY.StatusServiceCommunication)
+	}
+
+	statuses := make([]int, len(request.Characteristics))
+	writeContexts := make(map[uint64]*writeContext)
+	for index, item := range request.Characteristics {
+		statuses[index] = hkTY.StatusSuccess
+		ac := findAccessory(accessories, item.AccessoryID)
+		if ac == nil {
+			statuses[index] = hkTY.StatusResourceDoesNotExist
+			continue
+		}
+		characteristic := ac.get(item.InstanceID)
+		if characteristic == nil {
+			statuses[index] = hkTY.StatusResourceDoesNotExist
+			continue
+		}
+
+		if item.Events != nil {
+			if !hasPerm(characteristic, hkTY.PermEvents) {
+				statuses[index] = hkTY.StatusNotificationNotSupported
+				continue
+			}
+			c.subscribe(characteristicKey(item.AccessoryID, item.InstanceID), *item.Events)
+		}
+
+		if item.Value == nil {
+			continue
+		}
+		write, found := ac.writers[item.InstanceID]
+		if !found {
+			statuses[index] = hkTY.StatusReadOnly
+			continue
+		}
+		wc, found := writeContexts[item.AccessoryID]
+		if !found {
+			wc = newWriteContext(ac.vDevice)
+			writeContexts[item.AccessoryID] = wc
+		}
+		if err := write(wc, item.Value); err != nil {
+			a.logger.Debug("error on writing a characteristic", zap.Uint64("aid", item.AccessoryID), zap.Uint64("iid", item.InstanceID), zap.Any("value", item.Value), zap.Error(err))
+			statuses[index] = hkTY.StatusInvalidValue
+		}
+	}
+
+	// executes the actions of each accessory
+	for aid, wc := range writeContexts {
+		err := wc.finish()
+		if err == nil {
+			if wc.identify {
+				a.identify(findAccessory(accessories, aid))
+			}
+			err = a.postActions(wc.vDevice, wc.actions)
+		}
+		if err == nil {
+			continue
+		}
+		a.logger.Debug("error on executing the actions", zap.Uint64("aid", aid), zap.Error(err))
+		for index, item := range request.Characteristics {
+			if item.AccessoryID == aid && item.Value != nil && statuses[index] == hkTY.StatusSuccess {
+				statuses[index] = hkTY.StatusServiceCommunication
+			}
+		}
+	}
+
+	hasError := false
+	results := make([]hkTY.Characteristic, 0, len(statuses))
+	for index, item := range request.Characteristics {
+		status := statuses[index]
+		hasError = hasError || status != hkTY.StatusSuccess
+		results = append(results, hkTY.Characteristic{AccessoryID: item.AccessoryID, InstanceID: item.InstanceID, Status: &status})
+	}
+	if !hasError {
+		return &serverResponse{statusCode: http.StatusNoContent}
+	}
+	return jsonResponse(http.StatusMultiStatus, &hkTY.Characteristics{Characteristics: results})
+}
+
+// identify has no effect on the virtual devices, only logged
+func (a *Assistant) identify(ac *accessory) {
+	if ac.vDevice == nil {
+		a.logger.Info("identify requested on the bridge")
+		return
+	}
+	a.logger.Info("identify requested", zap.String("virtualDeviceId", ac.vDevice.ID), zap.String("virtualDeviceName", ac.vDevice.Name))
+}
+
+// sendEvents sends the changed characteristics to the subscribed controllers
+func (a *Assistant) sendEvents(characteristics []hkTY.Characteristic) {
+	if len(characteristics) == 0 {
+		return
+	}
+	for _, c := range a.getConnections() {
+		if !c.isVerified() {
+			continue
+		}
+		items := make([]hkTY.Characteristic, 0)
+		for _, characteristic := range characteristics {
+			if c.isSubscribed(characteristicKey(characteristic.AccessoryID, characteristic.InstanceID)) {
+				items = append(items, characteristic)
+			}
+		}
+		if len(items) == 0 {
+			continue
+		}
+		body, err := json.Marshal(&hkTY.Characteristics{Characteristics: items})
+		if err != nil {
+			a.logger.Error("error on converting to json", zap.Error(err))
+			return
+		}
+		if err := c.writeEvent(body); err != nil {
+			a.logger.Debug("error on sending event", zap.String("remoteAddress", c.conn.RemoteAddr().String()), zap.Error(err))
+			_ = c.close()
+		}
+	}
+}
+
+func (a *Assistant) addConnection(c *connection) {
+	a.connectionsMutex.Lock()
+	defer a.connectionsMutex.Unlock()
+	a.connections[c] = true
+}
+
+func (a *Assistant) removeConnection(c *connection) {
+	a.connectionsMutex.Lock()
+	defer a.connectionsMutex.Unlock()
+	delete(a.connections, c)
+}
+
+func (a *Assistant) getConnections() []*connection {
+	a.connectionsMutex.RLock()
+	defer a.connectionsMutex.RUnlock()
+	connections := make([]*connection, 0, len(a.connections))
+	for c := range a.connections {
+		connections = append(connections, c)
+	}
+	return connections
+}
+
+// closeUnpairedConnections closes the sessions of the removed controllers
+func (a *Assistant) closeUnpairedConnections() {
+	for _, c := range a.getConnections() {
+		pairingID := c.getPairingID()
+		if pairingID != "" && a.state.getPairing(pairingID) == nil {
+			a.logger.Debug("closing the session of removed controller", zap.String("controllerId", pairingID))
+			_ = c.close()
+		}
+	}
+}
+
+// closes the listener and all the connections
+func (a *Assistant) stopServer() {
+	if a.listener != nil {
+		_ = a.listener.Close()
+	}
+	for _, c := range a.getConnections() {
+		_ = c.close()
+	}
+}
+
+func hasPerm(characteristic *hkTY.Characteristic, perm string) bool {
+	for _, item := range characteristic.Perms {
+		if item == perm {
+			return true
+		}
+	}
+	return false
+}
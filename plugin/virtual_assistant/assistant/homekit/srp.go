@@ -0,0 +1,146 @@
+package homekit
+
+import (
+	"crypto/rand"
+	"crypto/sha512"
+	"crypto/subtle"
+	"errors"
+	"hash"
+	"math/big"
+)
+
+// srp-6a with 3072 bit group from rfc 5054 and sha-512, as defined on pair setup
+
+const (
+	srpUsername  = "Pair-Setup"
+	srpSaltSize  = 16
+	srpSecretLen = 32
+)
+
+var (
+	srpN = mustBigInt("" +
+		"FFFFFFFFFFFFFFFFC90FDAA22168C234C4C6628B80DC1CD129024E088A67CC74" +
+		"020BBEA63B139B22514A08798E3404DDEF9519B3CD3A431B302B0A6DF25F1437" +
+		"4FE1356D6D51C245E485B576625E7EC6F44C42E9A637ED6B0BFF5CB6F406B7ED" +
+		"EE386BFB5A899FA5AE9F24117C4B1FE649286651ECE45B3DC2007CB8A163BF05" +
+		"98DA48361C55D39A69163FA8FD24CF5F83655D23DCA3AD961C62F356208552BB" +
+		"9ED529077096966D670C354E4ABC9804F1746C08CA18217C32905E462E36CE3B" +
+		"E39E772C180E86039B2783A2EC07A28FB5C55DF06F4C52C9DE2BCBF695581718" +
+		"3995497CEA956AE515D2261898FA051015728E5A8AAAC42DAD33170D04507A33" +
+		"A85521ABDF1CBA64ECFB850458DBEF0A8AEA71575D060C7DB3970F85A6E1E4C7" +
+		"ABF5AE8CDB0933D71E8C94E04A25619DCEE3D2261AD2EE6BF12FFA06D98A0864" +
+		"D87602733EC86A64521F2B18177B200CBBE117577A615D6C770988C0BAD946E2" +
+		"08E24FA074E5AB3143DB5BFCE0FD108E4B82D120A93AD2CAFFFFFFFFFFFFFFFF")
+	srpG = big.NewInt(5)
+
+	srp3072 = &srpGroup{n: srpN, g: srpG, hash: sha512.New}
+)
+
+// srpGroup holds the prime, generator and hash function of the session
+type srpGroup struct {
+	n    *big.Int
+	g    *big.Int
+	hash func() hash.Hash
+}
+
+func mustBigInt(hexValue string) *big.Int {
+	value, ok := new(big.Int).SetString(hexValue, 16)
+	if !ok {
+		panic("invalid srp prime")
+	}
+	return value
+}
+
+// srpSession holds the server side of a pair setup
+type srpSession struct {
+	group     *srpGroup
+	username  string
+	salt      []byte
+	verifier  *big.Int
+	secret    *big.Int
+	publicKey []byte // B
+	key       []byte // K
+}
+
+func newSRPSession(password string) (*srpSession, error) {
+	salt := make([]byte, srpSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	secret := make([]byte, srpSecretLen)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	return newSRPSessionWith(srp3072, srpUsername, password, salt, secret), nil
+}
+
+// newSRPSessionWith creates the session with the given salt and secret (b)
+func newSRPSessionWith(group *srpGroup, username, password string, salt, secretBytes []byte) *srpSession {
+	x := new(big.Int).SetBytes(group.digest(salt, group.digest([]byte(username+":"+password))))
+	verifier := new(big.Int).Exp(group.g, x, group.n)
+	secret := new(big.Int).SetBytes(secretBytes)
+
+	// B = k*v + g^b
+	k := new(big.Int).SetBytes(group.digest(group.pad(group.n), group.pad(group.g)))
+	publicKey := new(big.Int).Mul(k, verifier)
+	publicKey.Add(publicKey, new(big.Int).Exp(group.g, secret, group.n))
+	publicKey.Mod(publicKey, group.n)
+
+	return &srpSession{
+		group:     group,
+		username:  username,
+		salt:      salt,
+		verifier:  verifier,
+		secret:    secret,
+		publicKey: group.pad(publicKey),
+	}
+}
+
+// verifyClient computes the session key from the client public key (A) and
+// verifies the client proof (M1). returns server proof (M2)
+func (s *srpSession) verifyClient(clientPublicKey, clientProof []byte) ([]byte, error) {
+	group := s.group
+	A := new(big.Int).SetBytes(clientPublicKey)
+	if new(big.Int).Mod(A, group.n).Sign() == 0 {
+		return nil, errors.New("invalid client public key")
+	}
+
+	// S = (A * v^u) ^ b
+	u := new(big.Int).SetBytes(group.digest(group.pad(A), s.publicKey))
+	S := new(big.Int).Exp(s.verifier, u, group.n)
+	S.Mul(S, A)
+	S.Exp(S, s.secret, group.n)
+	key := group.digest(group.pad(S))
+
+	expectedProof := group.clientProof(s.username, s.salt, clientPublicKey, s.publicKey, key)
+	if subtle.ConstantTimeCompare(expectedProof, clientProof) != 1 {
+		return nil, errors.New("invalid client proof")
+	}
+
+	s.key = key
+	// M2 = H(A, M1, K)
+	return group.digest(clientPublicKey, clientProof, key), nil
+}
+
+// clientProof returns M1 = H(H(N) xor H(g), H(I), s, A, B, K)
+func (g *srpGroup) clientProof(username string, salt, clientPublicKey, serverPublicKey, key []byte) []byte {
+	hashN := g.digest(g.n.Bytes())
+	hashG := g.digest(g.g.Bytes())
+	for index := range hashN {
+		hashN[index] ^= hashG[index]
+	}
+	return g.digest(hashN, g.digest([]byte(username)), salt, clientPublicKey, serverPublicKey, key)
+}
+
+func (g *srpGroup) digest(values ...[]byte) []byte {
+	hash := g.hash()
+	for _, value := range values {
+		hash.Write(value)
+	}
+	return hash.Sum(nil)
+}
+
+// returns the value padded to the length of N
+func (g *srpGroup) pad(value *big.Int) []byte {
+	return value.FillBytes(make([]byte, len(g.n.Bytes())))
+}
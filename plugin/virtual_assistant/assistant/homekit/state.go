@@ -0,0 +1,353 @@
+package homekit
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	entityAPI "github.com/mycontroller-org/server/v2/pkg/api/entities"
+	hkTY "github.com/mycontroller-org/server/v2/plugin/virtual_assistant/assistant/homekit/types"
+)
+
+const (
+	setupIDCharacters = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+)
+
+// setup codes not allowed by the specification
+var invalidSetupCodes = []string{
+	"000-00-000", "111-11-111", "222-22-222", "333-33-333", "444-44-444",
+	"555-55-555", "666-66-666", "777-77-777", "888-88-888", "999-99-999",
+	"123-45-678", "876-54-321",
+}
+
+// stateStore holds the bridge identity and pairings, stored on the assistant config.
+// secrets (setup code and private key) are encrypted by the virtual assistant api
+type stateStore struct {
+	api         *entityAPI.API
+	assistantID string
+	cfg         *hkTY.Config
+	privateKey  ed25519.PrivateKey
+	mutex       sync.RWMutex
+}
+
+// newStateStore generates the missing identity and stores it
+func newStateStore(api *entityAPI.API, assistantID string, cfg *hkTY.Config) (*stateStore, error) {
+	s := &stateStore{api: api, assistantID: assistantID, cfg: cfg}
+	updated := false
+
+	if cfg.SetupCode == "" {
+		setupCode, err := generateSetupCode()
+		if err != nil {
+			return nil, err
+		}
+		cfg.SetupCode = setupCode
+		updated = true
+	} else if err := validateSetupCode(cfg.SetupCode); err != nil {
+		return nil, err
+	}
+
+	if cfg.SetupID == "" {
+		setupID, err := randomString(setupIDCharacters, 4)
+		if err != nil {
+			return nil, err
+		}
+		cfg.SetupID = setupID
+		updated = true
+	}
+
+	if cfg.AccessoryID == "" {
+		bytes := make([]byte, 6)
+		if _, err := rand.Read(bytes); err != nil {
+			return nil, err
+		}
+		parts := make([]string, 0)
+		for _, b := range bytes {
+			parts = append(parts, fmt.Sprintf("%02X", b))
+		}
+		cfg.AccessoryID = strings.Join(parts, ":")
+		updated = true
+	}
+
+	if cfg.PrivateKey == "" {
+		_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		cfg.PrivateKey = hex.EncodeToString(privateKey.Seed())
+		updated = true
+	}
+	seed, err := hex.DecodeString(cfg.PrivateKey)
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("invalid private key")
+	}
+	s.privateKey = ed25519.NewKeyFromSeed(seed)
+
+	if cfg.Pairings == nil {
+		cfg.Pairings = make([]hkTY.Pairing, 0)
+	}
+	if cfg.AccessoryIDs == nil {
+		cfg.AccessoryIDs = make(map[string]uint64)
+	}
+	if cfg.LastAccessoryID < hkTY.BridgeAccessoryID {
+		cfg.LastAccessoryID = hkTY.BridgeAccessoryID
+	}
+	if cfg.ConfigNumber == 0 {
+		cfg.ConfigNumber = 1
+		updated = true
+	}
+
+	if updated {
+		if err := s.save(); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func (s *stateStore) publicKey() []byte {
+	return s.privateKey.Public().(ed25519.PublicKey)
+}
+
+func (s *stateStore) sign(message []byte) []byte {
+	return ed25519.Sign(s.privateKey, message)
+}
+
+func (s *stateStore) isPaired() bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return len(s.cfg.Pairings) > 0
+}
+
+func (s *stateStore) getPairing(id string) *hkTY.Pairing {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	for _, pairing := range s.cfg.Pairings {
+		if pairing.ID == id {
+			p := pairing
+			return &p
+		}
+	}
+	return nil
+}
+
+func (s *stateStore) listPairings() []hkTY.Pairing {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return append([]hkTY.Pairing{}, s.cfg.Pairings...)
+}
+
+// addPairing adds or updates the pairing
+func (s *stateStore) addPairing(pairing hkTY.Pairing) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for index := range s.cfg.Pairings {
+		if s.cfg.Pairings[index].ID == pairing.ID {
+			s.cfg.Pairings[index] = pairing
+			return s.saveLocked()
+		}
+	}
+	s.cfg.Pairings = append(s.cfg.Pairings, pairing)
+	return s.saveLocked()
+}
+
+// removePairing removes the pairing, removes all the pairings if no admin left
+func (s *stateStore) removePairing(id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	pairings := make([]hkTY.Pairing, 0)
+	hasAdmin := false
+	for _, pairing := range s.cfg.Pairings {
+		if pairing.ID == id {
+			continue
+		}
+		hasAdmin = hasAdmin || pairing.Admin
+		pairings = append(pairings, pairing)
+	}
+	if !hasAdmin {
+		pairings = make([]hkTY.Pairing, 0)
+	}
+	s.cfg.Pairings = pairings
+	return s.saveLocked()
+}
+
+// removeAllPairings resets the bridge into unpaired state, clears the pair setup attempts
+func (s *stateStore) removeAllPairings() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.cfg.Pairings = make([]hkTY.Pairing, 0)
+	s.cfg.PairSetupAttempts = 0
+	return s.saveLocked()
+}
+
+func (s *stateStore) pairSetupAttempts() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.cfg.PairSetupAttempts
+}
+
+// addPairSetupAttempt stores the unsuccessful attempt, the limit should survive the restart
+func (s *stateStore) addPairSetupAttempt() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.cfg.PairSetupAttempts++
+	return s.saveLocked()
+}
+
+// getAccessoryID returns the accessory id of the virtual device, assigns a new id if not available
+func (s *stateStore) getAccessoryID(deviceID string) (uint64, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if aid, found := s.cfg.AccessoryIDs[deviceID]; found {
+		return aid, false
+	}
+	s.cfg.LastAccessoryID++
+	s.cfg.AccessoryIDs[deviceID] = s.cfg.LastAccessoryID
+	return s.cfg.LastAccessoryID, true
+}
+
+// getDeviceIDs returns the virtual device ids of the accessory ids, unknown accessory ids are ignored
+func (s *stateStore) getDeviceIDs(aids []uint64) []string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	requested := make(map[uint64]bool)
+	for _, aid := range aids {
+		requested[aid] = true
+	}
+	deviceIDs := make([]string, 0)
+	for deviceID, aid := range s.cfg.AccessoryIDs {
+		if requested[aid] {
+			deviceIDs = append(deviceIDs, deviceID)
+		}
+	}
+	return deviceIDs
+}
+
+// lookupAccessoryID returns the accessory id of the virtual device, without assigning a new id
+func (s *stateStore) lookupAccessoryID(deviceID string) (uint64, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	aid, found := s.cfg.AccessoryIDs[deviceID]
+	return aid, found
+}
+
+// updateConfigHash increments the config number, if the accessory database changed
+func (s *stateStore) updateConfigHash(hash string, deviceIDs []string) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// removes the accessory ids of the devices not available
+	available := make(map[string]bool)
+	for _, deviceID := range deviceIDs {
+		available[deviceID] = true
+	}
+	for deviceID := range s.cfg.AccessoryIDs {
+		if !available[deviceID] {
+			delete(s.cfg.AccessoryIDs, deviceID)
+		}
+	}
+
+	if s.cfg.ConfigHash == hash {
+		return false, nil
+	}
+	s.cfg.ConfigHash = hash
+	s.cfg.ConfigNumber++
+	if s.cfg.ConfigNumber > 65535 { // c# is a 16 bit value
+		s.cfg.ConfigNumber = 1
+	}
+	return true, s.saveLocked()
+}
+
+func (s *stateStore) configNumber() uint64 {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.cfg.ConfigNumber
+}
+
+func (s *stateStore) save() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.saveLocked()
+}
+
+// stores the state on the assistant config, to be used after restart
+func (s *stateStore) saveLocked() error {
+	vaCfg, err := s.api.VirtualAssistant().GetByID(s.assistantID)
+	if err != nil {
+		return err
+	}
+	if vaCfg.Config == nil {
+		vaCfg.Config = make(map[string]interface{})
+	}
+
+	values := map[string]interface{}{
+		hkTY.ConfigKeySetupCode:         s.cfg.SetupCode,
+		hkTY.ConfigKeySetupID:           s.cfg.SetupID,
+		hkTY.ConfigKeyAccessoryID:       s.cfg.AccessoryID,
+		hkTY.ConfigKeyPrivateKey:        s.cfg.PrivateKey,
+		hkTY.ConfigKeyPairings:          s.cfg.Pairings,
+		hkTY.ConfigKeyAccessoryIDs:      s.cfg.AccessoryIDs,
+		hkTY.ConfigKeyLastAccessoryID:   s.cfg.LastAccessoryID,
+		hkTY.ConfigKeyConfigNumber:      s.cfg.ConfigNumber,
+		hkTY.ConfigKeyConfigHash:        s.cfg.ConfigHash,
+		hkTY.ConfigKeyPairSetupAttempts: s.cfg.PairSetupAttempts,
+	}
+	// remove the existing entries, in any case
+	for key := range vaCfg.Config {
+		for valueKey := range values {
+			if strings.EqualFold(key, valueKey) {
+				delete(vaCfg.Config, key)
+			}
+		}
+	}
+	for key, value := range values {
+		vaCfg.Config[key] = value
+	}
+	return s.api.VirtualAssistant().Save(vaCfg)
+}
+
+// generates a random setup code in the format XXX-XX-XXX
+func generateSetupCode() (string, error) {
+	for {
+		digits, err := randomString("0123456789", 8)
+		if err != nil {
+			return "", err
+		}
+		setupCode := fmt.Sprintf("%s-%s-%s", digits[0:3], digits[3:5], digits[5:8])
+		if validateSetupCode(setupCode) == nil {
+			return setupCode, nil
+		}
+	}
+}
+
+func validateSetupCode(setupCode string) error {
+	if len(setupCode) != 10 || setupCode[3] != '-' || setupCode[6] != '-' {
+		return fmt.Errorf("setup code should be in the format XXX-XX-XXX")
+	}
+	for index, char := range setupCode {
+		if index != 3 && index != 6 && (char < '0' || char > '9') {
+			return fmt.Errorf("setup code should contain only digits")
+		}
+	}
+	for _, invalidCode := range invalidSetupCodes {
+		if setupCode == invalidCode {
+			return fmt.Errorf("setup code %s is not allowed", setupCode)
+		}
+	}
+	return nil
+}
+
+func randomString(characters string, length int) (string, error) {
+	result := make([]byte, length)
+	for index := range result {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(characters))))
+		if err != nil {
+			return "", err
+		}
+		result[index] = characters[n.Int64()]
+	}
+	return string(result), nil
+}
@@ -0,0 +1,131 @@
+package homekit
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	entityAPI "github.com/mycontroller-org/server/v2/pkg/api/entities"
+	encryptionAPI "github.com/mycontroller-org/server/v2/pkg/encryption"
+	coreScheduler "github.com/mycontroller-org/server/v2/pkg/service/core_scheduler"
+	"github.com/mycontroller-org/server/v2/pkg/types/cmap"
+	schedulerTY "github.com/mycontroller-org/server/v2/pkg/types/scheduler"
+	"github.com/mycontroller-org/server/v2/pkg/utils"
+	loggerUtils "github.com/mycontroller-org/server/v2/pkg/utils/logger"
+	"github.com/mycontroller-org/server/v2/plugin/bus/embedded"
+	busTY "github.com/mycontroller-org/server/v2/plugin/bus/types"
+	"github.com/mycontroller-org/server/v2/plugin/database/storage/memory"
+	storageTY "github.com/mycontroller-org/server/v2/plugin/database/storage/types"
+	hkTY "github.com/mycontroller-org/server/v2/plugin/virtual_assistant/assistant/homekit/types"
+	vaTY "github.com/mycontroller-org/server/v2/plugin/virtual_assistant/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const testAssistantID = "homekit"
+
+func newTestEntityAPI(t *testing.T) (*entityAPI.API, *encryptionAPI.Encryption) {
+	ctx := loggerUtils.WithContext(context.Background(), zap.NewNop())
+	ctx = schedulerTY.WithContext(ctx, coreScheduler.New())
+
+	storage, err := memory.New(ctx, cmap.CustomMap{})
+	require.NoError(t, err)
+	ctx = storageTY.WithContext(ctx, storage)
+
+	bus, err := embedded.NewClient(ctx, cmap.CustomMap{})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = bus.Close() })
+	ctx = busTY.WithContext(ctx, bus)
+	enc := encryptionAPI.New(zap.NewNop(), "0123456789abcdef0123456789abcdef", nil, "")
+	ctx = encryptionAPI.WithContext(ctx, enc)
+
+	api, err := entityAPI.New(ctx)
+	require.NoError(t, err)
+	require.NoError(t, api.VirtualAssistant().Save(&vaTY.Config{ID: testAssistantID, Config: cmap.CustomMap{}}))
+	return api, enc
+}
+
+// loadStateStore loads the stored config, as on the bridge start
+func loadStateStore(t *testing.T, api *entityAPI.API, enc *encryptionAPI.Encryption) *stateStore {
+	vaCfg, err := api.VirtualAssistant().GetByID(testAssistantID)
+	require.NoError(t, err)
+	require.NoError(t, enc.DecryptSecrets(vaCfg))
+	hkCfg := &hkTY.Config{}
+	require.NoError(t, utils.MapToStruct(utils.TagNameNone, vaCfg.Config, hkCfg))
+	state, err := newStateStore(api, testAssistantID, hkCfg)
+	require.NoError(t, err)
+	return state
+}
+
+func TestStateStore(t *testing.T) {
+	api, enc := newTestEntityAPI(t)
+
+	state := loadStateStore(t, api, enc)
+	assert.NoError(t, validateSetupCode(state.cfg.SetupCode))
+	assert.Len(t, state.cfg.SetupID, 4)
+	assert.Regexp(t, "^([0-9A-F]{2}:){5}[0-9A-F]{2}$", state.cfg.AccessoryID)
+	assert.False(t, state.isPaired())
+
+	require.NoError(t, state.addPairing(hkTY.Pairing{ID: "controller_1", PublicKey: "01", Admin: true}))
+	aid, created := state.getAccessoryID("device_1")
+	assert.Equal(t, uint64(hkTY.BridgeAccessoryID+1), aid)
+	assert.True(t, created)
+	_, err := state.updateConfigHash("hash_1", []string{"device_1"})
+	require.NoError(t, err)
+
+	// identity, pairings and accessory ids retained after restart
+	reloaded := loadStateStore(t, api, enc)
+	assert.Equal(t, state.cfg.SetupCode, reloaded.cfg.SetupCode)
+	assert.Equal(t, state.cfg.AccessoryID, reloaded.cfg.AccessoryID)
+	assert.Equal(t, state.publicKey(), reloaded.publicKey())
+	assert.True(t, reloaded.isPaired())
+	aid, created = reloaded.getAccessoryID("device_1")
+	assert.Equal(t, uint64(hkTY.BridgeAccessoryID+1), aid)
+	assert.False(t, created)
+
+	// pairings removed, if no admin left
+	require.NoError(t, reloaded.addPairing(hkTY.Pairing{ID: "controller_2", PublicKey: "02"}))
+	require.NoError(t, reloaded.removePairing("controller_1"))
+	assert.False(t, reloaded.isPaired())
+}
+
+func TestPairSetupAttempts(t *testing.T) {
+	api, enc := newTestEntityAPI(t)
+	state := loadStateStore(t, api, enc)
+	for count := 0; count < maxPairSetupAttempts-1; count++ {
+		require.NoError(t, state.addPairSetupAttempt())
+	}
+
+	accessoryConn, controllerConn := net.Pipe()
+	defer accessoryConn.Close()
+	defer controllerConn.Close()
+	c := newConnection(accessoryConn)
+
+	// attempts retained after restart
+	state = loadStateStore(t, api, enc)
+	assert.Equal(t, maxPairSetupAttempts-1, state.pairSetupAttempts())
+	assistant := &Assistant{logger: zap.NewNop(), hkCfg: state.cfg, state: state}
+	response := assistant.pairSetupStart(c)
+	_, hasError := response.tlv.getByte(tlvError)
+	assert.False(t, hasError)
+
+	// invalid setup code proof
+	response = assistant.pairSetupVerify(c, newTLV8().add(tlvPublicKey, []byte{1}).add(tlvProof, []byte{1}))
+	errorCode, _ := response.tlv.getByte(tlvError)
+	assert.Equal(t, byte(tlvErrorAuthentication), errorCode)
+
+	// locked after the max attempts, even after restart
+	state = loadStateStore(t, api, enc)
+	assert.Equal(t, maxPairSetupAttempts, state.pairSetupAttempts())
+	assistant = &Assistant{logger: zap.NewNop(), hkCfg: state.cfg, state: state}
+	response = assistant.pairSetupStart(c)
+	errorCode, _ = response.tlv.getByte(tlvError)
+	assert.Equal(t, byte(tlvErrorMaxTries), errorCode)
+	assert.Equal(t, maxPairSetupAttempts, assistant.getSetupInfo().PairSetupAttempts)
+
+	// cleared on reset
+	require.NoError(t, state.removeAllPairings())
+	state = loadStateStore(t, api, enc)
+	assert.Equal(t, 0, state.pairSetupAttempts())
+}
@@ -0,0 +1,130 @@
+package homekit
+
+import (
+	"bytes"
+	"errors"
+)
+
+// tlv types, used on pairing
+const (
+	tlvMethod        = 0x00
+	tlvIdentifier    = 0x01
+	tlvSalt          = 0x02
+	tlvPublicKey     = 0x03
+	tlvProof         = 0x04
+	tlvEncryptedData = 0x05
+	tlvState         = 0x06
+	tlvError         = 0x07
+	tlvSignature     = 0x0A
+	tlvPermissions   = 0x0B
+	tlvSeparator     = 0xFF
+)
+
+// tlv error codes
+const (
+	tlvErrorUnknown        = 0x01
+	tlvErrorAuthentication = 0x02
+	tlvErrorMaxTries       = 0x05
+	tlvErrorUnavailable    = 0x06
+	tlvErrorBusy           = 0x07
+)
+
+// pairing methods
+const (
+	methodPairSetup      = 0x00
+	methodAddPairing     = 0x03
+	methodRemovePairing  = 0x04
+	methodListPairings   = 0x05
+	tlvMaxFragmentLength = 255
+)
+
+// tlvItem is a type and value pair, keeps the order on encoding
+type tlvItem struct {
+	itemType byte
+	value    []byte
+}
+
+// tlv8 holds the items, values longer than 255 bytes are fragmented on encoding
+type tlv8 struct {
+	items []tlvItem
+}
+
+func newTLV8() *tlv8 {
+	return &tlv8{items: make([]tlvItem, 0)}
+}
+
+func (t *tlv8) add(itemType byte, value []byte) *tlv8 {
+	t.items = append(t.items, tlvItem{itemType: itemType, value: value})
+	return t
+}
+
+func (t *tlv8) addByte(itemType byte, value byte) *tlv8 {
+	return t.add(itemType, []byte{value})
+}
+
+// returns value of the first item of the type
+func (t *tlv8) get(itemType byte) []byte {
+	for _, item := range t.items {
+		if item.itemType == itemType {
+			return item.value
+		}
+	}
+	return nil
+}
+
+func (t *tlv8) getByte(itemType byte) (byte, bool) {
+	value := t.get(itemType)
+	if len(value) != 1 {
+		return 0, false
+	}
+	return value[0], true
+}
+
+func (t *tlv8) encode() []byte {
+	var buffer bytes.Buffer
+	for _, item := range t.items {
+		if len(item.value) == 0 {
+			buffer.WriteByte(item.itemType)
+			buffer.WriteByte(0)
+			continue
+		}
+		for offset := 0; offset < len(item.value); offset += tlvMaxFragmentLength {
+			end := offset + tlvMaxFragmentLength
+			if end > len(item.value) {
+				end = len(item.value)
+			}
+			buffer.WriteByte(item.itemType)
+			buffer.WriteByte(byte(end - offset))
+			buffer.Write(item.value[offset:end])
+		}
+	}
+	return buffer.Bytes()
+}
+
+// decodeTLV8 parses the data, fragments are merged into a single item
+func decodeTLV8(data []byte) (*tlv8, error) {
+	t := newTLV8()
+	previousFragmented := false
+	for offset := 0; offset < len(data); {
+		if offset+2 > len(data) {
+			return nil, errors.New("invalid tlv8 data")
+		}
+		itemType := data[offset]
+		length := int(data[offset+1])
+		offset += 2
+		if offset+length > len(data) {
+			return nil, errors.New("invalid tlv8 length")
+		}
+		value := data[offset : offset+length]
+		offset += length
+
+		lastIndex := len(t.items) - 1
+		if previousFragmented && lastIndex >= 0 && t.items[lastIndex].itemType == itemType {
+			t.items[lastIndex].value = append(t.items[lastIndex].value, value...)
+		} else {
+			t.items = append(t.items, tlvItem{itemType: itemType, value: append([]byte{}, value...)})
+		}
+		previousFragmented = length == tlvMaxFragmentLength
+	}
+	return t, nil
+}
@@ -0,0 +1,102 @@
+package homekit
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTLV8Encode(t *testing.T) {
+	// pair setup M1
+	data := newTLV8().addByte(tlvState, 1).addByte(tlvMethod, methodPairSetup).encode()
+	assert.Equal(t, []byte{tlvState, 1, 1, tlvMethod, 1, 0}, data)
+
+	// empty value
+	data = newTLV8().add(tlvSeparator, nil).encode()
+	assert.Equal(t, []byte{tlvSeparator, 0}, data)
+}
+
+func TestTLV8Fragmentation(t *testing.T) {
+	tests := []struct {
+		name      string
+		length    int
+		fragments []int
+	}{
+		{name: "single fragment", length: 100, fragments: []int{100}},
+		{name: "max fragment length", length: 255, fragments: []int{255}},
+		{name: "two fragments", length: 256, fragments: []int{255, 1}},
+		{name: "srp public key", length: 384, fragments: []int{255, 129}},
+		{name: "three fragments", length: 600, fragments: []int{255, 255, 90}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			value := make([]byte, test.length)
+			for index := range value {
+				value[index] = byte(index)
+			}
+			data := newTLV8().add(tlvPublicKey, value).addByte(tlvState, 2).encode()
+
+			// fragments with the same type, followed by the next item
+			offset := 0
+			for _, fragment := range test.fragments {
+				require.Equal(t, byte(tlvPublicKey), data[offset])
+				require.Equal(t, byte(fragment), data[offset+1])
+				offset += 2 + fragment
+			}
+			assert.Equal(t, []byte{tlvState, 1, 2}, data[offset:])
+
+			// fragments merged on decoding
+			decoded, err := decodeTLV8(data)
+			require.NoError(t, err)
+			assert.Equal(t, value, decoded.get(tlvPublicKey))
+			state, ok := decoded.getByte(tlvState)
+			assert.True(t, ok)
+			assert.Equal(t, byte(2), state)
+			assert.Len(t, decoded.items, 2)
+		})
+	}
+}
+
+func TestTLV8Decode(t *testing.T) {
+	// items of the same type separated, list pairings response
+	data := newTLV8().
+		add(tlvIdentifier, []byte("controller_1")).
+		add(tlvSeparator, nil).
+		add(tlvIdentifier, []byte("controller_2")).encode()
+	decoded, err := decodeTLV8(data)
+	require.NoError(t, err)
+	require.Len(t, decoded.items, 3)
+	assert.Equal(t, []byte("controller_1"), decoded.items[0].value)
+	assert.Equal(t, []byte("controller_2"), decoded.items[2].value)
+	assert.Equal(t, []byte("controller_1"), decoded.get(tlvIdentifier))
+
+	// short item not merged with the next item of the same type
+	data = append([]byte{tlvIdentifier, 2, 'a', 'b'}, tlvIdentifier, 1, 'c')
+	decoded, err = decodeTLV8(data)
+	require.NoError(t, err)
+	assert.Len(t, decoded.items, 2)
+
+	// decoded values do not share the input buffer
+	data = []byte{tlvProof, 2, 1, 2}
+	decoded, err = decodeTLV8(data)
+	require.NoError(t, err)
+	data[2] = 9
+	assert.Equal(t, []byte{1, 2}, decoded.get(tlvProof))
+
+	// missing items
+	assert.Nil(t, decoded.get(tlvSalt))
+	_, ok := decoded.getByte(tlvProof)
+	assert.False(t, ok)
+
+	invalid := [][]byte{
+		{tlvState},
+		{tlvState, 2, 1},
+		append([]byte{tlvPublicKey, 255}, bytes.Repeat([]byte{1}, 254)...),
+	}
+	for _, data := range invalid {
+		_, err = decodeTLV8(data)
+		assert.Error(t, err)
+	}
+}
@@ -0,0 +1,144 @@
+package types
+
+// defaults
+const (
+	DefaultName         = "MyController Bridge"
+	DefaultManufacturer = "MyController"
+	DefaultModel        = "Bridge"
+	DefaultPort         = 51826
+	DefaultDeviceLimit  = 149 // a bridge supports 150 accessories, including the bridge
+	BridgeAccessoryID   = 1
+	ProtocolVersion     = "1.1.0"
+)
+
+// config keys, updated by the bridge
+const (
+	ConfigKeySetupCode         = "setupCode"
+	ConfigKeySetupID           = "setupId"
+	ConfigKeyAccessoryID       = "accessoryId"
+	ConfigKeyPrivateKey        = "privateKey"
+	ConfigKeyPairings          = "pairings"
+	ConfigKeyAccessoryIDs      = "accessoryIds"
+	ConfigKeyLastAccessoryID   = "lastAccessoryId"
+	ConfigKeyConfigNumber      = "configNumber"
+	ConfigKeyConfigHash        = "configHash"
+	ConfigKeyPairSetupAttempts = "pairSetupAttempts"
+)
+
+// Config of homekit bridge
+type Config struct {
+	Name              string            `json:"name" yaml:"name"`
+	Port              int               `json:"port" yaml:"port"`
+	Address           string            `json:"address" yaml:"address"`                     // ip address advertised on mdns, defaults to all the interface addresses
+	SetupCode         string            `json:"setupCode" yaml:"setupCode"`                 // setup code in the format XXX-XX-XXX, generated if empty
+	SetupID           string            `json:"setupId" yaml:"setupId"`                     // 4 characters, used on setup uri
+	AccessoryID       string            `json:"accessoryId" yaml:"accessoryId"`             // pairing identifier of the bridge, generated
+	PrivateKey        string            `json:"privateKey" yaml:"privateKey"`               // long term ed25519 secret key, generated
+	Pairings          []Pairing         `json:"pairings" yaml:"pairings"`                   // paired controllers
+	AccessoryIDs      map[string]uint64 `json:"accessoryIds" yaml:"accessoryIds"`           // virtual device id to accessory id
+	LastAccessoryID   uint64            `json:"lastAccessoryId" yaml:"lastAccessoryId"`     // accessory ids are not reused
+	ConfigNumber      uint64            `json:"configNumber" yaml:"configNumber"`           // incremented on accessory database change
+	ConfigHash        string            `json:"configHash" yaml:"configHash"`               // hash of the accessory database
+	PairSetupAttempts int               `json:"pairSetupAttempts" yaml:"pairSetupAttempts"` // unsuccessful pair setup attempts, cleared on reset
+}
+
+// Pairing of a controller
+type Pairing struct {
+	ID        string `json:"id" yaml:"id"`
+	PublicKey string `json:"publicKey" yaml:"publicKey"` // hex encoded long term public key
+	Admin     bool   `json:"admin" yaml:"admin"`
+}
+
+// SetupInfo served on the assistant api, used to pair the bridge
+type SetupInfo struct {
+	Name              string `json:"name"`
+	AccessoryID       string `json:"accessoryId"`
+	SetupCode         string `json:"setupCode"`
+	SetupURI          string `json:"setupUri"`
+	Port              int    `json:"port"`
+	Paired            bool   `json:"paired"`
+	Pairings          int    `json:"pairings"`
+	PairSetupAttempts int    `json:"pairSetupAttempts"`
+}
+
+// accessory categories
+const (
+	CategoryOther            = 1
+	CategoryBridge           = 2
+	CategoryFan              = 3
+	CategoryGarageDoorOpener = 4
+	CategoryLightbulb        = 5
+	CategoryDoorLock         = 6
+	CategoryOutlet           = 7
+	CategorySwitch           = 8
+	CategoryThermostat       = 9
+	CategorySensor           = 10
+	CategoryDoor             = 12
+	CategoryWindow           = 13
+	CategoryWindowCovering   = 14
+)
+
+// content types
+const (
+	ContentTypePairing = "application/pairing+tlv8"
+	ContentTypeHAPJSON = "application/hap+json"
+)
+
+// status codes
+const (
+	StatusSuccess                  = 0
+	StatusInsufficientPrivileges   = -70401
+	StatusServiceCommunication     = -70402
+	StatusReadOnly                 = -70404
+	StatusWriteOnly                = -70405
+	StatusNotificationNotSupported = -70406
+	StatusResourceDoesNotExist     = -70409
+	StatusInvalidValue             = -70410
+)
+
+// Characteristic on the accessory database, read and write requests
+type Characteristic struct {
+	AccessoryID uint64      `json:"aid,omitempty"`
+	InstanceID  uint64      `json:"iid"`
+	Type        string      `json:"type,omitempty"`
+	Perms       []string    `json:"perms,omitempty"`
+	Format      string      `json:"format,omitempty"`
+	Value       interface{} `json:"value,omitempty"`
+	Unit        string      `json:"unit,omitempty"`
+	MinValue    *float64    `json:"minValue,omitempty"`
+	MaxValue    *float64    `json:"maxValue,omitempty"`
+	MinStep     *float64    `json:"minStep,omitempty"`
+	ValidValues []int       `json:"valid-values,omitempty"`
+	Description string      `json:"description,omitempty"`
+	Events      *bool       `json:"ev,omitempty"`
+	Status      *int        `json:"status,omitempty"`
+}
+
+// Service on the accessory database
+type Service struct {
+	InstanceID      uint64           `json:"iid"`
+	Type            string           `json:"type"`
+	Primary         bool             `json:"primary,omitempty"`
+	Characteristics []Characteristic `json:"characteristics"`
+}
+
+// Accessory on the accessory database
+type Accessory struct {
+	AccessoryID uint64    `json:"aid"`
+	Services    []Service `json:"services"`
+}
+
+// Accessories response
+type Accessories struct {
+	Accessories []Accessory `json:"accessories"`
+}
+
+// Characteristics request and response
+type Characteristics struct {
+	Characteristics []Characteristic `json:"characteristics"`
+}
+
+// Status response
+type Status struct {
+	Status int `json:"status"`
+}
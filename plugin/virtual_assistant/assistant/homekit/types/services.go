@@ -0,0 +1,139 @@
+package types
+
+// services, short form of the apple defined uuid
+const (
+	ServiceAccessoryInformation = "3E"
+	ServiceProtocolInformation  = "A2"
+	ServiceContactSensor        = "80"
+	ServiceDoor                 = "81"
+	ServiceFan                  = "B7"
+	ServiceGarageDoorOpener     = "41"
+	ServiceHumiditySensor       = "82"
+	ServiceLightSensor          = "84"
+	ServiceLightbulb            = "43"
+	ServiceLockMechanism        = "45"
+	ServiceMotionSensor         = "85"
+	ServiceOccupancySensor      = "86"
+	ServiceOutlet               = "47"
+	ServiceSwitch               = "49"
+	ServiceTemperatureSensor    = "8A"
+	ServiceThermostat           = "4A"
+	ServiceWindow               = "8B"
+	ServiceWindowCovering       = "8C"
+)
+
+// characteristics, short form of the apple defined uuid
+const (
+	CharacteristicActive                     = "B0"
+	CharacteristicBrightness                 = "8"
+	CharacteristicColorTemperature           = "CE"
+	CharacteristicContactSensorState         = "6A"
+	CharacteristicCurrentAmbientLightLevel   = "6B"
+	CharacteristicCurrentDoorState           = "E"
+	CharacteristicCurrentHeatingCoolingState = "F"
+	CharacteristicCurrentPosition            = "6D"
+	CharacteristicCurrentRelativeHumidity    = "10"
+	CharacteristicCurrentTemperature         = "11"
+	CharacteristicFirmwareRevision           = "52"
+	CharacteristicHue                        = "13"
+	CharacteristicIdentify                   = "14"
+	CharacteristicLockCurrentState           = "1D"
+	CharacteristicLockTargetState            = "1E"
+	CharacteristicManufacturer               = "20"
+	CharacteristicModel                      = "21"
+	CharacteristicMotionDetected             = "22"
+	CharacteristicName                       = "23"
+	CharacteristicObstructionDetected        = "24"
+	CharacteristicOccupancyDetected          = "71"
+	CharacteristicOn                         = "25"
+	CharacteristicOutletInUse                = "26"
+	CharacteristicPositionState              = "72"
+	CharacteristicRotationSpeed              = "29"
+	CharacteristicSaturation                 = "2F"
+	CharacteristicSerialNumber               = "30"
+	CharacteristicTargetDoorState            = "32"
+	CharacteristicTargetHeatingCoolingState  = "33"
+	CharacteristicTargetPosition             = "7C"
+	CharacteristicTargetTemperature          = "35"
+	CharacteristicTemperatureDisplayUnits    = "36"
+	CharacteristicVersion                    = "37"
+)
+
+// characteristic permissions
+const (
+	PermRead   = "pr"
+	PermWrite  = "pw"
+	PermEvents = "ev"
+)
+
+// characteristic formats
+const (
+	FormatBool   = "bool"
+	FormatUInt8  = "uint8"
+	FormatUInt32 = "uint32"
+	FormatInt    = "int"
+	FormatFloat  = "float"
+	FormatString = "string"
+)
+
+// characteristic units
+const (
+	UnitPercentage = "percentage"
+	UnitArcDegrees = "arcdegrees"
+	UnitCelsius    = "celsius"
+	UnitLux        = "lux"
+)
+
+// characteristic values
+const (
+	HeatingCoolingOff  = 0
+	HeatingCoolingHeat = 1
+	HeatingCoolingCool = 2
+	HeatingCoolingAuto = 3
+
+	TemperatureDisplayCelsius    = 0
+	TemperatureDisplayFahrenheit = 1
+
+	LockUnsecured = 0
+	LockSecured   = 1
+
+	DoorStateOpen   = 0
+	DoorStateClosed = 1
+
+	PositionStateStopped = 2
+
+	ContactDetected    = 0
+	ContactNotDetected = 1
+)
+
+// HeatingCoolingMap converts the thermostat mode value into heating cooling state
+var HeatingCoolingMap = map[string]int64{
+	"off":      HeatingCoolingOff,
+	"heat":     HeatingCoolingHeat,
+	"cool":     HeatingCoolingCool,
+	"auto":     HeatingCoolingAuto,
+	"heatcool": HeatingCoolingAuto,
+}
+
+// HeatingCoolingNames used as thermostat mode value, when the supported values are not defined
+var HeatingCoolingNames = map[int64]string{
+	HeatingCoolingOff:  "off",
+	HeatingCoolingHeat: "heat",
+	HeatingCoolingCool: "cool",
+	HeatingCoolingAuto: "auto",
+}
+
+// SensorServiceMap maps sensor name of sensor_state trait into homekit service
+var SensorServiceMap = map[string]string{
+	"temperature": ServiceTemperatureSensor,
+	"humidity":    ServiceHumiditySensor,
+	"illuminance": ServiceLightSensor,
+	"light":       ServiceLightSensor,
+	"lux":         ServiceLightSensor,
+	"motion":      ServiceMotionSensor,
+	"occupancy":   ServiceOccupancySensor,
+	"presence":    ServiceOccupancySensor,
+	"contact":     ServiceContactSensor,
+	"door":        ServiceContactSensor,
+	"window":      ServiceContactSensor,
+}
@@ -117,9 +117,11 @@ func (d *DeviceAPI) ListDevices(filters []storageTY.Filter, limit, offset int64,
 }
 
 func (d *DeviceAPI) UpdateDeviceState(vDevices []vdTY.VirtualDevice) error {
-	for _, vDevice := range vDevices {
-		for _, vResource := range vDevice.Traits {
-			value, valueTimestamp, err := d.GetResourceState(&vDevice, vResource.TraitType, &vResource)
+	for index := range vDevices {
+		vDevice := &vDevices[index]
+		for traitIndex := range vDevice.Traits {
+			vResource := &vDevice.Traits[traitIndex]
+			value, valueTimestamp, err := d.GetResourceState(vDevice, vResource.TraitType, vResource)
 			if err != nil {
 				return err
 			}
@@ -133,7 +135,11 @@ func (d *DeviceAPI) UpdateDeviceState(vDevices []vdTY.VirtualDevice) error {
 func (d *DeviceAPI) GetResourceState(device *vdTY.VirtualDevice, trait string, vResource *vdTY.Resource) (interface{}, time.Time, error) {
 	valueTimestamp := time.Time{}
 
-	quickID := fmt.Sprintf("%s:%s", vResource.ResourceType, vResource.QuickID)
+	resourceQuickID, err := d.GetResourceQuickID(vResource)
+	if err != nil {
+		return nil, valueTimestamp, err
+	}
+	quickID := fmt.Sprintf("%s:%s", vResource.ResourceType, resourceQuickID)
 	responseMap, err := d.quickIdAPI.GetResources([]string{quickID})
 	if err != nil {
 		return nil, valueTimestamp, err
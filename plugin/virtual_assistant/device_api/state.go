@@ -0,0 +1,164 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mycontroller-org/server/v2/pkg/types"
+	fieldTY "github.com/mycontroller-org/server/v2/pkg/types/field"
+	vdTY "github.com/mycontroller-org/server/v2/pkg/types/virtual_device"
+	"github.com/mycontroller-org/server/v2/pkg/utils"
+	quickIdUtils "github.com/mycontroller-org/server/v2/pkg/utils/quick_id"
+	storageTY "github.com/mycontroller-org/server/v2/plugin/database/storage/types"
+	"go.uber.org/zap"
+)
+
+const defaultInactiveDuration = 15 * time.Minute
+
+// GetResourceQuickID returns the quick id of the resource,
+// resource without quick id is selected by the labels, "trait_*" labels are not used on the selection
+func (d *DeviceAPI) GetResourceQuickID(vResource *vdTY.Resource) (string, error) {
+	if vResource.QuickID != "" {
+		return vResource.QuickID, nil
+	}
+
+	filters := make([]storageTY.Filter, 0)
+	for key, value := range vResource.Labels {
+		if strings.HasPrefix(key, "trait_") {
+			continue
+		}
+		filters = append(filters, storageTY.Filter{Key: fmt.Sprintf("labels.%s", key), Operator: storageTY.OperatorEqual, Value: value})
+	}
+	if len(filters) == 0 {
+		return "", fmt.Errorf("quickId or selector labels not defined on the resource. trait:%s, name:%s", vResource.TraitType, vResource.Name)
+	}
+
+	switch vResource.ResourceType {
+	case quickIdUtils.QuickIdField:
+		result, err := d.api.Field().List(filters, &storageTY.Pagination{Limit: 1})
+		if err != nil {
+			return "", err
+		}
+		if fields, ok := result.Data.(*[]fieldTY.Field); ok && len(*fields) > 0 {
+			field := (*fields)[0]
+			return fmt.Sprintf("%s.%s.%s.%s", field.GatewayID, field.NodeID, field.SourceID, field.FieldID), nil
+		}
+		return "", fmt.Errorf("no field matches the labels. trait:%s, name:%s, labels:%v", vResource.TraitType, vResource.Name, vResource.Labels)
+
+	default:
+		return "", fmt.Errorf("labels selection not supported on the resource type[%s]", vResource.ResourceType)
+	}
+}
+
+// GetDeviceStates returns the devices with the current value of the traits and the online status
+func (d *DeviceAPI) GetDeviceStates(vDevices []vdTY.VirtualDevice) []vdTY.DeviceState {
+	inactiveDuration := defaultInactiveDuration
+	settings, err := d.api.Settings().GetSystemSettings()
+	if err != nil {
+		d.logger.Error("error on getting system settings", zap.Error(err))
+	} else {
+		inactiveDuration = utils.ToDuration(settings.NodeStateJob.InactiveDuration, defaultInactiveDuration)
+	}
+
+	states := make([]vdTY.DeviceState, 0, len(vDevices))
+	for index := range vDevices {
+		vDevice := &vDevices[index]
+		state := vdTY.DeviceState{
+			ID:          vDevice.ID,
+			Name:        vDevice.Name,
+			Description: vDevice.Description,
+			DeviceType:  vDevice.DeviceType,
+			Location:    vDevice.Location,
+			Labels:      vDevice.Labels,
+			Traits:      make([]vdTY.TraitState, 0, len(vDevice.Traits)),
+		}
+
+		// nodes of the resources, used to find the online status
+		nodes := make(map[string][]string)
+		for traitIndex := range vDevice.Traits {
+			vResource := &vDevice.Traits[traitIndex]
+			traitState := vdTY.TraitState{
+				Name:         vResource.Name,
+				TraitType:    vResource.TraitType,
+				Parameter:    GetTraitParameter(vResource),
+				ResourceType: vResource.ResourceType,
+			}
+			quickID, err := d.GetResourceQuickID(vResource)
+			if err != nil {
+				traitState.Error = err.Error()
+				state.Traits = append(state.Traits, traitState)
+				continue
+			}
+			traitState.QuickID = quickID
+
+			value, timestamp, err := d.GetResourceState(vDevice, vResource.TraitType, vResource)
+			if err != nil {
+				traitState.Error = err.Error()
+			} else {
+				traitState.Value = value
+				traitState.Timestamp = timestamp
+			}
+			state.Traits = append(state.Traits, traitState)
+
+			_, keys, err := quickIdUtils.EntityKeyValueMap(fmt.Sprintf("%s:%s", vResource.ResourceType, quickID))
+			if err == nil && keys[types.KeyGatewayID] != "" && keys[types.KeyNodeID] != "" {
+				nodes[fmt.Sprintf("%s.%s", keys[types.KeyGatewayID], keys[types.KeyNodeID])] = []string{keys[types.KeyGatewayID], keys[types.KeyNodeID]}
+			}
+		}
+
+		// device is online, if all the nodes seen within the inactive duration
+		state.Online = len(nodes) > 0
+		currentTime := time.Now()
+		for _, ids := range nodes {
+			node, err := d.api.Node().GetByGatewayAndNodeID(ids[0], ids[1])
+			if err != nil {
+				state.Online = false
+				continue
+			}
+			if state.LastSeen.IsZero() || node.LastSeen.Before(state.LastSeen) {
+				state.LastSeen = node.LastSeen
+			}
+			duration := utils.ToDuration(node.Labels.Get(types.LabelNodeInactiveDuration), inactiveDuration)
+			if node.LastSeen.Before(currentTime.Add(-duration)) {
+				state.Online = false
+			}
+		}
+
+		states = append(states, state)
+	}
+	return states
+}
+
+// ExecuteCommand converts the trait level command value to the resource value and sends it to the resource
+func (d *DeviceAPI) ExecuteCommand(vDevice *vdTY.VirtualDevice, command *vdTY.DeviceCommand) error {
+	if command.Trait == "" {
+		return fmt.Errorf("trait can not be empty")
+	}
+
+	var vResource *vdTY.Resource
+	if command.Name != "" {
+		vResource = GetTraitResourceByName(vDevice, command.Trait, command.Name)
+	} else {
+		parameter := command.Parameter
+		if parameter == "" {
+			parameter = DefaultTraitParameter[command.Trait]
+		}
+		vResource = GetTraitResource(vDevice, command.Trait, parameter)
+	}
+	if vResource == nil {
+		return fmt.Errorf("trait resource not found. deviceId:%s, trait:%s, parameter:%s, name:%s", vDevice.ID, command.Trait, command.Parameter, command.Name)
+	}
+
+	value, err := CommandToValue(vResource, command.Value)
+	if err != nil {
+		return err
+	}
+
+	quickID, err := d.GetResourceQuickID(vResource)
+	if err != nil {
+		return err
+	}
+
+	return d.PostActionOnResourceByQuickID(vResource.ResourceType, fmt.Sprintf("%s:%s", vResource.ResourceType, quickID), value)
+}
@@ -0,0 +1,228 @@
+package api
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	entityAPI "github.com/mycontroller-org/server/v2/pkg/api/entities"
+	encryptionAPI "github.com/mycontroller-org/server/v2/pkg/encryption"
+	coreScheduler "github.com/mycontroller-org/server/v2/pkg/service/core_scheduler"
+	types "github.com/mycontroller-org/server/v2/pkg/types"
+	"github.com/mycontroller-org/server/v2/pkg/types/cmap"
+	fieldTY "github.com/mycontroller-org/server/v2/pkg/types/field"
+	msgTY "github.com/mycontroller-org/server/v2/pkg/types/message"
+	nodeTY "github.com/mycontroller-org/server/v2/pkg/types/node"
+	schedulerTY "github.com/mycontroller-org/server/v2/pkg/types/scheduler"
+	"github.com/mycontroller-org/server/v2/pkg/types/topic"
+	vdTY "github.com/mycontroller-org/server/v2/pkg/types/virtual_device"
+	loggerUtils "github.com/mycontroller-org/server/v2/pkg/utils/logger"
+	"github.com/mycontroller-org/server/v2/plugin/bus/embedded"
+	busTY "github.com/mycontroller-org/server/v2/plugin/bus/types"
+	"github.com/mycontroller-org/server/v2/plugin/database/storage/memory"
+	storageTY "github.com/mycontroller-org/server/v2/plugin/database/storage/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newTestDeviceAPI(t *testing.T) (*DeviceAPI, busTY.Plugin) {
+	ctx := loggerUtils.WithContext(context.Background(), zap.NewNop())
+	ctx = schedulerTY.WithContext(ctx, coreScheduler.New())
+
+	storage, err := memory.New(ctx, cmap.CustomMap{})
+	require.NoError(t, err)
+	ctx = storageTY.WithContext(ctx, storage)
+
+	bus, err := embedded.NewClient(ctx, cmap.CustomMap{})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = bus.Close() })
+	ctx = busTY.WithContext(ctx, bus)
+	ctx = encryptionAPI.WithContext(ctx, encryptionAPI.New(zap.NewNop(), "0123456789abcdef0123456789abcdef", nil, ""))
+
+	api, err := entityAPI.New(ctx)
+	require.NoError(t, err)
+	ctx = entityAPI.WithContext(ctx, api)
+
+	_deviceAPI, err := New(ctx)
+	require.NoError(t, err)
+	return _deviceAPI, bus
+}
+
+func saveNode(t *testing.T, d *DeviceAPI, nodeID string, lastSeen time.Time, labels cmap.CustomStringMap) {
+	node := &nodeTY.Node{ID: "gw." + nodeID, GatewayID: "gw", NodeID: nodeID, LastSeen: lastSeen, Labels: labels}
+	require.NoError(t, d.api.Node().Save(node, false))
+}
+
+func saveField(t *testing.T, d *DeviceAPI, nodeID, fieldID string, value interface{}, timestamp time.Time, labels cmap.CustomStringMap) {
+	field := &fieldTY.Field{
+		ID:        "gw." + nodeID + ".source." + fieldID,
+		GatewayID: "gw",
+		NodeID:    nodeID,
+		SourceID:  "source",
+		FieldID:   fieldID,
+		Current:   fieldTY.Payload{Value: value, Timestamp: timestamp},
+		Labels:    labels,
+	}
+	require.NoError(t, d.api.Field().Save(field, false))
+}
+
+func TestGetDeviceStates(t *testing.T) {
+	d, _ := newTestDeviceAPI(t)
+
+	now := time.Now().Truncate(time.Second)
+	saveNode(t, d, "active", now.Add(-time.Minute), nil)
+	saveNode(t, d, "inactive", now.Add(-20*time.Minute), nil)
+	// node level inactive duration
+	saveNode(t, d, "sleeping", now.Add(-30*time.Minute), cmap.CustomStringMap{types.LabelNodeInactiveDuration: "1h"})
+
+	saveField(t, d, "active", "power", true, now.Add(-time.Minute), cmap.CustomStringMap{"location": "kitchen"})
+	saveField(t, d, "inactive", "temperature", 21.5, now.Add(-20*time.Minute), nil)
+	saveField(t, d, "sleeping", "contact", false, now.Add(-30*time.Minute), nil)
+
+	power := vdTY.Resource{Name: "power", TraitType: vdTY.DeviceTraitOnOff, ResourceType: "field", QuickID: "gw.active.source.power"}
+	temperature := vdTY.Resource{Name: "temperature", TraitType: vdTY.DeviceTraitSensorState, ResourceType: "field", QuickID: "gw.inactive.source.temperature"}
+	tests := []struct {
+		name     string
+		traits   []vdTY.Resource
+		online   bool
+		lastSeen time.Time
+		errors   []bool
+	}{
+		{name: "active node", traits: []vdTY.Resource{power}, online: true, lastSeen: now.Add(-time.Minute), errors: []bool{false}},
+		{name: "one of the nodes inactive", traits: []vdTY.Resource{power, temperature}, online: false, lastSeen: now.Add(-20 * time.Minute), errors: []bool{false, false}},
+		{name: "node inactive duration", traits: []vdTY.Resource{{Name: "contact", TraitType: vdTY.DeviceTraitSensorState, ResourceType: "field", QuickID: "gw.sleeping.source.contact"}}, online: true, lastSeen: now.Add(-30 * time.Minute), errors: []bool{false}},
+		{name: "selected by labels", traits: []vdTY.Resource{{Name: "power", TraitType: vdTY.DeviceTraitOnOff, ResourceType: "field", Labels: cmap.CustomStringMap{"location": "kitchen", vdTY.LabelTraitParameter: "on"}}}, online: true, lastSeen: now.Add(-time.Minute), errors: []bool{false}},
+		{name: "no resource matches the labels", traits: []vdTY.Resource{{Name: "power", TraitType: vdTY.DeviceTraitOnOff, ResourceType: "field", Labels: cmap.CustomStringMap{"location": "garage"}}}, online: false, errors: []bool{true}},
+		{name: "node not available", traits: []vdTY.Resource{{Name: "power", TraitType: vdTY.DeviceTraitOnOff, ResourceType: "field", QuickID: "gw.removed.source.power"}}, online: false, errors: []bool{true}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			states := d.GetDeviceStates([]vdTY.VirtualDevice{{ID: "device", Name: "Device", Traits: test.traits}})
+			require.Len(t, states, 1)
+			state := states[0]
+			assert.Equal(t, "device", state.ID)
+			assert.Equal(t, test.online, state.Online)
+			assert.True(t, test.lastSeen.Equal(state.LastSeen), "expected: %v, actual: %v", test.lastSeen, state.LastSeen)
+			require.Len(t, state.Traits, len(test.errors))
+			for index, hasError := range test.errors {
+				assert.Equal(t, hasError, state.Traits[index].Error != "", state.Traits[index].Error)
+			}
+		})
+	}
+
+	// trait values and timestamps
+	states := d.GetDeviceStates([]vdTY.VirtualDevice{{ID: "device", Traits: []vdTY.Resource{power, temperature}}})
+	require.Len(t, states, 1)
+	assert.Equal(t, true, states[0].Traits[0].Value)
+	assert.True(t, now.Add(-time.Minute).Equal(states[0].Traits[0].Timestamp))
+	assert.Equal(t, "gw.active.source.power", states[0].Traits[0].QuickID)
+	assert.Equal(t, 21.5, states[0].Traits[1].Value)
+}
+
+// providerMessages collects the messages posted to the gateway
+type providerMessages struct {
+	mutex    sync.Mutex
+	messages []msgTY.Message
+}
+
+func (pm *providerMessages) last() *msgTY.Message {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+	if len(pm.messages) == 0 {
+		return nil
+	}
+	return &pm.messages[len(pm.messages)-1]
+}
+
+func (pm *providerMessages) count() int {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+	return len(pm.messages)
+}
+
+func TestExecuteCommand(t *testing.T) {
+	d, bus := newTestDeviceAPI(t)
+
+	received := &providerMessages{}
+	_, err := bus.Subscribe(topic.TopicPostMessageToProvider+".gw", func(data *busTY.BusData) {
+		msg := msgTY.Message{}
+		if err := data.LoadData(&msg); err == nil {
+			received.mutex.Lock()
+			received.messages = append(received.messages, msg)
+			received.mutex.Unlock()
+		}
+	})
+	require.NoError(t, err)
+
+	saveNode(t, d, "node", time.Now(), nil)
+	fields := []string{"power", "dimmer", "setpoint", "rgb", "fan", "relay"}
+	for _, fieldID := range fields {
+		saveField(t, d, "node", fieldID, nil, time.Now(), cmap.CustomStringMap{"id": fieldID})
+	}
+
+	vDevice := &vdTY.VirtualDevice{
+		ID: "device",
+		Traits: []vdTY.Resource{
+			{Name: "power", TraitType: vdTY.DeviceTraitOnOff, ResourceType: "field", QuickID: "gw.node.source.power"},
+			{Name: "dimmer", TraitType: vdTY.DeviceTraitBrightness, ResourceType: "field", QuickID: "gw.node.source.dimmer",
+				Labels: cmap.CustomStringMap{vdTY.LabelTraitMin: "0", vdTY.LabelTraitMax: "254"}},
+			{Name: "setpoint", TraitType: vdTY.DeviceTraitTemperatureSetting, ResourceType: "field", QuickID: "gw.node.source.setpoint",
+				Labels: cmap.CustomStringMap{vdTY.LabelTraitParameter: vdTY.TraitParameterSetpoint, vdTY.LabelTraitUnit: vdTY.TraitUnitFahrenheit}},
+			{Name: "color", TraitType: vdTY.DeviceTraitColorSetting, ResourceType: "field", QuickID: "gw.node.source.rgb",
+				Labels: cmap.CustomStringMap{vdTY.LabelTraitUnit: vdTY.TraitUnitHex}},
+			{Name: "fan", TraitType: vdTY.DeviceTraitFanSpeed, ResourceType: "field", QuickID: "gw.node.source.fan",
+				Labels: cmap.CustomStringMap{vdTY.LabelTraitValues: "low,medium,high"}},
+			// selected by labels
+			{Name: "relay", TraitType: vdTY.DeviceTraitLockUnlock, ResourceType: "field", Labels: cmap.CustomStringMap{"id": "relay"}},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		command vdTY.DeviceCommand
+		fieldID string
+		value   string
+	}{
+		{name: "on off", command: vdTY.DeviceCommand{Trait: vdTY.DeviceTraitOnOff, Value: "on"}, fieldID: "power", value: "true"},
+		{name: "brightness scaled", command: vdTY.DeviceCommand{Trait: vdTY.DeviceTraitBrightness, Value: 50}, fieldID: "dimmer", value: "127"},
+		{name: "setpoint in fahrenheit", command: vdTY.DeviceCommand{Trait: vdTY.DeviceTraitTemperatureSetting, Value: 20}, fieldID: "setpoint", value: "68"},
+		{name: "rgb in hex", command: vdTY.DeviceCommand{Trait: vdTY.DeviceTraitColorSetting, Value: "#FF8000"}, fieldID: "rgb", value: "ff8000"},
+		{name: "rgb integer in hex", command: vdTY.DeviceCommand{Trait: vdTY.DeviceTraitColorSetting, Value: 255}, fieldID: "rgb", value: "0000ff"},
+		{name: "fan speed percentage", command: vdTY.DeviceCommand{Trait: vdTY.DeviceTraitFanSpeed, Value: 50}, fieldID: "fan", value: "medium"},
+		{name: "fan speed name", command: vdTY.DeviceCommand{Trait: vdTY.DeviceTraitFanSpeed, Value: "high"}, fieldID: "fan", value: "high"},
+		{name: "by name", command: vdTY.DeviceCommand{Trait: vdTY.DeviceTraitOnOff, Name: "power", Value: false}, fieldID: "power", value: "false"},
+		{name: "resource selected by labels", command: vdTY.DeviceCommand{Trait: vdTY.DeviceTraitLockUnlock, Value: 1}, fieldID: "relay", value: "true"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			count := received.count()
+			require.NoError(t, d.ExecuteCommand(vDevice, &test.command))
+			require.Eventually(t, func() bool { return received.count() == count+1 }, 2*time.Second, 10*time.Millisecond)
+			msg := received.last()
+			assert.Equal(t, msgTY.TypeSet, msg.Type)
+			assert.Equal(t, "node", msg.NodeID)
+			assert.Equal(t, "source", msg.SourceID)
+			require.Len(t, msg.Payloads, 1)
+			assert.Equal(t, test.fieldID, msg.Payloads[0].Key)
+			assert.Equal(t, test.value, msg.Payloads[0].Value.String())
+		})
+	}
+
+	errorTests := []struct {
+		name    string
+		command vdTY.DeviceCommand
+		err     string
+	}{
+		{name: "empty trait", command: vdTY.DeviceCommand{Value: true}, err: "trait can not be empty"},
+		{name: "trait not available", command: vdTY.DeviceCommand{Trait: vdTY.DeviceTraitOpenClose, Value: 10}, err: "trait resource not found"},
+		{name: "percentage out of range", command: vdTY.DeviceCommand{Trait: vdTY.DeviceTraitBrightness, Value: 150}, err: "percentage out of range"},
+		{name: "invalid rgb", command: vdTY.DeviceCommand{Trait: vdTY.DeviceTraitColorSetting, Value: "#XYZ"}, err: "invalid rgb value"},
+	}
+	for _, test := range errorTests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.ErrorContains(t, d.ExecuteCommand(vDevice, &test.command), test.err)
+		})
+	}
+}
@@ -0,0 +1,279 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	vdTY "github.com/mycontroller-org/server/v2/pkg/types/virtual_device"
+	convertorUtil "github.com/mycontroller-org/server/v2/pkg/utils/convertor"
+)
+
+// DefaultTraitParameter of a trait, used when the resource has no parameter label
+var DefaultTraitParameter = map[string]string{
+	vdTY.DeviceTraitTemperatureSetting: vdTY.TraitParameterSetpoint,
+	vdTY.DeviceTraitColorSetting:       vdTY.TraitParameterRGB,
+	vdTY.DeviceTraitArmDisarm:          vdTY.TraitParameterArm,
+	vdTY.DeviceTraitStartStop:          vdTY.TraitParameterStart,
+}
+
+// GetTraitParameter returns the parameter handled by the resource
+func GetTraitParameter(resource *vdTY.Resource) string {
+	parameter := resource.Labels.Get(vdTY.LabelTraitParameter)
+	if parameter == "" {
+		return DefaultTraitParameter[resource.TraitType]
+	}
+	return strings.ToLower(parameter)
+}
+
+// GetTraitResource returns the resource for the trait and parameter
+func GetTraitResource(vDevice *vdTY.VirtualDevice, trait, parameter string) *vdTY.Resource {
+	for index := range vDevice.Traits {
+		resource := &vDevice.Traits[index]
+		if resource.TraitType == trait && GetTraitParameter(resource) == parameter {
+			return resource
+		}
+	}
+	return nil
+}
+
+// GetTraitResourceByName returns the resource for the trait and name, used on modes and sensor state
+func GetTraitResourceByName(vDevice *vdTY.VirtualDevice, trait, name string) *vdTY.Resource {
+	for index := range vDevice.Traits {
+		resource := &vDevice.Traits[index]
+		if resource.TraitType == trait && GetTraitName(resource) == name {
+			return resource
+		}
+	}
+	return nil
+}
+
+// GetTraitName returns name of the mode or sensor, falls back to resource name
+func GetTraitName(resource *vdTY.Resource) string {
+	name := resource.Labels.Get(vdTY.LabelTraitName)
+	if name == "" {
+		return resource.Name
+	}
+	return name
+}
+
+// GetTraitValues returns supported values of the resource
+func GetTraitValues(resource *vdTY.Resource) []string {
+	values := make([]string, 0)
+	for _, value := range strings.Split(resource.Labels.Get(vdTY.LabelTraitValues), ",") {
+		value = strings.TrimSpace(value)
+		if value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// GetTraitRange returns min and max of the resource value, if defined
+func GetTraitRange(resource *vdTY.Resource) (float64, float64, bool) {
+	if !resource.Labels.IsExists(vdTY.LabelTraitMin) || !resource.Labels.IsExists(vdTY.LabelTraitMax) {
+		return 0, 0, false
+	}
+	min := resource.Labels.GetFloat(vdTY.LabelTraitMin)
+	max := resource.Labels.GetFloat(vdTY.LabelTraitMax)
+	if min == max {
+		return 0, 0, false
+	}
+	return min, max, true
+}
+
+// PercentToValue converts percentage into resource value
+func PercentToValue(resource *vdTY.Resource, percent interface{}) (interface{}, error) {
+	percentValue := convertorUtil.ToFloat(percent)
+	if percentValue < 0 || percentValue > 100 {
+		return nil, fmt.Errorf("percentage out of range: %v", percent)
+	}
+	min, max, found := GetTraitRange(resource)
+	if !found {
+		return int64(math.Round(percentValue)), nil
+	}
+	return int64(math.Round(min + (percentValue * (max - min) / 100))), nil
+}
+
+// ValueToPercent converts resource value into percentage
+func ValueToPercent(resource *vdTY.Resource, value interface{}) int64 {
+	floatValue := convertorUtil.ToFloat(value)
+	min, max, found := GetTraitRange(resource)
+	if found {
+		floatValue = (floatValue - min) * 100 / (max - min)
+	}
+	return int64(math.Round(math.Max(0, math.Min(100, floatValue))))
+}
+
+// CelsiusToValue converts celsius into resource value
+func CelsiusToValue(resource *vdTY.Resource, celsius interface{}) float64 {
+	value := convertorUtil.ToFloat(celsius)
+	if strings.EqualFold(resource.Labels.Get(vdTY.LabelTraitUnit), vdTY.TraitUnitFahrenheit) {
+		value = value*9/5 + 32
+	}
+	return math.Round(value*10) / 10
+}
+
+// ValueToCelsius converts resource value into celsius
+func ValueToCelsius(resource *vdTY.Resource, value interface{}) float64 {
+	celsius := convertorUtil.ToFloat(value)
+	if strings.EqualFold(resource.Labels.Get(vdTY.LabelTraitUnit), vdTY.TraitUnitFahrenheit) {
+		celsius = (celsius - 32) * 5 / 9
+	}
+	return math.Round(celsius*10) / 10
+}
+
+// RGBToValue converts rgb integer into resource value
+func RGBToValue(resource *vdTY.Resource, rgb interface{}) interface{} {
+	value := convertorUtil.ToInteger(rgb)
+	if strings.EqualFold(resource.Labels.Get(vdTY.LabelTraitUnit), vdTY.TraitUnitHex) {
+		return fmt.Sprintf("%06x", value)
+	}
+	return value
+}
+
+// ValueToRGB converts resource value into rgb integer
+func ValueToRGB(resource *vdTY.Resource, value interface{}) int64 {
+	if strings.EqualFold(resource.Labels.Get(vdTY.LabelTraitUnit), vdTY.TraitUnitHex) {
+		hexValue := strings.TrimPrefix(strings.TrimPrefix(convertorUtil.ToString(value), "#"), "0x")
+		rgb, err := strconv.ParseInt(hexValue, 16, 64)
+		if err != nil {
+			return 0
+		}
+		return rgb
+	}
+	return convertorUtil.ToInteger(value)
+}
+
+// RGBToHueSaturation converts rgb integer into hue (0 to 360) and saturation (0 to 1), brightness is ignored
+func RGBToHueSaturation(rgb int64) (float64, float64) {
+	red := float64((rgb>>16)&0xFF) / 255
+	green := float64((rgb>>8)&0xFF) / 255
+	blue := float64(rgb&0xFF) / 255
+
+	max := math.Max(red, math.Max(green, blue))
+	min := math.Min(red, math.Min(green, blue))
+	delta := max - min
+
+	hue := 0.0
+	switch {
+	case delta == 0:
+		hue = 0
+	case max == red:
+		hue = math.Mod((green-blue)/delta, 6)
+	case max == green:
+		hue = (blue-red)/delta + 2
+	default:
+		hue = (red-green)/delta + 4
+	}
+	hue *= 60
+	if hue < 0 {
+		hue += 360
+	}
+
+	saturation := 0.0
+	if max > 0 {
+		saturation = delta / max
+	}
+	return hue, saturation
+}
+
+// HueSaturationToRGB converts hue (0 to 360) and saturation (0 to 1) into rgb integer, with full brightness
+func HueSaturationToRGB(hue, saturation float64) int64 {
+	hue = math.Mod(math.Max(0, hue), 360)
+	saturation = math.Max(0, math.Min(1, saturation))
+
+	x := saturation * (1 - math.Abs(math.Mod(hue/60, 2)-1))
+	m := 1 - saturation
+
+	var red, green, blue float64
+	switch {
+	case hue < 60:
+		red, green, blue = saturation, x, 0
+	case hue < 120:
+		red, green, blue = x, saturation, 0
+	case hue < 180:
+		red, green, blue = 0, saturation, x
+	case hue < 240:
+		red, green, blue = 0, x, saturation
+	case hue < 300:
+		red, green, blue = x, 0, saturation
+	default:
+		red, green, blue = saturation, 0, x
+	}
+
+	toByte := func(value float64) int64 { return int64(math.Round((value + m) * 255)) }
+	return toByte(red)<<16 | toByte(green)<<8 | toByte(blue)
+}
+
+// ValueToSpeedPercent returns the fan speed in percentage, speed names are converted based on their position
+func ValueToSpeedPercent(resource *vdTY.Resource, value interface{}) int64 {
+	values := GetTraitValues(resource)
+	if len(values) == 0 {
+		return ValueToPercent(resource, value)
+	}
+	currentValue := convertorUtil.ToString(value)
+	if strings.EqualFold(currentValue, "off") {
+		return 0
+	}
+	for index, speed := range values {
+		if strings.EqualFold(speed, currentValue) {
+			return int64(math.Round(float64(index+1) * 100 / float64(len(values))))
+		}
+	}
+	return 0
+}
+
+// SpeedPercentToValue returns fan speed value for the percentage, speed names are selected based on their position
+func SpeedPercentToValue(resource *vdTY.Resource, percent int64) (interface{}, error) {
+	values := GetTraitValues(resource)
+	if len(values) == 0 {
+		return PercentToValue(resource, percent)
+	}
+	if percent < 0 || percent > 100 {
+		return nil, fmt.Errorf("percentage out of range: %v", percent)
+	}
+	index := int(math.Ceil(float64(percent)*float64(len(values))/100)) - 1
+	if index < 0 {
+		index = 0
+	}
+	return values[index], nil
+}
+
+// CommandToValue converts the trait level command value into resource value
+func CommandToValue(resource *vdTY.Resource, value interface{}) (interface{}, error) {
+	switch resource.TraitType {
+	case vdTY.DeviceTraitOnOff, vdTY.DeviceTraitLockUnlock:
+		return convertorUtil.ToBool(value), nil
+
+	case vdTY.DeviceTraitBrightness, vdTY.DeviceTraitOpenClose:
+		return PercentToValue(resource, value)
+
+	case vdTY.DeviceTraitFanSpeed:
+		// speed names are sent as is
+		if _, err := strconv.ParseFloat(convertorUtil.ToString(value), 64); err != nil {
+			return value, nil
+		}
+		return SpeedPercentToValue(resource, convertorUtil.ToInteger(value))
+
+	case vdTY.DeviceTraitColorSetting:
+		if GetTraitParameter(resource) == vdTY.TraitParameterRGB {
+			stringValue := convertorUtil.ToString(value)
+			if strings.HasPrefix(stringValue, "#") {
+				rgb, err := strconv.ParseInt(stringValue[1:], 16, 64)
+				if err != nil {
+					return nil, fmt.Errorf("invalid rgb value: %v", value)
+				}
+				return RGBToValue(resource, rgb), nil
+			}
+			return RGBToValue(resource, value), nil
+		}
+
+	case vdTY.DeviceTraitTemperatureSetting:
+		if GetTraitParameter(resource) == vdTY.TraitParameterSetpoint {
+			return CelsiusToValue(resource, value), nil
+		}
+	}
+	return value, nil
+}
@@ -0,0 +1,344 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/mycontroller-org/server/v2/pkg/types/cmap"
+	vdTY "github.com/mycontroller-org/server/v2/pkg/types/virtual_device"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newResource(trait string, labels cmap.CustomStringMap) *vdTY.Resource {
+	return &vdTY.Resource{Name: trait, TraitType: trait, Labels: labels}
+}
+
+func TestGetTraitParameter(t *testing.T) {
+	tests := []struct {
+		name      string
+		resource  *vdTY.Resource
+		parameter string
+	}{
+		{name: "default of temperature setting", resource: newResource(vdTY.DeviceTraitTemperatureSetting, nil), parameter: vdTY.TraitParameterSetpoint},
+		{name: "default of color setting", resource: newResource(vdTY.DeviceTraitColorSetting, nil), parameter: vdTY.TraitParameterRGB},
+		{name: "default of arm disarm", resource: newResource(vdTY.DeviceTraitArmDisarm, nil), parameter: vdTY.TraitParameterArm},
+		{name: "default of start stop", resource: newResource(vdTY.DeviceTraitStartStop, nil), parameter: vdTY.TraitParameterStart},
+		{name: "without default", resource: newResource(vdTY.DeviceTraitOnOff, nil), parameter: ""},
+		{
+			name:      "from label",
+			resource:  newResource(vdTY.DeviceTraitTemperatureSetting, cmap.CustomStringMap{vdTY.LabelTraitParameter: "Mode"}),
+			parameter: vdTY.TraitParameterMode,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.parameter, GetTraitParameter(test.resource))
+		})
+	}
+}
+
+func TestGetTraitResource(t *testing.T) {
+	vDevice := &vdTY.VirtualDevice{Traits: []vdTY.Resource{
+		{Name: "setpoint", TraitType: vdTY.DeviceTraitTemperatureSetting},
+		{Name: "mode", TraitType: vdTY.DeviceTraitTemperatureSetting, Labels: cmap.CustomStringMap{vdTY.LabelTraitParameter: vdTY.TraitParameterMode}},
+		{Name: "wash_mode", TraitType: vdTY.DeviceTraitModes, Labels: cmap.CustomStringMap{vdTY.LabelTraitName: "wash"}},
+		{Name: "dry", TraitType: vdTY.DeviceTraitModes},
+	}}
+
+	tests := []struct {
+		name     string
+		resource *vdTY.Resource
+		expected string
+	}{
+		{name: "default parameter", resource: GetTraitResource(vDevice, vdTY.DeviceTraitTemperatureSetting, vdTY.TraitParameterSetpoint), expected: "setpoint"},
+		{name: "labeled parameter", resource: GetTraitResource(vDevice, vdTY.DeviceTraitTemperatureSetting, vdTY.TraitParameterMode), expected: "mode"},
+		{name: "parameter not available", resource: GetTraitResource(vDevice, vdTY.DeviceTraitTemperatureSetting, vdTY.TraitParameterAmbient)},
+		{name: "trait not available", resource: GetTraitResource(vDevice, vdTY.DeviceTraitOnOff, "")},
+		{name: "labeled name", resource: GetTraitResourceByName(vDevice, vdTY.DeviceTraitModes, "wash"), expected: "wash_mode"},
+		{name: "resource name", resource: GetTraitResourceByName(vDevice, vdTY.DeviceTraitModes, "dry"), expected: "dry"},
+		{name: "labeled name hides resource name", resource: GetTraitResourceByName(vDevice, vdTY.DeviceTraitModes, "wash_mode")},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.expected == "" {
+				assert.Nil(t, test.resource)
+				return
+			}
+			require.NotNil(t, test.resource)
+			assert.Equal(t, test.expected, test.resource.Name)
+		})
+	}
+}
+
+func TestGetTraitValues(t *testing.T) {
+	assert.Equal(t, []string{"off", "heat", "cool"}, GetTraitValues(newResource(vdTY.DeviceTraitModes, cmap.CustomStringMap{vdTY.LabelTraitValues: " off, heat,,cool "})))
+	assert.Equal(t, []string{}, GetTraitValues(newResource(vdTY.DeviceTraitModes, nil)))
+}
+
+func TestPercentToValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		labels   cmap.CustomStringMap
+		percent  interface{}
+		expected interface{}
+		err      string
+	}{
+		{name: "without range", percent: 40, expected: int64(40)},
+		{name: "string percent", percent: "40", expected: int64(40)},
+		{name: "rounded", percent: 33.4, expected: int64(33)},
+		{name: "range", labels: cmap.CustomStringMap{vdTY.LabelTraitMin: "0", vdTY.LabelTraitMax: "255"}, percent: 50, expected: int64(128)},
+		{name: "range with offset", labels: cmap.CustomStringMap{vdTY.LabelTraitMin: "10", vdTY.LabelTraitMax: "20"}, percent: 50, expected: int64(15)},
+		{name: "range minimum", labels: cmap.CustomStringMap{vdTY.LabelTraitMin: "10", vdTY.LabelTraitMax: "20"}, percent: 0, expected: int64(10)},
+		{name: "range maximum", labels: cmap.CustomStringMap{vdTY.LabelTraitMin: "10", vdTY.LabelTraitMax: "20"}, percent: 100, expected: int64(20)},
+		{name: "min only", labels: cmap.CustomStringMap{vdTY.LabelTraitMin: "10"}, percent: 50, expected: int64(50)},
+		{name: "min equals max", labels: cmap.CustomStringMap{vdTY.LabelTraitMin: "10", vdTY.LabelTraitMax: "10"}, percent: 50, expected: int64(50)},
+		{name: "below zero", percent: -1, err: "percentage out of range: -1"},
+		{name: "above hundred", percent: 101, err: "percentage out of range: 101"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			value, err := PercentToValue(newResource(vdTY.DeviceTraitBrightness, test.labels), test.percent)
+			if test.err != "" {
+				assert.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, value)
+		})
+	}
+}
+
+func TestValueToPercent(t *testing.T) {
+	tests := []struct {
+		name     string
+		labels   cmap.CustomStringMap
+		value    interface{}
+		expected int64
+	}{
+		{name: "without range", value: 42, expected: 42},
+		{name: "string value", value: "42.6", expected: 43},
+		{name: "without range clamped", value: 150, expected: 100},
+		{name: "range", labels: cmap.CustomStringMap{vdTY.LabelTraitMin: "0", vdTY.LabelTraitMax: "255"}, value: 128, expected: 50},
+		{name: "range with offset", labels: cmap.CustomStringMap{vdTY.LabelTraitMin: "10", vdTY.LabelTraitMax: "20"}, value: 15, expected: 50},
+		{name: "above max", labels: cmap.CustomStringMap{vdTY.LabelTraitMin: "0", vdTY.LabelTraitMax: "255"}, value: 300, expected: 100},
+		{name: "below min", labels: cmap.CustomStringMap{vdTY.LabelTraitMin: "10", vdTY.LabelTraitMax: "20"}, value: 5, expected: 0},
+		{name: "min equals max", labels: cmap.CustomStringMap{vdTY.LabelTraitMin: "10", vdTY.LabelTraitMax: "10"}, value: 42, expected: 42},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, ValueToPercent(newResource(vdTY.DeviceTraitBrightness, test.labels), test.value))
+		})
+	}
+}
+
+func TestTemperatureConversion(t *testing.T) {
+	tests := []struct {
+		name    string
+		unit    string
+		celsius float64
+		value   float64
+	}{
+		{name: "without unit", celsius: 21.5, value: 21.5},
+		{name: "celsius", unit: vdTY.TraitUnitCelsius, celsius: 21.5, value: 21.5},
+		{name: "fahrenheit", unit: vdTY.TraitUnitFahrenheit, celsius: 20, value: 68},
+		{name: "fahrenheit below zero", unit: vdTY.TraitUnitFahrenheit, celsius: -40, value: -40},
+		{name: "fahrenheit rounded", unit: vdTY.TraitUnitFahrenheit, celsius: 21.5, value: 70.7},
+		{name: "unit case ignored", unit: "Fahrenheit", celsius: 100, value: 212},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resource := newResource(vdTY.DeviceTraitTemperatureSetting, cmap.CustomStringMap{vdTY.LabelTraitUnit: test.unit})
+			assert.Equal(t, test.value, CelsiusToValue(resource, test.celsius))
+			assert.Equal(t, test.celsius, ValueToCelsius(resource, test.value))
+		})
+	}
+
+	// reported value rounded to one decimal
+	resource := newResource(vdTY.DeviceTraitTemperatureSetting, cmap.CustomStringMap{vdTY.LabelTraitUnit: vdTY.TraitUnitFahrenheit})
+	assert.Equal(t, 21.1, ValueToCelsius(resource, "70"))
+}
+
+func TestRGBConversion(t *testing.T) {
+	tests := []struct {
+		name  string
+		unit  string
+		rgb   int64
+		value interface{}
+	}{
+		{name: "integer", rgb: 16711680, value: int64(16711680)},
+		{name: "hex red", unit: vdTY.TraitUnitHex, rgb: 0xff0000, value: "ff0000"},
+		{name: "hex blue padded", unit: vdTY.TraitUnitHex, rgb: 0x0000ff, value: "0000ff"},
+		{name: "hex black", unit: vdTY.TraitUnitHex, rgb: 0, value: "000000"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resource := newResource(vdTY.DeviceTraitColorSetting, cmap.CustomStringMap{vdTY.LabelTraitUnit: test.unit})
+			assert.Equal(t, test.value, RGBToValue(resource, test.rgb))
+			assert.Equal(t, test.rgb, ValueToRGB(resource, test.value))
+		})
+	}
+
+	valueTests := []struct {
+		name  string
+		unit  string
+		value interface{}
+		rgb   int64
+	}{
+		{name: "hex with hash", unit: vdTY.TraitUnitHex, value: "#00ff00", rgb: 0x00ff00},
+		{name: "hex with 0x", unit: vdTY.TraitUnitHex, value: "0x00ff00", rgb: 0x00ff00},
+		{name: "hex upper case", unit: vdTY.TraitUnitHex, value: "FF8000", rgb: 0xff8000},
+		{name: "invalid hex", unit: vdTY.TraitUnitHex, value: "zz", rgb: 0},
+		{name: "string integer", value: "255", rgb: 255},
+	}
+	for _, test := range valueTests {
+		t.Run(test.name, func(t *testing.T) {
+			resource := newResource(vdTY.DeviceTraitColorSetting, cmap.CustomStringMap{vdTY.LabelTraitUnit: test.unit})
+			assert.Equal(t, test.rgb, ValueToRGB(resource, test.value))
+		})
+	}
+}
+
+func TestHueSaturationConversion(t *testing.T) {
+	tests := []struct {
+		name       string
+		rgb        int64
+		hue        float64
+		saturation float64
+	}{
+		{name: "red", rgb: 0xff0000, hue: 0, saturation: 1},
+		{name: "green", rgb: 0x00ff00, hue: 120, saturation: 1},
+		{name: "blue", rgb: 0x0000ff, hue: 240, saturation: 1},
+		{name: "magenta", rgb: 0xff00ff, hue: 300, saturation: 1},
+		{name: "white", rgb: 0xffffff, hue: 0, saturation: 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hue, saturation := RGBToHueSaturation(test.rgb)
+			assert.Equal(t, test.hue, hue)
+			assert.Equal(t, test.saturation, saturation)
+			assert.Equal(t, test.rgb, HueSaturationToRGB(test.hue, test.saturation))
+		})
+	}
+
+	// brightness is ignored
+	hue, saturation := RGBToHueSaturation(0x800000)
+	assert.Equal(t, 0.0, hue)
+	assert.Equal(t, 1.0, saturation)
+
+	assert.Equal(t, int64(0xffff80), HueSaturationToRGB(60, 0.5))
+	assert.Equal(t, int64(0xff0000), HueSaturationToRGB(360, 1), "hue wrapped")
+	assert.Equal(t, int64(0xff0000), HueSaturationToRGB(-10, 2), "hue and saturation clamped")
+}
+
+func TestFanSpeedConversion(t *testing.T) {
+	speeds := cmap.CustomStringMap{vdTY.LabelTraitValues: "low, medium, high"}
+	speedRange := cmap.CustomStringMap{vdTY.LabelTraitMin: "0", vdTY.LabelTraitMax: "3"}
+
+	percentTests := []struct {
+		name    string
+		labels  cmap.CustomStringMap
+		value   interface{}
+		percent int64
+	}{
+		{name: "first speed", labels: speeds, value: "low", percent: 33},
+		{name: "speed case ignored", labels: speeds, value: "Medium", percent: 67},
+		{name: "last speed", labels: speeds, value: "high", percent: 100},
+		{name: "off", labels: speeds, value: "off", percent: 0},
+		{name: "unknown speed", labels: speeds, value: "turbo", percent: 0},
+		{name: "range", labels: speedRange, value: 2, percent: 67},
+		{name: "without values", value: 40, percent: 40},
+	}
+	for _, test := range percentTests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.percent, ValueToSpeedPercent(newResource(vdTY.DeviceTraitFanSpeed, test.labels), test.value))
+		})
+	}
+
+	valueTests := []struct {
+		name    string
+		labels  cmap.CustomStringMap
+		percent int64
+		value   interface{}
+		err     string
+	}{
+		{name: "zero", labels: speeds, percent: 0, value: "low"},
+		{name: "first step", labels: speeds, percent: 33, value: "low"},
+		{name: "second step", labels: speeds, percent: 34, value: "medium"},
+		{name: "second step end", labels: speeds, percent: 66, value: "medium"},
+		{name: "third step", labels: speeds, percent: 67, value: "high"},
+		{name: "hundred", labels: speeds, percent: 100, value: "high"},
+		{name: "above hundred", labels: speeds, percent: 101, err: "percentage out of range: 101"},
+		{name: "below zero", labels: speeds, percent: -1, err: "percentage out of range: -1"},
+		{name: "range", labels: speedRange, percent: 50, value: int64(2)},
+		{name: "without values", percent: 40, value: int64(40)},
+		{name: "without values above hundred", percent: 101, err: "percentage out of range: 101"},
+	}
+	for _, test := range valueTests {
+		t.Run(test.name, func(t *testing.T) {
+			value, err := SpeedPercentToValue(newResource(vdTY.DeviceTraitFanSpeed, test.labels), test.percent)
+			if test.err != "" {
+				assert.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.value, value)
+		})
+	}
+}
+
+func TestCommandToValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		resource *vdTY.Resource
+		value    interface{}
+		expected interface{}
+		err      string
+	}{
+		{name: "on off", resource: newResource(vdTY.DeviceTraitOnOff, nil), value: "true", expected: true},
+		{name: "lock unlock", resource: newResource(vdTY.DeviceTraitLockUnlock, nil), value: 0, expected: false},
+		{
+			name:     "brightness",
+			resource: newResource(vdTY.DeviceTraitBrightness, cmap.CustomStringMap{vdTY.LabelTraitMin: "0", vdTY.LabelTraitMax: "255"}),
+			value:    "100",
+			expected: int64(255),
+		},
+		{name: "open close", resource: newResource(vdTY.DeviceTraitOpenClose, nil), value: 30, expected: int64(30)},
+		{name: "open close out of range", resource: newResource(vdTY.DeviceTraitOpenClose, nil), value: 130, err: "percentage out of range: 130"},
+		{name: "fan speed name", resource: newResource(vdTY.DeviceTraitFanSpeed, cmap.CustomStringMap{vdTY.LabelTraitValues: "low,high"}), value: "high", expected: "high"},
+		{name: "fan speed percent", resource: newResource(vdTY.DeviceTraitFanSpeed, cmap.CustomStringMap{vdTY.LabelTraitValues: "low,high"}), value: "20", expected: "low"},
+		{name: "rgb hash", resource: newResource(vdTY.DeviceTraitColorSetting, cmap.CustomStringMap{vdTY.LabelTraitUnit: vdTY.TraitUnitHex}), value: "#FF0000", expected: "ff0000"},
+		{name: "rgb hash to integer", resource: newResource(vdTY.DeviceTraitColorSetting, nil), value: "#0000ff", expected: int64(255)},
+		{name: "rgb integer", resource: newResource(vdTY.DeviceTraitColorSetting, cmap.CustomStringMap{vdTY.LabelTraitUnit: vdTY.TraitUnitHex}), value: 255, expected: "0000ff"},
+		{name: "invalid rgb", resource: newResource(vdTY.DeviceTraitColorSetting, nil), value: "#zz", err: "invalid rgb value: #zz"},
+		{
+			name:     "color temperature",
+			resource: newResource(vdTY.DeviceTraitColorSetting, cmap.CustomStringMap{vdTY.LabelTraitParameter: vdTY.TraitParameterColorTemperature}),
+			value:    "3000",
+			expected: "3000",
+		},
+		{
+			name:     "setpoint",
+			resource: newResource(vdTY.DeviceTraitTemperatureSetting, cmap.CustomStringMap{vdTY.LabelTraitUnit: vdTY.TraitUnitFahrenheit}),
+			value:    "20",
+			expected: float64(68),
+		},
+		{
+			name:     "thermostat mode",
+			resource: newResource(vdTY.DeviceTraitTemperatureSetting, cmap.CustomStringMap{vdTY.LabelTraitParameter: vdTY.TraitParameterMode}),
+			value:    "cool",
+			expected: "cool",
+		},
+		{name: "other trait", resource: newResource(vdTY.DeviceTraitModes, nil), value: "quick", expected: "quick"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			value, err := CommandToValue(test.resource, test.value)
+			if test.err != "" {
+				assert.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, value)
+		})
+	}
+}
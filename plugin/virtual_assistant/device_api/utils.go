@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/mycontroller-org/server/v2/pkg/types/cmap"
+	vdTY "github.com/mycontroller-org/server/v2/pkg/types/virtual_device"
 	storageTY "github.com/mycontroller-org/server/v2/plugin/database/storage/types"
 )
 
@@ -24,3 +25,14 @@ func ApplyDeviceFilter(labelFilters cmap.CustomStringMap, filters []storageTY.Fi
 
 	return append(filters, labelsFilter...)
 }
+
+// IsDeviceFilterMatching returns true, if the device labels matches with the label filters
+func IsDeviceFilterMatching(labelFilters cmap.CustomStringMap, vDevice *vdTY.VirtualDevice) bool {
+	labels := vDevice.Labels.Init()
+	for key, value := range labelFilters {
+		if labels.Get(key) != value {
+			return false
+		}
+	}
+	return true
+}
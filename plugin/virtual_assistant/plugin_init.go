@@ -3,10 +3,12 @@ package virtual_assistant
 import (
 	vaAlexa "github.com/mycontroller-org/server/v2/plugin/virtual_assistant/assistant/alexa"
 	vaGoogle "github.com/mycontroller-org/server/v2/plugin/virtual_assistant/assistant/google"
+	vaHomeKit "github.com/mycontroller-org/server/v2/plugin/virtual_assistant/assistant/homekit"
 )
 
 // init plugins
 func init() {
 	Register(vaGoogle.PluginGoogleAssistant, vaGoogle.New)
 	Register(vaAlexa.PluginAlexaAssistant, vaAlexa.New)
+	Register(vaHomeKit.PluginHomeKitBridge, vaHomeKit.New)
 }
@@ -6,6 +6,7 @@ import (
 
 	"github.com/mycontroller-org/server/v2/pkg/types"
 	"github.com/mycontroller-org/server/v2/pkg/types/cmap"
+	vdTY "github.com/mycontroller-org/server/v2/pkg/types/virtual_device"
 )
 
 // Config of virtual assistant
@@ -28,4 +29,6 @@ type Plugin interface {
 	Config() *Config
 	ServeHTTP(http.ResponseWriter, *http.Request)
 	Name() string
+	ReportState(deviceIDs []string) error                            // reports the current state of the devices proactively
+	RequestSync(eventType string, vDevice *vdTY.VirtualDevice) error // notifies a device created, updated or deleted
 }